@@ -0,0 +1,88 @@
+/*
+Package age encrypts shards in the age file format (https://age-encryption.org/v1) with filippo.io/age, so that they can be
+stored with the same tooling as other secrets. Any age recipient or identity can be used, such as an age1... public key
+from age.ParseX25519Recipient, an AGE-SECRET-KEY-1... identity from age.ParseX25519Identity, or a passphrase from
+age.NewScryptRecipient and age.NewScryptIdentity. A shard encrypted by [EncryptShard] decrypts with the age command-line
+tool to the same PEM file produced by keysplitting.PrivateKeyShard.EncodePEM, and vice versa:
+
+	encrypted, err := age.EncryptShard(shard, recipient)
+	...
+	shard, err := age.DecryptShard(encrypted, identity)
+*/
+package age
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	fage "filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/bastionzero/keysplitting"
+)
+
+// EncryptShard encrypts the shard to one or more age recipients, for storage at rest or offline backup. The plaintext is
+// the shard's PEM encoding (see [keysplitting.PrivateKeyShard.EncodePEM]). The result is a binary age file, which can be
+// armored with filippo.io/age/armor if a text file is needed
+func EncryptShard(shard *keysplitting.PrivateKeyShard, recipients ...fage.Recipient) ([]byte, error) {
+	if len(recipients) == 0 {
+		return nil, fmt.Errorf("no age recipients specified")
+	}
+
+	plaintext, err := shard.EncodePEM()
+	if err != nil {
+		return nil, err
+	}
+
+	encrypted := new(bytes.Buffer)
+	w, err := fage.Encrypt(encrypted, recipients...)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.WriteString(w, plaintext); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return encrypted.Bytes(), nil
+}
+
+// DecryptShard decrypts a shard encrypted with [EncryptShard] or the age command-line tool, which may be armored. It fails
+// with [keysplitting.ErrKeyMismatch] if none of the identities can decrypt it, [keysplitting.ErrIncorrectPassphrase] if
+// none can and one of them is a passphrase, and [keysplitting.ErrInvalidShard] if the file is malformed or has been
+// tampered with
+func DecryptShard(ciphertext []byte, identities ...fage.Identity) (*keysplitting.PrivateKeyShard, error) {
+	var src io.Reader = bytes.NewReader(ciphertext)
+	if bytes.HasPrefix(bytes.TrimSpace(ciphertext), []byte(armor.Header)) {
+		src = armor.NewReader(bytes.NewReader(bytes.TrimSpace(ciphertext)))
+	}
+
+	r, err := fage.Decrypt(src, identities...)
+	var noMatch *fage.NoIdentityMatchError
+	switch {
+	case errors.As(err, &noMatch):
+		for _, identity := range identities {
+			if _, ok := identity.(*fage.ScryptIdentity); ok {
+				return nil, fmt.Errorf("%w: failed to decrypt age file key", keysplitting.ErrIncorrectPassphrase)
+			}
+		}
+		return nil, fmt.Errorf("%w: no age identity matched any of the recipients", keysplitting.ErrKeyMismatch)
+	case err != nil:
+		return nil, fmt.Errorf("%w: failed to decrypt age file: %s", keysplitting.ErrInvalidShard, err)
+	}
+
+	plaintext, err := io.ReadAll(r)
+	defer zeroize(plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to decrypt age payload: %s", keysplitting.ErrInvalidShard, err)
+	}
+	return keysplitting.DecodePEM(string(plaintext))
+}
+
+func zeroize(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
@@ -0,0 +1,136 @@
+package age
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	fage "filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/bastionzero/keysplitting"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestAge(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Age Suite")
+}
+
+var _ = Describe("Age encryption", func() {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	shards, _ := keysplitting.SplitD(key, 2, keysplitting.Addition)
+	alice, _ := fage.GenerateX25519Identity()
+	bob, _ := fage.GenerateX25519Identity()
+
+	It("Round-trips to each of several recipients", func() {
+		encrypted, err := EncryptShard(shards[0], alice.Recipient(), bob.Recipient())
+		Expect(err).To(BeNil())
+		Expect(string(encrypted)).To(HavePrefix("age-encryption.org/v1\n-> X25519 "))
+
+		for _, identity := range []*fage.X25519Identity{alice, bob} {
+			decrypted, err := DecryptShard(encrypted, identity)
+			Expect(err).To(BeNil())
+			Expect(decrypted.Equal(shards[0])).To(BeTrue())
+		}
+	})
+
+	It("Round-trips to a passphrase", func() {
+		recipient, err := fage.NewScryptRecipient("correct horse battery staple")
+		Expect(err).To(BeNil())
+		recipient.SetWorkFactor(10)
+		encrypted, err := EncryptShard(shards[1], recipient)
+		Expect(err).To(BeNil())
+
+		identity, err := fage.NewScryptIdentity("correct horse battery staple")
+		Expect(err).To(BeNil())
+		decrypted, err := DecryptShard(encrypted, identity)
+		Expect(err).To(BeNil())
+		Expect(decrypted.Equal(shards[1])).To(BeTrue())
+
+		wrong, err := fage.NewScryptIdentity("wrong")
+		Expect(err).To(BeNil())
+		_, err = DecryptShard(encrypted, wrong)
+		Expect(errors.Is(err, keysplitting.ErrIncorrectPassphrase)).To(BeTrue())
+	})
+
+	It("Doesn't mix passphrases with other recipients", func() {
+		recipient, err := fage.NewScryptRecipient("passphrase")
+		Expect(err).To(BeNil())
+		_, err = EncryptShard(shards[0], recipient, alice.Recipient())
+		Expect(err).NotTo(BeNil())
+	})
+
+	It("Rejects other identities", func() {
+		encrypted, err := EncryptShard(shards[0], alice.Recipient())
+		Expect(err).To(BeNil())
+
+		_, err = DecryptShard(encrypted, bob)
+		Expect(errors.Is(err, keysplitting.ErrKeyMismatch)).To(BeTrue())
+	})
+
+	It("Detects tampering with the header or payload", func() {
+		encrypted, err := EncryptShard(shards[0], alice.Recipient(), bob.Recipient())
+		Expect(err).To(BeNil())
+
+		// swapping the stanzas doesn't prevent either recipient from decrypting, but it does change the MAC
+		lines := strings.SplitN(string(encrypted), "\n", 6)
+		swapped := strings.Join([]string{lines[0], lines[3], lines[4], lines[1], lines[2], lines[5]}, "\n")
+		_, err = DecryptShard([]byte(swapped), alice)
+		Expect(errors.Is(err, keysplitting.ErrInvalidShard)).To(BeTrue())
+
+		tampered := append([]byte{}, encrypted...)
+		tampered[len(tampered)-1] ^= 1
+		_, err = DecryptShard(tampered, alice)
+		Expect(errors.Is(err, keysplitting.ErrInvalidShard)).To(BeTrue())
+
+		_, err = DecryptShard(encrypted[:len(encrypted)-20], alice)
+		Expect(errors.Is(err, keysplitting.ErrInvalidShard)).To(BeTrue())
+	})
+
+	It("Accepts armored files", func() {
+		encrypted, err := EncryptShard(shards[0], alice.Recipient())
+		Expect(err).To(BeNil())
+
+		armored := new(bytes.Buffer)
+		w := armor.NewWriter(armored)
+		_, err = w.Write(encrypted)
+		Expect(err).To(BeNil())
+		Expect(w.Close()).To(Succeed())
+
+		decrypted, err := DecryptShard(armored.Bytes(), alice)
+		Expect(err).To(BeNil())
+		Expect(decrypted.Equal(shards[0])).To(BeTrue())
+	})
+
+	It("Decrypts to the shard's PEM encoding", func() {
+		encrypted, err := EncryptShard(shards[0], alice.Recipient())
+		Expect(err).To(BeNil())
+
+		r, err := fage.Decrypt(bytes.NewReader(encrypted), alice)
+		Expect(err).To(BeNil())
+		plaintext, err := io.ReadAll(r)
+		Expect(err).To(BeNil())
+
+		encoded, err := shards[0].EncodePEM()
+		Expect(err).To(BeNil())
+		Expect(string(plaintext)).To(Equal(encoded))
+	})
+
+	It("Encrypts to recipients parsed from their bech32 encodings", func() {
+		recipient, err := fage.ParseX25519Recipient(alice.Recipient().String())
+		Expect(err).To(BeNil())
+		identity, err := fage.ParseX25519Identity(alice.String())
+		Expect(err).To(BeNil())
+
+		encrypted, err := EncryptShard(shards[0], recipient)
+		Expect(err).To(BeNil())
+		decrypted, err := DecryptShard(encrypted, identity)
+		Expect(err).To(BeNil())
+		Expect(decrypted.Equal(shards[0])).To(BeTrue())
+	})
+})
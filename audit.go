@@ -0,0 +1,131 @@
+package keysplitting
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"sync"
+	"time"
+)
+
+// An AuditEvent identifies the kind of operation described by an [AuditRecord]
+type AuditEvent string
+
+const (
+	AuditSplit       AuditEvent = "split"        // a key was split into shards, e.g. by [SplitD]
+	AuditDecode      AuditEvent = "decode"       // a shard was decoded from storage, e.g. by [DecodePEM]
+	AuditPartialSign AuditEvent = "partial-sign" // a shard produced a partial signature, e.g. with [SignFirst] or [SignNext]
+	AuditCombine     AuditEvent = "combine"      // partial signatures were combined, e.g. by [CombinePartialSignatures]
+	AuditVerify      AuditEvent = "verify"       // a signature was verified, e.g. by [VerifyFinal]
+
+	AuditPartialDecrypt AuditEvent = "partial-decrypt" // a shard partially decrypted a ciphertext, e.g. with [DecryptFirst]
+	AuditDecrypt        AuditEvent = "decrypt"         // partial decryptions were combined, e.g. by [CombineDecryption]
+)
+
+// An AuditRecord describes a single use of key material. It never includes the key material itself
+type AuditRecord struct {
+	Event   AuditEvent
+	Time    time.Time
+	KeyID   string      // the fingerprint of the public key (see [PublicKeyID]), or "" if it is unknown, e.g. if decoding failed
+	Parties []int       // indices of the shards involved, if known
+	SplitBy SplitBy     // the split algorithm, if known
+	Hash    crypto.Hash // the hash function used to produce Digest
+	Digest  []byte      // the signed digest, for signing, combining, and verifying
+	Err     error       // the reason the operation failed, or nil if it succeeded
+}
+
+// An AuditSink receives an [AuditRecord] for every split, shard decode, partial signature, combination, verification, and
+// decryption performed by this package, so that regulated users can keep an evidence trail of every use of key material. Audit is called
+// synchronously after each operation, from whichever goroutine performed it, so it must be safe for concurrent use and should
+// not block for long
+type AuditSink interface {
+	Audit(record *AuditRecord)
+}
+
+// NopAuditSink discards every record. It is the default [AuditSink]
+type NopAuditSink struct{}
+
+// Audit does nothing
+func (NopAuditSink) Audit(*AuditRecord) {}
+
+var auditSink struct {
+	sync.RWMutex
+	sink AuditSink
+}
+
+// SetAuditSink sets the [AuditSink] that receives records for all subsequent operations. A nil sink restores the default
+// [NopAuditSink]
+func SetAuditSink(sink AuditSink) {
+	auditSink.Lock()
+	defer auditSink.Unlock()
+
+	if _, ok := sink.(NopAuditSink); ok {
+		sink = nil
+	}
+	auditSink.sink = sink
+}
+
+// sends a record to the audit sink, if there is one. The key ID is only computed when a record is actually sent
+func audit(event AuditEvent, pub *rsa.PublicKey, parties []int, splitBy SplitBy, hashFn crypto.Hash, digest []byte, err error) {
+	auditSink.RLock()
+	sink := auditSink.sink
+	auditSink.RUnlock()
+	if sink == nil {
+		return
+	}
+
+	record := &AuditRecord{
+		Event:   event,
+		Time:    time.Now(),
+		Parties: append([]int{}, parties...),
+		SplitBy: splitBy,
+		Hash:    hashFn,
+		Digest:  append([]byte(nil), digest...),
+		Err:     err,
+	}
+	if pub != nil && pub.N != nil {
+		record.KeyID = PublicKeyID(pub)
+	}
+	sink.Audit(record)
+}
+
+// returns the indices of the given shards
+func shardIndices(shards []*PrivateKeyShard) []int {
+	indices := make([]int, 0, len(shards))
+	for _, shard := range shards {
+		indices = append(indices, shard.Index)
+	}
+	return indices
+}
+
+// records the decoding of a shard. Nothing about the shard is recorded unless it decoded successfully
+func auditDecode(shard *PrivateKeyShard, err error) {
+	if err != nil {
+		audit(AuditDecode, nil, nil, "", 0, nil, err)
+		return
+	}
+	audit(AuditDecode, shard.PublicKey, []int{shard.Index}, shard.SplitBy, 0, nil, nil)
+}
+
+// records a partial signature by shard
+func auditPartialSign(shard *PrivateKeyShard, opts crypto.SignerOpts, hashed []byte, err error) {
+	var hashFn crypto.Hash
+	if opts != nil {
+		hashFn = opts.HashFunc()
+	}
+	audit(AuditPartialSign, shard.PublicKey, []int{shard.Index}, shard.SplitBy, hashFn, hashed, err)
+}
+
+// records the combination of partial signatures. The digest isn't known to the combiner
+func auditCombine(pub *rsa.PublicKey, partials []*PartialSignature, err error) {
+	var parties []int
+	var splitBy SplitBy
+	var hashFn crypto.Hash
+	for _, partial := range partials {
+		if partial == nil {
+			continue
+		}
+		parties = append(parties, partial.Signers...)
+		splitBy, hashFn = partial.SplitBy, partial.Hash
+	}
+	audit(AuditCombine, pub, parties, splitBy, hashFn, nil, err)
+}
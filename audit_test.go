@@ -0,0 +1,159 @@
+package keysplitting
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// an AuditSink that keeps every record
+type recordingAuditSink struct {
+	mu      sync.Mutex
+	records []*AuditRecord
+}
+
+func (s *recordingAuditSink) Audit(record *AuditRecord) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, record)
+}
+
+// returns and forgets the records of the given event
+func (s *recordingAuditSink) take(event AuditEvent) []*AuditRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var taken, kept []*AuditRecord
+	for _, record := range s.records {
+		if record.Event == event {
+			taken = append(taken, record)
+		} else {
+			kept = append(kept, record)
+		}
+	}
+	s.records = kept
+	return taken
+}
+
+var _ = Describe("Audit logging", func() {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	keyID := PublicKeyID(&key.PublicKey)
+	hashed := sha256.Sum256([]byte("TEST MESSAGE"))
+	var sink *recordingAuditSink
+
+	BeforeEach(func() {
+		sink = &recordingAuditSink{}
+		SetAuditSink(sink)
+	})
+
+	AfterEach(func() {
+		SetAuditSink(nil)
+	})
+
+	It("Records splits and shard decoding", func() {
+		shards, err := SplitD(key, 3, Addition)
+		Expect(err).To(BeNil())
+
+		records := sink.take(AuditSplit)
+		Expect(records).To(HaveLen(1))
+		Expect(records[0].KeyID).To(Equal(keyID))
+		Expect(records[0].Parties).To(Equal([]int{1, 2, 3}))
+		Expect(records[0].SplitBy).To(Equal(Addition))
+		Expect(records[0].Err).To(BeNil())
+		Expect(records[0].Time).NotTo(BeZero())
+
+		encoded, err := shards[1].EncodePEM()
+		Expect(err).To(BeNil())
+		_, err = DecodePEM(encoded)
+		Expect(err).To(BeNil())
+		jwk, err := shards[2].MarshalJWK()
+		Expect(err).To(BeNil())
+		_, err = UnmarshalJWK(jwk)
+		Expect(err).To(BeNil())
+
+		records = sink.take(AuditDecode)
+		Expect(records).To(HaveLen(2))
+		Expect(records[0].KeyID).To(Equal(keyID))
+		Expect(records[0].Parties).To(Equal([]int{2}))
+		Expect(records[1].Parties).To(Equal([]int{3}))
+
+		_, err = UnmarshalJWK([]byte(`not json`))
+		Expect(err).NotTo(BeNil())
+		records = sink.take(AuditDecode)
+		Expect(records).To(HaveLen(1))
+		Expect(records[0].KeyID).To(BeEmpty())
+		Expect(errors.Is(records[0].Err, ErrInvalidShard)).To(BeTrue())
+	})
+
+	It("Records partial signatures, combination, and verification", func() {
+		shards, err := SplitD(key, 2, Addition)
+		Expect(err).To(BeNil())
+
+		first, err := SignFirst(rand.Reader, shards[0], crypto.SHA256, hashed[:])
+		Expect(err).To(BeNil())
+		second, err := SignFirst(rand.Reader, shards[1], crypto.SHA256, hashed[:])
+		Expect(err).To(BeNil())
+		_, err = SignNext(rand.Reader, shards[0], crypto.SHA256, hashed[:], first)
+		Expect(err).NotTo(BeNil())
+
+		records := sink.take(AuditPartialSign)
+		Expect(records).To(HaveLen(3))
+		for i, parties := range [][]int{{1}, {2}, {1}} {
+			Expect(records[i].KeyID).To(Equal(keyID))
+			Expect(records[i].Parties).To(Equal(parties))
+			Expect(records[i].Hash).To(Equal(crypto.SHA256))
+			Expect(records[i].Digest).To(Equal(hashed[:]))
+		}
+		Expect(records[0].Err).To(BeNil())
+		Expect(errors.Is(records[2].Err, ErrInvalidPartialSignature)).To(BeTrue())
+
+		sig, err := CombinePartialSignatures(&key.PublicKey, first, second)
+		Expect(err).To(BeNil())
+		records = sink.take(AuditCombine)
+		Expect(records).To(HaveLen(1))
+		Expect(records[0].Parties).To(Equal([]int{1, 2}))
+		Expect(records[0].SplitBy).To(Equal(Addition))
+
+		Expect(VerifyFinal(&key.PublicKey, crypto.SHA256, hashed[:], sig, Addition)).To(Succeed())
+		Expect(VerifyFinal(&key.PublicKey, crypto.SHA256, hashed[:], first.Sig, Addition)).NotTo(Succeed())
+		records = sink.take(AuditVerify)
+		Expect(records).To(HaveLen(2))
+		Expect(records[0].Err).To(BeNil())
+		Expect(records[0].Digest).To(Equal(hashed[:]))
+		Expect(errors.Is(records[1].Err, ErrIncompleteSignature)).To(BeTrue())
+	})
+
+	It("Records threshold signatures", func() {
+		shares, err := SplitThreshold(key, 2, 3)
+		Expect(err).To(BeNil())
+		partials := make([]*ThresholdPartialSignature, 2)
+		for i := range partials {
+			partials[i], err = SignThreshold(shares[i], crypto.SHA256, hashed[:])
+			Expect(err).To(BeNil())
+		}
+		_, err = CombineThreshold(&key.PublicKey, 2, 3, crypto.SHA256, hashed[:], partials...)
+		Expect(err).To(BeNil())
+
+		Expect(sink.take(AuditPartialSign)).To(HaveLen(2))
+		records := sink.take(AuditCombine)
+		Expect(records).To(HaveLen(1))
+		Expect(records[0].Parties).To(Equal([]int{1, 2}))
+	})
+
+	It("Discards records by default", func() {
+		SetAuditSink(NopAuditSink{})
+		_, err := SplitD(key, 2, Addition)
+		Expect(err).To(BeNil())
+
+		SetAuditSink(nil)
+		_, err = SplitD(key, 2, Addition)
+		Expect(err).To(BeNil())
+		Expect(sink.records).To(BeEmpty())
+	})
+})
@@ -0,0 +1,76 @@
+/*
+Package awskms keeps shards encrypted at rest under a symmetric AWS KMS key, by providing a keysplitting.DataKeySource that
+calls KMS through the AWS SDK. It is a separate package so that keysplitting doesn't depend on the SDK
+*/
+package awskms
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/kms/types"
+	"github.com/bastionzero/keysplitting"
+)
+
+// A DataKeySource is a keysplitting.DataKeySource backed by a symmetric AWS KMS key. Use it with
+// keysplitting.NewEnvelopeWrapper:
+//
+//	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion("us-east-1"))
+//	...
+//	wrapper := keysplitting.NewEnvelopeWrapper(&awskms.DataKeySource{Client: kms.NewFromConfig(cfg), KeyID: "alias/shards"})
+//	wrapped, err := wrapper.WrapShard(ctx, shard)
+//
+// The caller needs the kms:GenerateDataKey and kms:Decrypt permissions on the key. Errors from KMS, such as an
+// AccessDeniedException or an InvalidCiphertextException, are passed through, so they can be inspected with errors.As
+// and smithy.APIError or the types in github.com/aws/aws-sdk-go-v2/service/kms/types
+type DataKeySource struct {
+	// Client makes the requests, usually a *kms.Client
+	Client Client
+	// KeyID identifies the KMS key by its ID, ARN, alias name or alias ARN
+	KeyID string
+}
+
+var _ keysplitting.DataKeySource = (*DataKeySource)(nil)
+
+// Client is the part of *kms.Client that [DataKeySource] uses
+type Client interface {
+	GenerateDataKey(ctx context.Context, params *kms.GenerateDataKeyInput, optFns ...func(*kms.Options)) (*kms.GenerateDataKeyOutput, error)
+	Decrypt(ctx context.Context, params *kms.DecryptInput, optFns ...func(*kms.Options)) (*kms.DecryptOutput, error)
+}
+
+var _ Client = (*kms.Client)(nil)
+
+// GenerateDataKey calls the KMS GenerateDataKey operation for an AES_256 key
+func (k *DataKeySource) GenerateDataKey(ctx context.Context, encryptionContext map[string]string) ([]byte, []byte, error) {
+	resp, err := k.Client.GenerateDataKey(ctx, &kms.GenerateDataKeyInput{
+		KeyId:             aws.String(k.KeyID),
+		KeySpec:           types.DataKeySpecAes256,
+		EncryptionContext: encryptionContext,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(resp.Plaintext) != 32 || len(resp.CiphertextBlob) == 0 {
+		return nil, nil, fmt.Errorf("AWS KMS returned a malformed data key")
+	}
+	return resp.Plaintext, resp.CiphertextBlob, nil
+}
+
+// DecryptDataKey calls the KMS Decrypt operation. KMS rejects the request unless the data key was generated by KeyID with the
+// same encryption context
+func (k *DataKeySource) DecryptDataKey(ctx context.Context, encrypted []byte, encryptionContext map[string]string) ([]byte, error) {
+	resp, err := k.Client.Decrypt(ctx, &kms.DecryptInput{
+		KeyId:             aws.String(k.KeyID),
+		CiphertextBlob:    encrypted,
+		EncryptionContext: encryptionContext,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Plaintext) != 32 {
+		return nil, fmt.Errorf("AWS KMS returned a malformed data key")
+	}
+	return resp.Plaintext, nil
+}
@@ -0,0 +1,120 @@
+package awskms
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/kms/types"
+	"github.com/aws/smithy-go"
+	"github.com/bastionzero/keysplitting"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestAWSKMS(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "AWS KMS Suite")
+}
+
+// the encryption context key under which keysplitting.EnvelopeWrapper binds data keys to the shard's key
+const keyIDContextKey = "keysplitting:key-id"
+
+// a request to the KMS JSON API, as decoded by the emulated service
+type awsKMSRequest struct {
+	KeyID             string            `json:"KeyId"`
+	KeySpec           string            `json:"KeySpec"`
+	CiphertextBlob    []byte            `json:"CiphertextBlob"`
+	EncryptionContext map[string]string `json:"EncryptionContext"`
+}
+
+// a response from the KMS JSON API, as encoded by the emulated service
+type awsKMSResponse struct {
+	CiphertextBlob []byte `json:"CiphertextBlob,omitempty"`
+	Plaintext      []byte `json:"Plaintext"`
+}
+
+var _ = Describe("AWS KMS", func() {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	shards, _ := keysplitting.SplitD(key, 2, keysplitting.Addition)
+	ctx := context.Background()
+	creds := aws.Credentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+
+	// emulates the GenerateDataKey and Decrypt operations, with data keys "encrypted" by prefixing them with their context
+	newServer := func() *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer GinkgoRecover()
+			Expect(r.Header.Get("Content-Type")).To(Equal("application/x-amz-json-1.1"))
+			Expect(r.Header.Get("Authorization")).To(HavePrefix("AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/"))
+			Expect(r.Header.Get("Authorization")).To(ContainSubstring("/us-west-2/kms/aws4_request"))
+
+			var req awsKMSRequest
+			Expect(json.NewDecoder(r.Body).Decode(&req)).To(Succeed())
+			Expect(req.KeyID).To(Equal("alias/shards"))
+			context := []byte(req.EncryptionContext[keyIDContextKey])
+
+			switch r.Header.Get("X-Amz-Target") {
+			case "TrentService.GenerateDataKey":
+				Expect(req.KeySpec).To(Equal("AES_256"))
+				dataKey := make([]byte, 32)
+				_, _ = rand.Read(dataKey)
+				_ = json.NewEncoder(w).Encode(awsKMSResponse{Plaintext: dataKey, CiphertextBlob: append(context, dataKey...)})
+			case "TrentService.Decrypt":
+				if !strings.HasPrefix(string(req.CiphertextBlob), string(context)) {
+					w.WriteHeader(http.StatusBadRequest)
+					_, _ = w.Write([]byte(`{"__type":"com.amazonaws.kms#InvalidCiphertextException","message":"context mismatch"}`))
+					return
+				}
+				_ = json.NewEncoder(w).Encode(awsKMSResponse{Plaintext: req.CiphertextBlob[len(context):]})
+			default:
+				w.WriteHeader(http.StatusBadRequest)
+			}
+		}))
+	}
+
+	// returns a KMS client that calls server
+	newClient := func(server *httptest.Server) *kms.Client {
+		return kms.New(kms.Options{
+			Region:           "us-west-2",
+			Credentials:      aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) { return creds, nil }),
+			EndpointResolver: kms.EndpointResolverFromURL(server.URL),
+			HTTPClient:       server.Client(),
+			RetryMaxAttempts: 1,
+		})
+	}
+
+	It("Wraps shards with data keys from KMS", func() {
+		server := newServer()
+		defer server.Close()
+
+		wrapper := keysplitting.NewEnvelopeWrapper(&DataKeySource{Client: newClient(server), KeyID: "alias/shards"})
+		wrapped, err := wrapper.WrapShard(ctx, shards[0])
+		Expect(err).To(BeNil())
+
+		unwrapped, err := wrapper.UnwrapShard(ctx, wrapped)
+		Expect(err).To(BeNil())
+		Expect(unwrapped.Equal(shards[0])).To(BeTrue())
+	})
+
+	It("Returns KMS errors", func() {
+		server := newServer()
+		defer server.Close()
+
+		source := &DataKeySource{Client: newClient(server), KeyID: "alias/shards"}
+		_, err := source.DecryptDataKey(ctx, []byte("garbage"), map[string]string{keyIDContextKey: "00"})
+		var invalidErr *types.InvalidCiphertextException
+		Expect(errors.As(err, &invalidErr)).To(BeTrue())
+		Expect(invalidErr.ErrorMessage()).To(Equal("context mismatch"))
+		var apiErr smithy.APIError
+		Expect(errors.As(err, &apiErr)).To(BeTrue())
+		Expect(apiErr.ErrorCode()).To(Equal("InvalidCiphertextException"))
+	})
+})
@@ -0,0 +1,198 @@
+package keysplitting
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"math/big"
+)
+
+// Mersenne exponents p for which 2^p - 1 is prime. Backup shares are computed in the field of integers modulo the smallest
+// of these primes that is larger than the modulus, so that the field is public, fixed, and doesn't need to be generated
+var mersenneExponents = []uint{2203, 2281, 3217, 4253, 4423, 9689, 9941, 11213, 19937}
+
+// A BackupShare is one of n Shamir shares of an RSA private key, produced by [BackupSplit].
+// Unlike a [PrivateKeyShard], a BackupShare cannot be used to sign. It exists purely so the key can be recovered
+// with [BackupRecover] in case of disaster
+type BackupShare struct {
+	PublicKey *rsa.PublicKey // public part
+	Index     int            // this share's x-coordinate, in 1..n
+	Y         *big.Int       // f(Index) (mod Prime), where f(0) = D
+	Threshold int            // t, the number of shares required to recover the key
+	Prime     *big.Int       // the order of the field used for sharing
+}
+
+// BackupSplit splits priv into n backup shares such that any t of them can recover the whole key, but fewer than t
+// reveal nothing about it. Keep these separate from the shards used for signing: they are meant for cold storage
+func BackupSplit(priv *rsa.PrivateKey, t, n int) ([]*BackupShare, error) {
+	if t < 2 {
+		return nil, errorf(ErrTooFewShards, "threshold must be at least 2")
+	}
+	if n < t {
+		return nil, errorf(ErrTooFewShards, "cannot split key into fewer shares than the threshold")
+	}
+
+	prime, err := backupPrime(priv.N)
+	if err != nil {
+		return nil, err
+	}
+
+	// f(X) = D + a1 * X + a2 * X^2 + ... + a(t-1) * X^(t-1)
+	coefficients := make([]*big.Int, t)
+	coefficients[0] = priv.D
+	for i := 1; i < t; i++ {
+		a, err := rand.Int(rand.Reader, prime)
+		if err != nil {
+			return nil, &RandomnessError{Err: err}
+		}
+		coefficients[i] = a
+	}
+
+	shares := make([]*BackupShare, n)
+	for i := 1; i <= n; i++ {
+		shares[i-1] = &BackupShare{
+			PublicKey: &priv.PublicKey,
+			Index:     i,
+			Y:         evaluatePolynomial(coefficients, big.NewInt(int64(i)), prime),
+			Threshold: t,
+			Prime:     prime,
+		}
+	}
+
+	return shares, nil
+}
+
+// BackupRecover reconstructs the original private key, including its prime factors, from at least t backup shares
+func BackupRecover(shares ...*BackupShare) (*rsa.PrivateKey, error) {
+	if len(shares) == 0 {
+		return nil, errorf(ErrTooFewShards, "no backup shares provided")
+	}
+
+	pub, prime, t := shares[0].PublicKey, shares[0].Prime, shares[0].Threshold
+	if len(shares) < t {
+		return nil, errorf(ErrTooFewShards, "cannot recover key from fewer than %d backup shares", t)
+	}
+	shares = shares[:t]
+
+	for i, share := range shares {
+		if share.PublicKey.N.Cmp(pub.N) != 0 || share.PublicKey.E != pub.E || share.Prime.Cmp(prime) != 0 || share.Threshold != t {
+			return nil, errorf(ErrKeyMismatch, "backup shares do not belong to the same key")
+		}
+		for _, other := range shares[:i] {
+			if other.Index == share.Index {
+				return nil, errorf(ErrInvalidShard, "duplicate backup share %d", share.Index)
+			}
+		}
+	}
+
+	// Lagrange interpolation at 0: D <- sum of y_i * prod(x_j / (x_j - x_i)) (mod prime)
+	d := new(big.Int)
+	for i, share := range shares {
+		num, den := big.NewInt(1), big.NewInt(1)
+		for j, other := range shares {
+			if i == j {
+				continue
+			}
+			num.Mul(num, big.NewInt(int64(other.Index)))
+			den.Mul(den, big.NewInt(int64(other.Index-share.Index)))
+		}
+
+		den.Mod(den, prime)
+		if den.ModInverse(den, prime) == nil {
+			return nil, errorf(ErrInvalidShard, "invalid backup share index %d", share.Index)
+		}
+
+		term := new(big.Int).Mul(share.Y, num)
+		term.Mul(term, den)
+		d.Add(d, term)
+		d.Mod(d, prime)
+	}
+
+	primes, err := factorModulus(pub, d)
+	if err != nil {
+		return nil, errorf(ErrInvalidShard, "failed to recover key from backup shares, which may be corrupted: %s", err)
+	}
+
+	priv := &rsa.PrivateKey{
+		PublicKey: *pub,
+		D:         d,
+		Primes:    primes,
+	}
+	if err := priv.Validate(); err != nil {
+		return nil, errorf(ErrInvalidShard, "recovered key is invalid: %s", err)
+	}
+	priv.Precompute()
+
+	return priv, nil
+}
+
+// returns the smallest Mersenne prime larger than N
+func backupPrime(N *big.Int) (*big.Int, error) {
+	for _, exponent := range mersenneExponents {
+		if uint(N.BitLen()) < exponent {
+			prime := new(big.Int).Lsh(bigOne, exponent)
+			return prime.Sub(prime, bigOne), nil
+		}
+	}
+	return nil, errorf(ErrInvalidKey, "modulus is too large to back up")
+}
+
+// recovers the prime factors of N given a valid private exponent d, using the fact that D * E - 1 is a multiple of lambda(N).
+// This is the standard probabilistic algorithm: it finds nontrivial square roots of 1 (mod N), each of which splits N
+func factorModulus(pub *rsa.PublicKey, d *big.Int) ([]*big.Int, error) {
+	// k <- D * E - 1 = 2^s * r, where r is odd
+	k := new(big.Int).Mul(d, big.NewInt(int64(pub.E)))
+	k.Sub(k, bigOne)
+	if k.Sign() <= 0 || k.Bit(0) != 0 {
+		return nil, errorf(ErrKeyMismatch, "private exponent does not match public key")
+	}
+	s := k.TrailingZeroBits()
+	r := new(big.Int).Rsh(k, s)
+
+	factors := []*big.Int{new(big.Int).Set(pub.N)}
+	primes := make([]*big.Int, 0)
+
+	// each attempt succeeds with probability at least 1/2, so this bound is never reached with a valid key
+	for attempts := 0; len(factors) > 0 && attempts < 1000; attempts++ {
+		f := factors[len(factors)-1]
+		if f.ProbablyPrime(20) {
+			primes = append(primes, f)
+			factors = factors[:len(factors)-1]
+			continue
+		}
+
+		g, err := rand.Int(rand.Reader, f)
+		if err != nil {
+			return nil, &RandomnessError{Err: err}
+		}
+		if g.Cmp(bigOne) <= 0 {
+			continue
+		}
+		if gcd := new(big.Int).GCD(nil, nil, g, f); gcd.Cmp(bigOne) != 0 {
+			factors = append(factors[:len(factors)-1], gcd, new(big.Int).Quo(f, gcd))
+			continue
+		}
+
+		fMinusOne := new(big.Int).Sub(f, bigOne)
+		x := new(big.Int).Exp(g, r, f)
+		for i := uint(0); i < s; i++ {
+			if x.Cmp(bigOne) == 0 || x.Cmp(fMinusOne) == 0 {
+				break
+			}
+
+			y := new(big.Int).Mul(x, x)
+			y.Mod(y, f)
+			if y.Cmp(bigOne) == 0 {
+				// x is a nontrivial square root of 1, so gcd(x - 1, f) is a nontrivial factor
+				gcd := new(big.Int).GCD(nil, nil, new(big.Int).Sub(x, bigOne), f)
+				factors = append(factors[:len(factors)-1], gcd, new(big.Int).Quo(f, gcd))
+				break
+			}
+			x = y
+		}
+	}
+
+	if len(factors) > 0 {
+		return nil, errorf(ErrKeyMismatch, "failed to factor modulus")
+	}
+	return primes, nil
+}
@@ -0,0 +1,85 @@
+package keysplitting
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"math/big"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// the order of the recovered prime factors is arbitrary, so rsa.PrivateKey.Equal is too strict
+func expectKeysToBeEquivalent(recovered *rsa.PrivateKey, priv *rsa.PrivateKey) {
+	Expect(recovered.PublicKey.Equal(&priv.PublicKey)).To(BeTrue(), "public keys do not match")
+	Expect(recovered.D.Cmp(priv.D)).To(Equal(0), "private exponents do not match")
+
+	expected := make([]string, len(priv.Primes))
+	for i, p := range priv.Primes {
+		expected[i] = p.String()
+	}
+	actual := make([]string, len(recovered.Primes))
+	for i, p := range recovered.Primes {
+		actual[i] = p.String()
+	}
+	Expect(actual).To(ConsistOf(expected), "prime factors do not match")
+}
+
+var _ = Describe("Backup shares", func() {
+	priv, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	Context("Basic interfacing", func() {
+		It("Refuses a threshold below 2", func() {
+			_, err := BackupSplit(priv, 1, 3)
+			Expect(err).NotTo(BeNil())
+		})
+
+		It("Refuses more required shares than parties", func() {
+			_, err := BackupSplit(priv, 4, 3)
+			Expect(err).NotTo(BeNil())
+		})
+	})
+
+	When("Backing up a key 3-of-5", Ordered, func() {
+		var shares []*BackupShare
+
+		It("Successfully splits the key", func() {
+			var err error
+			shares, err = BackupSplit(priv, 3, 5)
+			Expect(err).To(BeNil())
+			Expect(shares).To(HaveLen(5))
+		})
+
+		It("Recovers the whole key from any 3 shares", func() {
+			for _, selection := range [][]*BackupShare{shares[:3], shares[2:], {shares[4], shares[0], shares[2]}} {
+				recovered, err := BackupRecover(selection...)
+				Expect(err).To(BeNil())
+				expectKeysToBeEquivalent(recovered, priv)
+			}
+		})
+
+		It("Fails to recover the key from 2 shares", func() {
+			_, err := BackupRecover(shares[:2]...)
+			Expect(err).NotTo(BeNil())
+		})
+
+		It("Fails to recover the key from a corrupted share", func() {
+			corrupted := *shares[0]
+			corrupted.Y = new(big.Int).Add(corrupted.Y, bigOne)
+			_, err := BackupRecover(&corrupted, shares[1], shares[2])
+			Expect(err).NotTo(BeNil())
+		})
+	})
+
+	When("Backing up a multi-prime key", func() {
+		It("Recovers all of the prime factors", func() {
+			multiPriv, _ := rsa.GenerateMultiPrimeKey(rand.Reader, 3, 2048)
+			shares, err := BackupSplit(multiPriv, 2, 2)
+			Expect(err).To(BeNil())
+
+			recovered, err := BackupRecover(shares...)
+			Expect(err).To(BeNil())
+			expectKeysToBeEquivalent(recovered, multiPriv)
+		})
+	})
+})
@@ -0,0 +1,191 @@
+package keysplitting
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"io"
+	"sync"
+	"time"
+)
+
+// A BatchRemoteShard is a [RemoteShard] whose transport can carry several signing requests in a single round trip. A
+// [BatchSigner] sends each of its batches to such a shard with one call to PartialSignBatch, and to any other shard as
+// concurrent calls to PartialSign
+type BatchRemoteShard interface {
+	RemoteShard
+	// PartialSignBatch is like PartialSign for each of reqs, and returns their partial signatures in the same order
+	PartialSignBatch(ctx context.Context, reqs []*SigningRequest) ([]*PartialSignature, error)
+}
+
+// DefaultMaxBatch is the largest batch a [BatchSigner] sends if [BatchSigner].MaxBatch is 0
+const DefaultMaxBatch = 64
+
+// A BatchSigner implements [crypto.Signer] with remote shards, as a [RemoteSigner] does, but batches concurrent calls to Sign,
+// so that a busy certificate authority, such as an ACME server issuing through a crypto.Signer, pays for one multi-party round
+// trip per batch rather than one per certificate.
+//
+// Batching adapts to the latency of the shards without any fixed delay: a request that arrives while no batch is being
+// signed is sent at once, and requests that arrive while one is being signed wait for it to complete and are then sent
+// together. Each batch has every shard sign in turn, as [SignSequential] does, so it works for keys split by either algorithm,
+// and a request that a shard fails fails on its own, without affecting the rest of its batch
+type BatchSigner struct {
+	MaxBatch int // the largest number of requests sent in a single batch, or [DefaultMaxBatch] if 0
+
+	pub    *rsa.PublicKey
+	shards []RemoteShard
+
+	mu       sync.Mutex
+	pending  []*batchedRequest
+	inFlight bool // whether a goroutine is sending the pending requests
+}
+
+// a request waiting to be signed as part of a batch
+type batchedRequest struct {
+	req        *SigningRequest
+	partialSig *PartialSignature // the partial signature of the shards that have signed so far
+	done       chan struct{}     // closed once sig or err is set
+	sig        []byte
+	err        error
+}
+
+// NewBatchSigner returns a BatchSigner for pub that signs with the given shards, in order
+func NewBatchSigner(pub *rsa.PublicKey, shards ...RemoteShard) (*BatchSigner, error) {
+	if len(shards) < 2 {
+		return nil, errorf(ErrTooFewShards, "cannot sign with fewer than 2 shards")
+	}
+	return &BatchSigner{pub: pub, shards: append([]RemoteShard{}, shards...)}, nil
+}
+
+// Public returns the public key of the split key
+func (s *BatchSigner) Public() crypto.PublicKey {
+	return s.pub
+}
+
+// Sign implements [crypto.Signer], accepting the same options as [RemoteSigner.Sign]. It blocks until the batch that the
+// request is sent in has been signed
+func (s *BatchSigner) Sign(random io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	opts, err := sharedSaltOpts(random, s.pub, opts)
+	if err != nil {
+		return nil, err
+	}
+	if opts, err = withNonce(opts); err != nil {
+		return nil, err
+	}
+	req, err := NewSigningRequest(s.pub, opts, digest)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &batchedRequest{req: req, done: make(chan struct{})}
+	s.mu.Lock()
+	s.pending = append(s.pending, r)
+	if !s.inFlight {
+		s.inFlight = true
+		go s.run()
+	}
+	s.mu.Unlock()
+
+	<-r.done
+	return r.sig, r.err
+}
+
+// signs the pending requests in batches until there are none left
+func (s *BatchSigner) run() {
+	maxBatch := s.MaxBatch
+	if maxBatch <= 0 {
+		maxBatch = DefaultMaxBatch
+	}
+	for {
+		s.mu.Lock()
+		n := len(s.pending)
+		if n == 0 {
+			s.inFlight = false
+			s.mu.Unlock()
+			return
+		}
+		if n > maxBatch {
+			n = maxBatch
+		}
+		batch := s.pending[:n]
+		s.pending = append([]*batchedRequest{}, s.pending[n:]...)
+		s.mu.Unlock()
+
+		s.signBatch(batch)
+	}
+}
+
+// has every shard sign each request in the batch in turn
+func (s *BatchSigner) signBatch(batch []*batchedRequest) {
+	defer startSession()()
+	ctx, span := StartSpan(context.Background(), "keysplitting.BatchSigner.Sign",
+		append(keyAttributes(s.pub, "", 0), TraceAttribute{Key: TraceShardCount, Value: len(s.shards)})...)
+
+	live := batch
+	for _, shard := range s.shards {
+		if len(live) == 0 {
+			break
+		}
+		reqs := make([]*SigningRequest, len(live))
+		for i, r := range live {
+			reqs[i] = r.req.next(r.partialSig)
+		}
+		partials, errs := partialSignBatch(ctx, shard, reqs)
+
+		var signed []*batchedRequest
+		for i, r := range live {
+			if errs[i] != nil {
+				r.err = errs[i]
+				close(r.done)
+				continue
+			}
+			r.partialSig = partials[i]
+			signed = append(signed, r)
+		}
+		live = signed
+	}
+
+	// the last shard completes each signature, so there is nothing to combine except the nonce to remove
+	for _, r := range live {
+		start := time.Now()
+		if r.err = r.partialSig.checkNonce(r.req.Nonce); r.err == nil {
+			r.sig, r.err = unbindNonce(s.pub, r.req.Nonce, r.partialSig.Sig)
+		}
+		observeCombine(start, r.err)
+		close(r.done)
+	}
+	span.End(nil)
+}
+
+// asks shard to sign each of reqs, in a single call if it is a BatchRemoteShard, and returns the partial signature or error
+// for each
+func partialSignBatch(ctx context.Context, shard RemoteShard, reqs []*SigningRequest) ([]*PartialSignature, []error) {
+	partials := make([]*PartialSignature, len(reqs))
+	errs := make([]error, len(reqs))
+
+	if b, ok := shard.(BatchRemoteShard); ok {
+		results, err := b.PartialSignBatch(ctx, reqs)
+		if err == nil && len(results) != len(reqs) {
+			err = errorf(ErrInvalidPartialSignature, "shard returned %d partial signatures for %d requests", len(results), len(reqs))
+		}
+		for i := range reqs {
+			if err != nil {
+				errs[i] = err
+			} else if partials[i] = results[i]; partials[i] == nil {
+				errs[i] = errorf(ErrInvalidPartialSignature, "shard returned no partial signature for request #%d", i)
+			}
+		}
+		return partials, errs
+	}
+
+	var wg sync.WaitGroup
+	for i, req := range reqs {
+		wg.Add(1)
+		go func(i int, req *SigningRequest) {
+			defer wg.Done()
+			partials[i], errs[i] = partialSign(ctx, shard, req)
+		}(i, req)
+	}
+	wg.Wait()
+	return partials, errs
+}
@@ -0,0 +1,142 @@
+package keysplitting
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// a BatchRemoteShard that counts its round trips, each of which takes a little while, and refuses to sign refused
+type batchingShard struct {
+	RemoteShard
+	calls   int32
+	refused []byte
+}
+
+func (s *batchingShard) PartialSignBatch(ctx context.Context, reqs []*SigningRequest) ([]*PartialSignature, error) {
+	atomic.AddInt32(&s.calls, 1)
+	time.Sleep(20 * time.Millisecond)
+	partials := make([]*PartialSignature, len(reqs))
+	for i, req := range reqs {
+		if bytes.Equal(req.Digest, s.refused) {
+			continue
+		}
+		var err error
+		if partials[i], err = s.PartialSign(ctx, req); err != nil {
+			return nil, err
+		}
+	}
+	return partials, nil
+}
+
+var _ = Describe("Batch signer", func() {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	// batching shards for each of the shards, except for the last, which only signs one request at a time
+	remotes := func(splitBy SplitBy) ([]RemoteShard, *batchingShard) {
+		shards, err := SplitD(key, 3, splitBy)
+		Expect(err).To(BeNil())
+		remotes := make([]RemoteShard, len(shards))
+		for i, shard := range shards {
+			remotes[i], err = NewLocalShard(shard)
+			Expect(err).To(BeNil())
+		}
+		first := &batchingShard{RemoteShard: remotes[0]}
+		remotes[0] = first
+		remotes[1] = &batchingShard{RemoteShard: remotes[1]}
+		return remotes, first
+	}
+
+	// signs n messages at once, returning their digests, signatures and errors
+	signConcurrently := func(signer crypto.Signer, n int, opts crypto.SignerOpts) ([][]byte, [][]byte, []error) {
+		digests := make([][]byte, n)
+		sigs := make([][]byte, n)
+		errs := make([]error, n)
+		var wg sync.WaitGroup
+		for i := 0; i < n; i++ {
+			hashed := sha256.Sum256([]byte(fmt.Sprintf("CERTIFICATE %d", i)))
+			digests[i] = hashed[:]
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				sigs[i], errs[i] = signer.Sign(rand.Reader, digests[i], opts)
+			}(i)
+		}
+		wg.Wait()
+		return digests, sigs, errs
+	}
+
+	It("Batches concurrent signatures with shards split by either algorithm", func() {
+		for _, splitBy := range []SplitBy{Addition, Multiplication} {
+			shards, first := remotes(splitBy)
+			signer, err := NewBatchSigner(&key.PublicKey, shards...)
+			Expect(err).To(BeNil())
+			var _ crypto.Signer = signer
+
+			digests, sigs, errs := signConcurrently(signer, 20, crypto.SHA256)
+			for i := range digests {
+				Expect(errs[i]).To(BeNil())
+				Expect(rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digests[i], sigs[i])).To(Succeed())
+			}
+			Expect(atomic.LoadInt32(&first.calls)).To(BeNumerically("<", 20))
+		}
+	})
+
+	It("Limits the size of each batch", func() {
+		shards, first := remotes(Addition)
+		signer, err := NewBatchSigner(&key.PublicKey, shards...)
+		Expect(err).To(BeNil())
+		signer.MaxBatch = 1
+
+		_, _, errs := signConcurrently(signer, 5, crypto.SHA256)
+		for _, err := range errs {
+			Expect(err).To(BeNil())
+		}
+		Expect(atomic.LoadInt32(&first.calls)).To(Equal(int32(5)))
+	})
+
+	It("Signs PSS digests for crypto/tls-style options", func() {
+		shards, _ := remotes(Addition)
+		signer, err := NewBatchSigner(&key.PublicKey, shards...)
+		Expect(err).To(BeNil())
+
+		opts := &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: crypto.SHA256}
+		digests, sigs, errs := signConcurrently(signer, 4, opts)
+		for i := range digests {
+			Expect(errs[i]).To(BeNil())
+			Expect(rsa.VerifyPSS(&key.PublicKey, crypto.SHA256, digests[i], sigs[i], opts)).To(Succeed())
+		}
+	})
+
+	It("Fails only the requests that a shard fails", func() {
+		shards, first := remotes(Addition)
+		refused := sha256.Sum256([]byte("CERTIFICATE 3"))
+		first.refused = refused[:]
+		signer, err := NewBatchSigner(&key.PublicKey, shards...)
+		Expect(err).To(BeNil())
+
+		digests, sigs, errs := signConcurrently(signer, 8, crypto.SHA256)
+		for i := range digests {
+			if i == 3 {
+				Expect(errs[i]).NotTo(BeNil())
+				continue
+			}
+			Expect(errs[i]).To(BeNil())
+			Expect(rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digests[i], sigs[i])).To(Succeed())
+		}
+
+		_, err = NewBatchSigner(&key.PublicKey, shards[0])
+		Expect(errors.Is(err, ErrTooFewShards)).To(BeTrue())
+	})
+})
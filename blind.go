@@ -0,0 +1,115 @@
+package keysplitting
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"io"
+	"math/big"
+	"time"
+)
+
+// Blind signatures let a requester have a message signed without the signers learning the message or being able to link the
+// signature to their signing of it, as in RFC 9474 and Privacy Pass token issuance. The requester blinds the encoded message
+// with [Blind], the shard holders sign the blinded value with [SignFirstBlinded] and [SignNextBlinded], their partial signatures
+// are combined as usual, e.g. with [CombinePartialSignatures], and the requester removes the blinding with [BlindingState.Unblind].
+//
+// Since the shard holders sign whatever value they are given, a key used for blind signatures must never be used for anything else
+
+// A BlindingState is kept by the requester between blinding a message with [Blind] and unblinding its signature
+type BlindingState struct {
+	pub     *rsa.PublicKey
+	em      *big.Int // the encoded message
+	inverse *big.Int // the inverse of the blinding factor (mod N)
+}
+
+// Blind encodes hashed for signing with opts, as for [SignFirst], and blinds it with a random factor r, i.e. returns
+// m * r^e (mod N). opts is a [crypto.Hash] for a PKCS #1 v1.5 signature, or a *[PSSOptions] with a salt chosen by the requester
+// for an RSASSA-PSS signature, as RFC 9474 recommends. The blinded value is sent to the shard holders, and the returned state
+// is kept to unblind the signature
+func Blind(random io.Reader, pub *rsa.PublicKey, opts crypto.SignerOpts, hashed []byte) ([]byte, *BlindingState, error) {
+	em, err := encodeMessage(pub, opts, hashed)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var r, inverse *big.Int
+	for inverse == nil {
+		if r, err = rand.Int(random, pub.N); err != nil {
+			return nil, nil, &RandomnessError{Err: err}
+		}
+		if r.Sign() != 0 {
+			inverse = new(big.Int).ModInverse(r, pub.N)
+		}
+	}
+
+	m := new(big.Int).SetBytes(em)
+	blinded := r.Exp(r, big.NewInt(int64(pub.E)), pub.N)
+	blinded.Mul(blinded, m).Mod(blinded, pub.N)
+	return blinded.FillBytes(make([]byte, pub.Size())), &BlindingState{pub: pub, em: m, inverse: inverse}, nil
+}
+
+// Unblind removes the blinding from blindSig, the complete signature on the blinded value returned by [Blind], and returns
+// the signature on the original message. It fails with [ErrIncompleteSignature] if the result doesn't verify, e.g. because
+// some shards haven't signed
+func (s *BlindingState) Unblind(blindSig []byte) ([]byte, error) {
+	if len(blindSig) != s.pub.Size() {
+		return nil, errorf(ErrInvalidPartialSignature, "blind signature is %d bytes long, but a signature under this key must be %d bytes long", len(blindSig), s.pub.Size())
+	}
+
+	sig := new(big.Int).SetBytes(blindSig)
+	sig.Mul(sig, s.inverse).Mod(sig, s.pub.N)
+	if new(big.Int).Exp(sig, big.NewInt(int64(s.pub.E)), s.pub.N).Cmp(s.em) != 0 {
+		return nil, ErrIncompleteSignature
+	}
+	return sig.FillBytes(make([]byte, s.pub.Size())), nil
+}
+
+// SignFirstBlinded uses the given key shard to perform the initial signature on a value blinded with [Blind]. The value is
+// signed as it is, without any encoding, so the resulting partial signature has no hash function
+func SignFirstBlinded(shard *PrivateKeyShard, blinded []byte) (partialSig *PartialSignature, err error) {
+	start := time.Now()
+	defer func() {
+		observePartialSignature(start, err)
+		auditPartialSign(shard, crypto.Hash(0), blinded, err)
+	}()
+
+	sig, err := signBlinded(shard, blinded)
+	if err != nil {
+		return nil, err
+	}
+	return newPartialSignature(shard, 0, sig), nil
+}
+
+// SignNextBlinded uses the given key shard to sign a partially-signed blinded value, chaining the shards as [SignNext] does
+func SignNextBlinded(shard *PrivateKeyShard, blinded []byte, partialSig *PartialSignature) (next *PartialSignature, err error) {
+	start := time.Now()
+	defer func() {
+		observePartialSignature(start, err)
+		auditPartialSign(shard, crypto.Hash(0), blinded, err)
+	}()
+
+	return signNext(shard, 0, nil, partialSig, func() ([]byte, error) {
+		return signBlinded(shard, blinded)
+	})
+}
+
+// returns blinded^shard (mod N)
+func signBlinded(shard *PrivateKeyShard, blinded []byte) ([]byte, error) {
+	if err := checkSplitBy(shard.SplitBy); err != nil {
+		return nil, err
+	}
+	if err := shard.checkUsable(); err != nil {
+		return nil, err
+	}
+	m := new(big.Int).SetBytes(blinded)
+	if len(blinded) != shard.PublicKey.Size() || m.Sign() == 0 || m.Cmp(shard.PublicKey.N) >= 0 {
+		return nil, errorf(ErrInvalidPartialSignature, "blinded value is out of range for the shard's public key")
+	}
+
+	sig, err := expSigned(m, shard.exponent(blinded), shard.PublicKey.N)
+	if err != nil {
+		return nil, errorf(ErrInvalidPartialSignature, "blinded value has no inverse modulo N")
+	}
+	return sig.FillBytes(make([]byte, shard.PublicKey.Size())), nil
+}
@@ -0,0 +1,86 @@
+package keysplitting
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Blind signatures", func() {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	hashed := sha256.Sum256([]byte("TEST MESSAGE"))
+
+	It("Signs a blinded message sequentially with shards split by either algorithm", func() {
+		for _, splitBy := range []SplitBy{Addition, Multiplication} {
+			shards, err := SplitD(key, 3, splitBy)
+			Expect(err).To(BeNil())
+
+			blinded, state, err := Blind(rand.Reader, &key.PublicKey, crypto.SHA256, hashed[:])
+			Expect(err).To(BeNil())
+			partialSig, err := SignFirstBlinded(shards[0], blinded)
+			Expect(err).To(BeNil())
+			for _, shard := range shards[1:] {
+				partialSig, err = SignNextBlinded(shard, blinded, partialSig)
+				Expect(err).To(BeNil())
+			}
+
+			sig, err := state.Unblind(partialSig.Sig)
+			Expect(err).To(BeNil())
+			Expect(rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, hashed[:], sig)).To(Succeed())
+		}
+	})
+
+	It("Combines blind partial signatures into a PSS signature", func() {
+		shards, err := SplitDWithOptions(key, 3, Addition, &SplitOptions{Mask: true})
+		Expect(err).To(BeNil())
+		salt, err := NewPSSSalt(rand.Reader, &key.PublicKey, crypto.SHA256, nil)
+		Expect(err).To(BeNil())
+
+		blinded, state, err := Blind(rand.Reader, &key.PublicKey, &PSSOptions{Hash: crypto.SHA256, Salt: salt}, hashed[:])
+		Expect(err).To(BeNil())
+		partials := make([]*PartialSignature, len(shards))
+		for i, shard := range shards {
+			partials[i], err = SignFirstBlinded(shard, blinded)
+			Expect(err).To(BeNil())
+		}
+		blindSig, err := CombinePartialSignatures(&key.PublicKey, partials...)
+		Expect(err).To(BeNil())
+
+		sig, err := state.Unblind(blindSig)
+		Expect(err).To(BeNil())
+		Expect(rsa.VerifyPSS(&key.PublicKey, crypto.SHA256, hashed[:], sig, &rsa.PSSOptions{SaltLength: len(salt), Hash: crypto.SHA256})).To(Succeed())
+
+		// the shards never see the encoded message
+		em, err := encodeMessage(&key.PublicKey, &PSSOptions{Hash: crypto.SHA256, Salt: salt}, hashed[:])
+		Expect(err).To(BeNil())
+		Expect(blinded).NotTo(Equal(em))
+	})
+
+	It("Refuses to unblind an incomplete signature", func() {
+		shards, err := SplitD(key, 2, Addition)
+		Expect(err).To(BeNil())
+		blinded, state, err := Blind(rand.Reader, &key.PublicKey, crypto.SHA256, hashed[:])
+		Expect(err).To(BeNil())
+		partialSig, err := SignFirstBlinded(shards[0], blinded)
+		Expect(err).To(BeNil())
+
+		_, err = state.Unblind(partialSig.Sig)
+		Expect(errors.Is(err, ErrIncompleteSignature)).To(BeTrue())
+		_, err = state.Unblind(partialSig.Sig[1:])
+		Expect(errors.Is(err, ErrInvalidPartialSignature)).To(BeTrue())
+	})
+
+	It("Refuses blinded values out of range", func() {
+		shards, err := SplitD(key, 2, Addition)
+		Expect(err).To(BeNil())
+		_, err = SignFirstBlinded(shards[0], key.N.Bytes())
+		Expect(errors.Is(err, ErrInvalidPartialSignature)).To(BeTrue())
+		_, err = SignFirstBlinded(shards[0], hashed[:])
+		Expect(errors.Is(err, ErrInvalidPartialSignature)).To(BeTrue())
+	})
+})
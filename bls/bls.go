@@ -0,0 +1,224 @@
+/*
+Package bls splits BLS12-381 keys into threshold shares, any t of which sign without the key ever being rebuilt. It is the
+BLS sibling of keysplitting's threshold RSA signatures, and the simplest of the threshold schemes: each share holder signs on
+its own, in a single message, so the brokered model of everyone signing in parallel needs no coordination at all. Anyone can
+check a partial signature against the signer's verification share, and [Combine] interpolates any t of them into a signature.
+
+Signatures follow the minimal-pubkey-size variant of the IETF BLS signature draft [1] with the proof of possession ciphersuite,
+BLS_SIG_BLS12381G2_XMD:SHA-256_SSWU_RO_POP_: public keys are compressed G1 points of 48 bytes, signatures compressed G2 points
+of 96 bytes, and messages are hashed to G2 as in RFC 9380 [2]. This is the format Ethereum's consensus layer uses, so the
+signatures can be checked with any implementation of it, as well as with [Verify].
+
+	[1] https://datatracker.ietf.org/doc/draft-irtf-cfrg-bls-signature/
+	[2] https://www.rfc-editor.org/rfc/rfc9380
+*/
+package bls
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/bastionzero/keysplitting"
+	"github.com/cloudflare/circl/ecc/bls12381"
+)
+
+// DST is the domain separation tag of the ciphersuite, which messages are hashed with
+const DST = "BLS_SIG_BLS12381G2_XMD:SHA-256_SSWU_RO_POP_"
+
+// the sizes of secret keys, public keys and signatures
+const (
+	SecretKeySize = 32
+	PublicKeySize = g1Size
+	SignatureSize = g2Size
+)
+
+// A GroupKey is the public part of a set of shares
+type GroupKey struct {
+	PublicKey          []byte   // the public key that signatures verify against
+	Threshold          int      // t, the number of shares required to sign
+	VerificationShares [][]byte // the public key of each share, in order of their index, which partial signatures verify against
+}
+
+// A KeyShare is one of n shares of a BLS secret key, from [Split]
+type KeyShare struct {
+	Group  *GroupKey        // public part
+	Index  int              // this share's position in 1..n
+	Secret *bls12381.Scalar // share of the secret key, f(Index) mod r
+}
+
+// A PartialSignature is the contribution of a single [KeyShare] to a signature. It is itself a BLS signature, by the share
+type PartialSignature struct {
+	Index int    // index of the share that produced the partial signature
+	Sig   []byte // the compressed G2 point s_i * H(message)
+}
+
+// PublicKey returns the public key of the 32-byte big-endian secret key sk
+func PublicKey(sk []byte) ([]byte, error) {
+	x, err := parseSecretKey(sk)
+	if err != nil {
+		return nil, err
+	}
+	return g1Mul(x).BytesCompressed(), nil
+}
+
+// Split splits the 32-byte big-endian secret key sk into n shares such that any t of them can sign with [Sign]
+func Split(random io.Reader, sk []byte, t, n int) ([]*KeyShare, error) {
+	x, err := parseSecretKey(sk)
+	if err != nil {
+		return nil, err
+	}
+	if t < 2 {
+		return nil, fmt.Errorf("%w: threshold must be at least 2", keysplitting.ErrTooFewShards)
+	}
+	if n < t {
+		return nil, fmt.Errorf("%w: cannot split key into fewer shares than the threshold", keysplitting.ErrTooFewShards)
+	}
+
+	// f(X) = x + a1 * X + ... + a(t-1) * X^(t-1)
+	coefficients := make([]*bls12381.Scalar, t)
+	coefficients[0] = x
+	for i := 1; i < t; i++ {
+		a := new(bls12381.Scalar)
+		if err := a.Random(random); err != nil {
+			return nil, &keysplitting.RandomnessError{Err: err}
+		}
+		coefficients[i] = a
+	}
+
+	group := &GroupKey{PublicKey: g1Mul(x).BytesCompressed(), Threshold: t, VerificationShares: make([][]byte, n)}
+	shares := make([]*KeyShare, n)
+	for i := 1; i <= n; i++ {
+		s := evaluatePolynomial(coefficients, scalarFromInt(i))
+		group.VerificationShares[i-1] = g1Mul(s).BytesCompressed()
+		shares[i-1] = &KeyShare{Group: group, Index: i, Secret: s}
+	}
+	return shares, nil
+}
+
+// Sign uses share to produce a partial signature on message. It needs no interaction with the other signers
+func Sign(share *KeyShare, message []byte) (*PartialSignature, error) {
+	if share.Index < 1 || share.Index > len(share.Group.VerificationShares) {
+		return nil, fmt.Errorf("%w: share index %d is out of range", keysplitting.ErrInvalidShard, share.Index)
+	}
+	sig := new(bls12381.G2)
+	sig.ScalarMult(share.Secret, hashToG2(message, []byte(DST)))
+	return &PartialSignature{Index: share.Index, Sig: sig.BytesCompressed()}, nil
+}
+
+// VerifyPartial checks a partial signature on message against its signer's verification share
+func VerifyPartial(group *GroupKey, message []byte, partial *PartialSignature) error {
+	if partial.Index < 1 || partial.Index > len(group.VerificationShares) {
+		return fmt.Errorf("%w: partial signature index %d is out of range", keysplitting.ErrInvalidPartialSignature, partial.Index)
+	}
+	if !Verify(group.VerificationShares[partial.Index-1], message, partial.Sig) {
+		return &keysplitting.MisbehavingSignersError{Signers: []int{partial.Index}}
+	}
+	return nil
+}
+
+// Combine combines at least t partial signatures on message into a signature, and checks it against the group's public key.
+// If more than t partial signatures are provided, the first t are used. If the signature doesn't verify, the error is a
+// *[keysplitting.MisbehavingSignersError] naming the shares whose partial signatures are bad
+func Combine(group *GroupKey, message []byte, partials ...*PartialSignature) ([]byte, error) {
+	if len(partials) < group.Threshold {
+		return nil, fmt.Errorf("%w: cannot combine fewer than %d partial signatures", keysplitting.ErrTooFewShards, group.Threshold)
+	}
+	partials = partials[:group.Threshold]
+
+	indices := make([]int, len(partials))
+	points := make([]*bls12381.G2, len(partials))
+	for i, partial := range partials {
+		if partial.Index < 1 || partial.Index > len(group.VerificationShares) {
+			return nil, fmt.Errorf("%w: partial signature index %d is out of range", keysplitting.ErrInvalidPartialSignature, partial.Index)
+		}
+		for _, index := range indices[:i] {
+			if index == partial.Index {
+				return nil, fmt.Errorf("%w: duplicate partial signature from share %d", keysplitting.ErrInvalidPartialSignature, index)
+			}
+		}
+		indices[i] = partial.Index
+
+		p, err := decodeG2(partial.Sig)
+		if err != nil {
+			return nil, &keysplitting.MisbehavingSignersError{Signers: []int{partial.Index}}
+		}
+		points[i] = p
+	}
+
+	// sig = sum of lambda_i * sig_i = f(0) * H(message)
+	sig := new(bls12381.G2)
+	sig.SetIdentity()
+	for i, p := range points {
+		term := new(bls12381.G2)
+		term.ScalarMult(lagrangeCoefficient(indices, i), p)
+		sig.Add(sig, term)
+	}
+	encoded := sig.BytesCompressed()
+	if Verify(group.PublicKey, message, encoded) {
+		return encoded, nil
+	}
+
+	var bad []int
+	for _, partial := range partials {
+		if VerifyPartial(group, message, partial) != nil {
+			bad = append(bad, partial.Index)
+		}
+	}
+	if len(bad) > 0 {
+		return nil, &keysplitting.MisbehavingSignersError{Signers: bad}
+	}
+	return nil, fmt.Errorf("%w: combined signature does not verify", keysplitting.ErrInvalidPartialSignature)
+}
+
+// Verify reports whether sig is a valid signature on message by the public key pub
+func Verify(pub, message, sig []byte) bool {
+	P, err := decodeG1(pub)
+	if err != nil {
+		return false
+	}
+	S, err := decodeG2(sig)
+	if err != nil {
+		return false
+	}
+
+	// e(pub, H(message)) == e(G1, sig), i.e. e(pub, H(message)) * e(G1, sig)^-1 == 1
+	e := bls12381.ProdPairFrac([]*bls12381.G1{P, bls12381.G1Generator()}, []*bls12381.G2{hashToG2(message, []byte(DST)), S}, []int{1, -1})
+	return e.IsIdentity()
+}
+
+func parseSecretKey(sk []byte) (*bls12381.Scalar, error) {
+	x := new(bls12381.Scalar)
+	if len(sk) != SecretKeySize || x.UnmarshalBinary(sk) != nil || x.IsZero() == 1 {
+		return nil, fmt.Errorf("%w: invalid BLS secret key", keysplitting.ErrInvalidShard)
+	}
+	return x, nil
+}
+
+// returns the Lagrange coefficient at 0 for the i'th of the given indices, mod r
+func lagrangeCoefficient(indices []int, i int) *bls12381.Scalar {
+	num, den := scalarFromInt(1), scalarFromInt(1)
+	xi := scalarFromInt(indices[i])
+	for j, index := range indices {
+		if j == i {
+			continue
+		}
+		xj := scalarFromInt(index)
+		num.Mul(num, xj)
+		xj.Sub(xj, xi)
+		den.Mul(den, xj)
+	}
+	// the indices are public, so the inverse needn't be constant time
+	den.Inv(den)
+	num.Mul(num, den)
+	return num
+}
+
+// evaluates the polynomial with the given coefficients at x, mod r
+func evaluatePolynomial(coefficients []*bls12381.Scalar, x *bls12381.Scalar) *bls12381.Scalar {
+	y := new(bls12381.Scalar)
+	for i := len(coefficients) - 1; i >= 0; i-- {
+		y.Mul(y, x)
+		y.Add(y, coefficients[i])
+	}
+	return y
+}
@@ -0,0 +1,92 @@
+package bls
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/bastionzero/keysplitting"
+	"github.com/cloudflare/circl/ecc/bls12381"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestBLS(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "BLS Suite")
+}
+
+func mustDecodeHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	Expect(err).To(BeNil())
+	return b
+}
+
+var _ = Describe("Threshold BLS", func() {
+	// a test vector from Ethereum's consensus specs, for the ciphersuite's sign and verify
+	sk := mustDecodeHex("263dbd792f5b1be47ed85f8938c0f29586af0d3ac7b977f21c278fe1462040e3")
+	pub := mustDecodeHex("a491d1b0ecd9bb917989f0e74f0dea0422eac4a873e5e2644f368dffb9a6e20fd6e10c1b77654d067c0618f6e5a7f79a")
+	message := make([]byte, 32)
+	expected := mustDecodeHex("b6ed936746e01f8ecf281f020953fbf1f01debd5657c4a383940b020b26507f6076334f91e2366c96e9ab279fb5158090352ea1c5b0c9274504f4f0e7053af24802e51e4568d164fe986834f41e55c8e850ce1f98458c0cfc9ab380b55285a55")
+	shares, splitErr := Split(rand.Reader, sk, 2, 3)
+
+	It("Verifies known signatures", func() {
+		p, err := PublicKey(sk)
+		Expect(err).To(BeNil())
+		Expect(p).To(Equal(pub))
+		Expect(Verify(pub, message, expected)).To(BeTrue())
+		Expect(Verify(pub, []byte("OTHER MESSAGE"), expected)).To(BeFalse())
+	})
+
+	It("Combines any t partial signatures into the same signature as the whole key", func() {
+		Expect(splitErr).To(BeNil())
+		Expect(shares[0].Group.PublicKey).To(Equal(pub))
+
+		partials := make([]*PartialSignature, len(shares))
+		for i, share := range shares {
+			var err error
+			partials[i], err = Sign(share, message)
+			Expect(err).To(BeNil())
+			Expect(VerifyPartial(share.Group, message, partials[i])).To(BeNil())
+		}
+		for _, pair := range [][]*PartialSignature{{partials[0], partials[1]}, {partials[2], partials[0]}} {
+			sig, err := Combine(shares[0].Group, message, pair...)
+			Expect(err).To(BeNil())
+			Expect(sig).To(Equal(expected))
+		}
+	})
+
+	It("Names the signers of bad partial signatures", func() {
+		good, err := Sign(shares[0], message)
+		Expect(err).To(BeNil())
+		secret := new(bls12381.Scalar)
+		secret.Add(shares[1].Secret, scalarFromInt(1))
+		bad, err := Sign(&KeyShare{Group: shares[1].Group, Index: 2, Secret: secret}, message)
+		Expect(err).To(BeNil())
+
+		var misbehaving *keysplitting.MisbehavingSignersError
+		Expect(errors.As(VerifyPartial(shares[1].Group, message, bad), &misbehaving)).To(BeTrue())
+		_, err = Combine(shares[0].Group, message, good, bad)
+		Expect(errors.As(err, &misbehaving)).To(BeTrue())
+		Expect(misbehaving.Signers).To(Equal([]int{2}))
+	})
+
+	It("Refuses to combine too few or duplicate partial signatures", func() {
+		partial, err := Sign(shares[0], message)
+		Expect(err).To(BeNil())
+		_, err = Combine(shares[0].Group, message, partial)
+		Expect(errors.Is(err, keysplitting.ErrTooFewShards)).To(BeTrue())
+		_, err = Combine(shares[0].Group, message, partial, partial)
+		Expect(errors.Is(err, keysplitting.ErrInvalidPartialSignature)).To(BeTrue())
+	})
+
+	It("Rejects invalid keys and thresholds", func() {
+		_, err := Split(rand.Reader, make([]byte, SecretKeySize), 2, 3)
+		Expect(errors.Is(err, keysplitting.ErrInvalidShard)).To(BeTrue())
+		_, err = Split(rand.Reader, sk, 1, 3)
+		Expect(errors.Is(err, keysplitting.ErrTooFewShards)).To(BeTrue())
+		_, err = Split(rand.Reader, sk, 4, 3)
+		Expect(errors.Is(err, keysplitting.ErrTooFewShards)).To(BeTrue())
+	})
+})
@@ -0,0 +1,69 @@
+package bls
+
+import (
+	"fmt"
+
+	"github.com/bastionzero/keysplitting"
+	"github.com/cloudflare/circl/ecc/bls12381"
+)
+
+// Points, scalars and pairings are those of github.com/cloudflare/circl/ecc/bls12381, whose scalar multiplication and field
+// arithmetic are constant time, so that neither the shares nor the secret key leak through timing. Messages are hashed to G2
+// with its hash_to_curve, which follows RFC 9380
+
+// the sizes of compressed G1 and G2 points
+const (
+	g1Size = bls12381.G1SizeCompressed
+	g2Size = bls12381.G2SizeCompressed
+)
+
+// the flags in the first byte of a compressed point
+const (
+	flagCompressed = 0x80
+	flagInfinity   = 0x40
+)
+
+// returns k * G1, the public key of k
+func g1Mul(k *bls12381.Scalar) *bls12381.G1 {
+	p := new(bls12381.G1)
+	p.ScalarMult(k, bls12381.G1Generator())
+	return p
+}
+
+// decodes a compressed G1 point, which must be in the prime-order subgroup and not the point at infinity
+func decodeG1(b []byte) (*bls12381.G1, error) {
+	if len(b) != g1Size || b[0]&(flagCompressed|flagInfinity) != flagCompressed {
+		return nil, fmt.Errorf("%w: invalid compressed G1 point", keysplitting.ErrKeyMismatch)
+	}
+	p := new(bls12381.G1)
+	if err := p.SetBytes(b); err != nil {
+		return nil, fmt.Errorf("%w: invalid G1 point: %s", keysplitting.ErrKeyMismatch, err)
+	}
+	return p, nil
+}
+
+// decodes a compressed G2 point, which must be in the prime-order subgroup and not the point at infinity
+func decodeG2(b []byte) (*bls12381.G2, error) {
+	if len(b) != g2Size || b[0]&(flagCompressed|flagInfinity) != flagCompressed {
+		return nil, fmt.Errorf("%w: invalid compressed G2 point", keysplitting.ErrInvalidPartialSignature)
+	}
+	p := new(bls12381.G2)
+	if err := p.SetBytes(b); err != nil {
+		return nil, fmt.Errorf("%w: invalid G2 point: %s", keysplitting.ErrInvalidPartialSignature, err)
+	}
+	return p, nil
+}
+
+// hashes msg to G2 with the given domain separation tag (RFC 9380, section 3)
+func hashToG2(msg, dst []byte) *bls12381.G2 {
+	p := new(bls12381.G2)
+	p.Hash(msg, dst)
+	return p
+}
+
+// returns the scalar x, for a share's index
+func scalarFromInt(x int) *bls12381.Scalar {
+	s := new(bls12381.Scalar)
+	s.SetUint64(uint64(x))
+	return s
+}
@@ -0,0 +1,51 @@
+package bls
+
+import (
+	"crypto/rand"
+
+	"github.com/cloudflare/circl/ecc/bls12381"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Curves", func() {
+	It("Decodes what it encodes", func() {
+		k := new(bls12381.Scalar)
+		Expect(k.Random(rand.Reader)).To(Succeed())
+
+		p1 := g1Mul(k)
+		decoded1, err := decodeG1(p1.BytesCompressed())
+		Expect(err).To(BeNil())
+		Expect(decoded1.IsEqual(p1)).To(BeTrue())
+
+		p2 := hashToG2([]byte("TEST MESSAGE"), []byte(DST))
+		p2.ScalarMult(k, p2)
+		decoded2, err := decodeG2(p2.BytesCompressed())
+		Expect(err).To(BeNil())
+		Expect(decoded2.IsEqual(p2)).To(BeTrue())
+	})
+
+	It("Rejects the point at infinity and uncompressed points", func() {
+		identity1 := new(bls12381.G1)
+		identity1.SetIdentity()
+		_, err := decodeG1(identity1.BytesCompressed())
+		Expect(err).ToNot(BeNil())
+		identity2 := new(bls12381.G2)
+		identity2.SetIdentity()
+		_, err = decodeG2(identity2.BytesCompressed())
+		Expect(err).ToNot(BeNil())
+
+		_, err = decodeG1(bls12381.G1Generator().Bytes())
+		Expect(err).ToNot(BeNil())
+		_, err = decodeG2(bls12381.G2Generator().Bytes())
+		Expect(err).ToNot(BeNil())
+	})
+
+	It("Rejects points outside G1", func() {
+		// the generator's x coordinate with its lowest bit flipped is not that of a point of G1
+		b := bls12381.G1Generator().BytesCompressed()
+		b[g1Size-1] ^= 1
+		_, err := decodeG1(b)
+		Expect(err).ToNot(BeNil())
+	})
+})
@@ -0,0 +1,29 @@
+package bls
+
+import (
+	"math/big"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Hash to G2", func() {
+	It("Hashes to the points of RFC 9380's test vectors", func() {
+		// RFC 9380, appendix J.10.1, msg = ""
+		p := hashToG2([]byte{}, []byte("QUUX-V01-CS02-with-BLS12381G2_XMD:SHA-256_SSWU_RO_"))
+
+		// the uncompressed encoding is x.c1 || x.c0 || y.c1 || y.c0
+		var expected []byte
+		for _, s := range []string{
+			"5cb8437535e20ecffaef7752baddf98034139c38452458baeefab379ba13dff5bf5dd71b72418717047f5b0f37da03d",
+			"141ebfbdca40eb85b87142e130ab689c673cf60f1a3e98d69335266f30d9b8d4ac44c1038e9dcdd5393faf5c41fb78a",
+			"12424ac32561493f3fe3c260708a12b7c620e7be00099a974e259ddc7d1f6395c3c811cdd19f1e8dbf3e9ecfdcbab8d6",
+			"503921d7f6a12805e72940b963c0cf3471c7b2a524950ca195d11062ee75ec076daf2d4bc358c4b190c0c98064fdd92",
+		} {
+			x, _ := new(big.Int).SetString(s, 16)
+			expected = append(expected, x.FillBytes(make([]byte, 48))...)
+		}
+		Expect(p.Bytes()).To(Equal(expected))
+		Expect(p.IsOnG2()).To(BeTrue())
+	})
+})
@@ -0,0 +1,195 @@
+package keysplitting
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"math/big"
+	"sync"
+)
+
+// A Broker collects the partial signatures of the holders of additive shards as they arrive, and combines them once every
+// shard has signed, as with [CombinePartialSignatures]. It is safe for concurrent use, so each holder's partial signature
+// can be added from its own goroutine, e.g. as it is received over the network. A Broker is used for a single signature,
+// which is identified by a session nonce that every partial signature must be bound to (see [Broker.SignerOpts])
+type Broker struct {
+	pub   *rsa.PublicKey
+	keyID string
+	k     int
+	nonce []byte
+
+	mu       sync.Mutex
+	logger   Logger
+	policy   *QuorumPolicy
+	proofs   *ShardCommitments
+	opts     crypto.SignerOpts
+	hashed   []byte
+	hash     crypto.Hash
+	partials []*PartialSignature
+	sig      []byte
+	done     chan struct{}
+}
+
+// NewBroker returns a Broker that expects partial signatures from k additive shards of pub
+func NewBroker(pub *rsa.PublicKey, k int) (*Broker, error) {
+	if k < 2 {
+		return nil, errorf(ErrTooFewShards, "cannot combine fewer than 2 partial signatures")
+	}
+	nonce, err := NewNonce(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return &Broker{pub: pub, keyID: PublicKeyID(pub), k: k, nonce: nonce, logger: NopLogger{}, done: make(chan struct{})}, nil
+}
+
+// SetLogger sets the [Logger] that the broker reports each partial signature it accepts or rejects to. A nil logger, the
+// default, discards them
+func (b *Broker) SetLogger(logger Logger) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.logger = loggerOrNop(logger)
+}
+
+// Nonce returns the session nonce that identifies this signature, which the broker sends to each shard holder along with the
+// digest to sign
+func (b *Broker) Nonce() []byte {
+	return append([]byte{}, b.nonce...)
+}
+
+// SetPolicy makes the broker refuse to complete the signature unless the shards that signed satisfy policy. The partial
+// signature that would have completed it is rejected with [ErrPolicyNotSatisfied], and the broker keeps waiting
+func (b *Broker) SetPolicy(policy *QuorumPolicy) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.policy = policy
+}
+
+// SetCommitments makes the broker check the proof attached to each partial signature against commitments, as with
+// [VerifyPartialSignature], before accepting it. A partial signature without a valid proof is rejected with a
+// *[MisbehavingSignersError] naming its shard. opts and hashed are those the shard holders are asked to sign
+func (b *Broker) SetCommitments(commitments *ShardCommitments, opts crypto.SignerOpts, hashed []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.proofs = commitments
+	b.opts = opts
+	b.hashed = append([]byte(nil), hashed...)
+}
+
+// SignerOpts returns opts bound to the broker's session nonce, for the shard holders to sign with
+func (b *Broker) SignerOpts(opts crypto.SignerOpts) *NonceOptions {
+	return &NonceOptions{Opts: opts, Nonce: b.Nonce()}
+}
+
+// AddPartial adds a partial signature. Once the last expected partial signature has been added, AddPartial combines them
+// and returns the complete signature; until then it returns nil. It fails with [ErrUnsupportedSplitBy] if partialSig isn't
+// from an additive shard, and [ErrInvalidPartialSignature] if it was produced with a different hash function than the others,
+// isn't bound to the broker's session nonce, comes from a shard that has already signed, or arrives after the signature is complete
+func (b *Broker) AddPartial(partialSig *PartialSignature) (sig []byte, err error) {
+	b.mu.Lock()
+	logger, proofs, opts, hashed := b.logger, b.proofs, b.opts, b.hashed
+	b.mu.Unlock()
+	defer func() {
+		switch {
+		case err != nil:
+			logger.Warn("rejected partial signature", "key_id", b.keyID, "signers", partialSig.Signers, "error", err)
+		case sig != nil:
+			logger.Info("combined partial signatures", "key_id", b.keyID, "shards", b.k)
+		default:
+			logger.Debug("added partial signature", "key_id", b.keyID, "signers", partialSig.Signers)
+		}
+	}()
+
+	if partialSig.SplitBy != Addition {
+		return nil, errorf(ErrUnsupportedSplitBy, "only partial signatures from additive shards can be combined")
+	}
+	if err := partialSig.checkNonce(b.nonce); err != nil {
+		return nil, err
+	}
+	if partialInt := new(big.Int).SetBytes(partialSig.Sig); partialInt.Sign() == 0 || partialInt.Cmp(b.pub.N) >= 0 {
+		return nil, errorf(ErrInvalidPartialSignature, "partial signature is out of range for the public key")
+	}
+	if proofs != nil {
+		if len(partialSig.Signers) != 1 || proofs.verify(opts, hashed, partialSig) != nil {
+			return nil, &MisbehavingSignersError{Signers: append([]int{}, partialSig.Signers...)}
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.sig != nil {
+		return nil, errorf(ErrInvalidPartialSignature, "all %d partial signatures have already been combined", b.k)
+	}
+	if len(b.partials) > 0 && partialSig.Hash != b.hash {
+		return nil, errorf(ErrInvalidPartialSignature, "partial signature was produced with a different hash function")
+	}
+	for _, partial := range b.partials {
+		for _, signer := range partialSig.Signers {
+			if partial.signedBy(signer) {
+				return nil, errorf(ErrInvalidPartialSignature, "shard %d has already signed", signer)
+			}
+		}
+		// partial signatures from shards without indices can still be recognized if they are resent
+		if bytes.Equal(partial.Sig, partialSig.Sig) {
+			return nil, errorf(ErrInvalidPartialSignature, "partial signature has already been added")
+		}
+	}
+
+	b.hash = partialSig.Hash
+	b.partials = append(b.partials, partialSig)
+	if len(b.partials) < b.k {
+		return nil, nil
+	}
+
+	if b.policy != nil {
+		sig, err = CombinePartialSignaturesWithPolicy(b.pub, b.policy, b.partials...)
+	} else {
+		sig, err = CombinePartialSignatures(b.pub, b.partials...)
+	}
+	if err == nil && proofs != nil {
+		sig, err = correctSign(b.pub, opts, hashed, sig)
+	}
+	if err != nil {
+		// leave the broker as it was, so that a valid partial signature can still complete it
+		b.partials = b.partials[:len(b.partials)-1]
+		return nil, err
+	}
+	b.sig = sig
+	close(b.done)
+	return sig, nil
+}
+
+// Signature returns the complete signature, or nil if some partial signatures are still missing
+func (b *Broker) Signature() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.sig
+}
+
+// Signers returns the indices of the shards that have signed so far
+func (b *Broker) Signers() []int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	signers := []int{}
+	for _, partial := range b.partials {
+		signers = append(signers, partial.Signers...)
+	}
+	return signers
+}
+
+// Wait blocks until every partial signature has been added and returns the complete signature, or returns ctx.Err() if ctx
+// is done first
+func (b *Broker) Wait(ctx context.Context) (sig []byte, err error) {
+	_, span := StartSpan(ctx, "keysplitting.Broker.Wait", append(keyAttributes(b.pub, Addition, 0), TraceAttribute{Key: TraceShardCount, Value: b.k})...)
+	defer func() { span.End(err) }()
+
+	select {
+	case <-b.done:
+		return b.Signature(), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
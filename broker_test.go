@@ -0,0 +1,153 @@
+package keysplitting
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Broker", func() {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	hashed := sha256.Sum256([]byte("TEST MESSAGE"))
+	shards, _ := SplitD(key, 5, Addition)
+
+	signAll := func(broker *Broker) []*PartialSignature {
+		partials := make([]*PartialSignature, len(shards))
+		for i, shard := range shards {
+			partials[i], _ = SignFirst(rand.Reader, shard, broker.SignerOpts(crypto.SHA256), hashed[:])
+		}
+		return partials
+	}
+
+	It("Combines partial signatures added concurrently", func() {
+		broker, err := NewBroker(&key.PublicKey, len(shards))
+		Expect(err).To(BeNil())
+		partials := signAll(broker)
+
+		var wg sync.WaitGroup
+		results := make(chan []byte, len(partials))
+		for _, partial := range partials {
+			wg.Add(1)
+			go func(partial *PartialSignature) {
+				defer wg.Done()
+				defer GinkgoRecover()
+				sig, err := broker.AddPartial(partial)
+				Expect(err).To(BeNil())
+				results <- sig
+			}(partial)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		sig, err := broker.Wait(ctx)
+		Expect(err).To(BeNil())
+		Expect(rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, hashed[:], sig)).To(Succeed())
+
+		// exactly one AddPartial call returns the signature
+		wg.Wait()
+		close(results)
+		var completed int
+		for result := range results {
+			if result != nil {
+				Expect(result).To(Equal(sig))
+				completed++
+			}
+		}
+		Expect(completed).To(Equal(1))
+		Expect(broker.Signers()).To(ConsistOf(1, 2, 3, 4, 5))
+	})
+
+	It("Rejects duplicates and mismatches", func() {
+		broker, err := NewBroker(&key.PublicKey, len(shards))
+		Expect(err).To(BeNil())
+		partials := signAll(broker)
+		_, err = broker.AddPartial(partials[0])
+		Expect(err).To(BeNil())
+
+		_, err = broker.AddPartial(partials[0])
+		Expect(errors.Is(err, ErrInvalidPartialSignature)).To(BeTrue())
+
+		anonymous := *partials[0]
+		anonymous.Signers = []int{0}
+		_, err = broker.AddPartial(&anonymous)
+		Expect(errors.Is(err, ErrInvalidPartialSignature)).To(BeTrue())
+
+		otherHash := sha256.Sum224([]byte("TEST MESSAGE"))
+		other, err := SignFirst(rand.Reader, shards[1], broker.SignerOpts(crypto.SHA224), otherHash[:])
+		Expect(err).To(BeNil())
+		_, err = broker.AddPartial(other)
+		Expect(errors.Is(err, ErrInvalidPartialSignature)).To(BeTrue())
+
+		multiplicative := *partials[1]
+		multiplicative.SplitBy = Multiplication
+		_, err = broker.AddPartial(&multiplicative)
+		Expect(errors.Is(err, ErrUnsupportedSplitBy)).To(BeTrue())
+		Expect(broker.Signature()).To(BeNil())
+	})
+
+	It("Refuses partial signatures from another session", func() {
+		broker, err := NewBroker(&key.PublicKey, len(shards))
+		Expect(err).To(BeNil())
+		other, err := NewBroker(&key.PublicKey, len(shards))
+		Expect(err).To(BeNil())
+		Expect(broker.Nonce()).NotTo(Equal(other.Nonce()))
+
+		partials := signAll(broker)
+		replayed := signAll(other)
+		for _, partial := range partials[:len(partials)-1] {
+			_, err := broker.AddPartial(partial)
+			Expect(err).To(BeNil())
+		}
+
+		_, err = broker.AddPartial(replayed[len(replayed)-1])
+		Expect(errors.Is(err, ErrInvalidPartialSignature)).To(BeTrue())
+
+		// changing the recorded nonce doesn't help, since the partial signature itself is bound to the other session
+		relabeled := *replayed[len(replayed)-1]
+		relabeled.Nonce = broker.Nonce()
+		sig, err := broker.AddPartial(&relabeled)
+		Expect(err).To(BeNil())
+		Expect(rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, hashed[:], sig)).NotTo(Succeed())
+
+		// unbound partial signatures are refused as well
+		unbound, err := SignFirst(rand.Reader, shards[0], crypto.SHA256, hashed[:])
+		Expect(err).To(BeNil())
+		_, err = other.AddPartial(unbound)
+		Expect(errors.Is(err, ErrInvalidPartialSignature)).To(BeTrue())
+	})
+
+	It("Refuses partial signatures once complete", func() {
+		broker, err := NewBroker(&key.PublicKey, 2)
+		Expect(err).To(BeNil())
+		partials := signAll(broker)
+		_, err = broker.AddPartial(partials[0])
+		Expect(err).To(BeNil())
+		sig, err := broker.AddPartial(partials[1])
+		Expect(err).To(BeNil())
+		Expect(sig).NotTo(BeNil())
+
+		_, err = broker.AddPartial(partials[2])
+		Expect(errors.Is(err, ErrInvalidPartialSignature)).To(BeTrue())
+	})
+
+	It("Stops waiting when the context is done", func() {
+		broker, err := NewBroker(&key.PublicKey, 2)
+		Expect(err).To(BeNil())
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		_, err = broker.Wait(ctx)
+		Expect(errors.Is(err, context.DeadlineExceeded)).To(BeTrue())
+
+		_, err = NewBroker(&key.PublicKey, 1)
+		Expect(errors.Is(err, ErrTooFewShards)).To(BeTrue())
+	})
+})
@@ -0,0 +1,467 @@
+/*
+Package brokerd implements an HTTP server that coordinates brokered signatures with additively split keys, as in the
+additive-brokered example. A client creates a signing session for a digest, each shard holder signs it with
+keysplitting.SignFirst, bound to the session's nonce with keysplitting.NonceOptions, and submits their partial signature.
+Once every shard has signed, the server combines them with
+keysplitting.CombinePartialSignatures and verifies the result. The server never holds a shard, only the public keys it
+accepts sessions for. If it is also given the public commitments to the shards with [Server.SetCommitments], and the holders
+prove their partial signatures with keysplitting.ShardCommitments.Prove, a session whose signature doesn't verify lists the
+shards whose partial signatures aren't proven in its "misbehaving" field.
+
+The API uses JSON throughout, with binary values in base64url without padding:
+
+	POST /sessions                  creates a session from {"key_id", "hash", "digest", "pss_salt", "shards"}
+	GET  /sessions                  lists the sessions
+	GET  /sessions/{id}             returns a session, including the "nonce" that partial signatures must be bound to
+	POST /sessions/{id}/partials    submits a partial signature, in the format of keysplitting.PartialSignature.MarshalJSON
+	GET  /sessions/{id}/signature   returns {"signature"} once the session is complete
+
+The server doesn't authenticate anyone, so it should sit behind something that does
+*/
+package brokerd
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bastionzero/keysplitting"
+)
+
+// the states of a session
+const (
+	StatusPending  = "pending"  // waiting for partial signatures
+	StatusComplete = "complete" // every shard has signed, and the signature verifies
+	StatusFailed   = "failed"   // every shard has signed, but the partial signatures could not be combined into a valid signature
+)
+
+// the largest request body the server will read
+const maxRequestSize = 1 << 20
+
+// Options configures a [Server]. The zero value, or nil, selects the defaults
+type Options struct {
+	// SessionTTL is how long a session is kept after it is created. The default is 10 minutes
+	SessionTTL time.Duration
+	// MaxSessions limits the number of sessions kept at once. The default is 1000
+	MaxSessions int
+	// Metrics, if set, counts the pending sessions as active sessions. It is usually the same Metrics given to
+	// keysplitting.SetMetrics, which measures the signatures themselves
+	Metrics keysplitting.Metrics
+	// Logger, if set, is told when sessions are created, completed, failed or expired, and about partial signatures that are
+	// rejected
+	Logger keysplitting.Logger
+}
+
+// A Server coordinates brokered signing sessions over HTTP. It is safe for concurrent use
+type Server struct {
+	opts Options
+	now  func() time.Time
+
+	mu          sync.Mutex
+	keys        map[string]*rsa.PublicKey
+	commitments map[string]*keysplitting.ShardCommitments
+	sessions    map[string]*session
+}
+
+// a signing session, which collects one partial signature from each shard
+type session struct {
+	id          string
+	keyID       string
+	pub         *rsa.PublicKey
+	hash        crypto.Hash
+	digest      []byte
+	pssSalt     []byte
+	nonce       []byte
+	shards      int
+	commitments *keysplitting.ShardCommitments
+	partials    []*keysplitting.PartialSignature
+	status      string
+	err         string
+	signature   []byte
+	created     time.Time
+
+	misbehaving []int // the shards whose partial signatures are invalid, if the session failed and they could be identified
+}
+
+// used exclusively as a placeholder for encoding-decoding
+type createRequest struct {
+	KeyID   string `json:"key_id"`
+	Hash    string `json:"hash"`
+	Digest  string `json:"digest"`
+	PSSSalt string `json:"pss_salt,omitempty"`
+	Shards  int    `json:"shards"`
+}
+
+// used exclusively as a placeholder for encoding-decoding
+type sessionJSON struct {
+	ID      string `json:"id"`
+	KeyID   string `json:"key_id"`
+	Hash    string `json:"hash"`
+	Digest  string `json:"digest"`
+	PSSSalt string `json:"pss_salt,omitempty"`
+	Nonce   string `json:"nonce"`
+	Shards  int    `json:"shards"`
+	Signers []int  `json:"signers"`
+	Status  string `json:"status"`
+	Error   string `json:"error,omitempty"`
+	// Misbehaving lists the shards whose partial signatures are invalid, if the session failed and they could be identified
+	Misbehaving []int     `json:"misbehaving,omitempty"`
+	Signature   string    `json:"signature,omitempty"`
+	Created     time.Time `json:"created"`
+}
+
+// New returns a Server that doesn't yet accept sessions for any key (see [Server.AddKey])
+func New(opts *Options) *Server {
+	s := &Server{
+		now:         time.Now,
+		keys:        map[string]*rsa.PublicKey{},
+		commitments: map[string]*keysplitting.ShardCommitments{},
+		sessions:    map[string]*session{},
+	}
+	if opts != nil {
+		s.opts = *opts
+	}
+	if s.opts.SessionTTL <= 0 {
+		s.opts.SessionTTL = 10 * time.Minute
+	}
+	if s.opts.MaxSessions <= 0 {
+		s.opts.MaxSessions = 1000
+	}
+	if s.opts.Logger == nil {
+		s.opts.Logger = keysplitting.NopLogger{}
+	}
+	return s
+}
+
+// AddKey allows sessions to be created for pub, and returns the key ID that clients must use to refer to it, which is the
+// same as keysplitting.PrivateKeyShard.KeyID for its shards
+func (s *Server) AddKey(pub *rsa.PublicKey) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keyID := keysplitting.PublicKeyID(pub)
+	s.keys[keyID] = pub
+	return keyID
+}
+
+// SetCommitments gives the server the public commitments to the shards of a key, and adds the key if it hasn't been added
+// already, returning its key ID. If a session for the key fails, the server then reports which shards' partial signatures
+// aren't proven, in its "misbehaving" field
+func (s *Server) SetCommitments(commitments *keysplitting.ShardCommitments) string {
+	keyID := s.AddKey(commitments.PublicKey)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.commitments[keyID] = commitments
+	return keyID
+}
+
+// ServeHTTP implements [http.Handler]
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if parts[0] != "sessions" || len(parts) > 3 {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expire()
+
+	switch {
+	case len(parts) == 1 && r.Method == http.MethodPost:
+		s.create(w, r)
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		s.list(w)
+	case len(parts) == 2 && r.Method == http.MethodGet:
+		if sess := s.lookup(w, parts[1]); sess != nil {
+			writeJSON(w, http.StatusOK, sess.toJSON())
+		}
+	case len(parts) == 3 && parts[2] == "partials" && r.Method == http.MethodPost:
+		if sess := s.lookup(w, parts[1]); sess != nil {
+			s.submit(w, r, sess)
+		}
+	case len(parts) == 3 && parts[2] == "signature" && r.Method == http.MethodGet:
+		if sess := s.lookup(w, parts[1]); sess != nil {
+			if sess.status != StatusComplete {
+				writeError(w, http.StatusConflict, fmt.Sprintf("session is %s", sess.status))
+				return
+			}
+			writeJSON(w, http.StatusOK, map[string]string{"signature": encode(sess.signature)})
+		}
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (s *Server) create(w http.ResponseWriter, r *http.Request) {
+	var req createRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxRequestSize)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request: %s", err))
+		return
+	}
+
+	pub, ok := s.keys[req.KeyID]
+	if !ok {
+		writeError(w, http.StatusNotFound, "unknown key")
+		return
+	}
+	hashFn, ok := parseHash(req.Hash)
+	if !ok {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("unsupported hash function %q", req.Hash))
+		return
+	}
+	digest, err := base64.RawURLEncoding.DecodeString(req.Digest)
+	if err != nil || len(digest) != hashFn.Size() {
+		writeError(w, http.StatusBadRequest, "digest is malformed or the wrong length for the hash function")
+		return
+	}
+	pssSalt, err := base64.RawURLEncoding.DecodeString(req.PSSSalt)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "malformed PSS salt")
+		return
+	}
+	if req.Shards < 2 {
+		writeError(w, http.StatusBadRequest, "a session needs at least 2 shards")
+		return
+	}
+	if len(s.sessions) >= s.opts.MaxSessions {
+		s.opts.Logger.Warn("refused to create session", "key_id", req.KeyID, "error", "too many sessions")
+		writeError(w, http.StatusServiceUnavailable, "too many sessions")
+		return
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		s.opts.Logger.Error("failed to generate session ID", "error", err)
+		writeError(w, http.StatusInternalServerError, "failed to generate session ID")
+		return
+	}
+	nonce, err := keysplitting.NewNonce(rand.Reader)
+	if err != nil {
+		s.opts.Logger.Error("failed to generate session nonce", "error", err)
+		writeError(w, http.StatusInternalServerError, "failed to generate session nonce")
+		return
+	}
+	sess := &session{
+		id:          hex.EncodeToString(id),
+		keyID:       req.KeyID,
+		pub:         pub,
+		hash:        hashFn,
+		digest:      digest,
+		pssSalt:     pssSalt,
+		nonce:       nonce,
+		shards:      req.Shards,
+		commitments: s.commitments[req.KeyID],
+		status:      StatusPending,
+		created:     s.now(),
+	}
+	s.sessions[sess.id] = sess
+	s.addActiveSessions(1)
+	s.opts.Logger.Info("created session", "session", sess.id, "key_id", sess.keyID, "shards", sess.shards)
+	writeJSON(w, http.StatusCreated, sess.toJSON())
+}
+
+func (s *Server) list(w http.ResponseWriter) {
+	sessions := make([]sessionJSON, 0, len(s.sessions))
+	for _, sess := range s.sessions {
+		sessions = append(sessions, sess.toJSON())
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		if !sessions[i].Created.Equal(sessions[j].Created) {
+			return sessions[i].Created.Before(sessions[j].Created)
+		}
+		return sessions[i].ID < sessions[j].ID
+	})
+	writeJSON(w, http.StatusOK, map[string][]sessionJSON{"sessions": sessions})
+}
+
+// adds a partial signature to the session, and combines them once every shard has signed
+func (s *Server) submit(w http.ResponseWriter, r *http.Request, sess *session) {
+	if sess.status != StatusPending {
+		s.rejectPartial(w, sess, http.StatusConflict, fmt.Sprintf("session is %s", sess.status))
+		return
+	}
+
+	var partialSig keysplitting.PartialSignature
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxRequestSize)).Decode(&partialSig); err != nil {
+		s.rejectPartial(w, sess, http.StatusBadRequest, fmt.Sprintf("invalid partial signature: %s", err))
+		return
+	}
+	if partialSig.SplitBy != keysplitting.Addition {
+		s.rejectPartial(w, sess, http.StatusBadRequest, "only partial signatures from additive shards can be brokered")
+		return
+	}
+	if partialSig.Hash != sess.hash {
+		s.rejectPartial(w, sess, http.StatusBadRequest, "partial signature was produced with a different hash function")
+		return
+	}
+	if !bytes.Equal(partialSig.Nonce, sess.nonce) {
+		// e.g. a partial signature captured from another session for the same digest
+		s.rejectPartial(w, sess, http.StatusBadRequest, "partial signature is not bound to this session's nonce")
+		return
+	}
+	if len(partialSig.Sig) != sess.pub.Size() {
+		s.rejectPartial(w, sess, http.StatusBadRequest, "partial signature is the wrong length for the key")
+		return
+	}
+	for _, signer := range partialSig.Signers {
+		for _, signed := range sess.signers() {
+			if signer != 0 && signer == signed {
+				s.rejectPartial(w, sess, http.StatusConflict, fmt.Sprintf("shard %d has already signed", signer))
+				return
+			}
+		}
+	}
+
+	sess.partials = append(sess.partials, &partialSig)
+	if len(sess.partials) == sess.shards {
+		sess.combine()
+		s.addActiveSessions(-1)
+		if sess.status == StatusComplete {
+			s.opts.Logger.Info("completed session", "session", sess.id, "key_id", sess.keyID)
+		} else {
+			s.opts.Logger.Error("session failed", "session", sess.id, "key_id", sess.keyID, "misbehaving", sess.misbehaving, "error", sess.err)
+		}
+	} else {
+		s.opts.Logger.Debug("accepted partial signature", "session", sess.id, "signers", partialSig.Signers)
+	}
+	writeJSON(w, http.StatusOK, sess.toJSON())
+}
+
+// combines the partial signatures and checks the result
+func (sess *session) combine() {
+	sig, err := keysplitting.CombinePartialSignatures(sess.pub, sess.partials...)
+	if err == nil {
+		if len(sess.pssSalt) > 0 {
+			err = rsa.VerifyPSS(sess.pub, sess.hash, sess.digest, sig, &rsa.PSSOptions{SaltLength: len(sess.pssSalt)})
+		} else {
+			err = keysplitting.VerifyFinal(sess.pub, sess.hash, sess.digest, sig, keysplitting.Addition)
+		}
+	}
+	if err != nil {
+		// some shard signed something else, signed with the wrong shard, or misbehaved
+		sess.status, sess.err = StatusFailed, fmt.Sprintf("partial signatures do not combine into a valid signature: %s", err)
+		sess.diagnose()
+		return
+	}
+	sess.status, sess.signature = StatusComplete, sig
+}
+
+// identifies the shards whose partial signatures are invalid, if the session has the commitments to them
+func (sess *session) diagnose() {
+	if sess.commitments == nil {
+		return
+	}
+	var opts crypto.SignerOpts = sess.hash
+	if len(sess.pssSalt) > 0 {
+		opts = &keysplitting.PSSOptions{Hash: sess.hash, Salt: sess.pssSalt}
+	}
+	var misbehaving *keysplitting.MisbehavingSignersError
+	if err := keysplitting.DiagnosePartialSignatures(sess.commitments, opts, sess.digest, sess.partials...); errors.As(err, &misbehaving) {
+		sess.misbehaving = misbehaving.Signers
+		sess.err = fmt.Sprintf("%s: %s", sess.err, err)
+	}
+}
+
+// returns the indices of the shards that have signed
+func (sess *session) signers() []int {
+	signers := []int{}
+	for _, partial := range sess.partials {
+		signers = append(signers, partial.Signers...)
+	}
+	return signers
+}
+
+func (sess *session) toJSON() sessionJSON {
+	encoded := sessionJSON{
+		ID:      sess.id,
+		KeyID:   sess.keyID,
+		Hash:    sess.hash.String(),
+		Digest:  encode(sess.digest),
+		PSSSalt: encode(sess.pssSalt),
+		Nonce:   encode(sess.nonce),
+		Shards:  sess.shards,
+		Signers: sess.signers(),
+		Status:  sess.status,
+		Error:   sess.err,
+
+		Misbehaving: sess.misbehaving,
+		Created:     sess.created,
+	}
+	if sess.signature != nil {
+		encoded.Signature = encode(sess.signature)
+	}
+	return encoded
+}
+
+// returns the session with the given ID, or writes an error if there is none
+func (s *Server) lookup(w http.ResponseWriter, id string) *session {
+	sess, ok := s.sessions[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "unknown session")
+	}
+	return sess
+}
+
+// discards sessions older than the TTL
+func (s *Server) expire() {
+	for id, sess := range s.sessions {
+		if s.now().Sub(sess.created) > s.opts.SessionTTL {
+			delete(s.sessions, id)
+			if sess.status == StatusPending {
+				s.addActiveSessions(-1)
+				s.opts.Logger.Warn("session expired before every shard signed", "session", id, "key_id", sess.keyID, "signers", sess.signers())
+			}
+		}
+	}
+}
+
+// rejects a partial signature submitted to sess
+func (s *Server) rejectPartial(w http.ResponseWriter, sess *session, status int, msg string) {
+	s.opts.Logger.Warn("rejected partial signature", "session", sess.id, "key_id", sess.keyID, "error", msg)
+	writeError(w, status, msg)
+}
+
+// updates the number of pending sessions in the metrics, if there are any
+func (s *Server) addActiveSessions(delta int) {
+	if s.opts.Metrics != nil {
+		s.opts.Metrics.AddActiveSessions(delta)
+	}
+}
+
+// returns the hash function with the given name, as given by crypto.Hash.String
+func parseHash(name string) (crypto.Hash, bool) {
+	for hashFn := crypto.MD4; hashFn <= crypto.BLAKE2b_512; hashFn++ {
+		if hashFn.String() == name && hashFn.Available() {
+			return hashFn, true
+		}
+	}
+	return 0, false
+}
+
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
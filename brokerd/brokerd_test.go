@@ -0,0 +1,284 @@
+package brokerd
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bastionzero/keysplitting"
+	"github.com/bastionzero/keysplitting/prommetrics"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+func TestBrokerd(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Brokerd Suite")
+}
+
+var _ = Describe("Signing coordinator", func() {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	hashed := sha256.Sum256([]byte("TEST MESSAGE"))
+	var server *Server
+	var keyID string
+
+	BeforeEach(func() {
+		server = New(nil)
+		keyID = server.AddKey(&key.PublicKey)
+	})
+
+	// makes a request and decodes the JSON response into out
+	do := func(method, path string, body interface{}, out interface{}) int {
+		var reqBody bytes.Buffer
+		if body != nil {
+			Expect(json.NewEncoder(&reqBody).Encode(body)).To(Succeed())
+		}
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, httptest.NewRequest(method, path, &reqBody))
+		if out != nil {
+			Expect(json.Unmarshal(recorder.Body.Bytes(), out)).To(Succeed())
+		}
+		return recorder.Code
+	}
+
+	create := func(shards int, pssSalt []byte) sessionJSON {
+		var sess sessionJSON
+		Expect(do(http.MethodPost, "/sessions", createRequest{
+			KeyID:   keyID,
+			Hash:    crypto.SHA256.String(),
+			Digest:  base64.RawURLEncoding.EncodeToString(hashed[:]),
+			PSSSalt: base64.RawURLEncoding.EncodeToString(pssSalt),
+			Shards:  shards,
+		}, &sess)).To(Equal(http.StatusCreated))
+		Expect(sess.Status).To(Equal(StatusPending))
+		return sess
+	}
+
+	// returns opts bound to the session's nonce
+	bind := func(sess sessionJSON, opts crypto.SignerOpts) *keysplitting.NonceOptions {
+		nonce, err := base64.RawURLEncoding.DecodeString(sess.Nonce)
+		Expect(err).To(BeNil())
+		Expect(nonce).To(HaveLen(keysplitting.NonceSize))
+		return &keysplitting.NonceOptions{Opts: opts, Nonce: nonce}
+	}
+
+	It("Combines partial signatures into a valid signature", func() {
+		shards, err := keysplitting.SplitD(key, 3, keysplitting.Addition)
+		Expect(err).To(BeNil())
+		sess := create(3, nil)
+
+		for i, shard := range shards {
+			Expect(do(http.MethodGet, "/sessions/"+sess.ID+"/signature", nil, nil)).To(Equal(http.StatusConflict))
+
+			partialSig, err := keysplitting.SignFirst(rand.Reader, shard, bind(sess, crypto.SHA256), hashed[:])
+			Expect(err).To(BeNil())
+			Expect(do(http.MethodPost, "/sessions/"+sess.ID+"/partials", partialSig, &sess)).To(Equal(http.StatusOK))
+			Expect(sess.Signers).To(HaveLen(i + 1))
+		}
+		Expect(sess.Status).To(Equal(StatusComplete))
+
+		var result map[string]string
+		Expect(do(http.MethodGet, "/sessions/"+sess.ID+"/signature", nil, &result)).To(Equal(http.StatusOK))
+		sig, err := base64.RawURLEncoding.DecodeString(result["signature"])
+		Expect(err).To(BeNil())
+		Expect(rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, hashed[:], sig)).To(Succeed())
+	})
+
+	It("Combines PSS partial signatures", func() {
+		shards, err := keysplitting.SplitD(key, 2, keysplitting.Addition)
+		Expect(err).To(BeNil())
+		salt, err := keysplitting.NewPSSSalt(rand.Reader, &key.PublicKey, crypto.SHA256, nil)
+		Expect(err).To(BeNil())
+		sess := create(2, salt)
+
+		for _, shard := range shards {
+			partialSig, err := keysplitting.SignFirst(rand.Reader, shard, bind(sess, &keysplitting.PSSOptions{Hash: crypto.SHA256, Salt: salt}), hashed[:])
+			Expect(err).To(BeNil())
+			Expect(do(http.MethodPost, "/sessions/"+sess.ID+"/partials", partialSig, &sess)).To(Equal(http.StatusOK))
+		}
+		Expect(sess.Status).To(Equal(StatusComplete))
+	})
+
+	It("Rejects duplicate and mismatched partial signatures", func() {
+		shards, err := keysplitting.SplitD(key, 3, keysplitting.Addition)
+		Expect(err).To(BeNil())
+		sess := create(3, nil)
+
+		partialSig, err := keysplitting.SignFirst(rand.Reader, shards[0], bind(sess, crypto.SHA256), hashed[:])
+		Expect(err).To(BeNil())
+		Expect(do(http.MethodPost, "/sessions/"+sess.ID+"/partials", partialSig, nil)).To(Equal(http.StatusOK))
+		Expect(do(http.MethodPost, "/sessions/"+sess.ID+"/partials", partialSig, nil)).To(Equal(http.StatusConflict))
+
+		otherHash := sha256.Sum224([]byte("TEST MESSAGE"))
+		partialSig, err = keysplitting.SignFirst(rand.Reader, shards[1], bind(sess, crypto.SHA224), otherHash[:])
+		Expect(err).To(BeNil())
+		Expect(do(http.MethodPost, "/sessions/"+sess.ID+"/partials", partialSig, nil)).To(Equal(http.StatusBadRequest))
+	})
+
+	It("Rejects partial signatures replayed from another session", func() {
+		shards, err := keysplitting.SplitD(key, 2, keysplitting.Addition)
+		Expect(err).To(BeNil())
+		sess := create(2, nil)
+		other := create(2, nil)
+
+		partialSig, err := keysplitting.SignFirst(rand.Reader, shards[0], bind(other, crypto.SHA256), hashed[:])
+		Expect(err).To(BeNil())
+		Expect(do(http.MethodPost, "/sessions/"+other.ID+"/partials", partialSig, nil)).To(Equal(http.StatusOK))
+		Expect(do(http.MethodPost, "/sessions/"+sess.ID+"/partials", partialSig, nil)).To(Equal(http.StatusBadRequest))
+
+		partialSig, err = keysplitting.SignFirst(rand.Reader, shards[0], crypto.SHA256, hashed[:])
+		Expect(err).To(BeNil())
+		Expect(do(http.MethodPost, "/sessions/"+sess.ID+"/partials", partialSig, nil)).To(Equal(http.StatusBadRequest))
+	})
+
+	It("Fails a session whose partial signatures don't combine", func() {
+		shards, err := keysplitting.SplitD(key, 2, keysplitting.Addition)
+		Expect(err).To(BeNil())
+		sess := create(2, nil)
+
+		otherHashed := sha256.Sum256([]byte("OTHER MESSAGE"))
+		for i, shard := range shards {
+			digest := hashed[:]
+			if i == 1 {
+				digest = otherHashed[:]
+			}
+			partialSig, err := keysplitting.SignFirst(rand.Reader, shard, bind(sess, crypto.SHA256), digest)
+			Expect(err).To(BeNil())
+			Expect(do(http.MethodPost, "/sessions/"+sess.ID+"/partials", partialSig, &sess)).To(Equal(http.StatusOK))
+		}
+		Expect(sess.Status).To(Equal(StatusFailed))
+		Expect(sess.Error).NotTo(BeEmpty())
+		Expect(sess.Misbehaving).To(BeEmpty())
+		Expect(do(http.MethodGet, "/sessions/"+sess.ID+"/signature", nil, nil)).To(Equal(http.StatusConflict))
+	})
+
+	It("Names the shards whose partial signatures are invalid", func() {
+		shards, commitments, err := keysplitting.SplitDWithCommitments(key, 3, nil)
+		Expect(err).To(BeNil())
+		Expect(server.SetCommitments(commitments)).To(Equal(keyID))
+		sess := create(3, nil)
+
+		otherHashed := sha256.Sum256([]byte("OTHER MESSAGE"))
+		for i, shard := range shards {
+			digest := hashed[:]
+			if i == 1 {
+				digest = otherHashed[:]
+			}
+			partialSig, err := keysplitting.SignFirst(rand.Reader, shard, bind(sess, crypto.SHA256), digest)
+			Expect(err).To(BeNil())
+			Expect(commitments.Prove(rand.Reader, shard, bind(sess, crypto.SHA256), digest, partialSig)).To(Succeed())
+			Expect(do(http.MethodPost, "/sessions/"+sess.ID+"/partials", partialSig, &sess)).To(Equal(http.StatusOK))
+		}
+		Expect(sess.Status).To(Equal(StatusFailed))
+		Expect(sess.Misbehaving).To(Equal([]int{2}))
+	})
+
+	It("Lists and expires sessions", func() {
+		first := create(2, nil)
+		second := create(3, nil)
+
+		var list map[string][]sessionJSON
+		Expect(do(http.MethodGet, "/sessions", nil, &list)).To(Equal(http.StatusOK))
+		Expect(list["sessions"]).To(HaveLen(2))
+		Expect([]string{list["sessions"][0].ID, list["sessions"][1].ID}).To(ConsistOf(first.ID, second.ID))
+
+		server.now = func() time.Time { return time.Now().Add(time.Hour) }
+		Expect(do(http.MethodGet, "/sessions", nil, &list)).To(Equal(http.StatusOK))
+		Expect(list["sessions"]).To(BeEmpty())
+		Expect(do(http.MethodGet, "/sessions/"+first.ID, nil, nil)).To(Equal(http.StatusNotFound))
+	})
+
+	It("Counts pending sessions as active", func() {
+		metrics := prommetrics.New(nil)
+		server = New(&Options{Metrics: metrics})
+		keyID = server.AddKey(&key.PublicKey)
+		registry := prometheus.NewRegistry()
+		registry.MustRegister(metrics)
+		active := func() string {
+			recorder := httptest.NewRecorder()
+			promhttp.HandlerFor(registry, promhttp.HandlerOpts{}).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+			return recorder.Body.String()
+		}
+
+		shards, err := keysplitting.SplitD(key, 2, keysplitting.Addition)
+		Expect(err).To(BeNil())
+		sess := create(2, nil)
+		create(2, nil)
+		Expect(active()).To(ContainSubstring("keysplitting_active_sessions 2\n"))
+
+		for _, shard := range shards {
+			partialSig, err := keysplitting.SignFirst(rand.Reader, shard, bind(sess, crypto.SHA256), hashed[:])
+			Expect(err).To(BeNil())
+			Expect(do(http.MethodPost, "/sessions/"+sess.ID+"/partials", partialSig, &sess)).To(Equal(http.StatusOK))
+		}
+		Expect(sess.Status).To(Equal(StatusComplete))
+		Expect(active()).To(ContainSubstring("keysplitting_active_sessions 1\n"))
+
+		server.now = func() time.Time { return time.Now().Add(time.Hour) }
+		Expect(do(http.MethodGet, "/sessions", nil, nil)).To(Equal(http.StatusOK))
+		Expect(active()).To(ContainSubstring("keysplitting_active_sessions 0\n"))
+	})
+
+	It("Logs the lifecycle of each session", func() {
+		logger := &messageLogger{}
+		server = New(&Options{Logger: logger})
+		keyID = server.AddKey(&key.PublicKey)
+
+		shards, err := keysplitting.SplitD(key, 2, keysplitting.Addition)
+		Expect(err).To(BeNil())
+		sess := create(2, nil)
+		expiring := create(2, nil)
+		for _, shard := range shards {
+			partialSig, err := keysplitting.SignFirst(rand.Reader, shard, bind(sess, crypto.SHA256), hashed[:])
+			Expect(err).To(BeNil())
+			Expect(do(http.MethodPost, "/sessions/"+sess.ID+"/partials", partialSig, nil)).To(Equal(http.StatusOK))
+		}
+		partialSig, err := keysplitting.SignFirst(rand.Reader, shards[0], bind(sess, crypto.SHA256), hashed[:])
+		Expect(err).To(BeNil())
+		Expect(do(http.MethodPost, "/sessions/"+expiring.ID+"/partials", partialSig, nil)).To(Equal(http.StatusBadRequest))
+		server.now = func() time.Time { return time.Now().Add(time.Hour) }
+		Expect(do(http.MethodGet, "/sessions", nil, nil)).To(Equal(http.StatusOK))
+
+		Expect(logger.messages).To(Equal([]string{
+			"info: created session",
+			"info: created session",
+			"debug: accepted partial signature",
+			"info: completed session",
+			"warn: rejected partial signature",
+			"warn: session expired before every shard signed",
+		}))
+	})
+
+	It("Only creates sessions for known keys", func() {
+		Expect(do(http.MethodPost, "/sessions", createRequest{
+			KeyID:  "unknown",
+			Hash:   crypto.SHA256.String(),
+			Digest: base64.RawURLEncoding.EncodeToString(hashed[:]),
+			Shards: 2,
+		}, nil)).To(Equal(http.StatusNotFound))
+	})
+})
+
+// a keysplitting.Logger that keeps the level and text of every message
+type messageLogger struct {
+	messages []string
+}
+
+func (l *messageLogger) Debug(msg string, args ...interface{}) { l.log("debug", msg) }
+func (l *messageLogger) Info(msg string, args ...interface{})  { l.log("info", msg) }
+func (l *messageLogger) Warn(msg string, args ...interface{})  { l.log("warn", msg) }
+func (l *messageLogger) Error(msg string, args ...interface{}) { l.log("error", msg) }
+
+func (l *messageLogger) log(level, msg string) {
+	l.messages = append(l.messages, level+": "+msg)
+}
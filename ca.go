@@ -0,0 +1,226 @@
+package keysplitting
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+	"io"
+	"math/big"
+	"time"
+)
+
+// A certificate authority whose key is split can also run the rest of its lifecycle under multi-party control. Certificates
+// are issued with [x509.CreateCertificate] and a split-key [crypto.Signer] such as a [RemoteSigner], while revocation lists are
+// signed with [SignCRL] and OCSP responses with [SignOCSPResponse]
+
+// SignCRL returns the DER encoding of the certificate revocation list described by template, signed by signer on behalf of
+// issuer as with [x509.CreateRevocationList]. signer is typically a [RemoteSigner] for the split key, and must have the
+// issuer's public key
+func SignCRL(random io.Reader, template *x509.RevocationList, issuer *x509.Certificate, signer crypto.Signer) ([]byte, error) {
+	if err := checkIssuerKey(issuer, signer); err != nil {
+		return nil, err
+	}
+	return x509.CreateRevocationList(random, template, issuer, signer)
+}
+
+// OCSPStatus is the status of a certificate in an OCSP response, as in RFC 6960
+type OCSPStatus int
+
+const (
+	OCSPGood OCSPStatus = iota
+	OCSPRevoked
+	OCSPUnknown
+)
+
+// An OCSPResponse describes the status of a single certificate, to be signed with [SignOCSPResponse]
+type OCSPResponse struct {
+	SerialNumber     *big.Int    // the serial number of the certificate
+	Status           OCSPStatus  // the status of the certificate
+	RevokedAt        time.Time   // when the certificate was revoked, if it was
+	RevocationReason int         // the CRL reason code for the revocation, e.g. 1 for keyCompromise. 0, unspecified, is omitted
+	ThisUpdate       time.Time   // when the status was known to be correct
+	NextUpdate       time.Time   // when newer information will be available, if set
+	Hash             crypto.Hash // the hash function to sign with, one of SHA-256, SHA-384 or SHA-512. SHA-256 if unset
+}
+
+var (
+	oidSHA1                = asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}
+	oidOCSPBasic           = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 1}
+	oidSignatureSHA256RSA  = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 11}
+	oidSignatureSHA384RSA  = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 12}
+	oidSignatureSHA512RSA  = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 13}
+	ocspSignatureAlgorithm = map[crypto.Hash]struct {
+		oid asn1.ObjectIdentifier
+		x509.SignatureAlgorithm
+	}{
+		crypto.SHA256: {oidSignatureSHA256RSA, x509.SHA256WithRSA},
+		crypto.SHA384: {oidSignatureSHA384RSA, x509.SHA384WithRSA},
+		crypto.SHA512: {oidSignatureSHA512RSA, x509.SHA512WithRSA},
+	}
+)
+
+// used exclusively as a placeholder for encoding-decoding
+type ocspResponse struct {
+	Status asn1.Enumerated
+	Bytes  ocspResponseBytes `asn1:"explicit,tag:0"`
+}
+
+// used exclusively as a placeholder for encoding-decoding
+type ocspResponseBytes struct {
+	Type     asn1.ObjectIdentifier
+	Response []byte
+}
+
+// used exclusively as a placeholder for encoding-decoding
+type basicOCSPResponse struct {
+	TBSResponseData    asn1.RawValue
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          asn1.BitString
+	Certificates       []asn1.RawValue `asn1:"explicit,tag:0,optional"`
+}
+
+// used exclusively as a placeholder for encoding-decoding
+type ocspResponseData struct {
+	ResponderKeyHash []byte    `asn1:"explicit,tag:2"`
+	ProducedAt       time.Time `asn1:"generalized"`
+	Responses        []ocspSingleResponse
+}
+
+// used exclusively as a placeholder for encoding-decoding
+type ocspSingleResponse struct {
+	CertID     ocspCertID
+	Good       asn1.Flag       `asn1:"tag:0,optional"`
+	Revoked    ocspRevokedInfo `asn1:"tag:1,optional"`
+	Unknown    asn1.Flag       `asn1:"tag:2,optional"`
+	ThisUpdate time.Time       `asn1:"generalized"`
+	NextUpdate time.Time       `asn1:"generalized,explicit,tag:0,optional"`
+}
+
+// used exclusively as a placeholder for encoding-decoding
+type ocspCertID struct {
+	HashAlgorithm  pkix.AlgorithmIdentifier
+	IssuerNameHash []byte
+	IssuerKeyHash  []byte
+	SerialNumber   *big.Int
+}
+
+// used exclusively as a placeholder for encoding-decoding
+type ocspRevokedInfo struct {
+	RevocationTime time.Time       `asn1:"generalized"`
+	Reason         asn1.Enumerated `asn1:"explicit,tag:0,optional"`
+}
+
+// used exclusively as a placeholder for encoding-decoding
+type subjectPublicKeyInfo struct {
+	Algorithm pkix.AlgorithmIdentifier
+	PublicKey asn1.BitString
+}
+
+// SignOCSPResponse returns the DER encoding of a successful OCSP response, as in RFC 6960, giving the status of the certificate
+// issued by issuer, and signed by signer on behalf of responder. responder is either issuer itself or a delegated OCSP
+// responder certificate issued by it, which is then included in the response, and signer is typically a [RemoteSigner] for
+// the responder's split key. The response is checked against the responder's public key before it is returned
+func SignOCSPResponse(random io.Reader, issuer, responder *x509.Certificate, signer crypto.Signer, resp *OCSPResponse) ([]byte, error) {
+	if err := checkIssuerKey(responder, signer); err != nil {
+		return nil, err
+	}
+	hashFn := resp.Hash
+	if hashFn == 0 {
+		hashFn = crypto.SHA256
+	}
+	algorithm, ok := ocspSignatureAlgorithm[hashFn]
+	if !ok {
+		return nil, errorf(ErrUnsupportedHash, "OCSP responses can't be signed with %v", hashFn)
+	}
+
+	issuerKeyHash, err := publicKeyHash(issuer)
+	if err != nil {
+		return nil, err
+	}
+	responderKeyHash, err := publicKeyHash(responder)
+	if err != nil {
+		return nil, err
+	}
+	issuerNameHash := sha1.Sum(issuer.RawSubject)
+
+	single := ocspSingleResponse{
+		CertID: ocspCertID{
+			HashAlgorithm:  pkix.AlgorithmIdentifier{Algorithm: oidSHA1, Parameters: asn1.NullRawValue},
+			IssuerNameHash: issuerNameHash[:],
+			IssuerKeyHash:  issuerKeyHash,
+			SerialNumber:   resp.SerialNumber,
+		},
+		ThisUpdate: resp.ThisUpdate.UTC().Truncate(time.Second),
+	}
+	if !resp.NextUpdate.IsZero() {
+		single.NextUpdate = resp.NextUpdate.UTC().Truncate(time.Second)
+	}
+	switch resp.Status {
+	case OCSPGood:
+		single.Good = true
+	case OCSPRevoked:
+		single.Revoked = ocspRevokedInfo{RevocationTime: resp.RevokedAt.UTC().Truncate(time.Second), Reason: asn1.Enumerated(resp.RevocationReason)}
+	case OCSPUnknown:
+		single.Unknown = true
+	default:
+		return nil, fmt.Errorf("unrecognized OCSP status: %d", resp.Status)
+	}
+
+	tbs, err := asn1.Marshal(ocspResponseData{
+		ResponderKeyHash: responderKeyHash,
+		ProducedAt:       time.Now().UTC().Truncate(time.Second),
+		Responses:        []ocspSingleResponse{single},
+	})
+	if err != nil {
+		return nil, err
+	}
+	h := hashFn.New()
+	h.Write(tbs)
+	sig, err := signer.Sign(random, h.Sum(nil), hashFn)
+	if err != nil {
+		return nil, err
+	}
+	if err := responder.CheckSignature(algorithm.SignatureAlgorithm, tbs, sig); err != nil {
+		return nil, errorf(ErrIncompleteSignature, "OCSP response signature doesn't verify: %s", err)
+	}
+
+	basic := basicOCSPResponse{
+		TBSResponseData:    asn1.RawValue{FullBytes: tbs},
+		SignatureAlgorithm: pkix.AlgorithmIdentifier{Algorithm: algorithm.oid, Parameters: asn1.NullRawValue},
+		Signature:          asn1.BitString{Bytes: sig, BitLength: 8 * len(sig)},
+	}
+	if !responder.Equal(issuer) {
+		basic.Certificates = []asn1.RawValue{{FullBytes: responder.Raw}}
+	}
+	basicDER, err := asn1.Marshal(basic)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(ocspResponse{Bytes: ocspResponseBytes{Type: oidOCSPBasic, Response: basicDER}})
+}
+
+// returns an error unless signer has cert's RSA public key
+func checkIssuerKey(cert *x509.Certificate, signer crypto.Signer) error {
+	pub, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return errorf(ErrKeyMismatch, "certificate doesn't have an RSA public key")
+	}
+	if !pub.Equal(signer.Public()) {
+		return errorf(ErrKeyMismatch, "signer doesn't have the certificate's public key")
+	}
+	return nil
+}
+
+// returns the SHA-1 hash of the certificate's public key, as used to identify keys in OCSP
+func publicKeyHash(cert *x509.Certificate) ([]byte, error) {
+	var spki subjectPublicKeyInfo
+	if _, err := asn1.Unmarshal(cert.RawSubjectPublicKeyInfo, &spki); err != nil {
+		return nil, err
+	}
+	hash := sha1.Sum(spki.PublicKey.RightAlign())
+	return hash[:], nil
+}
@@ -0,0 +1,150 @@
+package keysplitting
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"math/big"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Split-key certificate authority", func() {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	responderKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	// returns a remote signer for a key split in three
+	splitSigner := func(key *rsa.PrivateKey) *RemoteSigner {
+		shards, err := SplitD(key, 3, Addition)
+		Expect(err).To(BeNil())
+		remotes := make([]RemoteShard, len(shards))
+		for i, shard := range shards {
+			remotes[i], err = NewLocalShard(shard)
+			Expect(err).To(BeNil())
+		}
+		signer, err := NewRemoteSigner(&key.PublicKey, remotes...)
+		Expect(err).To(BeNil())
+		return signer
+	}
+
+	signer := splitSigner(key)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Split CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		SubjectKeyId:          []byte{1, 2, 3, 4},
+	}
+	der, _ := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, signer)
+	issuer, _ := x509.ParseCertificate(der)
+
+	// parses an OCSP response, checks its signature against responder and returns its single response
+	parseOCSP := func(der []byte, responder *x509.Certificate, certs int) ocspSingleResponse {
+		var resp ocspResponse
+		_, err := asn1.Unmarshal(der, &resp)
+		Expect(err).To(BeNil())
+		Expect(resp.Status).To(Equal(asn1.Enumerated(0)))
+		Expect(resp.Bytes.Type).To(Equal(oidOCSPBasic))
+
+		var basic basicOCSPResponse
+		_, err = asn1.Unmarshal(resp.Bytes.Response, &basic)
+		Expect(err).To(BeNil())
+		Expect(basic.Certificates).To(HaveLen(certs))
+		Expect(responder.CheckSignature(x509.SHA256WithRSA, basic.TBSResponseData.FullBytes, basic.Signature.RightAlign())).To(Succeed())
+
+		var data ocspResponseData
+		_, err = asn1.Unmarshal(basic.TBSResponseData.FullBytes, &data)
+		Expect(err).To(BeNil())
+		Expect(data.Responses).To(HaveLen(1))
+		return data.Responses[0]
+	}
+
+	It("Signs a certificate revocation list", func() {
+		crl, err := SignCRL(rand.Reader, &x509.RevocationList{
+			Number:              big.NewInt(1),
+			ThisUpdate:          time.Now(),
+			NextUpdate:          time.Now().Add(time.Hour),
+			RevokedCertificates: []pkix.RevokedCertificate{{SerialNumber: big.NewInt(42), RevocationTime: time.Now()}},
+		}, issuer, signer)
+		Expect(err).To(BeNil())
+
+		parsed, err := x509.ParseCRL(crl)
+		Expect(err).To(BeNil())
+		Expect(issuer.CheckCRLSignature(parsed)).To(Succeed())
+		Expect(parsed.TBSCertList.RevokedCertificates[0].SerialNumber).To(Equal(big.NewInt(42)))
+	})
+
+	It("Signs OCSP responses for good and revoked certificates", func() {
+		single := parseOCSP(signed(SignOCSPResponse(rand.Reader, issuer, issuer, signer, &OCSPResponse{
+			SerialNumber: big.NewInt(7),
+			Status:       OCSPGood,
+			ThisUpdate:   time.Now(),
+			NextUpdate:   time.Now().Add(time.Hour),
+		})), issuer, 0)
+		Expect(single.Good).To(Equal(asn1.Flag(true)))
+		Expect(single.CertID.SerialNumber).To(Equal(big.NewInt(7)))
+		Expect(single.NextUpdate.IsZero()).To(BeFalse())
+
+		revokedAt := time.Now().Add(-time.Minute).UTC().Truncate(time.Second)
+		single = parseOCSP(signed(SignOCSPResponse(rand.Reader, issuer, issuer, signer, &OCSPResponse{
+			SerialNumber:     big.NewInt(42),
+			Status:           OCSPRevoked,
+			RevokedAt:        revokedAt,
+			RevocationReason: 1,
+			ThisUpdate:       time.Now(),
+		})), issuer, 0)
+		Expect(single.Good).To(Equal(asn1.Flag(false)))
+		Expect(single.Revoked.RevocationTime.Equal(revokedAt)).To(BeTrue())
+		Expect(single.Revoked.Reason).To(Equal(asn1.Enumerated(1)))
+		Expect(single.NextUpdate.IsZero()).To(BeTrue())
+	})
+
+	It("Includes a delegated responder's certificate", func() {
+		responderSigner := splitSigner(responderKey)
+		der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+			SerialNumber: big.NewInt(2),
+			Subject:      pkix.Name{CommonName: "Split OCSP responder"},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageOCSPSigning},
+		}, issuer, &responderKey.PublicKey, signer)
+		Expect(err).To(BeNil())
+		responder, err := x509.ParseCertificate(der)
+		Expect(err).To(BeNil())
+
+		single := parseOCSP(signed(SignOCSPResponse(rand.Reader, issuer, responder, responderSigner, &OCSPResponse{
+			SerialNumber: big.NewInt(7),
+			Status:       OCSPUnknown,
+			ThisUpdate:   time.Now(),
+		})), responder, 1)
+		Expect(single.Unknown).To(Equal(asn1.Flag(true)))
+
+		// the issuer's signer can't sign on behalf of the responder
+		_, err = SignOCSPResponse(rand.Reader, issuer, responder, signer, &OCSPResponse{SerialNumber: big.NewInt(7), ThisUpdate: time.Now()})
+		Expect(errors.Is(err, ErrKeyMismatch)).To(BeTrue())
+	})
+
+	It("Refuses signers for other keys and unsupported hash functions", func() {
+		_, err := SignCRL(rand.Reader, &x509.RevocationList{Number: big.NewInt(1)}, issuer, splitSigner(responderKey))
+		Expect(errors.Is(err, ErrKeyMismatch)).To(BeTrue())
+
+		_, err = SignOCSPResponse(rand.Reader, issuer, issuer, signer, &OCSPResponse{SerialNumber: big.NewInt(7), Hash: crypto.SHA1})
+		Expect(errors.Is(err, ErrUnsupportedHash)).To(BeTrue())
+	})
+})
+
+// returns the signed response, failing the test if signing failed
+func signed(response []byte, err error) []byte {
+	Expect(err).To(BeNil())
+	return response
+}
@@ -0,0 +1,215 @@
+/*
+Package cbor encodes shards and partial signatures in CBOR (RFC 8949), for deployments that exchange them over constrained
+links and want a compact binary format with a canonical encoding, so that the encoded structures can be signed or hashed
+directly:
+
+	encoded, err := cbor.MarshalShard(shard)
+	...
+	shard, err := cbor.UnmarshalShard(encoded)
+
+[Shard] and [PartialSignature] wrap the values so that they can be embedded in other CBOR structures. The encoding is done
+by github.com/fxamacker/cbor, which is why this is a separate package from keysplitting
+*/
+package cbor
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rsa"
+	"fmt"
+	"math/big"
+
+	"github.com/bastionzero/keysplitting"
+	fxcbor "github.com/fxamacker/cbor/v2"
+)
+
+// Values are encoded as maps with small unsigned integer keys, using the core deterministic encoding requirements of
+// section 4.2.1: integers and lengths are as short as possible, lengths are definite, and map keys appear in ascending
+// order. Integers that don't fit in 64 bits are encoded as bignums (tags 2 and 3). The decoder rejects anything else,
+// including unknown keys, so every value has exactly one encoding
+var encMode, decMode = func() (fxcbor.EncMode, fxcbor.DecMode) {
+	encMode, err := fxcbor.CoreDetEncOptions().EncMode()
+	if err != nil {
+		panic(err)
+	}
+	decMode, err := fxcbor.DecOptions{
+		DupMapKey:         fxcbor.DupMapKeyEnforcedAPF,
+		IndefLength:       fxcbor.IndefLengthForbidden,
+		ExtraReturnErrors: fxcbor.ExtraDecErrorUnknownField,
+	}.DecMode()
+	if err != nil {
+		panic(err)
+	}
+	return encMode, decMode
+}()
+
+// an encoded shard
+type cborShard struct {
+	SplitBy string   `cbor:"1,keyasint"`
+	N       *big.Int `cbor:"2,keyasint"`
+	E       int      `cbor:"3,keyasint"`
+	D       *big.Int `cbor:"4,keyasint"`
+	Index   int      `cbor:"5,keyasint,omitempty"`
+	Mask    *big.Int `cbor:"6,keyasint,omitempty"`
+}
+
+// an encoded partial signature
+type cborPartialSignature struct {
+	SplitBy string      `cbor:"1,keyasint"`
+	Hash    crypto.Hash `cbor:"2,keyasint,omitempty"`
+	Signers []int       `cbor:"3,keyasint"`
+	Sig     []byte      `cbor:"4,keyasint"`
+}
+
+// MarshalShard returns a deterministic CBOR encoding of the shard, which must pass [keysplitting.PrivateKeyShard.Validate]
+func MarshalShard(shard *keysplitting.PrivateKeyShard) ([]byte, error) {
+	if err := shard.Validate(); err != nil {
+		return nil, err
+	}
+
+	return encMode.Marshal(&cborShard{
+		SplitBy: string(shard.SplitBy),
+		N:       shard.PublicKey.N,
+		E:       shard.PublicKey.E,
+		D:       shard.D,
+		Index:   shard.Index,
+		Mask:    shard.Mask,
+	})
+}
+
+// UnmarshalShard decodes a shard encoded by [MarshalShard], rejecting any that fails
+// [keysplitting.PrivateKeyShard.Validate]
+func UnmarshalShard(data []byte) (*keysplitting.PrivateKeyShard, error) {
+	var decoded cborShard
+	if err := decMode.Unmarshal(data, &decoded); err != nil {
+		return nil, fmt.Errorf("%w: failed to decode CBOR private key shard: %s", keysplitting.ErrInvalidShard, err)
+	}
+	if err := checkDeterministic(data, &decoded); err != nil {
+		return nil, fmt.Errorf("%w: failed to decode CBOR private key shard: %s", keysplitting.ErrInvalidShard, err)
+	}
+
+	shard := &keysplitting.PrivateKeyShard{
+		PublicKey: &rsa.PublicKey{N: decoded.N, E: decoded.E},
+		SplitBy:   keysplitting.SplitBy(decoded.SplitBy),
+		D:         decoded.D,
+		Index:     decoded.Index,
+		Mask:      decoded.Mask,
+	}
+	if err := shard.Validate(); err != nil {
+		return nil, err
+	}
+	return shard, nil
+}
+
+// MarshalPartialSignature returns a deterministic CBOR encoding of the partial signature (see [MarshalShard])
+func MarshalPartialSignature(partialSig *keysplitting.PartialSignature) ([]byte, error) {
+	if err := checkSplitBy(partialSig.SplitBy); err != nil {
+		return nil, err
+	}
+	for _, signer := range partialSig.Signers {
+		if signer < 0 {
+			return nil, fmt.Errorf("%w: signer index %d is negative", keysplitting.ErrInvalidPartialSignature, signer)
+		}
+	}
+
+	return encMode.Marshal(&cborPartialSignature{
+		SplitBy: string(partialSig.SplitBy),
+		Hash:    partialSig.Hash,
+		// an empty array rather than null if there are no signers
+		Signers: append([]int{}, partialSig.Signers...),
+		Sig:     partialSig.Sig,
+	})
+}
+
+// UnmarshalPartialSignature decodes a partial signature encoded by [MarshalPartialSignature]
+func UnmarshalPartialSignature(data []byte) (*keysplitting.PartialSignature, error) {
+	var decoded cborPartialSignature
+	if err := decMode.Unmarshal(data, &decoded); err != nil {
+		return nil, fmt.Errorf("%w: failed to decode CBOR partial signature: %s", keysplitting.ErrInvalidPartialSignature, err)
+	}
+	if err := checkSplitBy(keysplitting.SplitBy(decoded.SplitBy)); err != nil {
+		return nil, err
+	}
+	if err := checkDeterministic(data, &decoded); err != nil {
+		return nil, fmt.Errorf("%w: failed to decode CBOR partial signature: %s", keysplitting.ErrInvalidPartialSignature, err)
+	}
+	for _, signer := range decoded.Signers {
+		if signer < 0 {
+			return nil, fmt.Errorf("%w: signer index %d is negative", keysplitting.ErrInvalidPartialSignature, signer)
+		}
+	}
+	if len(decoded.Sig) == 0 {
+		return nil, fmt.Errorf("%w: partial signature is empty", keysplitting.ErrInvalidPartialSignature)
+	}
+
+	return &keysplitting.PartialSignature{
+		SplitBy: keysplitting.SplitBy(decoded.SplitBy),
+		Hash:    decoded.Hash,
+		Signers: append([]int{}, decoded.Signers...),
+		Sig:     decoded.Sig,
+	}, nil
+}
+
+// Shard wraps a shard so that it can be embedded in other CBOR structures, with the method signatures expected by common Go
+// CBOR libraries
+type Shard struct {
+	*keysplitting.PrivateKeyShard
+}
+
+// MarshalCBOR encodes the shard as with [MarshalShard]
+func (s Shard) MarshalCBOR() ([]byte, error) {
+	return MarshalShard(s.PrivateKeyShard)
+}
+
+// UnmarshalCBOR decodes the shard as with [UnmarshalShard]
+func (s *Shard) UnmarshalCBOR(data []byte) error {
+	shard, err := UnmarshalShard(data)
+	if err != nil {
+		return err
+	}
+	s.PrivateKeyShard = shard
+	return nil
+}
+
+// PartialSignature wraps a partial signature in the same way as [Shard]
+type PartialSignature struct {
+	*keysplitting.PartialSignature
+}
+
+// MarshalCBOR encodes the partial signature as with [MarshalPartialSignature]
+func (ps PartialSignature) MarshalCBOR() ([]byte, error) {
+	return MarshalPartialSignature(ps.PartialSignature)
+}
+
+// UnmarshalCBOR decodes the partial signature as with [UnmarshalPartialSignature]
+func (ps *PartialSignature) UnmarshalCBOR(data []byte) error {
+	partialSig, err := UnmarshalPartialSignature(data)
+	if err != nil {
+		return err
+	}
+	ps.PartialSignature = partialSig
+	return nil
+}
+
+// returns an error unless splitBy is one of the split algorithms
+func checkSplitBy(splitBy keysplitting.SplitBy) error {
+	switch splitBy {
+	case keysplitting.Multiplication, keysplitting.Addition:
+		return nil
+	default:
+		return fmt.Errorf("%w: unrecognized split algorithm: %v", keysplitting.ErrUnsupportedSplitBy, splitBy)
+	}
+}
+
+// checks that data, which was decoded into v, is the deterministic encoding of v. The decoder accepts integers that aren't
+// in their shortest encoding, bignums that would fit in an integer and keys in any order, all of which re-encode differently
+func checkDeterministic(data []byte, v interface{}) error {
+	encoded, err := encMode.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(encoded, data) {
+		return fmt.Errorf("not in deterministic encoding")
+	}
+	return nil
+}
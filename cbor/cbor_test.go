@@ -0,0 +1,174 @@
+package cbor
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/bastionzero/keysplitting"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestCBOR(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "CBOR Suite")
+}
+
+var _ = Describe("CBOR encoding", func() {
+	Context("Integers", func() {
+		// examples from RFC 8949, appendix A
+		examples := []struct {
+			value   string
+			encoded string
+		}{
+			{"0", "00"},
+			{"23", "17"},
+			{"24", "1818"},
+			{"1000", "1903e8"},
+			{"1000000", "1a000f4240"},
+			{"1000000000000", "1b000000e8d4a51000"},
+			{"18446744073709551615", "1bffffffffffffffff"},
+			{"18446744073709551616", "c249010000000000000000"},
+			{"-1", "20"},
+			{"-1000", "3903e7"},
+			{"-18446744073709551616", "3bffffffffffffffff"},
+			{"-18446744073709551617", "c349010000000000000000"},
+		}
+
+		for _, example := range examples {
+			example := example
+			It("Encodes and decodes "+example.value, func() {
+				n, _ := new(big.Int).SetString(example.value, 10)
+				encoded, err := encMode.Marshal(n)
+				Expect(err).To(BeNil())
+				Expect(hex.EncodeToString(encoded)).To(Equal(example.encoded))
+
+				decoded := new(big.Int)
+				Expect(decMode.Unmarshal(encoded, decoded)).To(Succeed())
+				Expect(checkDeterministic(encoded, decoded)).To(Succeed())
+				Expect(decoded.Cmp(n)).To(Equal(0))
+			})
+		}
+
+		It("Rejects encodings that aren't the shortest", func() {
+			for _, encoded := range []string{"1817", "190017", "c24101", "c2490000000000000000ff"} {
+				b, _ := hex.DecodeString(encoded)
+				decoded := new(big.Int)
+				Expect(decMode.Unmarshal(b, decoded)).To(Succeed())
+				Expect(checkDeterministic(b, decoded)).NotTo(Succeed(), encoded)
+			}
+		})
+	})
+
+	Context("Shards", func() {
+		key, _ := rsa.GenerateKey(rand.Reader, 2048)
+		shards, _ := keysplitting.SplitDWithOptions(key, 2, keysplitting.Addition, &keysplitting.SplitOptions{Mask: true})
+
+		It("Round-trips, including negative shards", func() {
+			subShards, err := keysplitting.SplitShard(shards[0], 2, 3)
+			Expect(err).To(BeNil())
+
+			for _, shard := range append(shards, subShards...) {
+				encoded, err := MarshalShard(shard)
+				Expect(err).To(BeNil())
+
+				decoded, err := UnmarshalShard(encoded)
+				Expect(err).To(BeNil())
+				Expect(decoded.Equal(shard)).To(BeTrue())
+			}
+		})
+
+		It("Is deterministic", func() {
+			first, err := MarshalShard(shards[0])
+			Expect(err).To(BeNil())
+			second, err := MarshalShard(shards[0])
+			Expect(err).To(BeNil())
+			Expect(first).To(Equal(second))
+		})
+
+		It("Rejects trailing data and unknown keys", func() {
+			encoded, err := MarshalShard(shards[0])
+			Expect(err).To(BeNil())
+
+			_, err = UnmarshalShard(append(encoded, 0))
+			Expect(errors.Is(err, keysplitting.ErrInvalidShard)).To(BeTrue())
+
+			// bump the number of entries in the map and append a new key
+			unknown := append([]byte{encoded[0] + 1}, encoded[1:]...)
+			unknown = append(unknown, 0x07, 0x00)
+			_, err = UnmarshalShard(unknown)
+			Expect(errors.Is(err, keysplitting.ErrInvalidShard)).To(BeTrue())
+		})
+	})
+
+	Context("Partial signatures", func() {
+		It("Round-trips", func() {
+			key, _ := rsa.GenerateKey(rand.Reader, 2048)
+			shards, _ := keysplitting.SplitD(key, 2, keysplitting.Addition)
+			hashed := sha256.Sum256([]byte("TEST MESSAGE"))
+
+			partialSig, err := keysplitting.SignFirst(rand.Reader, shards[0], crypto.SHA256, hashed[:])
+			Expect(err).To(BeNil())
+			encoded, err := MarshalPartialSignature(partialSig)
+			Expect(err).To(BeNil())
+
+			decoded, err := UnmarshalPartialSignature(encoded)
+			Expect(err).To(BeNil())
+			Expect(decoded).To(Equal(partialSig))
+
+			sig, err := keysplitting.SignNext(rand.Reader, shards[1], crypto.SHA256, hashed[:], decoded)
+			Expect(err).To(BeNil())
+			Expect(rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, hashed[:], sig.Sig)).To(Succeed())
+		})
+
+		It("Has a stable encoding", func() {
+			partialSig := &keysplitting.PartialSignature{SplitBy: keysplitting.Addition, Hash: crypto.SHA256, Signers: []int{0, 1}, Sig: []byte{1}}
+			encoded, err := MarshalPartialSignature(partialSig)
+			Expect(err).To(BeNil())
+			Expect(hex.EncodeToString(encoded)).To(Equal("a401684164646974696f6e020503820001044101"))
+		})
+
+		It("Rejects malformed partial signatures", func() {
+			_, err := UnmarshalPartialSignature([]byte{0xa0})
+			Expect(errors.Is(err, keysplitting.ErrUnsupportedSplitBy)).To(BeTrue())
+			_, err = UnmarshalPartialSignature([]byte{0xff})
+			Expect(errors.Is(err, keysplitting.ErrInvalidPartialSignature)).To(BeTrue())
+
+			// keys out of order
+			unordered, _ := hex.DecodeString("a404410101684164646974696f6e020503820001")
+			_, err = UnmarshalPartialSignature(unordered)
+			Expect(errors.Is(err, keysplitting.ErrInvalidPartialSignature)).To(BeTrue())
+		})
+	})
+
+	Context("Embedding", func() {
+		It("Encodes shards and partial signatures within other structures", func() {
+			key, _ := rsa.GenerateKey(rand.Reader, 2048)
+			shards, _ := keysplitting.SplitD(key, 2, keysplitting.Addition)
+			hashed := sha256.Sum256([]byte("TEST MESSAGE"))
+			partialSig, err := keysplitting.SignFirst(rand.Reader, shards[0], crypto.SHA256, hashed[:])
+			Expect(err).To(BeNil())
+
+			type message struct {
+				Shard            Shard            `cbor:"1,keyasint"`
+				PartialSignature PartialSignature `cbor:"2,keyasint"`
+			}
+			encoded, err := encMode.Marshal(message{Shard: Shard{shards[1]}, PartialSignature: PartialSignature{partialSig}})
+			Expect(err).To(BeNil())
+
+			var decoded message
+			Expect(decMode.Unmarshal(encoded, &decoded)).To(Succeed())
+			Expect(decoded.Shard.Equal(shards[1])).To(BeTrue())
+			Expect(decoded.PartialSignature.PartialSignature).To(Equal(partialSig))
+
+			_, err = encMode.Marshal(message{Shard: Shard{&keysplitting.PrivateKeyShard{}}, PartialSignature: PartialSignature{partialSig}})
+			Expect(errors.Is(err, keysplitting.ErrInvalidShard)).To(BeTrue())
+		})
+	})
+})
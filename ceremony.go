@@ -0,0 +1,169 @@
+package keysplitting
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"fmt"
+)
+
+// the context string that every acknowledgment signature is bound to, so that it can't be mistaken for any other signature
+const acknowledgmentContext = "keysplitting shard acknowledgment v1"
+
+// An Acknowledgment is a recipient's signed statement that they received a particular shard during a key ceremony.
+// The recipient signs it with their own long-term identity key (not the shard), whose public key the dealer knows in advance
+type Acknowledgment struct {
+	Recipient          string `json:"recipient"`            // the recipient's name, as known to the dealer
+	KeyID              string `json:"key_id"`               // the shard's [PrivateKeyShard.KeyID]
+	Index              int    `json:"index"`                // the shard's index
+	Fingerprint        string `json:"fingerprint"`          // the shard's [PrivateKeyShard.Fingerprint]
+	RecipientPublicKey []byte `json:"recipient_public_key"` // the recipient's identity public key, in PKIX DER form
+	Signature          []byte `json:"signature"`            // the recipient's signature over all of the above
+}
+
+// used exclusively as a placeholder for encoding-decoding
+type acknowledgmentMessage struct {
+	Context            string
+	Recipient          string `asn1:"utf8"`
+	KeyID              string
+	Index              int
+	Fingerprint        string
+	RecipientPublicKey []byte
+}
+
+// Acknowledge returns the recipient's acknowledgment that they received shard, signed with signer. The signer may hold an
+// Ed25519, ECDSA, or RSA key. ECDSA and RSA keys sign a SHA-256 digest, the latter using PKCS #1 v1.5
+func Acknowledge(shard *PrivateKeyShard, recipient string, signer crypto.Signer) (*Acknowledgment, error) {
+	recipientPub, err := x509.MarshalPKIXPublicKey(signer.Public())
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal recipient public key: %s", err)
+	}
+
+	ack := &Acknowledgment{
+		Recipient:          recipient,
+		KeyID:              shard.KeyID(),
+		Index:              shard.Index,
+		Fingerprint:        shard.Fingerprint(),
+		RecipientPublicKey: recipientPub,
+	}
+	message, err := ack.message()
+	if err != nil {
+		return nil, err
+	}
+
+	switch signer.Public().(type) {
+	case ed25519.PublicKey:
+		ack.Signature, err = signer.Sign(rand.Reader, message, crypto.Hash(0))
+	case *ecdsa.PublicKey, *rsa.PublicKey:
+		digest := sha256.Sum256(message)
+		ack.Signature, err = signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+	default:
+		return nil, fmt.Errorf("unsupported recipient key type %T", signer.Public())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign acknowledgment: %s", err)
+	}
+	return ack, nil
+}
+
+// Verify checks the acknowledgment's signature against the recipient public key that it carries. The caller must still
+// check that this is the key they expected the recipient to have
+func (ack *Acknowledgment) Verify() error {
+	pub, err := x509.ParsePKIXPublicKey(ack.RecipientPublicKey)
+	if err != nil {
+		return fmt.Errorf("failed to parse recipient public key: %s", err)
+	}
+	message, err := ack.message()
+	if err != nil {
+		return err
+	}
+
+	valid := false
+	digest := sha256.Sum256(message)
+	switch pub := pub.(type) {
+	case ed25519.PublicKey:
+		valid = ed25519.Verify(pub, message, ack.Signature)
+	case *ecdsa.PublicKey:
+		valid = ecdsa.VerifyASN1(pub, digest[:], ack.Signature)
+	case *rsa.PublicKey:
+		valid = rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], ack.Signature) == nil
+	default:
+		return fmt.Errorf("unsupported recipient key type %T", pub)
+	}
+	if !valid {
+		return fmt.Errorf("invalid acknowledgment signature from %q", ack.Recipient)
+	}
+	return nil
+}
+
+// returns the message that the recipient signs
+func (ack *Acknowledgment) message() ([]byte, error) {
+	message, err := asn1.Marshal(acknowledgmentMessage{
+		Context:            acknowledgmentContext,
+		Recipient:          ack.Recipient,
+		KeyID:              ack.KeyID,
+		Index:              ack.Index,
+		Fingerprint:        ack.Fingerprint,
+		RecipientPublicKey: ack.RecipientPublicKey,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to DER-encode acknowledgment: %s", err)
+	}
+	return message, nil
+}
+
+// A Transcript is the dealer's record of a key ceremony, binding the public key to the fingerprint of each of its shards,
+// the recipient each was handed out to, and that recipient's acknowledgment. Transcripts can be marshaled to JSON for
+// archiving, and checked with [Transcript.Verify] without access to the dealer
+type Transcript struct {
+	KeyID     string            `json:"key_id"`
+	PublicKey []byte            `json:"public_key"` // PKCS #1 DER form
+	SplitBy   SplitBy           `json:"split_by"`
+	Shards    []TranscriptEntry `json:"shards"`
+}
+
+// A TranscriptEntry records what happened to a single shard during a key ceremony
+type TranscriptEntry struct {
+	Index          int             `json:"index"`
+	Fingerprint    string          `json:"fingerprint"`
+	Recipient      string          `json:"recipient,omitempty"`      // empty if the shard has not been handed out to a named recipient
+	Acknowledgment *Acknowledgment `json:"acknowledgment,omitempty"` // nil if the shard has not been acknowledged
+}
+
+// Verify checks that the ceremony completed correctly: that every shard was handed out and acknowledged by its recipient,
+// and that every acknowledgment is validly signed and refers to the shard it is recorded against
+func (t *Transcript) Verify() error {
+	pub, err := x509.ParsePKCS1PublicKey(t.PublicKey)
+	if err != nil {
+		return errorf(ErrKeyMismatch, "failed to parse public key: %s", err)
+	}
+	if keyID := (&PrivateKeyShard{PublicKey: pub}).KeyID(); keyID != t.KeyID {
+		return errorf(ErrKeyMismatch, "key ID does not match the public key")
+	}
+	if len(t.Shards) < 2 {
+		return errorf(ErrTooFewShards, "transcript records fewer than 2 shards")
+	}
+
+	for i, entry := range t.Shards {
+		shard := &PrivateKeyShard{PublicKey: pub, SplitBy: t.SplitBy, Index: i + 1}
+		if entry.Index != shard.Index || entry.Fingerprint != shard.Fingerprint() {
+			return errorf(ErrInvalidShard, "transcript entry %d does not match shard %d", i+1, shard.Index)
+		}
+		ack := entry.Acknowledgment
+		if ack == nil {
+			return errorf(ErrInvalidShard, "shard %d has not been acknowledged", entry.Index)
+		}
+		if ack.Recipient != entry.Recipient || ack.KeyID != t.KeyID || ack.Index != entry.Index || ack.Fingerprint != entry.Fingerprint {
+			return errorf(ErrInvalidShard, "acknowledgment for shard %d does not match the transcript", entry.Index)
+		}
+		if err := ack.Verify(); err != nil {
+			return errorf(ErrInvalidShard, "acknowledgment for shard %d: %s", entry.Index, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package keysplitting
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Key ceremony", func() {
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	recipients := []struct {
+		name   string
+		signer crypto.Signer
+	}{
+		{"alice", edKey},
+		{"bob", ecKey},
+		{"carol", rsaKey},
+	}
+
+	// runs a ceremony in which every recipient receives and acknowledges their shard
+	runCeremony := func() (*Dealer, *rsa.PublicKey, []*Acknowledgment) {
+		dealer := NewDealer(nil)
+		pub, err := dealer.GenerateKey(rand.Reader, 2048)
+		Expect(err).To(BeNil())
+		Expect(dealer.Split(context.Background(), pub, len(recipients), Addition)).To(Succeed())
+
+		acks := make([]*Acknowledgment, len(recipients))
+		for i, recipient := range recipients {
+			shard, err := dealer.HandOutTo(pub, i+1, recipient.name)
+			Expect(err).To(BeNil())
+			acks[i], err = Acknowledge(shard, recipient.name, recipient.signer)
+			Expect(err).To(BeNil())
+			Expect(dealer.Acknowledge(pub, acks[i], recipient.signer.Public())).To(Succeed())
+		}
+		return dealer, pub, acks
+	}
+
+	It("Produces a transcript that verifies once every shard is acknowledged", func() {
+		dealer, pub, _ := runCeremony()
+		defer dealer.Close()
+
+		transcript, err := dealer.Transcript(pub)
+		Expect(err).To(BeNil())
+		Expect(transcript.Verify()).To(Succeed())
+
+		By("Surviving a JSON round trip")
+		encoded, err := json.Marshal(transcript)
+		Expect(err).To(BeNil())
+		var decoded Transcript
+		Expect(json.Unmarshal(encoded, &decoded)).To(Succeed())
+		Expect(decoded.Verify()).To(Succeed())
+		Expect(decoded.Shards[1].Recipient).To(Equal("bob"))
+	})
+
+	It("Reports shards that have not been acknowledged", func() {
+		dealer := NewDealer(nil)
+		defer dealer.Close()
+		pub, _ := dealer.GenerateKey(rand.Reader, 2048)
+		Expect(dealer.Split(context.Background(), pub, 2, Multiplication)).To(Succeed())
+
+		shard, _ := dealer.HandOutTo(pub, 1, "alice")
+		ack, _ := Acknowledge(shard, "alice", edKey)
+		Expect(dealer.Acknowledge(pub, ack, edKey.Public())).To(Succeed())
+
+		transcript, err := dealer.Transcript(pub)
+		Expect(err).To(BeNil())
+		Expect(transcript.Shards[1].Acknowledgment).To(BeNil())
+		Expect(errors.Is(transcript.Verify(), ErrInvalidShard)).To(BeTrue())
+	})
+
+	It("Rejects acknowledgments from the wrong recipient or key", func() {
+		dealer := NewDealer(nil)
+		defer dealer.Close()
+		pub, _ := dealer.GenerateKey(rand.Reader, 2048)
+		Expect(dealer.Split(context.Background(), pub, 2, Addition)).To(Succeed())
+		shard, _ := dealer.HandOutTo(pub, 1, "alice")
+
+		impostor, _ := Acknowledge(shard, "mallory", edKey)
+		Expect(errors.Is(dealer.Acknowledge(pub, impostor, edKey.Public()), ErrInvalidShard)).To(BeTrue())
+
+		wrongKey, _ := Acknowledge(shard, "alice", ecKey)
+		Expect(errors.Is(dealer.Acknowledge(pub, wrongKey, edKey.Public()), ErrKeyMismatch)).To(BeTrue())
+
+		notHandedOut := &Acknowledgment{Recipient: "bob", Index: 2}
+		Expect(errors.Is(dealer.Acknowledge(pub, notHandedOut, ecKey.Public()), ErrInvalidShard)).To(BeTrue())
+	})
+
+	for _, tc := range []struct {
+		description string
+		tamper      func(t *Transcript)
+	}{
+		{"a changed recipient", func(t *Transcript) { t.Shards[0].Recipient = "mallory" }},
+		{"a forged signature", func(t *Transcript) { t.Shards[1].Acknowledgment.Signature[0] ^= 1 }},
+		{"swapped shards", func(t *Transcript) { t.Shards[0], t.Shards[1] = t.Shards[1], t.Shards[0] }},
+		{"a different split algorithm", func(t *Transcript) { t.SplitBy = Multiplication }},
+	} {
+		tc := tc
+		It(fmt.Sprintf("Detects a transcript with %s", tc.description), func() {
+			dealer, pub, _ := runCeremony()
+			defer dealer.Close()
+			transcript, err := dealer.Transcript(pub)
+			Expect(err).To(BeNil())
+			tc.tamper(transcript)
+			Expect(transcript.Verify()).NotTo(Succeed())
+		})
+	}
+})
@@ -0,0 +1,188 @@
+package keysplitting
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"io"
+	"math/big"
+)
+
+// ShardCommitments commit to each of a key's additive shards, so that the holders can prove that their partial signatures
+// were produced with the shards they were dealt, and a broker can reject the ones that weren't before combining them. This
+// protects the signature against actively malicious holders, rather than just curious ones.
+//
+// Each commitment is Vi = V^Di (mod N), for a random square V, and each proof shows that the signed value and the
+// partial signature have the same discrete logarithm as V and Vi, in the manner of Chaum and Pedersen. Following Shoup [3],
+// the proof is over the squares of the signed value and the partial signature, so it establishes the partial signature only up
+// to its sign; [CombineProvenPartialSignatures] and [Broker] correct the sign of the complete signature accordingly.
+//
+// The commitments are public: they can be shared with everyone, including the shard holders, and published
+// alongside the public key with [ShardCommitments.MarshalJSON]. They no longer match once the shards are refreshed or
+// reshared, and masked shards can't be committed to, since they change from one signature to the next
+type ShardCommitments struct {
+	PublicKey *rsa.PublicKey // public part
+	V         *big.Int       // the random square that the shards are committed with
+	Vi        []*big.Int     // V^Di (mod N) for each shard, i.e. Vi[i] commits to the shard with index i+1
+}
+
+// SplitDWithCommitments is like [SplitDWithOptions] with [SplitBy].Addition, but also returns public commitments to the
+// shards, as with [NewShardCommitments]. opts must not set Mask, and its Rand, if any, is also used for the commitments
+func SplitDWithCommitments(priv *rsa.PrivateKey, k int, opts *SplitOptions) ([]*PrivateKeyShard, *ShardCommitments, error) {
+	if opts != nil && opts.Mask {
+		return nil, nil, errorf(ErrInvalidShard, "masked shards cannot be committed to")
+	}
+	shards, err := SplitDWithOptions(priv, k, Addition, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	random := io.Reader(rand.Reader)
+	if opts != nil && opts.Rand != nil {
+		random = opts.Rand
+	}
+	commitments, err := NewShardCommitments(random, shards)
+	if err != nil {
+		return nil, nil, err
+	}
+	return shards, commitments, nil
+}
+
+// NewShardCommitments commits to each of the additive shards of a key. It must be called by the dealer, since it requires
+// every shard, in index order, and the shards must have the indices 1 to len(shards) that [SplitD] gives them
+func NewShardCommitments(random io.Reader, shards []*PrivateKeyShard) (*ShardCommitments, error) {
+	if len(shards) == 0 {
+		return nil, errorf(ErrTooFewShards, "no shards to commit to")
+	}
+	pub := shards[0].PublicKey
+	for i, shard := range shards {
+		if shard.SplitBy != Addition {
+			return nil, errorf(ErrUnsupportedSplitBy, "only additive shards can be committed to")
+		}
+		if shard.PublicKey.N.Cmp(pub.N) != 0 || shard.PublicKey.E != pub.E {
+			return nil, errorf(ErrKeyMismatch, "shard #%d belongs to a different key", i)
+		}
+		if shard.Mask != nil {
+			return nil, errorf(ErrInvalidShard, "shard #%d is masked, so it cannot be committed to", i)
+		}
+		if shard.Index != i+1 {
+			return nil, errorf(ErrInvalidShard, "shard #%d has index %d, but shards must be committed to in index order", i, shard.Index)
+		}
+	}
+
+	var v *big.Int
+	for {
+		u, err := rand.Int(random, pub.N)
+		if err != nil {
+			return nil, err
+		}
+		if u.Sign() != 0 && new(big.Int).GCD(nil, nil, u, pub.N).Cmp(bigOne) == 0 {
+			v = u.Mul(u, u).Mod(u, pub.N)
+			break
+		}
+	}
+
+	vi := make([]*big.Int, len(shards))
+	for i, shard := range shards {
+		commitment, err := expSigned(v, shard.D, pub.N)
+		if err != nil {
+			return nil, err
+		}
+		vi[i] = commitment
+	}
+	return &ShardCommitments{PublicKey: pub, V: v, Vi: vi}, nil
+}
+
+// Validate checks that the commitments are well-formed, and that together they commit to the private key corresponding to
+// PublicKey, i.e. that (V1 * V2 * ... * Vk)^E ≡ V (mod N). It fails with [ErrKeyMismatch] if they don't, which means that
+// the shards committed to don't recombine to the private key. A nil error indicates that the commitments are valid
+func (c *ShardCommitments) Validate() error {
+	if c.PublicKey == nil || c.PublicKey.N == nil || c.PublicKey.N.Sign() <= 0 || c.PublicKey.E < 2 {
+		return errorf(ErrInvalidShard, "commitments have no valid public key")
+	}
+	N := c.PublicKey.N
+	if len(c.Vi) < 2 {
+		return errorf(ErrTooFewShards, "commitments must be to at least 2 shards")
+	}
+
+	product := new(big.Int).Set(bigOne)
+	for i, v := range append([]*big.Int{c.V}, c.Vi...) {
+		if v == nil || v.Sign() <= 0 || v.Cmp(N) >= 0 || new(big.Int).GCD(nil, nil, v, N).Cmp(bigOne) != 0 {
+			return errorf(ErrInvalidShard, "commitment #%d is out of range for the public key", i)
+		}
+		if i > 0 {
+			product.Mul(product, v).Mod(product, N)
+		}
+	}
+	if product.Exp(product, big.NewInt(int64(c.PublicKey.E)), N).Cmp(c.V) != 0 {
+		return errorf(ErrKeyMismatch, "commitments are not to shards of the private key")
+	}
+	return nil
+}
+
+// used exclusively as a placeholder for encoding-decoding
+type shardCommitmentsJSON struct {
+	Kty string   `json:"kty"`
+	Kid string   `json:"kid"`
+	N   string   `json:"n"`
+	E   string   `json:"e"`
+	V   string   `json:"v"`
+	Vi  []string `json:"vi"`
+}
+
+// MarshalJSON implements [json.Marshaler]. Like a public RSA JWK (RFC 7517), it has the key type "RSA", the public parameters
+// "n" and "e", and a "kid" from [PublicKeyID], along with the commitments "v" and "vi", all encoded in base64url without padding
+func (c *ShardCommitments) MarshalJSON() ([]byte, error) {
+	encoded := shardCommitmentsJSON{
+		Kty: "RSA",
+		Kid: PublicKeyID(c.PublicKey),
+		N:   encodeBase64URLInt(c.PublicKey.N),
+		E:   encodeBase64URLInt(big.NewInt(int64(c.PublicKey.E))),
+		V:   encodeBase64URLInt(c.V),
+		Vi:  make([]string, len(c.Vi)),
+	}
+	for i, v := range c.Vi {
+		encoded.Vi[i] = encodeBase64URLInt(v)
+	}
+	return json.Marshal(encoded)
+}
+
+// UnmarshalJSON implements [json.Unmarshaler], accepting the format produced by [ShardCommitments.MarshalJSON] and rejecting
+// commitments that fail [ShardCommitments.Validate]
+func (c *ShardCommitments) UnmarshalJSON(data []byte) error {
+	var encoded shardCommitmentsJSON
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return errorf(ErrInvalidShard, "failed to unmarshal shard commitments: %s", err)
+	}
+	if encoded.Kty != "RSA" {
+		return errorf(ErrInvalidShard, "shard commitments have key type %q rather than \"RSA\"", encoded.Kty)
+	}
+
+	n, err := decodeBase64URLInt(encoded.N)
+	if err != nil {
+		return err
+	}
+	e, err := decodeBase64URLInt(encoded.E)
+	if err != nil {
+		return err
+	}
+	if !e.IsInt64() || e.Int64() > 1<<31-1 {
+		return errorf(ErrInvalidShard, "public exponent is out of range")
+	}
+	decoded := ShardCommitments{
+		PublicKey: &rsa.PublicKey{N: n, E: int(e.Int64())},
+		Vi:        make([]*big.Int, len(encoded.Vi)),
+	}
+	if decoded.V, err = decodeBase64URLInt(encoded.V); err != nil {
+		return err
+	}
+	for i, v := range encoded.Vi {
+		if decoded.Vi[i], err = decodeBase64URLInt(v); err != nil {
+			return err
+		}
+	}
+	if err := decoded.Validate(); err != nil {
+		return err
+	}
+	*c = decoded
+	return nil
+}
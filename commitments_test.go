@@ -0,0 +1,100 @@
+package keysplitting
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"math/big"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Shard commitments", func() {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	hashed := sha256.Sum256([]byte("TEST MESSAGE"))
+
+	It("Commits to the shards at split time", func() {
+		shards, commitments, err := SplitDWithCommitments(key, 3, nil)
+		Expect(err).To(BeNil())
+		Expect(shards).To(HaveLen(3))
+		Expect(commitments.Vi).To(HaveLen(3))
+		Expect(commitments.PublicKey).To(Equal(&key.PublicKey))
+		Expect(commitments.Validate()).To(Succeed())
+
+		for i, shard := range shards {
+			Expect(commitments.Vi[i]).To(Equal(new(big.Int).Exp(commitments.V, new(big.Int).Mod(shard.D, eulerTotient(key.Primes)), key.N)))
+		}
+	})
+
+	It("Commits to shards split modulo lambda(N) or with a multiple of phi(N)", func() {
+		for _, opts := range []*SplitOptions{{Lambda: true}, {PhiMultiple: true}} {
+			_, commitments, err := SplitDWithCommitments(key, 2, opts)
+			Expect(err).To(BeNil())
+			Expect(commitments.Validate()).To(Succeed())
+		}
+		_, _, err := SplitDWithCommitments(key, 2, &SplitOptions{Mask: true})
+		Expect(errors.Is(err, ErrInvalidShard)).To(BeTrue())
+	})
+
+	It("Commits with the given source of randomness", func() {
+		_, commitments, err := SplitDWithCommitments(key, 2, &SplitOptions{Rand: newDeterministicReader("seed")})
+		Expect(err).To(BeNil())
+		_, again, err := SplitDWithCommitments(key, 2, &SplitOptions{Rand: newDeterministicReader("seed")})
+		Expect(err).To(BeNil())
+		Expect(again.V).To(Equal(commitments.V))
+		Expect(again.Vi).To(Equal(commitments.Vi))
+	})
+
+	It("Detects commitments to shards that don't recombine to the key", func() {
+		shards, err := SplitD(key, 3, Addition)
+		Expect(err).To(BeNil())
+		commitments, err := NewShardCommitments(rand.Reader, shards[:2])
+		Expect(err).To(BeNil())
+		Expect(errors.Is(commitments.Validate(), ErrKeyMismatch)).To(BeTrue())
+
+		commitments, err = NewShardCommitments(rand.Reader, shards)
+		Expect(err).To(BeNil())
+		commitments.Vi[1] = new(big.Int).Mod(new(big.Int).Mul(commitments.Vi[1], commitments.V), key.N)
+		Expect(errors.Is(commitments.Validate(), ErrKeyMismatch)).To(BeTrue())
+	})
+
+	It("Publishes the commitments with the public key", func() {
+		shards, commitments, err := SplitDWithCommitments(key, 3, nil)
+		Expect(err).To(BeNil())
+
+		encoded, err := json.Marshal(commitments)
+		Expect(err).To(BeNil())
+		var fields map[string]interface{}
+		Expect(json.Unmarshal(encoded, &fields)).To(Succeed())
+		Expect(fields["kty"]).To(Equal("RSA"))
+		Expect(fields["kid"]).To(Equal(PublicKeyID(&key.PublicKey)))
+
+		var decoded ShardCommitments
+		Expect(json.Unmarshal(encoded, &decoded)).To(Succeed())
+		Expect(decoded.PublicKey).To(Equal(&key.PublicKey))
+		Expect(decoded.V).To(Equal(commitments.V))
+		Expect(decoded.Vi).To(Equal(commitments.Vi))
+
+		// the decoded commitments check proofs just as well
+		partial, err := SignFirst(rand.Reader, shards[0], crypto.SHA256, hashed[:])
+		Expect(err).To(BeNil())
+		Expect(commitments.Prove(rand.Reader, shards[0], crypto.SHA256, hashed[:], partial)).To(Succeed())
+		Expect(VerifyPartialSignature(&decoded, crypto.SHA256, hashed[:], partial)).To(Succeed())
+	})
+
+	It("Refuses to decode invalid commitments", func() {
+		_, commitments, err := SplitDWithCommitments(key, 2, nil)
+		Expect(err).To(BeNil())
+		commitments.V = new(big.Int).Add(commitments.V, bigOne)
+		encoded, err := json.Marshal(commitments)
+		Expect(err).To(BeNil())
+
+		var decoded ShardCommitments
+		Expect(errors.Is(json.Unmarshal(encoded, &decoded), ErrKeyMismatch)).To(BeTrue())
+		Expect(errors.Is(json.Unmarshal([]byte(`{"kty":"EC"}`), &decoded), ErrInvalidShard)).To(BeTrue())
+	})
+})
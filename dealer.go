@@ -0,0 +1,320 @@
+package keysplitting
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"sync"
+)
+
+// A Dealer runs the dealer's side of a key ceremony: it holds one or more whole private keys, splits them, and hands out
+// each shard exactly once. It caches phi(N) (or lambda(N), see [SplitOptions].Lambda) for each key, and keeps track of
+// which shards have yet to be handed out. Once the ceremony is over, [Dealer.Close] zeroizes the keys and all of the
+// dealer's copies of the shards. A Dealer is safe for concurrent use
+type Dealer struct {
+	opts *SplitOptions
+
+	mu     sync.Mutex
+	keys   map[[32]byte]*dealtKey
+	closed bool
+	logger Logger
+}
+
+// the dealer's state for a single key
+type dealtKey struct {
+	priv   *rsa.PrivateKey
+	split  *rsa.PrivateKey // priv, or a copy with D reduced modulo lambda(N)
+	phi    *big.Int
+	shards []*PrivateKeyShard
+	dealt  []bool
+
+	// the recipient of each shard, and their acknowledgment (see Dealer.Acknowledge)
+	recipients []string
+	acks       []*Acknowledgment
+}
+
+// NewDealer returns a dealer that splits keys as [SplitDWithOptions] would with opts
+func NewDealer(opts *SplitOptions) *Dealer {
+	if opts == nil {
+		opts = &SplitOptions{}
+	}
+	return &Dealer{opts: opts, keys: make(map[[32]byte]*dealtKey), logger: NopLogger{}}
+}
+
+// SetLogger sets the [Logger] that the dealer reports the progress of the ceremony to. A nil logger, the default, discards it
+func (d *Dealer) SetLogger(logger Logger) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.logger = loggerOrNop(logger)
+}
+
+// GenerateKey generates a new RSA keypair of the given bit size for the dealer to split, and returns its public key.
+// The private key never leaves the dealer
+func (d *Dealer) GenerateKey(random io.Reader, bits int) (*rsa.PublicKey, error) {
+	priv, err := rsa.GenerateKey(random, bits)
+	if err != nil {
+		return nil, err
+	}
+
+	pub, err := d.AddKey(priv)
+	if err != nil {
+		zeroizePrivateKey(priv)
+	}
+	return pub, err
+}
+
+// AddKey gives an existing private key to the dealer to split, and returns a copy of its public key. The dealer takes
+// ownership of priv, which is zeroized by [Dealer.Close]
+func (d *Dealer) AddKey(priv *rsa.PrivateKey) (*rsa.PublicKey, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closed {
+		return nil, errorf(ErrClosed, "cannot add a key to a closed dealer")
+	}
+	pub := &rsa.PublicKey{N: new(big.Int).Set(priv.N), E: priv.E}
+	id := publicKeyID(pub)
+	if _, ok := d.keys[id]; ok {
+		return nil, errorf(ErrKeyMismatch, "key has already been added to the dealer")
+	}
+
+	key := &dealtKey{priv: priv, split: priv}
+	if d.opts.Lambda {
+		key.phi = carmichaelLambda(priv.Primes)
+		key.split = &rsa.PrivateKey{PublicKey: *pub, D: new(big.Int).Mod(priv.D, key.phi)}
+	} else {
+		key.phi = eulerTotient(priv.Primes)
+	}
+	d.keys[id] = key
+	d.logger.Info("added key to dealer", "key_id", PublicKeyID(pub), "bits", pub.N.BitLen())
+	return pub, nil
+}
+
+// Split splits the private key belonging to pub into k shards, which can then be handed out with [Dealer.HandOut].
+// Each key can only be split once, since shards from different splits of the same key cannot be combined
+func (d *Dealer) Split(ctx context.Context, pub *rsa.PublicKey, k int, splitBy SplitBy) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	key, err := d.lookup(pub)
+	if err != nil {
+		return err
+	}
+	if key.shards != nil {
+		return errorf(ErrInvalidShard, "key has already been split")
+	}
+
+	shards, err := splitD(ctx, key.split, key.phi, k, splitBy, d.opts)
+	if err != nil {
+		d.logger.Error("failed to split key", "key_id", PublicKeyID(pub), "shards", k, "split_by", splitBy, "error", err)
+		return err
+	}
+	// the shards share a copy of the public key so that they don't keep the private key alive
+	shardPub := &rsa.PublicKey{N: new(big.Int).Set(pub.N), E: pub.E}
+	for _, shard := range shards {
+		shard.PublicKey = shardPub
+	}
+
+	key.shards = shards
+	key.dealt = make([]bool, len(shards))
+	key.recipients = make([]string, len(shards))
+	key.acks = make([]*Acknowledgment, len(shards))
+	d.logger.Info("split key", "key_id", PublicKeyID(pub), "shards", k, "split_by", splitBy)
+	return nil
+}
+
+// HandOut returns a copy of the shard with the given index (from 1 to k) of the key belonging to pub. Each shard can
+// only be handed out once
+func (d *Dealer) HandOut(pub *rsa.PublicKey, index int) (*PrivateKeyShard, error) {
+	return d.HandOutTo(pub, index, "")
+}
+
+// HandOutTo is like [Dealer.HandOut], but records that the shard was handed out to the named recipient, who is expected to
+// return an [Acknowledgment] for [Dealer.Acknowledge]
+func (d *Dealer) HandOutTo(pub *rsa.PublicKey, index int, recipient string) (*PrivateKeyShard, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	key, err := d.lookup(pub)
+	if err != nil {
+		return nil, err
+	}
+	if index < 1 || index > len(key.shards) {
+		return nil, errorf(ErrInvalidShard, "key has no shard %d", index)
+	}
+	if key.dealt[index-1] {
+		// this may be an attempt to obtain a second copy of a shard
+		d.logger.Warn("refused to hand out shard again", "key_id", PublicKeyID(pub), "shard", index, "recipient", recipient)
+		return nil, errorf(ErrInvalidShard, "shard %d has already been handed out", index)
+	}
+
+	key.dealt[index-1] = true
+	key.recipients[index-1] = recipient
+	d.logger.Info("handed out shard", "key_id", PublicKeyID(pub), "shard", index, "recipient", recipient)
+	return copyShard(key.shards[index-1]), nil
+}
+
+// Acknowledge records a recipient's acknowledgment that they received a shard of the key belonging to pub, after checking
+// that it is validly signed by recipientPub, the identity key that the dealer expects the recipient to have, and that it
+// refers to the shard that was handed out to them
+func (d *Dealer) Acknowledge(pub *rsa.PublicKey, ack *Acknowledgment, recipientPub crypto.PublicKey) (err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	key, err := d.lookup(pub)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			d.logger.Warn("rejected shard acknowledgment", "key_id", PublicKeyID(pub), "shard", ack.Index, "recipient", ack.Recipient, "error", err)
+		} else {
+			d.logger.Info("shard acknowledged", "key_id", PublicKeyID(pub), "shard", ack.Index, "recipient", ack.Recipient)
+		}
+	}()
+	if ack.Index < 1 || ack.Index > len(key.shards) || !key.dealt[ack.Index-1] {
+		return errorf(ErrInvalidShard, "shard %d has not been handed out", ack.Index)
+	}
+	shard := key.shards[ack.Index-1]
+	if ack.KeyID != shard.KeyID() || ack.Fingerprint != shard.Fingerprint() {
+		return errorf(ErrKeyMismatch, "acknowledgment does not refer to shard %d", ack.Index)
+	}
+	if ack.Recipient != key.recipients[ack.Index-1] {
+		return errorf(ErrInvalidShard, "shard %d was not handed out to %q", ack.Index, ack.Recipient)
+	}
+
+	expected, err := x509.MarshalPKIXPublicKey(recipientPub)
+	if err != nil {
+		return fmt.Errorf("failed to marshal recipient public key: %s", err)
+	}
+	if !bytes.Equal(ack.RecipientPublicKey, expected) {
+		return errorf(ErrKeyMismatch, "acknowledgment for shard %d was not signed by the expected recipient key", ack.Index)
+	}
+	if err := ack.Verify(); err != nil {
+		return errorf(ErrInvalidShard, "acknowledgment for shard %d: %s", ack.Index, err)
+	}
+
+	key.acks[ack.Index-1] = ack
+	return nil
+}
+
+// Transcript returns the dealer's record of the ceremony for the key belonging to pub, which can be checked with
+// [Transcript.Verify] once every shard has been acknowledged
+func (d *Dealer) Transcript(pub *rsa.PublicKey) (*Transcript, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	key, err := d.lookup(pub)
+	if err != nil {
+		return nil, err
+	}
+	if key.shards == nil {
+		return nil, errorf(ErrInvalidShard, "key has not been split")
+	}
+
+	t := &Transcript{
+		KeyID:     key.shards[0].KeyID(),
+		PublicKey: x509.MarshalPKCS1PublicKey(key.shards[0].PublicKey),
+		SplitBy:   key.shards[0].SplitBy,
+		Shards:    make([]TranscriptEntry, len(key.shards)),
+	}
+	for i, shard := range key.shards {
+		t.Shards[i] = TranscriptEntry{
+			Index:          shard.Index,
+			Fingerprint:    shard.Fingerprint(),
+			Recipient:      key.recipients[i],
+			Acknowledgment: key.acks[i],
+		}
+	}
+	return t, nil
+}
+
+// Outstanding returns the indices of the shards of the key belonging to pub that have yet to be handed out, in ascending order.
+// It is empty until the key has been split
+func (d *Dealer) Outstanding(pub *rsa.PublicKey) ([]int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	key, err := d.lookup(pub)
+	if err != nil {
+		return nil, err
+	}
+
+	outstanding := []int{}
+	for i, dealt := range key.dealt {
+		if !dealt {
+			outstanding = append(outstanding, i+1)
+		}
+	}
+	return outstanding, nil
+}
+
+// Keys returns the public keys that have been added to the dealer
+func (d *Dealer) Keys() []*rsa.PublicKey {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	keys := make([]*rsa.PublicKey, 0, len(d.keys))
+	for _, key := range d.keys {
+		keys = append(keys, &rsa.PublicKey{N: new(big.Int).Set(key.priv.N), E: key.priv.E})
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].N.Cmp(keys[j].N) < 0
+	})
+	return keys
+}
+
+// Close zeroizes every key held by the dealer, along with phi and the dealer's copies of the shards, whether or not
+// they have been handed out. Shards that have been handed out are copies, so they are unaffected. The dealer cannot
+// be used afterwards. Close always returns nil, and can be called more than once
+func (d *Dealer) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if !d.closed {
+		d.logger.Info("closed dealer", "keys", len(d.keys))
+	}
+	for id, key := range d.keys {
+		zeroizePrivateKey(key.priv)
+		zeroize(key.split.D)
+		zeroize(key.phi)
+		for _, shard := range key.shards {
+			shard.Zeroize()
+		}
+		delete(d.keys, id)
+	}
+	d.closed = true
+	return nil
+}
+
+// returns the dealer's state for the key belonging to pub. d.mu must be held
+func (d *Dealer) lookup(pub *rsa.PublicKey) (*dealtKey, error) {
+	if d.closed {
+		return nil, errorf(ErrClosed, "dealer is closed")
+	}
+	key, ok := d.keys[publicKeyID(pub)]
+	if !ok {
+		return nil, errorf(ErrKeyMismatch, "key has not been added to the dealer")
+	}
+	return key, nil
+}
+
+// returns a deep copy of shard's secret values, sharing its public key
+func copyShard(shard *PrivateKeyShard) *PrivateKeyShard {
+	c := &PrivateKeyShard{
+		PublicKey: shard.PublicKey,
+		D:         new(big.Int).Set(shard.D),
+		SplitBy:   shard.SplitBy,
+		Index:     shard.Index,
+	}
+	if shard.Mask != nil {
+		c.Mask = new(big.Int).Set(shard.Mask)
+	}
+	return c
+}
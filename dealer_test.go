@@ -0,0 +1,153 @@
+package keysplitting
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha512"
+	"errors"
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Dealer", func() {
+	hashed := sha512.Sum512([]byte("TEST MESSAGE"))
+
+	It("Splits several keys and hands out each shard once", func() {
+		dealer := NewDealer(nil)
+		defer dealer.Close()
+
+		pubA, err := dealer.GenerateKey(rand.Reader, 2048)
+		Expect(err).To(BeNil())
+		priv, _ := rsa.GenerateKey(rand.Reader, 2048)
+		pubB, err := dealer.AddKey(priv)
+		Expect(err).To(BeNil())
+		Expect(dealer.Keys()).To(HaveLen(2))
+
+		Expect(dealer.Split(context.Background(), pubA, 3, Addition)).To(Succeed())
+		Expect(dealer.Split(context.Background(), pubB, 2, Multiplication)).To(Succeed())
+
+		outstanding, err := dealer.Outstanding(pubA)
+		Expect(err).To(BeNil())
+		Expect(outstanding).To(Equal([]int{1, 2, 3}))
+
+		shards := make([]*PrivateKeyShard, 3)
+		for i := range shards {
+			shards[i], err = dealer.HandOut(pubA, i+1)
+			Expect(err).To(BeNil())
+			Expect(shards[i].Index).To(Equal(i + 1))
+		}
+		_, err = dealer.HandOut(pubA, 2)
+		Expect(errors.Is(err, ErrInvalidShard)).To(BeTrue())
+
+		outstanding, err = dealer.Outstanding(pubA)
+		Expect(err).To(BeNil())
+		Expect(outstanding).To(BeEmpty())
+
+		sig := signBrokered(pubA, shards, hashed[:])
+		Expect(rsa.VerifyPKCS1v15(pubA, crypto.SHA512, hashed[:], sig)).To(Succeed())
+
+		outstanding, err = dealer.Outstanding(pubB)
+		Expect(err).To(BeNil())
+		Expect(outstanding).To(Equal([]int{1, 2}))
+	})
+
+	It("Uses lambda(N) if asked to", func() {
+		dealer := NewDealer(&SplitOptions{Lambda: true})
+		defer dealer.Close()
+
+		priv, _ := rsa.GenerateKey(rand.Reader, 2048)
+		pub, err := dealer.AddKey(priv)
+		Expect(err).To(BeNil())
+		Expect(dealer.Split(context.Background(), pub, 2, Multiplication)).To(Succeed())
+
+		first, _ := dealer.HandOut(pub, 1)
+		second, _ := dealer.HandOut(pub, 2)
+		partialSig, err := SignFirst(rand.Reader, first, crypto.SHA512, hashed[:])
+		Expect(err).To(BeNil())
+		sig, err := SignNext(rand.Reader, second, crypto.SHA512, hashed[:], partialSig)
+		Expect(err).To(BeNil())
+		Expect(rsa.VerifyPKCS1v15(pub, crypto.SHA512, hashed[:], sig.Sig)).To(Succeed())
+	})
+
+	It("Rejects unknown keys, repeated splits, and bad indices", func() {
+		dealer := NewDealer(nil)
+		defer dealer.Close()
+
+		priv, _ := rsa.GenerateKey(rand.Reader, 2048)
+		other, _ := rsa.GenerateKey(rand.Reader, 2048)
+		pub, err := dealer.AddKey(priv)
+		Expect(err).To(BeNil())
+
+		_, err = dealer.AddKey(priv)
+		Expect(errors.Is(err, ErrKeyMismatch)).To(BeTrue())
+		err = dealer.Split(context.Background(), &other.PublicKey, 2, Addition)
+		Expect(errors.Is(err, ErrKeyMismatch)).To(BeTrue())
+
+		Expect(dealer.Split(context.Background(), pub, 2, Addition)).To(Succeed())
+		err = dealer.Split(context.Background(), pub, 2, Addition)
+		Expect(errors.Is(err, ErrInvalidShard)).To(BeTrue())
+
+		for _, index := range []int{0, 3} {
+			_, err = dealer.HandOut(pub, index)
+			Expect(errors.Is(err, ErrInvalidShard)).To(BeTrue())
+		}
+	})
+
+	It("Zeroizes everything on Close, without affecting handed-out shards", func() {
+		dealer := NewDealer(nil)
+		priv, _ := rsa.GenerateKey(rand.Reader, 2048)
+		pub, err := dealer.AddKey(priv)
+		Expect(err).To(BeNil())
+		Expect(dealer.Split(context.Background(), pub, 2, Addition)).To(Succeed())
+
+		handedOut, err := dealer.HandOut(pub, 1)
+		Expect(err).To(BeNil())
+		kept := dealer.keys[publicKeyID(pub)]
+
+		Expect(dealer.Close()).To(Succeed())
+		Expect(priv.D.Sign()).To(Equal(0))
+		for _, p := range priv.Primes {
+			Expect(p.Sign()).To(Equal(0))
+		}
+		Expect(kept.phi.Sign()).To(Equal(0))
+		for _, shard := range kept.shards {
+			Expect(shard.D).To(BeNil())
+		}
+		Expect(handedOut.D.Sign()).NotTo(Equal(0))
+
+		_, err = dealer.HandOut(pub, 2)
+		Expect(errors.Is(err, ErrClosed)).To(BeTrue())
+		_, err = dealer.AddKey(priv)
+		Expect(errors.Is(err, ErrClosed)).To(BeTrue())
+		Expect(dealer.Close()).To(Succeed())
+	})
+
+	It("Hands out shards to concurrent callers exactly once", func() {
+		dealer := NewDealer(nil)
+		defer dealer.Close()
+		pub, err := dealer.GenerateKey(rand.Reader, 2048)
+		Expect(err).To(BeNil())
+		Expect(dealer.Split(context.Background(), pub, 4, Addition)).To(Succeed())
+
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		handedOut := 0
+		for i := 0; i < 16; i++ {
+			wg.Add(1)
+			go func(index int) {
+				defer wg.Done()
+				if _, err := dealer.HandOut(pub, index); err == nil {
+					mu.Lock()
+					handedOut++
+					mu.Unlock()
+				}
+			}(i%4 + 1)
+		}
+		wg.Wait()
+		Expect(handedOut).To(Equal(4))
+	})
+})
@@ -0,0 +1,211 @@
+package keysplitting
+
+import (
+	"crypto/rsa"
+	"crypto/subtle"
+	"math/big"
+)
+
+// A PartialDecryption is an RSA decryption performed by some, but not necessarily all, of the shards of a split key. Like a
+// [PartialSignature], it is extended by each shard in turn, or produced independently by the holders of additive shards and
+// combined, and [CombineDecryption] recovers the plaintext once every shard has contributed
+type PartialDecryption struct {
+	Decrypters []int   // indices of the shards that have decrypted, in the order they did so (0 if a shard's index is unknown)
+	SplitBy    SplitBy // the algorithm used to split the key, which determines how partial decryptions are combined
+	M          []byte  // the partially decrypted ciphertext, i.e. the ciphertext raised to the shards' exponents
+}
+
+// returns true if the shard with the given index has already contributed to pd. Unknown indices never match
+func (pd *PartialDecryption) decryptedBy(index int) bool {
+	if index == 0 {
+		return false
+	}
+	for _, decrypter := range pd.Decrypters {
+		if decrypter == index {
+			return true
+		}
+	}
+	return false
+}
+
+// DecryptFirst uses the given key shard to perform the initial decryption of an RSAES-PKCS1-v1_5 ciphertext, i.e.
+// ciphertext^shard (mod N). No padding is checked until the partial decryptions are combined with [CombineDecryption]
+func DecryptFirst(shard *PrivateKeyShard, ciphertext []byte) (pd *PartialDecryption, err error) {
+	defer func() { audit(AuditPartialDecrypt, shard.PublicKey, []int{shard.Index}, shard.SplitBy, 0, nil, err) }()
+
+	m, err := decryptWithShard(shard, ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	return &PartialDecryption{
+		Decrypters: []int{shard.Index},
+		SplitBy:    shard.SplitBy,
+		M:          m.FillBytes(make([]byte, shard.PublicKey.Size())),
+	}, nil
+}
+
+// DecryptNext uses the given key shard to add its decryption to a partially decrypted ciphertext, chaining the shards in the
+// same way as [SignNext]. It refuses to decrypt if pd was produced with a shard split by the other algorithm, or if the shard
+// has already decrypted it
+func DecryptNext(shard *PrivateKeyShard, ciphertext []byte, pd *PartialDecryption) (next *PartialDecryption, err error) {
+	defer func() { audit(AuditPartialDecrypt, shard.PublicKey, []int{shard.Index}, shard.SplitBy, 0, nil, err) }()
+
+	if pd.SplitBy != shard.SplitBy {
+		return nil, errorf(ErrInvalidPartialSignature, "cannot add a decryption from a shard split by %v to a partial decryption split by %v", shard.SplitBy, pd.SplitBy)
+	}
+	if pd.decryptedBy(shard.Index) {
+		return nil, errorf(ErrInvalidPartialSignature, "shard %d has already decrypted", shard.Index)
+	}
+	if err := checkCiphertext(shard.PublicKey, ciphertext); err != nil {
+		return nil, err
+	}
+	if err := shard.checkUsable(); err != nil {
+		return nil, err
+	}
+
+	N := shard.PublicKey.N
+	partialInt := new(big.Int).SetBytes(pd.M)
+	if partialInt.Cmp(N) >= 0 {
+		return nil, errorf(ErrInvalidPartialSignature, "partial decryption is out of range for the given public key")
+	}
+
+	var m *big.Int
+	switch shard.SplitBy {
+	case Multiplication:
+		if m, err = expSigned(partialInt, shard.exponent(ciphertext), N); err != nil {
+			return nil, errorf(ErrInvalidPartialSignature, "failed to add next decryption with the given shard and partial decryption")
+		}
+	case Addition:
+		if m, err = decryptWithShard(shard, ciphertext); err != nil {
+			return nil, err
+		}
+		m.Mul(m, partialInt).Mod(m, N)
+	default:
+		return nil, errorf(ErrUnsupportedSplitBy, "unrecognized split algorithm: %v", shard.SplitBy)
+	}
+
+	return &PartialDecryption{
+		Decrypters: append(append(make([]int, 0, len(pd.Decrypters)+1), pd.Decrypters...), shard.Index),
+		SplitBy:    pd.SplitBy,
+		M:          m.FillBytes(make([]byte, shard.PublicKey.Size())),
+	}, nil
+}
+
+// CombineDecryption recovers the plaintext of an RSAES-PKCS1-v1_5 ciphertext from partial decryptions. Given a single partial
+// decryption, to which every shard has contributed with [DecryptNext], it only removes the padding. Given several, which must be
+// from additive shards, it first multiplies them together as [CombinePartialSignatures] does.
+//
+// If the result doesn't match the ciphertext, e.g. because some shards haven't contributed, or its padding is invalid, it
+// returns [ErrDecryption]. The padding is checked in constant time, so that the combiner can't be used as a padding oracle as
+// long as its callers don't tell the failures apart either
+func CombineDecryption(pub *rsa.PublicKey, ciphertext []byte, partials ...*PartialDecryption) (plaintext []byte, err error) {
+	defer func() {
+		var parties []int
+		var splitBy SplitBy
+		for _, partial := range partials {
+			parties = append(parties, partial.Decrypters...)
+			splitBy = partial.SplitBy
+		}
+		audit(AuditDecrypt, pub, parties, splitBy, 0, nil, err)
+	}()
+
+	valid, em, index, err := combineDecryption(pub, ciphertext, partials)
+	if err != nil {
+		return nil, err
+	}
+	if valid == 0 {
+		return nil, ErrDecryption
+	}
+	return em[index:], nil
+}
+
+// combines partial decryptions as CombineDecryption does, returning the encoded message and, as for unpadPKCS1v15, whether its
+// padding is valid and where the message starts. An error is returned only for failures that depend on public values alone
+func combineDecryption(pub *rsa.PublicKey, ciphertext []byte, partials []*PartialDecryption) (valid int, em []byte, index int, err error) {
+	if len(partials) == 0 {
+		return 0, nil, 0, errorf(ErrTooFewShards, "no partial decryptions to combine")
+	}
+	if err := checkCiphertext(pub, ciphertext); err != nil {
+		return 0, nil, 0, err
+	}
+
+	m := big.NewInt(1)
+	combined := &PartialDecryption{}
+	for i, partial := range partials {
+		if len(partials) > 1 && partial.SplitBy != Addition {
+			return 0, nil, 0, errorf(ErrUnsupportedSplitBy, "only partial decryptions from additive shards can be combined")
+		}
+		for _, decrypter := range partial.Decrypters {
+			if combined.decryptedBy(decrypter) {
+				return 0, nil, 0, errorf(ErrInvalidPartialSignature, "shard %d contributed to more than one partial decryption", decrypter)
+			}
+			combined.Decrypters = append(combined.Decrypters, decrypter)
+		}
+
+		partialInt := new(big.Int).SetBytes(partial.M)
+		if partialInt.Cmp(pub.N) >= 0 {
+			return 0, nil, 0, errorf(ErrInvalidPartialSignature, "partial decryption #%d is out of range for the given public key", i)
+		}
+		m.Mul(m, partialInt).Mod(m, pub.N)
+	}
+
+	// m^e must be the ciphertext, which only depends on public values, so it's safe to check before the padding
+	if new(big.Int).Exp(m, big.NewInt(int64(pub.E)), pub.N).Cmp(new(big.Int).SetBytes(ciphertext)) != 0 {
+		return 0, nil, 0, ErrDecryption
+	}
+
+	em = m.FillBytes(make([]byte, pub.Size()))
+	valid, index = unpadPKCS1v15(em)
+	return valid, em, index, nil
+}
+
+// returns ciphertext^shard (mod N)
+func decryptWithShard(shard *PrivateKeyShard, ciphertext []byte) (*big.Int, error) {
+	if err := checkSplitBy(shard.SplitBy); err != nil {
+		return nil, err
+	}
+	if err := shard.checkUsable(); err != nil {
+		return nil, err
+	}
+	if err := checkCiphertext(shard.PublicKey, ciphertext); err != nil {
+		return nil, err
+	}
+
+	m, err := expSigned(new(big.Int).SetBytes(ciphertext), shard.exponent(ciphertext), shard.PublicKey.N)
+	if err != nil {
+		return nil, errorf(ErrDecryption, "ciphertext has no inverse modulo N")
+	}
+	return m, nil
+}
+
+// checks that ciphertext is a valid RSA ciphertext under pub. Like the padding, its length is public, so it can be checked
+// separately
+func checkCiphertext(pub *rsa.PublicKey, ciphertext []byte) error {
+	if pub.Size() < 11 {
+		return errorf(ErrDecryption, "key is too small for PKCS #1 v1.5 encryption")
+	}
+	if len(ciphertext) != pub.Size() || new(big.Int).SetBytes(ciphertext).Cmp(pub.N) >= 0 {
+		return errorf(ErrDecryption, "ciphertext is out of range for the given public key")
+	}
+	return nil
+}
+
+// checks the RSAES-PKCS1-v1_5 padding of em in constant time, as crypto/rsa does, returning 1 and the index of the first byte of
+// the message if it is valid, or 0 if it isn't
+func unpadPKCS1v15(em []byte) (valid int, index int) {
+	firstByteIsZero := subtle.ConstantTimeByteEq(em[0], 0)
+	secondByteIsTwo := subtle.ConstantTimeByteEq(em[1], 2)
+
+	// the padding ends at the first zero byte, which must be preceded by at least 8 nonzero bytes
+	lookingForIndex := 1
+	for i := 2; i < len(em); i++ {
+		equals0 := subtle.ConstantTimeByteEq(em[i], 0)
+		index = subtle.ConstantTimeSelect(lookingForIndex&equals0, i, index)
+		lookingForIndex = subtle.ConstantTimeSelect(equals0, 0, lookingForIndex)
+	}
+	validPS := subtle.ConstantTimeLessOrEq(2+8, index)
+
+	valid = firstByteIsZero & secondByteIsTwo & (^lookingForIndex & 1) & validPS
+	index = subtle.ConstantTimeSelect(valid, index+1, 0)
+	return valid, index
+}
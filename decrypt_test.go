@@ -0,0 +1,121 @@
+package keysplitting
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"math/big"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Split decryption", func() {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	message := []byte("TEST MESSAGE")
+	ciphertext, _ := rsa.EncryptPKCS1v15(rand.Reader, &key.PublicKey, message)
+
+	// decrypts ciphertext with each shard in turn
+	decryptAll := func(shards []*PrivateKeyShard, ciphertext []byte) (*PartialDecryption, error) {
+		pd, err := DecryptFirst(shards[0], ciphertext)
+		for _, shard := range shards[1:] {
+			if err != nil {
+				return nil, err
+			}
+			pd, err = DecryptNext(shard, ciphertext, pd)
+		}
+		return pd, err
+	}
+
+	It("Decrypts sequentially with shards split by either algorithm", func() {
+		for _, splitBy := range []SplitBy{Addition, Multiplication} {
+			shards, err := SplitD(key, 3, splitBy)
+			Expect(err).To(BeNil())
+
+			pd, err := decryptAll(shards, ciphertext)
+			Expect(err).To(BeNil())
+			Expect(pd.Decrypters).To(Equal([]int{1, 2, 3}))
+			plaintext, err := CombineDecryption(&key.PublicKey, ciphertext, pd)
+			Expect(err).To(BeNil())
+			Expect(plaintext).To(Equal(message))
+		}
+	})
+
+	It("Combines partial decryptions from additive shards", func() {
+		for _, opts := range []*SplitOptions{nil, {Mask: true}} {
+			shards, err := SplitDWithOptions(key, 3, Addition, opts)
+			Expect(err).To(BeNil())
+
+			partials := make([]*PartialDecryption, len(shards))
+			for i, shard := range shards {
+				partials[i], err = DecryptFirst(shard, ciphertext)
+				Expect(err).To(BeNil())
+			}
+			plaintext, err := CombineDecryption(&key.PublicKey, ciphertext, partials...)
+			Expect(err).To(BeNil())
+			Expect(plaintext).To(Equal(message))
+
+			_, err = CombineDecryption(&key.PublicKey, ciphertext, partials[0], partials[1])
+			Expect(err).To(Equal(ErrDecryption))
+			_, err = CombineDecryption(&key.PublicKey, ciphertext, partials[0], partials[1], partials[0])
+			Expect(errors.Is(err, ErrInvalidPartialSignature)).To(BeTrue())
+		}
+	})
+
+	It("Refuses invalid padding without saying why", func() {
+		shards, err := SplitD(key, 2, Addition)
+		Expect(err).To(BeNil())
+
+		// a ciphertext whose plaintext lacks the 0x00 0x02 prefix
+		unpadded := new(big.Int).Exp(big.NewInt(42), big.NewInt(int64(key.E)), key.N).FillBytes(make([]byte, key.Size()))
+		pd, err := decryptAll(shards, unpadded)
+		Expect(err).To(BeNil())
+		_, err = CombineDecryption(&key.PublicKey, unpadded, pd)
+		Expect(err).To(Equal(ErrDecryption))
+	})
+
+	It("Refuses ciphertexts and partial decryptions that don't fit", func() {
+		shards, err := SplitD(key, 2, Addition)
+		Expect(err).To(BeNil())
+		_, err = DecryptFirst(shards[0], ciphertext[1:])
+		Expect(errors.Is(err, ErrDecryption)).To(BeTrue())
+		_, err = DecryptFirst(shards[0], key.N.Bytes())
+		Expect(errors.Is(err, ErrDecryption)).To(BeTrue())
+
+		pd, err := DecryptFirst(shards[0], ciphertext)
+		Expect(err).To(BeNil())
+		_, err = DecryptNext(shards[0], ciphertext, pd)
+		Expect(errors.Is(err, ErrInvalidPartialSignature)).To(BeTrue())
+
+		multiplicative, err := SplitD(key, 2, Multiplication)
+		Expect(err).To(BeNil())
+		_, err = DecryptNext(multiplicative[1], ciphertext, pd)
+		Expect(errors.Is(err, ErrInvalidPartialSignature)).To(BeTrue())
+
+		first, err := DecryptFirst(multiplicative[0], ciphertext)
+		Expect(err).To(BeNil())
+		_, err = CombineDecryption(&key.PublicKey, ciphertext, first, pd)
+		Expect(errors.Is(err, ErrUnsupportedSplitBy)).To(BeTrue())
+		_, err = CombineDecryption(&key.PublicKey, ciphertext)
+		Expect(errors.Is(err, ErrTooFewShards)).To(BeTrue())
+	})
+
+	It("Audits each use of a shard", func() {
+		sink := &recordingAuditSink{}
+		SetAuditSink(sink)
+		defer SetAuditSink(nil)
+
+		shards, err := SplitD(key, 2, Addition)
+		Expect(err).To(BeNil())
+		pd, err := decryptAll(shards, ciphertext)
+		Expect(err).To(BeNil())
+		_, err = CombineDecryption(&key.PublicKey, ciphertext, pd)
+		Expect(err).To(BeNil())
+
+		Expect(sink.take(AuditPartialDecrypt)).To(HaveLen(2))
+		records := sink.take(AuditDecrypt)
+		Expect(records).To(HaveLen(1))
+		Expect(records[0].Parties).To(Equal([]int{1, 2}))
+		Expect(records[0].Err).To(BeNil())
+	})
+})
@@ -0,0 +1,104 @@
+package keysplitting
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/subtle"
+	"io"
+)
+
+// A ShardDecrypter produces partial decryptions with a shard, just as a [ShardSigner] produces partial signatures. A
+// *PrivateKeyShard is the in-memory implementation
+type ShardDecrypter interface {
+	// Public returns the public key of the whole key that the shard belongs to
+	Public() crypto.PublicKey
+	// DecryptFirst is like the package-level [DecryptFirst] with this shard
+	DecryptFirst(ciphertext []byte) (*PartialDecryption, error)
+	// DecryptNext is like the package-level [DecryptNext] with this shard
+	DecryptNext(ciphertext []byte, pd *PartialDecryption) (*PartialDecryption, error)
+}
+
+// DecryptFirst calls the package-level [DecryptFirst] with the shard, so that a PrivateKeyShard satisfies [ShardDecrypter]
+func (pks *PrivateKeyShard) DecryptFirst(ciphertext []byte) (*PartialDecryption, error) {
+	return DecryptFirst(pks, ciphertext)
+}
+
+// DecryptNext calls the package-level [DecryptNext] with the shard, so that a PrivateKeyShard satisfies [ShardDecrypter]
+func (pks *PrivateKeyShard) DecryptNext(ciphertext []byte, pd *PartialDecryption) (*PartialDecryption, error) {
+	return DecryptNext(pks, ciphertext, pd)
+}
+
+// A SplitDecrypter implements [crypto.Decrypter] with every shard of a split key, so that libraries which decrypt through a
+// crypto.Decrypter, such as CMS and S/MIME implementations, can use a split key without the private key ever being reassembled.
+// Each call to Decrypt has every shard decrypt in turn, as with [DecryptNext], and removes the padding as [CombineDecryption]
+// does. Only RSAES-PKCS1-v1_5 is supported
+type SplitDecrypter struct {
+	pub    *rsa.PublicKey
+	shards []ShardDecrypter
+}
+
+// NewSplitDecrypter returns a SplitDecrypter that decrypts with the given shards, in order, all of which must belong to the same key
+func NewSplitDecrypter(shards ...ShardDecrypter) (*SplitDecrypter, error) {
+	if len(shards) < 2 {
+		return nil, errorf(ErrTooFewShards, "cannot decrypt with fewer than 2 shards")
+	}
+	pub, ok := shards[0].Public().(*rsa.PublicKey)
+	if !ok {
+		return nil, errorf(ErrKeyMismatch, "shard #0 doesn't belong to an RSA key")
+	}
+	for i, shard := range shards[1:] {
+		if other, ok := shard.Public().(*rsa.PublicKey); !ok || !pub.Equal(other) {
+			return nil, errorf(ErrKeyMismatch, "shard #%d belongs to a different key", i+1)
+		}
+	}
+	return &SplitDecrypter{pub: pub, shards: append([]ShardDecrypter{}, shards...)}, nil
+}
+
+// Public returns the public key of the split key
+func (d *SplitDecrypter) Public() crypto.PublicKey {
+	return d.pub
+}
+
+// Decrypt implements [crypto.Decrypter]. opts must be nil or a *[rsa.PKCS1v15DecryptOptions], and, as with
+// [rsa.DecryptPKCS1v15SessionKey], a non-zero SessionKeyLen returns a random key of that length, read from random, instead of
+// an error if the plaintext is invalid or of the wrong length
+func (d *SplitDecrypter) Decrypt(random io.Reader, ciphertext []byte, opts crypto.DecrypterOpts) (plaintext []byte, err error) {
+	sessionKeyLen := 0
+	switch o := opts.(type) {
+	case nil:
+	case *rsa.PKCS1v15DecryptOptions:
+		sessionKeyLen = o.SessionKeyLen
+	default:
+		return nil, errorf(ErrDecryption, "split keys only support PKCS #1 v1.5 decryption")
+	}
+
+	pd, err := d.shards[0].DecryptFirst(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	for _, shard := range d.shards[1:] {
+		if pd, err = shard.DecryptNext(ciphertext, pd); err != nil {
+			return nil, err
+		}
+	}
+	if sessionKeyLen == 0 {
+		return CombineDecryption(d.pub, ciphertext, pd)
+	}
+
+	// as in crypto/rsa, a random key is always drawn, and overwritten in constant time only if the plaintext is valid
+	defer func() { audit(AuditDecrypt, d.pub, pd.Decrypters, pd.SplitBy, 0, nil, err) }()
+	key := make([]byte, sessionKeyLen)
+	if _, err := io.ReadFull(random, key); err != nil {
+		return nil, &RandomnessError{Err: err}
+	}
+	valid, em, index, err := combineDecryption(d.pub, ciphertext, []*PartialDecryption{pd})
+	if err != nil {
+		return nil, err
+	}
+	if sessionKeyLen > len(em) {
+		return key, nil
+	}
+	valid &= subtle.ConstantTimeEq(int32(len(em)-index), int32(sessionKeyLen))
+	subtle.ConstantTimeCopy(valid, key, em[len(em)-sessionKeyLen:])
+	return key, nil
+}
@@ -0,0 +1,77 @@
+package keysplitting
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Split decrypter", func() {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	message := []byte("TEST MESSAGE")
+	ciphertext, _ := rsa.EncryptPKCS1v15(rand.Reader, &key.PublicKey, message)
+
+	It("Decrypts through crypto.Decrypter with shards split by either algorithm", func() {
+		for _, splitBy := range []SplitBy{Addition, Multiplication} {
+			shards, err := SplitD(key, 3, splitBy)
+			Expect(err).To(BeNil())
+			decrypter, err := NewSplitDecrypter(shards[0], shards[1], shards[2])
+			Expect(err).To(BeNil())
+
+			var _ crypto.Decrypter = decrypter
+			Expect(decrypter.Public()).To(Equal(&key.PublicKey))
+			plaintext, err := decrypter.Decrypt(rand.Reader, ciphertext, nil)
+			Expect(err).To(BeNil())
+			Expect(plaintext).To(Equal(message))
+		}
+	})
+
+	It("Returns a random session key instead of revealing invalid padding", func() {
+		shards, err := SplitD(key, 2, Addition)
+		Expect(err).To(BeNil())
+		decrypter, err := NewSplitDecrypter(shards[0], shards[1])
+		Expect(err).To(BeNil())
+
+		sessionKey := bytes.Repeat([]byte{7}, 16)
+		encrypted, err := rsa.EncryptPKCS1v15(rand.Reader, &key.PublicKey, sessionKey)
+		Expect(err).To(BeNil())
+		opts := &rsa.PKCS1v15DecryptOptions{SessionKeyLen: len(sessionKey)}
+		Expect(decrypter.Decrypt(rand.Reader, encrypted, opts)).To(Equal(sessionKey))
+
+		// a plaintext of the wrong length, and one without valid padding
+		unpadded := new(big.Int).Exp(big.NewInt(42), big.NewInt(int64(key.E)), key.N).FillBytes(make([]byte, key.Size()))
+		for _, c := range [][]byte{ciphertext, unpadded} {
+			random := bytes.NewReader(bytes.Repeat([]byte{9}, len(sessionKey)))
+			Expect(decrypter.Decrypt(random, c, opts)).To(Equal(bytes.Repeat([]byte{9}, len(sessionKey))))
+		}
+	})
+
+	It("Refuses OAEP and shards of different keys", func() {
+		shards, err := SplitD(key, 2, Addition)
+		Expect(err).To(BeNil())
+		decrypter, err := NewSplitDecrypter(shards[0], shards[1])
+		Expect(err).To(BeNil())
+		_, err = decrypter.Decrypt(rand.Reader, ciphertext, &rsa.OAEPOptions{Hash: crypto.SHA256})
+		Expect(errors.Is(err, ErrDecryption)).To(BeTrue())
+
+		other, err := rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).To(BeNil())
+		otherShards, err := SplitD(other, 2, Addition)
+		Expect(err).To(BeNil())
+		_, err = NewSplitDecrypter(shards[0], otherShards[1])
+		Expect(errors.Is(err, ErrKeyMismatch)).To(BeTrue())
+		_, err = NewSplitDecrypter(shards[0])
+		Expect(errors.Is(err, ErrTooFewShards)).To(BeTrue())
+
+		hashed := sha256.Sum256(message)
+		_, err = decrypter.Decrypt(rand.Reader, hashed[:], nil)
+		Expect(errors.Is(err, ErrDecryption)).To(BeTrue())
+	})
+})
@@ -0,0 +1,123 @@
+package keysplitting
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"fmt"
+)
+
+// A MisbehavingSignersError identifies the parties whose partial signatures kept a signature from verifying, so that the
+// right custodians can be contacted. It wraps [ErrInvalidPartialSignature]
+type MisbehavingSignersError struct {
+	Signers []int // the indices of the shards or threshold shares whose partial signatures are invalid
+}
+
+func (e *MisbehavingSignersError) Error() string {
+	return fmt.Sprintf("invalid partial signatures from shards %v", e.Signers)
+}
+
+func (e *MisbehavingSignersError) Unwrap() error {
+	return ErrInvalidPartialSignature
+}
+
+// DiagnosePartialSignatures checks the proof attached to each of the partial signatures produced independently by the holders
+// of additive shards, as with [VerifyPartialSignature], e.g. after [CombinePartialSignatures] produced a signature that doesn't
+// verify, and returns a *[MisbehavingSignersError] naming the shards whose partial signatures are missing a valid proof, or nil
+// if every one of them is proven. opts and hashed are those the shards were asked to sign, as for [SignFirst]. Partial
+// signatures bound to a session nonce are checked against the nonce they record
+func DiagnosePartialSignatures(commitments *ShardCommitments, opts crypto.SignerOpts, hashed []byte, partials ...*PartialSignature) error {
+	if opts == nil {
+		return errorf(ErrUnsupportedHash, "no signer options provided")
+	}
+
+	var misbehaving []int
+	for i, partial := range partials {
+		if len(partial.Signers) != 1 {
+			return errorf(ErrInvalidPartialSignature, "partial signature #%d has %d signers, but only partial signatures from a single shard can be checked", i, len(partial.Signers))
+		}
+		index := partial.Signers[0]
+		if index < 1 || index > len(commitments.Vi) {
+			return errorf(ErrInvalidPartialSignature, "there is no commitment to shard %d", index)
+		}
+		if commitments.verify(opts, hashed, partial) != nil {
+			misbehaving = append(misbehaving, index)
+		}
+	}
+
+	if len(misbehaving) > 0 {
+		return &MisbehavingSignersError{Signers: misbehaving}
+	}
+	return nil
+}
+
+// DiagnoseThreshold identifies invalid partial signatures from a t-of-n split by elimination, without any verification
+// material, e.g. after [CombineThreshold] failed. It needs more than t partial signatures, at least t of which are valid:
+// it first looks for t of them that combine into a valid signature, and then swaps each of the others in turn into that set.
+// It returns a *[MisbehavingSignersError] naming the shares whose partial signatures are invalid, or nil if every one of them
+// is valid. Looking for the first t can take up to C(len(partials), t) attempts, so it is meant for a handful of extra partial
+// signatures rather than many
+func DiagnoseThreshold(pub *rsa.PublicKey, t, n int, hashFn crypto.Hash, hashed []byte, partials ...*ThresholdPartialSignature) error {
+	if t < 1 || len(partials) <= t {
+		return errorf(ErrTooFewShards, "identifying invalid partial signatures requires more than %d partial signatures", t)
+	}
+
+	good := validThresholdSubset(pub, t, n, hashFn, hashed, partials)
+	if good == nil {
+		return errorf(ErrInvalidPartialSignature, "no %d of the partial signatures combine into a valid signature, so the invalid ones can't be identified", t)
+	}
+
+	inGood := make([]bool, len(partials))
+	for _, i := range good {
+		inGood[i] = true
+	}
+	candidates := make([]*ThresholdPartialSignature, t)
+	for i, j := range good[1:] {
+		candidates[i+1] = partials[j]
+	}
+
+	var misbehaving []int
+	for i, partial := range partials {
+		if inGood[i] {
+			continue
+		}
+		candidates[0] = partial
+		if _, err := combineThreshold(pub, t, n, hashFn, hashed, candidates); err != nil {
+			misbehaving = append(misbehaving, partial.Index)
+		}
+	}
+
+	if len(misbehaving) > 0 {
+		return &MisbehavingSignersError{Signers: misbehaving}
+	}
+	return nil
+}
+
+// returns the positions of the first t partials, in lexicographic order, that combine into a valid signature, or nil if there are none
+func validThresholdSubset(pub *rsa.PublicKey, t, n int, hashFn crypto.Hash, hashed []byte, partials []*ThresholdPartialSignature) []int {
+	positions := make([]int, t)
+	for i := range positions {
+		positions[i] = i
+	}
+	candidates := make([]*ThresholdPartialSignature, t)
+	for {
+		for i, position := range positions {
+			candidates[i] = partials[position]
+		}
+		if _, err := combineThreshold(pub, t, n, hashFn, hashed, candidates); err == nil {
+			return positions
+		}
+
+		// advance to the next combination
+		i := t - 1
+		for i >= 0 && positions[i] == len(partials)-t+i {
+			i--
+		}
+		if i < 0 {
+			return nil
+		}
+		positions[i]++
+		for j := i + 1; j < t; j++ {
+			positions[j] = positions[j-1] + 1
+		}
+	}
+}
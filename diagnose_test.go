@@ -0,0 +1,153 @@
+package keysplitting
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Misbehaving signers", func() {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	hashed := sha256.Sum256([]byte("TEST MESSAGE"))
+	otherHashed := sha256.Sum256([]byte("OTHER MESSAGE"))
+
+	// returns the signers named by err, which must be a *MisbehavingSignersError
+	misbehaving := func(err error) []int {
+		var misbehavingErr *MisbehavingSignersError
+		Expect(errors.As(err, &misbehavingErr)).To(BeTrue())
+		Expect(errors.Is(err, ErrInvalidPartialSignature)).To(BeTrue())
+		return misbehavingErr.Signers
+	}
+
+	Context("With shard commitments", func() {
+		shards, commitments, _ := SplitDWithCommitments(key, 4, nil)
+
+		// returns a partial signature on digest from shard, proven as one on the digest it was signed on
+		signProven := func(shard *PrivateKeyShard, opts crypto.SignerOpts, digest []byte) *PartialSignature {
+			partial, err := SignFirst(rand.Reader, shard, opts, digest)
+			Expect(err).To(BeNil())
+			Expect(commitments.Prove(rand.Reader, shard, opts, digest, partial)).To(Succeed())
+			return partial
+		}
+
+		It("Identifies the shards that signed something else", func() {
+			partials := make([]*PartialSignature, len(shards))
+			for i, shard := range shards {
+				digest := hashed[:]
+				if i == 1 || i == 3 {
+					digest = otherHashed[:]
+				}
+				partials[i] = signProven(shard, crypto.SHA256, digest)
+			}
+			sig, err := CombinePartialSignatures(&key.PublicKey, partials...)
+			Expect(err).To(BeNil())
+			Expect(VerifyFinal(&key.PublicKey, crypto.SHA256, hashed[:], sig, Addition)).NotTo(Succeed())
+
+			Expect(misbehaving(DiagnosePartialSignatures(commitments, crypto.SHA256, hashed[:], partials...))).To(Equal([]int{2, 4}))
+			Expect(DiagnosePartialSignatures(commitments, crypto.SHA256, hashed[:], partials[0], partials[2])).To(Succeed())
+		})
+
+		It("Identifies the shards that didn't prove their partial signatures", func() {
+			partials := make([]*PartialSignature, len(shards))
+			for i, shard := range shards {
+				partials[i] = signProven(shard, crypto.SHA256, hashed[:])
+			}
+			partials[2].Proof = nil
+			Expect(misbehaving(DiagnosePartialSignatures(commitments, crypto.SHA256, hashed[:], partials...))).To(Equal([]int{3}))
+		})
+
+		It("Checks nonce-bound and PSS partial signatures", func() {
+			salt, err := NewPSSSalt(rand.Reader, &key.PublicKey, crypto.SHA256, nil)
+			Expect(err).To(BeNil())
+			nonce, err := NewNonce(rand.Reader)
+			Expect(err).To(BeNil())
+			opts := &NonceOptions{Opts: &PSSOptions{Hash: crypto.SHA256, Salt: salt}, Nonce: nonce}
+
+			partials := make([]*PartialSignature, len(shards))
+			for i, shard := range shards {
+				partials[i] = signProven(shard, opts, hashed[:])
+			}
+			Expect(DiagnosePartialSignatures(commitments, opts, hashed[:], partials...)).To(Succeed())
+
+			// a partial signature from another session, relabeled with this session's nonce, doesn't verify
+			otherNonce, err := NewNonce(rand.Reader)
+			Expect(err).To(BeNil())
+			replayed := signProven(shards[2], &NonceOptions{Opts: opts.Opts, Nonce: otherNonce}, hashed[:])
+			replayed.Nonce = nonce
+			Expect(misbehaving(DiagnosePartialSignatures(commitments, opts, hashed[:], partials[0], partials[1], replayed, partials[3]))).To(Equal([]int{3}))
+		})
+
+		It("Refuses partial signatures it can't attribute to a single shard", func() {
+			first := signProven(shards[0], crypto.SHA256, hashed[:])
+			next, err := SignNext(rand.Reader, shards[1], crypto.SHA256, hashed[:], first)
+			Expect(err).To(BeNil())
+			err = DiagnosePartialSignatures(commitments, crypto.SHA256, hashed[:], next)
+			Expect(errors.Is(err, ErrInvalidPartialSignature)).To(BeTrue())
+			Expect(errors.As(err, new(*MisbehavingSignersError))).To(BeFalse())
+
+			err = DiagnosePartialSignatures(commitments, crypto.SHA256, hashed[:], first, &PartialSignature{Signers: []int{5}, Hash: crypto.SHA256, Sig: first.Sig})
+			Expect(errors.As(err, new(*MisbehavingSignersError))).To(BeFalse())
+		})
+
+		It("Names the misbehaving shards from a session", func() {
+			session, err := NewSession(&key.PublicKey, Addition, len(shards), crypto.SHA256, hashed[:])
+			Expect(err).To(BeNil())
+			session.SetCommitments(commitments)
+			for i, shard := range shards {
+				digest := hashed[:]
+				if i == 2 {
+					digest = otherHashed[:]
+				}
+				Expect(session.AddPartial(signProven(shard, session.SignerOpts(), digest))).To(Succeed())
+			}
+			_, err = session.Signature()
+			Expect(misbehaving(err)).To(Equal([]int{3}))
+		})
+	})
+
+	Context("With more threshold partial signatures than needed", func() {
+		t, n := 3, 5
+		shares, _ := SplitThreshold(key, t, n)
+
+		sign := func(bad ...int) []*ThresholdPartialSignature {
+			partials := make([]*ThresholdPartialSignature, n)
+			for i, share := range shares {
+				digest := hashed[:]
+				for _, b := range bad {
+					if share.Index == b {
+						digest = otherHashed[:]
+					}
+				}
+				var err error
+				partials[i], err = SignThreshold(share, crypto.SHA256, digest)
+				Expect(err).To(BeNil())
+			}
+			return partials
+		}
+
+		It("Identifies invalid partial signatures by elimination", func() {
+			partials := sign(1, 4)
+			_, err := CombineThreshold(&key.PublicKey, t, n, crypto.SHA256, hashed[:], partials...)
+			Expect(errors.Is(err, ErrInvalidPartialSignature)).To(BeTrue())
+
+			Expect(misbehaving(DiagnoseThreshold(&key.PublicKey, t, n, crypto.SHA256, hashed[:], partials...))).To(Equal([]int{1, 4}))
+			Expect(DiagnoseThreshold(&key.PublicKey, t, n, crypto.SHA256, hashed[:], sign()...)).To(Succeed())
+		})
+
+		It("Needs more than t partial signatures, at least t of them valid", func() {
+			partials := sign(2)
+			err := DiagnoseThreshold(&key.PublicKey, t, n, crypto.SHA256, hashed[:], partials[:t]...)
+			Expect(errors.Is(err, ErrTooFewShards)).To(BeTrue())
+
+			partials = sign(1, 2, 3)
+			err = DiagnoseThreshold(&key.PublicKey, t, n, crypto.SHA256, hashed[:], partials...)
+			Expect(errors.Is(err, ErrInvalidPartialSignature)).To(BeTrue())
+			Expect(errors.As(err, new(*MisbehavingSignersError))).To(BeFalse())
+		})
+	})
+})
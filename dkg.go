@@ -0,0 +1,488 @@
+package keysplitting
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/binary"
+	"math/big"
+)
+
+// Distributed key generation (DKG) follows Boneh and Franklin [4]. The parties jointly pick N = (p1 + ... + pn) * (q1 + ... + qn),
+// where party i only ever knows pi and qi, and then derive additive shards of D without anyone learning phi(N). The full private
+// key never exists anywhere. The protocol assumes the parties are honest-but-curious, i.e. they follow the protocol but may try to
+// learn more than they should from the messages they see. It requires at least 3 parties, since N is computed with the BGW protocol,
+// which tolerates up to (n-1)/2 colluding parties.
+//
+// Each attempt runs in rounds, and every party must complete a round before any party can start the next one:
+//
+//  1. Every party calls [DKGParty.Candidate] and sends the j'th message to party j (including itself)
+//  2. Every party calls [DKGParty.ModulusShare] with the n messages it received, and broadcasts the result
+//  3. Every party calls [DKGParty.Modulus] with the n broadcasts. If the candidate modulus is rejected, start over at round 1
+//  4. Every party calls [DKGParty.BiprimalityShare] and broadcasts the result
+//  5. Every party calls [DKGParty.CheckBiprimality] with the n broadcasts. If N is not the product of two primes, start over at round 1
+//  6. Every party calls [DKGParty.ExponentShare] and broadcasts the result
+//  7. Every party calls [DKGParty.TestSignature] with the n broadcasts, and broadcasts the result
+//  8. Every party calls [DKGParty.Finalize] with the n broadcasts to obtain its shard
+//
+// Most candidates are rejected, so expect many attempts. Note that the biprimality test omits the second ("twisted group") stage
+// of [4], which only matters for moduli with repeated prime factors and is astronomically unlikely to be needed for random candidates
+
+// number of random bases used by the biprimality test, each of which has at least a 1/2 chance to catch a non-biprime modulus
+const dkgBiprimalityRounds = 64
+
+// candidate moduli with a prime factor smaller than this are rejected before the more expensive biprimality test
+const dkgTrialDivisionBound = 5000
+
+// digest signed to test the final shards
+var dkgTestDigest = sha256.Sum256([]byte("keysplitting distributed key generation"))
+
+// A DKGParty is one participant in distributed key generation. See [NewDKGParty]
+type DKGParty struct {
+	index int // this party's position in 1..n
+	n     int // number of parties
+	bits  int // size of the modulus
+	e     int // public exponent
+
+	halfBits  int      // size of each party's prime shares
+	bound     *big.Int // upper bound for the sum of a party's prime shares
+	field     *big.Int // order of the field used to compute N
+	primorial *big.Int // product of the primes used for trial division
+
+	// per-attempt state
+	p, q     *big.Int
+	N        *big.Int
+	d        *big.Int
+	verified bool
+}
+
+// A DKGCandidateMessage carries Shamir shares of the sender's candidate prime shares to a single recipient
+type DKGCandidateMessage struct {
+	From, To int
+	P, Q, H  *big.Int // the sender's polynomials for p and q, and its share of zero, evaluated at To
+}
+
+// A DKGModulusShare is a party's share of the candidate modulus
+type DKGModulusShare struct {
+	From int
+	N    *big.Int
+}
+
+// A DKGBiprimalityShare is a party's contribution to the biprimality test of the candidate modulus
+type DKGBiprimalityShare struct {
+	From int
+	V    []*big.Int
+}
+
+// A DKGExponentShare is a party's share of phi(N), reduced modulo the public exponent
+type DKGExponentShare struct {
+	From    int
+	PhiModE *big.Int
+}
+
+// NewDKGParty creates party index (in 1..n) of an n-party distributed key generation for a bits-sized modulus with public exponent e
+func NewDKGParty(index, n, bits, e int) (*DKGParty, error) {
+	if n < 3 {
+		return nil, errorf(ErrTooFewShards, "distributed key generation requires at least 3 parties")
+	}
+	if index < 1 || index > n {
+		return nil, errorf(ErrInvalidShard, "party index %d is out of range", index)
+	}
+	if !big.NewInt(int64(e)).ProbablyPrime(20) || e < 3 {
+		return nil, errorf(ErrInvalidKey, "public exponent must be an odd prime")
+	}
+
+	// each party picks prime shares of halfBits bits, so that p, q < n * 2^halfBits and N < n^2 * 2^(2 * halfBits)
+	nBits := big.NewInt(int64(n)).BitLen()
+	halfBits := bits/2 - nBits + 1
+	if halfBits < 16 {
+		return nil, errorf(ErrInvalidKey, "modulus size is too small")
+	}
+
+	bound := new(big.Int).Lsh(bigOne, uint(halfBits+1))
+	// every party must agree on the field, so use the smallest prime larger than the largest possible N
+	field := new(big.Int).Lsh(bigOne, uint(bits+2*nBits+2))
+	field.Add(field, bigOne)
+	for !field.ProbablyPrime(20) {
+		field.Add(field, bigOne)
+	}
+
+	primorial := big.NewInt(1)
+	for _, prime := range smallPrimes(dkgTrialDivisionBound) {
+		primorial.Mul(primorial, prime)
+	}
+
+	return &DKGParty{
+		index:     index,
+		n:         n,
+		bits:      bits,
+		e:         e,
+		halfBits:  halfBits,
+		bound:     bound,
+		field:     field,
+		primorial: primorial,
+	}, nil
+}
+
+// Candidate starts a new attempt by picking this party's shares of the candidate primes.
+// It returns one message for each party, in order of their index
+func (party *DKGParty) Candidate() ([]*DKGCandidateMessage, error) {
+	party.N, party.d, party.verified = nil, nil, false
+
+	var err error
+	if party.p, err = party.randomPrimeShare(); err != nil {
+		return nil, err
+	}
+	if party.q, err = party.randomPrimeShare(); err != nil {
+		return nil, err
+	}
+
+	// BGW: share p and q with polynomials of degree l, and zero with a polynomial of degree 2l, so that the product of the
+	// sums of the shares is a sharing of N with degree 2l, which n >= 2l + 1 parties can interpolate
+	l := (party.n - 1) / 2
+	pPoly, err := randomPolynomial(party.p, l, party.field)
+	if err != nil {
+		return nil, err
+	}
+	qPoly, err := randomPolynomial(party.q, l, party.field)
+	if err != nil {
+		return nil, err
+	}
+	hPoly, err := randomPolynomial(bigZero, 2*l, party.field)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]*DKGCandidateMessage, party.n)
+	for j := 1; j <= party.n; j++ {
+		x := big.NewInt(int64(j))
+		messages[j-1] = &DKGCandidateMessage{
+			From: party.index,
+			To:   j,
+			P:    evaluatePolynomial(pPoly, x, party.field),
+			Q:    evaluatePolynomial(qPoly, x, party.field),
+			H:    evaluatePolynomial(hPoly, x, party.field),
+		}
+	}
+	return messages, nil
+}
+
+// ModulusShare combines the candidate messages sent to this party into its share of N
+func (party *DKGParty) ModulusShare(messages []*DKGCandidateMessage) (*DKGModulusShare, error) {
+	if err := party.checkSenders(len(messages), func(i int) int { return messages[i].From }); err != nil {
+		return nil, err
+	}
+
+	// N_j <- (sum of p_i(j)) * (sum of q_i(j)) + sum of h_i(j)
+	pSum, qSum, hSum := new(big.Int), new(big.Int), new(big.Int)
+	for _, message := range messages {
+		if message.To != party.index {
+			return nil, errorf(ErrInvalidMessage, "received a message intended for party %d", message.To)
+		}
+		pSum.Add(pSum, message.P)
+		qSum.Add(qSum, message.Q)
+		hSum.Add(hSum, message.H)
+	}
+
+	share := new(big.Int).Mul(pSum, qSum)
+	share.Add(share, hSum)
+	share.Mod(share, party.field)
+	return &DKGModulusShare{From: party.index, N: share}, nil
+}
+
+// Modulus interpolates the candidate modulus from every party's share. It returns false if the candidate is rejected
+// by trial division, in which case the parties must start a new attempt
+func (party *DKGParty) Modulus(shares []*DKGModulusShare) (bool, error) {
+	if err := party.checkSenders(len(shares), func(i int) int { return shares[i].From }); err != nil {
+		return false, err
+	}
+
+	// Lagrange interpolation at 0 over all n shares
+	N := new(big.Int)
+	for i, share := range shares {
+		num, den := big.NewInt(1), big.NewInt(1)
+		for j, other := range shares {
+			if i == j {
+				continue
+			}
+			num.Mul(num, big.NewInt(int64(other.From)))
+			den.Mul(den, big.NewInt(int64(other.From-share.From)))
+		}
+		den.Mod(den, party.field)
+		den.ModInverse(den, party.field)
+
+		term := new(big.Int).Mul(share.N, num)
+		term.Mul(term, den)
+		N.Add(N, term)
+		N.Mod(N, party.field)
+	}
+
+	if N.BitLen() != party.bits {
+		return false, nil
+	}
+	if new(big.Int).GCD(nil, nil, N, party.primorial).Cmp(bigOne) != 0 {
+		// N has a small prime factor
+		return false, nil
+	}
+
+	party.N = N
+	return true, nil
+}
+
+// BiprimalityShare returns this party's contribution to the test of whether N is the product of two primes
+func (party *DKGParty) BiprimalityShare() (*DKGBiprimalityShare, error) {
+	if party.N == nil {
+		return nil, errorf(ErrInvalidMessage, "no candidate modulus")
+	}
+
+	// party 1 raises to (N - p1 - q1 + 1) / 4, everyone else to (pi + qi) / 4, so the product of the results is
+	// g^(phi(N) / 4) times the inverse of the others' contributions
+	exponent := new(big.Int).Add(party.p, party.q)
+	if party.index == 1 {
+		exponent.Sub(party.N, exponent)
+		exponent.Add(exponent, bigOne)
+	}
+	exponent.Rsh(exponent, 2)
+
+	bases := biprimalityBases(party.N)
+	v := make([]*big.Int, len(bases))
+	for i, g := range bases {
+		v[i] = new(big.Int).Exp(g, exponent, party.N)
+	}
+	return &DKGBiprimalityShare{From: party.index, V: v}, nil
+}
+
+// CheckBiprimality returns true if, with overwhelming probability, N is the product of two primes.
+// Otherwise, the parties must start a new attempt
+func (party *DKGParty) CheckBiprimality(shares []*DKGBiprimalityShare) (bool, error) {
+	if party.N == nil {
+		return false, errorf(ErrInvalidMessage, "no candidate modulus")
+	}
+	if err := party.checkSenders(len(shares), func(i int) int { return shares[i].From }); err != nil {
+		return false, err
+	}
+
+	nMinusOne := new(big.Int).Sub(party.N, bigOne)
+	for round := 0; round < dkgBiprimalityRounds; round++ {
+		var v1 *big.Int
+		others := big.NewInt(1)
+		for _, share := range shares {
+			if len(share.V) != dkgBiprimalityRounds {
+				return false, errorf(ErrInvalidMessage, "biprimality share from party %d is malformed", share.From)
+			}
+			if share.From == 1 {
+				v1 = share.V[round]
+			} else {
+				others.Mul(others, share.V[round])
+				others.Mod(others, party.N)
+			}
+		}
+
+		// v1 ≡ ±(product of the others' values) (mod N)
+		if v1.Cmp(others) != 0 && new(big.Int).Mod(new(big.Int).Mul(v1, nMinusOne), party.N).Cmp(others) != 0 {
+			return false, nil
+		}
+	}
+
+	party.verified = true
+	return true, nil
+}
+
+// ExponentShare reveals this party's share of phi(N) modulo the public exponent, which the parties need in order to invert E
+// without knowing phi(N). As in [4], this reveals about log2(E) bits of each party's share
+func (party *DKGParty) ExponentShare() (*DKGExponentShare, error) {
+	if !party.verified {
+		return nil, errorf(ErrInvalidMessage, "modulus has not passed the biprimality test")
+	}
+	return &DKGExponentShare{
+		From:    party.index,
+		PhiModE: new(big.Int).Mod(party.phiShare(), big.NewInt(int64(party.e))),
+	}, nil
+}
+
+// TestSignature derives this party's shard of D from every party's exponent share, and uses it to sign a fixed test
+// message, so that the parties can correct for the rounding error in their shards
+func (party *DKGParty) TestSignature(shares []*DKGExponentShare) (*PartialSignature, error) {
+	if !party.verified {
+		return nil, errorf(ErrInvalidMessage, "modulus has not passed the biprimality test")
+	}
+	if err := party.checkSenders(len(shares), func(i int) int { return shares[i].From }); err != nil {
+		return nil, err
+	}
+
+	// psi <- phi(N) (mod E), zeta <- -psi^-1 (mod E), so that zeta * phi(N) + 1 ≡ 0 (mod E)
+	e := big.NewInt(int64(party.e))
+	psi := new(big.Int)
+	for _, share := range shares {
+		psi.Add(psi, share.PhiModE)
+	}
+	psi.Mod(psi, e)
+	zeta := new(big.Int).ModInverse(psi, e)
+	if zeta == nil {
+		return nil, errorf(ErrInvalidKey, "public exponent divides phi(N); the parties must start a new attempt")
+	}
+	zeta.Sub(e, zeta)
+
+	// D = (zeta * phi(N) + 1) / E. Each party takes the floor of its own term, so their sum is D - r for some 0 <= r < n
+	party.d = new(big.Int).Mul(zeta, party.phiShare())
+	if party.index == 1 {
+		party.d.Add(party.d, bigOne)
+	}
+	party.d.Quo(party.d, e)
+
+	return SignFirst(nil, party.shard(), crypto.SHA256, dkgTestDigest[:])
+}
+
+// Finalize uses every party's test signature to correct the rounding error in the shards and returns this party's shard.
+// Party 1 absorbs the correction. All parties check that the final shards produce valid signatures
+func (party *DKGParty) Finalize(partials []*PartialSignature) (*PrivateKeyShard, error) {
+	if party.d == nil {
+		return nil, errorf(ErrInvalidMessage, "no test signature has been produced")
+	}
+	for _, partial := range partials {
+		if len(partial.Signers) != 1 {
+			return nil, errorf(ErrInvalidPartialSignature, "test signatures must each come from a single party")
+		}
+	}
+	if err := party.checkSenders(len(partials), func(i int) int { return partials[i].Signers[0] }); err != nil {
+		return nil, err
+	}
+
+	pub := &rsa.PublicKey{N: party.N, E: party.e}
+	sig, err := CombinePartialSignatures(pub, partials...)
+	if err != nil {
+		return nil, err
+	}
+
+	em, err := emsaPKCS1v15Encode(crypto.SHA256, dkgTestDigest[:], pub.Size())
+	if err != nil {
+		return nil, err
+	}
+	x := new(big.Int).SetBytes(em)
+	y := new(big.Int).SetBytes(sig)
+	e := big.NewInt(int64(party.e))
+
+	// find r such that (sig * x^r)^E ≡ x (mod N)
+	for r := 0; r < party.n; r++ {
+		if new(big.Int).Exp(y, e, party.N).Cmp(x) == 0 {
+			if party.index == 1 {
+				party.d.Add(party.d, big.NewInt(int64(r)))
+			}
+			return party.shard(), nil
+		}
+		y.Mul(y, x)
+		y.Mod(y, party.N)
+	}
+
+	return nil, errorf(ErrInvalidPartialSignature, "test signatures do not combine into a valid signature")
+}
+
+// returns this party's current shard
+func (party *DKGParty) shard() *PrivateKeyShard {
+	return &PrivateKeyShard{
+		PublicKey: &rsa.PublicKey{N: party.N, E: party.e},
+		D:         new(big.Int).Set(party.d),
+		SplitBy:   Addition,
+		Index:     party.index,
+	}
+}
+
+// returns this party's additive share of phi(N) = N + 1 - (p1 + q1) - ... - (pn + qn). To keep every share positive,
+// every party except the first adds a public bound B to its share, and the first subtracts (n-1) * B
+func (party *DKGParty) phiShare() *big.Int {
+	sum := new(big.Int).Add(party.p, party.q)
+	if party.index != 1 {
+		return new(big.Int).Sub(party.bound, sum)
+	}
+
+	share := new(big.Int).Add(party.N, bigOne)
+	share.Sub(share, sum)
+	share.Sub(share, new(big.Int).Mul(big.NewInt(int64(party.n-1)), party.bound))
+	return share
+}
+
+// returns a random halfBits-bit prime share. Party 1's share is 3 (mod 4) and everyone else's is 0 (mod 4),
+// so that p ≡ 3 (mod 4), as the biprimality test requires
+func (party *DKGParty) randomPrimeShare() (*big.Int, error) {
+	share, err := rand.Int(rand.Reader, new(big.Int).Lsh(bigOne, uint(party.halfBits-1)))
+	if err != nil {
+		return nil, &RandomnessError{Err: err}
+	}
+	share.SetBit(share, party.halfBits-1, 1)
+	share.SetBit(share, 0, 0)
+	share.SetBit(share, 1, 0)
+	if party.index == 1 {
+		share.Add(share, big.NewInt(3))
+	}
+	return share, nil
+}
+
+// checks that there is exactly one message from each party
+func (party *DKGParty) checkSenders(count int, from func(i int) int) error {
+	if count != party.n {
+		return errorf(ErrInvalidMessage, "expected messages from %d parties, got %d", party.n, count)
+	}
+	seen := make(map[int]bool)
+	for i := 0; i < count; i++ {
+		sender := from(i)
+		if sender < 1 || sender > party.n || seen[sender] {
+			return errorf(ErrInvalidMessage, "unexpected or duplicate message from party %d", sender)
+		}
+		seen[sender] = true
+	}
+	return nil
+}
+
+// returns a polynomial of the given degree with constant term secret and random coefficients (mod m)
+func randomPolynomial(secret *big.Int, degree int, m *big.Int) ([]*big.Int, error) {
+	coefficients := make([]*big.Int, degree+1)
+	coefficients[0] = new(big.Int).Mod(secret, m)
+	for i := 1; i <= degree; i++ {
+		a, err := rand.Int(rand.Reader, m)
+		if err != nil {
+			return nil, &RandomnessError{Err: err}
+		}
+		coefficients[i] = a
+	}
+	return coefficients, nil
+}
+
+// deterministically derives the bases for the biprimality test from N, so that all parties agree on them without another round
+func biprimalityBases(N *big.Int) []*big.Int {
+	bases := make([]*big.Int, 0, dkgBiprimalityRounds)
+	buf := make([]byte, (N.BitLen()+7)/8+8)
+	for counter := uint64(0); len(bases) < dkgBiprimalityRounds; counter++ {
+		// expand SHA-256(N || counter || block) to the size of N
+		for offset := 0; offset < len(buf); offset += sha256.Size {
+			h := sha256.New()
+			h.Write(N.Bytes())
+			binary.Write(h, binary.BigEndian, counter)
+			binary.Write(h, binary.BigEndian, uint64(offset))
+			copy(buf[offset:], h.Sum(nil))
+		}
+
+		g := new(big.Int).SetBytes(buf)
+		g.Mod(g, N)
+		if big.Jacobi(g, N) == 1 {
+			bases = append(bases, g)
+		}
+	}
+	return bases
+}
+
+// returns all primes less than bound
+func smallPrimes(bound int) []*big.Int {
+	composite := make([]bool, bound)
+	primes := make([]*big.Int, 0)
+	for i := 2; i < bound; i++ {
+		if composite[i] {
+			continue
+		}
+		primes = append(primes, big.NewInt(int64(i)))
+		for j := i * i; j < bound; j += i {
+			composite[j] = true
+		}
+	}
+	return primes
+}
@@ -0,0 +1,133 @@
+package keysplitting
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// runs every round of distributed key generation among the given parties, exchanging messages in memory
+func runDKG(parties []*DKGParty) []*PrivateKeyShard {
+	n := len(parties)
+	for {
+		// round 1: everyone sends each party its shares of their candidate primes
+		inboxes := make([][]*DKGCandidateMessage, n)
+		for _, party := range parties {
+			messages, err := party.Candidate()
+			Expect(err).To(BeNil())
+			for j, message := range messages {
+				inboxes[j] = append(inboxes[j], message)
+			}
+		}
+
+		// rounds 2 and 3: everyone broadcasts their share of N and interpolates it
+		modulusShares := make([]*DKGModulusShare, n)
+		for i, party := range parties {
+			share, err := party.ModulusShare(inboxes[i])
+			Expect(err).To(BeNil())
+			modulusShares[i] = share
+		}
+		accepted := true
+		for _, party := range parties {
+			ok, err := party.Modulus(modulusShares)
+			Expect(err).To(BeNil())
+			accepted = accepted && ok
+		}
+		if !accepted {
+			continue
+		}
+
+		// rounds 4 and 5: biprimality test
+		biprimalityShares := make([]*DKGBiprimalityShare, n)
+		for i, party := range parties {
+			share, err := party.BiprimalityShare()
+			Expect(err).To(BeNil())
+			biprimalityShares[i] = share
+		}
+		for _, party := range parties {
+			ok, err := party.CheckBiprimality(biprimalityShares)
+			Expect(err).To(BeNil())
+			accepted = accepted && ok
+		}
+		if !accepted {
+			continue
+		}
+
+		// rounds 6 through 8: derive the shards and correct them
+		exponentShares := make([]*DKGExponentShare, n)
+		for i, party := range parties {
+			share, err := party.ExponentShare()
+			Expect(err).To(BeNil())
+			exponentShares[i] = share
+		}
+		partials := make([]*PartialSignature, n)
+		for i, party := range parties {
+			partial, err := party.TestSignature(exponentShares)
+			Expect(err).To(BeNil())
+			partials[i] = partial
+		}
+		shards := make([]*PrivateKeyShard, n)
+		for i, party := range parties {
+			shard, err := party.Finalize(partials)
+			Expect(err).To(BeNil())
+			shards[i] = shard
+		}
+		return shards
+	}
+}
+
+var _ = Describe("Distributed key generation", func() {
+	hashed := sha256.Sum256([]byte("TEST MESSAGE"))
+
+	Context("Basic interfacing", func() {
+		It("Refuses fewer than 3 parties", func() {
+			_, err := NewDKGParty(1, 2, 2048, 65537)
+			Expect(err).NotTo(BeNil())
+		})
+
+		It("Refuses an out-of-range index", func() {
+			_, err := NewDKGParty(4, 3, 2048, 65537)
+			Expect(err).NotTo(BeNil())
+		})
+
+		It("Refuses a composite public exponent", func() {
+			_, err := NewDKGParty(1, 3, 2048, 65535)
+			Expect(err).NotTo(BeNil())
+		})
+	})
+
+	for _, n := range []int{3, 4} {
+		n := n
+
+		It(fmt.Sprintf("Generates a working %d-party split key", n), func() {
+			parties := make([]*DKGParty, n)
+			for i := range parties {
+				var err error
+				parties[i], err = NewDKGParty(i+1, n, 512, 65537)
+				Expect(err).To(BeNil())
+			}
+
+			shards := runDKG(parties)
+			pub := shards[0].PublicKey
+			Expect(pub.N.BitLen()).To(Equal(512))
+
+			By("Producing a valid brokered signature")
+			partials := make([]*PartialSignature, n)
+			for i, shard := range shards {
+				Expect(shard.PublicKey.N.Cmp(pub.N)).To(Equal(0))
+
+				var err error
+				partials[i], err = SignFirst(rand.Reader, shard, crypto.SHA256, hashed[:])
+				Expect(err).To(BeNil())
+			}
+			sig, err := CombinePartialSignatures(pub, partials...)
+			Expect(err).To(BeNil())
+			Expect(rsa.VerifyPKCS1v15(pub, crypto.SHA256, hashed[:], sig)).To(Succeed())
+		})
+	}
+})
@@ -0,0 +1,200 @@
+package keysplitting
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// the DKIM canonicalization algorithms (RFC 6376, section 3.4)
+const (
+	DKIMSimple  = "simple"
+	DKIMRelaxed = "relaxed"
+)
+
+// DKIMOptions describe the DKIM-Signature made by [SignDKIM]
+type DKIMOptions struct {
+	Domain                 string    // the signing domain, d=
+	Selector               string    // the selector of the DNS record holding the public key, s=
+	Headers                []string  // the names of the header fields to sign, h=, which must include From
+	HeaderCanonicalization string    // DKIMSimple or DKIMRelaxed, or DKIMRelaxed if empty
+	BodyCanonicalization   string    // DKIMSimple or DKIMRelaxed, or DKIMRelaxed if empty
+	Time                   time.Time // when the signature was made, t=, or omitted if zero
+	Expiration             time.Time // when the signature expires, x=, or omitted if zero
+}
+
+// SignDKIM signs message, an RFC 5322 message, with the rsa-sha256 DKIM algorithm (RFC 6376) through signer, a
+// [crypto.Signer] for the split key such as a [RemoteSigner], so that the DKIM key can be split between the MTA and a
+// policy service that has to agree to each message being signed. It canonicalizes the message as opts says, and returns the
+// DKIM-Signature header field, including its trailing CRLF, to be prepended to the message. Bare LF line endings in message
+// are treated as CRLF
+func SignDKIM(random io.Reader, signer crypto.Signer, opts *DKIMOptions, message []byte) (string, error) {
+	if _, ok := signer.Public().(*rsa.PublicKey); !ok {
+		return "", errorf(ErrKeyMismatch, "DKIM signer must have an RSA public key")
+	}
+	headerCanon, err := dkimCanonicalization(opts.HeaderCanonicalization)
+	if err != nil {
+		return "", err
+	}
+	bodyCanon, err := dkimCanonicalization(opts.BodyCanonicalization)
+	if err != nil {
+		return "", err
+	}
+	signsFrom := false
+	for _, name := range opts.Headers {
+		signsFrom = signsFrom || strings.EqualFold(name, "From")
+	}
+	if !signsFrom {
+		return "", fmt.Errorf("DKIM signature must cover the From header field")
+	}
+
+	message = bytes.ReplaceAll(bytes.ReplaceAll(message, []byte("\r\n"), []byte("\n")), []byte("\n"), []byte("\r\n"))
+	fields, body := splitDKIMMessage(message)
+	bodyHash, err := hashMessage(crypto.SHA256, dkimCanonicalBody(bodyCanon, body))
+	if err != nil {
+		return "", err
+	}
+
+	tags := []string{"v=1", "a=rsa-sha256", "c=" + headerCanon + "/" + bodyCanon, "d=" + opts.Domain, "s=" + opts.Selector}
+	if !opts.Time.IsZero() {
+		tags = append(tags, fmt.Sprintf("t=%d", opts.Time.Unix()))
+	}
+	if !opts.Expiration.IsZero() {
+		tags = append(tags, fmt.Sprintf("x=%d", opts.Expiration.Unix()))
+	}
+	tags = append(tags, "h="+strings.Join(opts.Headers, ":"), "bh="+base64.StdEncoding.EncodeToString(bodyHash), "b=")
+	sigField := "DKIM-Signature: " + strings.Join(tags, "; ")
+
+	// each signed header field is the last instance of its name that an earlier entry of h= hasn't taken already
+	var signed []byte
+	used := make([]bool, len(fields))
+	for _, name := range opts.Headers {
+		for i := len(fields) - 1; i >= 0; i-- {
+			if !used[i] && strings.EqualFold(dkimFieldName(fields[i]), name) {
+				used[i] = true
+				signed = append(signed, dkimCanonicalHeader(headerCanon, fields[i])...)
+				break
+			}
+		}
+	}
+	canonicalSig := dkimCanonicalHeader(headerCanon, sigField+"\r\n")
+	signed = append(signed, strings.TrimSuffix(canonicalSig, "\r\n")...)
+
+	hashed, err := hashMessage(crypto.SHA256, signed)
+	if err != nil {
+		return "", err
+	}
+	sig, err := signer.Sign(random, hashed, crypto.SHA256)
+	if err != nil {
+		return "", err
+	}
+	return sigField + base64.StdEncoding.EncodeToString(sig) + "\r\n", nil
+}
+
+// DKIMRecord returns the text of the DNS TXT record that publishes pub as a DKIM key, at <selector>._domainkey.<domain>
+func DKIMRecord(pub *rsa.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return "", err
+	}
+	return "v=DKIM1; k=rsa; p=" + base64.StdEncoding.EncodeToString(der), nil
+}
+
+func dkimCanonicalization(c string) (string, error) {
+	switch c {
+	case "":
+		return DKIMRelaxed, nil
+	case DKIMSimple, DKIMRelaxed:
+		return c, nil
+	}
+	return "", fmt.Errorf("unsupported DKIM canonicalization %q", c)
+}
+
+// splits a message with CRLF line endings into its header fields, each with its continuation lines and trailing CRLF, and
+// its body
+func splitDKIMMessage(message []byte) ([]string, []byte) {
+	var fields []string
+	for len(message) > 0 {
+		end := bytes.Index(message, []byte("\r\n"))
+		if end < 0 {
+			end = len(message)
+		} else {
+			end += 2
+		}
+		line := string(message[:end])
+		message = message[end:]
+		if line == "\r\n" {
+			break
+		}
+		if len(fields) > 0 && (line[0] == ' ' || line[0] == '\t') {
+			fields[len(fields)-1] += line
+		} else {
+			fields = append(fields, line)
+		}
+	}
+	return fields, message
+}
+
+func dkimFieldName(field string) string {
+	if i := strings.IndexByte(field, ':'); i >= 0 {
+		return strings.TrimRight(field[:i], " \t")
+	}
+	return field
+}
+
+// canonicalizes a header field, including its trailing CRLF (RFC 6376, section 3.4.1 and 3.4.2)
+func dkimCanonicalHeader(c string, field string) string {
+	if c == DKIMSimple {
+		return field
+	}
+	value := ""
+	if i := strings.IndexByte(field, ':'); i >= 0 {
+		value = field[i+1:]
+	}
+	value = strings.ReplaceAll(value, "\r\n", "")
+	return strings.ToLower(dkimFieldName(field)) + ":" + strings.TrimSpace(compressWSP(value)) + "\r\n"
+}
+
+// canonicalizes a body with CRLF line endings (RFC 6376, section 3.4.3 and 3.4.4)
+func dkimCanonicalBody(c string, body []byte) []byte {
+	lines := strings.Split(string(body), "\r\n")
+	if c == DKIMRelaxed {
+		for i, line := range lines {
+			lines[i] = strings.TrimRight(compressWSP(line), " ")
+		}
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 0 {
+		if c == DKIMSimple {
+			return []byte("\r\n")
+		}
+		return nil
+	}
+	return []byte(strings.Join(lines, "\r\n") + "\r\n")
+}
+
+// replaces every run of spaces and tabs in s with a single space
+func compressWSP(s string) string {
+	var b strings.Builder
+	inWSP := false
+	for i := 0; i < len(s); i++ {
+		if s[i] == ' ' || s[i] == '\t' {
+			if !inWSP {
+				b.WriteByte(' ')
+			}
+			inWSP = true
+			continue
+		}
+		inWSP = false
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
@@ -0,0 +1,111 @@
+package keysplitting
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DKIM", func() {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	shards, _ := SplitD(key, 2, Multiplication)
+	remotes := make([]RemoteShard, len(shards))
+	for i, shard := range shards {
+		remotes[i], _ = NewLocalShard(shard)
+	}
+	remoteSigner, _ := NewRemoteSigner(&key.PublicKey, remotes...)
+
+	message := "From: Alice <alice@example.com>\r\n" +
+		"To: Bob <bob@example.com>\r\n" +
+		"Subject:  Split   keys\r\n\tare fun \r\n" +
+		"\r\n" +
+		"Hello  Bob \r\n\r\n\r\n"
+
+	It("Canonicalizes as in the RFC 6376 example", func() {
+		fields, body := splitDKIMMessage([]byte("A: X\r\nB : Y\t\r\n\tZ  \r\n\r\n C \r\nD \t E\r\n\r\n\r\n"))
+		Expect(fields).To(Equal([]string{"A: X\r\n", "B : Y\t\r\n\tZ  \r\n"}))
+
+		Expect(dkimCanonicalHeader(DKIMRelaxed, fields[0]) + dkimCanonicalHeader(DKIMRelaxed, fields[1])).To(Equal("a:X\r\nb:Y Z\r\n"))
+		Expect(string(dkimCanonicalBody(DKIMRelaxed, body))).To(Equal(" C\r\nD E\r\n"))
+
+		Expect(dkimCanonicalHeader(DKIMSimple, fields[1])).To(Equal(fields[1]))
+		Expect(string(dkimCanonicalBody(DKIMSimple, body))).To(Equal(" C \r\nD \t E\r\n"))
+
+		Expect(dkimCanonicalBody(DKIMRelaxed, nil)).To(BeEmpty())
+		Expect(string(dkimCanonicalBody(DKIMSimple, nil))).To(Equal("\r\n"))
+	})
+
+	for _, c := range []string{DKIMSimple, DKIMRelaxed} {
+		c := c
+		It("Signs with "+c+" canonicalization", func() {
+			opts := &DKIMOptions{
+				Domain:                 "example.com",
+				Selector:               "split",
+				Headers:                []string{"From", "To", "Subject", "Date"},
+				HeaderCanonicalization: c,
+				BodyCanonicalization:   c,
+				Time:                   time.Unix(1700000000, 0),
+			}
+			field, err := SignDKIM(rand.Reader, remoteSigner, opts, []byte(message))
+			Expect(err).To(BeNil())
+			Expect(field).To(HavePrefix("DKIM-Signature: v=1; a=rsa-sha256; c=" + c + "/" + c + "; d=example.com; s=split; t=1700000000;"))
+			Expect(field).To(HaveSuffix("\r\n"))
+
+			// verify as a receiver would, from the signed message
+			fields, body := splitDKIMMessage([]byte(field + message))
+			bodyHash, _ := hashMessage(crypto.SHA256, dkimCanonicalBody(c, body))
+			Expect(field).To(ContainSubstring("; bh=" + base64.StdEncoding.EncodeToString(bodyHash) + ";"))
+
+			var signed string
+			for _, f := range fields[1:4] {
+				signed += dkimCanonicalHeader(c, f)
+			}
+			i := strings.LastIndex(fields[0], "b=") + 2
+			signed += strings.TrimSuffix(dkimCanonicalHeader(c, fields[0][:i]+"\r\n"), "\r\n")
+			sig, err := base64.StdEncoding.DecodeString(strings.TrimSuffix(fields[0][i:], "\r\n"))
+			Expect(err).To(BeNil())
+			hashed, _ := hashMessage(crypto.SHA256, []byte(signed))
+			Expect(rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, hashed, sig)).To(Succeed())
+		})
+	}
+
+	It("Treats bare LF line endings as CRLF", func() {
+		opts := &DKIMOptions{Domain: "example.com", Selector: "split", Headers: []string{"From"}}
+		crlf, err := SignDKIM(rand.Reader, remoteSigner, opts, []byte(message))
+		Expect(err).To(BeNil())
+		lf, err := SignDKIM(rand.Reader, remoteSigner, opts, []byte(strings.ReplaceAll(message, "\r\n", "\n")))
+		Expect(err).To(BeNil())
+		Expect(lf).To(Equal(crlf))
+	})
+
+	It("Rejects bad options and non-RSA keys", func() {
+		_, err := SignDKIM(rand.Reader, remoteSigner, &DKIMOptions{Headers: []string{"To"}}, []byte(message))
+		Expect(err).ToNot(BeNil())
+		_, err = SignDKIM(rand.Reader, remoteSigner, &DKIMOptions{Headers: []string{"From"}, BodyCanonicalization: "nowsp"}, []byte(message))
+		Expect(err).ToNot(BeNil())
+
+		_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+		_, err = SignDKIM(rand.Reader, edKey, &DKIMOptions{Headers: []string{"From"}}, []byte(message))
+		Expect(errors.Is(err, ErrKeyMismatch)).To(BeTrue())
+	})
+
+	It("Publishes the public key in a DNS record", func() {
+		record, err := DKIMRecord(&key.PublicKey)
+		Expect(err).To(BeNil())
+		Expect(record).To(HavePrefix("v=DKIM1; k=rsa; p="))
+		der, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(record, "v=DKIM1; k=rsa; p="))
+		Expect(err).To(BeNil())
+		pub, err := x509.ParsePKIXPublicKey(der)
+		Expect(err).To(BeNil())
+		Expect(pub).To(Equal(&key.PublicKey))
+	})
+})
@@ -11,31 +11,146 @@ Keysplitting supports a simple and secure flow for producing multi-party signatu
 	    return err
 	}
 
+Alternatively, [GenerateSplitKey] does both in one step, so the whole private key is never returned to the caller.
+
 The broker then distributes the private shards (as well as the public key) over a secure channel,
 destroying each shards as it is sent. If the broker will be one of the parties to the signature,
-it keeps one of the shards.
+it keeps one of the shards. [PrivateKeyShard.WrapShardFor] encrypts a shard to a recipient's RSA public key,
+so that it can be sent over an otherwise untrusted channel. To keep shards encrypted at rest under a key management service,
+use a [ShardWrapper] such as an [EnvelopeWrapper] backed by AWS KMS with the awskms subpackage.
 
 When it comes time to sign a message, the key shards do not need to be reassembled.
-Instead, each party uses its shard to generate a partial signature. It is these partial signatures,
+Instead, each party uses its shard to generate a [PartialSignature], which records who has signed and how. It is these partial signatures,
 not the shards, that are combined to create the final valid signature.
 This can be verified against the public key in the usual way:
 
 	err = rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA512, hash, fullSig)
 
+or with [VerifyFinal], which also reports a signature that is still missing some parties' contributions as [ErrIncompleteSignature].
+
+Callers that would rather not hash the message themselves can use [SignMessageFirst] and [SignMessageNext], or stream a large
+message into a [SigningSession]. Code that signs through the [ShardSigner] interface works with in-memory shards as well as
+shards held elsewhere, such as on a PKCS #11 token with [PKCS11Shard] or in an ssh-agent with [SSHAgentShard].
+Shard holders on other machines are reached through the [RemoteShard] interface, and [SignSequential] and [SignBrokered]
+sign with any mix of local and remote shards. [SignAllParallel] signs with additive shards held in this process, each in its
+own goroutine, and checks the result. The shardservice subpackage connects them over gRPC.
+A [RemoteSigner] signs with remote shards behind the standard [crypto.Signer] interface, and [NewTLSCertificate] uses one as
+the private key of a TLS server's certificate.
+A certificate authority with a split key signs certificates through a RemoteSigner as well, and its revocation lists and
+OCSP responses with [SignCRL] and [SignOCSPResponse]. A busy one, such as an ACME server, can sign through a [BatchSigner]
+instead, which sends concurrent requests to the shards in batches, and in a single round trip to a [BatchRemoteShard].
+A time-stamping authority issues RFC 3161 timestamp tokens with [SignTimestamp], so its signing key is never whole either.
+JSON Web Tokens are minted with a split key by passing a [JWTSigningMethod] such as [SigningMethodRS256] to golang-jwt,
+with a RemoteSigner as the signing key. The jose subpackage produces JSON Web Signatures from the shard holders' partial signatures.
+The sigstore subpackage lets cosign sign artifacts with a split key, through sigstore's SignerVerifier interface or a KMS
+plugin binary.
+An SSH certificate authority signs through an [SSHSigner], which implements the ssh.Signer interface of golang.org/x/crypto/ssh
+and signs OpenSSH certificates with ssh.Certificate.SignCert. The openpgp subpackage makes detached OpenPGP signatures and exports the split key
+as an OpenPGP public key, and [SignDKIM] signs outgoing mail with a DKIM key split between the MTA and a policy service.
+[HealthCheck] has the shards sign a throwaway challenge, to check periodically that they can still produce a signature.
+A [Session] tracks a single signature as it is assembled, refusing partial signatures that were split by the other algorithm,
+signed with a different hash function or by a shard that has already signed. A Session or [Broker] also binds every partial
+signature to a fresh session nonce with [NonceOptions], so that one captured on the wire can't be replayed into another session.
+To sign many messages at once, such as every artifact of a release, each shard holder approves a [MessageSet] once by its
+commitment and signs all of its digests, whose partial signatures are bound to the set and can't be diverted to other digests.
+
+RSASSA-PSS signatures are supported as well. Because every party must encode the message identically, the parties share a single salt
+generated with [NewPSSSalt] and sign with [SignFirstPSS] and [SignNextPSS].
+
+The same shards can also decrypt RSAES-PKCS1-v1_5 ciphertexts, e.g. to unwrap a legacy key, without reassembling the private
+key: each party contributes with [DecryptFirst] or [DecryptNext], and [CombineDecryption] removes the padding in constant time.
+A [SplitDecrypter] does all of this behind the standard [crypto.Decrypter] interface.
+
+For privacy-preserving token issuance, a requester can have a message signed without revealing it to the shard holders by
+blinding it with [Blind]. The holders sign the blinded value with [SignFirstBlinded] and [SignNextBlinded], and the requester
+unblinds the complete signature with [BlindingState.Unblind].
+
 # The additive vs. multiplicative split schemes
 
 Keysplitting offers two algorithms for splitting the private key, Addition and Multiplication, specified by the [SplitBy] type.
 Both methods are equally secure and applicable to most use cases. However, the following differences may lead you to choose one over the other:
   - The Multiplication algorithm supports blinding during signature (TODO: not yet implemented)
   - The Multiplication algorithm can only be used sequentially (i.e. partial signatures / decryptions are generated one at a time by parties who each have their own shard)
-  - The Addition algorithm can be used sequentially. Alternatively, all parties can partially sign at once and send the results to a broker, who can combine them without using a key shard
+  - The Addition algorithm can be used sequentially. Alternatively, all parties can partially sign at once and send the results to a broker, who can combine them with [CombinePartialSignatures] without using a key shard, or collect them as they arrive with a [Broker]
+
+In the brokered model, the dealer can also split the key with [SplitDWithCommitments] and publish the [ShardCommitments]
+alongside the public key. Each holder then proves that their partial signature used their shard with [ShardCommitments.Prove],
+so that the broker can reject a corrupted or malicious partial signature with [VerifyPartialSignature] before combining it,
+or find out which parties are to blame for a signature that doesn't verify with [DiagnosePartialSignatures]. This protects
+against actively malicious holders, and since the commitments are public, it doesn't require trusting the broker with
+anything. Proven partial signatures are combined with [CombineProvenPartialSignatures], or by a [Broker] given the commitments.
+Partial signatures handed along a sequential chain can't be checked this way, since each combines the shards of several
+holders. Where every contribution must be checked, the holders should sign independently and prove their partial signatures.
+Alternatively, additive shards can be split with [SplitOptions].Mask, which changes the exponent each shard signs with from
+one signature to the next to hinder side-channel analysis, at the cost of partial verification.
+Holders of additive shards signing in sequence can also hide the partial signature of the shards before them from each other
+with [SignFirstOrderBlinded] and [SignNextOrderBlinded], whose masks are removed with [RemoveOrderMasks].
+
+Additive shards can also be refreshed periodically with [NewRefreshDeltas] and [RefreshShard], which re-randomizes them
+without changing the key, so that shards stolen at different times can't be combined. A holder can also delegate their
+additive shard to several new parties with [SplitShard], without involving the dealer, and a holder can be decommissioned
+by merging their shard into another's with [MergeShards]. To change the number of shards altogether, the holders can
+reshare the key among a new set of holders with [NewReshareMessages] and [CombineReshareMessages]. In each case, the old
+shards should be wiped with [PrivateKeyShard.Zeroize] once they are no longer needed.
+
+Shards are drawn from crypto/rand unless [SplitOptions].Rand says otherwise. Deployments with strict requirements on their
+randomness can combine a hardware RNG with crypto/rand in an [EntropySource], which health tests each source as it is read.
+Integration tests that only need working shards, not secure ones, can build with the keysplitting_simulation tag, which
+allows keys of as few as 512 bits (see [Simulation]). Implementations of the scheme in other languages can be checked
+against golden [TestVector] values, which record every step of a signature in a stable JSON format.
+
+To keep a record of every use of the key material, register an [AuditSink] with [SetAuditSink]. It's told about each split,
+shard decoding, partial signature, combination, and verification, but never sees the shards themselves. Similarly, each signature
+can be traced across the parties by a [Tracer], e.g. with OpenTelemetry using the oteltrace subpackage, and [SetMetrics] counts and
+times the signatures produced, e.g. for Prometheus to scrape with the prommetrics subpackage. A [Dealer], [Broker] or
+shardservice.Server can also be given a [Logger], such as a *slog.Logger, to report its progress and the failures it sees.
 
 To learn how to use each algorithm, see the [examples]. To learn more about how they work, see this TODO: detailed explanation published somewhere!!
 
+# Threshold signatures
+
+Both split schemes require every shard to participate in each signature. If only some of the parties should be required,
+[SplitThreshold] splits the key into n shares such that any t of them can sign. Each party produces a partial signature with
+[SignThreshold], and a broker combines any t of them with [CombineThreshold]. See Shoup [3] for details. Given more than t
+partial signatures, [DiagnoseThreshold] identifies the invalid ones by elimination.
+To have some parties count as more than one approver, [SplitWeighted] gives each custodian a bundle of shares in proportion
+to their weight, and [CombineWeighted] signs once the custodians' weights add up to t.
+
+To require particular parties rather than any t of them, e.g. the security team's shard and a shard from each region,
+describe them with a [QuorumPolicy] and combine with [CombineThresholdWithPolicy]. A policy can also be set on a [Broker] or [Session].
+
+# Distributed key generation
+
+Splitting a key requires a broker who holds the whole key, if only briefly. To avoid this, three or more parties can instead
+generate a key together, each using a [DKGParty] to run the protocol of Boneh and Franklin [4]. Each party ends up with an
+additive shard of a key that never existed in full anywhere.
+
+# Other algorithms
+
+The ecdsa2p subpackage splits ECDSA keys between two parties, who sign together in four messages with Lindell's two-party
+ECDSA protocol, so the same guarantee extends beyond RSA.
+The frost subpackage splits Ed25519 keys into t-of-n shares, dealt from an existing key or generated without a dealer, which
+sign together in two rounds with FROST and produce ordinary Ed25519 signatures.
+The schnorr subpackage does the same for secp256k1 keys, producing BIP-340 signatures such as those of Taproot key path spends,
+with a Broker that collects the partial signatures as keysplitting's Broker does.
+The bls subpackage splits BLS12-381 keys, whose shares sign independently in a single message, producing signatures in the
+format of Ethereum's consensus layer.
+The paillier subpackage provides the additively homomorphic Paillier cryptosystem that ecdsa2p builds on, whose decryption key
+can be split into additive shards in the same way as an RSA key.
+
+# Backups
+
+The shards used for signing are not a good disaster recovery mechanism, since losing any one of them makes the key unusable.
+[BackupSplit] separately splits the whole key into Shamir shares for cold storage, any t of which recover it with [BackupRecover].
+The age subpackage encrypts a shard to age recipients or a passphrase, so that it can be stored with the same tools as other
+secrets.
+
 # Sources
 
 	[1] https://eprint.iacr.org/2001/060.pdf
 	[2] https://crypto.stanford.edu/semmail/mrsa.pdf
+	[3] https://www.iacr.org/archive/eurocrypt2000/1807/18070209-new.pdf
+	[4] https://crypto.stanford.edu/~dabo/pubs/papers/sharing.pdf
 
 [examples]: https://github.com/bastionzero/keysplitting/tree/master/examples
 */
@@ -0,0 +1,322 @@
+/*
+Package ecdsa2p splits ECDSA keys between two parties, who sign together without either of them ever holding the whole key,
+following Lindell's two-party ECDSA [1]. It is the ECDSA sibling of keysplitting's multiplicative RSA splitting: the private
+key x is split as x = x1 * x2 mod q, where q is the order of the curve, and a signature needs both shards.
+
+[Split] deals the shards from an existing key. Party 1 also gets a Paillier key pair, and party 2 an encryption of x1 under it,
+which lets party 2 fold its shard into the signature without learning x1. Signing a digest takes four messages, each round
+consuming the previous round's message:
+
+ 1. Party 1 calls [Party1Shard.SignRound1], and sends the [Round1Message], a commitment to its nonce, to party 2
+ 2. Party 2 calls [Party2Shard.SignRound2] with the same digest, and sends the [Round2Message], its nonce, to party 1
+ 3. Party 1 calls [Party1Session.SignRound3], and sends the [Round3Message], which opens its commitment, to party 2
+ 4. Party 2 calls [Party2Session.SignRound4], and sends the [Round4Message], its encrypted partial signature, to party 1
+ 5. Party 1 calls [Party1Session.Signature] to decrypt and complete the signature, which it checks against the public key
+
+Each party proves knowledge of its nonce, and party 1 commits to its nonce before seeing party 2's, so neither can bias the
+signature's nonce. Party 2 decides what is signed, since it computes its partial signature over the digest it was given
+itself, and party 1 learns nothing about x2 beyond the signature. Since the shards are dealt, the proofs of [1] that the
+Paillier key is well formed and encrypts x1 aren't needed: the dealer is trusted, as it is when splitting an RSA key. A session
+signs a single digest, and must never be reused.
+
+Party 2 doesn't prove that its encrypted partial signature is well formed, so party 1 only checks that it decrypts to a value
+in the range an honest party 2 produces, and that the signature verifies. A malicious party 2 can still craft one that passes
+or fails depending on x1, and learn up to a bit of x1 from each failure. As in [1], this is safe only if party 1 treats any
+failure of [Party1Session.Signature] as proof that party 2 is malicious, and never signs with it again.
+
+	[1] https://eprint.iacr.org/2017/552.pdf
+*/
+package ecdsa2p
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/asn1"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/bastionzero/keysplitting"
+	"github.com/bastionzero/keysplitting/paillier"
+)
+
+// the size of the Paillier modulus, which must exceed the largest plaintext in the signing protocol, about q^3 for a curve
+// of order q, or 2^1563 for P-521
+const paillierBits = 2048
+
+// the labels binding each party's proofs of knowledge to that party
+const (
+	party1Label = "party 1"
+	party2Label = "party 2"
+)
+
+// A Party1Shard is the first party's half of a split ECDSA key
+type Party1Shard struct {
+	PublicKey *ecdsa.PublicKey // the public key of the split key
+	X1        *big.Int         // this party's shard of the private key
+	PaillierP *big.Int         // the first prime of this party's Paillier key
+	PaillierQ *big.Int         // the second prime of this party's Paillier key
+}
+
+// A Party2Shard is the second party's half of a split ECDSA key
+type Party2Shard struct {
+	PublicKey *ecdsa.PublicKey // the public key of the split key
+	X2        *big.Int         // this party's shard of the private key
+	PaillierN *big.Int         // the modulus of party 1's Paillier key
+	CKey      *big.Int         // the encryption of party 1's shard under its Paillier key
+}
+
+// Split splits priv into a shard for each of the two parties, x1 and x2, such that x = x1 * x2 mod q
+func Split(random io.Reader, priv *ecdsa.PrivateKey) (*Party1Shard, *Party2Shard, error) {
+	q := priv.Curve.Params().N
+	if priv.D.Sign() <= 0 || priv.D.Cmp(q) >= 0 {
+		return nil, nil, fmt.Errorf("%w: ECDSA private key out of range", keysplitting.ErrKeyMismatch)
+	}
+	x1, err := randomScalar(random, priv.Curve)
+	if err != nil {
+		return nil, nil, &keysplitting.RandomnessError{Err: err}
+	}
+	x2 := new(big.Int).ModInverse(x1, q)
+	x2.Mul(x2, priv.D)
+	x2.Mod(x2, q)
+
+	key, err := paillier.GenerateKey(random, paillierBits)
+	if err != nil {
+		return nil, nil, err
+	}
+	ckey, err := key.Encrypt(random, x1)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pub := &ecdsa.PublicKey{Curve: priv.Curve, X: priv.X, Y: priv.Y}
+	return &Party1Shard{PublicKey: pub, X1: x1, PaillierP: key.P, PaillierQ: key.Q},
+		&Party2Shard{PublicKey: pub, X2: x2, PaillierN: key.N, CKey: ckey}, nil
+}
+
+// A Round1Message is party 1's commitment to its nonce
+type Round1Message struct {
+	Commitment []byte
+}
+
+// A Round2Message is party 2's nonce point, with a proof that it knows the nonce
+type Round2Message struct {
+	R2    []byte // compressed point
+	Proof *DLogProof
+}
+
+// A Round3Message opens party 1's commitment to its nonce point
+type Round3Message struct {
+	R1    []byte // compressed point
+	Proof *DLogProof
+	Salt  []byte // the randomness of the commitment
+}
+
+// A Round4Message is party 2's partial signature, encrypted under party 1's Paillier key
+type Round4Message struct {
+	C3 *big.Int
+}
+
+// A Party1Session is party 1's state while signing a single digest
+type Party1Session struct {
+	shard   *Party1Shard
+	hashed  []byte
+	k1      *big.Int
+	opening *Round3Message // opens the commitment, in round 3
+	r       *big.Int       // the x-coordinate of the joint nonce point, once known
+}
+
+// A Party2Session is party 2's state while signing a single digest
+type Party2Session struct {
+	shard      *Party2Shard
+	hashed     []byte
+	k2         *big.Int
+	commitment []byte
+}
+
+// SignRound1 starts a session in which party 1 signs hashed, the result of hashing a message, and returns the message to
+// send to party 2
+func (s *Party1Shard) SignRound1(random io.Reader, hashed []byte) (*Party1Session, *Round1Message, error) {
+	curve := s.PublicKey.Curve
+	k1, err := randomScalar(random, curve)
+	if err != nil {
+		return nil, nil, &keysplitting.RandomnessError{Err: err}
+	}
+	R1 := scalarBaseMult(curve, k1)
+	proof, err := proveDLog(random, curve, party1Label, k1, R1)
+	if err != nil {
+		return nil, nil, &keysplitting.RandomnessError{Err: err}
+	}
+	salt, err := keysplitting.NewNonce(random)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sess := &Party1Session{
+		shard:   s,
+		hashed:  append([]byte{}, hashed...),
+		k1:      k1,
+		opening: &Round3Message{R1: R1, Proof: proof, Salt: salt},
+	}
+	return sess, &Round1Message{Commitment: commitNonce(salt, R1, proof)}, nil
+}
+
+// SignRound2 starts a session in which party 2 signs hashed, which it must have decided to sign itself, in response to
+// party 1's first message, and returns the message to send to party 1
+func (s *Party2Shard) SignRound2(random io.Reader, hashed []byte, msg *Round1Message) (*Party2Session, *Round2Message, error) {
+	if msg == nil || len(msg.Commitment) == 0 {
+		return nil, nil, fmt.Errorf("%w: missing commitment from party 1", keysplitting.ErrInvalidPartialSignature)
+	}
+	curve := s.PublicKey.Curve
+	k2, err := randomScalar(random, curve)
+	if err != nil {
+		return nil, nil, &keysplitting.RandomnessError{Err: err}
+	}
+	R2 := scalarBaseMult(curve, k2)
+	proof, err := proveDLog(random, curve, party2Label, k2, R2)
+	if err != nil {
+		return nil, nil, &keysplitting.RandomnessError{Err: err}
+	}
+
+	sess := &Party2Session{
+		shard:      s,
+		hashed:     append([]byte{}, hashed...),
+		k2:         k2,
+		commitment: append([]byte{}, msg.Commitment...),
+	}
+	return sess, &Round2Message{R2: R2, Proof: proof}, nil
+}
+
+// SignRound3 checks party 2's nonce and returns the message opening party 1's commitment
+func (sess *Party1Session) SignRound3(msg *Round2Message) (*Round3Message, error) {
+	if sess.k1 == nil || sess.r != nil {
+		return nil, fmt.Errorf("%w: session is used up", keysplitting.ErrInvalidPartialSignature)
+	}
+	if msg == nil {
+		return nil, fmt.Errorf("%w: missing nonce from party 2", keysplitting.ErrInvalidPartialSignature)
+	}
+	curve := sess.shard.PublicKey.Curve
+	if err := verifyDLog(curve, party2Label, msg.R2, msg.Proof); err != nil {
+		sess.close()
+		return nil, err
+	}
+	R2x, R2y, _ := unmarshalPoint(curve, msg.R2)
+	x, _ := curve.ScalarMult(R2x, R2y, sess.k1.Bytes())
+	if sess.r = x.Mod(x, curve.Params().N); sess.r.Sign() == 0 {
+		sess.close()
+		return nil, fmt.Errorf("%w: degenerate nonce, start a new session", keysplitting.ErrInvalidPartialSignature)
+	}
+	return sess.opening, nil
+}
+
+// SignRound4 checks that party 1 opened its commitment to a nonce it knows, and returns party 2's encrypted partial
+// signature. The session can't be used again
+func (sess *Party2Session) SignRound4(random io.Reader, msg *Round3Message) (*Round4Message, error) {
+	if sess.k2 == nil {
+		return nil, fmt.Errorf("%w: session is used up", keysplitting.ErrInvalidPartialSignature)
+	}
+	defer sess.close()
+	if msg == nil {
+		return nil, fmt.Errorf("%w: missing nonce from party 1", keysplitting.ErrInvalidPartialSignature)
+	}
+	curve := sess.shard.PublicKey.Curve
+	q := curve.Params().N
+	if err := checkCommitment(sess.commitment, msg.Salt, msg.R1, msg.Proof); err != nil {
+		return nil, err
+	}
+	if err := verifyDLog(curve, party1Label, msg.R1, msg.Proof); err != nil {
+		return nil, err
+	}
+	R1x, R1y, _ := unmarshalPoint(curve, msg.R1)
+	r, _ := curve.ScalarMult(R1x, R1y, sess.k2.Bytes())
+	if r.Mod(r, q).Sign() == 0 {
+		return nil, fmt.Errorf("%w: degenerate nonce, start a new session", keysplitting.ErrInvalidPartialSignature)
+	}
+
+	// c3 = Enc(rho * q + k2^-1 * m mod q) + (k2^-1 * r * x2 mod q) * Enc(x1), where rho masks the sum modulo q
+	key := &paillier.PublicKey{N: sess.shard.PaillierN}
+	k2Inv := new(big.Int).ModInverse(sess.k2, q)
+	rho, err := rand.Int(random, new(big.Int).Mul(q, q))
+	if err != nil {
+		return nil, &keysplitting.RandomnessError{Err: err}
+	}
+	m := new(big.Int).Mul(k2Inv, hashToInt(sess.hashed, curve))
+	m.Mod(m, q)
+	m.Add(m, rho.Mul(rho, q))
+	c1, err := key.Encrypt(random, m)
+	if err != nil {
+		return nil, err
+	}
+	v := new(big.Int).Mul(k2Inv, r)
+	v.Mul(v, sess.shard.X2)
+	v.Mod(v, q)
+	return &Round4Message{C3: key.Add(c1, key.Mul(sess.shard.CKey, v))}, nil
+}
+
+// Signature decrypts party 2's partial signature and completes the signature, which it returns in the ASN.1 form of
+// [ecdsa.SignASN1] once it has checked it against the public key. It fails with keysplitting.ErrInvalidPartialSignature if
+// the partial signature is malformed, or keysplitting.ErrIncompleteSignature if the signature doesn't verify, either of which
+// means that party 2 misbehaved. The session can't be used again.
+//
+// Security assumption: party 1 must stop signing with party 2 after any failure, since party 2 may have crafted its partial
+// signature to learn about x1 from whether it fails (see the package documentation)
+func (sess *Party1Session) Signature(msg *Round4Message) ([]byte, error) {
+	if sess.k1 == nil || sess.r == nil {
+		return nil, fmt.Errorf("%w: session is used up or round 3 is incomplete", keysplitting.ErrInvalidPartialSignature)
+	}
+	defer sess.close()
+	if msg == nil || msg.C3 == nil {
+		return nil, fmt.Errorf("%w: missing partial signature from party 2", keysplitting.ErrInvalidPartialSignature)
+	}
+	key, err := paillier.NewPrivateKey(sess.shard.PaillierP, sess.shard.PaillierQ)
+	if err != nil {
+		return nil, err
+	}
+	sPrime, err := key.Decrypt(msg.C3)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", keysplitting.ErrInvalidPartialSignature, err)
+	}
+
+	// an honest party 2 encrypts m + rho*q + v*x1 with m, v, x1 < q and rho < q^2, which is less than q^3 + q^2
+	q := sess.shard.PublicKey.Curve.Params().N
+	bound := new(big.Int).Mul(q, q)
+	bound.Add(bound, new(big.Int).Mul(bound, q))
+	if sPrime.Cmp(bound) >= 0 {
+		return nil, fmt.Errorf("%w: partial signature from party 2 is out of range", keysplitting.ErrInvalidPartialSignature)
+	}
+	s := new(big.Int).ModInverse(sess.k1, q)
+	s.Mul(s, sPrime)
+	s.Mod(s, q)
+	if neg := new(big.Int).Sub(q, s); neg.Cmp(s) < 0 {
+		s = neg
+	}
+	if s.Sign() == 0 || !ecdsa.Verify(sess.shard.PublicKey, sess.hashed, sess.r, s) {
+		return nil, keysplitting.ErrIncompleteSignature
+	}
+	return asn1.Marshal(struct{ R, S *big.Int }{sess.r, s})
+}
+
+// forgets party 1's nonce, so that it can never be used for another signature
+func (sess *Party1Session) close() {
+	sess.k1 = nil
+}
+
+// forgets party 2's nonce, so that it can never be used for another signature
+func (sess *Party2Session) close() {
+	sess.k2 = nil
+}
+
+// converts a digest to an integer modulo the order of the curve, keeping its leftmost bits as ECDSA does
+func hashToInt(hashed []byte, curve elliptic.Curve) *big.Int {
+	orderBits := curve.Params().N.BitLen()
+	orderBytes := (orderBits + 7) / 8
+	if len(hashed) > orderBytes {
+		hashed = hashed[:orderBytes]
+	}
+	m := new(big.Int).SetBytes(hashed)
+	if excess := len(hashed)*8 - orderBits; excess > 0 {
+		m.Rsh(m, uint(excess))
+	}
+	return m
+}
@@ -0,0 +1,155 @@
+package ecdsa2p
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/bastionzero/keysplitting"
+	"github.com/bastionzero/keysplitting/paillier"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestECDSA2P(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "ECDSA2P Suite")
+}
+
+var _ = Describe("Two-party ECDSA", func() {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	party1, party2, splitErr := Split(rand.Reader, key)
+	hashed := sha256.Sum256([]byte("TEST MESSAGE"))
+
+	// runs the protocol up to round 3, returning both sessions and party 1's opening
+	toRound3 := func(hashed1, hashed2 []byte) (*Party1Session, *Party2Session, *Round3Message) {
+		sess1, msg1, err := party1.SignRound1(rand.Reader, hashed1)
+		Expect(err).To(BeNil())
+		sess2, msg2, err := party2.SignRound2(rand.Reader, hashed2, msg1)
+		Expect(err).To(BeNil())
+		msg3, err := sess1.SignRound3(msg2)
+		Expect(err).To(BeNil())
+		return sess1, sess2, msg3
+	}
+
+	It("Splits the key multiplicatively", func() {
+		Expect(splitErr).To(BeNil())
+		x := new(big.Int).Mul(party1.X1, party2.X2)
+		Expect(x.Mod(x, elliptic.P256().Params().N)).To(Equal(key.D))
+
+		paillierKey, err := paillier.NewPrivateKey(party1.PaillierP, party1.PaillierQ)
+		Expect(err).To(BeNil())
+		Expect(paillierKey.N).To(Equal(party2.PaillierN))
+		x1, err := paillierKey.Decrypt(party2.CKey)
+		Expect(err).To(BeNil())
+		Expect(x1).To(Equal(party1.X1))
+	})
+
+	It("Signs in four rounds", func() {
+		sess1, sess2, msg3 := toRound3(hashed[:], hashed[:])
+		msg4, err := sess2.SignRound4(rand.Reader, msg3)
+		Expect(err).To(BeNil())
+		sig, err := sess1.Signature(msg4)
+		Expect(err).To(BeNil())
+		Expect(ecdsa.VerifyASN1(&key.PublicKey, hashed[:], sig)).To(BeTrue())
+	})
+
+	It("Signs with larger curves and digests", func() {
+		key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+		Expect(err).To(BeNil())
+		party1, party2, err := Split(rand.Reader, key)
+		Expect(err).To(BeNil())
+		hashed := sha512.Sum512([]byte("TEST MESSAGE"))
+
+		sess1, msg1, err := party1.SignRound1(rand.Reader, hashed[:])
+		Expect(err).To(BeNil())
+		sess2, msg2, err := party2.SignRound2(rand.Reader, hashed[:], msg1)
+		Expect(err).To(BeNil())
+		msg3, err := sess1.SignRound3(msg2)
+		Expect(err).To(BeNil())
+		msg4, err := sess2.SignRound4(rand.Reader, msg3)
+		Expect(err).To(BeNil())
+		sig, err := sess1.Signature(msg4)
+		Expect(err).To(BeNil())
+		Expect(ecdsa.VerifyASN1(&key.PublicKey, hashed[:], sig)).To(BeTrue())
+	})
+
+	It("Fails if the parties sign different digests", func() {
+		other := sha256.Sum256([]byte("OTHER MESSAGE"))
+		sess1, sess2, msg3 := toRound3(hashed[:], other[:])
+		msg4, err := sess2.SignRound4(rand.Reader, msg3)
+		Expect(err).To(BeNil())
+		_, err = sess1.Signature(msg4)
+		Expect(errors.Is(err, keysplitting.ErrIncompleteSignature)).To(BeTrue())
+	})
+
+	It("Rejects a malformed partial signature from party 2", func() {
+		q := elliptic.P256().Params().N
+		paillierKey := &paillier.PublicKey{N: party2.PaillierN}
+		tooLarge := new(big.Int).Exp(q, big.NewInt(3), nil)
+		tooLarge.Add(tooLarge, new(big.Int).Mul(q, q))
+
+		for _, c3 := range []func() *big.Int{
+			// a plaintext larger than an honest party 2 could produce
+			func() *big.Int {
+				c3, err := paillierKey.Encrypt(rand.Reader, tooLarge)
+				Expect(err).To(BeNil())
+				return c3
+			},
+			// not a ciphertext at all
+			func() *big.Int { return new(big.Int).Mul(party2.PaillierN, party2.PaillierN) },
+			func() *big.Int { return big.NewInt(0) },
+		} {
+			sess1, sess2, msg3 := toRound3(hashed[:], hashed[:])
+			_, err := sess2.SignRound4(rand.Reader, msg3)
+			Expect(err).To(BeNil())
+			_, err = sess1.Signature(&Round4Message{C3: c3()})
+			Expect(errors.Is(err, keysplitting.ErrInvalidPartialSignature)).To(BeTrue())
+		}
+	})
+
+	It("Rejects a nonce that doesn't match the commitment", func() {
+		sess1, msg1, err := party1.SignRound1(rand.Reader, hashed[:])
+		Expect(err).To(BeNil())
+		sess2, msg2, err := party2.SignRound2(rand.Reader, hashed[:], msg1)
+		Expect(err).To(BeNil())
+		msg3, err := sess1.SignRound3(msg2)
+		Expect(err).To(BeNil())
+
+		// a fresh nonce with a valid proof, but not the committed one
+		k, _ := randomScalar(rand.Reader, elliptic.P256())
+		R := scalarBaseMult(elliptic.P256(), k)
+		proof, err := proveDLog(rand.Reader, elliptic.P256(), party1Label, k, R)
+		Expect(err).To(BeNil())
+		_, err = sess2.SignRound4(rand.Reader, &Round3Message{R1: R, Proof: proof, Salt: msg3.Salt})
+		Expect(errors.Is(err, keysplitting.ErrInvalidPartialSignature)).To(BeTrue())
+	})
+
+	It("Rejects a nonce without a valid proof of knowledge", func() {
+		sess1, msg1, err := party1.SignRound1(rand.Reader, hashed[:])
+		Expect(err).To(BeNil())
+		_, msg2, err := party2.SignRound2(rand.Reader, hashed[:], msg1)
+		Expect(err).To(BeNil())
+		msg2.Proof.Z = new(big.Int).Add(msg2.Proof.Z, big.NewInt(1))
+		_, err = sess1.SignRound3(msg2)
+		Expect(errors.Is(err, keysplitting.ErrInvalidPartialSignature)).To(BeTrue())
+	})
+
+	It("Never reuses a session", func() {
+		sess1, sess2, msg3 := toRound3(hashed[:], hashed[:])
+		msg4, err := sess2.SignRound4(rand.Reader, msg3)
+		Expect(err).To(BeNil())
+		_, err = sess2.SignRound4(rand.Reader, msg3)
+		Expect(errors.Is(err, keysplitting.ErrInvalidPartialSignature)).To(BeTrue())
+
+		_, err = sess1.Signature(msg4)
+		Expect(err).To(BeNil())
+		_, err = sess1.Signature(msg4)
+		Expect(errors.Is(err, keysplitting.ErrInvalidPartialSignature)).To(BeTrue())
+	})
+})
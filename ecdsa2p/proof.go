@@ -0,0 +1,112 @@
+package ecdsa2p
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/bastionzero/keysplitting"
+)
+
+// A DLogProof is a non-interactive Schnorr proof of knowledge of k such that R = k * G, made with the Fiat-Shamir heuristic
+type DLogProof struct {
+	A []byte   // the commitment a * G, as a compressed point
+	Z *big.Int // the response a + e * k mod q, where e is the challenge
+}
+
+// returns a proof of knowledge of k for R = k * G, bound to label, which names the party making it
+func proveDLog(random io.Reader, curve elliptic.Curve, label string, k *big.Int, R []byte) (*DLogProof, error) {
+	a, err := randomScalar(random, curve)
+	if err != nil {
+		return nil, err
+	}
+	A := scalarBaseMult(curve, a)
+	e := dlogChallenge(curve, label, R, A)
+	z := new(big.Int).Mul(e, k)
+	z.Add(z, a)
+	z.Mod(z, curve.Params().N)
+	return &DLogProof{A: A, Z: z}, nil
+}
+
+// checks a proof of knowledge of the discrete logarithm of R, made with the same label
+func verifyDLog(curve elliptic.Curve, label string, R []byte, proof *DLogProof) error {
+	if proof == nil || proof.Z == nil || proof.Z.Sign() < 0 || proof.Z.Cmp(curve.Params().N) >= 0 {
+		return fmt.Errorf("%w: malformed proof of knowledge", keysplitting.ErrInvalidPartialSignature)
+	}
+	Rx, Ry, err := unmarshalPoint(curve, R)
+	if err != nil {
+		return err
+	}
+	Ax, Ay, err := unmarshalPoint(curve, proof.A)
+	if err != nil {
+		return err
+	}
+	e := dlogChallenge(curve, label, R, proof.A)
+
+	// z * G == A + e * R
+	zx, zy := curve.ScalarBaseMult(proof.Z.Bytes())
+	ex, ey := curve.ScalarMult(Rx, Ry, e.Bytes())
+	x, y := curve.Add(Ax, Ay, ex, ey)
+	if zx.Cmp(x) != 0 || zy.Cmp(y) != 0 {
+		return fmt.Errorf("%w: invalid proof of knowledge", keysplitting.ErrInvalidPartialSignature)
+	}
+	return nil
+}
+
+func dlogChallenge(curve elliptic.Curve, label string, R, A []byte) *big.Int {
+	h := sha256.New()
+	h.Write([]byte("keysplitting ecdsa2p dlog proof\x00" + curve.Params().Name + "\x00" + label + "\x00"))
+	h.Write(R)
+	h.Write(A)
+	e := new(big.Int).SetBytes(h.Sum(nil))
+	return e.Mod(e, curve.Params().N)
+}
+
+// returns the commitment to party 1's nonce point and its proof, with the given salt
+func commitNonce(salt, R []byte, proof *DLogProof) []byte {
+	h := sha256.New()
+	h.Write([]byte("keysplitting ecdsa2p commitment\x00"))
+	h.Write(salt)
+	h.Write(R)
+	h.Write(proof.A)
+	h.Write(proof.Z.Bytes())
+	return h.Sum(nil)
+}
+
+func checkCommitment(commitment, salt, R []byte, proof *DLogProof) error {
+	if proof == nil || proof.Z == nil || subtle.ConstantTimeCompare(commitment, commitNonce(salt, R, proof)) != 1 {
+		return fmt.Errorf("%w: party 1 revealed a nonce that doesn't match its commitment", keysplitting.ErrInvalidPartialSignature)
+	}
+	return nil
+}
+
+// returns a random scalar in [1, q)
+func randomScalar(random io.Reader, curve elliptic.Curve) (*big.Int, error) {
+	for {
+		k, err := rand.Int(random, curve.Params().N)
+		if err != nil {
+			return nil, err
+		}
+		if k.Sign() > 0 {
+			return k, nil
+		}
+	}
+}
+
+func unmarshalPoint(curve elliptic.Curve, data []byte) (*big.Int, *big.Int, error) {
+	x, y := elliptic.UnmarshalCompressed(curve, data)
+	if x == nil {
+		return nil, nil, fmt.Errorf("%w: invalid %s point", keysplitting.ErrInvalidPartialSignature, curve.Params().Name)
+	}
+	return x, y, nil
+}
+
+// returns k * G as a compressed point
+func scalarBaseMult(curve elliptic.Curve, k *big.Int) []byte {
+	x, y := curve.ScalarBaseMult(k.Bytes())
+	return elliptic.MarshalCompressed(curve, x, y)
+}
@@ -0,0 +1,150 @@
+package keysplitting
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/asn1"
+	"encoding/pem"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const encryptedPEMType = "ENCRYPTED RSA SPLIT PRIVATE KEY"
+
+// the scrypt parameters used by EncodeEncryptedPEM, which take a fraction of a second on commodity hardware
+const (
+	scryptLogN = 15
+	scryptR    = 8
+	scryptP    = 1
+)
+
+// the largest scrypt parameters DecodeEncryptedPEM accepts, so that a malicious file can't make it allocate without bound.
+// These allow up to 128 * 32 * 2^20 = 4 GiB of memory, far beyond what EncodeEncryptedPEM uses
+const (
+	maxScryptLogN = 20
+	maxScryptR    = 32
+	maxScryptP    = 16
+)
+
+// used exclusively as a placeholder for encoding-decoding
+type scryptParams struct {
+	Salt []byte
+	LogN int
+	R    int
+	P    int
+}
+
+// used exclusively as a placeholder for encoding-decoding
+type encryptedShard struct {
+	KDF        scryptParams
+	Nonce      []byte
+	Ciphertext []byte
+}
+
+// EncodeEncryptedPEM is like [PrivateKeyShard.EncodePEM], but encrypts the shard under passphrase so that it can be safely
+// written to disk or backup media. The key is derived from passphrase with scrypt, and the shard is encrypted with AES-256-GCM.
+// Use [DecodeEncryptedPEM] to decrypt it
+func (pks *PrivateKeyShard) EncodeEncryptedPEM(passphrase []byte) (string, error) {
+	plaintext, err := pks.marshalDER()
+	if err != nil {
+		return "", err
+	}
+	defer zeroizeBytes(plaintext)
+
+	params := scryptParams{
+		Salt: make([]byte, 16),
+		LogN: scryptLogN,
+		R:    scryptR,
+		P:    scryptP,
+	}
+	if _, err := io.ReadFull(rand.Reader, params.Salt); err != nil {
+		return "", &RandomnessError{Err: err}
+	}
+
+	aead, err := newShardAEAD(passphrase, params)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", &RandomnessError{Err: err}
+	}
+
+	b, err := asn1.Marshal(encryptedShard{
+		KDF:        params,
+		Nonce:      nonce,
+		Ciphertext: aead.Seal(nil, nonce, plaintext, []byte(encryptedPEMType)),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to DER-encode: %s", err)
+	}
+
+	keyPEM := new(bytes.Buffer)
+	err = pem.Encode(keyPEM, &pem.Block{
+		Type:  encryptedPEMType,
+		Bytes: b,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to PEM-encode: %s", err)
+	}
+
+	return keyPEM.String(), nil
+}
+
+// DecodeEncryptedPEM decrypts a shard encoded by [PrivateKeyShard.EncodeEncryptedPEM]. It fails with [ErrIncorrectPassphrase]
+// if passphrase is wrong or the ciphertext has been tampered with, which are indistinguishable
+func DecodeEncryptedPEM(encodedPks string, passphrase []byte) (*PrivateKeyShard, error) {
+	block, rest := pem.Decode([]byte(encodedPks))
+	if block == nil || block.Type != encryptedPEMType || len(rest) > 0 {
+		return nil, errorf(ErrInvalidShard, "failed to decode PEM block containing encrypted private key shard")
+	}
+
+	var encrypted encryptedShard
+	rest, err := asn1.Unmarshal(block.Bytes, &encrypted)
+	if err != nil {
+		return nil, errorf(ErrInvalidShard, "failed to unmarshal DER-encoded encrypted private key shard: %s", err)
+	}
+	if len(rest) > 0 {
+		return nil, errorf(ErrInvalidShard, "failed to unmarshal DER-encoded encrypted private key shard")
+	}
+
+	params := encrypted.KDF
+	if params.LogN < 1 || params.LogN > maxScryptLogN || params.R < 1 || params.R > maxScryptR || params.P < 1 || params.P > maxScryptP {
+		return nil, errorf(ErrInvalidShard, "unsupported scrypt parameters")
+	}
+
+	aead, err := newShardAEAD(passphrase, params)
+	if err != nil {
+		return nil, err
+	}
+	if len(encrypted.Nonce) != aead.NonceSize() {
+		return nil, errorf(ErrInvalidShard, "invalid nonce length")
+	}
+
+	plaintext, err := aead.Open(nil, encrypted.Nonce, encrypted.Ciphertext, []byte(encryptedPEMType))
+	if err != nil {
+		return nil, errorf(ErrIncorrectPassphrase, "failed to decrypt private key shard")
+	}
+	defer zeroizeBytes(plaintext)
+
+	return unmarshalDER(plaintext)
+}
+
+// derives a key from passphrase and returns an AES-256-GCM cipher that uses it
+func newShardAEAD(passphrase []byte, params scryptParams) (cipher.AEAD, error) {
+	key, err := scrypt.Key(passphrase, params.Salt, 1<<params.LogN, params.R, params.P, 32)
+	if err != nil {
+		return nil, errorf(ErrInvalidShard, "unsupported scrypt parameters: %s", err)
+	}
+	defer zeroizeBytes(key)
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
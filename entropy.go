@@ -0,0 +1,102 @@
+package keysplitting
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// the health tests' parameters, following the continuous tests of NIST SP 800-90B, section 4.4, for a source that is
+// expected to deliver full entropy, with a false positive rate of about 2^-40 per byte
+const (
+	// the number of identical bytes in a row at which the repetition count test fails
+	repetitionCutoff = 6
+	// the size of the blocks that the stuck output test compares with the block before them
+	healthBlockSize = 16
+)
+
+// An EntropySource is a source of randomness for shard generation, for deployments with strict requirements on it. It reads
+// the same number of bytes from each of its underlying sources, such as a hardware RNG and crypto/rand.Reader, and XORs
+// them together, so its output is unpredictable as long as any one of them is. Before it is used, the output of each
+// source is health tested for stuck or repeating output, and once a source fails a test, every later read fails with
+// [ErrHealthTestFailed]. Use it as [SplitOptions].Rand, including for a [Dealer]. An EntropySource is safe for concurrent use
+type EntropySource struct {
+	mu      sync.Mutex
+	sources []*healthTest
+	err     error
+}
+
+// the state of the continuous health tests of a single source
+type healthTest struct {
+	r         io.Reader
+	lastByte  int // -1 before the first byte
+	run       int // the number of times lastByte has been repeated in a row
+	block     []byte
+	lastBlock []byte
+}
+
+// NewEntropySource returns an EntropySource that combines the given sources, of which there must be at least one
+func NewEntropySource(sources ...io.Reader) (*EntropySource, error) {
+	if len(sources) == 0 {
+		return nil, errorf(ErrHealthTestFailed, "no sources of randomness given")
+	}
+	s := &EntropySource{sources: make([]*healthTest, len(sources))}
+	for i, r := range sources {
+		s.sources[i] = &healthTest{r: r, lastByte: -1}
+	}
+	return s, nil
+}
+
+// Read fills p with the XOR of the output of every source. It fails if any source fails to read or fails a health test
+func (s *EntropySource) Read(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return 0, s.err
+	}
+	out := make([]byte, len(p))
+	buf := make([]byte, len(p))
+	for i, source := range s.sources {
+		if _, err := io.ReadFull(source.r, buf); err != nil {
+			return 0, err
+		}
+		if failure := source.check(buf); failure != "" {
+			s.err = errorf(ErrHealthTestFailed, "source #%d %s", i+1, failure)
+			return 0, s.err
+		}
+		for j := range buf {
+			out[j] ^= buf[j]
+		}
+	}
+	zeroizeBytes(buf)
+	copy(p, out)
+	zeroizeBytes(out)
+	return len(p), nil
+}
+
+// runs the repetition count test and the stuck output test on the next output of the source, and returns how it failed, if it did
+func (t *healthTest) check(p []byte) string {
+	for _, b := range p {
+		// repetition count test: too many identical bytes in a row
+		if int(b) == t.lastByte {
+			t.run++
+			if t.run >= repetitionCutoff {
+				return fmt.Sprintf("repeated the same byte %d times", t.run)
+			}
+		} else {
+			t.lastByte, t.run = int(b), 1
+		}
+
+		// stuck output test: a block identical to the one before it
+		t.block = append(t.block, b)
+		if len(t.block) == healthBlockSize {
+			if bytes.Equal(t.block, t.lastBlock) {
+				return fmt.Sprintf("repeated a %d-byte block", healthBlockSize)
+			}
+			t.lastBlock, t.block = t.block, t.lastBlock[:0]
+		}
+	}
+	return ""
+}
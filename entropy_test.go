@@ -0,0 +1,98 @@
+package keysplitting
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"io"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// an io.Reader that repeats pattern forever
+type patternReader struct {
+	pattern []byte
+	offset  int
+}
+
+func (r *patternReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = r.pattern[r.offset%len(r.pattern)]
+		r.offset++
+	}
+	return len(p), nil
+}
+
+var _ = Describe("EntropySource", func() {
+	It("XORs its sources together", func() {
+		a := make([]byte, 64)
+		b := make([]byte, 64)
+		_, _ = rand.Read(a)
+		_, _ = rand.Read(b)
+		source, err := NewEntropySource(bytes.NewReader(a), bytes.NewReader(b))
+		Expect(err).To(BeNil())
+
+		out := make([]byte, 64)
+		_, err = io.ReadFull(source, out)
+		Expect(err).To(BeNil())
+		for i := range out {
+			Expect(out[i]).To(Equal(a[i] ^ b[i]))
+		}
+	})
+
+	It("Splits keys", func() {
+		priv, _ := rsa.GenerateKey(rand.Reader, 2048)
+		source, err := NewEntropySource(rand.Reader, newDeterministicReader("hardware"))
+		Expect(err).To(BeNil())
+
+		dealer := NewDealer(&SplitOptions{Rand: source})
+		defer dealer.Close()
+		pub, err := dealer.AddKey(priv)
+		Expect(err).To(BeNil())
+		Expect(dealer.Split(context.Background(), pub, 3, Addition)).To(Succeed())
+	})
+
+	It("Fails and stays failed once a source gets stuck", func() {
+		source, err := NewEntropySource(rand.Reader, &patternReader{pattern: []byte{0}})
+		Expect(err).To(BeNil())
+		_, err = source.Read(make([]byte, 32))
+		Expect(errors.Is(err, ErrHealthTestFailed)).To(BeTrue())
+
+		priv, _ := rsa.GenerateKey(rand.Reader, 2048)
+		_, err = SplitDWithOptions(priv, 2, Addition, &SplitOptions{Rand: source})
+		var randomnessErr *RandomnessError
+		Expect(errors.As(err, &randomnessErr)).To(BeTrue())
+		Expect(errors.Is(err, ErrHealthTestFailed)).To(BeTrue())
+	})
+
+	It("Detects repeating output", func() {
+		pattern := make([]byte, 16)
+		_, _ = rand.Read(pattern)
+		source, err := NewEntropySource(&patternReader{pattern: pattern})
+		Expect(err).To(BeNil())
+		_, err = source.Read(make([]byte, 64))
+		Expect(errors.Is(err, ErrHealthTestFailed)).To(BeTrue())
+	})
+
+	It("Passes good randomness", func() {
+		source, err := NewEntropySource(rand.Reader)
+		Expect(err).To(BeNil())
+		for i := 0; i < 100; i++ {
+			_, err = source.Read(make([]byte, 1024))
+			Expect(err).To(BeNil())
+		}
+	})
+
+	It("Reports failing sources", func() {
+		source, err := NewEntropySource(rand.Reader, failingReader{})
+		Expect(err).To(BeNil())
+		_, err = source.Read(make([]byte, 32))
+		Expect(errors.Is(err, errFailingReader)).To(BeTrue())
+
+		_, err = NewEntropySource()
+		Expect(err).ToNot(BeNil())
+	})
+})
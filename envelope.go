@@ -0,0 +1,132 @@
+package keysplitting
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// the prefix of every partial signature envelope, which identifies it to both people and programs
+const envelopePrefix = "rsa-split-partial-v1:"
+
+// EncodeEnvelope wraps the partial signature in a compact text envelope for passing between signers over e-mail, chat,
+// tickets and the like. Along with the signature itself, the envelope records the key it belongs to (see [PrivateKeyShard.KeyID]),
+// the hash function, the split algorithm, and the signers. It consists of a fixed prefix followed by the base64url-encoded
+// SHA-256 hash of the PKCS #1 public key and a DER encoding of the partial signature. Use [DecodeEnvelope] to decode it
+func (ps *PartialSignature) EncodeEnvelope(pub *rsa.PublicKey) (string, error) {
+	encoded, err := ps.marshalDER()
+	if err != nil {
+		return "", err
+	}
+
+	keyID := publicKeyID(pub)
+	return envelopePrefix + base64.RawURLEncoding.EncodeToString(append(keyID[:], encoded...)), nil
+}
+
+// DecodeEnvelope decodes a partial signature encoded by [PartialSignature.EncodeEnvelope], failing with [ErrKeyMismatch] if it
+// belongs to a key other than pub. Whitespace is ignored, so envelopes that have been wrapped across lines can be decoded as is
+func DecodeEnvelope(envelope string, pub *rsa.PublicKey) (*PartialSignature, error) {
+	envelope = strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, envelope)
+	if !strings.HasPrefix(envelope, envelopePrefix) {
+		return nil, errorf(ErrInvalidPartialSignature, "not a partial signature envelope")
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(envelope, envelopePrefix))
+	if err != nil {
+		return nil, errorf(ErrInvalidPartialSignature, "invalid base64url-encoded partial signature envelope")
+	}
+	if len(b) < sha256.Size {
+		return nil, errorf(ErrInvalidPartialSignature, "partial signature envelope is too short")
+	}
+
+	keyID := publicKeyID(pub)
+	if subtle.ConstantTimeCompare(b[:sha256.Size], keyID[:]) != 1 {
+		return nil, errorf(ErrKeyMismatch, "partial signature envelope belongs to a different key")
+	}
+
+	return unmarshalPartialSignatureDER(b[sha256.Size:])
+}
+
+// used exclusively as a placeholder for encoding-decoding
+type partialSignatureDER struct {
+	Signers []int
+	Hash    int
+	SplitBy SplitBy
+	Sig     []byte
+	Nonce   []byte `asn1:"optional,tag:0"`
+	Proof   []byte `asn1:"optional,tag:1"`
+}
+
+// returns a DER encoding of the partial signature
+func (ps *PartialSignature) marshalDER() ([]byte, error) {
+	if err := checkSplitBy(ps.SplitBy); err != nil {
+		return nil, err
+	}
+	for _, signer := range ps.Signers {
+		if signer < 0 {
+			return nil, errorf(ErrInvalidPartialSignature, "signer index %d is negative", signer)
+		}
+	}
+
+	b, err := asn1.Marshal(partialSignatureDER{
+		Signers: append([]int{}, ps.Signers...),
+		Hash:    int(ps.Hash),
+		SplitBy: ps.SplitBy,
+		Sig:     ps.Sig,
+		Nonce:   ps.Nonce,
+		Proof:   ps.Proof,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to DER-encode: %s", err)
+	}
+	return b, nil
+}
+
+// returns a partial signature from its DER encoding
+func unmarshalPartialSignatureDER(der []byte) (*PartialSignature, error) {
+	var encoded partialSignatureDER
+	rest, err := asn1.Unmarshal(der, &encoded)
+	if err != nil {
+		return nil, errorf(ErrInvalidPartialSignature, "failed to unmarshal DER-encoded partial signature: %s", err)
+	}
+	if len(rest) > 0 {
+		return nil, errorf(ErrInvalidPartialSignature, "unexpected data after DER-encoded partial signature")
+	}
+	if err := checkSplitBy(encoded.SplitBy); err != nil {
+		return nil, err
+	}
+	if len(encoded.Sig) == 0 {
+		return nil, errorf(ErrInvalidPartialSignature, "partial signature is empty")
+	}
+	for _, signer := range encoded.Signers {
+		if signer < 0 {
+			return nil, errorf(ErrInvalidPartialSignature, "signer index %d is negative", signer)
+		}
+	}
+
+	return &PartialSignature{
+		Signers: encoded.Signers,
+		Hash:    crypto.Hash(encoded.Hash),
+		SplitBy: encoded.SplitBy,
+		Sig:     encoded.Sig,
+		Nonce:   encoded.Nonce,
+		Proof:   encoded.Proof,
+	}, nil
+}
+
+// returns the SHA-256 hash of the PKCS #1 encoding of pub
+func publicKeyID(pub *rsa.PublicKey) [sha256.Size]byte {
+	return sha256.Sum256(x509.MarshalPKCS1PublicKey(pub))
+}
@@ -0,0 +1,88 @@
+package keysplitting
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Partial signature envelopes", func() {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	shards, _ := SplitD(key, 2, Multiplication)
+	hashed := sha256.Sum256([]byte("TEST MESSAGE"))
+
+	It("Carries a partial signature from one signer to the next", func() {
+		partialSig, err := SignFirst(rand.Reader, shards[0], crypto.SHA256, hashed[:])
+		Expect(err).To(BeNil())
+
+		envelope, err := partialSig.EncodeEnvelope(&key.PublicKey)
+		Expect(err).To(BeNil())
+		Expect(envelope).To(HavePrefix("rsa-split-partial-v1:"))
+
+		decoded, err := DecodeEnvelope(envelope, &key.PublicKey)
+		Expect(err).To(BeNil())
+		Expect(decoded).To(Equal(partialSig))
+
+		sig, err := SignNext(rand.Reader, shards[1], crypto.SHA256, hashed[:], decoded)
+		Expect(err).To(BeNil())
+		Expect(rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, hashed[:], sig.Sig)).To(Succeed())
+	})
+
+	It("Keeps the session nonce and proof", func() {
+		partialSig, _ := SignFirst(rand.Reader, shards[0], crypto.SHA256, hashed[:])
+		partialSig.Nonce = []byte("NONCE")
+		partialSig.Proof = []byte("PROOF")
+		envelope, err := partialSig.EncodeEnvelope(&key.PublicKey)
+		Expect(err).To(BeNil())
+
+		decoded, err := DecodeEnvelope(envelope, &key.PublicKey)
+		Expect(err).To(BeNil())
+		Expect(decoded).To(Equal(partialSig))
+	})
+
+	It("Ignores line wrapping", func() {
+		partialSig, _ := SignFirst(rand.Reader, shards[0], crypto.SHA256, hashed[:])
+		envelope, err := partialSig.EncodeEnvelope(&key.PublicKey)
+		Expect(err).To(BeNil())
+
+		var wrapped strings.Builder
+		for i := 0; i < len(envelope); i += 64 {
+			end := i + 64
+			if end > len(envelope) {
+				end = len(envelope)
+			}
+			wrapped.WriteString("> " + envelope[i:end] + "\r\n")
+		}
+		_, err = DecodeEnvelope(strings.ReplaceAll(wrapped.String(), "> ", ""), &key.PublicKey)
+		Expect(err).To(BeNil())
+	})
+
+	It("Rejects envelopes for other keys", func() {
+		otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+		partialSig, _ := SignFirst(rand.Reader, shards[0], crypto.SHA256, hashed[:])
+		envelope, err := partialSig.EncodeEnvelope(&key.PublicKey)
+		Expect(err).To(BeNil())
+
+		_, err = DecodeEnvelope(envelope, &otherKey.PublicKey)
+		Expect(errors.Is(err, ErrKeyMismatch)).To(BeTrue())
+	})
+
+	It("Rejects malformed envelopes", func() {
+		for _, envelope := range []string{
+			"",
+			"AAAA",
+			"rsa-split-partial-v1:!!!",
+			"rsa-split-partial-v1:AAAA",
+			"rsa-split-partial-v2:AAAA",
+		} {
+			_, err := DecodeEnvelope(envelope, &key.PublicKey)
+			Expect(errors.Is(err, ErrInvalidPartialSignature)).To(BeTrue(), envelope)
+		}
+	})
+})
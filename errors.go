@@ -0,0 +1,111 @@
+package keysplitting
+
+import (
+	"errors"
+	"fmt"
+)
+
+// The errors returned by this package wrap one of the following, so that callers can tell broad classes of failure apart
+// with [errors.Is]. The error messages themselves are more specific and are not part of the API
+var (
+	// ErrTooFewShards means that a key or shard was split into, or a signature was combined from, too few pieces
+	ErrTooFewShards = errors.New("too few shards")
+
+	// ErrUnsupportedSplitBy means that a [SplitBy] was unrecognized, or not supported by the requested operation
+	ErrUnsupportedSplitBy = errors.New("unsupported split algorithm")
+
+	// ErrUnsupportedHash means that a hash function was unavailable, or a digest didn't match its hash function
+	ErrUnsupportedHash = errors.New("unsupported hash function")
+
+	// ErrInvalidPartialSignature means that a partial or final signature was malformed, didn't match the shard or other partial signatures,
+	// or failed verification
+	ErrInvalidPartialSignature = errors.New("invalid partial signature")
+
+	// ErrKeyMismatch means that shards, shares, or keys that must belong to the same key do not
+	ErrKeyMismatch = errors.New("mismatched keys")
+
+	// ErrInvalidShard means that a shard was malformed, out of range, or otherwise unusable for the requested operation
+	ErrInvalidShard = errors.New("invalid shard")
+
+	// ErrTooManyAttempts means that a search for random shards gave up (see [SplitOptions].MaxAttempts)
+	ErrTooManyAttempts = errors.New("too many attempts")
+
+	// ErrIncompleteSignature is returned by [VerifyFinal] when a signature does not verify against the public key.
+	// A partial signature that is still missing some shards' contributions is indistinguishable from any other invalid signature,
+	// so this is what VerifyFinal returns for both
+	ErrIncompleteSignature = errors.New("signature is invalid or still missing some partial signatures")
+
+	// ErrIncorrectPassphrase is returned by [DecodeEncryptedPEM] when a shard cannot be decrypted. A wrong passphrase is
+	// indistinguishable from a corrupted or tampered ciphertext, so this is what DecodeEncryptedPEM returns for both
+	ErrIncorrectPassphrase = errors.New("incorrect passphrase or corrupt encrypted shard")
+
+	// ErrDecryption is returned when a ciphertext cannot be decrypted, e.g. by [CombineDecryption]. As with [crypto/rsa.ErrDecryption],
+	// invalid padding is indistinguishable from any other failure, so as not to give away a padding oracle
+	ErrDecryption = errors.New("decryption error")
+
+	// ErrPolicyNotSatisfied means that the shards that signed don't satisfy a [QuorumPolicy]
+	ErrPolicyNotSatisfied = errors.New("quorum policy not satisfied")
+
+	// ErrClosed means that an object such as a [Dealer] was used after it was closed
+	ErrClosed = errors.New("already closed")
+
+	// ErrInvalidKey means that a key was malformed, or that its parameters, such as its public exponent or the size of its
+	// modulus, aren't supported by the requested operation
+	ErrInvalidKey = errors.New("invalid key")
+
+	// ErrInvalidMessage means that a message of a multi-party protocol, such as distributed key generation, was malformed,
+	// misdirected, or duplicated, or that a step of the protocol was taken out of order
+	ErrInvalidMessage = errors.New("invalid protocol message")
+
+	// ErrHealthTestFailed means that a source of randomness failed a health test of an [EntropySource], such as by getting stuck
+	ErrHealthTestFailed = errors.New("randomness health test failed")
+)
+
+// A RandomnessError means that reading from a source of randomness failed. Err is the underlying error
+type RandomnessError struct {
+	Err error
+}
+
+func (e *RandomnessError) Error() string {
+	return fmt.Sprintf("failed to read randomness: %s", e.Err)
+}
+
+func (e *RandomnessError) Unwrap() error {
+	return e.Err
+}
+
+// A SizeLimitError means that a shard being decoded exceeded one of the size limits of [DecodeOptions]. Field names the
+// integer that was too large, i.e. "N", "E", "D" or "Mask", or "encoding" if the encoding itself was too long to
+// be parsed. It matches [ErrInvalidShard] with [errors.Is]
+type SizeLimitError struct {
+	Field   string
+	Bits    int
+	MaxBits int
+}
+
+func (e *SizeLimitError) Error() string {
+	return fmt.Sprintf("shard's %s is %d bits long, which exceeds the limit of %d bits", e.Field, e.Bits, e.MaxBits)
+}
+
+func (e *SizeLimitError) Unwrap() error {
+	return ErrInvalidShard
+}
+
+// an error with its own message that still matches a sentinel error with errors.Is
+type wrappedError struct {
+	sentinel error
+	msg      string
+}
+
+func (e *wrappedError) Error() string {
+	return e.msg
+}
+
+func (e *wrappedError) Unwrap() error {
+	return e.sentinel
+}
+
+// like fmt.Errorf, but the result wraps sentinel
+func errorf(sentinel error, format string, args ...interface{}) error {
+	return &wrappedError{sentinel: sentinel, msg: fmt.Sprintf(format, args...)}
+}
@@ -0,0 +1,89 @@
+package keysplitting
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha512"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// an io.Reader that always fails
+type failingReader struct{}
+
+var errFailingReader = errors.New("failing reader")
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, errFailingReader
+}
+
+var _ = Describe("Errors", func() {
+	hashed := sha512.Sum512([]byte("TEST MESSAGE"))
+	priv, _ := rsa.GenerateKey(rand.Reader, 2048)
+	shards, _ := SplitD(priv, 2, Addition)
+
+	It("Wraps ErrTooFewShards", func() {
+		_, err := SplitD(priv, 1, Addition)
+		Expect(errors.Is(err, ErrTooFewShards)).To(BeTrue())
+		Expect(err.Error()).To(Equal("cannot split key into fewer than 2 shards"))
+	})
+
+	It("Wraps ErrUnsupportedSplitBy", func() {
+		_, err := SplitD(priv, 2, "Exponentiation")
+		Expect(errors.Is(err, ErrUnsupportedSplitBy)).To(BeTrue())
+	})
+
+	It("Wraps ErrUnsupportedHash", func() {
+		_, err := SignMessageFirst(rand.Reader, shards[0], crypto.MD4, []byte("TEST MESSAGE"))
+		Expect(errors.Is(err, ErrUnsupportedHash)).To(BeTrue())
+
+		_, err = SignFirst(rand.Reader, shards[0], crypto.SHA256, hashed[:])
+		Expect(errors.Is(err, ErrUnsupportedHash)).To(BeTrue())
+	})
+
+	It("Wraps ErrInvalidPartialSignature", func() {
+		partialSig, _ := SignFirst(rand.Reader, shards[0], crypto.SHA512, hashed[:])
+		_, err := SignNext(rand.Reader, shards[0], crypto.SHA512, hashed[:], partialSig)
+		Expect(errors.Is(err, ErrInvalidPartialSignature)).To(BeTrue())
+		Expect(errors.Is(err, ErrTooFewShards)).To(BeFalse())
+	})
+
+	It("Wraps ErrKeyMismatch", func() {
+		otherPriv, _ := rsa.GenerateKey(rand.Reader, 2048)
+		otherShards, _ := SplitD(otherPriv, 2, Addition)
+
+		_, err := MergeShards(shards[0], otherShards[1])
+		Expect(errors.Is(err, ErrKeyMismatch)).To(BeTrue())
+	})
+
+	It("Wraps ErrInvalidShard", func() {
+		_, err := DecodePEM("not a PEM block")
+		Expect(errors.Is(err, ErrInvalidShard)).To(BeTrue())
+	})
+
+	It("Wraps ErrInvalidKey", func() {
+		_, err := SplitPEM([]byte("not a PEM block"), 2, Addition, nil)
+		Expect(errors.Is(err, ErrInvalidKey)).To(BeTrue())
+
+		_, err = NewDKGParty(1, 3, 2048, 4)
+		Expect(errors.Is(err, ErrInvalidKey)).To(BeTrue())
+	})
+
+	It("Wraps ErrInvalidMessage", func() {
+		party, err := NewDKGParty(1, 3, 2048, 65537)
+		Expect(err).To(BeNil())
+		_, err = party.BiprimalityShare()
+		Expect(errors.Is(err, ErrInvalidMessage)).To(BeTrue())
+	})
+
+	It("Returns a RandomnessError when the random source fails", func() {
+		_, err := NewPSSSalt(failingReader{}, &priv.PublicKey, crypto.SHA512, nil)
+
+		var randomnessErr *RandomnessError
+		Expect(errors.As(err, &randomnessErr)).To(BeTrue())
+		Expect(errors.Is(err, errFailingReader)).To(BeTrue())
+	})
+})
@@ -6,7 +6,7 @@ import (
 	"crypto/rsa"
 	"crypto/sha512"
 	"fmt"
-	"math/big"
+	"sync"
 
 	"github.com/bastionzero/keysplitting"
 )
@@ -34,51 +34,65 @@ func runAdditiveBrokered() {
 
 	/*
 	 * In this model, all parties sign in parallel, then send their partial signatures to a central broker for verification.
+	 * The broker starts a session first, and sends its nonce to each party along with the digest. Binding the partial
+	 * signatures to the nonce keeps them from being replayed into another session for the same digest
 	 */
-	sig1, err := keysplitting.SignFirst(rand.Reader, shard0, crypto.SHA512, hashed)
+	broker, err := keysplitting.NewBroker(&key.PublicKey, 3)
 	if err != nil {
 		panic(err)
 	}
+	opts := broker.SignerOpts(crypto.SHA512)
 
-	sig2, err := keysplitting.SignFirst(rand.Reader, shard1, crypto.SHA512, hashed)
+	sig1, err := keysplitting.SignFirst(rand.Reader, shard0, opts, hashed)
 	if err != nil {
 		panic(err)
 	}
 
-	sig3, err := keysplitting.SignFirst(rand.Reader, shard2, crypto.SHA512, hashed)
+	sig2, err := keysplitting.SignFirst(rand.Reader, shard1, opts, hashed)
+	if err != nil {
+		panic(err)
+	}
+
+	sig3, err := keysplitting.SignFirst(rand.Reader, shard2, opts, hashed)
 	if err != nil {
 		panic(err)
 	}
 
 	/*
 	 * The broker rolls up all the partial signatures into the complete one, which verifies.
-	 * To do this, simply convert the signatures to integers, multiply them, and mod by the public modulus
+	 * It does not need a key shard to do this, only the public key. The partial signatures usually arrive
+	 * concurrently, so the Broker accepts them from any goroutine and returns the signature once all 3 are in
 	 */
-	sig1int := new(big.Int).SetBytes(sig1)
-	sig2int := new(big.Int).SetBytes(sig2)
-	sig3int := new(big.Int).SetBytes(sig3)
-
-	sig1and2 := new(big.Int).Mul(sig1int, sig2int)
-	sigFinal := new(big.Int).Mul(sig1and2, sig3int)
-	sigFinal.Mod(sigFinal, key.N)
+	var wg sync.WaitGroup
+	for _, partialSig := range []*keysplitting.PartialSignature{sig1, sig2, sig3} {
+		wg.Add(1)
+		go func(partialSig *keysplitting.PartialSignature) {
+			defer wg.Done()
+			if _, err := broker.AddPartial(partialSig); err != nil {
+				panic(err)
+			}
+		}(partialSig)
+	}
+	wg.Wait()
 
-	err = rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA512, hashed, sigFinal.Bytes())
+	sigFinal := broker.Signature()
+	err = rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA512, hashed, sigFinal)
 	if err != nil {
 		panic(err)
 	}
 
 	// none of the 3 partial signatures will verify
-	sig1Err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA512, hashed, sig1)
+	sig1Err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA512, hashed, sig1.Sig)
 	if sig1Err == nil {
 		panic(err)
 	}
 
-	sig2Err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA512, hashed, sig2)
+	sig2Err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA512, hashed, sig2.Sig)
 	if sig2Err == nil {
 		panic(err)
 	}
 
-	sig3Err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA512, hashed, sig3)
+	sig3Err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA512, hashed, sig3.Sig)
 	if sig3Err == nil {
 		panic(err)
 	}
@@ -5,71 +5,36 @@ import (
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/sha512"
-	mrand "math/rand"
-
-	"encoding/json"
 	"fmt"
-	"runtime"
+	mrand "math/rand"
+	"net/http"
 	"time"
 
 	"github.com/bastionzero/keysplitting"
+	"github.com/bastionzero/keysplitting/prommetrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
-// source: https://scene-si.org/2018/08/06/basic-monitoring-of-go-apps-with-the-runtime-package/
-type Monitor struct {
-	Alloc,
-	TotalAlloc,
-	Sys,
-	Mallocs,
-	Frees,
-	LiveObjects,
-	PauseTotalNs uint64
-
-	NumGC        uint32
-	NumGoroutine int
-}
-
-func NewMonitor(duration int) {
-	var m Monitor
-	var rtm runtime.MemStats
-	var interval = time.Duration(duration) * time.Second
-	for {
-		<-time.After(interval)
-
-		// Read full mem stats
-		runtime.ReadMemStats(&rtm)
-
-		// Number of goroutines
-		m.NumGoroutine = runtime.NumGoroutine()
-
-		// Misc memory stats
-		m.Alloc = rtm.Alloc
-		m.TotalAlloc = rtm.TotalAlloc
-		m.Sys = rtm.Sys
-		m.Mallocs = rtm.Mallocs
-		m.Frees = rtm.Frees
-
-		// Live objects = Mallocs - Frees
-		m.LiveObjects = m.Mallocs - m.Frees
-
-		// GC Stats
-		m.PauseTotalNs = rtm.PauseTotalNs
-		m.NumGC = rtm.NumGC
-
-		// Just encode to json and print
-		b, _ := json.Marshal(m)
-		fmt.Println(string(b))
-	}
-}
+// the address that Prometheus scrapes the metrics from
+const metricsAddr = "localhost:9090"
 
 func runMetrics() {
-	fmt.Println("Running metrics script -- a continuous random workflow to sanity check memory usage and other statistics")
+	fmt.Println("Running metrics script -- a continuous random workflow whose signature counts and latencies are served for Prometheus at http://" + metricsAddr + "/metrics")
 	msg := "test message"
 	hasher := sha512.New()
 	hasher.Write([]byte(msg))
 	hashed := hasher.Sum(nil)
 
-	go NewMonitor(30)
+	metrics := prommetrics.New(nil)
+	keysplitting.SetMetrics(metrics)
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(metrics)
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	go func() {
+		panic(http.ListenAndServe(metricsAddr, mux))
+	}()
 	mrand.Seed(time.Hour.Microseconds())
 
 	for {
@@ -94,7 +59,7 @@ func runMetrics() {
 				panic(err)
 			}
 		}
-		err = rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA512, hashed, sigNext)
+		err = rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA512, hashed, sigNext.Sig)
 		if err != nil {
 			panic(err)
 		}
@@ -58,18 +58,18 @@ func runMultiplicative() {
 		panic(err)
 	}
 
-	err = rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA512, hashed, sig3)
+	err = rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA512, hashed, sig3.Sig)
 	if err != nil {
 		panic(err)
 	}
 
 	// neither of the partial signatures will verify
-	sig1Err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA512, hashed, sig1)
+	sig1Err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA512, hashed, sig1.Sig)
 	if sig1Err == nil {
 		panic(err)
 	}
 
-	sig2Err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA512, hashed, sig2)
+	sig2Err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA512, hashed, sig2.Sig)
 	if sig2Err == nil {
 		panic(err)
 	}
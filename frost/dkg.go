@@ -0,0 +1,194 @@
+package frost
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"io"
+
+	"filippo.io/edwards25519"
+	"github.com/bastionzero/keysplitting"
+)
+
+// Distributed key generation follows Pedersen's protocol with the proofs of knowledge from the FROST paper, as RFC 9591 suggests:
+// every party deals Shamir shares of its own random secret, and the key is the sum of the secrets, so no party ever knows it.
+// Every party must complete a round before any party can start the next one:
+//
+//  1. Every party calls [DKGParty.Commit] and broadcasts the result
+//  2. Every party calls [DKGParty.Shares] with the n broadcasts and sends the j'th share to party j (including itself), privately
+//  3. Every party calls [DKGParty.Finalize] with the n shares it received to obtain its [KeyShare]
+//
+// A party that deals inconsistent shares is caught, and named, in round 3, at which point the protocol must be abandoned
+
+// A DKGParty is one participant in distributed key generation. See [NewDKGParty]
+type DKGParty struct {
+	random       io.Reader
+	index        int                     // this party's position in 1..n
+	t, n         int                     // threshold and number of parties
+	coefficients []*edwards25519.Scalar  // this party's polynomial, which is cleared once its shares have been dealt
+	commitments  [][]*edwards25519.Point // every party's commitments to its polynomial, once they have been checked
+}
+
+// A DKGCommitment is a party's broadcast commitment to its polynomial, with a proof of knowledge of its secret
+type DKGCommitment struct {
+	From        int
+	Commitments [][]byte             // a_k * B for each coefficient of the polynomial, as encoded points
+	ProofR      []byte               // the proof's commitment r * B
+	ProofZ      *edwards25519.Scalar // the proof's response r + a_0 * c mod L
+}
+
+// A DKGShare is the share of one party's secret that it deals to another
+type DKGShare struct {
+	From, To int
+	Value    *edwards25519.Scalar // the sender's polynomial evaluated at To
+}
+
+// NewDKGParty creates party index (in 1..n) of an n-party distributed key generation for a t-of-n split
+func NewDKGParty(random io.Reader, index, t, n int) (*DKGParty, error) {
+	if err := checkThreshold(t, n); err != nil {
+		return nil, err
+	}
+	if index < 1 || index > n {
+		return nil, fmt.Errorf("%w: party index %d is out of range", keysplitting.ErrInvalidShard, index)
+	}
+	return &DKGParty{random: random, index: index, t: t, n: n}, nil
+}
+
+// Commit picks this party's random polynomial, and returns the commitment to broadcast
+func (party *DKGParty) Commit() (*DKGCommitment, error) {
+	party.coefficients = make([]*edwards25519.Scalar, party.t)
+	commitments := make([][]byte, party.t)
+	for i := range party.coefficients {
+		a, err := randomScalar(party.random)
+		if err != nil {
+			return nil, err
+		}
+		party.coefficients[i] = a
+		commitments[i] = new(edwards25519.Point).ScalarBaseMult(a).Bytes()
+	}
+
+	// prove knowledge of a_0, so that no party can pick its commitment as a function of the others'
+	r, err := randomScalar(party.random)
+	if err != nil {
+		return nil, err
+	}
+	R := new(edwards25519.Point).ScalarBaseMult(r).Bytes()
+	c := proofChallenge(party.index, commitments[0], R)
+	z := edwards25519.NewScalar().MultiplyAdd(c, party.coefficients[0], r)
+
+	return &DKGCommitment{From: party.index, Commitments: commitments, ProofR: R, ProofZ: z}, nil
+}
+
+// Shares checks every party's commitment, and returns this party's share for each party, in order of their index
+func (party *DKGParty) Shares(commitments []*DKGCommitment) ([]*DKGShare, error) {
+	if party.coefficients == nil {
+		return nil, fmt.Errorf("shares have already been dealt")
+	}
+	if len(commitments) != party.n {
+		return nil, fmt.Errorf("%w: expected %d commitments, got %d", keysplitting.ErrTooFewShards, party.n, len(commitments))
+	}
+
+	party.commitments = make([][]*edwards25519.Point, party.n)
+	for _, commitment := range commitments {
+		if commitment.From < 1 || commitment.From > party.n || party.commitments[commitment.From-1] != nil {
+			return nil, fmt.Errorf("%w: unexpected commitment from party %d", keysplitting.ErrInvalidShard, commitment.From)
+		}
+		points, err := checkDKGCommitment(commitment, party.t)
+		if err != nil {
+			return nil, err
+		}
+		party.commitments[commitment.From-1] = points
+	}
+
+	shares := make([]*DKGShare, party.n)
+	for j := 1; j <= party.n; j++ {
+		shares[j-1] = &DKGShare{From: party.index, To: j, Value: evaluatePolynomial(party.coefficients, scalarFromInt(j))}
+	}
+	party.coefficients = nil
+	return shares, nil
+}
+
+// Finalize checks the shares dealt to this party against their dealers' commitments, and returns this party's key share
+func (party *DKGParty) Finalize(shares []*DKGShare) (*KeyShare, error) {
+	if party.commitments == nil {
+		return nil, fmt.Errorf("commitments have not been checked")
+	}
+	if len(shares) != party.n {
+		return nil, fmt.Errorf("%w: expected %d shares, got %d", keysplitting.ErrTooFewShards, party.n, len(shares))
+	}
+
+	seen := make([]bool, party.n)
+	secret := edwards25519.NewScalar()
+	for _, share := range shares {
+		if share.To != party.index || share.From < 1 || share.From > party.n || seen[share.From-1] {
+			return nil, fmt.Errorf("%w: unexpected share from party %d", keysplitting.ErrInvalidShard, share.From)
+		}
+		seen[share.From-1] = true
+
+		// f_j(i) * B == sum of C_jk * i^k
+		expected := evaluateCommitments(party.commitments[share.From-1], party.index)
+		if share.Value == nil || new(edwards25519.Point).ScalarBaseMult(share.Value).Equal(expected) != 1 {
+			return nil, fmt.Errorf("%w: share from party %d doesn't match its commitment", keysplitting.ErrInvalidShard, share.From)
+		}
+		secret.Add(secret, share.Value)
+	}
+
+	// the group's commitments are the sums of every party's, and the public key is the commitment to the constant term
+	sum := make([]*edwards25519.Point, party.t)
+	for k := range sum {
+		sum[k] = edwards25519.NewIdentityPoint()
+		for _, points := range party.commitments {
+			sum[k].Add(sum[k], points[k])
+		}
+	}
+	group := &GroupKey{
+		PublicKey:          ed25519.PublicKey(sum[0].Bytes()),
+		Threshold:          party.t,
+		VerificationShares: make([][]byte, party.n),
+	}
+	for i := 1; i <= party.n; i++ {
+		group.VerificationShares[i-1] = evaluateCommitments(sum, i).Bytes()
+	}
+	return &KeyShare{Group: group, Index: party.index, Secret: secret}, nil
+}
+
+// checks the commitment's length and proof of knowledge, returning the decoded commitments
+func checkDKGCommitment(commitment *DKGCommitment, t int) ([]*edwards25519.Point, error) {
+	if len(commitment.Commitments) != t || commitment.ProofZ == nil {
+		return nil, fmt.Errorf("%w: malformed commitment from party %d", keysplitting.ErrInvalidShard, commitment.From)
+	}
+	points := make([]*edwards25519.Point, t)
+	for k, encoded := range commitment.Commitments {
+		p, err := decodePoint(encoded)
+		if err != nil {
+			return nil, err
+		}
+		points[k] = p
+	}
+	R, err := decodePoint(commitment.ProofR)
+	if err != nil {
+		return nil, err
+	}
+
+	// z * B - c * C_0 == R, all of which are public
+	c := proofChallenge(commitment.From, commitment.Commitments[0], commitment.ProofR)
+	if new(edwards25519.Point).VarTimeDoubleScalarBaseMult(c.Negate(c), points[0], commitment.ProofZ).Equal(R) != 1 {
+		return nil, fmt.Errorf("%w: invalid proof of knowledge from party %d", keysplitting.ErrInvalidShard, commitment.From)
+	}
+	return points, nil
+}
+
+// returns the challenge for party index's proof of knowledge of the secret behind C_0
+func proofChallenge(index int, C0, R []byte) *edwards25519.Scalar {
+	return hashToScalar("dkg", scalarFromInt(index).Bytes(), C0, R)
+}
+
+// returns the sum of C_k * x^k
+func evaluateCommitments(commitments []*edwards25519.Point, x int) *edwards25519.Point {
+	result := edwards25519.NewIdentityPoint()
+	power, xs := scalarFromInt(1), scalarFromInt(x)
+	for _, C := range commitments {
+		result.Add(result, new(edwards25519.Point).ScalarMult(power, C))
+		power.Multiply(power, xs)
+	}
+	return result
+}
@@ -0,0 +1,84 @@
+package frost
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+
+	"filippo.io/edwards25519"
+	"github.com/bastionzero/keysplitting"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Distributed key generation", func() {
+	const t, n = 2, 3
+
+	// runs the first two rounds, returning the parties and the shares dealt to each of them
+	deal := func() ([]*DKGParty, [][]*DKGShare) {
+		parties := make([]*DKGParty, n)
+		commitments := make([]*DKGCommitment, n)
+		for i := range parties {
+			var err error
+			parties[i], err = NewDKGParty(rand.Reader, i+1, t, n)
+			Expect(err).To(BeNil())
+			commitments[i], err = parties[i].Commit()
+			Expect(err).To(BeNil())
+		}
+		received := make([][]*DKGShare, n)
+		for _, party := range parties {
+			shares, err := party.Shares(commitments)
+			Expect(err).To(BeNil())
+			for j, share := range shares {
+				received[j] = append(received[j], share)
+			}
+		}
+		return parties, received
+	}
+
+	It("Generates shares that sign together", func() {
+		parties, received := deal()
+		shares := make([]*KeyShare, n)
+		for i, party := range parties {
+			var err error
+			shares[i], err = party.Finalize(received[i])
+			Expect(err).To(BeNil())
+		}
+		for _, share := range shares[1:] {
+			Expect(share.Group).To(Equal(shares[0].Group))
+		}
+
+		message := []byte("TEST MESSAGE")
+		commitments, partials := signWith([]*KeyShare{shares[2], shares[0]}, message)
+		sig, err := Combine(shares[0].Group, message, commitments, partials...)
+		Expect(err).To(BeNil())
+		Expect(ed25519.Verify(shares[0].Group.PublicKey, message, sig)).To(BeTrue())
+	})
+
+	It("Catches a party that deals a bad share", func() {
+		parties, received := deal()
+		received[0][1].Value = edwards25519.NewScalar().Add(received[0][1].Value, scalarFromInt(1))
+		_, err := parties[0].Finalize(received[0])
+		Expect(errors.Is(err, keysplitting.ErrInvalidShard)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("party 2"))
+	})
+
+	It("Rejects a commitment without a valid proof of knowledge", func() {
+		party, err := NewDKGParty(rand.Reader, 1, t, n)
+		Expect(err).To(BeNil())
+		commitments := make([]*DKGCommitment, n)
+		for i := range commitments {
+			other, err := NewDKGParty(rand.Reader, i+1, t, n)
+			Expect(err).To(BeNil())
+			commitments[i], err = other.Commit()
+			Expect(err).To(BeNil())
+		}
+		_, err = party.Commit()
+		Expect(err).To(BeNil())
+
+		// a commitment copied from another party fails, since the proof is bound to the sender
+		commitments[2] = &DKGCommitment{From: 3, Commitments: commitments[1].Commitments, ProofR: commitments[1].ProofR, ProofZ: commitments[1].ProofZ}
+		_, err = party.Shares(commitments)
+		Expect(errors.Is(err, keysplitting.ErrInvalidShard)).To(BeTrue())
+	})
+})
@@ -0,0 +1,46 @@
+package frost
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"filippo.io/edwards25519"
+	"github.com/bastionzero/keysplitting"
+)
+
+// Points and scalars are those of filippo.io/edwards25519, whose arithmetic is constant time, so that neither shares nor
+// nonces leak through timing. The only exception is VarTimeDoubleScalarBaseMult, which is only used to check public values
+
+// decodes a canonically encoded point (RFC 8032, section 5.1.3)
+func decodePoint(b []byte) (*edwards25519.Point, error) {
+	p, err := new(edwards25519.Point).SetBytes(b)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid edwards25519 point: %s", keysplitting.ErrInvalidPartialSignature, err)
+	}
+	if !bytes.Equal(p.Bytes(), b) {
+		return nil, fmt.Errorf("%w: non-canonical edwards25519 point", keysplitting.ErrInvalidPartialSignature)
+	}
+	return p, nil
+}
+
+// returns a uniformly random scalar, from 64 bytes of randomness reduced mod L
+func randomScalar(random io.Reader) (*edwards25519.Scalar, error) {
+	buf := make([]byte, 64)
+	if _, err := io.ReadFull(random, buf); err != nil {
+		return nil, &keysplitting.RandomnessError{Err: err}
+	}
+	return edwards25519.NewScalar().SetUniformBytes(buf)
+}
+
+// returns the scalar x, for a share's index
+func scalarFromInt(x int) *edwards25519.Scalar {
+	buf := make([]byte, 32)
+	binary.LittleEndian.PutUint64(buf, uint64(x))
+	s, err := edwards25519.NewScalar().SetCanonicalBytes(buf)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
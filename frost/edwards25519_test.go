@@ -0,0 +1,50 @@
+package frost
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+
+	"filippo.io/edwards25519"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Edwards25519", func() {
+	It("Derives the same public keys as crypto/ed25519", func() {
+		for i := 0; i < 8; i++ {
+			pub, priv, err := ed25519.GenerateKey(rand.Reader)
+			Expect(err).To(BeNil())
+			Expect(new(edwards25519.Point).ScalarBaseMult(secretScalar(priv)).Bytes()).To(Equal([]byte(pub)))
+		}
+	})
+
+	It("Decodes what it encodes", func() {
+		for i := 0; i < 8; i++ {
+			k, err := randomScalar(rand.Reader)
+			Expect(err).To(BeNil())
+			p := new(edwards25519.Point).ScalarBaseMult(k)
+			decoded, err := decodePoint(p.Bytes())
+			Expect(err).To(BeNil())
+			Expect(decoded.Equal(p)).To(Equal(1))
+		}
+	})
+
+	It("Encodes indices as little-endian scalars", func() {
+		Expect(scalarFromInt(258).Bytes()).To(Equal(append([]byte{2, 1}, make([]byte, 30)...)))
+	})
+
+	It("Rejects invalid encodings", func() {
+		_, err := decodePoint(make([]byte, 31))
+		Expect(err).ToNot(BeNil())
+
+		// y = p is not canonical, although y = 0 is on the curve
+		p := append([]byte{0xed}, bytes.Repeat([]byte{0xff}, 30)...)
+		_, err = decodePoint(append(p, 0x7f))
+		Expect(err).ToNot(BeNil())
+
+		// y = 2 is not on the curve
+		_, err = decodePoint(append([]byte{2}, make([]byte, 31)...))
+		Expect(err).ToNot(BeNil())
+	})
+})
@@ -0,0 +1,368 @@
+/*
+Package frost splits Ed25519 keys into threshold shares, any t of which sign together without the key ever being rebuilt,
+following FROST [1] with the FROST(Ed25519, SHA-512) ciphersuite. It is the Ed25519 sibling of keysplitting's threshold RSA
+signatures: the signatures it produces are ordinary Ed25519 signatures, which [ed25519.Verify] accepts for the group's public key.
+
+The shares either come from an existing key with [Split], much like [keysplitting.SplitThreshold], or from distributed key
+generation with [DKGParty], in which case the whole key never exists anywhere. Signing a message with t or more shares takes
+two rounds:
+
+ 1. Every signer calls [Commit] with its share, keeps the [SigningNonces] secret, and sends the [NonceCommitment] to the others
+ 2. Every signer calls [Sign] with its nonces, the message and the signers' commitments, and sends the [PartialSignature] on
+ 3. Anyone calls [Combine] with the commitments and partial signatures, which checks the result against the public key
+
+Nonces must only ever be used once, and [Sign] clears them. Since signing doesn't depend on a secret held elsewhere, a signer
+may commit ahead of time, before the message is known. A partial signature that doesn't match its signer's verification share
+is reported by [Combine], so a misbehaving signer can be identified.
+
+	[1] https://www.rfc-editor.org/rfc/rfc9591
+*/
+package frost
+
+import (
+	"crypto/ed25519"
+	"crypto/sha512"
+	"fmt"
+	"io"
+	"sort"
+
+	"filippo.io/edwards25519"
+	"github.com/bastionzero/keysplitting"
+)
+
+// the ciphersuite's context string, which domain-separates its hash functions
+const contextString = "FROST-ED25519-SHA512-v1"
+
+// A GroupKey is the public part of a set of shares
+type GroupKey struct {
+	PublicKey          ed25519.PublicKey // the public key that signatures verify against
+	Threshold          int               // t, the number of shares required to sign
+	VerificationShares [][]byte          // s_i * B for each share, in order of their index, which identify bad partial signatures
+}
+
+// A KeyShare is one of n shares of an Ed25519 private key, from [Split] or [DKGParty.Finalize]
+type KeyShare struct {
+	Group  *GroupKey            // public part
+	Index  int                  // this share's position in 1..n
+	Secret *edwards25519.Scalar // share of the private scalar, f(Index) mod L
+}
+
+// SigningNonces are a signer's secret nonces for a single signature
+type SigningNonces struct {
+	index           int
+	hiding, binding *edwards25519.Scalar
+	commitment      *NonceCommitment
+}
+
+// A NonceCommitment is a signer's public commitment to its [SigningNonces]
+type NonceCommitment struct {
+	Index   int    // index of the committing share
+	Hiding  []byte // d * B, as an encoded point
+	Binding []byte // e * B, as an encoded point
+}
+
+// A PartialSignature is the contribution of a single [KeyShare] to a signature
+type PartialSignature struct {
+	Index int                  // index of the share that produced the partial signature
+	Z     *edwards25519.Scalar // d + e * rho + lambda * s * c mod L
+}
+
+// Split splits priv into n shares such that any t of them can sign with [Sign]. The group's public key is priv's public key
+func Split(random io.Reader, priv ed25519.PrivateKey, t, n int) ([]*KeyShare, error) {
+	if len(priv) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("%w: invalid Ed25519 private key", keysplitting.ErrInvalidShard)
+	}
+	if err := checkThreshold(t, n); err != nil {
+		return nil, err
+	}
+
+	// f(X) = s + a1 * X + ... + a(t-1) * X^(t-1), where s is the scalar derived from the seed (RFC 8032, section 5.1.5)
+	coefficients := make([]*edwards25519.Scalar, t)
+	coefficients[0] = secretScalar(priv)
+	for i := 1; i < t; i++ {
+		a, err := randomScalar(random)
+		if err != nil {
+			return nil, err
+		}
+		coefficients[i] = a
+	}
+
+	group := &GroupKey{
+		PublicKey:          append(ed25519.PublicKey{}, priv.Public().(ed25519.PublicKey)...),
+		Threshold:          t,
+		VerificationShares: make([][]byte, n),
+	}
+	shares := make([]*KeyShare, n)
+	for i := 1; i <= n; i++ {
+		s := evaluatePolynomial(coefficients, scalarFromInt(i))
+		group.VerificationShares[i-1] = new(edwards25519.Point).ScalarBaseMult(s).Bytes()
+		shares[i-1] = &KeyShare{Group: group, Index: i, Secret: s}
+	}
+	return shares, nil
+}
+
+// Commit generates fresh nonces for signing with share, and the commitment to send to the other signers
+func Commit(random io.Reader, share *KeyShare) (*SigningNonces, *NonceCommitment, error) {
+	hiding, err := generateNonce(random, share.Secret)
+	if err != nil {
+		return nil, nil, err
+	}
+	binding, err := generateNonce(random, share.Secret)
+	if err != nil {
+		return nil, nil, err
+	}
+	commitment := &NonceCommitment{
+		Index:   share.Index,
+		Hiding:  new(edwards25519.Point).ScalarBaseMult(hiding).Bytes(),
+		Binding: new(edwards25519.Point).ScalarBaseMult(binding).Bytes(),
+	}
+	return &SigningNonces{index: share.Index, hiding: hiding, binding: binding, commitment: commitment}, commitment, nil
+}
+
+// Sign uses share and its nonces from [Commit] to produce a partial signature on message. The commitments must be those of
+// every signer, including this one, and every signer must sign the same message with the same commitments
+func Sign(share *KeyShare, nonces *SigningNonces, message []byte, commitments []*NonceCommitment) (*PartialSignature, error) {
+	if nonces == nil || nonces.hiding == nil {
+		return nil, fmt.Errorf("%w: signing nonces have already been used", keysplitting.ErrInvalidPartialSignature)
+	}
+	if nonces.index != share.Index {
+		return nil, fmt.Errorf("%w: signing nonces belong to share %d", keysplitting.ErrInvalidShard, nonces.index)
+	}
+	commitments, err := sortCommitments(share.Group, commitments)
+	if err != nil {
+		return nil, err
+	}
+	found := false
+	for _, commitment := range commitments {
+		if commitment.Index == share.Index {
+			found = string(commitment.Hiding) == string(nonces.commitment.Hiding) &&
+				string(commitment.Binding) == string(nonces.commitment.Binding)
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("%w: commitments don't include this signer's nonces", keysplitting.ErrInvalidPartialSignature)
+	}
+
+	session, err := newSigningSession(share.Group, message, commitments)
+	if err != nil {
+		return nil, err
+	}
+
+	// z_i = d_i + e_i * rho_i + lambda_i * s_i * c
+	i := session.position(share.Index)
+	z := edwards25519.NewScalar().MultiplyAdd(nonces.binding, session.bindingFactors[i], nonces.hiding)
+	z.MultiplyAdd(edwards25519.NewScalar().Multiply(session.lambdas[i], share.Secret), session.challenge, z)
+
+	// never reuse the nonces, since two signatures with the same nonces reveal the share
+	nonces.hiding, nonces.binding = nil, nil
+	return &PartialSignature{Index: share.Index, Z: z}, nil
+}
+
+// Combine combines the partial signatures of every signer whose commitment is given into an Ed25519 signature on message,
+// and checks it against the group's public key. If it doesn't verify, the error is a *[keysplitting.MisbehavingSignersError]
+// naming the shares whose partial signatures are bad
+func Combine(group *GroupKey, message []byte, commitments []*NonceCommitment, partials ...*PartialSignature) ([]byte, error) {
+	commitments, err := sortCommitments(group, commitments)
+	if err != nil {
+		return nil, err
+	}
+	if len(partials) != len(commitments) {
+		return nil, fmt.Errorf("%w: expected %d partial signatures, got %d", keysplitting.ErrTooFewShards, len(commitments), len(partials))
+	}
+	session, err := newSigningSession(group, message, commitments)
+	if err != nil {
+		return nil, err
+	}
+
+	z := edwards25519.NewScalar()
+	zs := make([]*edwards25519.Scalar, len(commitments))
+	for _, partial := range partials {
+		i := session.position(partial.Index)
+		if i < 0 || zs[i] != nil {
+			return nil, fmt.Errorf("%w: unexpected partial signature from share %d", keysplitting.ErrInvalidPartialSignature, partial.Index)
+		}
+		if partial.Z == nil {
+			return nil, fmt.Errorf("%w: partial signature from share %d is missing", keysplitting.ErrInvalidPartialSignature, partial.Index)
+		}
+		zs[i] = partial.Z
+		z.Add(z, partial.Z)
+	}
+
+	sig := append(session.groupCommitment.Bytes(), z.Bytes()...)
+	if ed25519.Verify(group.PublicKey, message, sig) {
+		return sig, nil
+	}
+
+	// z_i * B - lambda_i * c * Y_i == D_i + rho_i * E_i for every honest signer. These are all public, so the check can
+	// be variable time
+	var bad []int
+	for i, commitment := range commitments {
+		Y, err := decodePoint(group.VerificationShares[commitment.Index-1])
+		if err != nil {
+			return nil, err
+		}
+		k := edwards25519.NewScalar().Multiply(session.lambdas[i], session.challenge)
+		actual := new(edwards25519.Point).VarTimeDoubleScalarBaseMult(k.Negate(k), Y, zs[i])
+		if actual.Equal(session.commitmentShares[i]) != 1 {
+			bad = append(bad, commitment.Index)
+		}
+	}
+	if len(bad) > 0 {
+		return nil, &keysplitting.MisbehavingSignersError{Signers: bad}
+	}
+	return nil, fmt.Errorf("%w: combined signature does not verify", keysplitting.ErrInvalidPartialSignature)
+}
+
+// the values every signer derives from the message and the commitments
+type signingSession struct {
+	indices          []int
+	bindingFactors   []*edwards25519.Scalar
+	lambdas          []*edwards25519.Scalar
+	commitmentShares []*edwards25519.Point // D_i + rho_i * E_i
+	groupCommitment  *edwards25519.Point   // R, the sum of the commitment shares
+	challenge        *edwards25519.Scalar  // c = H2(R || Y || message), the Ed25519 challenge
+}
+
+func newSigningSession(group *GroupKey, message []byte, commitments []*NonceCommitment) (*signingSession, error) {
+	session := &signingSession{groupCommitment: edwards25519.NewIdentityPoint()}
+
+	// rho_i = H1(Y || H4(message) || H5(encoded commitments) || i)
+	msgHash := hashWith("msg", message)
+	var encoded []byte
+	for _, commitment := range commitments {
+		encoded = append(encoded, scalarFromInt(commitment.Index).Bytes()...)
+		encoded = append(encoded, commitment.Hiding...)
+		encoded = append(encoded, commitment.Binding...)
+	}
+	prefix := append(append(append([]byte{}, group.PublicKey...), msgHash...), hashWith("com", encoded)...)
+
+	for _, commitment := range commitments {
+		session.indices = append(session.indices, commitment.Index)
+	}
+	for i, commitment := range commitments {
+		D, err := decodePoint(commitment.Hiding)
+		if err != nil {
+			return nil, err
+		}
+		E, err := decodePoint(commitment.Binding)
+		if err != nil {
+			return nil, err
+		}
+		rho := hashToScalar("rho", prefix, scalarFromInt(commitment.Index).Bytes())
+		share := new(edwards25519.Point).Add(D, new(edwards25519.Point).ScalarMult(rho, E))
+
+		session.bindingFactors = append(session.bindingFactors, rho)
+		session.lambdas = append(session.lambdas, lagrangeCoefficient(session.indices, i))
+		session.commitmentShares = append(session.commitmentShares, share)
+		session.groupCommitment.Add(session.groupCommitment, share)
+	}
+
+	session.challenge = hashToScalar("", session.groupCommitment.Bytes(), group.PublicKey, message)
+	return session, nil
+}
+
+// returns the position of the share with the given index among the signers, or -1
+func (session *signingSession) position(index int) int {
+	for i, signer := range session.indices {
+		if signer == index {
+			return i
+		}
+	}
+	return -1
+}
+
+// returns a copy of the commitments in order of their index, after checking that there are enough of them, with no duplicates
+func sortCommitments(group *GroupKey, commitments []*NonceCommitment) ([]*NonceCommitment, error) {
+	if len(commitments) < group.Threshold {
+		return nil, fmt.Errorf("%w: cannot sign with fewer than %d shares", keysplitting.ErrTooFewShards, group.Threshold)
+	}
+	sorted := append([]*NonceCommitment{}, commitments...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Index < sorted[j].Index })
+	for i, commitment := range sorted {
+		if commitment.Index < 1 || commitment.Index > len(group.VerificationShares) {
+			return nil, fmt.Errorf("%w: commitment index %d is out of range", keysplitting.ErrInvalidPartialSignature, commitment.Index)
+		}
+		if i > 0 && sorted[i-1].Index == commitment.Index {
+			return nil, fmt.Errorf("%w: duplicate commitment from share %d", keysplitting.ErrInvalidPartialSignature, commitment.Index)
+		}
+	}
+	return sorted, nil
+}
+
+// returns H3(random || secret), so that a weak source of randomness doesn't on its own expose the share (RFC 9591, section 4.1)
+func generateNonce(random io.Reader, secret *edwards25519.Scalar) (*edwards25519.Scalar, error) {
+	buf := make([]byte, 32)
+	if _, err := io.ReadFull(random, buf); err != nil {
+		return nil, &keysplitting.RandomnessError{Err: err}
+	}
+	return hashToScalar("nonce", buf, secret.Bytes()), nil
+}
+
+// returns SHA-512(contextString || label || data...) as a little-endian scalar mod L. The empty label is the Ed25519
+// challenge, which has no prefix at all
+func hashToScalar(label string, data ...[]byte) *edwards25519.Scalar {
+	h := sha512.New()
+	if label != "" {
+		h.Write([]byte(contextString + label))
+	}
+	for _, d := range data {
+		h.Write(d)
+	}
+	x, err := edwards25519.NewScalar().SetUniformBytes(h.Sum(nil))
+	if err != nil {
+		panic(err)
+	}
+	return x
+}
+
+// returns SHA-512(contextString || label || data)
+func hashWith(label string, data []byte) []byte {
+	h := sha512.New()
+	h.Write([]byte(contextString + label))
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+// returns the Lagrange coefficient at 0 for the i'th of the given indices, mod L
+func lagrangeCoefficient(indices []int, i int) *edwards25519.Scalar {
+	num, den := scalarFromInt(1), scalarFromInt(1)
+	xi := scalarFromInt(indices[i])
+	for j, index := range indices {
+		if j == i {
+			continue
+		}
+		xj := scalarFromInt(index)
+		num.Multiply(num, xj)
+		den.Multiply(den, edwards25519.NewScalar().Subtract(xj, xi))
+	}
+	return num.Multiply(num, den.Invert(den))
+}
+
+// evaluates the polynomial with the given coefficients at x, mod L
+func evaluatePolynomial(coefficients []*edwards25519.Scalar, x *edwards25519.Scalar) *edwards25519.Scalar {
+	y := edwards25519.NewScalar()
+	for i := len(coefficients) - 1; i >= 0; i-- {
+		y.MultiplyAdd(y, x, coefficients[i])
+	}
+	return y
+}
+
+// returns the secret scalar of an Ed25519 private key: the clamped first half of SHA-512(seed), mod L
+func secretScalar(priv ed25519.PrivateKey) *edwards25519.Scalar {
+	h := sha512.Sum512(priv.Seed())
+	s, err := edwards25519.NewScalar().SetBytesWithClamping(h[:32])
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func checkThreshold(t, n int) error {
+	if t < 2 {
+		return fmt.Errorf("%w: threshold must be at least 2", keysplitting.ErrTooFewShards)
+	}
+	if n < t {
+		return fmt.Errorf("%w: cannot split key into fewer shares than the threshold", keysplitting.ErrTooFewShards)
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package frost
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"filippo.io/edwards25519"
+	"github.com/bastionzero/keysplitting"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestFROST(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "FROST Suite")
+}
+
+// runs both signing rounds with the given shares, returning the commitments and partial signatures
+func signWith(shares []*KeyShare, message []byte) ([]*NonceCommitment, []*PartialSignature) {
+	nonces := make([]*SigningNonces, len(shares))
+	commitments := make([]*NonceCommitment, len(shares))
+	for i, share := range shares {
+		var err error
+		nonces[i], commitments[i], err = Commit(rand.Reader, share)
+		Expect(err).To(BeNil())
+	}
+	partials := make([]*PartialSignature, len(shares))
+	for i, share := range shares {
+		var err error
+		partials[i], err = Sign(share, nonces[i], message, commitments)
+		Expect(err).To(BeNil())
+	}
+	return commitments, partials
+}
+
+var _ = Describe("FROST", func() {
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	shares, splitErr := Split(rand.Reader, priv, 3, 5)
+	message := []byte("TEST MESSAGE")
+
+	It("Splits the key into Shamir shares", func() {
+		Expect(splitErr).To(BeNil())
+		Expect(shares).To(HaveLen(5))
+		Expect(shares[0].Group.PublicKey).To(Equal(pub))
+
+		indices := []int{1, 3, 4}
+		s := edwards25519.NewScalar()
+		for i, index := range indices {
+			s.MultiplyAdd(lagrangeCoefficient(indices, i), shares[index-1].Secret, s)
+		}
+		Expect(s.Equal(secretScalar(priv))).To(Equal(1))
+	})
+
+	It("Signs with any t shares", func() {
+		for _, signers := range [][]*KeyShare{shares[:3], {shares[4], shares[1], shares[2]}, shares} {
+			commitments, partials := signWith(signers, message)
+			sig, err := Combine(shares[0].Group, message, commitments, partials...)
+			Expect(err).To(BeNil())
+			Expect(ed25519.Verify(pub, message, sig)).To(BeTrue())
+		}
+	})
+
+	It("Refuses to sign with fewer than t shares", func() {
+		nonces, commitment, err := Commit(rand.Reader, shares[0])
+		Expect(err).To(BeNil())
+		_, other, err := Commit(rand.Reader, shares[1])
+		Expect(err).To(BeNil())
+		_, err = Sign(shares[0], nonces, message, []*NonceCommitment{commitment, other})
+		Expect(errors.Is(err, keysplitting.ErrTooFewShards)).To(BeTrue())
+	})
+
+	It("Never reuses nonces", func() {
+		signers := shares[:3]
+		commitments, _ := signWith(signers, message)
+		nonces, commitment, err := Commit(rand.Reader, signers[0])
+		Expect(err).To(BeNil())
+		commitments[0] = commitment
+		_, err = Sign(signers[0], nonces, message, commitments)
+		Expect(err).To(BeNil())
+		_, err = Sign(signers[0], nonces, message, commitments)
+		Expect(errors.Is(err, keysplitting.ErrInvalidPartialSignature)).To(BeTrue())
+	})
+
+	It("Rejects commitments that don't include the signer's nonces", func() {
+		nonces, _, err := Commit(rand.Reader, shares[0])
+		Expect(err).To(BeNil())
+		commitments, _ := signWith(shares[:3], message)
+		_, err = Sign(shares[0], nonces, message, commitments)
+		Expect(errors.Is(err, keysplitting.ErrInvalidPartialSignature)).To(BeTrue())
+	})
+
+	It("Names the signers of bad partial signatures", func() {
+		commitments, partials := signWith(shares[1:4], message)
+		partials[1].Z = edwards25519.NewScalar().Add(partials[1].Z, scalarFromInt(1))
+		_, err := Combine(shares[0].Group, message, commitments, partials...)
+		Expect(errors.Is(err, keysplitting.ErrInvalidPartialSignature)).To(BeTrue())
+		var misbehaving *keysplitting.MisbehavingSignersError
+		Expect(errors.As(err, &misbehaving)).To(BeTrue())
+		Expect(misbehaving.Signers).To(Equal([]int{3}))
+	})
+
+	It("Fails if the signers sign different messages", func() {
+		signers := shares[:3]
+		nonces := make([]*SigningNonces, 3)
+		commitments := make([]*NonceCommitment, 3)
+		for i, share := range signers {
+			var err error
+			nonces[i], commitments[i], err = Commit(rand.Reader, share)
+			Expect(err).To(BeNil())
+		}
+		partials := make([]*PartialSignature, 3)
+		for i, share := range signers {
+			msg := message
+			if i == 2 {
+				msg = []byte("OTHER MESSAGE")
+			}
+			var err error
+			partials[i], err = Sign(share, nonces[i], msg, commitments)
+			Expect(err).To(BeNil())
+		}
+		_, err := Combine(shares[0].Group, message, commitments, partials...)
+		Expect(errors.Is(err, keysplitting.ErrInvalidPartialSignature)).To(BeTrue())
+	})
+
+	It("Rejects invalid thresholds", func() {
+		_, err := Split(rand.Reader, priv, 1, 3)
+		Expect(errors.Is(err, keysplitting.ErrTooFewShards)).To(BeTrue())
+		_, err = Split(rand.Reader, priv, 4, 3)
+		Expect(errors.Is(err, keysplitting.ErrTooFewShards)).To(BeTrue())
+	})
+})
@@ -0,0 +1,42 @@
+package keysplitting
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+)
+
+// HealthCheckDigest returns the SHA-256 digest that shard holders sign for a health check of pub with the given challenge.
+// It is derived from a fixed prefix, the public key, and the challenge, so that it can't coincide with the digest of a
+// production message
+func HealthCheckDigest(pub *rsa.PublicKey, challenge []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte("keysplitting health check"))
+	h.Write(pub.N.Bytes())
+	h.Write(challenge)
+	return h.Sum(nil)
+}
+
+// HealthCheck has every shard of pub sign a fresh health-check challenge in turn, as with [SignSequential], and verifies the
+// result, so that a scheduled probe can confirm that the shards still recombine to the private key without signing any
+// production message. It works for keys split by either algorithm, and the shards are asked to sign [HealthCheckDigest] with
+// SHA-256. A nil error means that the shards can still sign; a signature that doesn't verify is reported as
+// [ErrIncompleteSignature], and any other failure as it occurred, e.g. a shard that can't be reached
+func HealthCheck(ctx context.Context, pub *rsa.PublicKey, shards ...RemoteShard) error {
+	challenge, err := NewNonce(rand.Reader)
+	if err != nil {
+		return err
+	}
+	hashed := HealthCheckDigest(pub, challenge)
+
+	sig, err := SignSequential(ctx, pub, crypto.SHA256, hashed, shards...)
+	if err != nil {
+		return err
+	}
+	if err := rsa.VerifyPKCS1v15(pub, crypto.SHA256, hashed, sig); err != nil {
+		return ErrIncompleteSignature
+	}
+	return nil
+}
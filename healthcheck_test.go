@@ -0,0 +1,61 @@
+package keysplitting
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Health checks", func() {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ctx := context.Background()
+
+	remotes := func(shards []*PrivateKeyShard) []RemoteShard {
+		remotes := make([]RemoteShard, len(shards))
+		for i, shard := range shards {
+			var err error
+			remotes[i], err = NewLocalShard(shard)
+			Expect(err).To(BeNil())
+		}
+		return remotes
+	}
+
+	It("Passes while the shards still recombine to the key", func() {
+		for _, splitBy := range []SplitBy{Addition, Multiplication} {
+			shards, err := SplitD(key, 3, splitBy)
+			Expect(err).To(BeNil())
+			Expect(HealthCheck(ctx, &key.PublicKey, remotes(shards)...)).To(Succeed())
+		}
+	})
+
+	It("Fails once a shard is missing or no longer matches", func() {
+		shards, err := SplitD(key, 3, Addition)
+		Expect(err).To(BeNil())
+		Expect(errors.Is(HealthCheck(ctx, &key.PublicKey, remotes(shards[:2])...), ErrIncompleteSignature)).To(BeTrue())
+
+		others, err := SplitD(key, 3, Addition)
+		Expect(err).To(BeNil())
+		mixed := remotes([]*PrivateKeyShard{shards[0], others[1], shards[2]})
+		Expect(errors.Is(HealthCheck(ctx, &key.PublicKey, mixed...), ErrIncompleteSignature)).To(BeTrue())
+	})
+
+	It("Reports a shard that can't be reached", func() {
+		shards, err := SplitD(key, 2, Addition)
+		Expect(err).To(BeNil())
+		err = HealthCheck(ctx, &key.PublicKey, append(remotes(shards), failingRemoteShard{})...)
+		Expect(err).To(MatchError("holder is unavailable"))
+	})
+
+	It("Signs a digest that depends on the key and the challenge", func() {
+		other, err := rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).To(BeNil())
+		challenge := []byte("challenge")
+		Expect(HealthCheckDigest(&key.PublicKey, challenge)).To(Equal(HealthCheckDigest(&key.PublicKey, challenge)))
+		Expect(HealthCheckDigest(&key.PublicKey, challenge)).NotTo(Equal(HealthCheckDigest(&other.PublicKey, challenge)))
+		Expect(HealthCheckDigest(&key.PublicKey, challenge)).NotTo(Equal(HealthCheckDigest(&key.PublicKey, []byte("other"))))
+	})
+})
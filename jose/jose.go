@@ -0,0 +1,207 @@
+/*
+Package jose produces JSON Web Signatures (RFC 7515) with a split key, in the compact or the flattened JSON serialization.
+It takes care of the JWS Signing Input and the base64url encoding, so that the parties only ever exchange partial signatures.
+
+A coordinator creates a [Message] for a payload and sends it to every shard holder, who signs it with [Message.SignFirst] or
+[Message.SignNext], sequentially or, for additive shards, all at once. The coordinator then turns the partial signatures into
+a JWS:
+
+	msg, err := jose.NewMessage(rand.Reader, pub, "PS256", nil, payload)
+	partialSig, err := msg.SignFirst(rand.Reader, shard1)
+	partialSig, err = msg.SignNext(rand.Reader, shard2, partialSig)
+	jws, err := msg.Compact(pub, partialSig)
+
+The RS256, RS384, RS512, PS256, PS384 and PS512 algorithms are supported. A Message marshals to JSON, so it can be sent to the
+shard holders as it is
+*/
+package jose
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/bastionzero/keysplitting"
+)
+
+// the algorithms a split key can sign with, by "alg" header parameter
+var methods = map[string]*keysplitting.JWTSigningMethod{}
+
+func init() {
+	for _, method := range []*keysplitting.JWTSigningMethod{
+		keysplitting.SigningMethodRS256,
+		keysplitting.SigningMethodRS384,
+		keysplitting.SigningMethodRS512,
+		keysplitting.SigningMethodPS256,
+		keysplitting.SigningMethodPS384,
+		keysplitting.SigningMethodPS512,
+	} {
+		methods[method.Alg()] = method
+	}
+}
+
+// A Message is a JWS payload and protected header that are being signed with a split key
+type Message struct {
+	Protected string `json:"protected"`          // BASE64URL(UTF8(JWS Protected Header))
+	Payload   string `json:"payload"`            // BASE64URL(JWS Payload)
+	PSSSalt   []byte `json:"pss_salt,omitempty"` // the salt shared by every party for the PS* algorithms
+}
+
+// NewMessage returns a Message that signs payload with the given algorithm, e.g. "RS256" or "PS256", and the parameters in
+// header, to which it adds "alg". For the PS* algorithms it also generates the shared salt, as long as the hash, from random
+func NewMessage(random io.Reader, pub *rsa.PublicKey, alg string, header map[string]interface{}, payload []byte) (*Message, error) {
+	method, ok := methods[alg]
+	if !ok {
+		return nil, fmt.Errorf("%w: unsupported JWS algorithm %q", keysplitting.ErrUnsupportedHash, alg)
+	}
+
+	protected := map[string]interface{}{}
+	for name, value := range header {
+		protected[name] = value
+	}
+	protected["alg"] = alg
+	encoded, err := json.Marshal(protected)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := &Message{
+		Protected: base64.RawURLEncoding.EncodeToString(encoded),
+		Payload:   base64.RawURLEncoding.EncodeToString(payload),
+	}
+	if method.PSS {
+		opts := &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: method.Hash}
+		if msg.PSSSalt, err = keysplitting.NewPSSSalt(random, pub, method.Hash, opts); err != nil {
+			return nil, err
+		}
+	}
+	return msg, nil
+}
+
+// Header returns the decoded protected header
+func (m *Message) Header() (map[string]interface{}, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(m.Protected)
+	if err != nil {
+		return nil, fmt.Errorf("malformed JWS protected header: %w", err)
+	}
+	var header map[string]interface{}
+	if err := json.Unmarshal(decoded, &header); err != nil {
+		return nil, fmt.Errorf("malformed JWS protected header: %w", err)
+	}
+	return header, nil
+}
+
+// SigningInput returns the JWS Signing Input, ASCII(BASE64URL(UTF8(JWS Protected Header)) || '.' || BASE64URL(JWS Payload))
+func (m *Message) SigningInput() string {
+	return m.Protected + "." + m.Payload
+}
+
+// SignFirst has shard perform the initial signature on the message, as with [keysplitting.SignFirst]
+func (m *Message) SignFirst(random io.Reader, shard keysplitting.ShardSigner) (*keysplitting.PartialSignature, error) {
+	opts, hashed, err := m.digest()
+	if err != nil {
+		return nil, err
+	}
+	return shard.SignFirst(random, opts, hashed)
+}
+
+// SignNext has shard add its signature to partialSig, as with [keysplitting.SignNext]
+func (m *Message) SignNext(random io.Reader, shard keysplitting.ShardSigner, partialSig *keysplitting.PartialSignature) (*keysplitting.PartialSignature, error) {
+	opts, hashed, err := m.digest()
+	if err != nil {
+		return nil, err
+	}
+	return shard.SignNext(random, opts, hashed, partialSig)
+}
+
+// Signature returns the complete signature on the message, either from the single partial signature at the end of a
+// sequential chain, or combined from the partial signatures of all the additive shards with
+// [keysplitting.CombinePartialSignatures]. It fails with [keysplitting.ErrIncompleteSignature] if the signature doesn't verify
+func (m *Message) Signature(pub *rsa.PublicKey, partials ...*keysplitting.PartialSignature) ([]byte, error) {
+	if len(partials) == 0 {
+		return nil, fmt.Errorf("%w: no partial signatures provided", keysplitting.ErrTooFewShards)
+	}
+	method, err := m.method()
+	if err != nil {
+		return nil, err
+	}
+
+	sig := partials[0].Sig
+	if len(partials) > 1 {
+		if sig, err = keysplitting.CombinePartialSignatures(pub, partials...); err != nil {
+			return nil, err
+		}
+	}
+	if err := method.Verify(m.SigningInput(), sig, pub); err != nil {
+		return nil, err
+	}
+	return sig, nil
+}
+
+// Compact returns the JWS Compact Serialization of the message with the signature from [Message.Signature]
+func (m *Message) Compact(pub *rsa.PublicKey, partials ...*keysplitting.PartialSignature) (string, error) {
+	sig, err := m.Signature(pub, partials...)
+	if err != nil {
+		return "", err
+	}
+	return m.SigningInput() + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// used exclusively as a placeholder for encoding-decoding
+type flattenedJWS struct {
+	Protected string `json:"protected"`
+	Payload   string `json:"payload"`
+	Signature string `json:"signature"`
+}
+
+// JSON returns the flattened JWS JSON Serialization of the message with the signature from [Message.Signature]
+func (m *Message) JSON(pub *rsa.PublicKey, partials ...*keysplitting.PartialSignature) ([]byte, error) {
+	sig, err := m.Signature(pub, partials...)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(flattenedJWS{
+		Protected: m.Protected,
+		Payload:   m.Payload,
+		Signature: base64.RawURLEncoding.EncodeToString(sig),
+	})
+}
+
+// returns the signing method named by the "alg" header parameter
+func (m *Message) method() (*keysplitting.JWTSigningMethod, error) {
+	header, err := m.Header()
+	if err != nil {
+		return nil, err
+	}
+	alg, _ := header["alg"].(string)
+	method, ok := methods[alg]
+	if !ok {
+		return nil, fmt.Errorf("%w: unsupported JWS algorithm %q", keysplitting.ErrUnsupportedHash, alg)
+	}
+	return method, nil
+}
+
+// returns the signer options and digest that the shards sign
+func (m *Message) digest() (crypto.SignerOpts, []byte, error) {
+	method, err := m.method()
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := base64.RawURLEncoding.DecodeString(m.Payload); err != nil {
+		return nil, nil, fmt.Errorf("malformed JWS payload: %w", err)
+	}
+
+	var opts crypto.SignerOpts = method.Hash
+	if method.PSS {
+		if len(m.PSSSalt) != method.Hash.Size() {
+			return nil, nil, fmt.Errorf("%w: %s requires a shared salt as long as the hash", keysplitting.ErrUnsupportedHash, method.Alg())
+		}
+		opts = &keysplitting.PSSOptions{Hash: method.Hash, Salt: m.PSSSalt}
+	}
+	h := method.Hash.New()
+	h.Write([]byte(m.SigningInput()))
+	return opts, h.Sum(nil), nil
+}
@@ -0,0 +1,110 @@
+package jose
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/bastionzero/keysplitting"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestJOSE(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "JOSE Suite")
+}
+
+var _ = Describe("JSON Web Signatures", func() {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	payload := []byte(`{"iss":"split","sub":"TEST"}`)
+
+	// verifies a compact JWS with the ordinary PKCS #1 v1.5 or PSS verification
+	verify := func(jws string, pss bool) {
+		parts := strings.Split(jws, ".")
+		Expect(parts).To(HaveLen(3))
+		decoded, err := base64.RawURLEncoding.DecodeString(parts[1])
+		Expect(err).To(BeNil())
+		Expect(decoded).To(Equal(payload))
+
+		sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+		Expect(err).To(BeNil())
+		hashed := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		if pss {
+			Expect(rsa.VerifyPSS(&key.PublicKey, crypto.SHA256, hashed[:], sig, &rsa.PSSOptions{SaltLength: crypto.SHA256.Size()})).To(Succeed())
+		} else {
+			Expect(rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, hashed[:], sig)).To(Succeed())
+		}
+	}
+
+	It("Produces compact RS256 and PS256 signatures sequentially", func() {
+		for _, splitBy := range []keysplitting.SplitBy{keysplitting.Addition, keysplitting.Multiplication} {
+			shards, err := keysplitting.SplitD(key, 3, splitBy)
+			Expect(err).To(BeNil())
+
+			for _, alg := range []string{"RS256", "PS256"} {
+				msg, err := NewMessage(rand.Reader, &key.PublicKey, alg, map[string]interface{}{"typ": "JWT"}, payload)
+				Expect(err).To(BeNil())
+				header, err := msg.Header()
+				Expect(err).To(BeNil())
+				Expect(header).To(Equal(map[string]interface{}{"alg": alg, "typ": "JWT"}))
+
+				partialSig, err := msg.SignFirst(rand.Reader, shards[0])
+				Expect(err).To(BeNil())
+				for _, shard := range shards[1:] {
+					partialSig, err = msg.SignNext(rand.Reader, shard, partialSig)
+					Expect(err).To(BeNil())
+				}
+				jws, err := msg.Compact(&key.PublicKey, partialSig)
+				Expect(err).To(BeNil())
+				verify(jws, alg == "PS256")
+			}
+		}
+	})
+
+	It("Produces flattened JSON signatures from brokered partial signatures", func() {
+		shards, err := keysplitting.SplitD(key, 3, keysplitting.Addition)
+		Expect(err).To(BeNil())
+		msg, err := NewMessage(rand.Reader, &key.PublicKey, "PS256", nil, payload)
+		Expect(err).To(BeNil())
+
+		// the message travels to each shard holder as JSON
+		encoded, err := json.Marshal(msg)
+		Expect(err).To(BeNil())
+		partials := make([]*keysplitting.PartialSignature, len(shards))
+		for i, shard := range shards {
+			var received Message
+			Expect(json.Unmarshal(encoded, &received)).To(Succeed())
+			partials[i], err = received.SignFirst(rand.Reader, shard)
+			Expect(err).To(BeNil())
+		}
+
+		jws, err := msg.JSON(&key.PublicKey, partials...)
+		Expect(err).To(BeNil())
+		var flattened map[string]string
+		Expect(json.Unmarshal(jws, &flattened)).To(Succeed())
+		verify(flattened["protected"]+"."+flattened["payload"]+"."+flattened["signature"], true)
+	})
+
+	It("Refuses incomplete signatures and unsupported algorithms", func() {
+		shards, err := keysplitting.SplitD(key, 2, keysplitting.Addition)
+		Expect(err).To(BeNil())
+		msg, err := NewMessage(rand.Reader, &key.PublicKey, "RS256", nil, payload)
+		Expect(err).To(BeNil())
+		partialSig, err := msg.SignFirst(rand.Reader, shards[0])
+		Expect(err).To(BeNil())
+		_, err = msg.Compact(&key.PublicKey, partialSig)
+		Expect(errors.Is(err, keysplitting.ErrIncompleteSignature)).To(BeTrue())
+
+		_, err = NewMessage(rand.Reader, &key.PublicKey, "ES256", nil, payload)
+		Expect(errors.Is(err, keysplitting.ErrUnsupportedHash)).To(BeTrue())
+		_, err = NewMessage(rand.Reader, &key.PublicKey, "none", nil, payload)
+		Expect(errors.Is(err, keysplitting.ErrUnsupportedHash)).To(BeTrue())
+	})
+})
@@ -0,0 +1,165 @@
+package keysplitting
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+)
+
+// used exclusively as a placeholder for encoding-decoding
+type jwk struct {
+	Kty        string  `json:"kty"`
+	Kid        string  `json:"kid,omitempty"`
+	N          string  `json:"n"`
+	E          string  `json:"e"`
+	SplitD     string  `json:"split_d"`
+	SplitBy    SplitBy `json:"split_by"`
+	SplitIndex int     `json:"split_index,omitempty"`
+	SplitMask  string  `json:"split_mask,omitempty"`
+}
+
+// MarshalJWK returns a JSON Web Key (RFC 7517) representation of the shard, so that it can be kept in the same key stores
+// as ordinary RSA JWKs. It is an "RSA" key with the usual public parameters "n" and "e", and a "kid" from [PrivateKeyShard.KeyID].
+// Instead of the private exponent "d", it has the shard's "split_d" and "split_by", and the "split_index" and "split_mask" if
+// they are set. Since "d" is absent, tools that don't understand shards will see only the public key.
+//
+// Like all big integers in a JWK, "split_d" and "split_mask" are encoded in base64url without padding, except that they are
+// in two's complement form so that negative additive shards can be represented. Use [UnmarshalJWK] to decode it
+func (pks *PrivateKeyShard) MarshalJWK() ([]byte, error) {
+	if err := pks.checkUsable(); err != nil {
+		return nil, err
+	}
+	if err := checkSplitBy(pks.SplitBy); err != nil {
+		return nil, err
+	}
+
+	key := jwk{
+		Kty:        "RSA",
+		Kid:        pks.KeyID(),
+		N:          encodeBase64URLInt(pks.PublicKey.N),
+		E:          encodeBase64URLInt(big.NewInt(int64(pks.PublicKey.E))),
+		SplitD:     encodeBase64URLSignedInt(pks.D),
+		SplitBy:    pks.SplitBy,
+		SplitIndex: pks.Index,
+	}
+	if pks.Mask != nil {
+		key.SplitMask = encodeBase64URLSignedInt(pks.Mask)
+	}
+	return json.Marshal(key)
+}
+
+// UnmarshalJWK decodes a shard encoded by [PrivateKeyShard.MarshalJWK], rejecting any that fails [PrivateKeyShard.Validate].
+// The "kid" is not checked, since key stores may assign their own
+func UnmarshalJWK(data []byte) (shard *PrivateKeyShard, err error) {
+	defer func() { auditDecode(shard, err) }()
+
+	var key jwk
+	if err := json.Unmarshal(data, &key); err != nil {
+		return nil, errorf(ErrInvalidShard, "failed to unmarshal JWK: %s", err)
+	}
+	if key.Kty != "RSA" {
+		return nil, errorf(ErrInvalidShard, "JWK has key type %q rather than \"RSA\"", key.Kty)
+	}
+
+	n, err := decodeBase64URLInt(key.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := decodeBase64URLInt(key.E)
+	if err != nil {
+		return nil, err
+	}
+	if !e.IsInt64() || e.Int64() > 1<<31-1 {
+		return nil, errorf(ErrInvalidShard, "JWK public exponent is out of range")
+	}
+	d, err := decodeBase64URLSignedInt(key.SplitD)
+	if err != nil {
+		return nil, err
+	}
+
+	shard = &PrivateKeyShard{
+		PublicKey: &rsa.PublicKey{N: n, E: int(e.Int64())},
+		D:         d,
+		SplitBy:   key.SplitBy,
+		Index:     key.SplitIndex,
+	}
+	if key.SplitMask != "" {
+		if shard.Mask, err = decodeBase64URLSignedInt(key.SplitMask); err != nil {
+			return nil, err
+		}
+	}
+	if err := shard.Validate(); err != nil {
+		return nil, err
+	}
+	return shard, nil
+}
+
+// MarshalJSON implements [json.Marshaler] using the same format as [PrivateKeyShard.MarshalJWK], so that shards can be
+// embedded directly in JSON documents. Note that this makes it easy to leak a shard by logging a struct that contains one
+func (pks *PrivateKeyShard) MarshalJSON() ([]byte, error) {
+	return pks.MarshalJWK()
+}
+
+// UnmarshalJSON implements [json.Unmarshaler] in the same way as [UnmarshalJWK]
+func (pks *PrivateKeyShard) UnmarshalJSON(data []byte) error {
+	shard, err := UnmarshalJWK(data)
+	if err != nil {
+		return err
+	}
+	*pks = *shard
+	return nil
+}
+
+// encodes n as unpadded base64url. Like an ASN.1 INTEGER, n is in minimal big-endian two's complement form, so that
+// negative additive shards can be represented
+func encodeBase64URLSignedInt(n *big.Int) string {
+	var b []byte
+	if n.Sign() < 0 {
+		// -n - 1 has the same magnitude as the two's complement of n, with each bit flipped
+		b = new(big.Int).Not(n).Bytes()
+		for i := range b {
+			b[i] ^= 0xff
+		}
+		if len(b) == 0 || b[0]&0x80 == 0 {
+			b = append([]byte{0xff}, b...)
+		}
+	} else {
+		b = n.Bytes()
+		if len(b) == 0 || b[0]&0x80 != 0 {
+			b = append([]byte{0}, b...)
+		}
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// decodes an integer encoded by encodeBase64URLSignedInt
+func decodeBase64URLSignedInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, errorf(ErrInvalidShard, "invalid base64url-encoded integer")
+	}
+
+	if b[0]&0x80 == 0 {
+		return new(big.Int).SetBytes(b), nil
+	}
+	flipped := make([]byte, len(b))
+	for i := range b {
+		flipped[i] = b[i] ^ 0xff
+	}
+	return new(big.Int).Not(new(big.Int).SetBytes(flipped)), nil
+}
+
+// encodes a non-negative n as unpadded base64url, as for the public parameters of an RSA JWK
+func encodeBase64URLInt(n *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(n.Bytes())
+}
+
+// decodes an integer encoded by encodeBase64URLInt
+func decodeBase64URLInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, errorf(ErrInvalidShard, "invalid base64url-encoded integer")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,106 @@
+package keysplitting
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha512"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("JWK encoding", func() {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	shards, _ := SplitD(key, 2, Addition)
+
+	It("Round-trips", func() {
+		encoded, err := shards[0].MarshalJWK()
+		Expect(err).To(BeNil())
+
+		decoded, err := UnmarshalJWK(encoded)
+		Expect(err).To(BeNil())
+		Expect(decoded.Equal(shards[0])).To(BeTrue())
+	})
+
+	It("Looks like an RSA public JWK to other tools", func() {
+		encoded, err := shards[0].MarshalJWK()
+		Expect(err).To(BeNil())
+
+		var fields map[string]interface{}
+		Expect(json.Unmarshal(encoded, &fields)).To(Succeed())
+		Expect(fields).To(HaveKeyWithValue("kty", "RSA"))
+		Expect(fields).To(HaveKeyWithValue("e", "AQAB"))
+		Expect(fields).To(HaveKeyWithValue("kid", shards[0].KeyID()))
+		Expect(fields).To(HaveKeyWithValue("split_by", "Addition"))
+		Expect(fields).To(HaveKeyWithValue("split_index", BeNumerically("==", 1)))
+		Expect(fields).NotTo(HaveKey("d"))
+	})
+
+	It("Preserves negative shards and masks", func() {
+		masked, err := SplitDWithOptions(key, 2, Addition, &SplitOptions{Mask: true})
+		Expect(err).To(BeNil())
+		subShards, err := SplitShard(masked[0], 3, 3)
+		Expect(err).To(BeNil())
+
+		decoded := make([]*PrivateKeyShard, len(subShards))
+		for i, subShard := range subShards {
+			encoded, err := subShard.MarshalJWK()
+			Expect(err).To(BeNil())
+			decoded[i], err = UnmarshalJWK(encoded)
+			Expect(err).To(BeNil())
+			Expect(decoded[i].Equal(subShard)).To(BeTrue())
+		}
+
+		hashed := sha512.Sum512([]byte("TEST MESSAGE"))
+		sig := signBrokered(&key.PublicKey, append(decoded, masked[1]), hashed[:])
+		Expect(rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA512, hashed[:], sig)).To(Succeed())
+	})
+
+	It("Embeds shards in other JSON documents", func() {
+		type config struct {
+			Name  string           `json:"name"`
+			Shard *PrivateKeyShard `json:"shard"`
+		}
+
+		encoded, err := json.Marshal(config{Name: "signer", Shard: shards[1]})
+		Expect(err).To(BeNil())
+
+		var decoded config
+		Expect(json.Unmarshal(encoded, &decoded)).To(Succeed())
+		Expect(decoded.Name).To(Equal("signer"))
+		Expect(decoded.Shard.Equal(shards[1])).To(BeTrue())
+	})
+
+	It("Encodes signed integers like ASN.1", func() {
+		for _, v := range []int64{0, 1, 127, 128, 255, 256, 0xf8ffff, -1, -128, -129, -256, -0xf8ffff} {
+			n := big.NewInt(v)
+			der, err := asn1.Marshal(n)
+			Expect(err).To(BeNil())
+
+			encoded := encodeBase64URLSignedInt(n)
+			Expect(encoded).To(Equal(base64.RawURLEncoding.EncodeToString(der[2:])), "%d", v)
+
+			decoded, err := decodeBase64URLSignedInt(encoded)
+			Expect(err).To(BeNil())
+			Expect(decoded.Int64()).To(Equal(v))
+		}
+	})
+
+	It("Rejects other key types and malformed values", func() {
+		for _, encoded := range []string{
+			`{"kty":"EC","n":"AQAB","e":"AQAB","split_d":"AQAB","split_by":"Addition"}`,
+			`{"kty":"RSA","n":"!!!","e":"AQAB","split_d":"AQAB","split_by":"Addition"}`,
+			`{"kty":"RSA","n":"AQAB","e":"AQAB","split_d":"AQAB","split_by":"Exponentiation"}`,
+			`not json`,
+		} {
+			_, err := UnmarshalJWK([]byte(encoded))
+			Expect(errors.Is(err, ErrInvalidShard)).To(BeTrue(), encoded)
+		}
+	})
+})
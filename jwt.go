@@ -0,0 +1,83 @@
+package keysplitting
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+)
+
+// A JWTSigningMethod mints RS* and PS* JSON Web Tokens (RFC 7519) with a split key. It has the method set of the SigningMethod
+// interface of github.com/golang-jwt/jwt/v5, so it can be passed to jwt.NewWithClaims without this package depending on
+// golang-jwt, and the key passed to SignedString is a [crypto.Signer] for the split key, such as a [RemoteSigner], which hides
+// the round trip to the shard holders:
+//
+//	token := jwt.NewWithClaims(keysplitting.SigningMethodRS256, claims)
+//	signed, err := token.SignedString(remoteSigner)
+//
+// The tokens are ordinary RS* and PS* tokens, so they are verified with the public key in the usual way
+type JWTSigningMethod struct {
+	Name string      // the "alg" header parameter, e.g. "RS256"
+	Hash crypto.Hash // the hash function the algorithm signs with
+	PSS  bool        // whether the algorithm is RSASSA-PSS rather than RSASSA-PKCS1-v1_5
+}
+
+// The JWS algorithms of RFC 7518 that a split key can sign with
+var (
+	SigningMethodRS256 = &JWTSigningMethod{Name: "RS256", Hash: crypto.SHA256}
+	SigningMethodRS384 = &JWTSigningMethod{Name: "RS384", Hash: crypto.SHA384}
+	SigningMethodRS512 = &JWTSigningMethod{Name: "RS512", Hash: crypto.SHA512}
+	SigningMethodPS256 = &JWTSigningMethod{Name: "PS256", Hash: crypto.SHA256, PSS: true}
+	SigningMethodPS384 = &JWTSigningMethod{Name: "PS384", Hash: crypto.SHA384, PSS: true}
+	SigningMethodPS512 = &JWTSigningMethod{Name: "PS512", Hash: crypto.SHA512, PSS: true}
+)
+
+// Alg returns the "alg" header parameter of the method
+func (m *JWTSigningMethod) Alg() string {
+	return m.Name
+}
+
+// Sign returns the signature on signingString, the encoded header and claims, made by key, which must be a [crypto.Signer]
+// with an RSA public key. PSS signatures use a salt as long as the hash, as RFC 7518 requires
+func (m *JWTSigningMethod) Sign(signingString string, key interface{}) ([]byte, error) {
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, errorf(ErrKeyMismatch, "JWT signing key must be a crypto.Signer for the split key")
+	}
+	if _, ok := signer.Public().(*rsa.PublicKey); !ok {
+		return nil, errorf(ErrKeyMismatch, "JWT signing key must have an RSA public key")
+	}
+	hashed, err := hashMessage(m.Hash, []byte(signingString))
+	if err != nil {
+		return nil, err
+	}
+	return signer.Sign(rand.Reader, hashed, m.signerOpts())
+}
+
+// Verify checks sig, the signature on signingString, against key, which must be an *[rsa.PublicKey]
+func (m *JWTSigningMethod) Verify(signingString string, sig []byte, key interface{}) error {
+	pub, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return errorf(ErrKeyMismatch, "JWT verification key must be an *rsa.PublicKey")
+	}
+	hashed, err := hashMessage(m.Hash, []byte(signingString))
+	if err != nil {
+		return err
+	}
+	if m.PSS {
+		err = rsa.VerifyPSS(pub, m.Hash, hashed, sig, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthAuto, Hash: m.Hash})
+	} else {
+		err = rsa.VerifyPKCS1v15(pub, m.Hash, hashed, sig)
+	}
+	if err != nil {
+		return ErrIncompleteSignature
+	}
+	return nil
+}
+
+// returns the options to sign with, as crypto/rsa would take them
+func (m *JWTSigningMethod) signerOpts() crypto.SignerOpts {
+	if m.PSS {
+		return &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: m.Hash}
+	}
+	return m.Hash
+}
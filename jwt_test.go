@@ -0,0 +1,58 @@
+package keysplitting
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// the SigningMethod interface of github.com/golang-jwt/jwt/v5
+type golangJWTSigningMethod interface {
+	Verify(signingString string, sig []byte, key interface{}) error
+	Sign(signingString string, key interface{}) ([]byte, error)
+	Alg() string
+}
+
+var _ golangJWTSigningMethod = SigningMethodRS256
+
+var _ = Describe("JWT signing methods", func() {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	shards, _ := SplitD(key, 2, Addition)
+	remotes := make([]RemoteShard, len(shards))
+	for i, shard := range shards {
+		remotes[i], _ = NewLocalShard(shard)
+	}
+	signer, _ := NewRemoteSigner(&key.PublicKey, remotes...)
+
+	signingString := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`)) + "." +
+		base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"1234567890","name":"Test"}`))
+
+	It("Signs tokens that verify against the public key", func() {
+		for _, method := range []*JWTSigningMethod{SigningMethodRS256, SigningMethodRS384, SigningMethodRS512, SigningMethodPS256, SigningMethodPS384, SigningMethodPS512} {
+			sig, err := method.Sign(signingString, signer)
+			Expect(err).To(BeNil())
+			Expect(method.Verify(signingString, sig, &key.PublicKey)).To(Succeed())
+
+			err = method.Verify(signingString+"x", sig, &key.PublicKey)
+			Expect(errors.Is(err, ErrIncompleteSignature)).To(BeTrue())
+		}
+
+		sig, err := SigningMethodPS256.Sign(signingString, signer)
+		Expect(err).To(BeNil())
+		hashed := sha256.Sum256([]byte(signingString))
+		Expect(rsa.VerifyPSS(&key.PublicKey, crypto.SHA256, hashed[:], sig, &rsa.PSSOptions{SaltLength: crypto.SHA256.Size()})).To(Succeed())
+	})
+
+	It("Refuses keys that aren't split RSA signers or public keys", func() {
+		_, err := SigningMethodRS256.Sign(signingString, key.PublicKey)
+		Expect(errors.Is(err, ErrKeyMismatch)).To(BeTrue())
+		err = SigningMethodRS256.Verify(signingString, nil, signer)
+		Expect(errors.Is(err, ErrKeyMismatch)).To(BeTrue())
+	})
+})
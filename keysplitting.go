@@ -1,12 +1,14 @@
 package keysplitting
 
 import (
+	"context"
 	"crypto"
 	"crypto/rand"
 	"crypto/rsa"
-	"fmt"
 	"io"
 	"math/big"
+	"strings"
+	"time"
 )
 
 var (
@@ -14,8 +16,6 @@ var (
 	bigOne  = big.NewInt(1)
 )
 
-// TODO: considering modifying the additive split to d + (r * phi)
-
 // SplitBy determines the algorithm used to split the private key and combine partial signatures.
 // Either algorithm is suitable from a performance and security standpoint
 type SplitBy string
@@ -25,35 +25,241 @@ const (
 	Addition       SplitBy = "Addition"
 )
 
+// ParseSplitBy returns the split algorithm with the given name, ignoring case, e.g. "addition" or "Multiplication"
+func ParseSplitBy(name string) (SplitBy, error) {
+	for _, splitBy := range []SplitBy{Multiplication, Addition} {
+		if strings.EqualFold(name, string(splitBy)) {
+			return splitBy, nil
+		}
+	}
+	return "", errorf(ErrUnsupportedSplitBy, "unrecognized split algorithm: %q", name)
+}
+
+// String returns the name of the split algorithm
+func (splitBy SplitBy) String() string {
+	return string(splitBy)
+}
+
+// MarshalText implements [encoding.TextMarshaler]. It fails for unrecognized split algorithms
+func (splitBy SplitBy) MarshalText() ([]byte, error) {
+	if err := checkSplitBy(splitBy); err != nil {
+		return nil, err
+	}
+	return []byte(splitBy), nil
+}
+
+// UnmarshalText implements [encoding.TextUnmarshaler], accepting the same names as [ParseSplitBy]
+func (splitBy *SplitBy) UnmarshalText(text []byte) error {
+	parsed, err := ParseSplitBy(string(text))
+	if err != nil {
+		return err
+	}
+	*splitBy = parsed
+	return nil
+}
+
+// returns an error if splitBy is not one of the recognized split algorithms
+func checkSplitBy(splitBy SplitBy) error {
+	switch splitBy {
+	case Multiplication, Addition:
+		return nil
+	default:
+		return errorf(ErrUnsupportedSplitBy, "unrecognized split algorithm: %v", splitBy)
+	}
+}
+
+// SplitOptions configures how a private key is split by [SplitDWithOptions].
+// A nil *SplitOptions is equivalent to the zero value, which behaves exactly like [SplitD]
+type SplitOptions struct {
+	// PhiMultiple selects the "d + r * phi" variant of the additive split. Rather than picking a final shard that balances
+	// the sum to exactly D (mod phi), restarting the search whenever that shard turns out to be degenerate, the final shard is
+	// chosen such that all shards sum to D + r * phi over the integers, for some r >= 1. The resulting shards are combined in
+	// exactly the same way as ordinary additive shards. It is ignored by [SplitBy].Multiplication
+	PhiMultiple bool
+
+	// Lambda splits D modulo the Carmichael function lambda(N) = lcm(p1 - 1, ..., pn - 1) instead of Euler's totient phi(N).
+	// This is what crypto/rsa itself uses to compute D, and since lambda(N) is a proper divisor of phi(N), the shards are
+	// smaller. The shards are combined in exactly the same way, but are congruent to D (mod lambda(N)) rather than (mod phi(N))
+	Lambda bool
+
+	// MaxAttempts caps the number of random candidates drawn while searching for suitable shards, counting restarts,
+	// after which the split fails with [ErrTooManyAttempts]. The search normally takes a handful of attempts per shard,
+	// so hitting the cap points to a bad key or a broken source of randomness. Zero means [DefaultMaxAttempts]
+	MaxAttempts int
+
+	// Mask gives each additive shard a random masking exponent w_i (see [PrivateKeyShard].Mask), chosen such that
+	// w_1 + w_2 + ... + w_k ≡ 0 (mod phi(N)). Each shard then signs with d_i + r * w_i instead of d_i, where r changes from
+	// one signature to the next, so that repeated signing doesn't expose a stable exponent to side-channel analysis. Since
+	// r is derived from the digest being signed (and the salt, for PSS), every shard uses the same r and the masks cancel out.
+	// Masked shards cannot be committed to (see [ShardCommitments]). It is ignored by [SplitBy].Multiplication
+	Mask bool
+
+	// EqualLength reduces every multiplicative shard (mod phi), so that each is uniformly distributed in [1, phi) and about as
+	// long as N. Otherwise, as in earlier versions, each shard is longer than the last and signing with the later ones is
+	// slower. Either kind of shard is combined in exactly the same way. It is ignored by [SplitBy].Addition
+	EqualLength bool
+
+	// Rand is the source of randomness for the shards and masks, such as an HSM's DRBG, or a deterministic one for tests
+	// and reproducible key ceremonies. Nil means crypto/rand.Reader
+	Rand io.Reader
+}
+
+// DefaultMaxAttempts is the number of attempts a split makes before giving up, unless [SplitOptions].MaxAttempts says otherwise
+const DefaultMaxAttempts = 10000
+
 // SplitD returns k private key shards that together compose priv.D
 //
 // If [SplitBy].Multiplication is used, the shards will be such that s1 * s2 * ... * sk ≡ D (mod phi(N))
 //
 // If [SplitBy].Addition is used, the shards will be such that s1 + s2 + ... + sk ≡ D (mod phi(N))
 func SplitD(priv *rsa.PrivateKey, k int, splitBy SplitBy) ([]*PrivateKeyShard, error) {
-	if k < 2 {
-		return nil, fmt.Errorf("cannot split key into fewer than 2 shards")
-	}
+	return SplitDWithOptions(priv, k, splitBy, nil)
+}
 
+// SplitDWithOptions is like [SplitD] but allows the caller to configure the split with opts
+func SplitDWithOptions(priv *rsa.PrivateKey, k int, splitBy SplitBy, opts *SplitOptions) ([]*PrivateKeyShard, error) {
+	return SplitDContext(context.Background(), priv, k, splitBy, opts)
+}
+
+// SplitDContext is like [SplitDWithOptions], but gives up with ctx.Err() if ctx is done before the split is complete.
+// Splitting searches for suitable random shards, which usually takes a handful of attempts but has no fixed upper bound
+func SplitDContext(ctx context.Context, priv *rsa.PrivateKey, k int, splitBy SplitBy, opts *SplitOptions) ([]*PrivateKeyShard, error) {
 	// because rsa.GenerateMultiPrimeKey supports an arbitrary number of primes, so do we.
 	// priv.Primes are the factors of the modulus N
+	if opts != nil && opts.Lambda {
+		// D may have been computed modulo phi(N), so reduce it to keep the shards small
+		lambda := carmichaelLambda(priv.Primes)
+		reduced := &rsa.PrivateKey{
+			PublicKey: priv.PublicKey,
+			D:         new(big.Int).Mod(priv.D, lambda),
+		}
+		shards, err := splitD(ctx, reduced, lambda, k, splitBy, opts)
+		for _, shard := range shards {
+			shard.PublicKey = &priv.PublicKey
+		}
+		return shards, err
+	}
+
 	phi := eulerTotient(priv.Primes)
 
+	return splitD(ctx, priv, phi, k, splitBy, opts)
+}
+
+// SplitDFromPhi is like [SplitD] for keys whose prime factors are unavailable, such as keys exported from some HSMs or older formats.
+// Instead of the prime factors, the caller provides phi, which may be either Euler's totient phi(N) or the Carmichael function lambda(N).
+// The shards are then congruent to d modulo whichever was given
+func SplitDFromPhi(d, phi *big.Int, pub *rsa.PublicKey, k int, splitBy SplitBy) ([]*PrivateKeyShard, error) {
+	if phi.Cmp(bigOne) <= 0 || phi.Cmp(pub.N) >= 0 {
+		return nil, errorf(ErrKeyMismatch, "phi is out of range for the given public key")
+	}
+
+	// d is only guaranteed to be the inverse of E modulo lambda(N), which divides phi(N), so rather than checking
+	// d * E ≡ 1 (mod phi), check that d undoes E on a random value. This catches mismatched or corrupted inputs
+	x, err := rand.Int(rand.Reader, pub.N)
+	if err != nil {
+		return nil, &RandomnessError{Err: err}
+	}
+	y := new(big.Int).Exp(x, big.NewInt(int64(pub.E)), pub.N)
+	if y.Exp(y, d, pub.N).Cmp(x) != 0 {
+		return nil, errorf(ErrKeyMismatch, "d is not the private exponent for the given public key")
+	}
+	// likewise, x^phi ≡ 1 (mod N) if and only if phi is a multiple of lambda(N), barring a vanishingly unlikely choice of x
+	if y.Exp(x, phi, pub.N).Cmp(bigOne) != 0 {
+		return nil, errorf(ErrKeyMismatch, "phi is not a multiple of lambda(N) for the given public key")
+	}
+
+	priv := &rsa.PrivateKey{
+		PublicKey: *pub,
+		D:         new(big.Int).Set(d),
+	}
+	return splitD(context.Background(), priv, phi, k, splitBy, nil)
+}
+
+// splits priv.D into k shards modulo phi
+func splitD(ctx context.Context, priv *rsa.PrivateKey, phi *big.Int, k int, splitBy SplitBy, opts *SplitOptions) (shards []*PrivateKeyShard, err error) {
+	ctx, span := StartSpan(ctx, "keysplitting.SplitD", append(keyAttributes(&priv.PublicKey, splitBy, 0), TraceAttribute{Key: TraceShardCount, Value: k})...)
+	defer func() {
+		span.End(err)
+		audit(AuditSplit, &priv.PublicKey, shardIndices(shards), splitBy, 0, nil, err)
+	}()
+
+	if opts == nil {
+		opts = &SplitOptions{}
+	}
+
+	if k < 2 {
+		return nil, errorf(ErrTooFewShards, "cannot split key into fewer than 2 shards")
+	}
+
+	search := newShardSearch(ctx, opts)
+
 	switch splitBy {
 	case Multiplication:
-		return splitMultiplicative(priv, k, phi)
+		shards, err = splitMultiplicative(search, priv, k, phi, opts.EqualLength)
 	case Addition:
-		return splitAdditive(priv, k, phi)
+		if opts.PhiMultiple {
+			shards, err = splitAdditivePhiMultiple(search, priv, k, phi)
+		} else {
+			shards, err = splitAdditive(search, priv, k, phi)
+		}
 	default:
-		return nil, fmt.Errorf("unrecognized splitBy argument: %v", splitBy)
+		return nil, errorf(ErrUnsupportedSplitBy, "unrecognized splitBy argument: %v", splitBy)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Mask && splitBy == Addition {
+		masks, err := newMasks(search.random, k, phi)
+		if err != nil {
+			return nil, err
+		}
+		for i, shard := range shards {
+			shard.Mask = masks[i]
+		}
+	}
+
+	// number the shards so partial signatures can record who has signed
+	for i, shard := range shards {
+		shard.Index = i + 1
+	}
+	return shards, nil
+}
+
+// GenerateSplitKey generates a new RSA keypair of the given bit size and splits its private exponent into k shards, drawing
+// both from random.
+// The whole private key only exists within this function and is zeroized before it returns, so the caller never holds it.
+// Note that zeroization is best-effort, since the Go runtime may have copied the key material elsewhere in memory
+func GenerateSplitKey(random io.Reader, bits, k int, splitBy SplitBy) (*rsa.PublicKey, []*PrivateKeyShard, error) {
+	if k < 2 {
+		return nil, nil, errorf(ErrTooFewShards, "cannot split key into fewer than 2 shards")
+	}
+
+	priv, err := rsa.GenerateKey(random, bits)
+	if err != nil {
+		return nil, nil, err
 	}
+	defer zeroizePrivateKey(priv)
+
+	shards, err := SplitDWithOptions(priv, k, splitBy, &SplitOptions{Rand: random})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// copy the public key so that the shards don't keep the private key alive
+	pub := &rsa.PublicKey{N: new(big.Int).Set(priv.N), E: priv.E}
+	for _, shard := range shards {
+		shard.PublicKey = pub
+	}
+
+	return pub, shards, nil
 }
 
 // finds shards for priv.D by finding random pairs of factors whose cumulative product is congruent to priv.D (mod phi)
 //
-// note: each shard is longer than the last, at a linear rate of growth.
+// note: unless reduce is set, each shard is longer than the last, at a linear rate of growth.
 // If the first shard is length 1, the second shard is length 2, the third length 3, and so on
-func splitMultiplicative(priv *rsa.PrivateKey, k int, phi *big.Int) ([]*PrivateKeyShard, error) {
+func splitMultiplicative(search *shardSearch, priv *rsa.PrivateKey, k int, phi *big.Int, reduce bool) ([]*PrivateKeyShard, error) {
 
 	shards := make([]*PrivateKeyShard, 0)
 	seed := priv.D
@@ -63,7 +269,7 @@ func splitMultiplicative(priv *rsa.PrivateKey, k int, phi *big.Int) ([]*PrivateK
 	// For a *purely visual* but not mathematically correct analogy, think of it this way: https://i.stack.imgur.com/k4h0y.png,
 	// where in the 3-shard case, we would use one 1/2 block and two 1/4 blocks
 	for len(shards) < k {
-		shardA, shardB, err := splitSeed(seed, phi)
+		shardA, shardB, err := splitSeed(search, seed, phi, reduce)
 		if err != nil {
 			return nil, err
 		}
@@ -90,11 +296,11 @@ func splitMultiplicative(priv *rsa.PrivateKey, k int, phi *big.Int) ([]*PrivateK
 	return shards, nil
 }
 
-// generate two shards of seed such that shardA * shardB ≡ seed (mod phi)
-func splitSeed(seed *big.Int, phi *big.Int) (shardA *big.Int, shardB *big.Int, err error) {
+// generate two shards of seed such that shardA * shardB ≡ seed (mod phi). If reduce is set, shardB is also less than phi
+func splitSeed(search *shardSearch, seed *big.Int, phi *big.Int, reduce bool) (shardA *big.Int, shardB *big.Int, err error) {
 	success := false
 	for !success {
-		shardA, err = validRandomNumber(phi, seed)
+		shardA, err = validRandomNumber(search, phi, seed)
 		if err != nil {
 			return
 		}
@@ -110,6 +316,12 @@ func splitSeed(seed *big.Int, phi *big.Int) (shardA *big.Int, shardB *big.Int, e
 
 		// shardB <- seed/shardA mod phi
 		shardB = new(big.Int).Mul(seed, shardAInverse)
+		if reduce {
+			// shardB is then as uniformly distributed as shardA, but must still be a usable shard
+			if shardB.Mod(shardB, phi).Cmp(bigOne) <= 0 {
+				continue
+			}
+		}
 		success = true
 	}
 
@@ -117,10 +329,14 @@ func splitSeed(seed *big.Int, phi *big.Int) (shardA *big.Int, shardB *big.Int, e
 }
 
 // finds shards for priv.D by picking k random numbers whose sum is congruent to D (mod phi)
-func splitAdditive(priv *rsa.PrivateKey, k int, phi *big.Int) ([]*PrivateKeyShard, error) {
+func splitAdditive(search *shardSearch, priv *rsa.PrivateKey, k int, phi *big.Int) ([]*PrivateKeyShard, error) {
 	// we use this outer loop as a restart mechanism in case of an undesirable combination of shards
 ShardSearchLoop:
 	for {
+		if err := search.next(); err != nil {
+			return nil, err
+		}
+
 		shards := make([]*PrivateKeyShard, k)
 		var err error
 
@@ -158,7 +374,7 @@ ShardSearchLoop:
 
 			// if this is a shard other than the last one, just pick a new random number
 			for !foundNewShard {
-				newShard.D, err = validRandomNumber(phi, priv.D)
+				newShard.D, err = validRandomNumber(search, phi, priv.D)
 				if err != nil {
 					return nil, err
 				}
@@ -176,14 +392,84 @@ ShardSearchLoop:
 	}
 }
 
+// finds shards for priv.D by picking k-1 random numbers and a final shard such that the sum of all shards is D + r * phi
+//
+// each random shard is less than phi, so their sum is less than (k-1) * phi. Choosing r = k guarantees that the final shard
+// is positive and greater than phi, which means it can never be zero, equal to D, or equal to any of the other shards.
+// No restarts are needed
+func splitAdditivePhiMultiple(search *shardSearch, priv *rsa.PrivateKey, k int, phi *big.Int) ([]*PrivateKeyShard, error) {
+	shards := make([]*PrivateKeyShard, k)
+
+	for i := 0; i < k-1; i++ {
+		newShard := &PrivateKeyShard{PublicKey: &priv.PublicKey, SplitBy: Addition}
+		for {
+			d, err := validRandomNumber(search, phi, priv.D)
+			if err != nil {
+				return nil, err
+			}
+
+			newShard.D = d
+			if !shardIn(shards, newShard) {
+				break
+			}
+		}
+		shards[i] = newShard
+	}
+
+	// final shard <- D + k * phi - [sum of shards]
+	lastD := new(big.Int).Mul(big.NewInt(int64(k)), phi)
+	lastD.Add(lastD, priv.D)
+	lastD.Sub(lastD, shardSum(shards))
+	shards[k-1] = &PrivateKeyShard{PublicKey: &priv.PublicKey, D: lastD, SplitBy: Addition}
+
+	return shards, nil
+}
+
+// tracks the progress of a search for shards, so that it gives up when the context is done or it has taken too long
+type shardSearch struct {
+	ctx         context.Context
+	random      io.Reader
+	attempts    int
+	maxAttempts int
+}
+
+func newShardSearch(ctx context.Context, opts *SplitOptions) *shardSearch {
+	maxAttempts := DefaultMaxAttempts
+	if opts != nil && opts.MaxAttempts > 0 {
+		maxAttempts = opts.MaxAttempts
+	}
+	random := io.Reader(rand.Reader)
+	if opts != nil && opts.Rand != nil {
+		random = opts.Rand
+	}
+	return &shardSearch{ctx: ctx, random: random, maxAttempts: maxAttempts}
+}
+
+// records another attempt, returning an error if the search should give up instead
+func (search *shardSearch) next() error {
+	if err := search.ctx.Err(); err != nil {
+		return err
+	}
+	search.attempts++
+	if search.attempts > search.maxAttempts {
+		return errorf(ErrTooManyAttempts, "failed to find suitable shards within %d attempts", search.maxAttempts)
+	}
+	return nil
+}
+
 // returns a random number between 1 and phi that is
 //   - coprime to phi
 //   - not equal to seed
-func validRandomNumber(phi *big.Int, seed *big.Int) (r *big.Int, err error) {
+func validRandomNumber(search *shardSearch, phi *big.Int, seed *big.Int) (r *big.Int, err error) {
 	for {
+		if err = search.next(); err != nil {
+			return nil, err
+		}
+
 		// from section 2 of [1], pick a random integer between 1 and phi (exclusive)
-		r, err = rand.Int(rand.Reader, phi)
+		r, err = rand.Int(search.random, phi)
 		if err != nil {
+			err = &RandomnessError{Err: err}
 			return
 		}
 
@@ -223,11 +509,73 @@ func shardIn(shards []*PrivateKeyShard, shard *PrivateKeyShard) bool {
 }
 
 // SignFirst uses the given key shard to perform the initial signature on a hashed message.
-// Note that hashed must be the result of hashing the input message using the given hash function
-func SignFirst(random io.Reader, shard *PrivateKeyShard, hashFn crypto.Hash, hashed []byte) ([]byte, error) {
+// Note that hashed must be the result of hashing the input message using the hash function given by opts.HashFunc().
+//
+// opts is usually just a [crypto.Hash], which produces a PKCS #1 v1.5 signature. A *[PSSOptions] produces an RSASSA-PSS
+// signature instead, as with [SignFirstPSS]. A plain *[rsa.PSSOptions] is refused, since it cannot carry the shared salt.
+// Either can be wrapped in a *[NonceOptions] to bind the signature to a signing session.
+//
+// The split algorithm is read from the shard, which records it at [SplitD] time, so the parties never need to agree on it
+func SignFirst(random io.Reader, shard *PrivateKeyShard, opts crypto.SignerOpts, hashed []byte) (*PartialSignature, error) {
+	return signFirstContext(context.Background(), random, shard, opts, hashed)
+}
+
+// signs as SignFirst does, tracing the signature as part of ctx
+func signFirstContext(ctx context.Context, random io.Reader, shard *PrivateKeyShard, opts crypto.SignerOpts, hashed []byte) (*PartialSignature, error) {
+	start := time.Now()
+	_, span := StartSpan(ctx, "keysplitting.SignFirst", shardAttributes(shard, opts)...)
+	sig, err := signFirstWithOpts(random, shard, opts, hashed)
+	span.End(err)
+	observePartialSignature(start, err)
+	auditPartialSign(shard, opts, hashed, err)
+	if err != nil {
+		return nil, err
+	}
+	partialSig := newPartialSignature(shard, opts.HashFunc(), sig)
+	partialSig.Nonce = nonceOf(opts)
+	return partialSig, nil
+}
+
+// returns the shard's own signature on hashed using the scheme selected by opts
+func signFirstWithOpts(random io.Reader, shard *PrivateKeyShard, opts crypto.SignerOpts, hashed []byte) ([]byte, error) {
+	switch o := opts.(type) {
+	case nil:
+		return nil, errorf(ErrUnsupportedHash, "no signer options provided")
+	case *PSSOptions:
+		return signFirstPSS(random, shard, o.Hash, hashed, o.Salt)
+	case *NonceOptions:
+		return signFirstWithNonce(shard, o, hashed)
+	case *rsa.PSSOptions:
+		return nil, errorf(ErrUnsupportedHash, "split PSS signatures require a shared salt, which *rsa.PSSOptions cannot carry; use *keysplitting.PSSOptions instead")
+	default:
+		return signFirst(random, shard, opts.HashFunc(), hashed)
+	}
+}
+
+// returns the shard's own PKCS #1 v1.5 signature on hashed
+func signFirst(random io.Reader, shard *PrivateKeyShard, hashFn crypto.Hash, hashed []byte) ([]byte, error) {
+	// the split algorithm doesn't affect the first signature, but a shard without a valid one can't be combined
+	if err := checkSplitBy(shard.SplitBy); err != nil {
+		return nil, err
+	}
+	if err := shard.checkUsable(); err != nil {
+		return nil, err
+	}
+	if hashFn == 0 {
+		if err := checkRawLength(shard.PublicKey, hashed); err != nil {
+			return nil, err
+		}
+	}
+
+	// This is a full-width exponentiation mod N. The CRT speedup isn't available to shards: reducing d_i mod p-1 and q-1
+	// only helps if the signer can also reduce mod p and q, and a shard holder who knows p or q can factor N and recover the
+	// whole key. Any shard material that would allow it would defeat the split, whoever dealt it.
+	// Nor is there per-shard setup worth caching between calls: the Montgomery multiplications themselves account for nearly
+	// all of the time. The reduction constants for N cost about one division, which BenchmarkModulusSetup puts at well under
+	// 0.1% of BenchmarkSignFirst for a 2048-bit key, and the window table depends on the base, which is different for every message
 	priv := &rsa.PrivateKey{
 		PublicKey: *shard.PublicKey,
-		D:         shard.D,
+		D:         shard.exponent(hashed),
 	}
 	// TODO: revisit name
 	return signPKCS1v15(random, priv, hashFn, hashed)
@@ -239,20 +587,117 @@ func SignFirst(random io.Reader, shard *PrivateKeyShard, hashFn crypto.Hash, has
 //
 // If the original key was split additively, nextSig(H) <- partialSig(H) * H^shard (mod N), i.e. a chain of multiplication
 //
-// Note that hashed must be the result of hashing the input message using the given hash function.
-func SignNext(random io.Reader, shard *PrivateKeyShard, hashFn crypto.Hash, hashed []byte, partialSig []byte) ([]byte, error) {
-	partialInt := new(big.Int).SetBytes(partialSig)
+// Note that hashed must be the result of hashing the input message using the hash function given by opts.HashFunc(),
+// and that opts selects the signature scheme in the same way as for [SignFirst].
+// SignNext refuses to sign if partialSig was produced with a different hash function or split algorithm, or if the shard has already signed it
+func SignNext(random io.Reader, shard *PrivateKeyShard, opts crypto.SignerOpts, hashed []byte, partialSig *PartialSignature) (*PartialSignature, error) {
+	return signNextContext(context.Background(), random, shard, opts, hashed, partialSig)
+}
+
+// signs as SignNext does, tracing the signature as part of ctx
+func signNextContext(ctx context.Context, random io.Reader, shard *PrivateKeyShard, opts crypto.SignerOpts, hashed []byte, partialSig *PartialSignature) (*PartialSignature, error) {
+	if opts == nil {
+		return nil, errorf(ErrUnsupportedHash, "no signer options provided")
+	}
+	start := time.Now()
+	_, span := StartSpan(ctx, "keysplitting.SignNext", shardAttributes(shard, opts)...)
+	next, err := signNext(shard, opts.HashFunc(), nonceOf(opts), partialSig, func() ([]byte, error) {
+		return signFirstWithOpts(random, shard, opts, hashed)
+	})
+	span.End(err)
+	observePartialSignature(start, err)
+	auditPartialSign(shard, opts, hashed, err)
+	return next, err
+}
+
+// SignFirstContext is like [SignFirst], but returns ctx.Err() instead of signing if ctx is already done.
+// Signing is a single exponentiation, which cannot be interrupted once it has started
+func SignFirstContext(ctx context.Context, random io.Reader, shard *PrivateKeyShard, opts crypto.SignerOpts, hashed []byte) (*PartialSignature, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return signFirstContext(ctx, random, shard, opts, hashed)
+}
+
+// SignNextContext is like [SignNext], but returns ctx.Err() instead of signing if ctx is already done (see [SignFirstContext])
+func SignNextContext(ctx context.Context, random io.Reader, shard *PrivateKeyShard, opts crypto.SignerOpts, hashed []byte, partialSig *PartialSignature) (*PartialSignature, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return signNextContext(ctx, random, shard, opts, hashed, partialSig)
+}
+
+// SignMessageFirst is like [SignFirst], but takes the raw message and hashes it with opts.HashFunc() internally
+func SignMessageFirst(random io.Reader, shard *PrivateKeyShard, opts crypto.SignerOpts, message []byte) (*PartialSignature, error) {
+	if opts == nil {
+		return nil, errorf(ErrUnsupportedHash, "no signer options provided")
+	}
+	hashed, err := hashMessage(opts.HashFunc(), message)
+	if err != nil {
+		return nil, err
+	}
+	return SignFirst(random, shard, opts, hashed)
+}
+
+// SignMessageNext is like [SignNext], but takes the raw message and hashes it with opts.HashFunc() internally.
+// Since partialSig records the hash function used by the previous signers, opts.HashFunc() must match it
+func SignMessageNext(random io.Reader, shard *PrivateKeyShard, opts crypto.SignerOpts, message []byte, partialSig *PartialSignature) (*PartialSignature, error) {
+	if opts == nil {
+		return nil, errorf(ErrUnsupportedHash, "no signer options provided")
+	}
+	hashed, err := hashMessage(opts.HashFunc(), message)
+	if err != nil {
+		return nil, err
+	}
+	return SignNext(random, shard, opts, hashed, partialSig)
+}
+
+// checks that hashFn is an actual hash function that is linked into the binary
+func checkHashAvailable(hashFn crypto.Hash) error {
+	if hashFn == 0 || !hashFn.Available() {
+		return errorf(ErrUnsupportedHash, "hash function %v is unavailable", hashFn)
+	}
+	return nil
+}
+
+// returns the digest of message using hashFn
+func hashMessage(hashFn crypto.Hash, message []byte) ([]byte, error) {
+	if err := checkHashAvailable(hashFn); err != nil {
+		return nil, err
+	}
+
+	h := hashFn.New()
+	h.Write(message)
+	hashed := h.Sum(nil)
+
+	if len(hashed) != hashFn.Size() {
+		return nil, errorf(ErrUnsupportedHash, "hash function %v produced a digest of the wrong length", hashFn)
+	}
+	return hashed, nil
+}
+
+// adds the shard's signature to partialSig according to the shard's split algorithm. signFirst is only
+// called for additive shards, and must produce the shard's own partial signature on the encoded message
+func signNext(shard *PrivateKeyShard, hashFn crypto.Hash, nonce []byte, partialSig *PartialSignature, signFirst func() ([]byte, error)) (*PartialSignature, error) {
+	if err := partialSig.checkNext(shard, hashFn, nonce); err != nil {
+		return nil, err
+	}
+	if err := shard.checkUsable(); err != nil {
+		return nil, err
+	}
+
+	partialInt := new(big.Int).SetBytes(partialSig.Sig)
 
 	switch shard.SplitBy {
 	case Multiplication:
 		nextSig := new(big.Int).Exp(partialInt, shard.D, shard.PublicKey.N)
 		if nextSig == nil {
-			return nil, fmt.Errorf("failed to add next signature with the given shard, public key, and partial signature")
+			return nil, errorf(ErrInvalidPartialSignature, "failed to add next signature with the given shard, public key, and partial signature")
 		}
 
-		return nextSig.Bytes(), nil
+		return partialSig.extend(shard, nextSig.FillBytes(make([]byte, shard.PublicKey.Size()))), nil
 	case Addition:
-		nextBaseSig, err := SignFirst(random, shard, hashFn, hashed)
+		nextBaseSig, err := signFirst()
 		if err != nil {
 			return nil, err
 		}
@@ -260,8 +705,70 @@ func SignNext(random io.Reader, shard *PrivateKeyShard, hashFn crypto.Hash, hash
 		nextBaseInt := new(big.Int).SetBytes(nextBaseSig)
 		nextSig := new(big.Int).Mul(nextBaseInt, partialInt)
 		nextSig.Mod(nextSig, shard.PublicKey.N)
-		return nextSig.Bytes(), nil
+		return partialSig.extend(shard, nextSig.FillBytes(make([]byte, shard.PublicKey.Size()))), nil
 	default:
-		return nil, fmt.Errorf("unrecognized split algorithm: %v", shard.SplitBy)
+		return nil, errorf(ErrUnsupportedSplitBy, "unrecognized split algorithm: %v", shard.SplitBy)
+	}
+}
+
+// CombinePartialSignatures rolls up partial signatures produced independently by the holders of additive shards into
+// the complete signature, i.e. sig(H) <- partialSig1(H) * partialSig2(H) * ... * partialSigk(H) (mod N)
+//
+// This allows a broker to combine the partial signatures without holding a key shard itself. It works the same way for
+// PKCS #1 v1.5 and PSS partial signatures. Note that this only works for keys split with [SplitBy].Addition,
+// since multiplicative partial signatures must be chained with [SignNext]. Partial signatures bound to a session nonce
+// (see [NonceOptions]) must all be bound to the same one, which is removed from the complete signature
+func CombinePartialSignatures(pub *rsa.PublicKey, partials ...*PartialSignature) (sig []byte, err error) {
+	start := time.Now()
+	_, span := StartSpan(context.Background(), "keysplitting.CombinePartialSignatures",
+		append(keyAttributes(pub, Addition, 0), TraceAttribute{Key: TraceShardCount, Value: len(partials)})...)
+	defer func() {
+		span.End(err)
+		observeCombine(start, err)
+		auditCombine(pub, partials, err)
+	}()
+
+	if len(partials) < 2 {
+		return nil, errorf(ErrTooFewShards, "cannot combine fewer than 2 partial signatures")
+	}
+	for i, partial := range partials {
+		if partial == nil {
+			return nil, errorf(ErrInvalidPartialSignature, "partial signature #%d is nil", i)
+		}
+	}
+
+	sigInt := big.NewInt(1)
+	combined := &PartialSignature{}
+	for i, partial := range partials {
+		if partial.SplitBy != Addition {
+			return nil, errorf(ErrUnsupportedSplitBy, "only partial signatures from additive shards can be combined")
+		}
+		if partial.Hash != partials[0].Hash {
+			return nil, errorf(ErrInvalidPartialSignature, "partial signature #%d was produced with a different hash function", i)
+		}
+		if err := partial.checkNonce(partials[0].Nonce); err != nil {
+			return nil, err
+		}
+		for _, signer := range partial.Signers {
+			if combined.signedBy(signer) {
+				return nil, errorf(ErrInvalidPartialSignature, "shard %d contributed to more than one partial signature", signer)
+			}
+			combined.Signers = append(combined.Signers, signer)
+		}
+
+		partialInt := new(big.Int).SetBytes(partial.Sig)
+		if partialInt.Sign() == 0 || partialInt.Cmp(pub.N) >= 0 {
+			return nil, errorf(ErrInvalidPartialSignature, "partial signature #%d is out of range for the given public key", i)
+		}
+
+		sigInt.Mul(sigInt, partialInt)
+		sigInt.Mod(sigInt, pub.N)
+	}
+
+	// a signature must be exactly as long as the modulus, so keep any leading zeros
+	sigBytes := sigInt.FillBytes(make([]byte, pub.Size()))
+	if len(partials[0].Nonce) > 0 {
+		return unbindNonce(pub, partials[0].Nonce, sigBytes)
 	}
+	return sigBytes, nil
 }
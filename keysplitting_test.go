@@ -1,10 +1,13 @@
 package keysplitting
 
 import (
+	"context"
 	"crypto"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/sha512"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"math/big"
 	mrand "math/rand"
@@ -17,6 +20,28 @@ import (
 
 const maxTestShards = 16
 
+// an io.Reader that deterministically expands seed with SHA-512 in counter mode
+type deterministicReader struct {
+	seed    string
+	counter uint64
+	buf     []byte
+}
+
+func newDeterministicReader(seed string) *deterministicReader {
+	return &deterministicReader{seed: seed}
+}
+
+func (r *deterministicReader) Read(p []byte) (int, error) {
+	for len(r.buf) < len(p) {
+		block := sha512.Sum512([]byte(fmt.Sprintf("%s/%d", r.seed, r.counter)))
+		r.counter++
+		r.buf = append(r.buf, block[:]...)
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
 // randomize order of shards
 func shuffleShards(shards []*PrivateKeyShard) {
 	mrand.Seed(int64(time.Now().UnixMicro()))
@@ -35,7 +60,7 @@ func shardProduct(shards []*PrivateKeyShard) *big.Int {
 }
 
 // run a full workflow of splitting a key and using the shards to sign a message
-func runTest(priv *rsa.PrivateKey, i int, hashed []byte, splitBy SplitBy) {
+func runTest(priv *rsa.PrivateKey, i int, hashed []byte, splitBy SplitBy, opts *SplitOptions) {
 	var shards []*PrivateKeyShard
 	var err error
 
@@ -48,12 +73,20 @@ func runTest(priv *rsa.PrivateKey, i int, hashed []byte, splitBy SplitBy) {
 	}
 
 	It("Successfully splits the key", func() {
-		shards, err = SplitD(priv, i, splitBy)
+		shards, err = SplitDWithOptions(priv, i, splitBy, opts)
 		Expect(err).To(BeNil(), fmt.Sprintf("failed to split RSA key into %d shards: %s", i, err))
 	})
 
-	It(fmt.Sprintf("Produces keys whose %s is congruent to the original key mod phi(N)", label), func() {
+	modulus := "phi(N)"
+	if opts != nil && opts.Lambda {
+		modulus = "lambda(N)"
+	}
+
+	It(fmt.Sprintf("Produces keys whose %s is congruent to the original key mod %s", label, modulus), func() {
 		phi := eulerTotient(priv.Primes)
+		if opts != nil && opts.Lambda {
+			phi = carmichaelLambda(priv.Primes)
+		}
 
 		switch splitBy {
 		case Multiplication:
@@ -77,16 +110,34 @@ func runTest(priv *rsa.PrivateKey, i int, hashed []byte, splitBy SplitBy) {
 		sigNext := sig1
 		for k := 1; k < len(shards); k++ {
 			// no partial signatures should verify
-			Expect(rsa.VerifyPKCS1v15(&priv.PublicKey, crypto.SHA512, hashed, sigNext)).NotTo(Succeed(), "partial signature must not verify")
+			Expect(rsa.VerifyPKCS1v15(&priv.PublicKey, crypto.SHA512, hashed, sigNext.Sig)).NotTo(Succeed(), "partial signature must not verify")
 
 			sigNext, err = SignNext(rand.Reader, shards[k], crypto.SHA512, hashed, sigNext)
 			Expect(err).To(BeNil(), fmt.Sprintf("failed to generate signature #%d: %s", k, err))
 		}
 
 		// verify once all parties have signed
-		err = rsa.VerifyPKCS1v15(&priv.PublicKey, crypto.SHA512, hashed, sigNext)
+		Expect(sigNext.Signers).To(HaveLen(len(shards)))
+		err = rsa.VerifyPKCS1v15(&priv.PublicKey, crypto.SHA512, hashed, sigNext.Sig)
 		Expect(err).To(BeNil(), fmt.Sprintf("failed to verify signature: %s", err))
 	})
+
+	if splitBy == Addition {
+		It("Produces a valid brokered signature", func() {
+			// every party signs independently and a broker combines the results
+			partials := make([]*PartialSignature, len(shards))
+			for k, shard := range shards {
+				partials[k], err = SignFirst(rand.Reader, shard, crypto.SHA512, hashed)
+				Expect(err).To(BeNil(), fmt.Sprintf("failed to generate partial signature #%d: %s", k, err))
+			}
+
+			sig, err := CombinePartialSignatures(&priv.PublicKey, partials...)
+			Expect(err).To(BeNil(), fmt.Sprintf("failed to combine partial signatures: %s", err))
+
+			err = rsa.VerifyPKCS1v15(&priv.PublicKey, crypto.SHA512, hashed, sig)
+			Expect(err).To(BeNil(), fmt.Sprintf("failed to verify signature: %s", err))
+		})
+	}
 }
 
 func TestKeysplitting(t *testing.T) {
@@ -94,6 +145,45 @@ func TestKeysplitting(t *testing.T) {
 	RunSpecs(t, "Keysplitting Suite")
 }
 
+var _ = Describe("SplitBy", func() {
+	It("Parses names regardless of case", func() {
+		for _, name := range []string{"addition", "Addition", "ADDITION"} {
+			splitBy, err := ParseSplitBy(name)
+			Expect(err).To(BeNil())
+			Expect(splitBy).To(Equal(Addition))
+		}
+		splitBy, err := ParseSplitBy("multiplication")
+		Expect(err).To(BeNil())
+		Expect(splitBy).To(Equal(Multiplication))
+	})
+
+	It("Rejects unrecognized names", func() {
+		_, err := ParseSplitBy("exponentiation")
+		Expect(errors.Is(err, ErrUnsupportedSplitBy)).To(BeTrue())
+		_, err = ParseSplitBy("")
+		Expect(errors.Is(err, ErrUnsupportedSplitBy)).To(BeTrue())
+	})
+
+	It("Round-trips through JSON", func() {
+		type config struct {
+			SplitBy SplitBy `json:"splitBy"`
+		}
+
+		encoded, err := json.Marshal(config{SplitBy: Multiplication})
+		Expect(err).To(BeNil())
+		Expect(string(encoded)).To(Equal(`{"splitBy":"Multiplication"}`))
+
+		var decoded config
+		Expect(json.Unmarshal([]byte(`{"splitBy":"addition"}`), &decoded)).To(Succeed())
+		Expect(decoded.SplitBy).To(Equal(Addition))
+		Expect(decoded.SplitBy.String()).To(Equal("Addition"))
+
+		Expect(json.Unmarshal([]byte(`{"splitBy":"division"}`), &decoded)).NotTo(Succeed())
+		_, err = json.Marshal(config{SplitBy: "Division"})
+		Expect(err).NotTo(BeNil())
+	})
+})
+
 var _ = Describe("Keysplitting", func() {
 
 	keyLength := 2048
@@ -112,6 +202,234 @@ var _ = Describe("Keysplitting", func() {
 				Expect(err).NotTo(BeNil(), "Shouldn't be able to split a key into 1 shard")
 			})
 		})
+
+		When("Attempting to sign with a shard that has no split algorithm", func() {
+			It("Should fail", func() {
+				shards, err := SplitD(priv, 2, Multiplication)
+				Expect(err).To(BeNil())
+
+				shards[0].SplitBy = ""
+				_, err = SignFirst(rand.Reader, shards[0], crypto.SHA512, hashed)
+				Expect(err).NotTo(BeNil(), "Shouldn't be able to sign with an unrecognized split algorithm")
+
+				shards[1].SplitBy = "Exponentiation"
+				_, err = SignNext(rand.Reader, shards[1], crypto.SHA512, hashed, &PartialSignature{Hash: crypto.SHA512, SplitBy: Multiplication, Sig: []byte{1}})
+				Expect(err).NotTo(BeNil(), "Shouldn't be able to sign with an unrecognized split algorithm")
+			})
+		})
+
+		When("Signing a partial signature that doesn't match the shard", Ordered, func() {
+			var additive, multiplicative []*PrivateKeyShard
+			var partial *PartialSignature
+
+			BeforeAll(func() {
+				additive, _ = SplitD(priv, 3, Addition)
+				multiplicative, _ = SplitD(priv, 3, Multiplication)
+				partial, _ = SignFirst(rand.Reader, additive[0], crypto.SHA512, hashed)
+			})
+
+			It("Records the signer, hash function, and split algorithm", func() {
+				Expect(partial.Signers).To(Equal([]int{1}))
+				Expect(partial.Hash).To(Equal(crypto.SHA512))
+				Expect(partial.SplitBy).To(Equal(Addition))
+			})
+
+			It("Refuses a shard that has already signed", func() {
+				_, err := SignNext(rand.Reader, additive[0], crypto.SHA512, hashed, partial)
+				Expect(err).NotTo(BeNil())
+			})
+
+			It("Refuses a different hash function", func() {
+				_, err := SignNext(rand.Reader, additive[1], crypto.SHA256, hashed[:32], partial)
+				Expect(err).NotTo(BeNil())
+			})
+
+			It("Refuses a different split algorithm", func() {
+				_, err := SignNext(rand.Reader, multiplicative[1], crypto.SHA512, hashed, partial)
+				Expect(err).NotTo(BeNil())
+			})
+
+			It("Refuses to combine the same shard's signature twice", func() {
+				_, err := CombinePartialSignatures(&priv.PublicKey, partial, partial)
+				Expect(err).NotTo(BeNil())
+			})
+		})
+
+		When("Signing a message rather than a digest", func() {
+			for _, splitBy := range []SplitBy{Multiplication, Addition} {
+				splitBy := splitBy
+
+				It(fmt.Sprintf("Produces a valid %s split signature", splitBy), func() {
+					shards, _ := SplitD(priv, 3, splitBy)
+
+					sig, err := SignMessageFirst(rand.Reader, shards[0], crypto.SHA512, []byte(message))
+					Expect(err).To(BeNil())
+					for _, shard := range shards[1:] {
+						sig, err = SignMessageNext(rand.Reader, shard, crypto.SHA512, []byte(message), sig)
+						Expect(err).To(BeNil())
+					}
+					Expect(rsa.VerifyPKCS1v15(&priv.PublicKey, crypto.SHA512, hashed, sig.Sig)).To(Succeed())
+				})
+			}
+
+			It("Refuses a hash function that doesn't match the partial signature", func() {
+				shards, _ := SplitD(priv, 2, Addition)
+
+				sig, err := SignMessageFirst(rand.Reader, shards[0], crypto.SHA512, []byte(message))
+				Expect(err).To(BeNil())
+				_, err = SignMessageNext(rand.Reader, shards[1], crypto.SHA256, []byte(message), sig)
+				Expect(err).NotTo(BeNil())
+			})
+
+			It("Refuses an unavailable hash function", func() {
+				shards, _ := SplitD(priv, 2, Addition)
+
+				_, err := SignMessageFirst(rand.Reader, shards[0], crypto.Hash(0), []byte(message))
+				Expect(err).NotTo(BeNil())
+				_, err = SignMessageFirst(rand.Reader, shards[0], crypto.MD4, []byte(message))
+				Expect(err).NotTo(BeNil())
+			})
+		})
+
+		When("A signature has leading zero bytes", func() {
+			It("Keeps them when signing multiplicatively", func() {
+				shards, _ := SplitD(priv, 2, Multiplication)
+
+				// 1^shard = 1, which is as short as a partial signature can be
+				sig, err := SignNext(rand.Reader, shards[1], crypto.SHA512, hashed, &PartialSignature{Signers: []int{1}, Hash: crypto.SHA512, SplitBy: Multiplication, Sig: []byte{1}})
+				Expect(err).To(BeNil())
+				Expect(sig.Sig).To(HaveLen(priv.Size()))
+			})
+
+			It("Keeps them when combining", func() {
+				sig, err := CombinePartialSignatures(&priv.PublicKey,
+					&PartialSignature{Signers: []int{1}, Hash: crypto.SHA512, SplitBy: Addition, Sig: []byte{2}},
+					&PartialSignature{Signers: []int{2}, Hash: crypto.SHA512, SplitBy: Addition, Sig: []byte{3}})
+				Expect(err).To(BeNil())
+				Expect(sig).To(HaveLen(priv.Size()))
+				Expect(new(big.Int).SetBytes(sig).Int64()).To(Equal(int64(6)))
+			})
+		})
+
+		When("The search for shards takes too many attempts", func() {
+			It("Gives up", func() {
+				for _, opts := range []*SplitOptions{{MaxAttempts: 1}, {MaxAttempts: 1, PhiMultiple: true}} {
+					for _, splitBy := range []SplitBy{Multiplication, Addition} {
+						_, err := SplitDWithOptions(priv, 3, splitBy, opts)
+						Expect(errors.Is(err, ErrTooManyAttempts)).To(BeTrue())
+					}
+				}
+			})
+		})
+
+		When("The caller provides the randomness", func() {
+			It("Splits deterministically", func() {
+				for _, splitBy := range []SplitBy{Multiplication, Addition} {
+					for _, opts := range []SplitOptions{{}, {Mask: true, EqualLength: true}} {
+						opts.Rand = newDeterministicReader("seed")
+						shards, err := SplitDWithOptions(priv, 3, splitBy, &opts)
+						Expect(err).To(BeNil())
+						opts.Rand = newDeterministicReader("seed")
+						again, err := SplitDWithOptions(priv, 3, splitBy, &opts)
+						Expect(err).To(BeNil())
+						opts.Rand = newDeterministicReader("other seed")
+						other, err := SplitDWithOptions(priv, 3, splitBy, &opts)
+						Expect(err).To(BeNil())
+
+						for i := range shards {
+							Expect(shards[i].Equal(again[i])).To(BeTrue())
+							Expect(shards[i].Equal(other[i])).To(BeFalse())
+						}
+					}
+				}
+			})
+
+			It("Reports a failing source of randomness", func() {
+				_, err := SplitDWithOptions(priv, 3, Addition, &SplitOptions{Rand: failingReader{}})
+				var randomnessErr *RandomnessError
+				Expect(errors.As(err, &randomnessErr)).To(BeTrue())
+			})
+		})
+
+		When("The context is done", func() {
+			It("Refuses to split", func() {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+
+				for _, splitBy := range []SplitBy{Multiplication, Addition} {
+					_, err := SplitDContext(ctx, priv, 3, splitBy, nil)
+					Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+				}
+				_, err := SplitDContext(ctx, priv, 3, Addition, &SplitOptions{PhiMultiple: true})
+				Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+			})
+
+			It("Refuses to sign", func() {
+				shards, _ := SplitDContext(context.Background(), priv, 2, Addition, nil)
+				ctx, cancel := context.WithTimeout(context.Background(), 0)
+				defer cancel()
+
+				_, err := SignFirstContext(ctx, rand.Reader, shards[0], crypto.SHA512, hashed)
+				Expect(errors.Is(err, context.DeadlineExceeded)).To(BeTrue())
+
+				partialSig, err := SignFirstContext(context.Background(), rand.Reader, shards[0], crypto.SHA512, hashed)
+				Expect(err).To(BeNil())
+				_, err = SignNextContext(ctx, rand.Reader, shards[1], crypto.SHA512, hashed, partialSig)
+				Expect(errors.Is(err, context.DeadlineExceeded)).To(BeTrue())
+			})
+		})
+
+		When("Attempting to combine a single partial signature", func() {
+			It("Should fail", func() {
+				_, err := CombinePartialSignatures(&priv.PublicKey, &PartialSignature{Hash: crypto.SHA512, SplitBy: Addition, Sig: []byte{1}})
+				Expect(err).NotTo(BeNil(), "Shouldn't be able to combine 1 partial signature")
+			})
+		})
+
+		When("Attempting to combine a partial signature that is out of range", func() {
+			It("Should fail", func() {
+				_, err := CombinePartialSignatures(&priv.PublicKey,
+					&PartialSignature{Signers: []int{1}, Hash: crypto.SHA512, SplitBy: Addition, Sig: []byte{1}},
+					&PartialSignature{Signers: []int{2}, Hash: crypto.SHA512, SplitBy: Addition, Sig: priv.N.Bytes()})
+				Expect(err).NotTo(BeNil(), "Shouldn't be able to combine a partial signature >= N")
+			})
+		})
+
+		When("Attempting to combine a nil partial signature", func() {
+			It("Should fail", func() {
+				_, err := CombinePartialSignatures(&priv.PublicKey,
+					&PartialSignature{Signers: []int{1}, Hash: crypto.SHA512, SplitBy: Addition, Sig: []byte{1}},
+					nil)
+				Expect(errors.Is(err, ErrInvalidPartialSignature)).To(BeTrue())
+			})
+		})
+	})
+
+	Context("Generating split keys", func() {
+		for _, splitBy := range []SplitBy{Multiplication, Addition} {
+			splitBy := splitBy
+
+			It(fmt.Sprintf("Produces a working %s split", splitBy), func() {
+				pub, shards, err := GenerateSplitKey(rand.Reader, keyLength, 3, splitBy)
+				Expect(err).To(BeNil())
+				Expect(shards).To(HaveLen(3))
+
+				sig, err := SignFirst(rand.Reader, shards[0], crypto.SHA512, hashed)
+				Expect(err).To(BeNil())
+				for _, shard := range shards[1:] {
+					Expect(shard.PublicKey).To(BeIdenticalTo(pub))
+
+					sig, err = SignNext(rand.Reader, shard, crypto.SHA512, hashed, sig)
+					Expect(err).To(BeNil())
+				}
+				Expect(rsa.VerifyPKCS1v15(pub, crypto.SHA512, hashed, sig.Sig)).To(Succeed())
+			})
+		}
+
+		It("Refuses to split a key into 1 shard", func() {
+			_, _, err := GenerateSplitKey(rand.Reader, keyLength, 1, Addition)
+			Expect(err).NotTo(BeNil())
+		})
 	})
 
 	Context("Splitting keys multiplicatively", func() {
@@ -119,7 +437,7 @@ var _ = Describe("Keysplitting", func() {
 
 		for i := 2; i <= maxTestShards; i++ {
 			When(fmt.Sprintf("Splitting a key %d ways", i), Ordered, func() {
-				runTest(priv, i, hashed, Multiplication)
+				runTest(priv, i, hashed, Multiplication, nil)
 			})
 		}
 	})
@@ -128,21 +446,155 @@ var _ = Describe("Keysplitting", func() {
 		priv, _ := rsa.GenerateKey(rand.Reader, keyLength)
 		for i := 2; i <= maxTestShards; i++ {
 			When(fmt.Sprintf("Splitting a key %d ways", i), func() {
-				runTest(priv, i, hashed, Addition)
+				runTest(priv, i, hashed, Addition, nil)
 			})
 		}
 	})
 
+	Context("Splitting keys additively with the d + r * phi variant", func() {
+		priv, _ := rsa.GenerateKey(rand.Reader, keyLength)
+		opts := &SplitOptions{PhiMultiple: true}
+
+		for i := 2; i <= maxTestShards; i++ {
+			When(fmt.Sprintf("Splitting a key %d ways", i), func() {
+				runTest(priv, i, hashed, Addition, opts)
+			})
+		}
+
+		It("Produces shards that sum to D + r * phi for some r >= 1", func() {
+			phi := eulerTotient(priv.Primes)
+			shards, err := SplitDWithOptions(priv, 4, Addition, opts)
+			Expect(err).To(BeNil())
+
+			r, rem := new(big.Int).DivMod(new(big.Int).Sub(shardSum(shards), priv.D), phi, new(big.Int))
+			Expect(rem.Sign()).To(Equal(0))
+			Expect(r.Cmp(bigOne)).To(BeNumerically(">=", 0))
+		})
+	})
+
+	Context("Splitting keys modulo lambda(N)", func() {
+		priv, _ := rsa.GenerateKey(rand.Reader, keyLength)
+		opts := &SplitOptions{Lambda: true}
+
+		for _, splitBy := range []SplitBy{Multiplication, Addition} {
+			for _, i := range []int{2, 3, 5} {
+				When(fmt.Sprintf("Splitting a key %d ways by %s", i, splitBy), Ordered, func() {
+					runTest(priv, i, hashed, splitBy, opts)
+				})
+			}
+		}
+	})
+
+	Context("Splitting keys multiplicatively into shards of equal length", func() {
+		priv, _ := rsa.GenerateKey(rand.Reader, keyLength)
+		opts := &SplitOptions{EqualLength: true}
+
+		for _, i := range []int{2, 3, 5} {
+			When(fmt.Sprintf("Splitting a key %d ways", i), Ordered, func() {
+				runTest(priv, i, hashed, Multiplication, opts)
+			})
+		}
+
+		It("Reduces every shard mod phi(N)", func() {
+			phi := eulerTotient(priv.Primes)
+			shards, err := SplitDWithOptions(priv, 5, Multiplication, opts)
+			Expect(err).To(BeNil())
+			for _, shard := range shards {
+				Expect(shard.D.Cmp(bigOne)).To(Equal(1))
+				Expect(shard.D.Cmp(phi)).To(Equal(-1))
+				Expect(shard.Validate()).To(Succeed())
+			}
+		})
+	})
+
+	Context("Splitting keys without their prime factors", func() {
+		priv, _ := rsa.GenerateKey(rand.Reader, keyLength)
+
+		// lambda(N) = lcm(p-1, q-1), and the matching private exponent is E^-1 (mod lambda(N)), which differs from priv.D
+		pMinusOne := new(big.Int).Sub(priv.Primes[0], bigOne)
+		qMinusOne := new(big.Int).Sub(priv.Primes[1], bigOne)
+		lambda := new(big.Int).Mul(pMinusOne, qMinusOne)
+		lambda.Quo(lambda, new(big.Int).GCD(nil, nil, pMinusOne, qMinusOne))
+		lambdaD := new(big.Int).ModInverse(big.NewInt(int64(priv.E)), lambda)
+
+		for _, splitBy := range []SplitBy{Multiplication, Addition} {
+			splitBy := splitBy
+
+			for _, c := range []struct {
+				label  string
+				d, phi *big.Int
+			}{
+				{"phi(N)", priv.D, eulerTotient(priv.Primes)},
+				{"lambda(N)", lambdaD, lambda},
+			} {
+				label, d, phi := c.label, c.d, c.phi
+
+				It(fmt.Sprintf("Produces a working %s split given %s", splitBy, label), func() {
+					shards, err := SplitDFromPhi(d, phi, &priv.PublicKey, 3, splitBy)
+					Expect(err).To(BeNil())
+
+					sig, err := SignFirst(rand.Reader, shards[0], crypto.SHA512, hashed)
+					Expect(err).To(BeNil())
+					for _, shard := range shards[1:] {
+						sig, err = SignNext(rand.Reader, shard, crypto.SHA512, hashed, sig)
+						Expect(err).To(BeNil())
+					}
+					Expect(rsa.VerifyPKCS1v15(&priv.PublicKey, crypto.SHA512, hashed, sig.Sig)).To(Succeed())
+				})
+			}
+		}
+
+		It("Refuses a private exponent that doesn't match the public key", func() {
+			_, err := SplitDFromPhi(new(big.Int).Add(priv.D, bigOne), eulerTotient(priv.Primes), &priv.PublicKey, 2, Addition)
+			Expect(err).NotTo(BeNil())
+		})
+
+		It("Refuses a phi that doesn't match the public key", func() {
+			_, err := SplitDFromPhi(priv.D, new(big.Int).Add(eulerTotient(priv.Primes), big.NewInt(2)), &priv.PublicKey, 2, Addition)
+			Expect(err).NotTo(BeNil())
+		})
+
+		It("Refuses a phi that is out of range", func() {
+			_, err := SplitDFromPhi(priv.D, priv.N, &priv.PublicKey, 2, Addition)
+			Expect(err).NotTo(BeNil())
+		})
+	})
+
 	// we don't expect multi-prime keys to be heavily used but we should make sure they can be split just like everybody else
 	Context("Multi-prime keys", func() {
 		When("Using a 4096-bit / 3-prime key split 5 ways additively", func() {
 			priv, _ := rsa.GenerateMultiPrimeKey(rand.Reader, 3, keyLength*2)
-			runTest(priv, 5, hashed, Addition)
+			runTest(priv, 5, hashed, Addition, nil)
 		})
 
 		When("Using a 8192-bit / 5-prime key split 3 ways multiplicatively", func() {
 			priv, _ := rsa.GenerateMultiPrimeKey(rand.Reader, 5, keyLength*4)
-			runTest(priv, 5, hashed, Addition)
+			runTest(priv, 5, hashed, Addition, nil)
 		})
 	})
 })
+
+// signing with a shard, for comparison with the setup that signing repeats on every call (see BenchmarkModulusSetup)
+func BenchmarkSignFirst(b *testing.B) {
+	priv, _ := rsa.GenerateKey(rand.Reader, 2048)
+	shards, _ := SplitD(priv, 2, Addition)
+	hashed := sha512.Sum512([]byte("TEST MESSAGE"))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := SignFirst(rand.Reader, shards[0], crypto.SHA512, hashed[:]); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// the only per-modulus setup of a Montgomery exponentiation that could be cached between signatures, i.e. 2^(2*|N|) mod N
+func BenchmarkModulusSetup(b *testing.B) {
+	priv, _ := rsa.GenerateKey(rand.Reader, 2048)
+	rr := new(big.Int).Lsh(bigOne, uint(2*priv.N.BitLen()))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		new(big.Int).Mod(rr, priv.N)
+	}
+}
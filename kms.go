@@ -0,0 +1,150 @@
+package keysplitting
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"io"
+)
+
+const kmsWrappedPEMType = "KMS WRAPPED RSA SPLIT PRIVATE KEY"
+
+// the encryption context key under which the shard's key ID is bound to its data key
+const kmsKeyIDContextKey = "keysplitting:key-id"
+
+// A ShardWrapper protects shards at rest, typically by encrypting them with a key that never leaves a key management service,
+// so that a shard never reaches disk in the clear
+type ShardWrapper interface {
+	// WrapShard encrypts the shard
+	WrapShard(ctx context.Context, shard *PrivateKeyShard) ([]byte, error)
+	// UnwrapShard decrypts a shard encrypted by WrapShard
+	UnwrapShard(ctx context.Context, wrapped []byte) (*PrivateKeyShard, error)
+}
+
+// A DataKeySource generates and decrypts 256-bit data keys for envelope encryption, as with the GenerateDataKey and Decrypt
+// operations of AWS KMS, or the equivalent operations of other cloud key management services. The encryption context is
+// non-secret data that must be supplied again to decrypt the data key, and is typically recorded in the service's audit log
+type DataKeySource interface {
+	GenerateDataKey(ctx context.Context, encryptionContext map[string]string) (plaintext, encrypted []byte, err error)
+	DecryptDataKey(ctx context.Context, encrypted []byte, encryptionContext map[string]string) ([]byte, error)
+}
+
+// used exclusively as a placeholder for encoding-decoding
+type kmsWrappedShard struct {
+	KeyID            []byte // SHA-256 hash of the shard's PKCS #1 public key
+	EncryptedDataKey []byte // as returned by the DataKeySource
+	Nonce            []byte
+	Ciphertext       []byte
+}
+
+// An EnvelopeWrapper is a [ShardWrapper] that encrypts each shard with AES-256-GCM under a fresh data key from a [DataKeySource],
+// and stores the encrypted data key alongside it in a PEM block. The shard's key ID is bound to the data key as its encryption
+// context, so a key management service's audit log records which key each shard belongs to
+type EnvelopeWrapper struct {
+	source DataKeySource
+}
+
+// NewEnvelopeWrapper returns an EnvelopeWrapper that gets its data keys from source, such as an awskms.DataKeySource
+func NewEnvelopeWrapper(source DataKeySource) *EnvelopeWrapper {
+	return &EnvelopeWrapper{source: source}
+}
+
+// WrapShard encrypts the shard under a new data key
+func (w *EnvelopeWrapper) WrapShard(ctx context.Context, shard *PrivateKeyShard) ([]byte, error) {
+	plaintext, err := shard.marshalDER()
+	if err != nil {
+		return nil, err
+	}
+	defer zeroizeBytes(plaintext)
+
+	keyID := publicKeyID(shard.PublicKey)
+	key, encryptedKey, err := w.source.GenerateDataKey(ctx, kmsEncryptionContext(keyID[:]))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate data key: %w", err)
+	}
+	defer zeroizeBytes(key)
+
+	aead, err := newWrappingAEAD(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid data key: %s", err)
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, &RandomnessError{Err: err}
+	}
+
+	b, err := asn1.Marshal(kmsWrappedShard{
+		KeyID:            keyID[:],
+		EncryptedDataKey: encryptedKey,
+		Nonce:            nonce,
+		Ciphertext:       aead.Seal(nil, nonce, plaintext, kmsWrappingAAD(keyID[:])),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to DER-encode: %s", err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{
+		Type:  kmsWrappedPEMType,
+		Bytes: b,
+	}), nil
+}
+
+// UnwrapShard decrypts a shard encrypted by [EnvelopeWrapper.WrapShard]. It fails with [ErrInvalidShard] if the shard is
+// malformed or has been tampered with, and with the DataKeySource's error if the data key can't be decrypted
+func (w *EnvelopeWrapper) UnwrapShard(ctx context.Context, wrapped []byte) (*PrivateKeyShard, error) {
+	block, rest := pem.Decode(wrapped)
+	if block == nil || block.Type != kmsWrappedPEMType || len(bytes.TrimSpace(rest)) > 0 {
+		return nil, errorf(ErrInvalidShard, "failed to decode PEM block containing KMS-wrapped private key shard")
+	}
+
+	var envelope kmsWrappedShard
+	rest, err := asn1.Unmarshal(block.Bytes, &envelope)
+	if err != nil {
+		return nil, errorf(ErrInvalidShard, "failed to unmarshal DER-encoded KMS-wrapped private key shard: %s", err)
+	}
+	if len(rest) > 0 {
+		return nil, errorf(ErrInvalidShard, "failed to unmarshal DER-encoded KMS-wrapped private key shard")
+	}
+
+	key, err := w.source.DecryptDataKey(ctx, envelope.EncryptedDataKey, kmsEncryptionContext(envelope.KeyID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt data key: %w", err)
+	}
+	defer zeroizeBytes(key)
+
+	aead, err := newWrappingAEAD(key)
+	if err != nil {
+		return nil, errorf(ErrInvalidShard, "failed to decrypt KMS-wrapped private key shard")
+	}
+	if len(envelope.Nonce) != aead.NonceSize() {
+		return nil, errorf(ErrInvalidShard, "invalid nonce length")
+	}
+
+	plaintext, err := aead.Open(nil, envelope.Nonce, envelope.Ciphertext, kmsWrappingAAD(envelope.KeyID))
+	if err != nil {
+		return nil, errorf(ErrInvalidShard, "failed to decrypt KMS-wrapped private key shard")
+	}
+	defer zeroizeBytes(plaintext)
+
+	shard, err := unmarshalDER(plaintext)
+	if err != nil {
+		return nil, err
+	}
+	if keyID := publicKeyID(shard.PublicKey); !bytes.Equal(keyID[:], envelope.KeyID) {
+		return nil, errorf(ErrInvalidShard, "KMS-wrapped private key shard doesn't match its key ID")
+	}
+	return shard, nil
+}
+
+func kmsEncryptionContext(keyID []byte) map[string]string {
+	return map[string]string{kmsKeyIDContextKey: hex.EncodeToString(keyID)}
+}
+
+// binds the ciphertext to both the format and the key
+func kmsWrappingAAD(keyID []byte) []byte {
+	return append([]byte(kmsWrappedPEMType), keyID...)
+}
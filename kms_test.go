@@ -0,0 +1,153 @@
+package keysplitting
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// a DataKeySource that encrypts data keys with a local key, standing in for a key management service
+type fakeDataKeySource struct {
+	masterKey []byte
+}
+
+func newFakeDataKeySource() *fakeDataKeySource {
+	masterKey := make([]byte, 32)
+	_, _ = rand.Read(masterKey)
+	return &fakeDataKeySource{masterKey: masterKey}
+}
+
+func (s *fakeDataKeySource) GenerateDataKey(ctx context.Context, encryptionContext map[string]string) ([]byte, []byte, error) {
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, nil, err
+	}
+	aead, _ := newWrappingAEAD(s.masterKey)
+	nonce := make([]byte, aead.NonceSize())
+	_, _ = rand.Read(nonce)
+	return key, aead.Seal(nonce, nonce, key, []byte(fmt.Sprint(encryptionContext))), nil
+}
+
+func (s *fakeDataKeySource) DecryptDataKey(ctx context.Context, encrypted []byte, encryptionContext map[string]string) ([]byte, error) {
+	aead, _ := newWrappingAEAD(s.masterKey)
+	if len(encrypted) < aead.NonceSize() {
+		return nil, fmt.Errorf("invalid ciphertext")
+	}
+	return aead.Open(nil, encrypted[:aead.NonceSize()], encrypted[aead.NonceSize():], []byte(fmt.Sprint(encryptionContext)))
+}
+
+var _ = Describe("KMS shard wrapping", func() {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	shards, _ := SplitD(key, 2, Addition)
+	ctx := context.Background()
+
+	It("Round-trips", func() {
+		var wrapper ShardWrapper = NewEnvelopeWrapper(newFakeDataKeySource())
+		wrapped, err := wrapper.WrapShard(ctx, shards[0])
+		Expect(err).To(BeNil())
+
+		unwrapped, err := wrapper.UnwrapShard(ctx, wrapped)
+		Expect(err).To(BeNil())
+		Expect(unwrapped.Equal(shards[0])).To(BeTrue())
+	})
+
+	It("Doesn't reveal the shard", func() {
+		wrapped, err := NewEnvelopeWrapper(newFakeDataKeySource()).WrapShard(ctx, shards[0])
+		Expect(err).To(BeNil())
+
+		_, err = DecodePEM(string(wrapped))
+		Expect(errors.Is(err, ErrInvalidShard)).To(BeTrue())
+	})
+
+	It("Fails without the right data key", func() {
+		wrapped, err := NewEnvelopeWrapper(newFakeDataKeySource()).WrapShard(ctx, shards[0])
+		Expect(err).To(BeNil())
+
+		_, err = NewEnvelopeWrapper(newFakeDataKeySource()).UnwrapShard(ctx, wrapped)
+		Expect(err).NotTo(BeNil())
+	})
+
+	It("Binds the data key to the shard's key", func() {
+		source := newFakeDataKeySource()
+		wrapper := NewEnvelopeWrapper(source)
+		other, _ := rsa.GenerateKey(rand.Reader, 2048)
+		otherShards, _ := SplitD(other, 2, Addition)
+
+		wrapped, err := wrapper.WrapShard(ctx, shards[0])
+		Expect(err).To(BeNil())
+		otherWrapped, err := wrapper.WrapShard(ctx, otherShards[0])
+		Expect(err).To(BeNil())
+
+		// move the encrypted data key of one shard onto the other
+		var envelope, otherEnvelope kmsWrappedShard
+		Expect(decodeKMSWrappedShard(wrapped, &envelope)).To(Succeed())
+		Expect(decodeKMSWrappedShard(otherWrapped, &otherEnvelope)).To(Succeed())
+		otherEnvelope.KeyID = envelope.KeyID
+		_, err = wrapper.UnwrapShard(ctx, encodeKMSWrappedShard(otherEnvelope))
+		Expect(err).NotTo(BeNil())
+	})
+
+	It("Detects tampering", func() {
+		wrapper := NewEnvelopeWrapper(newFakeDataKeySource())
+		wrapped, err := wrapper.WrapShard(ctx, shards[0])
+		Expect(err).To(BeNil())
+
+		var envelope kmsWrappedShard
+		Expect(decodeKMSWrappedShard(wrapped, &envelope)).To(Succeed())
+		envelope.Ciphertext[0] ^= 1
+		_, err = wrapper.UnwrapShard(ctx, encodeKMSWrappedShard(envelope))
+		Expect(errors.Is(err, ErrInvalidShard)).To(BeTrue())
+	})
+
+	It("Passes errors from the key management service through", func() {
+		kmsErr := &kmsAPIError{code: "AccessDeniedException", message: "not authorized"}
+		_, err := NewEnvelopeWrapper(failingDataKeySource{kmsErr}).WrapShard(ctx, shards[0])
+		var target *kmsAPIError
+		Expect(errors.As(err, &target)).To(BeTrue())
+		Expect(reflect.DeepEqual(target, kmsErr)).To(BeTrue())
+	})
+})
+
+// an error returned by a key management service's API
+type kmsAPIError struct {
+	code, message string
+}
+
+func (e *kmsAPIError) Error() string {
+	return e.code + ": " + e.message
+}
+
+type failingDataKeySource struct {
+	err error
+}
+
+func (s failingDataKeySource) GenerateDataKey(context.Context, map[string]string) ([]byte, []byte, error) {
+	return nil, nil, s.err
+}
+
+func (s failingDataKeySource) DecryptDataKey(context.Context, []byte, map[string]string) ([]byte, error) {
+	return nil, s.err
+}
+
+func decodeKMSWrappedShard(wrapped []byte, envelope *kmsWrappedShard) error {
+	block, _ := pem.Decode(wrapped)
+	if block == nil {
+		return fmt.Errorf("not PEM")
+	}
+	_, err := asn1.Unmarshal(block.Bytes, envelope)
+	return err
+}
+
+func encodeKMSWrappedShard(envelope kmsWrappedShard) []byte {
+	b, _ := asn1.Marshal(envelope)
+	return pem.EncodeToMemory(&pem.Block{Type: kmsWrappedPEMType, Bytes: b})
+}
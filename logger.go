@@ -0,0 +1,34 @@
+package keysplitting
+
+// A Logger receives structured log messages about the lifecycle of a [Dealer], [Broker] or shardservice.Server, along with
+// the failures they see. args are alternating keys and values, as with log/slog, and a *slog.Logger can be used as a Logger
+// directly. The messages identify keys, shards and signers by their public key ID and index only, never by anything secret
+type Logger interface {
+	Debug(msg string, args ...interface{})
+	Info(msg string, args ...interface{})
+	Warn(msg string, args ...interface{})
+	Error(msg string, args ...interface{})
+}
+
+// NopLogger discards every message. It is the default [Logger]
+type NopLogger struct{}
+
+// Debug does nothing
+func (NopLogger) Debug(string, ...interface{}) {}
+
+// Info does nothing
+func (NopLogger) Info(string, ...interface{}) {}
+
+// Warn does nothing
+func (NopLogger) Warn(string, ...interface{}) {}
+
+// Error does nothing
+func (NopLogger) Error(string, ...interface{}) {}
+
+// returns logger, or a NopLogger if it is nil
+func loggerOrNop(logger Logger) Logger {
+	if logger == nil {
+		return NopLogger{}
+	}
+	return logger
+}
@@ -0,0 +1,145 @@
+package keysplitting
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// a message logged to a recordingLogger
+type loggedMessage struct {
+	level string
+	msg   string
+	args  map[string]interface{}
+}
+
+// a Logger that keeps every message
+type recordingLogger struct {
+	mu       sync.Mutex
+	messages []loggedMessage
+}
+
+func (l *recordingLogger) log(level, msg string, args []interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	Expect(len(args) % 2).To(Equal(0))
+	message := loggedMessage{level: level, msg: msg, args: map[string]interface{}{}}
+	for i := 0; i < len(args); i += 2 {
+		key, ok := args[i].(string)
+		Expect(ok).To(BeTrue())
+		// nothing secret, or anything that could contain it, is ever logged
+		switch args[i+1].(type) {
+		case string, int, []int, SplitBy, error:
+		default:
+			Fail("unexpected log value for " + key)
+		}
+		message.args[key] = args[i+1]
+	}
+	l.messages = append(l.messages, message)
+}
+
+func (l *recordingLogger) Debug(msg string, args ...interface{}) { l.log("debug", msg, args) }
+func (l *recordingLogger) Info(msg string, args ...interface{})  { l.log("info", msg, args) }
+func (l *recordingLogger) Warn(msg string, args ...interface{})  { l.log("warn", msg, args) }
+func (l *recordingLogger) Error(msg string, args ...interface{}) { l.log("error", msg, args) }
+
+// returns the messages logged at the given level
+func (l *recordingLogger) at(level string) []loggedMessage {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var messages []loggedMessage
+	for _, message := range l.messages {
+		if message.level == level {
+			messages = append(messages, message)
+		}
+	}
+	return messages
+}
+
+var _ = Describe("Logging", func() {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	keyID := PublicKeyID(&key.PublicKey)
+	hashed := sha256.Sum256([]byte("TEST MESSAGE"))
+	var logger *recordingLogger
+
+	BeforeEach(func() {
+		logger = &recordingLogger{}
+	})
+
+	It("Reports the progress of a key ceremony", func() {
+		dealer := NewDealer(nil)
+		dealer.SetLogger(logger)
+		pub, err := dealer.GenerateKey(rand.Reader, 2048)
+		Expect(err).To(BeNil())
+		Expect(dealer.Split(context.Background(), pub, 2, Addition)).To(Succeed())
+		shard, err := dealer.HandOutTo(pub, 1, "alice")
+		Expect(err).To(BeNil())
+		_, err = dealer.HandOutTo(pub, 1, "mallory")
+		Expect(err).NotTo(BeNil())
+
+		_, recipientKey, err := ed25519.GenerateKey(rand.Reader)
+		Expect(err).To(BeNil())
+		ack, err := Acknowledge(shard, "alice", recipientKey)
+		Expect(err).To(BeNil())
+		Expect(dealer.Acknowledge(pub, ack, recipientKey.Public())).To(Succeed())
+		forged, err := Acknowledge(shard, "mallory", recipientKey)
+		Expect(err).To(BeNil())
+		Expect(dealer.Acknowledge(pub, forged, recipientKey.Public())).NotTo(Succeed())
+		Expect(dealer.Close()).To(Succeed())
+		Expect(dealer.Close()).To(Succeed())
+
+		var infos []string
+		for _, message := range logger.at("info") {
+			infos = append(infos, message.msg)
+			if message.msg != "closed dealer" {
+				Expect(message.args).To(HaveKeyWithValue("key_id", PublicKeyID(pub)))
+			}
+		}
+		Expect(infos).To(Equal([]string{"added key to dealer", "split key", "handed out shard", "shard acknowledged", "closed dealer"}))
+
+		warnings := logger.at("warn")
+		Expect(warnings).To(HaveLen(2))
+		Expect(warnings[0].args).To(HaveKeyWithValue("recipient", "mallory"))
+		Expect(warnings[0].args).To(HaveKeyWithValue("shard", 1))
+		Expect(errors.Is(warnings[1].args["error"].(error), ErrInvalidShard)).To(BeTrue())
+	})
+
+	It("Reports the partial signatures a broker accepts and rejects", func() {
+		shards, err := SplitD(key, 2, Addition)
+		Expect(err).To(BeNil())
+		broker, err := NewBroker(&key.PublicKey, 2)
+		Expect(err).To(BeNil())
+		broker.SetLogger(logger)
+
+		partials := make([]*PartialSignature, len(shards))
+		for i, shard := range shards {
+			partials[i], err = SignFirst(rand.Reader, shard, broker.SignerOpts(crypto.SHA256), hashed[:])
+			Expect(err).To(BeNil())
+		}
+		_, err = broker.AddPartial(partials[0])
+		Expect(err).To(BeNil())
+		_, err = broker.AddPartial(partials[0])
+		Expect(err).NotTo(BeNil())
+		_, err = broker.AddPartial(partials[1])
+		Expect(err).To(BeNil())
+
+		Expect(logger.at("debug")).To(HaveLen(1))
+		Expect(logger.at("debug")[0].args).To(HaveKeyWithValue("signers", []int{1}))
+		warnings := logger.at("warn")
+		Expect(warnings).To(HaveLen(1))
+		Expect(warnings[0].args).To(HaveKeyWithValue("key_id", keyID))
+		Expect(errors.Is(warnings[0].args["error"].(error), ErrInvalidPartialSignature)).To(BeTrue())
+		Expect(logger.at("info")).To(HaveLen(1))
+		Expect(logger.at("info")[0].msg).To(Equal("combined partial signatures"))
+	})
+})
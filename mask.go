@@ -0,0 +1,43 @@
+package keysplitting
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"io"
+	"math/big"
+)
+
+// returns k masks that are random (mod phi) and sum to a multiple of phi
+func newMasks(random io.Reader, k int, phi *big.Int) ([]*big.Int, error) {
+	masks := make([]*big.Int, k)
+	sum := new(big.Int)
+	for i := 0; i < k-1; i++ {
+		w, err := rand.Int(random, phi)
+		if err != nil {
+			return nil, &RandomnessError{Err: err}
+		}
+		masks[i] = w
+		sum.Add(sum, w)
+	}
+
+	// final mask <- -[sum of masks] (mod phi)
+	masks[k-1] = sum.Neg(sum).Mod(sum, phi)
+	return masks, nil
+}
+
+// returns the exponent the shard signs with, i.e. D + r * Mask where r is derived from the given signing inputs,
+// or just D if the shard is unmasked
+func (pks *PrivateKeyShard) exponent(inputs ...[]byte) *big.Int {
+	if pks.Mask == nil {
+		return pks.D
+	}
+
+	h := sha256.New()
+	for _, input := range inputs {
+		h.Write(input)
+	}
+	r := new(big.Int).SetBytes(h.Sum(nil))
+
+	exponent := r.Mul(r, pks.Mask)
+	return exponent.Add(exponent, pks.D)
+}
@@ -0,0 +1,114 @@
+package keysplitting
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"math/big"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Exponent masking", func() {
+	hashed := sha512.Sum512([]byte("TEST MESSAGE"))
+	otherHashed := sha512.Sum512([]byte("OTHER MESSAGE"))
+	priv, _ := rsa.GenerateKey(rand.Reader, 2048)
+	opts := &SplitOptions{Mask: true}
+
+	When("Splitting a key additively with masking", Ordered, func() {
+		var shards []*PrivateKeyShard
+
+		BeforeAll(func() {
+			var err error
+			shards, err = SplitDWithOptions(priv, 3, Addition, opts)
+			Expect(err).To(BeNil())
+		})
+
+		It("Gives each shard masks that cancel out", func() {
+			sum := new(big.Int)
+			for _, shard := range shards {
+				Expect(shard.Mask).NotTo(BeNil())
+				sum.Add(sum, shard.Mask)
+			}
+			Expect(new(big.Int).Mod(sum, eulerTotient(priv.Primes)).Sign()).To(Equal(0))
+		})
+
+		It("Signs with a different exponent for each digest", func() {
+			Expect(shards[0].exponent(hashed[:]).Cmp(shards[0].D)).NotTo(Equal(0))
+			Expect(shards[0].exponent(hashed[:]).Cmp(shards[0].exponent(otherHashed[:]))).NotTo(Equal(0))
+		})
+
+		It("Produces valid brokered signatures", func() {
+			for _, digest := range [][]byte{hashed[:], otherHashed[:]} {
+				sig := signBrokered(&priv.PublicKey, shards, digest)
+				Expect(rsa.VerifyPKCS1v15(&priv.PublicKey, crypto.SHA512, digest, sig)).To(Succeed())
+			}
+		})
+
+		It("Produces valid sequential signatures", func() {
+			sig, err := SignFirst(rand.Reader, shards[0], crypto.SHA512, hashed[:])
+			Expect(err).To(BeNil())
+			for _, shard := range shards[1:] {
+				sig, err = SignNext(rand.Reader, shard, crypto.SHA512, hashed[:], sig)
+				Expect(err).To(BeNil())
+			}
+			Expect(rsa.VerifyPKCS1v15(&priv.PublicKey, crypto.SHA512, hashed[:], sig.Sig)).To(Succeed())
+		})
+
+		It("Produces valid PSS signatures", func() {
+			pssHashed := sha256.Sum256([]byte("TEST MESSAGE"))
+			salt, err := NewPSSSalt(rand.Reader, &priv.PublicKey, crypto.SHA256, nil)
+			Expect(err).To(BeNil())
+
+			sig, err := SignFirstPSS(rand.Reader, shards[0], crypto.SHA256, pssHashed[:], salt)
+			Expect(err).To(BeNil())
+			for _, shard := range shards[1:] {
+				sig, err = SignNextPSS(rand.Reader, shard, crypto.SHA256, pssHashed[:], salt, sig)
+				Expect(err).To(BeNil())
+			}
+			Expect(rsa.VerifyPSS(&priv.PublicKey, crypto.SHA256, pssHashed[:], sig.Sig, nil)).To(Succeed())
+		})
+
+		It("Keeps the masks through PEM encoding", func() {
+			pemEncoded, err := shards[0].EncodePEM()
+			Expect(err).To(BeNil())
+			decoded, err := DecodePEM(pemEncoded)
+			Expect(err).To(BeNil())
+			Expect(decoded.Mask.Cmp(shards[0].Mask)).To(Equal(0))
+		})
+
+		It("Keeps the masks balanced when shards are split, merged, or refreshed", func() {
+			subShards, err := SplitShard(shards[0], 2, 4)
+			Expect(err).To(BeNil())
+			merged, err := MergeShards(shards[1], shards[2])
+			Expect(err).To(BeNil())
+			rearranged := append(subShards, merged)
+			sig := signBrokered(&priv.PublicKey, rearranged, hashed[:])
+			Expect(rsa.VerifyPKCS1v15(&priv.PublicKey, crypto.SHA512, hashed[:], sig)).To(Succeed())
+
+			refreshed, err := RefreshShards(shards)
+			Expect(err).To(BeNil())
+			sig = signBrokered(&priv.PublicKey, refreshed, hashed[:])
+			Expect(rsa.VerifyPKCS1v15(&priv.PublicKey, crypto.SHA512, hashed[:], sig)).To(Succeed())
+		})
+
+		It("Refuses to commit to the shards", func() {
+			_, err := NewShardCommitments(rand.Reader, shards)
+			Expect(errors.Is(err, ErrInvalidShard)).To(BeTrue())
+		})
+	})
+
+	When("Splitting a key multiplicatively with masking", func() {
+		It("Ignores the option", func() {
+			shards, err := SplitDWithOptions(priv, 3, Multiplication, opts)
+			Expect(err).To(BeNil())
+			for _, shard := range shards {
+				Expect(shard.Mask).To(BeNil())
+			}
+		})
+	})
+})
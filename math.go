@@ -1,6 +1,8 @@
 package keysplitting
 
 import (
+	"crypto/rsa"
+	"crypto/subtle"
 	"math/big"
 )
 
@@ -29,3 +31,63 @@ func eulerTotient(primes []*big.Int) *big.Int {
 
 	return phi
 }
+
+// calculate the Carmichael function lambda(n) using the prime factors of n, which is the lcm of each prime minus 1
+func carmichaelLambda(primes []*big.Int) *big.Int {
+	lambda := big.NewInt(1)
+	for _, p := range primes {
+		// lambda <- lcm(lambda, p - 1) = lambda * (p - 1) / gcd(lambda, p - 1)
+		pm1 := new(big.Int).Sub(p, bigOne)
+		gcd := new(big.Int).GCD(nil, nil, lambda, pm1)
+		lambda.Mul(lambda, pm1)
+		lambda.Quo(lambda, gcd)
+	}
+
+	return lambda
+}
+
+// reports whether a and b are equal, in constant time with respect to their values (but not their lengths).
+// nil is only equal to nil, so that a zeroized shard is not equal to one whose exponent happens to be 0
+func secretEqual(a, b *big.Int) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return subtle.ConstantTimeCompare(a.Bytes(), b.Bytes()) == 1 &&
+		subtle.ConstantTimeEq(int32(a.Sign()), int32(b.Sign())) == 1
+}
+
+// overwrites the words backing n with zeros and sets n to 0. This is best-effort: it cannot reach copies of n
+// that math/big made internally, e.g. when growing or shrinking n
+func zeroize(n *big.Int) {
+	if n == nil {
+		return
+	}
+	words := n.Bits()
+	for i := range words {
+		words[i] = 0
+	}
+	n.SetInt64(0)
+}
+
+// overwrites b with zeros
+func zeroizeBytes(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+// zeroizes all of the secret values of priv, leaving the public key intact
+func zeroizePrivateKey(priv *rsa.PrivateKey) {
+	zeroize(priv.D)
+	for _, p := range priv.Primes {
+		zeroize(p)
+	}
+	zeroize(priv.Precomputed.Dp)
+	zeroize(priv.Precomputed.Dq)
+	zeroize(priv.Precomputed.Qinv)
+	for _, crt := range priv.Precomputed.CRTValues {
+		zeroize(crt.Exp)
+		zeroize(crt.Coeff)
+		zeroize(crt.R)
+	}
+}
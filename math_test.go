@@ -53,3 +53,32 @@ var _ = Describe("Keysplitting Euler totient", func() {
 		})
 	})
 })
+
+var _ = Describe("Keysplitting Carmichael function", func() {
+	Context("Small numbers", func() {
+		It("Correctly gives lambda(77837) == 19320", func() {
+			// let p = 277 and q = 281. Then n = 77837
+			lambda := carmichaelLambda([]*big.Int{big.NewInt(277), big.NewInt(281)})
+			Expect(lambda.Cmp(big.NewInt(19320))).To(Equal(0), "Incorrect result: lambda(77837) != 19320")
+		})
+
+		It("Correctly gives lambda(9191070797) == 19010880", func() {
+			// let our primes equal 277, 281, and 118081. Then n = 9191070797
+			lambda := carmichaelLambda([]*big.Int{big.NewInt(277), big.NewInt(281), big.NewInt(118081)})
+			Expect(lambda.Cmp(big.NewInt(19010880))).To(Equal(0), "Incorrect result: lambda(9191070797) != 19010880")
+		})
+	})
+})
+
+var _ = Describe("Keysplitting zeroization", func() {
+	It("Overwrites the value", func() {
+		n, _ := new(big.Int).SetString("14411463122699977911481627357183340675117845456707438151886283579067899255171385549601519500391210995137972501924606343388195146474013619734159781079685498658201113144791690767478757196033013131697249113257067485435669073579036912426360350296470577449136532036910043291259246967323053207699907512178076148392", 10)
+		words := n.Bits()
+
+		zeroize(n)
+		Expect(n.Sign()).To(Equal(0))
+		for _, word := range words {
+			Expect(word).To(BeZero())
+		}
+	})
+})
@@ -0,0 +1,145 @@
+package keysplitting
+
+import (
+	"crypto/rand"
+	"math/big"
+)
+
+// SplitShard lets the holder of an additive shard delegate it without going back to the dealer, by splitting it into k
+// sub-shards that add up to the original. All k sub-shards must then sign in place of the original shard, and together with
+// the other shards they produce a valid signature exactly as before.
+//
+// Since the holder doesn't know phi(N), the first k-1 sub-shards are random integers 2^128 times larger than N or the shard,
+// so that they hide the shard statistically, and the final sub-shard is whatever remains, which may be negative.
+// The first sub-shard keeps the original shard's index, and the others are numbered nextIndex, nextIndex+1, and so on, which
+// must not be in use by any other shard of the key, e.g. nextIndex is 4 when splitting one of 3 shards for the first time.
+// Multiplicative shards cannot be split this way, because splitting them would require factoring the shard modulo phi(N)
+func SplitShard(shard *PrivateKeyShard, k, nextIndex int) ([]*PrivateKeyShard, error) {
+	if shard.SplitBy != Addition {
+		return nil, errorf(ErrUnsupportedSplitBy, "only additive shards can be split")
+	}
+	if k < 2 {
+		return nil, errorf(ErrTooFewShards, "cannot split shard into fewer than 2 sub-shards")
+	}
+	if nextIndex < 1 || (shard.Index >= nextIndex && shard.Index < nextIndex+k-1) {
+		return nil, errorf(ErrInvalidShard, "sub-shards numbered from %d would reuse index %d", nextIndex, shard.Index)
+	}
+	if err := shard.checkUsable(); err != nil {
+		return nil, err
+	}
+
+	ds, err := splitInteger(shard.D, shard.PublicKey.N, k)
+	if err != nil {
+		return nil, err
+	}
+	subShards := make([]*PrivateKeyShard, k)
+	for i, d := range ds {
+		index := shard.Index
+		if i > 0 {
+			index = nextIndex + i - 1
+		}
+		subShards[i] = &PrivateKeyShard{PublicKey: shard.PublicKey, D: d, SplitBy: Addition, Index: index}
+	}
+
+	// a masked shard's mask is split in the same way, so that the sub-shards' masks still cancel out those of the other shards
+	if shard.Mask != nil {
+		masks, err := splitInteger(shard.Mask, shard.PublicKey.N, k)
+		if err != nil {
+			return nil, err
+		}
+		for i, mask := range masks {
+			subShards[i].Mask = mask
+		}
+	}
+
+	return subShards, nil
+}
+
+// MergeShards combines two shards of the same key into a single shard, so that a holder can be decommissioned by handing
+// their shard to another holder over a secure channel. The merged shard signs in place of both of the original shards,
+// and keeps the index of the first one. Both shards must have known, distinct indices.
+//
+// Additive shards are merged by adding them, along with their masks, and multiplicative shards by multiplying them. In
+// either case the original shards should be destroyed once they have been merged, and shard commitments must be reissued
+func MergeShards(shard, other *PrivateKeyShard) (*PrivateKeyShard, error) {
+	if shard.PublicKey.N.Cmp(other.PublicKey.N) != 0 || shard.PublicKey.E != other.PublicKey.E {
+		return nil, errorf(ErrKeyMismatch, "cannot merge shards of different keys")
+	}
+	if shard.SplitBy != other.SplitBy {
+		return nil, errorf(ErrUnsupportedSplitBy, "cannot merge a shard split by %v with a shard split by %v", shard.SplitBy, other.SplitBy)
+	}
+	if shard.Index < 1 || other.Index < 1 {
+		return nil, errorf(ErrInvalidShard, "cannot merge shards without known indices")
+	}
+	if shard.Index == other.Index {
+		return nil, errorf(ErrInvalidShard, "cannot merge shard %d with itself", shard.Index)
+	}
+	if err := shard.checkUsable(); err != nil {
+		return nil, err
+	}
+	if err := other.checkUsable(); err != nil {
+		return nil, err
+	}
+
+	d := new(big.Int)
+	var mask *big.Int
+	switch shard.SplitBy {
+	case Addition:
+		d.Add(shard.D, other.D)
+		if shard.Mask != nil || other.Mask != nil {
+			mask = new(big.Int)
+			for _, m := range []*big.Int{shard.Mask, other.Mask} {
+				if m != nil {
+					mask.Add(mask, m)
+				}
+			}
+		}
+	case Multiplication:
+		if shard.Mask != nil || other.Mask != nil {
+			return nil, errorf(ErrInvalidShard, "multiplicative shards cannot be masked")
+		}
+		d.Mul(shard.D, other.D)
+	default:
+		return nil, checkSplitBy(shard.SplitBy)
+	}
+
+	return &PrivateKeyShard{
+		PublicKey: shard.PublicKey,
+		D:         d,
+		SplitBy:   shard.SplitBy,
+		Index:     shard.Index,
+		Mask:      mask,
+	}, nil
+}
+
+// the number of bits by which a random integer must be longer than a secret for their sum to hide the secret statistically
+const hidingBits = 128
+
+// returns a random integer in [0, 2^(b+hidingBits)), where b is the length of the longer of x and y, so that it hides either
+func randomHiding(x, y *big.Int) (*big.Int, error) {
+	bits := x.BitLen()
+	if y.BitLen() > bits {
+		bits = y.BitLen()
+	}
+	r, err := rand.Int(rand.Reader, new(big.Int).Lsh(bigOne, uint(bits+hidingBits)))
+	if err != nil {
+		return nil, &RandomnessError{Err: err}
+	}
+	return r, nil
+}
+
+// returns k integers that add up to n, the first k-1 of which are random and long enough to hide n (see randomHiding)
+func splitInteger(n, modulus *big.Int, k int) ([]*big.Int, error) {
+	parts := make([]*big.Int, k)
+	remaining := new(big.Int).Set(n)
+	for i := 0; i < k-1; i++ {
+		part, err := randomHiding(n, modulus)
+		if err != nil {
+			return nil, err
+		}
+		remaining.Sub(remaining, part)
+		parts[i] = part
+	}
+	parts[k-1] = remaining
+	return parts, nil
+}
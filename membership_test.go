@@ -0,0 +1,161 @@
+package keysplitting
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha512"
+	"errors"
+	"math/big"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Changing membership", func() {
+	hashed := sha512.Sum512([]byte("TEST MESSAGE"))
+	priv, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	When("Splitting an additive shard", Ordered, func() {
+		var shards, subShards []*PrivateKeyShard
+
+		BeforeAll(func() {
+			var err error
+			shards, _ = SplitD(priv, 2, Addition)
+			subShards, err = SplitShard(shards[1], 3, 3)
+			Expect(err).To(BeNil())
+		})
+
+		It("Produces sub-shards that add up to the original", func() {
+			Expect(subShards).To(HaveLen(3))
+			Expect(shardSum(subShards).Cmp(shards[1].D)).To(Equal(0))
+		})
+
+		It("Draws sub-shards from a range far larger than the modulus", func() {
+			for _, subShard := range subShards[:2] {
+				Expect(subShard.D.BitLen()).To(BeNumerically(">", priv.N.BitLen()))
+			}
+		})
+
+		It("Numbers the sub-shards from the next free index", func() {
+			Expect(subShards[0].Index).To(Equal(shards[1].Index))
+			Expect(subShards[1].Index).To(Equal(3))
+			Expect(subShards[2].Index).To(Equal(4))
+		})
+
+		It("Produces a valid signature with the sub-shards in place of the original", func() {
+			sig := signBrokered(&priv.PublicKey, append([]*PrivateKeyShard{shards[0]}, subShards...), hashed[:])
+			Expect(rsa.VerifyPKCS1v15(&priv.PublicKey, crypto.SHA512, hashed[:], sig)).To(Succeed())
+		})
+
+		It("Produces a valid signature when signing sequentially", func() {
+			partialSig, err := SignFirst(rand.Reader, shards[0], crypto.SHA512, hashed[:])
+			Expect(err).To(BeNil())
+			for _, subShard := range subShards {
+				partialSig, err = SignNext(rand.Reader, subShard, crypto.SHA512, hashed[:], partialSig)
+				Expect(err).To(BeNil())
+			}
+			Expect(rsa.VerifyPKCS1v15(&priv.PublicKey, crypto.SHA512, hashed[:], partialSig.Sig)).To(Succeed())
+		})
+
+		It("Does not produce a valid signature without every sub-shard", func() {
+			sig := signBrokered(&priv.PublicKey, []*PrivateKeyShard{shards[0], subShards[0], subShards[1]}, hashed[:])
+			Expect(rsa.VerifyPKCS1v15(&priv.PublicKey, crypto.SHA512, hashed[:], sig)).NotTo(Succeed())
+		})
+	})
+
+	When("Splitting a multiplicative shard", func() {
+		It("Fails", func() {
+			shards, _ := SplitD(priv, 2, Multiplication)
+			_, err := SplitShard(shards[0], 2, 3)
+			Expect(err).NotTo(BeNil())
+		})
+	})
+
+	When("Splitting into indices that are already in use", func() {
+		It("Fails", func() {
+			shards, _ := SplitD(priv, 2, Addition)
+			_, err := SplitShard(shards[1], 3, 1)
+			Expect(errors.Is(err, ErrInvalidShard)).To(BeTrue())
+			_, err = SplitShard(shards[1], 2, 0)
+			Expect(errors.Is(err, ErrInvalidShard)).To(BeTrue())
+		})
+	})
+
+	When("Merging additive shards", func() {
+		It("Produces a valid signature with the merged shard in place of both originals", func() {
+			shards, _ := SplitD(priv, 3, Addition)
+			merged, err := MergeShards(shards[1], shards[2])
+			Expect(err).To(BeNil())
+			Expect(merged.Index).To(Equal(shards[1].Index))
+
+			sig := signBrokered(&priv.PublicKey, []*PrivateKeyShard{shards[0], merged}, hashed[:])
+			Expect(rsa.VerifyPKCS1v15(&priv.PublicKey, crypto.SHA512, hashed[:], sig)).To(Succeed())
+		})
+	})
+
+	When("Merging multiplicative shards", func() {
+		It("Produces a valid signature with the merged shard in place of both originals", func() {
+			shards, _ := SplitD(priv, 3, Multiplication)
+			merged, err := MergeShards(shards[0], shards[2])
+			Expect(err).To(BeNil())
+
+			partialSig, err := SignFirst(rand.Reader, merged, crypto.SHA512, hashed[:])
+			Expect(err).To(BeNil())
+			partialSig, err = SignNext(rand.Reader, shards[1], crypto.SHA512, hashed[:], partialSig)
+			Expect(err).To(BeNil())
+			Expect(rsa.VerifyPKCS1v15(&priv.PublicKey, crypto.SHA512, hashed[:], partialSig.Sig)).To(Succeed())
+		})
+	})
+
+	When("Merging incompatible shards", func() {
+		It("Fails if the shards belong to different keys", func() {
+			otherPriv, _ := rsa.GenerateKey(rand.Reader, 2048)
+			shards, _ := SplitD(priv, 2, Addition)
+			otherShards, _ := SplitD(otherPriv, 2, Addition)
+
+			_, err := MergeShards(shards[0], otherShards[1])
+			Expect(err).NotTo(BeNil())
+		})
+
+		It("Fails if the shards were split differently", func() {
+			addShards, _ := SplitD(priv, 2, Addition)
+			mulShards, _ := SplitD(priv, 2, Multiplication)
+
+			_, err := MergeShards(addShards[0], mulShards[1])
+			Expect(err).NotTo(BeNil())
+		})
+
+		It("Fails if a shard is merged with itself", func() {
+			shards, _ := SplitD(priv, 2, Addition)
+
+			_, err := MergeShards(shards[0], shards[0])
+			Expect(err).NotTo(BeNil())
+		})
+
+		It("Fails if the shards have the same or unknown indices", func() {
+			shards, _ := SplitD(priv, 3, Addition)
+
+			sameIndex := *shards[1]
+			sameIndex.Index = shards[0].Index
+			_, err := MergeShards(shards[0], &sameIndex)
+			Expect(errors.Is(err, ErrInvalidShard)).To(BeTrue())
+
+			unknown, otherUnknown := *shards[0], *shards[1]
+			unknown.Index, otherUnknown.Index = 0, 0
+			_, err = MergeShards(&unknown, &otherUnknown)
+			Expect(errors.Is(err, ErrInvalidShard)).To(BeTrue())
+			_, err = MergeShards(&unknown, shards[1])
+			Expect(errors.Is(err, ErrInvalidShard)).To(BeTrue())
+		})
+
+		It("Fails if multiplicative shards are masked", func() {
+			shards, _ := SplitD(priv, 2, Multiplication)
+
+			masked := *shards[1]
+			masked.Mask = big.NewInt(1)
+			_, err := MergeShards(shards[0], &masked)
+			Expect(errors.Is(err, ErrInvalidShard)).To(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,151 @@
+package keysplitting
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+	"io"
+)
+
+// A MessageSet is a list of digests, such as those of every artifact of a release, that each shard holder approves once
+// and then signs together. The set is identified by its [MessageSet.Commitment], which is what a custodian reviews and
+// approves, and [MessageSet.Sign] refuses to sign a set whose commitment differs from the approved one.
+//
+// Every partial signature in the set is bound to a session nonce derived from the commitment and the digest's position in
+// it, so a [Session] from [MessageSet.NewSessions] only accepts partial signatures made for its own digest of the approved
+// set. A partial signature can't be diverted to a digest outside of it, or to a different position within it
+type MessageSet struct {
+	Opts    crypto.SignerOpts // the signature scheme of every message, i.e. a crypto.Hash or *PSSOptions
+	Digests [][]byte          // the hashed messages, each hashed with Opts.HashFunc()
+	Nonce   []byte            // makes the set unique, so that approving it again is a separate decision
+}
+
+// NewMessageSet returns a set of the given digests, which must be the result of hashing each message with opts.HashFunc()
+func NewMessageSet(random io.Reader, opts crypto.SignerOpts, digests ...[]byte) (*MessageSet, error) {
+	nonce, err := NewNonce(random)
+	if err != nil {
+		return nil, err
+	}
+	ms := &MessageSet{Opts: opts, Digests: make([][]byte, len(digests)), Nonce: nonce}
+	for i, digest := range digests {
+		ms.Digests[i] = append([]byte{}, digest...)
+	}
+	if err := ms.check(); err != nil {
+		return nil, err
+	}
+	return ms, nil
+}
+
+// Commitment returns the SHA-256 hash that identifies the set, computed from its signature scheme, nonce, and every digest in order
+func (ms *MessageSet) Commitment() []byte {
+	h := sha256.New()
+	h.Write([]byte("keysplitting message set"))
+	writeLengthPrefixed(h, ms.Nonce)
+	var hashFn crypto.Hash
+	if ms.Opts != nil {
+		hashFn = ms.Opts.HashFunc()
+	}
+	writeUint64(h, uint64(hashFn))
+	if pssOpts, ok := ms.Opts.(*PSSOptions); ok {
+		writeLengthPrefixed(h, pssOpts.Salt)
+	} else {
+		writeUint64(h, 0)
+	}
+	writeUint64(h, uint64(len(ms.Digests)))
+	for _, digest := range ms.Digests {
+		writeLengthPrefixed(h, digest)
+	}
+	return h.Sum(nil)
+}
+
+// SignerOpts returns the options that each party must sign the i'th digest with, i.e. the set's options bound to the
+// session nonce of that digest
+func (ms *MessageSet) SignerOpts(i int) *NonceOptions {
+	return &NonceOptions{Opts: ms.Opts, Nonce: messageNonce(ms.Commitment(), i)}
+}
+
+// Sign has signer sign every digest in the set, provided that the set's commitment is approved. If previous is nil, signer
+// signs first as with [SignFirst], otherwise it must hold a partial signature for each digest, which signer extends as with [SignNext]
+func (ms *MessageSet) Sign(random io.Reader, signer ShardSigner, approved []byte, previous []*PartialSignature) ([]*PartialSignature, error) {
+	if err := ms.check(); err != nil {
+		return nil, err
+	}
+	commitment := ms.Commitment()
+	if subtle.ConstantTimeCompare(commitment, approved) != 1 {
+		return nil, errorf(ErrInvalidPartialSignature, "message set does not match the approved commitment")
+	}
+	if previous != nil && len(previous) != len(ms.Digests) {
+		return nil, errorf(ErrInvalidPartialSignature, "message set has %d digests, but %d partial signatures were given", len(ms.Digests), len(previous))
+	}
+
+	partials := make([]*PartialSignature, len(ms.Digests))
+	for i, digest := range ms.Digests {
+		opts := &NonceOptions{Opts: ms.Opts, Nonce: messageNonce(commitment, i)}
+		var err error
+		if previous == nil {
+			partials[i], err = signer.SignFirst(random, opts, digest)
+		} else {
+			partials[i], err = signer.SignNext(random, opts, digest, previous[i])
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return partials, nil
+}
+
+// NewSessions starts a [Session] for each digest in the set, in order, in which k shards of pub split by splitBy sign it.
+// Each session only accepts partial signatures bound to its own digest's nonce (see [MessageSet.SignerOpts])
+func (ms *MessageSet) NewSessions(pub *rsa.PublicKey, splitBy SplitBy, k int) ([]*Session, error) {
+	if err := ms.check(); err != nil {
+		return nil, err
+	}
+	commitment := ms.Commitment()
+	sessions := make([]*Session, len(ms.Digests))
+	for i, digest := range ms.Digests {
+		sess, err := newSession(pub, splitBy, k, ms.Opts, digest, messageNonce(commitment, i))
+		if err != nil {
+			return nil, err
+		}
+		sessions[i] = sess
+	}
+	return sessions, nil
+}
+
+// checks that the set is well formed, e.g. after it has been received from a broker
+func (ms *MessageSet) check() error {
+	if len(ms.Digests) == 0 {
+		return errorf(ErrInvalidPartialSignature, "message set has no digests")
+	}
+	if len(ms.Nonce) != NonceSize {
+		return errorf(ErrInvalidPartialSignature, "message set nonce is %d bytes long, but must be %d bytes long", len(ms.Nonce), NonceSize)
+	}
+	for _, digest := range ms.Digests {
+		if err := checkSessionOpts(ms.Opts, digest); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// returns the session nonce of the i'th digest of the set with the given commitment
+func messageNonce(commitment []byte, i int) []byte {
+	h := sha256.New()
+	h.Write([]byte("keysplitting message set nonce"))
+	h.Write(commitment)
+	writeUint64(h, uint64(i))
+	return h.Sum(nil)
+}
+
+func writeUint64(w io.Writer, n uint64) {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], n)
+	w.Write(b[:])
+}
+
+func writeLengthPrefixed(w io.Writer, b []byte) {
+	writeUint64(w, uint64(len(b)))
+	w.Write(b)
+}
@@ -0,0 +1,114 @@
+package keysplitting
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MessageSet", func() {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	additive, _ := SplitD(key, 2, Addition)
+	multiplicative, _ := SplitD(key, 2, Multiplication)
+	digests := func(messages ...string) [][]byte {
+		digests := make([][]byte, len(messages))
+		for i, message := range messages {
+			digest := sha256.Sum256([]byte(message))
+			digests[i] = digest[:]
+		}
+		return digests
+	}
+	release := digests("artifact 1", "artifact 2", "artifact 3")
+
+	It("Signs every message with a single approval per shard", func() {
+		for _, shards := range [][]*PrivateKeyShard{additive, multiplicative} {
+			ms, err := NewMessageSet(rand.Reader, crypto.SHA256, release...)
+			Expect(err).To(BeNil())
+			approved := ms.Commitment()
+			sessions, err := ms.NewSessions(&key.PublicKey, shards[0].SplitBy, len(shards))
+			Expect(err).To(BeNil())
+
+			partials, err := ms.Sign(rand.Reader, shards[0], approved, nil)
+			Expect(err).To(BeNil())
+			partials, err = ms.Sign(rand.Reader, shards[1], approved, partials)
+			Expect(err).To(BeNil())
+
+			for i, session := range sessions {
+				Expect(session.AddPartial(partials[i])).To(Succeed())
+				sig, err := session.Signature()
+				Expect(err).To(BeNil())
+				Expect(rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, release[i], sig)).To(Succeed())
+			}
+		}
+	})
+
+	It("Combines brokered partial signatures", func() {
+		salt, err := NewPSSSalt(rand.Reader, &key.PublicKey, crypto.SHA256, nil)
+		Expect(err).To(BeNil())
+		ms, err := NewMessageSet(rand.Reader, &PSSOptions{Hash: crypto.SHA256, Salt: salt}, release...)
+		Expect(err).To(BeNil())
+
+		first, err := ms.Sign(rand.Reader, additive[0], ms.Commitment(), nil)
+		Expect(err).To(BeNil())
+		second, err := ms.Sign(rand.Reader, additive[1], ms.Commitment(), nil)
+		Expect(err).To(BeNil())
+		for i := range release {
+			sig, err := CombinePartialSignatures(&key.PublicKey, first[i], second[i])
+			Expect(err).To(BeNil())
+			Expect(rsa.VerifyPSS(&key.PublicKey, crypto.SHA256, release[i], sig, nil)).To(Succeed())
+		}
+	})
+
+	It("Refuses to sign a set that wasn't approved", func() {
+		ms, err := NewMessageSet(rand.Reader, crypto.SHA256, release...)
+		Expect(err).To(BeNil())
+		approved := ms.Commitment()
+
+		ms.Digests = append(ms.Digests, digests("unapproved")...)
+		_, err = ms.Sign(rand.Reader, additive[0], approved, nil)
+		Expect(errors.Is(err, ErrInvalidPartialSignature)).To(BeTrue())
+
+		other, err := NewMessageSet(rand.Reader, crypto.SHA256, release...)
+		Expect(err).To(BeNil())
+		Expect(other.Commitment()).ToNot(Equal(approved))
+		_, err = other.Sign(rand.Reader, additive[0], approved, nil)
+		Expect(errors.Is(err, ErrInvalidPartialSignature)).To(BeTrue())
+	})
+
+	It("Refuses partial signatures diverted to another digest", func() {
+		ms, err := NewMessageSet(rand.Reader, crypto.SHA256, release...)
+		Expect(err).To(BeNil())
+		sessions, err := ms.NewSessions(&key.PublicKey, Addition, 2)
+		Expect(err).To(BeNil())
+		partials, err := ms.Sign(rand.Reader, additive[0], ms.Commitment(), nil)
+		Expect(err).To(BeNil())
+		Expect(errors.Is(sessions[1].AddPartial(partials[0]), ErrInvalidPartialSignature)).To(BeTrue())
+
+		// a partial signature outside of the set isn't bound to any of its nonces
+		session, err := NewSession(&key.PublicKey, Addition, 2, crypto.SHA256, release[0])
+		Expect(err).To(BeNil())
+		Expect(session.AddPartial(partials[0])).ToNot(Succeed())
+		outside, err := SignFirst(rand.Reader, additive[0], crypto.SHA256, release[0])
+		Expect(err).To(BeNil())
+		Expect(sessions[0].AddPartial(outside)).ToNot(Succeed())
+	})
+
+	It("Rejects malformed sets", func() {
+		_, err := NewMessageSet(rand.Reader, crypto.SHA256)
+		Expect(errors.Is(err, ErrInvalidPartialSignature)).To(BeTrue())
+		_, err = NewMessageSet(rand.Reader, crypto.SHA512, release...)
+		Expect(errors.Is(err, ErrUnsupportedHash)).To(BeTrue())
+		_, err = NewMessageSet(rand.Reader, &NonceOptions{Opts: crypto.SHA256}, release...)
+		Expect(errors.Is(err, ErrUnsupportedHash)).To(BeTrue())
+
+		ms, err := NewMessageSet(rand.Reader, crypto.SHA256, release...)
+		Expect(err).To(BeNil())
+		_, err = ms.Sign(rand.Reader, additive[1], ms.Commitment(), []*PartialSignature{nil})
+		Expect(errors.Is(err, ErrInvalidPartialSignature)).To(BeTrue())
+	})
+})
@@ -0,0 +1,64 @@
+package keysplitting
+
+import (
+	"sync"
+	"time"
+)
+
+// Metrics receives measurements of the signatures produced by this package. Every method is called synchronously from
+// whichever goroutine did the work, so implementations must be safe for concurrent use and should not block for long.
+// The prommetrics subpackage implements it with Prometheus collectors
+type Metrics interface {
+	// ObservePartialSignature is called after each attempt to produce a partial signature with a shard, e.g. with
+	// [SignFirst], [SignNext] or [SignThreshold], with how long it took and why it failed, if it did
+	ObservePartialSignature(d time.Duration, err error)
+	// ObserveCombine is called after each attempt to complete a signature, whether by combining partial signatures, e.g. with
+	// [CombinePartialSignatures] or [CombineThreshold], or at the end of the chain of partial signatures in [SignSequential]
+	ObserveCombine(d time.Duration, err error)
+	// AddActiveSessions adds delta to the number of signing sessions in progress, such as calls to [SignSequential] and
+	// [SignBrokered]
+	AddActiveSessions(delta int)
+}
+
+var metrics struct {
+	sync.RWMutex
+	metrics Metrics
+}
+
+// SetMetrics sets the [Metrics] that receive measurements of all subsequent operations. A nil Metrics, the default, disables them
+func SetMetrics(m Metrics) {
+	metrics.Lock()
+	defer metrics.Unlock()
+	metrics.metrics = m
+}
+
+// returns the current metrics, or nil if they are disabled
+func currentMetrics() Metrics {
+	metrics.RLock()
+	defer metrics.RUnlock()
+	return metrics.metrics
+}
+
+// records a partial signature that began at start
+func observePartialSignature(start time.Time, err error) {
+	if m := currentMetrics(); m != nil {
+		m.ObservePartialSignature(time.Since(start), err)
+	}
+}
+
+// records the completion of a signature that began at start
+func observeCombine(start time.Time, err error) {
+	if m := currentMetrics(); m != nil {
+		m.ObserveCombine(time.Since(start), err)
+	}
+}
+
+// records the start of a signing session, and returns a function that records its end
+func startSession() func() {
+	m := currentMetrics()
+	if m == nil {
+		return func() {}
+	}
+	m.AddActiveSessions(1)
+	return func() { m.AddActiveSessions(-1) }
+}
@@ -0,0 +1,182 @@
+package keysplitting
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"io"
+	"math/big"
+)
+
+// NonceSize is the length of the nonces returned by [NewNonce]
+const NonceSize = 32
+
+// NewNonce returns a random nonce that identifies a signing session (see [NonceOptions])
+func NewNonce(random io.Reader) ([]byte, error) {
+	nonce := make([]byte, NonceSize)
+	if _, err := io.ReadFull(random, nonce); err != nil {
+		return nil, &RandomnessError{Err: err}
+	}
+	return nonce, nil
+}
+
+// NonceOptions binds a partial signature to the signing session identified by Nonce, so that it can't be replayed into another
+// session for the same digest. Instead of the encoded message m, each shard signs m * r^e (mod N), where r is derived from the
+// nonce and the public key, so the parties' partial signatures only combine into m^d * r. A [Session], [Broker], or
+// [CombinePartialSignatures] removes r once every shard has signed, and a partial signature from a session with a
+// different nonce leaves the result invalid.
+//
+// The nonce is recorded in each [PartialSignature], and [SignNext] refuses to extend a partial signature from another session
+type NonceOptions struct {
+	Opts  crypto.SignerOpts // the options for the underlying signature scheme, i.e. a crypto.Hash or *PSSOptions
+	Nonce []byte            // identifies the session, e.g. from [NewNonce]
+}
+
+// HashFunc returns opts.Opts.HashFunc() so that NonceOptions implements [crypto.SignerOpts]
+func (opts *NonceOptions) HashFunc() crypto.Hash {
+	if opts.Opts == nil {
+		return 0
+	}
+	return opts.Opts.HashFunc()
+}
+
+// returns opts bound to a fresh session nonce, unless it already is
+func withNonce(opts crypto.SignerOpts) (crypto.SignerOpts, error) {
+	if opts == nil {
+		return nil, errorf(ErrUnsupportedHash, "no signer options provided")
+	}
+	if _, ok := opts.(*NonceOptions); ok {
+		return opts, nil
+	}
+	nonce, err := NewNonce(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return &NonceOptions{Opts: opts, Nonce: nonce}, nil
+}
+
+// returns the nonce that opts binds signatures to, or nil if it doesn't
+func nonceOf(opts crypto.SignerOpts) []byte {
+	if o, ok := opts.(*NonceOptions); ok {
+		return o.Nonce
+	}
+	return nil
+}
+
+// returns the options for the signature scheme underlying o
+func (o *NonceOptions) unwrap() (crypto.SignerOpts, error) {
+	if len(o.Nonce) == 0 {
+		return nil, errorf(ErrInvalidPartialSignature, "session nonce is empty")
+	}
+	switch o.Opts.(type) {
+	case nil:
+		return nil, errorf(ErrUnsupportedHash, "no signer options provided")
+	case *NonceOptions:
+		return nil, errorf(ErrUnsupportedHash, "a signature can only be bound to one session nonce")
+	}
+	return o.Opts, nil
+}
+
+// checks that ps belongs to the session identified by nonce
+func (ps *PartialSignature) checkNonce(nonce []byte) error {
+	if !bytes.Equal(ps.Nonce, nonce) {
+		return errorf(ErrInvalidPartialSignature, "partial signature belongs to a different signing session")
+	}
+	return nil
+}
+
+// returns the factor r that a session nonce contributes to a complete signature under pub
+func nonceFactor(pub *rsa.PublicKey, nonce []byte) (*big.Int, error) {
+	seed := append([]byte("keysplitting session nonce"), pub.N.Bytes()...)
+	seed = append(seed, nonce...)
+
+	// draw 128 extra bits so that r is close to uniform mod N
+	out := make([]byte, pub.Size()+16)
+	mgf1XOR(out, sha256.New(), seed)
+	r := new(big.Int).SetBytes(out)
+	r.Mod(r, pub.N)
+
+	if r.Sign() == 0 || new(big.Int).GCD(nil, nil, r, pub.N).Cmp(bigOne) != 0 {
+		return nil, errorf(ErrInvalidPartialSignature, "session nonce is unusable with this key")
+	}
+	return r, nil
+}
+
+// returns m * r^e (mod N), the value that shards sign in the session identified by nonce
+func bindNonce(pub *rsa.PublicKey, nonce []byte, m *big.Int) (*big.Int, error) {
+	r, err := nonceFactor(pub, nonce)
+	if err != nil {
+		return nil, err
+	}
+	r.Exp(r, big.NewInt(int64(pub.E)), pub.N)
+	return r.Mul(r, m).Mod(r, pub.N), nil
+}
+
+// removes the session nonce's factor r from a complete signature, i.e. returns sig * r^-1 (mod N)
+func unbindNonce(pub *rsa.PublicKey, nonce []byte, sig []byte) ([]byte, error) {
+	r, err := nonceFactor(pub, nonce)
+	if err != nil {
+		return nil, err
+	}
+	r.ModInverse(r, pub.N)
+	r.Mul(r, new(big.Int).SetBytes(sig)).Mod(r, pub.N)
+	return r.FillBytes(make([]byte, pub.Size())), nil
+}
+
+// returns the encoded message that is exponentiated to sign hashed using the scheme selected by opts
+func encodeMessage(pub *rsa.PublicKey, opts crypto.SignerOpts, hashed []byte) ([]byte, error) {
+	switch o := opts.(type) {
+	case nil:
+		return nil, errorf(ErrUnsupportedHash, "no signer options provided")
+	case *PSSOptions:
+		if err := checkHashAvailable(o.Hash); err != nil {
+			return nil, err
+		}
+		return emsaPSSEncode(hashed, pub.N.BitLen()-1, o.Salt, o.Hash.New())
+	case *rsa.PSSOptions:
+		return nil, errorf(ErrUnsupportedHash, "split PSS signatures require a shared salt, which *rsa.PSSOptions cannot carry; use *keysplitting.PSSOptions instead")
+	case *NonceOptions:
+		return nil, errorf(ErrUnsupportedHash, "a signature can only be bound to one session nonce")
+	default:
+		if opts.HashFunc() == 0 {
+			if err := checkRawLength(pub, hashed); err != nil {
+				return nil, err
+			}
+		}
+		return emsaPKCS1v15Encode(opts.HashFunc(), hashed, pub.Size())
+	}
+}
+
+// returns the shard's own signature on hashed, bound to the session nonce given by opts
+func signFirstWithNonce(shard *PrivateKeyShard, opts *NonceOptions, hashed []byte) ([]byte, error) {
+	inner, err := opts.unwrap()
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSplitBy(shard.SplitBy); err != nil {
+		return nil, err
+	}
+	if err := shard.checkUsable(); err != nil {
+		return nil, err
+	}
+
+	em, err := encodeMessage(shard.PublicKey, inner, hashed)
+	if err != nil {
+		return nil, err
+	}
+	m, err := bindNonce(shard.PublicKey, opts.Nonce, new(big.Int).SetBytes(em))
+	if err != nil {
+		return nil, err
+	}
+
+	// masks must be derived from the same inputs as for an unbound signature, so that every shard agrees on them
+	var exponent *big.Int
+	if pssOpts, ok := inner.(*PSSOptions); ok {
+		exponent = shard.exponent(hashed, pssOpts.Salt)
+	} else {
+		exponent = shard.exponent(hashed)
+	}
+	return m.Exp(m, exponent, shard.PublicKey.N).FillBytes(make([]byte, shard.PublicKey.Size())), nil
+}
@@ -0,0 +1,181 @@
+package keysplitting
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"math/big"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Session nonces", func() {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	hashed := sha256.Sum256([]byte("TEST MESSAGE"))
+
+	newOpts := func(opts crypto.SignerOpts) *NonceOptions {
+		nonce, err := NewNonce(rand.Reader)
+		Expect(err).To(BeNil())
+		Expect(nonce).To(HaveLen(NonceSize))
+		return &NonceOptions{Opts: opts, Nonce: nonce}
+	}
+
+	signChain := func(signers []ShardSigner, opts crypto.SignerOpts) *PartialSignature {
+		partialSig, err := signers[0].SignFirst(rand.Reader, opts, hashed[:])
+		Expect(err).To(BeNil())
+		for _, signer := range signers[1:] {
+			partialSig, err = signer.SignNext(rand.Reader, opts, hashed[:], partialSig)
+			Expect(err).To(BeNil())
+		}
+		return partialSig
+	}
+
+	for _, splitBy := range []SplitBy{Addition, Multiplication} {
+		splitBy := splitBy
+
+		It("Binds sequential signatures to the nonce with shards split by "+string(splitBy), func() {
+			shards, err := SplitD(key, 3, splitBy)
+			Expect(err).To(BeNil())
+			signers := []ShardSigner{shards[0], shards[1], shards[2]}
+			opts := newOpts(crypto.SHA256)
+
+			partialSig := signChain(signers, opts)
+			Expect(partialSig.Nonce).To(Equal(opts.Nonce))
+			Expect(rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, hashed[:], partialSig.Sig)).NotTo(Succeed())
+
+			sig, err := unbindNonce(&key.PublicKey, opts.Nonce, partialSig.Sig)
+			Expect(err).To(BeNil())
+			Expect(rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, hashed[:], sig)).To(Succeed())
+		})
+
+		It("Binds signatures exponentiated elsewhere in the same way with shards split by "+string(splitBy), func() {
+			shards, err := SplitD(key, 3, splitBy)
+			Expect(err).To(BeNil())
+			signers := make([]ShardSigner, len(shards))
+			for i, shard := range shards {
+				shard := shard
+				signers[i] = &exponentiationSigner{
+					shard: &PrivateKeyShard{PublicKey: shard.PublicKey, SplitBy: shard.SplitBy, Index: shard.Index},
+					exp: func(m *big.Int) (*big.Int, error) {
+						return new(big.Int).Exp(m, shard.D, shard.PublicKey.N), nil
+					},
+				}
+			}
+			opts := newOpts(crypto.SHA256)
+
+			Expect(signChain(signers, opts)).To(Equal(signChain([]ShardSigner{shards[0], shards[1], shards[2]}, opts)))
+		})
+	}
+
+	It("Removes the nonce when combining brokered signatures", func() {
+		shards, err := SplitDWithOptions(key, 3, Addition, &SplitOptions{Mask: true})
+		Expect(err).To(BeNil())
+		salt, err := NewPSSSalt(rand.Reader, &key.PublicKey, crypto.SHA256, nil)
+		Expect(err).To(BeNil())
+
+		for _, opts := range []*NonceOptions{newOpts(crypto.SHA256), newOpts(&PSSOptions{Hash: crypto.SHA256, Salt: salt})} {
+			partials := make([]*PartialSignature, len(shards))
+			for i, shard := range shards {
+				partials[i], err = SignFirst(rand.Reader, shard, opts, hashed[:])
+				Expect(err).To(BeNil())
+			}
+
+			sig, err := CombinePartialSignatures(&key.PublicKey, partials...)
+			Expect(err).To(BeNil())
+			if _, ok := opts.Opts.(*PSSOptions); ok {
+				Expect(rsa.VerifyPSS(&key.PublicKey, crypto.SHA256, hashed[:], sig, nil)).To(Succeed())
+			} else {
+				Expect(rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, hashed[:], sig)).To(Succeed())
+			}
+		}
+	})
+
+	It("Refuses to mix partial signatures from different sessions", func() {
+		shards, err := SplitD(key, 2, Addition)
+		Expect(err).To(BeNil())
+		opts := newOpts(crypto.SHA256)
+
+		first, err := SignFirst(rand.Reader, shards[0], opts, hashed[:])
+		Expect(err).To(BeNil())
+		for _, otherOpts := range []crypto.SignerOpts{newOpts(crypto.SHA256), crypto.SHA256} {
+			_, err = SignNext(rand.Reader, shards[1], otherOpts, hashed[:], first)
+			Expect(errors.Is(err, ErrInvalidPartialSignature)).To(BeTrue())
+
+			second, err := SignFirst(rand.Reader, shards[1], otherOpts, hashed[:])
+			Expect(err).To(BeNil())
+			_, err = CombinePartialSignatures(&key.PublicKey, first, second)
+			Expect(errors.Is(err, ErrInvalidPartialSignature)).To(BeTrue())
+		}
+	})
+
+	It("Verifies partial signatures bound to a nonce", func() {
+		shards, commitments, err := SplitDWithCommitments(key, 2, nil)
+		Expect(err).To(BeNil())
+
+		opts := newOpts(crypto.SHA256)
+		partialSig, err := SignFirst(rand.Reader, shards[0], opts, hashed[:])
+		Expect(err).To(BeNil())
+		Expect(commitments.Prove(rand.Reader, shards[0], opts, hashed[:], partialSig)).To(Succeed())
+		Expect(VerifyPartialSignature(commitments, crypto.SHA256, hashed[:], partialSig)).To(Succeed())
+
+		partialSig.Nonce = newOpts(crypto.SHA256).Nonce
+		Expect(VerifyPartialSignature(commitments, crypto.SHA256, hashed[:], partialSig)).NotTo(Succeed())
+	})
+
+	It("Preserves the nonce when encoding partial signatures and requests", func() {
+		shards, err := SplitD(key, 2, Addition)
+		Expect(err).To(BeNil())
+		opts := newOpts(crypto.SHA256)
+		partialSig, err := SignFirst(rand.Reader, shards[0], opts, hashed[:])
+		Expect(err).To(BeNil())
+
+		encoded, err := json.Marshal(partialSig)
+		Expect(err).To(BeNil())
+		var fromJSON PartialSignature
+		Expect(json.Unmarshal(encoded, &fromJSON)).To(Succeed())
+		Expect(fromJSON.Nonce).To(Equal(opts.Nonce))
+
+		envelope, err := partialSig.EncodeEnvelope(&key.PublicKey)
+		Expect(err).To(BeNil())
+		fromEnvelope, err := DecodeEnvelope(envelope, &key.PublicKey)
+		Expect(err).To(BeNil())
+		Expect(fromEnvelope.Nonce).To(Equal(opts.Nonce))
+
+		req, err := NewSigningRequest(&key.PublicKey, opts, hashed[:])
+		Expect(err).To(BeNil())
+		Expect(req.Nonce).To(Equal(opts.Nonce))
+		Expect(req.next(partialSig).signerOpts()).To(Equal(opts))
+	})
+
+	It("Binds remote signatures to a fresh nonce", func() {
+		shards, err := SplitD(key, 2, Addition)
+		Expect(err).To(BeNil())
+		remotes := make([]RemoteShard, len(shards))
+		for i, shard := range shards {
+			remotes[i], err = NewLocalShard(shard)
+			Expect(err).To(BeNil())
+		}
+
+		for _, sign := range []func(context.Context, *rsa.PublicKey, crypto.SignerOpts, []byte, ...RemoteShard) ([]byte, error){SignSequential, SignBrokered} {
+			sig, err := sign(context.Background(), &key.PublicKey, crypto.SHA256, hashed[:], remotes...)
+			Expect(err).To(BeNil())
+			Expect(rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, hashed[:], sig)).To(Succeed())
+		}
+	})
+
+	It("Rejects empty and nested nonces", func() {
+		shards, err := SplitD(key, 2, Addition)
+		Expect(err).To(BeNil())
+
+		_, err = SignFirst(rand.Reader, shards[0], &NonceOptions{Opts: crypto.SHA256}, hashed[:])
+		Expect(errors.Is(err, ErrInvalidPartialSignature)).To(BeTrue())
+
+		_, err = SignFirst(rand.Reader, shards[0], &NonceOptions{Opts: newOpts(crypto.SHA256), Nonce: []byte("nonce")}, hashed[:])
+		Expect(errors.Is(err, ErrUnsupportedHash)).To(BeTrue())
+	})
+})
@@ -0,0 +1,129 @@
+/*
+Package openpgp makes OpenPGP (RFC 4880) signatures with a split key, and exports it as an OpenPGP public key. It is a
+separate package so that keysplitting doesn't depend on github.com/ProtonMail/go-crypto
+*/
+package openpgp
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rsa"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	"github.com/bastionzero/keysplitting"
+)
+
+// the OpenPGP hash algorithm IDs (RFC 4880, section 9.4) of the hash functions a split key signs with
+var hashes = map[crypto.Hash]uint8{
+	crypto.SHA256: 8,
+	crypto.SHA384: 9,
+	crypto.SHA512: 10,
+}
+
+// A Signer produces OpenPGP signatures with a split key through a [crypto.Signer] for it, such as a
+// keysplitting.RemoteSigner. The packets are built and serialized by github.com/ProtonMail/go-crypto's openpgp/packet, which signs with
+// the crypto.Signer through a packet.PrivateKey.
+//
+// An OpenPGP key is identified by its fingerprint, which covers the time the key was created as well as the public key, so
+// the same creation time must be given every time the key is used
+type Signer struct {
+	key *packet.PrivateKey
+}
+
+// NewSigner returns a Signer for the OpenPGP key created at the given time that signs with signer, which must
+// have an RSA public key
+func NewSigner(signer crypto.Signer, created time.Time) (*Signer, error) {
+	pub, ok := signer.Public().(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: OpenPGP signer must have an RSA public key", keysplitting.ErrKeyMismatch)
+	}
+	// packet.NewSignerPrivateKey only accepts in-memory keys, but packet.Signature.Sign signs with any crypto.Signer for an
+	// RSA key
+	return &Signer{key: &packet.PrivateKey{PublicKey: *packet.NewRSAPublicKey(created, pub), PrivateKey: signer}}, nil
+}
+
+// Fingerprint returns the v4 fingerprint of the OpenPGP key
+func (s *Signer) Fingerprint() []byte {
+	return append([]byte(nil), s.key.Fingerprint...)
+}
+
+// KeyID returns the key ID of the OpenPGP key, i.e. the low 64 bits of its fingerprint
+func (s *Signer) KeyID() uint64 {
+	return s.key.KeyId
+}
+
+// PublicKey returns the OpenPGP key as a transferable public key, i.e. its public key packet followed by a user ID packet for
+// userID, e.g. "Alice <alice@example.com>", and a self-signature certifying the user ID, made with the split key
+func (s *Signer) PublicKey(random io.Reader, userID string) ([]byte, error) {
+	sig := s.newSignature(packet.SigTypePositiveCert, crypto.SHA512)
+	sig.FlagsValid, sig.FlagCertify, sig.FlagSign = true, true, true
+	sig.PreferredHash = []uint8{hashes[crypto.SHA512], hashes[crypto.SHA256]}
+	if err := sig.SignUserId(userID, &s.key.PublicKey, s.key, &packet.Config{Rand: random}); err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	if err := s.key.PublicKey.Serialize(&b); err != nil {
+		return nil, err
+	}
+	if err := (&packet.UserId{Id: userID}).Serialize(&b); err != nil {
+		return nil, err
+	}
+	if err := sig.Serialize(&b); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// SignDetached returns a detached binary signature packet on the message read from message, hashed with hashFn, which is
+// SHA-256, SHA-384 or SHA-512
+func (s *Signer) SignDetached(random io.Reader, message io.Reader, hashFn crypto.Hash) ([]byte, error) {
+	if _, ok := hashes[hashFn]; !ok {
+		return nil, fmt.Errorf("%w: unsupported OpenPGP hash function %v", keysplitting.ErrUnsupportedHash, hashFn)
+	}
+	if !hashFn.Available() {
+		return nil, fmt.Errorf("%w: hash function %v is unavailable", keysplitting.ErrUnsupportedHash, hashFn)
+	}
+
+	h := hashFn.New()
+	if _, err := io.Copy(h, message); err != nil {
+		return nil, err
+	}
+	sig := s.newSignature(packet.SigTypeBinary, hashFn)
+	if err := sig.Sign(h, s.key, &packet.Config{Rand: random}); err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	if err := sig.Serialize(&b); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// returns an unsigned v4 signature of the given type, issued by the key now
+func (s *Signer) newSignature(sigType packet.SignatureType, hashFn crypto.Hash) *packet.Signature {
+	keyID := s.key.KeyId
+	return &packet.Signature{
+		Version:      4,
+		SigType:      sigType,
+		PubKeyAlgo:   packet.PubKeyAlgoRSA,
+		Hash:         hashFn,
+		CreationTime: time.Now(),
+		IssuerKeyId:  &keyID,
+	}
+}
+
+// Armor returns data in OpenPGP ASCII armor with the given block type, e.g. "PGP SIGNATURE" or "PGP PUBLIC KEY BLOCK"
+func Armor(blockType string, data []byte) []byte {
+	var b bytes.Buffer
+	// writes to a bytes.Buffer can't fail
+	w, _ := armor.Encode(&b, blockType, nil)
+	w.Write(data)
+	w.Close()
+	return b.Bytes()
+}
@@ -0,0 +1,127 @@
+package openpgp
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	pgp "github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	"github.com/bastionzero/keysplitting"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestOpenPGP(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "OpenPGP Suite")
+}
+
+var _ = Describe("OpenPGP signer", func() {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	shards, _ := keysplitting.SplitD(key, 2, keysplitting.Addition)
+	remotes := make([]keysplitting.RemoteShard, len(shards))
+	for i, shard := range shards {
+		remotes[i], _ = keysplitting.NewLocalShard(shard)
+	}
+	remoteSigner, _ := keysplitting.NewRemoteSigner(&key.PublicKey, remotes...)
+	created := time.Unix(1700000000, 0)
+
+	// the split key as an OpenPGP entity, read from its exported transferable public key
+	readEntity := func(signer *Signer, userID string) *pgp.Entity {
+		pubKey, err := signer.PublicKey(rand.Reader, userID)
+		Expect(err).To(BeNil())
+		entities, err := pgp.ReadKeyRing(bytes.NewReader(pubKey))
+		Expect(err).To(BeNil())
+		Expect(entities).To(HaveLen(1))
+		return entities[0]
+	}
+
+	It("Identifies the key by its v4 fingerprint", func() {
+		signer, err := NewSigner(remoteSigner, created)
+		Expect(err).To(BeNil())
+
+		pub := packet.NewRSAPublicKey(created, &key.PublicKey)
+		Expect(signer.Fingerprint()).To(Equal(pub.Fingerprint))
+		Expect(signer.KeyID()).To(Equal(pub.KeyId))
+
+		other, err := NewSigner(remoteSigner, created.Add(time.Second))
+		Expect(err).To(BeNil())
+		Expect(other.Fingerprint()).ToNot(Equal(signer.Fingerprint()))
+	})
+
+	It("Signs detached signatures with each hash function", func() {
+		signer, err := NewSigner(remoteSigner, created)
+		Expect(err).To(BeNil())
+		keyring := pgp.EntityList{readEntity(signer, "Split Key <split@example.com>")}
+
+		message := []byte("OpenPGP signed message")
+		for _, hashFn := range []crypto.Hash{crypto.SHA256, crypto.SHA384, crypto.SHA512} {
+			sig, err := signer.SignDetached(rand.Reader, bytes.NewReader(message), hashFn)
+			Expect(err).To(BeNil())
+
+			p, err := packet.Read(bytes.NewReader(sig))
+			Expect(err).To(BeNil())
+			Expect(p).To(BeAssignableToTypeOf(&packet.Signature{}))
+			Expect(p.(*packet.Signature).Hash).To(Equal(hashFn))
+			Expect(p.(*packet.Signature).IssuerFingerprint).To(Equal(signer.Fingerprint()))
+
+			entity, err := pgp.CheckDetachedSignature(keyring, bytes.NewReader(message), bytes.NewReader(sig), nil)
+			Expect(err).To(BeNil())
+			Expect(entity.PrimaryKey.Fingerprint).To(Equal(signer.Fingerprint()))
+
+			_, err = pgp.CheckDetachedSignature(keyring, strings.NewReader("another message"), bytes.NewReader(sig), nil)
+			Expect(err).ToNot(BeNil())
+		}
+	})
+
+	It("Exports a self-signed transferable public key", func() {
+		signer, err := NewSigner(remoteSigner, created)
+		Expect(err).To(BeNil())
+
+		userID := "Split Key <split@example.com>"
+		entity := readEntity(signer, userID)
+		Expect(entity.PrimaryKey.Fingerprint).To(Equal(signer.Fingerprint()))
+		Expect(entity.PrimaryKey.CreationTime.Equal(created)).To(BeTrue())
+		Expect(entity.PrimaryKey.PublicKey).To(Equal(&key.PublicKey))
+
+		Expect(entity.Identities).To(HaveKey(userID))
+		sig := entity.Identities[userID].SelfSignature
+		Expect(sig.SigType).To(Equal(packet.SignatureType(packet.SigTypePositiveCert)))
+		Expect(sig.Hash).To(Equal(crypto.SHA512))
+		Expect(sig.FlagCertify && sig.FlagSign).To(BeTrue())
+		Expect(entity.PrimaryKey.VerifyUserIdSignature(userID, entity.PrimaryKey, sig)).To(Succeed())
+	})
+
+	It("Rejects unsupported hash functions and non-RSA keys", func() {
+		signer, err := NewSigner(remoteSigner, created)
+		Expect(err).To(BeNil())
+		_, err = signer.SignDetached(rand.Reader, strings.NewReader("message"), crypto.SHA1)
+		Expect(errors.Is(err, keysplitting.ErrUnsupportedHash)).To(BeTrue())
+
+		_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+		_, err = NewSigner(edKey, created)
+		Expect(errors.Is(err, keysplitting.ErrKeyMismatch)).To(BeTrue())
+	})
+
+	It("Armors data", func() {
+		data := bytes.Repeat([]byte{0xab}, 100)
+		armored := Armor("PGP SIGNATURE", data)
+		Expect(string(armored)).To(HavePrefix("-----BEGIN PGP SIGNATURE-----\n"))
+
+		block, err := armor.Decode(bytes.NewReader(armored))
+		Expect(err).To(BeNil())
+		Expect(block.Type).To(Equal("PGP SIGNATURE"))
+		decoded, err := io.ReadAll(block.Body)
+		Expect(err).To(BeNil())
+		Expect(decoded).To(Equal(data))
+	})
+})
@@ -0,0 +1,105 @@
+package keysplitting
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"io"
+	"math/big"
+)
+
+// In the sequential flow for additive shards, each holder receives the product of the partial signatures of the shards before
+// it, so an intermediate holder who knows the message could compare the value it receives with those it has seen before,
+// or with values from colluding holders, to learn about the contributions of the shards before it. Order blinding hides them:
+// each holder signs with [SignFirstOrderBlinded] or [SignNextOrderBlinded], which multiply a fresh random mask into the partial
+// signature they hand on, and sends the returned [OrderMask] directly to whoever combines the signature, who removes all of
+// the masks with [RemoveOrderMasks].
+//
+// A blinded partial signature doesn't verify until its masks are removed, so it can't be checked along the chain. The list of
+// signers is not hidden
+
+// An OrderMask is the random factor that a holder multiplied into a partial signature with [SignFirstOrderBlinded] or
+// [SignNextOrderBlinded]. It must only be sent to whoever removes the masks, never along the chain
+type OrderMask struct {
+	Signer int      // the index of the shard that signed with the mask
+	R      *big.Int // the random mask, invertible (mod N)
+}
+
+// SignFirstOrderBlinded is like [SignFirst], but blinds the partial signature with a random mask, which it returns to be sent
+// to whoever removes the masks. The shard must be split by [SplitBy].Addition
+func SignFirstOrderBlinded(random io.Reader, shard *PrivateKeyShard, opts crypto.SignerOpts, hashed []byte) (*PartialSignature, *OrderMask, error) {
+	if shard.SplitBy != Addition {
+		return nil, nil, errorf(ErrUnsupportedSplitBy, "order blinding requires shards split by addition")
+	}
+	partialSig, err := SignFirst(random, shard, opts, hashed)
+	if err != nil {
+		return nil, nil, err
+	}
+	return applyOrderMask(random, shard, partialSig)
+}
+
+// SignNextOrderBlinded is like [SignNext], but blinds the partial signature with a fresh random mask, which it returns to be
+// sent to whoever removes the masks. partialSig is the blinded partial signature handed on by the previous holder
+func SignNextOrderBlinded(random io.Reader, shard *PrivateKeyShard, opts crypto.SignerOpts, hashed []byte, partialSig *PartialSignature) (*PartialSignature, *OrderMask, error) {
+	if shard.SplitBy != Addition {
+		return nil, nil, errorf(ErrUnsupportedSplitBy, "order blinding requires shards split by addition")
+	}
+	next, err := SignNext(random, shard, opts, hashed, partialSig)
+	if err != nil {
+		return nil, nil, err
+	}
+	return applyOrderMask(random, shard, next)
+}
+
+// RemoveOrderMasks removes the masks of all of the signers of partialSig, a partial signature produced by
+// [SignFirstOrderBlinded] and [SignNextOrderBlinded], and returns the partial signature as it would have been produced by
+// [SignFirst] and [SignNext], i.e. the complete signature once every shard has signed. There must be exactly one mask for each
+// signer
+func RemoveOrderMasks(pub *rsa.PublicKey, partialSig *PartialSignature, masks ...*OrderMask) (*PartialSignature, error) {
+	if len(masks) != len(partialSig.Signers) {
+		return nil, errorf(ErrInvalidPartialSignature, "partial signature has %d signers, but %d masks were given", len(partialSig.Signers), len(masks))
+	}
+
+	product := big.NewInt(1)
+	seen := map[int]bool{}
+	for _, mask := range masks {
+		if seen[mask.Signer] || !partialSig.signedBy(mask.Signer) {
+			return nil, errorf(ErrInvalidPartialSignature, "mask from shard %d doesn't belong to a signer of the partial signature", mask.Signer)
+		}
+		seen[mask.Signer] = true
+		product.Mul(product, mask.R).Mod(product, pub.N)
+	}
+
+	inverse := product.ModInverse(product, pub.N)
+	if inverse == nil {
+		return nil, errorf(ErrInvalidPartialSignature, "masks have no inverse modulo N")
+	}
+	unmasked := partialSig.copy()
+	sig := new(big.Int).SetBytes(partialSig.Sig)
+	unmasked.Sig = sig.Mul(sig, inverse).Mod(sig, pub.N).FillBytes(make([]byte, pub.Size()))
+	return unmasked, nil
+}
+
+// multiplies a fresh random mask into the partial signature, which the shard has just signed
+func applyOrderMask(random io.Reader, shard *PrivateKeyShard, partialSig *PartialSignature) (*PartialSignature, *OrderMask, error) {
+	r, err := randomUnit(random, shard.PublicKey.N)
+	if err != nil {
+		return nil, nil, err
+	}
+	sig := new(big.Int).SetBytes(partialSig.Sig)
+	partialSig.Sig = sig.Mul(sig, r).Mod(sig, shard.PublicKey.N).FillBytes(make([]byte, shard.PublicKey.Size()))
+	return partialSig, &OrderMask{Signer: shard.Index, R: r}, nil
+}
+
+// returns a random value that is invertible (mod n)
+func randomUnit(random io.Reader, n *big.Int) (*big.Int, error) {
+	for {
+		r, err := rand.Int(random, n)
+		if err != nil {
+			return nil, &RandomnessError{Err: err}
+		}
+		if r.Sign() != 0 && new(big.Int).GCD(nil, nil, r, n).Cmp(bigOne) == 0 {
+			return r, nil
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package keysplitting
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Order blinding", func() {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	hashed := sha256.Sum256([]byte("TEST MESSAGE"))
+
+	It("Produces the complete signature once the masks are removed", func() {
+		shards, err := SplitD(key, 3, Addition)
+		Expect(err).To(BeNil())
+
+		masks := make([]*OrderMask, len(shards))
+		var partialSig *PartialSignature
+		partialSig, masks[0], err = SignFirstOrderBlinded(rand.Reader, shards[0], crypto.SHA256, hashed[:])
+		Expect(err).To(BeNil())
+		unblinded, err := SignFirst(rand.Reader, shards[0], crypto.SHA256, hashed[:])
+		Expect(err).To(BeNil())
+		for i, shard := range shards[1:] {
+			// the holder sees a value unrelated to the partial signature of the shards before it
+			Expect(partialSig.Sig).NotTo(Equal(unblinded.Sig))
+
+			partialSig, masks[i+1], err = SignNextOrderBlinded(rand.Reader, shard, crypto.SHA256, hashed[:], partialSig)
+			Expect(err).To(BeNil())
+			unblinded, err = SignNext(rand.Reader, shard, crypto.SHA256, hashed[:], unblinded)
+			Expect(err).To(BeNil())
+		}
+		Expect(rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, hashed[:], partialSig.Sig)).NotTo(Succeed())
+
+		unmasked, err := RemoveOrderMasks(&key.PublicKey, partialSig, masks[2], masks[0], masks[1])
+		Expect(err).To(BeNil())
+		Expect(unmasked).To(Equal(unblinded))
+		Expect(rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, hashed[:], unmasked.Sig)).To(Succeed())
+	})
+
+	It("Refuses masks that don't match the signers", func() {
+		shards, err := SplitD(key, 3, Addition)
+		Expect(err).To(BeNil())
+		partialSig, first, err := SignFirstOrderBlinded(rand.Reader, shards[0], crypto.SHA256, hashed[:])
+		Expect(err).To(BeNil())
+		partialSig, second, err := SignNextOrderBlinded(rand.Reader, shards[1], crypto.SHA256, hashed[:], partialSig)
+		Expect(err).To(BeNil())
+
+		_, err = RemoveOrderMasks(&key.PublicKey, partialSig, first)
+		Expect(errors.Is(err, ErrInvalidPartialSignature)).To(BeTrue())
+		_, err = RemoveOrderMasks(&key.PublicKey, partialSig, first, first)
+		Expect(errors.Is(err, ErrInvalidPartialSignature)).To(BeTrue())
+		_, err = RemoveOrderMasks(&key.PublicKey, partialSig, first, &OrderMask{Signer: 3, R: second.R})
+		Expect(errors.Is(err, ErrInvalidPartialSignature)).To(BeTrue())
+	})
+
+	It("Refuses multiplicative shards", func() {
+		shards, err := SplitD(key, 2, Multiplication)
+		Expect(err).To(BeNil())
+		_, _, err = SignFirstOrderBlinded(rand.Reader, shards[0], crypto.SHA256, hashed[:])
+		Expect(errors.Is(err, ErrUnsupportedSplitBy)).To(BeTrue())
+	})
+})
@@ -0,0 +1,107 @@
+/*
+Package oteltrace traces keysplitting with OpenTelemetry. It is a separate package so that keysplitting doesn't depend on the
+OpenTelemetry API
+*/
+package oteltrace
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bastionzero/keysplitting"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// the name of the OpenTelemetry instrumentation library, which is the import path of keysplitting
+const tracerName = "github.com/bastionzero/keysplitting"
+
+// Tracer is a keysplitting.Tracer that starts OpenTelemetry spans. It is also a keysplitting.TracePropagator, so that the
+// caller's span is continued by the shard holders:
+//
+//	keysplitting.SetTracer(oteltrace.New(nil, nil))
+//
+// It is safe for concurrent use
+type Tracer struct {
+	provider   trace.TracerProvider
+	propagator propagation.TextMapPropagator
+}
+
+var (
+	_ keysplitting.Tracer          = (*Tracer)(nil)
+	_ keysplitting.TracePropagator = (*Tracer)(nil)
+)
+
+// New returns a Tracer that starts spans with provider and carries them across process boundaries with propagator, e.g.
+// propagation.TraceContext{}. A nil provider or propagator uses the global one from otel.GetTracerProvider or
+// otel.GetTextMapPropagator, which do nothing unless the application has set them
+func New(provider trace.TracerProvider, propagator propagation.TextMapPropagator) *Tracer {
+	return &Tracer{provider: provider, propagator: propagator}
+}
+
+// Start implements keysplitting.Tracer, starting a span under ctx
+func (t *Tracer) Start(ctx context.Context, name string, attrs ...keysplitting.TraceAttribute) (context.Context, keysplitting.TraceSpan) {
+	provider := t.provider
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+	ctx, s := provider.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attributes(attrs)...))
+	return ctx, span{s}
+}
+
+// Inject implements keysplitting.TracePropagator, adding the span in ctx to carrier
+func (t *Tracer) Inject(ctx context.Context, carrier keysplitting.TraceCarrier) {
+	t.textMapPropagator().Inject(ctx, carrier)
+}
+
+// Extract implements keysplitting.TracePropagator, returning ctx with the span carried by carrier
+func (t *Tracer) Extract(ctx context.Context, carrier keysplitting.TraceCarrier) context.Context {
+	return t.textMapPropagator().Extract(ctx, carrier)
+}
+
+func (t *Tracer) textMapPropagator() propagation.TextMapPropagator {
+	if t.propagator == nil {
+		return otel.GetTextMapPropagator()
+	}
+	return t.propagator
+}
+
+// the keysplitting.TraceSpan started by a Tracer
+type span struct {
+	span trace.Span
+}
+
+func (s span) SetAttributes(attrs ...keysplitting.TraceAttribute) {
+	s.span.SetAttributes(attributes(attrs)...)
+}
+
+// ends the span, recording err as an event and setting the span's status to an error if it isn't nil
+func (s span) End(err error) {
+	if err != nil {
+		s.span.RecordError(err)
+		s.span.SetStatus(codes.Error, err.Error())
+	}
+	s.span.End()
+}
+
+// converts attrs to OpenTelemetry attributes
+func attributes(attrs []keysplitting.TraceAttribute) []attribute.KeyValue {
+	kvs := make([]attribute.KeyValue, len(attrs))
+	for i, attr := range attrs {
+		key := attribute.Key(attr.Key)
+		switch value := attr.Value.(type) {
+		case string:
+			kvs[i] = key.String(value)
+		case int:
+			kvs[i] = key.Int(value)
+		case bool:
+			kvs[i] = key.Bool(value)
+		default:
+			kvs[i] = key.String(fmt.Sprint(value))
+		}
+	}
+	return kvs
+}
@@ -0,0 +1,156 @@
+package oteltrace
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"testing"
+
+	"github.com/bastionzero/keysplitting"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestOpenTelemetryTracing(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "OpenTelemetry Tracing Suite")
+}
+
+// returns the ended spans with the given name, in the order they ended
+func namedSpans(recorder *tracetest.SpanRecorder, name string) []sdktrace.ReadOnlySpan {
+	var spans []sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		if span.Name() == name {
+			spans = append(spans, span)
+		}
+	}
+	return spans
+}
+
+// returns the attributes of span as a map
+func spanAttributes(span sdktrace.ReadOnlySpan) map[attribute.Key]interface{} {
+	attrs := map[attribute.Key]interface{}{}
+	for _, attr := range span.Attributes() {
+		attrs[attr.Key] = attr.Value.AsInterface()
+	}
+	return attrs
+}
+
+var _ = Describe("Tracer", func() {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	hashed := sha256.Sum256([]byte("TEST MESSAGE"))
+	ctx := context.Background()
+	var recorder *tracetest.SpanRecorder
+	var provider *sdktrace.TracerProvider
+
+	BeforeEach(func() {
+		recorder = tracetest.NewSpanRecorder()
+		provider = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+		keysplitting.SetTracer(New(provider, propagation.TraceContext{}))
+		DeferCleanup(func() { keysplitting.SetTracer(nil) })
+	})
+
+	It("Traces splitting, signing, and combining", func() {
+		shards, err := keysplitting.SplitD(key, 3, keysplitting.Addition)
+		Expect(err).To(BeNil())
+
+		spans := namedSpans(recorder, "keysplitting.SplitD")
+		Expect(spans).To(HaveLen(1))
+		Expect(spans[0].Status().Code).To(Equal(codes.Unset))
+		Expect(spans[0].InstrumentationScope().Name).To(Equal("github.com/bastionzero/keysplitting"))
+		Expect(spanAttributes(spans[0])).To(Equal(map[attribute.Key]interface{}{
+			keysplitting.TraceKeySize:    int64(2048),
+			keysplitting.TraceShardCount: int64(3),
+			keysplitting.TraceSplitBy:    "Addition",
+		}))
+
+		partials := make([]*keysplitting.PartialSignature, len(shards))
+		for i, shard := range shards {
+			partials[i], err = keysplitting.SignFirst(rand.Reader, shard, crypto.SHA256, hashed[:])
+			Expect(err).To(BeNil())
+		}
+		spans = namedSpans(recorder, "keysplitting.SignFirst")
+		Expect(spans).To(HaveLen(3))
+		Expect(spanAttributes(spans[2])).To(HaveKeyWithValue(attribute.Key(keysplitting.TraceShardIndex), int64(3)))
+		Expect(spanAttributes(spans[2])).To(HaveKeyWithValue(attribute.Key(keysplitting.TraceHash), "SHA-256"))
+
+		_, err = keysplitting.CombinePartialSignatures(&key.PublicKey, partials...)
+		Expect(err).To(BeNil())
+		_, err = keysplitting.CombinePartialSignatures(&key.PublicKey, partials[0])
+		Expect(errors.Is(err, keysplitting.ErrTooFewShards)).To(BeTrue())
+
+		spans = namedSpans(recorder, "keysplitting.CombinePartialSignatures")
+		Expect(spans).To(HaveLen(2))
+		Expect(spans[0].Status().Code).To(Equal(codes.Unset))
+		Expect(spans[1].Status().Code).To(Equal(codes.Error))
+		Expect(spans[1].Status().Description).To(Equal(err.Error()))
+		Expect(spans[1].Events()).To(HaveLen(1))
+	})
+
+	It("Nests the spans of a remote signature", func() {
+		shards, err := keysplitting.SplitD(key, 2, keysplitting.Multiplication)
+		Expect(err).To(BeNil())
+		remotes := make([]keysplitting.RemoteShard, len(shards))
+		for i, shard := range shards {
+			remotes[i], err = keysplitting.NewLocalShard(shard)
+			Expect(err).To(BeNil())
+		}
+
+		_, err = keysplitting.SignSequential(ctx, &key.PublicKey, crypto.SHA256, hashed[:], remotes...)
+		Expect(err).To(BeNil())
+
+		root := namedSpans(recorder, "keysplitting.SignSequential")
+		Expect(root).To(HaveLen(1))
+		requests := namedSpans(recorder, "keysplitting.RemoteShard.PartialSign")
+		Expect(requests).To(HaveLen(2))
+		for i, request := range requests {
+			Expect(request.Parent().SpanID()).To(Equal(root[0].SpanContext().SpanID()))
+			Expect(spanAttributes(request)).To(HaveKeyWithValue(attribute.Key(keysplitting.TraceShardIndex), int64(i+1)))
+		}
+	})
+
+	It("Carries spans across process boundaries", func() {
+		tracer := New(provider, propagation.TraceContext{})
+		parentCtx, parent := tracer.Start(ctx, "parent")
+		carrier := propagation.MapCarrier{}
+		tracer.Inject(parentCtx, carrier)
+		Expect(carrier.Keys()).To(ContainElement("traceparent"))
+
+		_, child := tracer.Start(tracer.Extract(ctx, carrier), "child")
+		child.End(nil)
+		parent.End(nil)
+
+		spans := namedSpans(recorder, "child")
+		Expect(spans).To(HaveLen(1))
+		Expect(spans[0].Parent().IsRemote()).To(BeTrue())
+		Expect(spans[0].Parent().SpanID()).To(Equal(trace.SpanContextFromContext(parentCtx).SpanID()))
+	})
+
+	It("Uses the global provider and propagator by default", func() {
+		globalProvider, globalPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+		otel.SetTracerProvider(provider)
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+		DeferCleanup(func() {
+			otel.SetTracerProvider(globalProvider)
+			otel.SetTextMapPropagator(globalPropagator)
+		})
+
+		tracer := New(nil, nil)
+		spanCtx, span := tracer.Start(ctx, "global")
+		carrier := propagation.MapCarrier{}
+		tracer.Inject(spanCtx, carrier)
+		span.End(nil)
+		Expect(namedSpans(recorder, "global")).To(HaveLen(1))
+		Expect(carrier.Keys()).To(ContainElement("traceparent"))
+	})
+})
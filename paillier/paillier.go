@@ -0,0 +1,206 @@
+/*
+Package paillier implements the Paillier cryptosystem [1], whose ciphertexts can be added together, or multiplied by a
+constant, without decrypting them. This makes it a common building block of multi-party protocols, such as the two-party
+ECDSA of the ecdsa2p subpackage, where one party computes on a value encrypted under another party's key.
+
+The decryption key can also be split into additive shards, much like an RSA private exponent split with keysplitting's
+Addition: [PrivateKey.Split] deals k shards, each shard holder decrypts a ciphertext partially with [DecryptionShard.Decrypt],
+and [Combine] recovers the plaintext from all k partial decryptions. No shard holder alone learns anything about the plaintext.
+
+Keys use the generator N + 1, so encryption is (1 + N)^m * r^N mod N^2 for a random r.
+
+	[1] https://link.springer.com/content/pdf/10.1007/3-540-48910-X_16.pdf
+*/
+package paillier
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/bastionzero/keysplitting"
+)
+
+var bigOne = big.NewInt(1)
+
+// A PublicKey is a Paillier public key
+type PublicKey struct {
+	N *big.Int // the modulus
+}
+
+// A PrivateKey is a Paillier private key
+type PrivateKey struct {
+	PublicKey
+	P, Q *big.Int // the prime factors of N
+}
+
+// A DecryptionShard is one of k additive shards of a Paillier private key, from [PrivateKey.Split]
+type DecryptionShard struct {
+	PublicKey *PublicKey // public part
+	Index     int        // this shard's position in 1..k
+	D         *big.Int   // share of the decryption exponent d, where d = 0 (mod phi(N)) and d = 1 (mod N)
+}
+
+// A PartialDecryption is the contribution of a single [DecryptionShard] to a decryption
+type PartialDecryption struct {
+	Index int      // index of the shard that produced the partial decryption
+	C     *big.Int // c^(d_i) mod N^2
+}
+
+// GenerateKey generates a Paillier key pair whose modulus has the given number of bits
+func GenerateKey(random io.Reader, bits int) (*PrivateKey, error) {
+	if bits < 64 {
+		return nil, fmt.Errorf("Paillier modulus size is too small")
+	}
+	for {
+		p, err := rand.Prime(random, bits-bits/2)
+		if err != nil {
+			return nil, &keysplitting.RandomnessError{Err: err}
+		}
+		q, err := rand.Prime(random, bits/2)
+		if err != nil {
+			return nil, &keysplitting.RandomnessError{Err: err}
+		}
+		if priv, err := NewPrivateKey(p, q); err == nil && priv.N.BitLen() == bits {
+			return priv, nil
+		}
+	}
+}
+
+// NewPrivateKey returns the Paillier private key with prime factors p and q
+func NewPrivateKey(p, q *big.Int) (*PrivateKey, error) {
+	priv := &PrivateKey{PublicKey: PublicKey{N: new(big.Int).Mul(p, q)}, P: p, Q: q}
+	if p.Cmp(q) == 0 || new(big.Int).ModInverse(priv.phi(), priv.N) == nil {
+		return nil, fmt.Errorf("invalid Paillier primes")
+	}
+	return priv, nil
+}
+
+// Encrypt returns an encryption of m, which must be in [0, N)
+func (pub *PublicKey) Encrypt(random io.Reader, m *big.Int) (*big.Int, error) {
+	if m.Sign() < 0 || m.Cmp(pub.N) >= 0 {
+		return nil, fmt.Errorf("Paillier plaintext out of range")
+	}
+	var r *big.Int
+	for {
+		var err error
+		if r, err = rand.Int(random, pub.N); err != nil {
+			return nil, &keysplitting.RandomnessError{Err: err}
+		}
+		if r.Sign() > 0 && new(big.Int).GCD(nil, nil, r, pub.N).Cmp(bigOne) == 0 {
+			break
+		}
+	}
+	nSquared := pub.nSquared()
+	c := new(big.Int).Mul(m, pub.N)
+	c.Add(c, bigOne)
+	c.Mul(c, new(big.Int).Exp(r, pub.N, nSquared))
+	return c.Mod(c, nSquared), nil
+}
+
+// Add returns an encryption of the sum of the plaintexts of c1 and c2, mod N
+func (pub *PublicKey) Add(c1, c2 *big.Int) *big.Int {
+	c := new(big.Int).Mul(c1, c2)
+	return c.Mod(c, pub.nSquared())
+}
+
+// Mul returns an encryption of the product of the plaintext of c and k, mod N
+func (pub *PublicKey) Mul(c, k *big.Int) *big.Int {
+	return new(big.Int).Exp(c, k, pub.nSquared())
+}
+
+// Decrypt returns the plaintext of c
+func (priv *PrivateKey) Decrypt(c *big.Int) (*big.Int, error) {
+	if err := priv.checkCiphertext(c); err != nil {
+		return nil, err
+	}
+	// m = L(c^phi mod N^2) / phi mod N, where L(x) = (x - 1) / N
+	phi := priv.phi()
+	m := priv.l(new(big.Int).Exp(c, phi, priv.nSquared()))
+	m.Mul(m, new(big.Int).ModInverse(phi, priv.N))
+	return m.Mod(m, priv.N), nil
+}
+
+// Split splits the private key's decryption exponent into k additive shards, all of which are needed to decrypt
+func (priv *PrivateKey) Split(random io.Reader, k int) ([]*DecryptionShard, error) {
+	if k < 2 {
+		return nil, fmt.Errorf("%w: cannot split key into fewer than 2 shards", keysplitting.ErrTooFewShards)
+	}
+
+	// d = phi * (phi^-1 mod N), so c^d = (1 + N)^m mod N^2. Exponents only matter modulo N * phi, the order of the group
+	phi := priv.phi()
+	d := new(big.Int).ModInverse(phi, priv.N)
+	d.Mul(d, phi)
+	order := new(big.Int).Mul(priv.N, phi)
+
+	pub := &PublicKey{N: new(big.Int).Set(priv.N)}
+	shards := make([]*DecryptionShard, k)
+	last := new(big.Int).Set(d)
+	for i := 0; i < k-1; i++ {
+		di, err := rand.Int(random, order)
+		if err != nil {
+			return nil, &keysplitting.RandomnessError{Err: err}
+		}
+		last.Sub(last, di)
+		shards[i] = &DecryptionShard{PublicKey: pub, Index: i + 1, D: di}
+	}
+	shards[k-1] = &DecryptionShard{PublicKey: pub, Index: k, D: last.Mod(last, order)}
+	return shards, nil
+}
+
+// Decrypt returns the shard's partial decryption of c
+func (s *DecryptionShard) Decrypt(c *big.Int) (*PartialDecryption, error) {
+	if err := s.PublicKey.checkCiphertext(c); err != nil {
+		return nil, err
+	}
+	return &PartialDecryption{Index: s.Index, C: new(big.Int).Exp(c, s.D, s.PublicKey.nSquared())}, nil
+}
+
+// Combine returns the plaintext from the partial decryptions of every shard of the key
+func Combine(pub *PublicKey, partials ...*PartialDecryption) (*big.Int, error) {
+	if len(partials) < 2 {
+		return nil, fmt.Errorf("%w: cannot combine fewer than 2 partial decryptions", keysplitting.ErrTooFewShards)
+	}
+	nSquared := pub.nSquared()
+	c := big.NewInt(1)
+	for i, partial := range partials {
+		for _, other := range partials[:i] {
+			if other.Index == partial.Index {
+				return nil, fmt.Errorf("%w: duplicate partial decryption from shard %d", keysplitting.ErrInvalidPartialSignature, partial.Index)
+			}
+		}
+		if err := pub.checkCiphertext(partial.C); err != nil {
+			return nil, err
+		}
+		c.Mul(c, partial.C)
+		c.Mod(c, nSquared)
+	}
+
+	// c^d = 1 + m * N, which a missing or wrong partial decryption breaks
+	if new(big.Int).Mod(c, pub.N).Cmp(bigOne) != 0 {
+		return nil, fmt.Errorf("%w: partial decryptions are missing or invalid", keysplitting.ErrInvalidPartialSignature)
+	}
+	return pub.l(c), nil
+}
+
+func (pub *PublicKey) nSquared() *big.Int {
+	return new(big.Int).Mul(pub.N, pub.N)
+}
+
+// returns L(x) = (x - 1) / N
+func (pub *PublicKey) l(x *big.Int) *big.Int {
+	x = new(big.Int).Sub(x, bigOne)
+	return x.Div(x, pub.N)
+}
+
+func (pub *PublicKey) checkCiphertext(c *big.Int) error {
+	if c == nil || c.Sign() <= 0 || c.Cmp(pub.nSquared()) >= 0 || new(big.Int).GCD(nil, nil, c, pub.N).Cmp(bigOne) != 0 {
+		return fmt.Errorf("Paillier ciphertext out of range")
+	}
+	return nil
+}
+
+func (priv *PrivateKey) phi() *big.Int {
+	return new(big.Int).Mul(new(big.Int).Sub(priv.P, bigOne), new(big.Int).Sub(priv.Q, bigOne))
+}
@@ -0,0 +1,147 @@
+package paillier
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/bastionzero/keysplitting"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestPaillier(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Paillier Suite")
+}
+
+var _ = Describe("Paillier", func() {
+	// small primes keep the tests fast, and the arithmetic doesn't depend on their size
+	p, _ := rand.Prime(rand.Reader, 256)
+	q, _ := rand.Prime(rand.Reader, 256)
+	key, keyErr := NewPrivateKey(p, q)
+
+	It("Decrypts what it encrypts", func() {
+		Expect(keyErr).To(BeNil())
+		for _, m := range []*big.Int{big.NewInt(0), big.NewInt(42), new(big.Int).Sub(key.N, bigOne)} {
+			c, err := key.Encrypt(rand.Reader, m)
+			Expect(err).To(BeNil())
+			decrypted, err := key.Decrypt(c)
+			Expect(err).To(BeNil())
+			Expect(decrypted.Cmp(m)).To(Equal(0))
+		}
+		_, err := key.Encrypt(rand.Reader, key.N)
+		Expect(err).ToNot(BeNil())
+		_, err = key.Decrypt(new(big.Int).Mul(key.N, key.N))
+		Expect(err).ToNot(BeNil())
+	})
+
+	It("Is additively homomorphic", func() {
+		public := &PublicKey{N: key.N}
+		c1, err := public.Encrypt(rand.Reader, big.NewInt(1000))
+		Expect(err).To(BeNil())
+		c2, err := public.Encrypt(rand.Reader, big.NewInt(234))
+		Expect(err).To(BeNil())
+
+		sum, err := key.Decrypt(public.Add(c1, c2))
+		Expect(err).To(BeNil())
+		Expect(sum.Cmp(big.NewInt(1234))).To(Equal(0))
+		product, err := key.Decrypt(public.Mul(c1, big.NewInt(7)))
+		Expect(err).To(BeNil())
+		Expect(product.Cmp(big.NewInt(7000))).To(Equal(0))
+	})
+
+	It("Generates keys of the requested size", func() {
+		key, err := GenerateKey(rand.Reader, 1024)
+		Expect(err).To(BeNil())
+		Expect(key.N.BitLen()).To(Equal(1024))
+		Expect(new(big.Int).Mul(key.P, key.Q).Cmp(key.N)).To(Equal(0))
+
+		_, err = GenerateKey(rand.Reader, 32)
+		Expect(err).ToNot(BeNil())
+	})
+
+	It("Rejects primes that don't make a key", func() {
+		_, err := NewPrivateKey(p, p)
+		Expect(err).ToNot(BeNil())
+	})
+
+	Context("Split", func() {
+		m := big.NewInt(123456789)
+
+		decryptWith := func(shards []*DecryptionShard, c *big.Int) []*PartialDecryption {
+			partials := make([]*PartialDecryption, len(shards))
+			for i, shard := range shards {
+				partial, err := shard.Decrypt(c)
+				Expect(err).To(BeNil())
+				partials[i] = partial
+			}
+			return partials
+		}
+
+		It("Decrypts with every shard", func() {
+			for _, k := range []int{2, 3, 5} {
+				shards, err := key.Split(rand.Reader, k)
+				Expect(err).To(BeNil())
+				Expect(shards).To(HaveLen(k))
+				for i, shard := range shards {
+					Expect(shard.Index).To(Equal(i + 1))
+					Expect(shard.PublicKey.N.Cmp(key.N)).To(Equal(0))
+				}
+
+				c, err := shards[0].PublicKey.Encrypt(rand.Reader, m)
+				Expect(err).To(BeNil())
+				decrypted, err := Combine(shards[0].PublicKey, decryptWith(shards, c)...)
+				Expect(err).To(BeNil())
+				Expect(decrypted.Cmp(m)).To(Equal(0))
+			}
+		})
+
+		It("Decrypts homomorphic results", func() {
+			shards, err := key.Split(rand.Reader, 3)
+			Expect(err).To(BeNil())
+			pub := shards[0].PublicKey
+			c1, err := pub.Encrypt(rand.Reader, big.NewInt(20))
+			Expect(err).To(BeNil())
+			c2, err := pub.Encrypt(rand.Reader, big.NewInt(22))
+			Expect(err).To(BeNil())
+
+			sum, err := Combine(pub, decryptWith(shards, pub.Add(c1, c2))...)
+			Expect(err).To(BeNil())
+			Expect(sum.Cmp(big.NewInt(42))).To(Equal(0))
+		})
+
+		It("Fails without every shard", func() {
+			shards, err := key.Split(rand.Reader, 3)
+			Expect(err).To(BeNil())
+			c, err := key.Encrypt(rand.Reader, m)
+			Expect(err).To(BeNil())
+			partials := decryptWith(shards, c)
+
+			_, err = Combine(&key.PublicKey, partials[:2]...)
+			Expect(errors.Is(err, keysplitting.ErrInvalidPartialSignature)).To(BeTrue())
+			_, err = Combine(&key.PublicKey, partials[0])
+			Expect(errors.Is(err, keysplitting.ErrTooFewShards)).To(BeTrue())
+			_, err = Combine(&key.PublicKey, partials[0], partials[1], partials[1])
+			Expect(errors.Is(err, keysplitting.ErrInvalidPartialSignature)).To(BeTrue())
+		})
+
+		It("Fails with a tampered partial decryption", func() {
+			shards, err := key.Split(rand.Reader, 2)
+			Expect(err).To(BeNil())
+			c, err := key.Encrypt(rand.Reader, m)
+			Expect(err).To(BeNil())
+			partials := decryptWith(shards, c)
+			partials[1].C = new(big.Int).Add(partials[1].C, bigOne)
+
+			_, err = Combine(&key.PublicKey, partials...)
+			Expect(err).ToNot(BeNil())
+		})
+
+		It("Rejects fewer than 2 shards", func() {
+			_, err := key.Split(rand.Reader, 1)
+			Expect(errors.Is(err, keysplitting.ErrTooFewShards)).To(BeTrue())
+		})
+	})
+})
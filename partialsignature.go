@@ -0,0 +1,163 @@
+package keysplitting
+
+import (
+	"crypto"
+	"encoding/base64"
+	"encoding/json"
+)
+
+// A PartialSignature is a signature produced by some, but not necessarily all, of the shards of a split key.
+// Once every shard has signed, Sig is the complete signature and verifies against the public key in the usual way
+type PartialSignature struct {
+	Signers []int       // indices of the shards that have signed, in the order they signed (0 if a shard's index is unknown)
+	Hash    crypto.Hash // the hash function used to produce the signed digest
+	SplitBy SplitBy     // the algorithm used to split the key, which determines how signatures are combined
+	Sig     []byte      // the signature itself
+	Nonce   []byte      // the session nonce the signature is bound to, if any (see [NonceOptions])
+	Proof   []byte      // a proof that the signature was produced with a committed shard, if any (see [ShardCommitments])
+}
+
+// returns a new partial signature signed only by shard
+func newPartialSignature(shard *PrivateKeyShard, hashFn crypto.Hash, sig []byte) *PartialSignature {
+	return &PartialSignature{
+		Signers: []int{shard.Index},
+		Hash:    hashFn,
+		SplitBy: shard.SplitBy,
+		Sig:     sig,
+	}
+}
+
+// returns a copy of ps with shard added to the signers and the signature replaced by sig
+func (ps *PartialSignature) extend(shard *PrivateKeyShard, sig []byte) *PartialSignature {
+	signers := make([]int, len(ps.Signers), len(ps.Signers)+1)
+	copy(signers, ps.Signers)
+
+	return &PartialSignature{
+		Signers: append(signers, shard.Index),
+		Hash:    ps.Hash,
+		SplitBy: ps.SplitBy,
+		Sig:     sig,
+		Nonce:   ps.Nonce,
+	}
+}
+
+// returns a copy of ps that shares no memory with it
+func (ps *PartialSignature) copy() *PartialSignature {
+	return &PartialSignature{
+		Signers: append([]int{}, ps.Signers...),
+		Hash:    ps.Hash,
+		SplitBy: ps.SplitBy,
+		Sig:     append([]byte{}, ps.Sig...),
+		Nonce:   append([]byte(nil), ps.Nonce...),
+		Proof:   append([]byte(nil), ps.Proof...),
+	}
+}
+
+// returns true if the shard with the given index has already contributed to ps. Unknown indices never match
+func (ps *PartialSignature) signedBy(index int) bool {
+	if index == 0 {
+		return false
+	}
+	for _, signer := range ps.Signers {
+		if signer == index {
+			return true
+		}
+	}
+	return false
+}
+
+// checks that shard can add its signature on a digest produced with hashFn, in the session identified by nonce, to ps
+func (ps *PartialSignature) checkNext(shard *PrivateKeyShard, hashFn crypto.Hash, nonce []byte) error {
+	if ps.SplitBy != shard.SplitBy {
+		return errorf(ErrInvalidPartialSignature, "cannot add a signature from a shard split by %v to a partial signature split by %v", shard.SplitBy, ps.SplitBy)
+	}
+	if ps.Hash != hashFn {
+		return errorf(ErrInvalidPartialSignature, "partial signature was produced with a different hash function")
+	}
+	if ps.signedBy(shard.Index) {
+		return errorf(ErrInvalidPartialSignature, "shard %d has already signed", shard.Index)
+	}
+	return ps.checkNonce(nonce)
+}
+
+// used exclusively as a placeholder for encoding-decoding
+type partialSignatureJSON struct {
+	Signers []int   `json:"signers"`
+	Hash    string  `json:"hash,omitempty"`
+	SplitBy SplitBy `json:"split_by"`
+	Sig     string  `json:"sig"`
+	Nonce   string  `json:"nonce,omitempty"`
+	Proof   string  `json:"proof,omitempty"`
+}
+
+// MarshalJSON implements [json.Marshaler]. The hash function is given by name, e.g. "SHA-256", and omitted for raw signatures.
+// The signature, session nonce and proof are encoded in base64url without padding, and the nonce and proof are omitted if
+// there are none
+func (ps *PartialSignature) MarshalJSON() ([]byte, error) {
+	encoded := partialSignatureJSON{
+		Signers: ps.Signers,
+		SplitBy: ps.SplitBy,
+		Sig:     base64.RawURLEncoding.EncodeToString(ps.Sig),
+		Nonce:   base64.RawURLEncoding.EncodeToString(ps.Nonce),
+		Proof:   base64.RawURLEncoding.EncodeToString(ps.Proof),
+	}
+	if ps.Hash != 0 {
+		encoded.Hash = ps.Hash.String()
+	}
+	return json.Marshal(encoded)
+}
+
+// UnmarshalJSON implements [json.Unmarshaler], accepting the format produced by [PartialSignature.MarshalJSON]
+func (ps *PartialSignature) UnmarshalJSON(data []byte) error {
+	var encoded partialSignatureJSON
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return errorf(ErrInvalidPartialSignature, "failed to unmarshal partial signature: %s", err)
+	}
+	if err := checkSplitBy(encoded.SplitBy); err != nil {
+		return err
+	}
+
+	hashFn, err := parseHash(encoded.Hash)
+	if err != nil {
+		return err
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(encoded.Sig)
+	if err != nil || len(sig) == 0 {
+		return errorf(ErrInvalidPartialSignature, "invalid base64url-encoded signature")
+	}
+	var nonce []byte
+	if encoded.Nonce != "" {
+		if nonce, err = base64.RawURLEncoding.DecodeString(encoded.Nonce); err != nil {
+			return errorf(ErrInvalidPartialSignature, "invalid base64url-encoded session nonce")
+		}
+	}
+	var proof []byte
+	if encoded.Proof != "" {
+		if proof, err = base64.RawURLEncoding.DecodeString(encoded.Proof); err != nil {
+			return errorf(ErrInvalidPartialSignature, "invalid base64url-encoded proof")
+		}
+	}
+
+	*ps = PartialSignature{
+		Signers: encoded.Signers,
+		Hash:    hashFn,
+		SplitBy: encoded.SplitBy,
+		Sig:     sig,
+		Nonce:   nonce,
+		Proof:   proof,
+	}
+	return nil
+}
+
+// returns the hash function with the given name, as given by crypto.Hash.String, or 0 for an empty name
+func parseHash(name string) (crypto.Hash, error) {
+	if name == "" {
+		return 0, nil
+	}
+	for hashFn := crypto.MD4; hashFn <= crypto.BLAKE2b_512; hashFn++ {
+		if hashFn.String() == name {
+			return hashFn, nil
+		}
+	}
+	return 0, errorf(ErrUnsupportedHash, "unrecognized hash function %q", name)
+}
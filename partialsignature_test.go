@@ -0,0 +1,64 @@
+package keysplitting
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PartialSignature", func() {
+	Context("JSON encoding", func() {
+		hashed := sha256.Sum256([]byte("TEST MESSAGE"))
+		key, _ := rsa.GenerateKey(rand.Reader, 2048)
+		shards, _ := SplitD(key, 3, Multiplication)
+
+		It("Round-trips a partial signature, which can then be extended", func() {
+			partialSig, err := SignFirst(rand.Reader, shards[0], crypto.SHA256, hashed[:])
+			Expect(err).To(BeNil())
+			partialSig, err = SignNext(rand.Reader, shards[1], crypto.SHA256, hashed[:], partialSig)
+			Expect(err).To(BeNil())
+
+			encoded, err := json.Marshal(partialSig)
+			Expect(err).To(BeNil())
+			Expect(string(encoded)).To(ContainSubstring(`"hash":"SHA-256"`))
+			Expect(string(encoded)).To(ContainSubstring(`"signers":[1,2]`))
+
+			var decoded PartialSignature
+			Expect(json.Unmarshal(encoded, &decoded)).To(Succeed())
+			Expect(decoded).To(Equal(*partialSig))
+
+			sig, err := SignNext(rand.Reader, shards[2], crypto.SHA256, hashed[:], &decoded)
+			Expect(err).To(BeNil())
+			Expect(rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, hashed[:], sig.Sig)).To(Succeed())
+		})
+
+		It("Omits the hash function of raw partial signatures", func() {
+			partialSig := &PartialSignature{Signers: []int{1}, SplitBy: Addition, Sig: []byte{0, 1, 2}}
+			encoded, err := json.Marshal(partialSig)
+			Expect(err).To(BeNil())
+			Expect(string(encoded)).NotTo(ContainSubstring("hash"))
+
+			var decoded PartialSignature
+			Expect(json.Unmarshal(encoded, &decoded)).To(Succeed())
+			Expect(decoded).To(Equal(*partialSig), "leading zeros must be kept")
+		})
+
+		It("Rejects malformed partial signatures", func() {
+			var decoded PartialSignature
+			err := json.Unmarshal([]byte(`{"signers":[1],"hash":"SHA-257","split_by":"Addition","sig":"AQAB"}`), &decoded)
+			Expect(errors.Is(err, ErrUnsupportedHash)).To(BeTrue())
+
+			err = json.Unmarshal([]byte(`{"signers":[1],"hash":"SHA-256","split_by":"Addition","sig":""}`), &decoded)
+			Expect(errors.Is(err, ErrInvalidPartialSignature)).To(BeTrue())
+
+			err = json.Unmarshal([]byte(`{"signers":[1],"hash":"SHA-256","sig":"AQAB"}`), &decoded)
+			Expect(errors.Is(err, ErrUnsupportedSplitBy)).To(BeTrue())
+		})
+	})
+})
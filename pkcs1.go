@@ -0,0 +1,60 @@
+package keysplitting
+
+import (
+	"encoding/asn1"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+)
+
+const pkcs1PEMType = "RSA PRIVATE KEY"
+
+// used exclusively as a placeholder for encoding-decoding. It mirrors RSAPrivateKey from RFC 8017
+type pkcs1PrivateKey struct {
+	Version int
+	N       *big.Int
+	E       int
+	D       *big.Int
+	P       *big.Int
+	Q       *big.Int
+	Dp      *big.Int
+	Dq      *big.Int
+	Qinv    *big.Int
+}
+
+// ExportAsRSAPrivateKey returns the shard as a PKCS #1 "RSA PRIVATE KEY" PEM block, so that it can be loaded into
+// existing tools or HSMs that can perform a plain RSA private-key operation but don't understand shards. The key is
+// degenerate: its private exponent is the shard's D, and since no shard holder knows the factors of N, the primes and
+// CRT values are all zero. Tools that validate keys (including x509.ParsePKCS1PrivateKey) will reject it.
+//
+// Raising a padded message to the exported exponent gives the same result as [SignFirst], and raising a partial signature
+// to it gives the same result as [SignNext] for a multiplicative shard. Negative additive shards and masked shards have no
+// such equivalent, so they cannot be exported
+func (pks *PrivateKeyShard) ExportAsRSAPrivateKey() (string, error) {
+	if err := pks.checkUsable(); err != nil {
+		return "", err
+	}
+	if pks.D.Sign() <= 0 {
+		return "", errorf(ErrInvalidShard, "a negative shard cannot be exported as an RSA private key")
+	}
+	if pks.Mask != nil {
+		return "", errorf(ErrInvalidShard, "a masked shard cannot be exported as an RSA private key")
+	}
+
+	zero := new(big.Int)
+	b, err := asn1.Marshal(pkcs1PrivateKey{
+		N:    pks.PublicKey.N,
+		E:    pks.PublicKey.E,
+		D:    pks.D,
+		P:    zero,
+		Q:    zero,
+		Dp:   zero,
+		Dq:   zero,
+		Qinv: zero,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to DER-encode: %s", err)
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: pkcs1PEMType, Bytes: b})), nil
+}
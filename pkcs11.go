@@ -0,0 +1,161 @@
+package keysplitting
+
+import (
+	"crypto/rsa"
+	"fmt"
+	"math/big"
+	"sync"
+)
+
+// A PKCS11Object is the handle of an object on a PKCS #11 token (CK_OBJECT_HANDLE)
+type PKCS11Object uint
+
+// A PKCS11Token is the part of a logged-in PKCS #11 session used by a [PKCS11Shard]. Package
+// github.com/bastionzero/keysplitting/pkcs11token implements it with github.com/miekg/pkcs11, which needs cgo, so that this
+// package doesn't. Its methods are expected to:
+//
+//   - CreateRSAPrivateKey calls C_CreateObject with CKA_CLASS = CKO_PRIVATE_KEY, CKA_KEY_TYPE = CKK_RSA, CKA_TOKEN = true,
+//     CKA_PRIVATE = true, CKA_SENSITIVE = true, CKA_EXTRACTABLE = false, CKA_SIGN = true, and the given CKA_LABEL,
+//     CKA_MODULUS, CKA_PUBLIC_EXPONENT and CKA_PRIVATE_EXPONENT. The token must accept an RSA key without CRT parameters
+//   - SignRSAX509 calls C_SignInit with CKM_RSA_X_509 and then C_Sign, i.e. raw RSA on a block as long as the modulus
+//   - DestroyObject calls C_DestroyObject
+type PKCS11Token interface {
+	CreateRSAPrivateKey(label string, modulus, publicExponent, privateExponent []byte) (PKCS11Object, error)
+	SignRSAX509(key PKCS11Object, data []byte) ([]byte, error)
+	DestroyObject(key PKCS11Object) error
+}
+
+// PKCS11ShardInfo is the public information about a shard held on a PKCS #11 token, which is needed to use it again with
+// [OpenPKCS11Shard] after a restart
+type PKCS11ShardInfo struct {
+	PublicKey *rsa.PublicKey
+	SplitBy   SplitBy
+	Index     int
+	// Negative is set if the shard's exponent is negative, in which case the token holds its absolute value
+	Negative bool
+}
+
+// A PKCS11Shard is a [ShardSigner] whose shard is held as an RSA private key on a PKCS #11 token such as an HSM, so that the
+// shard never exists in process memory while signing. Each exponentiation is a single raw RSA operation on the token.
+// It is safe for concurrent use if the token is
+type PKCS11Shard struct {
+	exponentiationSigner
+
+	token    PKCS11Token
+	key      PKCS11Object
+	negative bool
+
+	mu        sync.Mutex
+	destroyed bool
+}
+
+// ImportPKCS11Shard imports the shard into the token as a non-extractable RSA private key with the given label, and returns
+// a PKCS11Shard that signs with it. Since the token only accepts non-negative exponents, a negative additive shard is stored
+// as its absolute value and its input is inverted first. Masked shards can't be imported, because their exponent changes
+// from one signature to the next, and neither can shards longer than the modulus, which tokens reject. The last shard of a
+// [SplitOptions].PhiMultiple split and multiplicative shards split without [SplitOptions].EqualLength can be, and the
+// sub-shards of [SplitShard] always are. The caller should [PrivateKeyShard.Zeroize] the shard once it has been imported
+func ImportPKCS11Shard(token PKCS11Token, label string, shard *PrivateKeyShard) (*PKCS11Shard, error) {
+	if err := shard.Validate(); err != nil {
+		return nil, err
+	}
+	if shard.Mask != nil {
+		return nil, errorf(ErrInvalidShard, "masked shards can't be held on a PKCS #11 token")
+	}
+	if shard.D.BitLen() > shard.PublicKey.N.BitLen() {
+		return nil, errorf(ErrInvalidShard, "shards longer than the modulus can't be held on a PKCS #11 token")
+	}
+
+	exponent := new(big.Int).Abs(shard.D)
+	privateExponent := exponent.Bytes()
+	defer zeroize(exponent)
+	defer zeroizeBytes(privateExponent)
+
+	key, err := token.CreateRSAPrivateKey(label, shard.PublicKey.N.Bytes(), big.NewInt(int64(shard.PublicKey.E)).Bytes(), privateExponent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to import shard into PKCS #11 token: %w", err)
+	}
+
+	return OpenPKCS11Shard(token, key, PKCS11ShardInfo{
+		PublicKey: shard.PublicKey,
+		SplitBy:   shard.SplitBy,
+		Index:     shard.Index,
+		Negative:  shard.D.Sign() < 0,
+	})
+}
+
+// OpenPKCS11Shard returns a PKCS11Shard that signs with a shard already imported into the token by [ImportPKCS11Shard]
+func OpenPKCS11Shard(token PKCS11Token, key PKCS11Object, info PKCS11ShardInfo) (*PKCS11Shard, error) {
+	if info.PublicKey == nil {
+		return nil, errorf(ErrInvalidShard, "shard has no public key")
+	}
+	if err := checkSplitBy(info.SplitBy); err != nil {
+		return nil, err
+	}
+	if info.Negative && info.SplitBy != Addition {
+		return nil, errorf(ErrInvalidShard, "only additive shards can be negative")
+	}
+
+	s := &PKCS11Shard{token: token, key: key, negative: info.Negative}
+	s.shard = &PrivateKeyShard{PublicKey: info.PublicKey, SplitBy: info.SplitBy, Index: info.Index}
+	s.exp = s.exponentiate
+	return s, nil
+}
+
+// Info returns the public information needed to open the shard again with [OpenPKCS11Shard]
+func (s *PKCS11Shard) Info() PKCS11ShardInfo {
+	return PKCS11ShardInfo{
+		PublicKey: s.shard.PublicKey,
+		SplitBy:   s.shard.SplitBy,
+		Index:     s.shard.Index,
+		Negative:  s.negative,
+	}
+}
+
+// Object returns the handle of the shard's private key object on the token
+func (s *PKCS11Shard) Object() PKCS11Object {
+	return s.key
+}
+
+// Destroy deletes the shard from the token. The PKCS11Shard can't be used afterwards, and fails with [ErrClosed]
+func (s *PKCS11Shard) Destroy() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.destroyed {
+		return errorf(ErrClosed, "PKCS #11 shard has already been destroyed")
+	}
+	if err := s.token.DestroyObject(s.key); err != nil {
+		return fmt.Errorf("failed to destroy PKCS #11 shard: %w", err)
+	}
+	s.destroyed = true
+	return nil
+}
+
+// returns m^D (mod N), computed on the token
+func (s *PKCS11Shard) exponentiate(m *big.Int) (*big.Int, error) {
+	s.mu.Lock()
+	destroyed := s.destroyed
+	s.mu.Unlock()
+	if destroyed {
+		return nil, errorf(ErrClosed, "PKCS #11 shard has been destroyed")
+	}
+
+	pub := s.shard.PublicKey
+	if s.negative {
+		// m^-|D| = (m^-1)^|D|, and the inverse is of public data, so it can be computed outside the token
+		if m = new(big.Int).ModInverse(m, pub.N); m == nil {
+			return nil, errorf(ErrInvalidPartialSignature, "message representative is not invertible")
+		}
+	}
+
+	c, err := s.token.SignRSAX509(s.key, m.FillBytes(make([]byte, pub.Size())))
+	if err != nil {
+		return nil, fmt.Errorf("PKCS #11 token failed to sign: %w", err)
+	}
+	result := new(big.Int).SetBytes(c)
+	if len(c) != pub.Size() || result.Cmp(pub.N) >= 0 {
+		return nil, fmt.Errorf("PKCS #11 token returned a malformed signature")
+	}
+	return result, nil
+}
@@ -0,0 +1,155 @@
+package keysplitting
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// a PKCS11Token that keeps its keys in memory, standing in for an HSM. Like one, it refuses private exponents longer than the
+// modulus
+type fakePKCS11Token struct {
+	keys map[PKCS11Object]*rsa.PrivateKey
+	next PKCS11Object
+}
+
+func newFakePKCS11Token() *fakePKCS11Token {
+	return &fakePKCS11Token{keys: map[PKCS11Object]*rsa.PrivateKey{}}
+}
+
+func (t *fakePKCS11Token) CreateRSAPrivateKey(label string, modulus, publicExponent, privateExponent []byte) (PKCS11Object, error) {
+	if new(big.Int).SetBytes(privateExponent).BitLen() > new(big.Int).SetBytes(modulus).BitLen() {
+		return 0, fmt.Errorf("CKR_ATTRIBUTE_VALUE_INVALID")
+	}
+	t.next++
+	t.keys[t.next] = &rsa.PrivateKey{
+		PublicKey: rsa.PublicKey{N: new(big.Int).SetBytes(modulus), E: int(new(big.Int).SetBytes(publicExponent).Int64())},
+		D:         new(big.Int).SetBytes(privateExponent),
+	}
+	return t.next, nil
+}
+
+func (t *fakePKCS11Token) SignRSAX509(key PKCS11Object, data []byte) ([]byte, error) {
+	priv, ok := t.keys[key]
+	if !ok {
+		return nil, fmt.Errorf("CKR_KEY_HANDLE_INVALID")
+	}
+	if len(data) != priv.Size() {
+		return nil, fmt.Errorf("CKR_DATA_LEN_RANGE")
+	}
+	m := new(big.Int).SetBytes(data)
+	return m.Exp(m, priv.D, priv.N).FillBytes(make([]byte, priv.Size())), nil
+}
+
+func (t *fakePKCS11Token) DestroyObject(key PKCS11Object) error {
+	if _, ok := t.keys[key]; !ok {
+		return fmt.Errorf("CKR_OBJECT_HANDLE_INVALID")
+	}
+	delete(t.keys, key)
+	return nil
+}
+
+var _ = Describe("PKCS #11 shards", func() {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	hashed := sha256.Sum256([]byte("TEST MESSAGE"))
+
+	It("Signs with additive shards on a token, including negative ones", func() {
+		token := newFakePKCS11Token()
+		shards, err := SplitD(key, 3, Addition)
+		Expect(err).To(BeNil())
+		// a shard is only determined modulo phi(N), so subtracting phi(N) leaves it negative but still valid
+		shards[0].D.Sub(shards[0].D, eulerTotient(key.Primes))
+		Expect(shards[0].D.Sign()).To(Equal(-1))
+
+		signers := make([]ShardSigner, len(shards))
+		for i, shard := range shards {
+			signers[i], err = ImportPKCS11Shard(token, fmt.Sprintf("shard %d", i), shard)
+			Expect(err).To(BeNil())
+		}
+
+		partials := make([]*PartialSignature, len(signers))
+		for i, signer := range signers {
+			partials[i], err = signer.SignFirst(rand.Reader, crypto.SHA256, hashed[:])
+			Expect(err).To(BeNil())
+		}
+		sig, err := CombinePartialSignatures(&key.PublicKey, partials...)
+		Expect(err).To(BeNil())
+		Expect(rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, hashed[:], sig)).To(Succeed())
+	})
+
+	It("Signs with multiplicative shards alongside in-memory ones", func() {
+		token := newFakePKCS11Token()
+		// without EqualLength, the last multiplicative shard is longer than the modulus
+		shards, err := SplitDWithOptions(key, 2, Multiplication, &SplitOptions{EqualLength: true})
+		Expect(err).To(BeNil())
+		hsmShard, err := ImportPKCS11Shard(token, "shard", shards[1])
+		Expect(err).To(BeNil())
+
+		partialSig, err := SignFirst(rand.Reader, shards[0], crypto.SHA256, hashed[:])
+		Expect(err).To(BeNil())
+		partialSig, err = hsmShard.SignNext(rand.Reader, crypto.SHA256, hashed[:], partialSig)
+		Expect(err).To(BeNil())
+		Expect(rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, hashed[:], partialSig.Sig)).To(Succeed())
+	})
+
+	It("Reopens a shard already on the token", func() {
+		token := newFakePKCS11Token()
+		shards, err := SplitD(key, 2, Addition)
+		Expect(err).To(BeNil())
+		imported, err := ImportPKCS11Shard(token, "shard", shards[0])
+		Expect(err).To(BeNil())
+
+		reopened, err := OpenPKCS11Shard(token, imported.Object(), imported.Info())
+		Expect(err).To(BeNil())
+		partialSig, err := reopened.SignFirst(rand.Reader, crypto.SHA256, hashed[:])
+		Expect(err).To(BeNil())
+		expected, err := SignFirst(rand.Reader, shards[0], crypto.SHA256, hashed[:])
+		Expect(err).To(BeNil())
+		Expect(partialSig).To(Equal(expected))
+	})
+
+	It("Refuses masked shards", func() {
+		shards, err := SplitDWithOptions(key, 2, Addition, &SplitOptions{Mask: true})
+		Expect(err).To(BeNil())
+
+		_, err = ImportPKCS11Shard(newFakePKCS11Token(), "shard", shards[0])
+		Expect(errors.Is(err, ErrInvalidShard)).To(BeTrue())
+	})
+
+	It("Refuses shards longer than the modulus", func() {
+		shards, err := SplitD(key, 2, Addition)
+		Expect(err).To(BeNil())
+		sub, err := SplitShard(shards[0], 2, 3)
+		Expect(err).To(BeNil())
+		Expect(sub[0].D.BitLen()).To(BeNumerically(">", key.N.BitLen()))
+
+		token := newFakePKCS11Token()
+		_, err = ImportPKCS11Shard(token, "sub-shard", sub[0])
+		Expect(errors.Is(err, ErrInvalidShard)).To(BeTrue())
+		Expect(token.keys).To(BeEmpty())
+
+		_, err = token.CreateRSAPrivateKey("sub-shard", key.N.Bytes(), big.NewInt(int64(key.E)).Bytes(), sub[0].D.Bytes())
+		Expect(err).NotTo(BeNil())
+	})
+
+	It("Can't sign once destroyed", func() {
+		token := newFakePKCS11Token()
+		shards, err := SplitD(key, 2, Addition)
+		Expect(err).To(BeNil())
+		hsmShard, err := ImportPKCS11Shard(token, "shard", shards[0])
+		Expect(err).To(BeNil())
+
+		Expect(hsmShard.Destroy()).To(Succeed())
+		Expect(token.keys).To(BeEmpty())
+		_, err = hsmShard.SignFirst(rand.Reader, crypto.SHA256, hashed[:])
+		Expect(errors.Is(err, ErrClosed)).To(BeTrue())
+		Expect(errors.Is(hsmShard.Destroy(), ErrClosed)).To(BeTrue())
+	})
+})
@@ -0,0 +1,175 @@
+/*
+Package pkcs11token implements keysplitting.PKCS11Token with github.com/miekg/pkcs11, so that a keysplitting.PKCS11Shard can
+hold its shard on a hardware security module or any other token with a PKCS #11 module:
+
+	token, err := pkcs11token.Open("/usr/lib/softhsm/libsofthsm2.so", "shards", pin)
+	...
+	defer token.Close()
+	shard, err := keysplitting.ImportPKCS11Shard(token, "release-key-1", privateKeyShard)
+
+It is a separate package because the binding loads the module with cgo, which the keysplitting package doesn't need
+*/
+package pkcs11token
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/bastionzero/keysplitting"
+	"github.com/miekg/pkcs11"
+)
+
+// A Token is a logged-in session with a PKCS #11 token. It is safe for concurrent use, making one call to the token at a time
+type Token struct {
+	mu      sync.Mutex
+	ctx     *pkcs11.Ctx
+	session pkcs11.SessionHandle
+	owned   bool
+}
+
+var _ keysplitting.PKCS11Token = (*Token)(nil)
+
+// Open loads the PKCS #11 module at modulePath, opens a read-write session with the token labelled tokenLabel, and logs in
+// as the normal user with pin. The token must be closed with [Token.Close]
+func Open(modulePath, tokenLabel, pin string) (*Token, error) {
+	ctx := pkcs11.New(modulePath)
+	if ctx == nil {
+		return nil, fmt.Errorf("failed to load PKCS #11 module %s", modulePath)
+	}
+	if err := ctx.Initialize(); err != nil {
+		ctx.Destroy()
+		return nil, fmt.Errorf("failed to initialize PKCS #11 module: %w", err)
+	}
+
+	session, err := openSession(ctx, tokenLabel, pin)
+	if err != nil {
+		_ = ctx.Finalize()
+		ctx.Destroy()
+		return nil, err
+	}
+	return &Token{ctx: ctx, session: session, owned: true}, nil
+}
+
+// New returns a Token that uses a session the caller has already opened and logged in to with ctx. Closing the Token
+// doesn't close the session
+func New(ctx *pkcs11.Ctx, session pkcs11.SessionHandle) *Token {
+	return &Token{ctx: ctx, session: session}
+}
+
+// opens a session with the token labelled tokenLabel and logs in with pin
+func openSession(ctx *pkcs11.Ctx, tokenLabel, pin string) (pkcs11.SessionHandle, error) {
+	slots, err := ctx.GetSlotList(true)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list PKCS #11 slots: %w", err)
+	}
+	for _, slot := range slots {
+		info, err := ctx.GetTokenInfo(slot)
+		if err != nil {
+			return 0, fmt.Errorf("failed to read PKCS #11 token info: %w", err)
+		}
+		if info.Label != tokenLabel {
+			continue
+		}
+
+		session, err := ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION|pkcs11.CKF_RW_SESSION)
+		if err != nil {
+			return 0, fmt.Errorf("failed to open PKCS #11 session: %w", err)
+		}
+		if err := ctx.Login(session, pkcs11.CKU_USER, pin); err != nil && err != pkcs11.Error(pkcs11.CKR_USER_ALREADY_LOGGED_IN) {
+			_ = ctx.CloseSession(session)
+			return 0, fmt.Errorf("failed to log in to PKCS #11 token: %w", err)
+		}
+		return session, nil
+	}
+	return 0, fmt.Errorf("no PKCS #11 token labelled %q", tokenLabel)
+}
+
+// CreateRSAPrivateKey implements keysplitting.PKCS11Token, creating a sensitive, non-extractable RSA private key object that
+// can only sign
+func (t *Token) CreateRSAPrivateKey(label string, modulus, publicExponent, privateExponent []byte) (keysplitting.PKCS11Object, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key, err := t.ctx.CreateObject(t.session, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_PRIVATE_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_RSA),
+		pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+		pkcs11.NewAttribute(pkcs11.CKA_PRIVATE, true),
+		pkcs11.NewAttribute(pkcs11.CKA_SENSITIVE, true),
+		pkcs11.NewAttribute(pkcs11.CKA_EXTRACTABLE, false),
+		pkcs11.NewAttribute(pkcs11.CKA_SIGN, true),
+		pkcs11.NewAttribute(pkcs11.CKA_DECRYPT, false),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
+		pkcs11.NewAttribute(pkcs11.CKA_MODULUS, modulus),
+		pkcs11.NewAttribute(pkcs11.CKA_PUBLIC_EXPONENT, publicExponent),
+		pkcs11.NewAttribute(pkcs11.CKA_PRIVATE_EXPONENT, privateExponent),
+	})
+	if err != nil {
+		return 0, err
+	}
+	return keysplitting.PKCS11Object(key), nil
+}
+
+// SignRSAX509 implements keysplitting.PKCS11Token with the CKM_RSA_X_509 mechanism
+func (t *Token) SignRSAX509(key keysplitting.PKCS11Object, data []byte) ([]byte, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	mechanism := []*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_RSA_X_509, nil)}
+	if err := t.ctx.SignInit(t.session, mechanism, pkcs11.ObjectHandle(key)); err != nil {
+		return nil, err
+	}
+	return t.ctx.Sign(t.session, data)
+}
+
+// DestroyObject implements keysplitting.PKCS11Token
+func (t *Token) DestroyObject(key keysplitting.PKCS11Object) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.ctx.DestroyObject(t.session, pkcs11.ObjectHandle(key))
+}
+
+// FindObject returns the handle of the private key object with the given label, e.g. to reopen a shard with
+// keysplitting.OpenPKCS11Shard after a restart
+func (t *Token) FindObject(label string) (keysplitting.PKCS11Object, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	template := []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_PRIVATE_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
+	}
+	if err := t.ctx.FindObjectsInit(t.session, template); err != nil {
+		return 0, err
+	}
+	objects, _, err := t.ctx.FindObjects(t.session, 2)
+	if finalErr := t.ctx.FindObjectsFinal(t.session); err == nil {
+		err = finalErr
+	}
+	if err != nil {
+		return 0, err
+	}
+	if len(objects) != 1 {
+		return 0, fmt.Errorf("found %d private keys labelled %q, rather than one", len(objects), label)
+	}
+	return keysplitting.PKCS11Object(objects[0]), nil
+}
+
+// Close logs out, closes the session and unloads the module if the token was opened with [Open], after which it can't be
+// used. Otherwise, it does nothing
+func (t *Token) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.owned || t.ctx == nil {
+		return nil
+	}
+	_ = t.ctx.Logout(t.session)
+	err := t.ctx.CloseSession(t.session)
+	if finalErr := t.ctx.Finalize(); err == nil {
+		err = finalErr
+	}
+	t.ctx.Destroy()
+	t.ctx = nil
+	return err
+}
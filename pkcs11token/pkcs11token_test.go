@@ -0,0 +1,77 @@
+package pkcs11token
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/bastionzero/keysplitting"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestPKCS11Token(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "PKCS11Token Suite")
+}
+
+// The specs run against a real token, such as one created with
+//
+//	softhsm2-util --init-token --free --label shards --pin 1234 --so-pin 1234
+//
+// and are skipped unless KEYSPLITTING_PKCS11_MODULE, KEYSPLITTING_PKCS11_TOKEN and KEYSPLITTING_PKCS11_PIN name it
+var _ = Describe("PKCS #11 token", func() {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	hashed := sha256.Sum256([]byte("TEST MESSAGE"))
+	var token *Token
+
+	BeforeEach(func() {
+		modulePath := os.Getenv("KEYSPLITTING_PKCS11_MODULE")
+		if modulePath == "" {
+			Skip("KEYSPLITTING_PKCS11_MODULE is not set")
+		}
+		var err error
+		token, err = Open(modulePath, os.Getenv("KEYSPLITTING_PKCS11_TOKEN"), os.Getenv("KEYSPLITTING_PKCS11_PIN"))
+		Expect(err).To(BeNil())
+		DeferCleanup(token.Close)
+	})
+
+	It("Signs with shards held on the token", func() {
+		shards, err := keysplitting.SplitD(key, 2, keysplitting.Addition)
+		Expect(err).To(BeNil())
+
+		partials := make([]*keysplitting.PartialSignature, len(shards))
+		for i, shard := range shards {
+			held, err := keysplitting.ImportPKCS11Shard(token, "keysplitting-test", shard)
+			Expect(err).To(BeNil())
+			DeferCleanup(held.Destroy)
+
+			partials[i], err = held.SignFirst(rand.Reader, crypto.SHA256, hashed[:])
+			Expect(err).To(BeNil())
+		}
+		sig, err := keysplitting.CombinePartialSignatures(&key.PublicKey, partials...)
+		Expect(err).To(BeNil())
+		Expect(rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, hashed[:], sig)).To(Succeed())
+	})
+
+	It("Finds shards by label", func() {
+		shards, err := keysplitting.SplitDWithOptions(key, 2, keysplitting.Multiplication, &keysplitting.SplitOptions{EqualLength: true})
+		Expect(err).To(BeNil())
+		held, err := keysplitting.ImportPKCS11Shard(token, "keysplitting-find-test", shards[0])
+		Expect(err).To(BeNil())
+
+		object, err := token.FindObject("keysplitting-find-test")
+		Expect(err).To(BeNil())
+		Expect(object).To(Equal(held.Object()))
+
+		Expect(held.Destroy()).To(Succeed())
+		_, err = token.FindObject("keysplitting-find-test")
+		Expect(err).NotTo(BeNil())
+		_, err = held.SignFirst(rand.Reader, crypto.SHA256, hashed[:])
+		Expect(errors.Is(err, keysplitting.ErrClosed)).To(BeTrue())
+	})
+})
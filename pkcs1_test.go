@@ -0,0 +1,90 @@
+package keysplitting
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"math/big"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PKCS #1 export", func() {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	shards, _ := SplitD(key, 2, Multiplication)
+	hashed := sha256.Sum256([]byte("TEST MESSAGE"))
+
+	// decodes an exported shard the way a tool that doesn't validate keys would
+	parseExported := func(encoded string) pkcs1PrivateKey {
+		block, rest := pem.Decode([]byte(encoded))
+		Expect(block).NotTo(BeNil())
+		Expect(block.Type).To(Equal("RSA PRIVATE KEY"))
+		Expect(rest).To(BeEmpty())
+
+		var exported pkcs1PrivateKey
+		_, err := asn1.Unmarshal(block.Bytes, &exported)
+		Expect(err).To(BeNil())
+		return exported
+	}
+
+	It("Exports a degenerate key with the shard as its private exponent", func() {
+		encoded, err := shards[0].ExportAsRSAPrivateKey()
+		Expect(err).To(BeNil())
+
+		exported := parseExported(encoded)
+		Expect(exported.Version).To(Equal(0))
+		Expect(exported.N).To(Equal(key.N))
+		Expect(exported.E).To(Equal(key.E))
+		Expect(exported.D).To(Equal(shards[0].D))
+		Expect(exported.P.Sign()).To(Equal(0))
+		Expect(exported.Q.Sign()).To(Equal(0))
+	})
+
+	It("Produces the same partial signatures as the shard", func() {
+		em, err := emsaPKCS1v15Encode(crypto.SHA256, hashed[:], key.Size())
+		Expect(err).To(BeNil())
+
+		// a plain RSA private-key operation with each exported key in turn
+		sig := new(big.Int).SetBytes(em)
+		for _, shard := range shards {
+			encoded, err := shard.ExportAsRSAPrivateKey()
+			Expect(err).To(BeNil())
+			exported := parseExported(encoded)
+			sig.Exp(sig, exported.D, exported.N)
+		}
+
+		Expect(rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, hashed[:], sig.FillBytes(make([]byte, key.Size())))).To(Succeed())
+	})
+
+	It("Is rejected by x509.ParsePKCS1PrivateKey", func() {
+		encoded, err := shards[0].ExportAsRSAPrivateKey()
+		Expect(err).To(BeNil())
+
+		block, _ := pem.Decode([]byte(encoded))
+		_, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+		Expect(err).NotTo(BeNil())
+	})
+
+	It("Refuses to export negative or masked shards", func() {
+		masked, err := SplitDWithOptions(key, 2, Addition, &SplitOptions{Mask: true})
+		Expect(err).To(BeNil())
+		_, err = masked[0].ExportAsRSAPrivateKey()
+		Expect(errors.Is(err, ErrInvalidShard)).To(BeTrue())
+
+		additive, err := SplitD(key, 2, Addition)
+		Expect(err).To(BeNil())
+		negative := &PrivateKeyShard{
+			PublicKey: additive[0].PublicKey,
+			D:         new(big.Int).Neg(additive[0].D),
+			SplitBy:   Addition,
+		}
+		_, err = negative.ExportAsRSAPrivateKey()
+		Expect(errors.Is(err, ErrInvalidShard)).To(BeTrue())
+	})
+})
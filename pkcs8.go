@@ -0,0 +1,75 @@
+package keysplitting
+
+import (
+	"bytes"
+	"encoding/asn1"
+	"fmt"
+)
+
+// oidSplitRSAPrivateKey identifies an RSA split private key shard in the algorithm identifier of a PKCS #8-style encoding.
+// It is the UUID-based OID 2.25.320285206588671432109912103336472554591 (see ITU-T X.667), which doesn't need to be registered.
+// Its last arc doesn't fit in an asn1.ObjectIdentifier, so we keep its DER encoding instead
+var oidSplitRSAPrivateKey = []byte{
+	0x06, 0x14, 0x69, 0x83, 0xe1, 0xf4, 0xd7, 0xf7, 0xff, 0x99, 0xd2, 0x80,
+	0xcb, 0xaf, 0xbe, 0xf2, 0x9d, 0xc3, 0xdb, 0xe4, 0xd8, 0x5f,
+}
+
+// the only version of the PKCS #8-style encoding so far
+const splitPrivateKeyInfoVersion = 0
+
+// used exclusively as a placeholder for encoding-decoding
+type algorithmIdentifier struct {
+	Algorithm  asn1.RawValue
+	Parameters asn1.RawValue `asn1:"optional"`
+}
+
+// used exclusively as a placeholder for encoding-decoding. It mirrors PrivateKeyInfo from RFC 5208
+type splitPrivateKeyInfo struct {
+	Version    int
+	Algorithm  algorithmIdentifier
+	PrivateKey []byte
+}
+
+// MarshalPKCS8PrivateKeyShard converts a shard to a PKCS #8-style DER encoding, in which the same encoding used by
+// [PrivateKeyShard.EncodePEM] is wrapped in a PrivateKeyInfo with a version and an algorithm identifier. This lets shards
+// be stored by generic key-management tooling, which can tell them apart from other keys without understanding them.
+// Use [ParsePKCS8PrivateKeyShard] to decode it
+func MarshalPKCS8PrivateKeyShard(pks *PrivateKeyShard) ([]byte, error) {
+	privateKey, err := pks.marshalDER()
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := asn1.Marshal(splitPrivateKeyInfo{
+		Version: splitPrivateKeyInfoVersion,
+		Algorithm: algorithmIdentifier{
+			Algorithm:  asn1.RawValue{FullBytes: oidSplitRSAPrivateKey},
+			Parameters: asn1.NullRawValue,
+		},
+		PrivateKey: privateKey,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to DER-encode: %s", err)
+	}
+	return b, nil
+}
+
+// ParsePKCS8PrivateKeyShard decodes a shard encoded by [MarshalPKCS8PrivateKeyShard], rejecting any that fails [PrivateKeyShard.Validate]
+func ParsePKCS8PrivateKeyShard(der []byte) (*PrivateKeyShard, error) {
+	var info splitPrivateKeyInfo
+	rest, err := asn1.Unmarshal(der, &info)
+	if err != nil {
+		return nil, errorf(ErrInvalidShard, "failed to unmarshal PKCS #8 private key shard: %s", err)
+	}
+	if len(rest) > 0 {
+		return nil, errorf(ErrInvalidShard, "failed to unmarshal PKCS #8 private key shard")
+	}
+	if info.Version != splitPrivateKeyInfoVersion {
+		return nil, errorf(ErrInvalidShard, "unsupported PKCS #8 private key shard version %d", info.Version)
+	}
+	if !bytes.Equal(info.Algorithm.Algorithm.FullBytes, oidSplitRSAPrivateKey) {
+		return nil, errorf(ErrInvalidShard, "PKCS #8 private key is not an RSA split private key shard")
+	}
+
+	return unmarshalDER(info.PrivateKey)
+}
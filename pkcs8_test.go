@@ -0,0 +1,78 @@
+package keysplitting
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/asn1"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PKCS #8 encoding", func() {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	shards, _ := SplitD(key, 2, Multiplication)
+
+	It("Round-trips", func() {
+		der, err := MarshalPKCS8PrivateKeyShard(shards[0])
+		Expect(err).To(BeNil())
+
+		decoded, err := ParsePKCS8PrivateKeyShard(der)
+		Expect(err).To(BeNil())
+		Expect(decoded.Equal(shards[0])).To(BeTrue())
+	})
+
+	It("Identifies the algorithm with the expected OID", func() {
+		der, err := MarshalPKCS8PrivateKeyShard(shards[0])
+		Expect(err).To(BeNil())
+
+		var info struct {
+			Version   int
+			Algorithm struct {
+				Algorithm  asn1.RawValue
+				Parameters asn1.RawValue `asn1:"optional"`
+			}
+			PrivateKey []byte
+		}
+		_, err = asn1.Unmarshal(der, &info)
+		Expect(err).To(BeNil())
+		Expect(info.Version).To(Equal(0))
+		Expect(info.Algorithm.Algorithm.Tag).To(Equal(asn1.TagOID))
+		Expect(info.Algorithm.Algorithm.FullBytes).To(Equal(oidSplitRSAPrivateKey))
+	})
+
+	It("Is rejected by x509.ParsePKCS8PrivateKey", func() {
+		der, err := MarshalPKCS8PrivateKeyShard(shards[0])
+		Expect(err).To(BeNil())
+
+		_, err = x509.ParsePKCS8PrivateKey(der)
+		Expect(err).NotTo(BeNil())
+	})
+
+	It("Rejects ordinary PKCS #8 keys", func() {
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		Expect(err).To(BeNil())
+
+		_, err = ParsePKCS8PrivateKeyShard(der)
+		Expect(errors.Is(err, ErrInvalidShard)).To(BeTrue())
+	})
+
+	It("Rejects unknown versions", func() {
+		privateKey, err := shards[0].marshalDER()
+		Expect(err).To(BeNil())
+		der, err := asn1.Marshal(splitPrivateKeyInfo{
+			Version: 1,
+			Algorithm: algorithmIdentifier{
+				Algorithm:  asn1.RawValue{FullBytes: oidSplitRSAPrivateKey},
+				Parameters: asn1.NullRawValue,
+			},
+			PrivateKey: privateKey,
+		})
+		Expect(err).To(BeNil())
+
+		_, err = ParsePKCS8PrivateKeyShard(der)
+		Expect(errors.Is(err, ErrInvalidShard)).To(BeTrue())
+	})
+})
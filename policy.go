@@ -0,0 +1,218 @@
+package keysplitting
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// A QuorumPolicy decides whether a set of shards may produce a complete signature, beyond the split scheme's own requirement
+// that every shard signs. It is declarative, so that it can be kept in a configuration file as JSON, e.g.
+//
+//	{
+//	  "labels": {"1": ["security-team", "us"], "2": ["us"], "3": ["eu"]},
+//	  "rule": {"all_of": [{"label": "security-team"}, {"label": "us"}, {"label": "eu"}]}
+//	}
+//
+// requires the security team's shard and at least one shard from each region. Rules are evaluated against the signers recorded
+// in each [PartialSignature], so shards must have known indices to count towards one. Since the additive and multiplicative
+// schemes need every shard anyway, a policy matters most for threshold signatures, where any t shares could otherwise sign
+type QuorumPolicy struct {
+	Labels  map[int][]string `json:"labels,omitempty"`  // labels for each shard, by index, such as teams or regions
+	Weights map[int]int      `json:"weights,omitempty"` // the number of approvers each shard counts as, by index (default 1)
+	Rule    QuorumRule       `json:"rule"`              // the rule that the signers must satisfy
+}
+
+// A QuorumRule is a condition on the set of shards that have signed. Exactly one of Shard, Label, Weight, AllOf, or AnyOf
+// must be set. Shards are counted by their weight in [QuorumPolicy].Weights
+type QuorumRule struct {
+	Shard  int          `json:"shard,omitempty"`  // satisfied if the shard with this index has signed
+	Label  string       `json:"label,omitempty"`  // satisfied if shards with this label and a total weight of at least Min have signed
+	Weight int          `json:"weight,omitempty"` // satisfied if shards with a total weight of at least this have signed
+	AllOf  []QuorumRule `json:"all_of,omitempty"` // satisfied if every one of the rules is
+	AnyOf  []QuorumRule `json:"any_of,omitempty"` // satisfied if at least Min of the rules are
+	Min    int          `json:"min,omitempty"`    // the weight or number of rules required by Label or AnyOf (default 1)
+}
+
+// ParseQuorumPolicy decodes a policy from JSON and checks that it is well-formed
+func ParseQuorumPolicy(data []byte) (*QuorumPolicy, error) {
+	var policy QuorumPolicy
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal quorum policy: %w", err)
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// Validate checks that every rule in the policy is well-formed
+func (p *QuorumPolicy) Validate() error {
+	for index := range p.Labels {
+		if index <= 0 {
+			return fmt.Errorf("quorum policy labels shard %d, but shard indices start at 1", index)
+		}
+	}
+	for index, weight := range p.Weights {
+		if index <= 0 {
+			return fmt.Errorf("quorum policy weighs shard %d, but shard indices start at 1", index)
+		}
+		if weight < 1 {
+			return fmt.Errorf("quorum policy gives shard %d a weight of %d, but weights must be at least 1", index, weight)
+		}
+	}
+	return p.Rule.validate()
+}
+
+func (r *QuorumRule) validate() error {
+	set := 0
+	for _, isSet := range []bool{r.Shard != 0, r.Label != "", r.Weight != 0, r.AllOf != nil, r.AnyOf != nil} {
+		if isSet {
+			set++
+		}
+	}
+	if set != 1 {
+		return fmt.Errorf("quorum rule must set exactly one of shard, label, weight, all_of, or any_of")
+	}
+
+	switch {
+	case r.Shard < 0:
+		return fmt.Errorf("quorum rule requires shard %d, but shard indices start at 1", r.Shard)
+	case r.Weight < 0:
+		return fmt.Errorf("quorum rule requires a negative weight")
+	case r.Min < 0:
+		return fmt.Errorf("quorum rule has a negative minimum")
+	case r.Min != 0 && r.Shard != 0, r.Min != 0 && r.Weight != 0, r.Min != 0 && r.AllOf != nil:
+		return fmt.Errorf("quorum rule sets a minimum, which only applies to label and any_of")
+	case r.AnyOf != nil && r.Min > len(r.AnyOf):
+		return fmt.Errorf("quorum rule requires %d of only %d rules", r.Min, len(r.AnyOf))
+	}
+
+	for _, rules := range [][]QuorumRule{r.AllOf, r.AnyOf} {
+		if rules != nil && len(rules) == 0 {
+			return fmt.Errorf("quorum rule has an empty list of rules")
+		}
+		for i := range rules {
+			if err := rules[i].validate(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Check returns nil if the shards that signed the partial signatures satisfy the policy, and otherwise an error wrapping
+// [ErrPolicyNotSatisfied] that describes the first unsatisfied rule
+func (p *QuorumPolicy) Check(partials ...*PartialSignature) error {
+	var signers []int
+	for _, partial := range partials {
+		signers = append(signers, partial.Signers...)
+	}
+	return p.CheckSigners(signers...)
+}
+
+// CheckSigners is like [QuorumPolicy.Check], but takes the indices of the shards that signed directly, e.g. the Index of
+// each [ThresholdPartialSignature]
+func (p *QuorumPolicy) CheckSigners(signers ...int) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+
+	signed := map[int]bool{}
+	for _, signer := range signers {
+		if signer > 0 {
+			signed[signer] = true
+		}
+	}
+	if unmet := p.Rule.unmet(p, signed); unmet != "" {
+		return errorf(ErrPolicyNotSatisfied, "quorum policy requires %s", unmet)
+	}
+	return nil
+}
+
+// describes why the rule isn't satisfied by the signed shards, or returns "" if it is
+func (r *QuorumRule) unmet(p *QuorumPolicy, signed map[int]bool) string {
+	min := r.Min
+	if min == 0 {
+		min = 1
+	}
+
+	switch {
+	case r.Shard != 0:
+		if !signed[r.Shard] {
+			return fmt.Sprintf("shard %d", r.Shard)
+		}
+	case r.Label != "":
+		weight := 0
+		for index := range signed {
+			for _, label := range p.Labels[index] {
+				if label == r.Label {
+					weight += p.weight(index)
+					break
+				}
+			}
+		}
+		if weight < min {
+			return fmt.Sprintf("a weight of %d from shards labeled %q, but only %d signed", min, r.Label, weight)
+		}
+	case r.Weight != 0:
+		weight := 0
+		for index := range signed {
+			weight += p.weight(index)
+		}
+		if weight < r.Weight {
+			return fmt.Sprintf("a total weight of %d, but only %d signed", r.Weight, weight)
+		}
+	case r.AllOf != nil:
+		for i := range r.AllOf {
+			if unmet := r.AllOf[i].unmet(p, signed); unmet != "" {
+				return unmet
+			}
+		}
+	default:
+		var unmet []string
+		for i := range r.AnyOf {
+			if u := r.AnyOf[i].unmet(p, signed); u != "" {
+				unmet = append(unmet, u)
+			}
+		}
+		if len(r.AnyOf)-len(unmet) < min {
+			return fmt.Sprintf("%d of (%s)", min, strings.Join(unmet, "; "))
+		}
+	}
+	return ""
+}
+
+// returns the weight of the shard with the given index
+func (p *QuorumPolicy) weight(index int) int {
+	if weight, ok := p.Weights[index]; ok {
+		return weight
+	}
+	return 1
+}
+
+// CombinePartialSignaturesWithPolicy is like [CombinePartialSignatures], but first checks that the shards that signed
+// satisfy policy
+func CombinePartialSignaturesWithPolicy(pub *rsa.PublicKey, policy *QuorumPolicy, partials ...*PartialSignature) ([]byte, error) {
+	if err := policy.Check(partials...); err != nil {
+		return nil, err
+	}
+	return CombinePartialSignatures(pub, partials...)
+}
+
+// CombineThresholdWithPolicy is like [CombineThreshold], but first checks that the shares that signed satisfy policy.
+// Only the first t partial signatures count, since those are the ones that are combined
+func CombineThresholdWithPolicy(pub *rsa.PublicKey, t, n int, hashFn crypto.Hash, hashed []byte, policy *QuorumPolicy, partials ...*ThresholdPartialSignature) ([]byte, error) {
+	var signers []int
+	for i, partial := range partials {
+		if i < t {
+			signers = append(signers, partial.Index)
+		}
+	}
+	if err := policy.CheckSigners(signers...); err != nil {
+		return nil, err
+	}
+	return CombineThreshold(pub, t, n, hashFn, hashed, partials...)
+}
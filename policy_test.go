@@ -0,0 +1,134 @@
+package keysplitting
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Quorum policies", func() {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	hashed := sha256.Sum256([]byte("TEST MESSAGE"))
+
+	regional, _ := ParseQuorumPolicy([]byte(`{
+		"labels": {"1": ["security-team", "us"], "2": ["us"], "3": ["eu"], "4": ["eu"]},
+		"rule": {"all_of": [{"label": "security-team"}, {"label": "us"}, {"label": "eu"}]}
+	}`))
+
+	It("Requires specific shards and labels", func() {
+		Expect(regional).NotTo(BeNil())
+		Expect(regional.CheckSigners(1, 3)).To(Succeed())
+		Expect(regional.CheckSigners(1, 2, 4)).To(Succeed())
+
+		err := regional.CheckSigners(2, 3)
+		Expect(errors.Is(err, ErrPolicyNotSatisfied)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("security-team"))
+
+		err = regional.CheckSigners(1, 2)
+		Expect(errors.Is(err, ErrPolicyNotSatisfied)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring(`"eu"`))
+
+		// unknown indices never count
+		Expect(errors.Is(regional.CheckSigners(0, 3), ErrPolicyNotSatisfied)).To(BeTrue())
+	})
+
+	It("Counts shards and rules against a minimum", func() {
+		policy := &QuorumPolicy{
+			Labels: map[int][]string{1: {"ops"}, 2: {"ops"}, 3: {"ops"}},
+			Rule: QuorumRule{AnyOf: []QuorumRule{
+				{Label: "ops", Min: 2},
+				{Shard: 4},
+				{Shard: 5},
+			}, Min: 1},
+		}
+		Expect(policy.CheckSigners(1, 2)).To(Succeed())
+		Expect(policy.CheckSigners(5)).To(Succeed())
+		Expect(errors.Is(policy.CheckSigners(1, 1), ErrPolicyNotSatisfied)).To(BeTrue())
+
+		policy.Rule.Min = 2
+		Expect(errors.Is(policy.CheckSigners(1, 2), ErrPolicyNotSatisfied)).To(BeTrue())
+		Expect(policy.CheckSigners(1, 2, 4)).To(Succeed())
+		Expect(policy.CheckSigners(4, 5)).To(Succeed())
+	})
+
+	It("Checks the signers of partial signatures", func() {
+		partials := []*PartialSignature{{Signers: []int{1, 2}}, {Signers: []int{4}}}
+		Expect(regional.Check(partials...)).To(Succeed())
+		Expect(errors.Is(regional.Check(partials[:1]...), ErrPolicyNotSatisfied)).To(BeTrue())
+	})
+
+	It("Rejects malformed policies", func() {
+		for _, encoded := range []string{
+			`{"rule": {}}`,
+			`{"rule": {"shard": 1, "label": "ops"}}`,
+			`{"rule": {"shard": -1}}`,
+			`{"rule": {"shard": 1, "min": 2}}`,
+			`{"rule": {"all_of": []}}`,
+			`{"rule": {"any_of": [{"shard": 1}], "min": 2}}`,
+			`{"rule": {"all_of": [{"shard": 1}, {}]}}`,
+			`{"labels": {"0": ["ops"]}, "rule": {"label": "ops"}}`,
+			`not json`,
+		} {
+			_, err := ParseQuorumPolicy([]byte(encoded))
+			Expect(err).NotTo(BeNil(), encoded)
+		}
+	})
+
+	It("Restricts which threshold shares can combine", func() {
+		shares, err := SplitThreshold(key, 2, 3)
+		Expect(err).To(BeNil())
+		partials := make([]*ThresholdPartialSignature, len(shares))
+		for i, share := range shares {
+			partials[i], err = SignThreshold(share, crypto.SHA256, hashed[:])
+			Expect(err).To(BeNil())
+		}
+		policy := &QuorumPolicy{Rule: QuorumRule{Shard: 1}}
+
+		_, err = CombineThresholdWithPolicy(&key.PublicKey, 2, 3, crypto.SHA256, hashed[:], policy, partials[1], partials[2], partials[0])
+		Expect(errors.Is(err, ErrPolicyNotSatisfied)).To(BeTrue())
+
+		sig, err := CombineThresholdWithPolicy(&key.PublicKey, 2, 3, crypto.SHA256, hashed[:], policy, partials[2], partials[0])
+		Expect(err).To(BeNil())
+		Expect(rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, hashed[:], sig)).To(Succeed())
+	})
+
+	It("Holds back brokered and session signatures until the policy is satisfied", func() {
+		shards, err := SplitD(key, 2, Addition)
+		Expect(err).To(BeNil())
+		subShards, err := SplitShard(shards[1], 2, 3)
+		Expect(err).To(BeNil())
+		policy := &QuorumPolicy{Rule: QuorumRule{Shard: 3}}
+
+		broker, err := NewBroker(&key.PublicKey, 2)
+		Expect(err).To(BeNil())
+		broker.SetPolicy(policy)
+		first, err := SignFirst(rand.Reader, shards[0], broker.SignerOpts(crypto.SHA256), hashed[:])
+		Expect(err).To(BeNil())
+		second, err := SignFirst(rand.Reader, shards[1], broker.SignerOpts(crypto.SHA256), hashed[:])
+		Expect(err).To(BeNil())
+		_, err = broker.AddPartial(first)
+		Expect(err).To(BeNil())
+		_, err = broker.AddPartial(second)
+		Expect(errors.Is(err, ErrPolicyNotSatisfied)).To(BeTrue())
+		Expect(broker.Signature()).To(BeNil())
+
+		session, err := NewSession(&key.PublicKey, Addition, 3, crypto.SHA256, hashed[:])
+		Expect(err).To(BeNil())
+		session.SetPolicy(&QuorumPolicy{Rule: QuorumRule{Shard: 4}})
+		Expect(session.Sign(rand.Reader, shards[0])).To(Succeed())
+		Expect(session.Sign(rand.Reader, subShards[0])).To(Succeed())
+		err = session.Sign(rand.Reader, subShards[1])
+		Expect(errors.Is(err, ErrPolicyNotSatisfied)).To(BeTrue())
+
+		session.SetPolicy(policy)
+		Expect(session.Sign(rand.Reader, subShards[1])).To(Succeed())
+		sig, err := session.Signature()
+		Expect(err).To(BeNil())
+		Expect(rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, hashed[:], sig)).To(Succeed())
+	})
+})
@@ -2,22 +2,163 @@ package keysplitting
 
 import (
 	"bytes"
+	"crypto"
 	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
 	"encoding/asn1"
+	"encoding/binary"
+	"encoding/hex"
 	"encoding/pem"
 	"fmt"
+	"io"
 	"math/big"
 )
 
 const pemType = "RSA SPLIT PRIVATE KEY"
 
+// the largest modulus, in bits, that Validate accepts. The smallest depends on whether this is a simulation build (see [Simulation])
+const maxModulusBits = 16384
+
+// the default limits of DecodeOptions. Shards are at most a few bits longer than phi(N) unless they're deliberately
+// inflated, so twice the largest modulus leaves plenty of room
+const (
+	defaultMaxPrivateExponentBits = 2 * maxModulusBits
+	defaultMaxPublicExponentBits  = 31
+)
+
 // A PrivateKeyShard represents one shard of a split RSA key. The public key matches that of the whole original key
 type PrivateKeyShard struct {
 	PublicKey *rsa.PublicKey // public part
 	D         *big.Int       // split private exponent
 	SplitBy   SplitBy        // the algorithm used to split the original key
-	// someday could have "E minor," the split public exponent
+	Index     int            // the shard's position in 1..k, assigned at split time and recorded in partial signatures (0 if unknown)
+	Mask      *big.Int       // masking exponent for additive shards split with SplitOptions.Mask (nil if unmasked)
+	// there is deliberately no "E minor", a split public exponent: the inverse of any part of D is a trapdoor that factors N
+	// together with the other shards. Partial signatures are checked against public ShardCommitments instead
+}
+
+// Public returns the public key corresponding to the shard, which is the public key of the whole original key
+func (pks *PrivateKeyShard) Public() crypto.PublicKey {
+	return pks.PublicKey
+}
+
+// Equal reports whether pks and x are the same shard of the same key. The secret values are compared in constant time,
+// but as with [rsa.PrivateKey.Equal], x must be a *PrivateKeyShard for the comparison to succeed. Shards without a public key
+// are not equal to anything
+func (pks *PrivateKeyShard) Equal(x crypto.PrivateKey) bool {
+	other, ok := x.(*PrivateKeyShard)
+	if !ok || pks.PublicKey == nil || other.PublicKey == nil {
+		return false
+	}
+	return pks.PublicKey.Equal(other.PublicKey) &&
+		pks.SplitBy == other.SplitBy &&
+		pks.Index == other.Index &&
+		secretEqual(pks.D, other.D) &&
+		secretEqual(pks.Mask, other.Mask)
+}
 
+// Validate performs basic sanity checks on the shard, such as those that catch a corrupt or maliciously crafted encoding.
+// It checks that the public key is of a plausible size, that the shard has a recognized split algorithm, and that D is in
+// range for it: multiplicative shards must be greater than 1, while additive shards must be nonzero but may be negative.
+// Neither has an upper bound, since shards are not always reduced (mod phi(N)) and may be larger than N.
+// A nil error does not mean that the shard actually belongs to the public key, which cannot be checked without the other shards
+func (pks *PrivateKeyShard) Validate() error {
+	if err := pks.checkUsable(); err != nil {
+		return err
+	}
+	if err := checkSplitBy(pks.SplitBy); err != nil {
+		return err
+	}
+	if pks.Index < 0 {
+		return errorf(ErrInvalidShard, "shard index %d is negative", pks.Index)
+	}
+
+	pub := pks.PublicKey
+	if pub == nil || pub.N == nil {
+		return errorf(ErrInvalidShard, "shard has no public key")
+	}
+	if bits := pub.N.BitLen(); pub.N.Sign() <= 0 || bits < minModulusBits || bits > maxModulusBits {
+		return errorf(ErrInvalidShard, "modulus of %d bits is out of range [%d, %d]", bits, minModulusBits, maxModulusBits)
+	}
+	if pub.N.Bit(0) == 0 {
+		return errorf(ErrInvalidShard, "modulus is even")
+	}
+	if pub.E < 3 || pub.E > 1<<31-1 || pub.E%2 == 0 {
+		return errorf(ErrInvalidShard, "public exponent %d is invalid", pub.E)
+	}
+
+	switch pks.SplitBy {
+	case Multiplication:
+		if pks.D.Cmp(bigOne) <= 0 {
+			return errorf(ErrInvalidShard, "multiplicative shard is out of range")
+		}
+		if pks.Mask != nil {
+			return errorf(ErrInvalidShard, "multiplicative shards cannot be masked")
+		}
+	case Addition:
+		if pks.D.Sign() == 0 {
+			return errorf(ErrInvalidShard, "additive shard is out of range")
+		}
+	}
+	return nil
+}
+
+// Zeroize overwrites the shard's private exponent with zeros and marks the shard as unusable, so that any further attempt
+// to sign with, split, merge, refresh, or encode it fails. Call it once a shard is no longer needed, e.g. after it has been
+// refreshed or reshared, rather than leaving the secret in memory until it is garbage collected. The public key is left intact
+func (pks *PrivateKeyShard) Zeroize() {
+	zeroize(pks.D)
+	zeroize(pks.Mask)
+	pks.D = nil
+	pks.Mask = nil
+}
+
+// checks that the shard has a private exponent, i.e. that it has not been zeroized
+func (pks *PrivateKeyShard) checkUsable() error {
+	if pks.D == nil {
+		return errorf(ErrInvalidShard, "shard has been zeroized")
+	}
+	return nil
+}
+
+// KeyID returns a stable identifier for the public key that the shard belongs to, which is shared by all of its shards.
+// It is the hex-encoded SHA-256 hash of the PKCS #1 encoding of the public key
+func (pks *PrivateKeyShard) KeyID() string {
+	return PublicKeyID(pks.PublicKey)
+}
+
+// PublicKeyID returns the key ID of pub, as returned by [PrivateKeyShard.KeyID] for each of its shards
+func PublicKeyID(pub *rsa.PublicKey) string {
+	id := publicKeyID(pub)
+	return hex.EncodeToString(id[:])
+}
+
+// Fingerprint returns a stable identifier for the shard, computed from its public key, index, and split algorithm.
+// It never depends on D, so it is safe to log. It is the hex-encoded SHA-256 hash of the PKCS #1 encoding of the public key,
+// followed by the index as a big-endian 64-bit integer and the name of the split algorithm
+func (pks *PrivateKeyShard) Fingerprint() string {
+	h := sha256.New()
+	h.Write(x509.MarshalPKCS1PublicKey(pks.PublicKey))
+	var index [8]byte
+	binary.BigEndian.PutUint64(index[:], uint64(pks.Index))
+	h.Write(index[:])
+	h.Write([]byte(pks.SplitBy))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Sign produces a partial signature of digest using the shard, so that a PrivateKeyShard satisfies [crypto.Signer].
+// Note that the result is not a valid signature on its own. It is equivalent to calling [SignFirst] and must be combined
+// with the other parties' partial signatures, either by passing it along to [SignNext] or, for additive shards, by
+// having a broker multiply the partial signatures together
+//
+// opts.HashFunc() must be the hash function used to produce digest. Pass a *[PSSOptions] for an RSASSA-PSS partial signature
+func (pks *PrivateKeyShard) Sign(random io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	partialSig, err := SignFirst(random, pks, opts, digest)
+	if err != nil {
+		return nil, err
+	}
+	return partialSig.Sig, nil
 }
 
 // used exclusively as a placeholder for encoding-decoding
@@ -26,27 +167,45 @@ type publicKey struct {
 	E int
 }
 
+// versions of the DER encoding of a shard. Shards encoded before versioning was introduced have no version field, and are
+// treated as version 0. They store D and Mask as unsigned octet strings, so they cannot represent negative additive shards
+const (
+	shardVersionLegacy = 0
+	shardVersion1      = 1
+
+	currentShardVersion = shardVersion1
+)
+
 // used exclusively as a placeholder for encoding-decoding
 type privateKeyShard struct {
 	PublicKey publicKey
 	D         []byte
 	SplitBy   SplitBy
+	Index     int    `asn1:"optional"`
+	Mask      []byte `asn1:"optional"`
+}
+
+// used exclusively as a placeholder for encoding-decoding
+type privateKeyShardV1 struct {
+	Version   int
+	PublicKey publicKey
+	D         *big.Int
+	SplitBy   SplitBy
+	Index     int
+	Mask      *big.Int `asn1:"optional"`
+}
+
+// used exclusively as a placeholder for encoding-decoding. Any fields following the version are ignored, and a missing
+// version is left as shardVersionLegacy
+type shardVersionHeader struct {
+	Version int `asn1:"optional"`
 }
 
 // returns a PEM encoding of the key data
 func (pks *PrivateKeyShard) EncodePEM() (string, error) {
-	// we perform this conversion because asn1.Marshal cannot handle pointer values or unexported fields
-	b, err := asn1.Marshal(privateKeyShard{
-		PublicKey: publicKey{
-			N: pks.PublicKey.N.Bytes(),
-			E: pks.PublicKey.E,
-		},
-		D:       pks.D.Bytes(),
-		SplitBy: pks.SplitBy,
-	})
-
+	b, err := pks.marshalDER()
 	if err != nil {
-		return "", fmt.Errorf("failed to DER-encode: %s", err)
+		return "", err
 	}
 
 	keyPEM := new(bytes.Buffer)
@@ -61,25 +220,248 @@ func (pks *PrivateKeyShard) EncodePEM() (string, error) {
 	return keyPEM.String(), nil
 }
 
-// returns key data from a PEM encoding
+// MarshalBinary implements [encoding.BinaryMarshaler] with the DER encoding inside the shard's PEM block (see
+// [PrivateKeyShard.EncodePEM]), so that shards can be encoded with encoding/gob and stored in caches that expect binary values
+func (pks *PrivateKeyShard) MarshalBinary() ([]byte, error) {
+	return pks.marshalDER()
+}
+
+// UnmarshalBinary implements [encoding.BinaryUnmarshaler], decoding the shard as strictly as [DecodePEM]
+func (pks *PrivateKeyShard) UnmarshalBinary(data []byte) error {
+	shard, err := unmarshalDER(data)
+	if err != nil {
+		return err
+	}
+	*pks = *shard
+	return nil
+}
+
+// returns key data from a PEM encoding, rejecting any that fails [PrivateKeyShard.Validate]. It is strict, meaning that it
+// rejects any data after the shard's PEM block, or within the block after the shard itself (see [DecodeOptions])
 func DecodePEM(encodedPks string) (*PrivateKeyShard, error) {
+	return DecodePEMWithOptions(encodedPks, nil)
+}
+
+// DecodeOptions configures how a shard is decoded by [DecodePEMWithOptions].
+// A nil *DecodeOptions is equivalent to the zero value, which behaves exactly like [DecodePEM]
+type DecodeOptions struct {
+	// Lenient ignores any data after the shard's PEM block, such as other PEM blocks or stray text, and any bytes
+	// after the DER-encoded shard within the block. This is only meant for legacy files written by tools that appended
+	// such data. Everything up to the end of the shard is still checked just as strictly
+	Lenient bool
+
+	// MaxModulusBits limits the size of the shard's modulus N, and MaxPrivateExponentBits that of its private exponent D,
+	// as well as its Mask, if any. MaxPublicExponentBits limits the size of the public exponent E.
+	// A shard that exceeds a limit is rejected with a *[SizeLimitError], as is an encoding too long to hold a shard within
+	// the limits, before any of its integers are allocated. This keeps services that decode untrusted shards from being
+	// made to allocate huge integers, or to spend minutes signing with them.
+	// Zero selects the default: 16384 bits for N, 32768 bits for the private exponents, and 31 bits for E, which are also
+	// the largest that [PrivateKeyShard.Validate] accepts for N and E
+	MaxModulusBits         int
+	MaxPrivateExponentBits int
+	MaxPublicExponentBits  int
+}
+
+// returns the options' limits, with defaults in place of zeros
+func (opts *DecodeOptions) limits() (modulusBits, privateExponentBits, publicExponentBits int) {
+	modulusBits, privateExponentBits, publicExponentBits = opts.MaxModulusBits, opts.MaxPrivateExponentBits, opts.MaxPublicExponentBits
+	if modulusBits <= 0 {
+		modulusBits = maxModulusBits
+	}
+	if privateExponentBits <= 0 {
+		privateExponentBits = defaultMaxPrivateExponentBits
+	}
+	if publicExponentBits <= 0 {
+		publicExponentBits = defaultMaxPublicExponentBits
+	}
+	return modulusBits, privateExponentBits, publicExponentBits
+}
+
+// checks that der is short enough to hold a shard within the limits, allowing generously for the ASN.1 framing and the
+// other fields, so that a huge encoding is rejected before its integers are parsed
+func (opts *DecodeOptions) checkEncodingSize(der []byte) error {
+	modulusBits, privateExponentBits, publicExponentBits := opts.limits()
+	// D and Mask are private exponents, and each integer may need a leading zero byte
+	maxBits := modulusBits + 2*privateExponentBits + publicExponentBits + 3*8 + 1024*8
+	if bits := 8 * len(der); bits > maxBits {
+		return &SizeLimitError{Field: "encoding", Bits: bits, MaxBits: maxBits}
+	}
+	return nil
+}
+
+// checks the size of each of the shard's integers against the limits
+func (opts *DecodeOptions) checkShardSize(shard *PrivateKeyShard) error {
+	modulusBits, privateExponentBits, publicExponentBits := opts.limits()
+	check := func(field string, n *big.Int, maxBits int) error {
+		if n != nil && n.BitLen() > maxBits {
+			return &SizeLimitError{Field: field, Bits: n.BitLen(), MaxBits: maxBits}
+		}
+		return nil
+	}
+	if err := check("N", shard.PublicKey.N, modulusBits); err != nil {
+		return err
+	}
+	if err := check("E", big.NewInt(int64(shard.PublicKey.E)), publicExponentBits); err != nil {
+		return err
+	}
+	if err := check("D", shard.D, privateExponentBits); err != nil {
+		return err
+	}
+	return check("Mask", shard.Mask, privateExponentBits)
+}
+
+// DecodePEMWithOptions is like [DecodePEM] but allows the caller to configure the decoding with opts
+func DecodePEMWithOptions(encodedPks string, opts *DecodeOptions) (*PrivateKeyShard, error) {
+	if opts == nil {
+		opts = &DecodeOptions{}
+	}
+
 	block, rest := pem.Decode([]byte(encodedPks))
-	if block == nil || block.Type != pemType || len(rest) > 0 {
-		return nil, fmt.Errorf("failed to decode PEM block containing private key shard")
+	if block == nil || block.Type != pemType {
+		return nil, errorf(ErrInvalidShard, "failed to decode PEM block containing private key shard")
+	}
+	if len(rest) > 0 && !opts.Lenient {
+		return nil, errorf(ErrInvalidShard, "unexpected data after PEM block containing private key shard")
+	}
+
+	return decodeDER(block.Bytes, opts)
+}
+
+// DecodeAllPEM returns the shards in every "RSA SPLIT PRIVATE KEY" block of encodedPks, in the order they appear. Blocks of
+// other types, such as certificates or encrypted shards, are skipped, as is any text between blocks. It fails if any shard
+// block is invalid or if there are no shard blocks at all
+func DecodeAllPEM(encodedPks string) ([]*PrivateKeyShard, error) {
+	var shards []*PrivateKeyShard
+	rest := []byte(encodedPks)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != pemType {
+			continue
+		}
+
+		shard, err := unmarshalDER(block.Bytes)
+		if err != nil {
+			return nil, errorf(ErrInvalidShard, "failed to decode shard %d: %s", len(shards)+1, err)
+		}
+		shards = append(shards, shard)
+	}
+
+	if len(shards) == 0 {
+		return nil, errorf(ErrInvalidShard, "no PEM blocks containing private key shards")
+	}
+	return shards, nil
+}
+
+// returns a DER encoding of the key data, in the current version of the encoding
+func (pks *PrivateKeyShard) marshalDER() ([]byte, error) {
+	if err := pks.checkUsable(); err != nil {
+		return nil, err
+	}
+
+	// we perform this conversion because asn1.Marshal cannot handle pointer values or unexported fields
+	b, err := asn1.Marshal(privateKeyShardV1{
+		Version: currentShardVersion,
+		PublicKey: publicKey{
+			N: pks.PublicKey.N.Bytes(),
+			E: pks.PublicKey.E,
+		},
+		D:       pks.D,
+		SplitBy: pks.SplitBy,
+		Index:   pks.Index,
+		Mask:    pks.Mask,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to DER-encode: %s", err)
+	}
+	return b, nil
+}
+
+// returns key data from a DER encoding of any version, rejecting any that fails [PrivateKeyShard.Validate] or exceeds the
+// default size limits of [DecodeOptions]
+func unmarshalDER(der []byte) (*PrivateKeyShard, error) {
+	return decodeDER(der, &DecodeOptions{})
+}
+
+// like unmarshalDER, but with the size limits of opts, and ignoring any bytes after the shard if opts.Lenient is set
+func decodeDER(der []byte, opts *DecodeOptions) (shard *PrivateKeyShard, err error) {
+	defer func() { auditDecode(shard, err) }()
+
+	var header shardVersionHeader
+	trailing, err := asn1.Unmarshal(der, &header)
+	if err != nil {
+		return nil, errorf(ErrInvalidShard, "failed to unmarshal DER-encoded private key shard: %s", err)
+	}
+	if err := opts.checkEncodingSize(der[:len(der)-len(trailing)]); err != nil {
+		return nil, err
 	}
 
+	var rest []byte
+	switch header.Version {
+	case shardVersionLegacy:
+		shard, rest, err = unmarshalLegacyDER(der)
+	case shardVersion1:
+		shard, rest, err = unmarshalDERV1(der)
+	default:
+		return nil, errorf(ErrInvalidShard, "unsupported private key shard version %d", header.Version)
+	}
+	if err != nil {
+		return nil, errorf(ErrInvalidShard, "failed to unmarshal DER-encoded private key shard: %s", err)
+	}
+	if len(rest) > 0 && !opts.Lenient {
+		return nil, errorf(ErrInvalidShard, "unexpected data after DER-encoded private key shard")
+	}
+
+	if err := opts.checkShardSize(shard); err != nil {
+		return nil, err
+	}
+	if err := shard.Validate(); err != nil {
+		return nil, err
+	}
+	return shard, nil
+}
+
+// decodes a shard encoded before versioning was introduced
+func unmarshalLegacyDER(der []byte) (*PrivateKeyShard, []byte, error) {
 	var pks privateKeyShard
-	rest, err := asn1.Unmarshal(block.Bytes, &pks)
-	if len(rest) > 0 {
-		return nil, fmt.Errorf("failed to unmarshal DER-encoded private key shard")
+	rest, err := asn1.Unmarshal(der, &pks)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return &PrivateKeyShard{
+	shard := &PrivateKeyShard{
 		PublicKey: &rsa.PublicKey{
 			N: new(big.Int).SetBytes(pks.PublicKey.N),
 			E: pks.PublicKey.E,
 		},
 		D:       new(big.Int).SetBytes(pks.D),
 		SplitBy: pks.SplitBy,
-	}, err
+		Index:   pks.Index,
+	}
+	if pks.Mask != nil {
+		shard.Mask = new(big.Int).SetBytes(pks.Mask)
+	}
+	return shard, rest, nil
+}
+
+func unmarshalDERV1(der []byte) (*PrivateKeyShard, []byte, error) {
+	var pks privateKeyShardV1
+	rest, err := asn1.Unmarshal(der, &pks)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &PrivateKeyShard{
+		PublicKey: &rsa.PublicKey{
+			N: new(big.Int).SetBytes(pks.PublicKey.N),
+			E: pks.PublicKey.E,
+		},
+		D:       pks.D,
+		SplitBy: pks.SplitBy,
+		Index:   pks.Index,
+		Mask:    pks.Mask,
+	}, rest, nil
 }
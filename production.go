@@ -0,0 +1,10 @@
+//go:build !keysplitting_simulation
+
+package keysplitting
+
+// Simulation reports whether the package was built with the keysplitting_simulation build tag, which allows small, insecure
+// keys for tests. It is never set in production builds
+const Simulation = false
+
+// the smallest modulus, in bits, that Validate accepts
+const minModulusBits = 1024
@@ -0,0 +1,22 @@
+//go:build !keysplitting_simulation
+
+package keysplitting
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"math/big"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Production builds", func() {
+	It("Refuse keys smaller than 1024 bits", func() {
+		Expect(Simulation).To(BeFalse())
+		n, _ := rand.Prime(rand.Reader, 512)
+		shard := &PrivateKeyShard{PublicKey: &rsa.PublicKey{N: n, E: 65537}, D: big.NewInt(3), SplitBy: Multiplication}
+		Expect(errors.Is(shard.Validate(), ErrInvalidShard)).To(BeTrue())
+	})
+})
@@ -0,0 +1,120 @@
+/*
+Package prommetrics exports the measurements of keysplitting.Metrics as Prometheus metrics. It is a separate package so that
+keysplitting doesn't depend on the Prometheus client library
+*/
+package prommetrics
+
+import (
+	"time"
+
+	"github.com/bastionzero/keysplitting"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DefaultLatencyBuckets are the upper bounds, in seconds, of the latency histograms of a [Metrics] created with nil buckets
+var DefaultLatencyBuckets = []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Metrics is a keysplitting.Metrics that is also a prometheus.Collector, so that its measurements can be registered with a
+// Prometheus registry and scraped along with the rest of a service's metrics:
+//
+//	m := prommetrics.New(nil)
+//	keysplitting.SetMetrics(m)
+//	prometheus.MustRegister(m)
+//	http.Handle("/metrics", promhttp.Handler())
+//
+// It exports the counters keysplitting_partial_signatures_total and keysplitting_signatures_total, labelled with whether each
+// "succeeded" or "failed", the histograms keysplitting_partial_sign_duration_seconds and keysplitting_combine_duration_seconds,
+// and the gauge keysplitting_active_sessions. It is safe for concurrent use
+type Metrics struct {
+	partials       *prometheus.CounterVec
+	signatures     *prometheus.CounterVec
+	signLatency    prometheus.Histogram
+	combineLatency prometheus.Histogram
+	activeSessions prometheus.Gauge
+}
+
+var (
+	_ keysplitting.Metrics = (*Metrics)(nil)
+	_ prometheus.Collector = (*Metrics)(nil)
+)
+
+// New returns a Metrics whose latency histograms have the given bucket upper bounds in seconds, or
+// [DefaultLatencyBuckets] if buckets is nil
+func New(buckets []float64) *Metrics {
+	if buckets == nil {
+		buckets = DefaultLatencyBuckets
+	}
+	m := &Metrics{
+		partials: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "keysplitting_partial_signatures_total",
+			Help: "Partial signatures produced with a shard, by outcome",
+		}, []string{"outcome"}),
+		signatures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "keysplitting_signatures_total",
+			Help: "Complete signatures produced from partial signatures, by outcome",
+		}, []string{"outcome"}),
+		signLatency: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "keysplitting_partial_sign_duration_seconds",
+			Help:    "Time taken to produce a partial signature",
+			Buckets: buckets,
+		}),
+		combineLatency: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "keysplitting_combine_duration_seconds",
+			Help:    "Time taken to complete a signature from partial signatures",
+			Buckets: buckets,
+		}),
+		activeSessions: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "keysplitting_active_sessions",
+			Help: "Signing sessions in progress",
+		}),
+	}
+	// export both outcomes from the start, rather than once each has happened
+	for _, outcome := range []string{"succeeded", "failed"} {
+		m.partials.WithLabelValues(outcome)
+		m.signatures.WithLabelValues(outcome)
+	}
+	return m
+}
+
+// ObservePartialSignature implements keysplitting.Metrics
+func (m *Metrics) ObservePartialSignature(d time.Duration, err error) {
+	m.partials.WithLabelValues(outcome(err)).Inc()
+	m.signLatency.Observe(d.Seconds())
+}
+
+// ObserveCombine implements keysplitting.Metrics
+func (m *Metrics) ObserveCombine(d time.Duration, err error) {
+	m.signatures.WithLabelValues(outcome(err)).Inc()
+	m.combineLatency.Observe(d.Seconds())
+}
+
+// AddActiveSessions implements keysplitting.Metrics
+func (m *Metrics) AddActiveSessions(delta int) {
+	m.activeSessions.Add(float64(delta))
+}
+
+// Describe implements prometheus.Collector
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	for _, c := range m.collectors() {
+		c.Describe(ch)
+	}
+}
+
+// Collect implements prometheus.Collector
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	for _, c := range m.collectors() {
+		c.Collect(ch)
+	}
+}
+
+func (m *Metrics) collectors() []prometheus.Collector {
+	return []prometheus.Collector{m.partials, m.signatures, m.signLatency, m.combineLatency, m.activeSessions}
+}
+
+// returns the value of the outcome label for an operation that failed with err, if it isn't nil
+func outcome(err error) string {
+	if err != nil {
+		return "failed"
+	}
+	return "succeeded"
+}
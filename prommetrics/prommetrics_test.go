@@ -0,0 +1,83 @@
+package prommetrics
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/bastionzero/keysplitting"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestPrometheusMetrics(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Prometheus Metrics Suite")
+}
+
+// returns the metric families gathered from m, by name
+func gatherMetrics(m *Metrics) map[string]*dto.MetricFamily {
+	registry := prometheus.NewRegistry()
+	Expect(registry.Register(m)).To(Succeed())
+	families, err := registry.Gather()
+	Expect(err).To(BeNil())
+
+	byName := map[string]*dto.MetricFamily{}
+	for _, family := range families {
+		byName[family.GetName()] = family
+	}
+	return byName
+}
+
+// returns the value of the counter in family with the given outcome label
+func outcomeCount(family *dto.MetricFamily, outcome string) float64 {
+	for _, metric := range family.GetMetric() {
+		for _, label := range metric.GetLabel() {
+			if label.GetName() == "outcome" && label.GetValue() == outcome {
+				return metric.GetCounter().GetValue()
+			}
+		}
+	}
+	return -1
+}
+
+var _ = Describe("Prometheus metrics", func() {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	hashed := sha256.Sum256([]byte("TEST MESSAGE"))
+
+	It("Counts and times signatures", func() {
+		metrics := New(nil)
+		keysplitting.SetMetrics(metrics)
+		DeferCleanup(func() { keysplitting.SetMetrics(nil) })
+
+		families := gatherMetrics(metrics)
+		Expect(outcomeCount(families["keysplitting_signatures_total"], "succeeded")).To(Equal(0.0))
+		Expect(outcomeCount(families["keysplitting_signatures_total"], "failed")).To(Equal(0.0))
+
+		shards, err := keysplitting.SplitD(key, 2, keysplitting.Addition)
+		Expect(err).To(BeNil())
+		partials := make([]*keysplitting.PartialSignature, len(shards))
+		for i, shard := range shards {
+			partials[i], err = keysplitting.SignFirst(rand.Reader, shard, crypto.SHA256, hashed[:])
+			Expect(err).To(BeNil())
+		}
+		_, err = keysplitting.CombinePartialSignatures(&key.PublicKey, partials...)
+		Expect(err).To(BeNil())
+		_, err = keysplitting.CombinePartialSignatures(&key.PublicKey, partials[0])
+		Expect(err).NotTo(BeNil())
+		metrics.AddActiveSessions(2)
+
+		families = gatherMetrics(metrics)
+		Expect(outcomeCount(families["keysplitting_partial_signatures_total"], "succeeded")).To(Equal(2.0))
+		Expect(outcomeCount(families["keysplitting_signatures_total"], "succeeded")).To(Equal(1.0))
+		Expect(outcomeCount(families["keysplitting_signatures_total"], "failed")).To(Equal(1.0))
+		histogram := families["keysplitting_partial_sign_duration_seconds"].GetMetric()[0].GetHistogram()
+		Expect(histogram.GetSampleCount()).To(Equal(uint64(2)))
+		Expect(histogram.GetBucket()).To(HaveLen(len(DefaultLatencyBuckets)))
+		Expect(families["keysplitting_active_sessions"].GetMetric()[0].GetGauge().GetValue()).To(Equal(2.0))
+	})
+})
@@ -0,0 +1,218 @@
+package keysplitting
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+	"io"
+	"math/big"
+)
+
+// the size of a proof's challenge, in bytes
+const proofChallengeSize = sha256.Size
+
+// Prove sets partialSig.Proof to a proof that partialSig was produced with shard, by signing hashed with opts. It is called by
+// the holder of shard after [SignFirst], with the same opts and hashed. It fails with [ErrInvalidPartialSignature] if
+// partialSig wasn't produced with shard, since a false proof can't be made
+func (c *ShardCommitments) Prove(random io.Reader, shard *PrivateKeyShard, opts crypto.SignerOpts, hashed []byte, partialSig *PartialSignature) error {
+	commitment, err := c.commitment(partialSig)
+	if err != nil {
+		return err
+	}
+	if shard.Index != partialSig.Signers[0] || shard.SplitBy != Addition || shard.Mask != nil {
+		return errorf(ErrInvalidShard, "shard %d cannot prove a partial signature from shard %d", shard.Index, partialSig.Signers[0])
+	}
+	N := c.PublicKey.N
+
+	// the proof can't be trusted if the commitment isn't to this shard, so check it rather than leak anything about the shard
+	expected, err := expSigned(c.V, shard.D, N)
+	if err != nil {
+		return err
+	}
+	if expected.Cmp(commitment) != 0 {
+		return errorf(ErrKeyMismatch, "shard %d doesn't match its commitment", shard.Index)
+	}
+
+	base, err := c.base(opts, hashed, partialSig)
+	if err != nil {
+		return err
+	}
+	sigInt := new(big.Int).SetBytes(partialSig.Sig)
+	if signed, err := expSigned(base, shard.D, N); err != nil || signed.Cmp(sigInt) != 0 {
+		return errorf(ErrInvalidPartialSignature, "partial signature wasn't produced with shard %d", shard.Index)
+	}
+
+	x := new(big.Int).Mul(base, base)
+	x.Mod(x, N)
+	sigma := new(big.Int).Mul(sigInt, sigInt)
+	sigma.Mod(sigma, N)
+
+	// r must hide D*c statistically, so it is 2 challenges longer than either
+	bits := N.BitLen()
+	if shard.D.BitLen() > bits {
+		bits = shard.D.BitLen()
+	}
+	bound := new(big.Int).Lsh(bigOne, uint(bits+2*8*proofChallengeSize))
+	for {
+		r, err := rand.Int(random, bound)
+		if err != nil {
+			return err
+		}
+		vCommit := new(big.Int).Exp(c.V, r, N)
+		xCommit := new(big.Int).Exp(x, r, N)
+		challenge := c.challenge(commitment, x, sigma, vCommit, xCommit)
+
+		z := new(big.Int).Mul(shard.D, new(big.Int).SetBytes(challenge))
+		z.Add(z, r)
+		// z can only be negative if the shard is, and even then with negligible probability
+		if z.Sign() < 0 {
+			continue
+		}
+		partialSig.Proof = append(challenge, z.Bytes()...)
+		return nil
+	}
+}
+
+// checks the proof attached to partialSig, i.e. that it was produced with the committed shard by signing hashed with opts, up
+// to its sign
+func (c *ShardCommitments) verify(opts crypto.SignerOpts, hashed []byte, partialSig *PartialSignature) error {
+	commitment, err := c.commitment(partialSig)
+	if err != nil {
+		return err
+	}
+	if len(partialSig.Proof) <= proofChallengeSize {
+		return errorf(ErrInvalidPartialSignature, "partial signature from shard %d has no proof", partialSig.Signers[0])
+	}
+	N := c.PublicKey.N
+
+	base, err := c.base(opts, hashed, partialSig)
+	if err != nil {
+		return err
+	}
+	sigInt := new(big.Int).SetBytes(partialSig.Sig)
+	if sigInt.Sign() == 0 || sigInt.Cmp(N) >= 0 {
+		return errorf(ErrInvalidPartialSignature, "partial signature is out of range for the given public key")
+	}
+	x := new(big.Int).Mul(base, base)
+	x.Mod(x, N)
+	sigma := new(big.Int).Mul(sigInt, sigInt)
+	sigma.Mod(sigma, N)
+
+	challenge := partialSig.Proof[:proofChallengeSize]
+	z := new(big.Int).SetBytes(partialSig.Proof[proofChallengeSize:])
+	negC := new(big.Int).Neg(new(big.Int).SetBytes(challenge))
+
+	// V^z * Vi^-c and x^z * sigma^-c are the prover's commitments, if the proof is valid
+	vCommit, err := expSigned(commitment, negC, N)
+	if err != nil {
+		return errorf(ErrInvalidPartialSignature, "invalid commitment to shard %d", partialSig.Signers[0])
+	}
+	vCommit.Mul(vCommit, new(big.Int).Exp(c.V, z, N)).Mod(vCommit, N)
+	xCommit, err := expSigned(sigma, negC, N)
+	if err != nil {
+		return errorf(ErrInvalidPartialSignature, "partial signature from shard %d has no inverse", partialSig.Signers[0])
+	}
+	xCommit.Mul(xCommit, new(big.Int).Exp(x, z, N)).Mod(xCommit, N)
+
+	if subtle.ConstantTimeCompare(challenge, c.challenge(commitment, x, sigma, vCommit, xCommit)) != 1 {
+		return errorf(ErrInvalidPartialSignature, "proof of the partial signature from shard %d is invalid", partialSig.Signers[0])
+	}
+	return nil
+}
+
+// returns the commitment to the single shard that produced partialSig
+func (c *ShardCommitments) commitment(partialSig *PartialSignature) (*big.Int, error) {
+	if len(partialSig.Signers) != 1 {
+		return nil, errorf(ErrInvalidPartialSignature, "only partial signatures from a single shard can be proven")
+	}
+	if partialSig.SplitBy != Addition {
+		return nil, errorf(ErrUnsupportedSplitBy, "only partial signatures from additive shards can be proven")
+	}
+	index := partialSig.Signers[0]
+	if index < 1 || index > len(c.Vi) {
+		return nil, errorf(ErrInvalidPartialSignature, "there is no commitment to shard %d", index)
+	}
+	return c.Vi[index-1], nil
+}
+
+// returns the value that partialSig should be an exponentiation of, i.e. the encoded message bound to its session nonce
+func (c *ShardCommitments) base(opts crypto.SignerOpts, hashed []byte, partialSig *PartialSignature) (*big.Int, error) {
+	if opts == nil {
+		return nil, errorf(ErrUnsupportedHash, "no signer options provided")
+	}
+	if o, ok := opts.(*NonceOptions); ok {
+		var err error
+		if opts, err = o.unwrap(); err != nil {
+			return nil, err
+		}
+	}
+	if partialSig.Hash != opts.HashFunc() {
+		return nil, errorf(ErrInvalidPartialSignature, "partial signature was produced with a different hash function")
+	}
+
+	em, err := encodeMessage(c.PublicKey, opts, hashed)
+	if err != nil {
+		return nil, err
+	}
+	base := new(big.Int).SetBytes(em)
+	if len(partialSig.Nonce) > 0 {
+		return bindNonce(c.PublicKey, partialSig.Nonce, base)
+	}
+	return base, nil
+}
+
+// returns the Fiat-Shamir challenge for a proof
+func (c *ShardCommitments) challenge(commitment, x, sigma, vCommit, xCommit *big.Int) []byte {
+	h := sha256.New()
+	h.Write([]byte("keysplitting partial signature proof"))
+	for _, v := range []*big.Int{c.PublicKey.N, c.V, commitment, x, sigma, vCommit, xCommit} {
+		b := v.Bytes()
+		_ = binary.Write(h, binary.BigEndian, uint32(len(b)))
+		h.Write(b)
+	}
+	return h.Sum(nil)
+}
+
+// CombineProvenPartialSignatures checks the proof attached to each partial signature against commitments, as with
+// [VerifyPartialSignature], and then combines them as with [CombinePartialSignatures], correcting the sign of the complete
+// signature if necessary. opts and hashed are those the shards were asked to sign. If any proofs are missing or invalid, it
+// returns a *[MisbehavingSignersError] naming the shards that produced them instead
+func CombineProvenPartialSignatures(commitments *ShardCommitments, opts crypto.SignerOpts, hashed []byte, partials ...*PartialSignature) ([]byte, error) {
+	if err := DiagnosePartialSignatures(commitments, opts, hashed, partials...); err != nil {
+		return nil, err
+	}
+
+	sig, err := CombinePartialSignatures(commitments.PublicKey, partials...)
+	if err != nil {
+		return nil, err
+	}
+	return correctSign(commitments.PublicKey, opts, hashed, sig)
+}
+
+// returns whichever of sig and -sig (mod N) is a valid signature on hashed, since proven partial signatures may each have
+// been negated
+func correctSign(pub *rsa.PublicKey, opts crypto.SignerOpts, hashed []byte, sig []byte) ([]byte, error) {
+	if o, ok := opts.(*NonceOptions); ok {
+		var err error
+		if opts, err = o.unwrap(); err != nil {
+			return nil, err
+		}
+	}
+	em, err := encodeMessage(pub, opts, hashed)
+	if err != nil {
+		return nil, err
+	}
+
+	sigInt := new(big.Int).SetBytes(sig)
+	m := new(big.Int).Exp(sigInt, big.NewInt(int64(pub.E)), pub.N)
+	if m.Cmp(new(big.Int).SetBytes(em)) == 0 {
+		return sig, nil
+	}
+	if m.Add(m, new(big.Int).SetBytes(em)).Cmp(pub.N) == 0 {
+		return sigInt.Sub(pub.N, sigInt).FillBytes(make([]byte, pub.Size())), nil
+	}
+	return nil, ErrIncompleteSignature
+}
@@ -0,0 +1,155 @@
+package keysplitting
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"math/big"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Proofs of partial signatures", func() {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	hashed := sha256.Sum256([]byte("TEST MESSAGE"))
+	otherHashed := sha256.Sum256([]byte("OTHER MESSAGE"))
+	shards, _ := SplitD(key, 3, Addition)
+	commitments, _ := NewShardCommitments(rand.Reader, shards)
+
+	// returns a proven partial signature from each shard
+	signAll := func(opts crypto.SignerOpts) []*PartialSignature {
+		partials := make([]*PartialSignature, len(shards))
+		for i, shard := range shards {
+			var err error
+			partials[i], err = SignFirst(rand.Reader, shard, opts, hashed[:])
+			Expect(err).To(BeNil())
+			Expect(commitments.Prove(rand.Reader, shard, opts, hashed[:], partials[i])).To(Succeed())
+		}
+		return partials
+	}
+
+	// returns partial with its signature negated (mod N), which a proof can't detect
+	negate := func(partial *PartialSignature) *PartialSignature {
+		negated := partial.copy()
+		negated.Sig = new(big.Int).Sub(key.N, new(big.Int).SetBytes(partial.Sig)).Bytes()
+		return negated
+	}
+
+	It("Proves and combines valid partial signatures", func() {
+		partials := signAll(crypto.SHA256)
+		for _, partial := range partials {
+			Expect(VerifyPartialSignature(commitments, crypto.SHA256, hashed[:], partial)).To(Succeed())
+		}
+		sig, err := CombineProvenPartialSignatures(commitments, crypto.SHA256, hashed[:], partials...)
+		Expect(err).To(BeNil())
+		Expect(rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, hashed[:], sig)).To(Succeed())
+	})
+
+	It("Proves nonce-bound and PSS partial signatures", func() {
+		salt, err := NewPSSSalt(rand.Reader, &key.PublicKey, crypto.SHA256, nil)
+		Expect(err).To(BeNil())
+		nonce, err := NewNonce(rand.Reader)
+		Expect(err).To(BeNil())
+		opts := &NonceOptions{Opts: &PSSOptions{Hash: crypto.SHA256, Salt: salt}, Nonce: nonce}
+
+		sig, err := CombineProvenPartialSignatures(commitments, opts, hashed[:], signAll(opts)...)
+		Expect(err).To(BeNil())
+		Expect(rsa.VerifyPSS(&key.PublicKey, crypto.SHA256, hashed[:], sig, &rsa.PSSOptions{SaltLength: len(salt), Hash: crypto.SHA256})).To(Succeed())
+	})
+
+	It("Identifies partial signatures without valid proofs", func() {
+		partials := signAll(crypto.SHA256)
+
+		// a partial signature on another message, with a proof copied from a valid one
+		forged, err := SignFirst(rand.Reader, shards[1], crypto.SHA256, otherHashed[:])
+		Expect(err).To(BeNil())
+		forged.Proof = partials[1].Proof
+		err = VerifyPartialSignature(commitments, crypto.SHA256, hashed[:], forged)
+		Expect(errors.Is(err, ErrInvalidPartialSignature)).To(BeTrue())
+
+		unproven := partials[2].copy()
+		unproven.Proof = nil
+
+		_, err = CombineProvenPartialSignatures(commitments, crypto.SHA256, hashed[:], partials[0], forged, unproven)
+		var misbehaving *MisbehavingSignersError
+		Expect(errors.As(err, &misbehaving)).To(BeTrue())
+		Expect(misbehaving.Signers).To(Equal([]int{2, 3}))
+	})
+
+	It("Refuses to prove a partial signature that wasn't produced with the shard", func() {
+		partial, err := SignFirst(rand.Reader, shards[0], crypto.SHA256, otherHashed[:])
+		Expect(err).To(BeNil())
+		err = commitments.Prove(rand.Reader, shards[0], crypto.SHA256, hashed[:], partial)
+		Expect(errors.Is(err, ErrInvalidPartialSignature)).To(BeTrue())
+		Expect(partial.Proof).To(BeNil())
+
+		err = commitments.Prove(rand.Reader, shards[1], crypto.SHA256, otherHashed[:], partial)
+		Expect(errors.Is(err, ErrInvalidShard)).To(BeTrue())
+	})
+
+	It("Corrects the sign of negated partial signatures", func() {
+		partials := signAll(crypto.SHA256)
+		partials[0] = negate(partials[0])
+		Expect(VerifyPartialSignature(commitments, crypto.SHA256, hashed[:], partials[0])).To(Succeed())
+
+		sig, err := CombineProvenPartialSignatures(commitments, crypto.SHA256, hashed[:], partials...)
+		Expect(err).To(BeNil())
+		Expect(rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, hashed[:], sig)).To(Succeed())
+	})
+
+	It("Refuses to commit to shards that can't be proven", func() {
+		multiplicative, err := SplitD(key, 2, Multiplication)
+		Expect(err).To(BeNil())
+		_, err = NewShardCommitments(rand.Reader, multiplicative)
+		Expect(errors.Is(err, ErrUnsupportedSplitBy)).To(BeTrue())
+
+		masked, err := SplitDWithOptions(key, 2, Addition, &SplitOptions{Mask: true})
+		Expect(err).To(BeNil())
+		_, err = NewShardCommitments(rand.Reader, masked)
+		Expect(errors.Is(err, ErrInvalidShard)).To(BeTrue())
+
+		_, err = NewShardCommitments(rand.Reader, []*PrivateKeyShard{shards[1], shards[0]})
+		Expect(errors.Is(err, ErrInvalidShard)).To(BeTrue())
+	})
+
+	It("Keeps proofs when partial signatures are encoded", func() {
+		partial := signAll(crypto.SHA256)[0]
+
+		encoded, err := json.Marshal(partial)
+		Expect(err).To(BeNil())
+		var decoded PartialSignature
+		Expect(json.Unmarshal(encoded, &decoded)).To(Succeed())
+		Expect(decoded.Proof).To(Equal(partial.Proof))
+
+		envelope, err := partial.EncodeEnvelope(&key.PublicKey)
+		Expect(err).To(BeNil())
+		fromEnvelope, err := DecodeEnvelope(envelope, &key.PublicKey)
+		Expect(err).To(BeNil())
+		Expect(VerifyPartialSignature(commitments, crypto.SHA256, hashed[:], fromEnvelope)).To(Succeed())
+	})
+
+	It("Makes a broker reject partial signatures without valid proofs", func() {
+		broker, err := NewBroker(&key.PublicKey, len(shards))
+		Expect(err).To(BeNil())
+		broker.SetCommitments(commitments, crypto.SHA256, hashed[:])
+		opts := broker.SignerOpts(crypto.SHA256)
+
+		partials := signAll(opts)
+		unproven := partials[1].copy()
+		unproven.Proof = nil
+		_, err = broker.AddPartial(unproven)
+		var misbehaving *MisbehavingSignersError
+		Expect(errors.As(err, &misbehaving)).To(BeTrue())
+		Expect(misbehaving.Signers).To(Equal([]int{2}))
+
+		Expect(broker.AddPartial(negate(partials[0]))).To(BeNil())
+		Expect(broker.AddPartial(partials[1])).To(BeNil())
+		sig, err := broker.AddPartial(partials[2])
+		Expect(err).To(BeNil())
+		Expect(rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, hashed[:], sig)).To(Succeed())
+	})
+})
@@ -0,0 +1,882 @@
+// Protocol buffer definitions for exchanging shards, partial signatures, and signing requests between services.
+// The Go code generated from this file, keysplitting.pb.go, holds the messages that the protobuf package encodes and
+// decodes, and keysplitting_grpc.pb.go is the ShardService that the shardservice package's Server and Client implement and
+// call. Regenerate them with go generate ./protobuf after changing this file
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.33.0
+// 	protoc        (unknown)
+// source: keysplitting/v1/keysplitting.proto
+
+package keysplittingv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type SplitBy int32
+
+const (
+	SplitBy_SPLIT_BY_UNSPECIFIED    SplitBy = 0
+	SplitBy_SPLIT_BY_MULTIPLICATION SplitBy = 1
+	SplitBy_SPLIT_BY_ADDITION       SplitBy = 2
+)
+
+// Enum value maps for SplitBy.
+var (
+	SplitBy_name = map[int32]string{
+		0: "SPLIT_BY_UNSPECIFIED",
+		1: "SPLIT_BY_MULTIPLICATION",
+		2: "SPLIT_BY_ADDITION",
+	}
+	SplitBy_value = map[string]int32{
+		"SPLIT_BY_UNSPECIFIED":    0,
+		"SPLIT_BY_MULTIPLICATION": 1,
+		"SPLIT_BY_ADDITION":       2,
+	}
+)
+
+func (x SplitBy) Enum() *SplitBy {
+	p := new(SplitBy)
+	*p = x
+	return p
+}
+
+func (x SplitBy) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SplitBy) Descriptor() protoreflect.EnumDescriptor {
+	return file_keysplitting_v1_keysplitting_proto_enumTypes[0].Descriptor()
+}
+
+func (SplitBy) Type() protoreflect.EnumType {
+	return &file_keysplitting_v1_keysplitting_proto_enumTypes[0]
+}
+
+func (x SplitBy) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SplitBy.Descriptor instead.
+func (SplitBy) EnumDescriptor() ([]byte, []int) {
+	return file_keysplitting_v1_keysplitting_proto_rawDescGZIP(), []int{0}
+}
+
+type HealthResponse_ServingStatus int32
+
+const (
+	HealthResponse_SERVING_STATUS_UNSPECIFIED HealthResponse_ServingStatus = 0
+	HealthResponse_SERVING_STATUS_SERVING     HealthResponse_ServingStatus = 1
+	HealthResponse_SERVING_STATUS_NOT_SERVING HealthResponse_ServingStatus = 2
+)
+
+// Enum value maps for HealthResponse_ServingStatus.
+var (
+	HealthResponse_ServingStatus_name = map[int32]string{
+		0: "SERVING_STATUS_UNSPECIFIED",
+		1: "SERVING_STATUS_SERVING",
+		2: "SERVING_STATUS_NOT_SERVING",
+	}
+	HealthResponse_ServingStatus_value = map[string]int32{
+		"SERVING_STATUS_UNSPECIFIED": 0,
+		"SERVING_STATUS_SERVING":     1,
+		"SERVING_STATUS_NOT_SERVING": 2,
+	}
+)
+
+func (x HealthResponse_ServingStatus) Enum() *HealthResponse_ServingStatus {
+	p := new(HealthResponse_ServingStatus)
+	*p = x
+	return p
+}
+
+func (x HealthResponse_ServingStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (HealthResponse_ServingStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_keysplitting_v1_keysplitting_proto_enumTypes[1].Descriptor()
+}
+
+func (HealthResponse_ServingStatus) Type() protoreflect.EnumType {
+	return &file_keysplitting_v1_keysplitting_proto_enumTypes[1]
+}
+
+func (x HealthResponse_ServingStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use HealthResponse_ServingStatus.Descriptor instead.
+func (HealthResponse_ServingStatus) EnumDescriptor() ([]byte, []int) {
+	return file_keysplitting_v1_keysplitting_proto_rawDescGZIP(), []int{7, 0}
+}
+
+// An arbitrary-precision integer. Additive shards may be negative
+type BigInt struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Magnitude []byte `protobuf:"bytes,1,opt,name=magnitude,proto3" json:"magnitude,omitempty"` // big-endian absolute value
+	Negative  bool   `protobuf:"varint,2,opt,name=negative,proto3" json:"negative,omitempty"`
+}
+
+func (x *BigInt) Reset() {
+	*x = BigInt{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_keysplitting_v1_keysplitting_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BigInt) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BigInt) ProtoMessage() {}
+
+func (x *BigInt) ProtoReflect() protoreflect.Message {
+	mi := &file_keysplitting_v1_keysplitting_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BigInt.ProtoReflect.Descriptor instead.
+func (*BigInt) Descriptor() ([]byte, []int) {
+	return file_keysplitting_v1_keysplitting_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *BigInt) GetMagnitude() []byte {
+	if x != nil {
+		return x.Magnitude
+	}
+	return nil
+}
+
+func (x *BigInt) GetNegative() bool {
+	if x != nil {
+		return x.Negative
+	}
+	return false
+}
+
+type PublicKey struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	N []byte `protobuf:"bytes,1,opt,name=n,proto3" json:"n,omitempty"` // big-endian modulus
+	E int64  `protobuf:"varint,2,opt,name=e,proto3" json:"e,omitempty"`
+}
+
+func (x *PublicKey) Reset() {
+	*x = PublicKey{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_keysplitting_v1_keysplitting_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PublicKey) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PublicKey) ProtoMessage() {}
+
+func (x *PublicKey) ProtoReflect() protoreflect.Message {
+	mi := &file_keysplitting_v1_keysplitting_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PublicKey.ProtoReflect.Descriptor instead.
+func (*PublicKey) Descriptor() ([]byte, []int) {
+	return file_keysplitting_v1_keysplitting_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *PublicKey) GetN() []byte {
+	if x != nil {
+		return x.N
+	}
+	return nil
+}
+
+func (x *PublicKey) GetE() int64 {
+	if x != nil {
+		return x.E
+	}
+	return 0
+}
+
+// One shard of a split RSA key. This is secret and must only be sent over a secure channel
+type PrivateKeyShard struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	PublicKey *PublicKey `protobuf:"bytes,1,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
+	D         *BigInt    `protobuf:"bytes,2,opt,name=d,proto3" json:"d,omitempty"`
+	SplitBy   SplitBy    `protobuf:"varint,3,opt,name=split_by,json=splitBy,proto3,enum=keysplitting.v1.SplitBy" json:"split_by,omitempty"`
+	Index     int32      `protobuf:"varint,4,opt,name=index,proto3" json:"index,omitempty"` // the shard's position in 1..k (0 if unknown)
+	Mask      *BigInt    `protobuf:"bytes,5,opt,name=mask,proto3" json:"mask,omitempty"`    // masking exponent, if the key was split with masking
+}
+
+func (x *PrivateKeyShard) Reset() {
+	*x = PrivateKeyShard{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_keysplitting_v1_keysplitting_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PrivateKeyShard) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PrivateKeyShard) ProtoMessage() {}
+
+func (x *PrivateKeyShard) ProtoReflect() protoreflect.Message {
+	mi := &file_keysplitting_v1_keysplitting_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PrivateKeyShard.ProtoReflect.Descriptor instead.
+func (*PrivateKeyShard) Descriptor() ([]byte, []int) {
+	return file_keysplitting_v1_keysplitting_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *PrivateKeyShard) GetPublicKey() *PublicKey {
+	if x != nil {
+		return x.PublicKey
+	}
+	return nil
+}
+
+func (x *PrivateKeyShard) GetD() *BigInt {
+	if x != nil {
+		return x.D
+	}
+	return nil
+}
+
+func (x *PrivateKeyShard) GetSplitBy() SplitBy {
+	if x != nil {
+		return x.SplitBy
+	}
+	return SplitBy_SPLIT_BY_UNSPECIFIED
+}
+
+func (x *PrivateKeyShard) GetIndex() int32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *PrivateKeyShard) GetMask() *BigInt {
+	if x != nil {
+		return x.Mask
+	}
+	return nil
+}
+
+// A signature produced by some, but not necessarily all, of the shards of a split key
+type PartialSignature struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Signers []int32 `protobuf:"varint,1,rep,packed,name=signers,proto3" json:"signers,omitempty"` // indices of the shards that have signed, in order
+	Hash    uint32  `protobuf:"varint,2,opt,name=hash,proto3" json:"hash,omitempty"`              // the Go crypto.Hash of the signed digest (0 for raw signatures)
+	SplitBy SplitBy `protobuf:"varint,3,opt,name=split_by,json=splitBy,proto3,enum=keysplitting.v1.SplitBy" json:"split_by,omitempty"`
+	Sig     []byte  `protobuf:"bytes,4,opt,name=sig,proto3" json:"sig,omitempty"`
+	Nonce   []byte  `protobuf:"bytes,5,opt,name=nonce,proto3" json:"nonce,omitempty"` // the session nonce the signature is bound to, if any
+	Proof   []byte  `protobuf:"bytes,6,opt,name=proof,proto3" json:"proof,omitempty"` // a proof that the signature was produced with a committed shard, if any
+}
+
+func (x *PartialSignature) Reset() {
+	*x = PartialSignature{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_keysplitting_v1_keysplitting_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PartialSignature) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PartialSignature) ProtoMessage() {}
+
+func (x *PartialSignature) ProtoReflect() protoreflect.Message {
+	mi := &file_keysplitting_v1_keysplitting_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PartialSignature.ProtoReflect.Descriptor instead.
+func (*PartialSignature) Descriptor() ([]byte, []int) {
+	return file_keysplitting_v1_keysplitting_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *PartialSignature) GetSigners() []int32 {
+	if x != nil {
+		return x.Signers
+	}
+	return nil
+}
+
+func (x *PartialSignature) GetHash() uint32 {
+	if x != nil {
+		return x.Hash
+	}
+	return 0
+}
+
+func (x *PartialSignature) GetSplitBy() SplitBy {
+	if x != nil {
+		return x.SplitBy
+	}
+	return SplitBy_SPLIT_BY_UNSPECIFIED
+}
+
+func (x *PartialSignature) GetSig() []byte {
+	if x != nil {
+		return x.Sig
+	}
+	return nil
+}
+
+func (x *PartialSignature) GetNonce() []byte {
+	if x != nil {
+		return x.Nonce
+	}
+	return nil
+}
+
+func (x *PartialSignature) GetProof() []byte {
+	if x != nil {
+		return x.Proof
+	}
+	return nil
+}
+
+// A request for a shard holder to add their signature to a digest
+type SigningRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	KeyId            string            `protobuf:"bytes,1,opt,name=key_id,json=keyId,proto3" json:"key_id,omitempty"` // identifies the key, as returned by PrivateKeyShard.KeyID
+	Hash             uint32            `protobuf:"varint,2,opt,name=hash,proto3" json:"hash,omitempty"`               // the Go crypto.Hash of the digest (0 for raw signatures)
+	Digest           []byte            `protobuf:"bytes,3,opt,name=digest,proto3" json:"digest,omitempty"`
+	PssSalt          []byte            `protobuf:"bytes,4,opt,name=pss_salt,json=pssSalt,proto3" json:"pss_salt,omitempty"`                            // the shared salt, for RSASSA-PSS signatures only
+	PartialSignature *PartialSignature `protobuf:"bytes,5,opt,name=partial_signature,json=partialSignature,proto3" json:"partial_signature,omitempty"` // the signature to extend, unless the holder signs first
+	Nonce            []byte            `protobuf:"bytes,6,opt,name=nonce,proto3" json:"nonce,omitempty"`                                               // binds the signature to a signing session, if set
+}
+
+func (x *SigningRequest) Reset() {
+	*x = SigningRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_keysplitting_v1_keysplitting_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SigningRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SigningRequest) ProtoMessage() {}
+
+func (x *SigningRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_keysplitting_v1_keysplitting_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SigningRequest.ProtoReflect.Descriptor instead.
+func (*SigningRequest) Descriptor() ([]byte, []int) {
+	return file_keysplitting_v1_keysplitting_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *SigningRequest) GetKeyId() string {
+	if x != nil {
+		return x.KeyId
+	}
+	return ""
+}
+
+func (x *SigningRequest) GetHash() uint32 {
+	if x != nil {
+		return x.Hash
+	}
+	return 0
+}
+
+func (x *SigningRequest) GetDigest() []byte {
+	if x != nil {
+		return x.Digest
+	}
+	return nil
+}
+
+func (x *SigningRequest) GetPssSalt() []byte {
+	if x != nil {
+		return x.PssSalt
+	}
+	return nil
+}
+
+func (x *SigningRequest) GetPartialSignature() *PartialSignature {
+	if x != nil {
+		return x.PartialSignature
+	}
+	return nil
+}
+
+func (x *SigningRequest) GetNonce() []byte {
+	if x != nil {
+		return x.Nonce
+	}
+	return nil
+}
+
+type GetPublicKeyRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *GetPublicKeyRequest) Reset() {
+	*x = GetPublicKeyRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_keysplitting_v1_keysplitting_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetPublicKeyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPublicKeyRequest) ProtoMessage() {}
+
+func (x *GetPublicKeyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_keysplitting_v1_keysplitting_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPublicKeyRequest.ProtoReflect.Descriptor instead.
+func (*GetPublicKeyRequest) Descriptor() ([]byte, []int) {
+	return file_keysplitting_v1_keysplitting_proto_rawDescGZIP(), []int{5}
+}
+
+type HealthRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *HealthRequest) Reset() {
+	*x = HealthRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_keysplitting_v1_keysplitting_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *HealthRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HealthRequest) ProtoMessage() {}
+
+func (x *HealthRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_keysplitting_v1_keysplitting_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HealthRequest.ProtoReflect.Descriptor instead.
+func (*HealthRequest) Descriptor() ([]byte, []int) {
+	return file_keysplitting_v1_keysplitting_proto_rawDescGZIP(), []int{6}
+}
+
+type HealthResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Status HealthResponse_ServingStatus `protobuf:"varint,1,opt,name=status,proto3,enum=keysplitting.v1.HealthResponse_ServingStatus" json:"status,omitempty"`
+}
+
+func (x *HealthResponse) Reset() {
+	*x = HealthResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_keysplitting_v1_keysplitting_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *HealthResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HealthResponse) ProtoMessage() {}
+
+func (x *HealthResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_keysplitting_v1_keysplitting_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HealthResponse.ProtoReflect.Descriptor instead.
+func (*HealthResponse) Descriptor() ([]byte, []int) {
+	return file_keysplitting_v1_keysplitting_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *HealthResponse) GetStatus() HealthResponse_ServingStatus {
+	if x != nil {
+		return x.Status
+	}
+	return HealthResponse_SERVING_STATUS_UNSPECIFIED
+}
+
+var File_keysplitting_v1_keysplitting_proto protoreflect.FileDescriptor
+
+var file_keysplitting_v1_keysplitting_proto_rawDesc = []byte{
+	0x0a, 0x22, 0x6b, 0x65, 0x79, 0x73, 0x70, 0x6c, 0x69, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x2f, 0x76,
+	0x31, 0x2f, 0x6b, 0x65, 0x79, 0x73, 0x70, 0x6c, 0x69, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0f, 0x6b, 0x65, 0x79, 0x73, 0x70, 0x6c, 0x69, 0x74, 0x74, 0x69,
+	0x6e, 0x67, 0x2e, 0x76, 0x31, 0x22, 0x42, 0x0a, 0x06, 0x42, 0x69, 0x67, 0x49, 0x6e, 0x74, 0x12,
+	0x1c, 0x0a, 0x09, 0x6d, 0x61, 0x67, 0x6e, 0x69, 0x74, 0x75, 0x64, 0x65, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0c, 0x52, 0x09, 0x6d, 0x61, 0x67, 0x6e, 0x69, 0x74, 0x75, 0x64, 0x65, 0x12, 0x1a, 0x0a,
+	0x08, 0x6e, 0x65, 0x67, 0x61, 0x74, 0x69, 0x76, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52,
+	0x08, 0x6e, 0x65, 0x67, 0x61, 0x74, 0x69, 0x76, 0x65, 0x22, 0x27, 0x0a, 0x09, 0x50, 0x75, 0x62,
+	0x6c, 0x69, 0x63, 0x4b, 0x65, 0x79, 0x12, 0x0c, 0x0a, 0x01, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0c, 0x52, 0x01, 0x6e, 0x12, 0x0c, 0x0a, 0x01, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x01, 0x65, 0x22, 0xeb, 0x01, 0x0a, 0x0f, 0x50, 0x72, 0x69, 0x76, 0x61, 0x74, 0x65, 0x4b, 0x65,
+	0x79, 0x53, 0x68, 0x61, 0x72, 0x64, 0x12, 0x39, 0x0a, 0x0a, 0x70, 0x75, 0x62, 0x6c, 0x69, 0x63,
+	0x5f, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x6b, 0x65, 0x79,
+	0x73, 0x70, 0x6c, 0x69, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x75, 0x62,
+	0x6c, 0x69, 0x63, 0x4b, 0x65, 0x79, 0x52, 0x09, 0x70, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x4b, 0x65,
+	0x79, 0x12, 0x25, 0x0a, 0x01, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x6b,
+	0x65, 0x79, 0x73, 0x70, 0x6c, 0x69, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x2e, 0x76, 0x31, 0x2e, 0x42,
+	0x69, 0x67, 0x49, 0x6e, 0x74, 0x52, 0x01, 0x64, 0x12, 0x33, 0x0a, 0x08, 0x73, 0x70, 0x6c, 0x69,
+	0x74, 0x5f, 0x62, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x18, 0x2e, 0x6b, 0x65, 0x79,
+	0x73, 0x70, 0x6c, 0x69, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x70, 0x6c,
+	0x69, 0x74, 0x42, 0x79, 0x52, 0x07, 0x73, 0x70, 0x6c, 0x69, 0x74, 0x42, 0x79, 0x12, 0x14, 0x0a,
+	0x05, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x69, 0x6e,
+	0x64, 0x65, 0x78, 0x12, 0x2b, 0x0a, 0x04, 0x6d, 0x61, 0x73, 0x6b, 0x18, 0x05, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x17, 0x2e, 0x6b, 0x65, 0x79, 0x73, 0x70, 0x6c, 0x69, 0x74, 0x74, 0x69, 0x6e, 0x67,
+	0x2e, 0x76, 0x31, 0x2e, 0x42, 0x69, 0x67, 0x49, 0x6e, 0x74, 0x52, 0x04, 0x6d, 0x61, 0x73, 0x6b,
+	0x22, 0xb3, 0x01, 0x0a, 0x10, 0x50, 0x61, 0x72, 0x74, 0x69, 0x61, 0x6c, 0x53, 0x69, 0x67, 0x6e,
+	0x61, 0x74, 0x75, 0x72, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x69, 0x67, 0x6e, 0x65, 0x72, 0x73,
+	0x18, 0x01, 0x20, 0x03, 0x28, 0x05, 0x52, 0x07, 0x73, 0x69, 0x67, 0x6e, 0x65, 0x72, 0x73, 0x12,
+	0x12, 0x0a, 0x04, 0x68, 0x61, 0x73, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x04, 0x68,
+	0x61, 0x73, 0x68, 0x12, 0x33, 0x0a, 0x08, 0x73, 0x70, 0x6c, 0x69, 0x74, 0x5f, 0x62, 0x79, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x18, 0x2e, 0x6b, 0x65, 0x79, 0x73, 0x70, 0x6c, 0x69, 0x74,
+	0x74, 0x69, 0x6e, 0x67, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x70, 0x6c, 0x69, 0x74, 0x42, 0x79, 0x52,
+	0x07, 0x73, 0x70, 0x6c, 0x69, 0x74, 0x42, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x73, 0x69, 0x67, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x03, 0x73, 0x69, 0x67, 0x12, 0x14, 0x0a, 0x05, 0x6e, 0x6f,
+	0x6e, 0x63, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x05, 0x6e, 0x6f, 0x6e, 0x63, 0x65,
+	0x12, 0x14, 0x0a, 0x05, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0c, 0x52,
+	0x05, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x22, 0xd4, 0x01, 0x0a, 0x0e, 0x53, 0x69, 0x67, 0x6e, 0x69,
+	0x6e, 0x67, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x15, 0x0a, 0x06, 0x6b, 0x65, 0x79,
+	0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x6b, 0x65, 0x79, 0x49, 0x64,
+	0x12, 0x12, 0x0a, 0x04, 0x68, 0x61, 0x73, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x04,
+	0x68, 0x61, 0x73, 0x68, 0x12, 0x16, 0x0a, 0x06, 0x64, 0x69, 0x67, 0x65, 0x73, 0x74, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x64, 0x69, 0x67, 0x65, 0x73, 0x74, 0x12, 0x19, 0x0a, 0x08,
+	0x70, 0x73, 0x73, 0x5f, 0x73, 0x61, 0x6c, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07,
+	0x70, 0x73, 0x73, 0x53, 0x61, 0x6c, 0x74, 0x12, 0x4e, 0x0a, 0x11, 0x70, 0x61, 0x72, 0x74, 0x69,
+	0x61, 0x6c, 0x5f, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x18, 0x05, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x21, 0x2e, 0x6b, 0x65, 0x79, 0x73, 0x70, 0x6c, 0x69, 0x74, 0x74, 0x69, 0x6e,
+	0x67, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x61, 0x72, 0x74, 0x69, 0x61, 0x6c, 0x53, 0x69, 0x67, 0x6e,
+	0x61, 0x74, 0x75, 0x72, 0x65, 0x52, 0x10, 0x70, 0x61, 0x72, 0x74, 0x69, 0x61, 0x6c, 0x53, 0x69,
+	0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x6e, 0x6f, 0x6e, 0x63, 0x65,
+	0x18, 0x06, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x05, 0x6e, 0x6f, 0x6e, 0x63, 0x65, 0x22, 0x15, 0x0a,
+	0x13, 0x47, 0x65, 0x74, 0x50, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x4b, 0x65, 0x79, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x22, 0x0f, 0x0a, 0x0d, 0x48, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0xc4, 0x01, 0x0a, 0x0e, 0x48, 0x65, 0x61, 0x6c, 0x74, 0x68,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x45, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74,
+	0x75, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x2d, 0x2e, 0x6b, 0x65, 0x79, 0x73, 0x70,
+	0x6c, 0x69, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x2e, 0x76, 0x31, 0x2e, 0x48, 0x65, 0x61, 0x6c, 0x74,
+	0x68, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x2e, 0x53, 0x65, 0x72, 0x76, 0x69, 0x6e,
+	0x67, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x22,
+	0x6b, 0x0a, 0x0d, 0x53, 0x65, 0x72, 0x76, 0x69, 0x6e, 0x67, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73,
+	0x12, 0x1e, 0x0a, 0x1a, 0x53, 0x45, 0x52, 0x56, 0x49, 0x4e, 0x47, 0x5f, 0x53, 0x54, 0x41, 0x54,
+	0x55, 0x53, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00,
+	0x12, 0x1a, 0x0a, 0x16, 0x53, 0x45, 0x52, 0x56, 0x49, 0x4e, 0x47, 0x5f, 0x53, 0x54, 0x41, 0x54,
+	0x55, 0x53, 0x5f, 0x53, 0x45, 0x52, 0x56, 0x49, 0x4e, 0x47, 0x10, 0x01, 0x12, 0x1e, 0x0a, 0x1a,
+	0x53, 0x45, 0x52, 0x56, 0x49, 0x4e, 0x47, 0x5f, 0x53, 0x54, 0x41, 0x54, 0x55, 0x53, 0x5f, 0x4e,
+	0x4f, 0x54, 0x5f, 0x53, 0x45, 0x52, 0x56, 0x49, 0x4e, 0x47, 0x10, 0x02, 0x2a, 0x57, 0x0a, 0x07,
+	0x53, 0x70, 0x6c, 0x69, 0x74, 0x42, 0x79, 0x12, 0x18, 0x0a, 0x14, 0x53, 0x50, 0x4c, 0x49, 0x54,
+	0x5f, 0x42, 0x59, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10,
+	0x00, 0x12, 0x1b, 0x0a, 0x17, 0x53, 0x50, 0x4c, 0x49, 0x54, 0x5f, 0x42, 0x59, 0x5f, 0x4d, 0x55,
+	0x4c, 0x54, 0x49, 0x50, 0x4c, 0x49, 0x43, 0x41, 0x54, 0x49, 0x4f, 0x4e, 0x10, 0x01, 0x12, 0x15,
+	0x0a, 0x11, 0x53, 0x50, 0x4c, 0x49, 0x54, 0x5f, 0x42, 0x59, 0x5f, 0x41, 0x44, 0x44, 0x49, 0x54,
+	0x49, 0x4f, 0x4e, 0x10, 0x02, 0x32, 0xfe, 0x01, 0x0a, 0x0c, 0x53, 0x68, 0x61, 0x72, 0x64, 0x53,
+	0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x51, 0x0a, 0x0b, 0x53, 0x69, 0x67, 0x6e, 0x50, 0x61,
+	0x72, 0x74, 0x69, 0x61, 0x6c, 0x12, 0x1f, 0x2e, 0x6b, 0x65, 0x79, 0x73, 0x70, 0x6c, 0x69, 0x74,
+	0x74, 0x69, 0x6e, 0x67, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x69, 0x67, 0x6e, 0x69, 0x6e, 0x67, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x21, 0x2e, 0x6b, 0x65, 0x79, 0x73, 0x70, 0x6c, 0x69,
+	0x74, 0x74, 0x69, 0x6e, 0x67, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x61, 0x72, 0x74, 0x69, 0x61, 0x6c,
+	0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x12, 0x50, 0x0a, 0x0c, 0x47, 0x65, 0x74,
+	0x50, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x4b, 0x65, 0x79, 0x12, 0x24, 0x2e, 0x6b, 0x65, 0x79, 0x73,
+	0x70, 0x6c, 0x69, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x74, 0x50,
+	0x75, 0x62, 0x6c, 0x69, 0x63, 0x4b, 0x65, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x1a, 0x2e, 0x6b, 0x65, 0x79, 0x73, 0x70, 0x6c, 0x69, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x2e, 0x76,
+	0x31, 0x2e, 0x50, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x4b, 0x65, 0x79, 0x12, 0x49, 0x0a, 0x06, 0x48,
+	0x65, 0x61, 0x6c, 0x74, 0x68, 0x12, 0x1e, 0x2e, 0x6b, 0x65, 0x79, 0x73, 0x70, 0x6c, 0x69, 0x74,
+	0x74, 0x69, 0x6e, 0x67, 0x2e, 0x76, 0x31, 0x2e, 0x48, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1f, 0x2e, 0x6b, 0x65, 0x79, 0x73, 0x70, 0x6c, 0x69, 0x74,
+	0x74, 0x69, 0x6e, 0x67, 0x2e, 0x76, 0x31, 0x2e, 0x48, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42, 0x4a, 0x5a, 0x48, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62,
+	0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x62, 0x61, 0x73, 0x74, 0x69, 0x6f, 0x6e, 0x7a, 0x65, 0x72, 0x6f,
+	0x2f, 0x6b, 0x65, 0x79, 0x73, 0x70, 0x6c, 0x69, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x2f, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x2f, 0x6b, 0x65, 0x79, 0x73, 0x70, 0x6c, 0x69, 0x74, 0x74, 0x69, 0x6e, 0x67,
+	0x2f, 0x76, 0x31, 0x3b, 0x6b, 0x65, 0x79, 0x73, 0x70, 0x6c, 0x69, 0x74, 0x74, 0x69, 0x6e, 0x67,
+	0x76, 0x31, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_keysplitting_v1_keysplitting_proto_rawDescOnce sync.Once
+	file_keysplitting_v1_keysplitting_proto_rawDescData = file_keysplitting_v1_keysplitting_proto_rawDesc
+)
+
+func file_keysplitting_v1_keysplitting_proto_rawDescGZIP() []byte {
+	file_keysplitting_v1_keysplitting_proto_rawDescOnce.Do(func() {
+		file_keysplitting_v1_keysplitting_proto_rawDescData = protoimpl.X.CompressGZIP(file_keysplitting_v1_keysplitting_proto_rawDescData)
+	})
+	return file_keysplitting_v1_keysplitting_proto_rawDescData
+}
+
+var file_keysplitting_v1_keysplitting_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_keysplitting_v1_keysplitting_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_keysplitting_v1_keysplitting_proto_goTypes = []interface{}{
+	(SplitBy)(0),                      // 0: keysplitting.v1.SplitBy
+	(HealthResponse_ServingStatus)(0), // 1: keysplitting.v1.HealthResponse.ServingStatus
+	(*BigInt)(nil),                    // 2: keysplitting.v1.BigInt
+	(*PublicKey)(nil),                 // 3: keysplitting.v1.PublicKey
+	(*PrivateKeyShard)(nil),           // 4: keysplitting.v1.PrivateKeyShard
+	(*PartialSignature)(nil),          // 5: keysplitting.v1.PartialSignature
+	(*SigningRequest)(nil),            // 6: keysplitting.v1.SigningRequest
+	(*GetPublicKeyRequest)(nil),       // 7: keysplitting.v1.GetPublicKeyRequest
+	(*HealthRequest)(nil),             // 8: keysplitting.v1.HealthRequest
+	(*HealthResponse)(nil),            // 9: keysplitting.v1.HealthResponse
+}
+var file_keysplitting_v1_keysplitting_proto_depIdxs = []int32{
+	3,  // 0: keysplitting.v1.PrivateKeyShard.public_key:type_name -> keysplitting.v1.PublicKey
+	2,  // 1: keysplitting.v1.PrivateKeyShard.d:type_name -> keysplitting.v1.BigInt
+	0,  // 2: keysplitting.v1.PrivateKeyShard.split_by:type_name -> keysplitting.v1.SplitBy
+	2,  // 3: keysplitting.v1.PrivateKeyShard.mask:type_name -> keysplitting.v1.BigInt
+	0,  // 4: keysplitting.v1.PartialSignature.split_by:type_name -> keysplitting.v1.SplitBy
+	5,  // 5: keysplitting.v1.SigningRequest.partial_signature:type_name -> keysplitting.v1.PartialSignature
+	1,  // 6: keysplitting.v1.HealthResponse.status:type_name -> keysplitting.v1.HealthResponse.ServingStatus
+	6,  // 7: keysplitting.v1.ShardService.SignPartial:input_type -> keysplitting.v1.SigningRequest
+	7,  // 8: keysplitting.v1.ShardService.GetPublicKey:input_type -> keysplitting.v1.GetPublicKeyRequest
+	8,  // 9: keysplitting.v1.ShardService.Health:input_type -> keysplitting.v1.HealthRequest
+	5,  // 10: keysplitting.v1.ShardService.SignPartial:output_type -> keysplitting.v1.PartialSignature
+	3,  // 11: keysplitting.v1.ShardService.GetPublicKey:output_type -> keysplitting.v1.PublicKey
+	9,  // 12: keysplitting.v1.ShardService.Health:output_type -> keysplitting.v1.HealthResponse
+	10, // [10:13] is the sub-list for method output_type
+	7,  // [7:10] is the sub-list for method input_type
+	7,  // [7:7] is the sub-list for extension type_name
+	7,  // [7:7] is the sub-list for extension extendee
+	0,  // [0:7] is the sub-list for field type_name
+}
+
+func init() { file_keysplitting_v1_keysplitting_proto_init() }
+func file_keysplitting_v1_keysplitting_proto_init() {
+	if File_keysplitting_v1_keysplitting_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_keysplitting_v1_keysplitting_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BigInt); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_keysplitting_v1_keysplitting_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PublicKey); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_keysplitting_v1_keysplitting_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PrivateKeyShard); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_keysplitting_v1_keysplitting_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PartialSignature); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_keysplitting_v1_keysplitting_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SigningRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_keysplitting_v1_keysplitting_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetPublicKeyRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_keysplitting_v1_keysplitting_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*HealthRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_keysplitting_v1_keysplitting_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*HealthResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_keysplitting_v1_keysplitting_proto_rawDesc,
+			NumEnums:      2,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_keysplitting_v1_keysplitting_proto_goTypes,
+		DependencyIndexes: file_keysplitting_v1_keysplitting_proto_depIdxs,
+		EnumInfos:         file_keysplitting_v1_keysplitting_proto_enumTypes,
+		MessageInfos:      file_keysplitting_v1_keysplitting_proto_msgTypes,
+	}.Build()
+	File_keysplitting_v1_keysplitting_proto = out.File
+	file_keysplitting_v1_keysplitting_proto_rawDesc = nil
+	file_keysplitting_v1_keysplitting_proto_goTypes = nil
+	file_keysplitting_v1_keysplitting_proto_depIdxs = nil
+}
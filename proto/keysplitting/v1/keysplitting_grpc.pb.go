@@ -0,0 +1,188 @@
+// Protocol buffer definitions for exchanging shards, partial signatures, and signing requests between services.
+// The Go code generated from this file, keysplitting.pb.go, holds the messages that the protobuf package encodes and
+// decodes, and keysplitting_grpc.pb.go is the ShardService that the shardservice package's Server and Client implement and
+// call. Regenerate them with go generate ./protobuf after changing this file
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: keysplitting/v1/keysplitting.proto
+
+package keysplittingv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	ShardService_SignPartial_FullMethodName  = "/keysplitting.v1.ShardService/SignPartial"
+	ShardService_GetPublicKey_FullMethodName = "/keysplitting.v1.ShardService/GetPublicKey"
+	ShardService_Health_FullMethodName       = "/keysplitting.v1.ShardService/Health"
+)
+
+// ShardServiceClient is the client API for ShardService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ShardServiceClient interface {
+	SignPartial(ctx context.Context, in *SigningRequest, opts ...grpc.CallOption) (*PartialSignature, error)
+	GetPublicKey(ctx context.Context, in *GetPublicKeyRequest, opts ...grpc.CallOption) (*PublicKey, error)
+	Health(ctx context.Context, in *HealthRequest, opts ...grpc.CallOption) (*HealthResponse, error)
+}
+
+type shardServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewShardServiceClient(cc grpc.ClientConnInterface) ShardServiceClient {
+	return &shardServiceClient{cc}
+}
+
+func (c *shardServiceClient) SignPartial(ctx context.Context, in *SigningRequest, opts ...grpc.CallOption) (*PartialSignature, error) {
+	out := new(PartialSignature)
+	err := c.cc.Invoke(ctx, ShardService_SignPartial_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shardServiceClient) GetPublicKey(ctx context.Context, in *GetPublicKeyRequest, opts ...grpc.CallOption) (*PublicKey, error) {
+	out := new(PublicKey)
+	err := c.cc.Invoke(ctx, ShardService_GetPublicKey_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shardServiceClient) Health(ctx context.Context, in *HealthRequest, opts ...grpc.CallOption) (*HealthResponse, error) {
+	out := new(HealthResponse)
+	err := c.cc.Invoke(ctx, ShardService_Health_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ShardServiceServer is the server API for ShardService service.
+// All implementations must embed UnimplementedShardServiceServer
+// for forward compatibility
+type ShardServiceServer interface {
+	SignPartial(context.Context, *SigningRequest) (*PartialSignature, error)
+	GetPublicKey(context.Context, *GetPublicKeyRequest) (*PublicKey, error)
+	Health(context.Context, *HealthRequest) (*HealthResponse, error)
+	mustEmbedUnimplementedShardServiceServer()
+}
+
+// UnimplementedShardServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedShardServiceServer struct {
+}
+
+func (UnimplementedShardServiceServer) SignPartial(context.Context, *SigningRequest) (*PartialSignature, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SignPartial not implemented")
+}
+func (UnimplementedShardServiceServer) GetPublicKey(context.Context, *GetPublicKeyRequest) (*PublicKey, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPublicKey not implemented")
+}
+func (UnimplementedShardServiceServer) Health(context.Context, *HealthRequest) (*HealthResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Health not implemented")
+}
+func (UnimplementedShardServiceServer) mustEmbedUnimplementedShardServiceServer() {}
+
+// UnsafeShardServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ShardServiceServer will
+// result in compilation errors.
+type UnsafeShardServiceServer interface {
+	mustEmbedUnimplementedShardServiceServer()
+}
+
+func RegisterShardServiceServer(s grpc.ServiceRegistrar, srv ShardServiceServer) {
+	s.RegisterService(&ShardService_ServiceDesc, srv)
+}
+
+func _ShardService_SignPartial_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SigningRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShardServiceServer).SignPartial(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ShardService_SignPartial_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShardServiceServer).SignPartial(ctx, req.(*SigningRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ShardService_GetPublicKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPublicKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShardServiceServer).GetPublicKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ShardService_GetPublicKey_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShardServiceServer).GetPublicKey(ctx, req.(*GetPublicKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ShardService_Health_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HealthRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShardServiceServer).Health(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ShardService_Health_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShardServiceServer).Health(ctx, req.(*HealthRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ShardService_ServiceDesc is the grpc.ServiceDesc for ShardService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ShardService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "keysplitting.v1.ShardService",
+	HandlerType: (*ShardServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SignPartial",
+			Handler:    _ShardService_SignPartial_Handler,
+		},
+		{
+			MethodName: "GetPublicKey",
+			Handler:    _ShardService_GetPublicKey_Handler,
+		},
+		{
+			MethodName: "Health",
+			Handler:    _ShardService_Health_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "keysplitting/v1/keysplitting.proto",
+}
@@ -0,0 +1,307 @@
+/*
+Package protobuf encodes shards, partial signatures and signing requests as the protobuf messages defined in
+proto/keysplitting/v1/keysplitting.proto and generated in package keysplittingv1, so that shard holders written in other
+languages can exchange them:
+
+	encoded, err := protobuf.MarshalShard(shard)
+	...
+	shard, err := protobuf.UnmarshalShard(encoded)
+
+It also converts values to and from the generated messages, for transports such as the gRPC service of the shardservice
+package. It is a separate package so that keysplitting doesn't depend on the protobuf runtime
+*/
+package protobuf
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/bastionzero/keysplitting"
+	keysplittingv1 "github.com/bastionzero/keysplitting/proto/keysplitting/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+//go:generate protoc --proto_path=../proto --go_out=../proto --go_opt=paths=source_relative --go-grpc_out=../proto --go-grpc_opt=paths=source_relative keysplitting/v1/keysplitting.proto
+
+// values of the SplitBy enum
+var protoSplitBy = map[keysplitting.SplitBy]keysplittingv1.SplitBy{
+	keysplitting.Multiplication: keysplittingv1.SplitBy_SPLIT_BY_MULTIPLICATION,
+	keysplitting.Addition:       keysplittingv1.SplitBy_SPLIT_BY_ADDITION,
+}
+
+// MarshalShard returns the protobuf encoding of the shard as a keysplitting.v1.PrivateKeyShard message
+func MarshalShard(shard *keysplitting.PrivateKeyShard) ([]byte, error) {
+	if shard.D == nil {
+		return nil, fmt.Errorf("%w: shard has been zeroized", keysplitting.ErrInvalidShard)
+	}
+	splitBy, err := splitByToProto(shard.SplitBy)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := &keysplittingv1.PrivateKeyShard{
+		PublicKey: PublicKeyToProto(shard.PublicKey),
+		D:         bigIntToProto(shard.D),
+		SplitBy:   splitBy,
+		Index:     int32(shard.Index),
+		Mask:      bigIntToProto(shard.Mask),
+	}
+	return proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+}
+
+// UnmarshalShard decodes a keysplitting.v1.PrivateKeyShard message, rejecting any shard that fails
+// [keysplitting.PrivateKeyShard.Validate]
+func UnmarshalShard(data []byte) (*keysplitting.PrivateKeyShard, error) {
+	var msg keysplittingv1.PrivateKeyShard
+	if err := proto.Unmarshal(data, &msg); err != nil {
+		return nil, fmt.Errorf("%w: failed to decode protobuf private key shard: %s", keysplitting.ErrInvalidShard, err)
+	}
+	pub, err := PublicKeyFromProto(msg.GetPublicKey())
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to decode protobuf private key shard: %s", keysplitting.ErrInvalidShard, err)
+	}
+	splitBy, err := splitByFromProto(msg.GetSplitBy())
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to decode protobuf private key shard: %s", keysplitting.ErrInvalidShard, err)
+	}
+
+	shard := &keysplitting.PrivateKeyShard{
+		PublicKey: pub,
+		D:         bigIntFromProto(msg.GetD()),
+		SplitBy:   splitBy,
+		Index:     int(msg.GetIndex()),
+		Mask:      bigIntFromProto(msg.GetMask()),
+	}
+	if err := shard.Validate(); err != nil {
+		return nil, err
+	}
+	return shard, nil
+}
+
+// MarshalPartialSignature returns the protobuf encoding of the partial signature as a keysplitting.v1.PartialSignature
+// message
+func MarshalPartialSignature(partialSig *keysplitting.PartialSignature) ([]byte, error) {
+	msg, err := PartialSignatureToProto(partialSig)
+	if err != nil {
+		return nil, err
+	}
+	return proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+}
+
+// UnmarshalPartialSignature decodes a keysplitting.v1.PartialSignature message
+func UnmarshalPartialSignature(data []byte) (*keysplitting.PartialSignature, error) {
+	var msg keysplittingv1.PartialSignature
+	if err := proto.Unmarshal(data, &msg); err != nil {
+		return nil, fmt.Errorf("%w: failed to decode protobuf partial signature: %s", keysplitting.ErrInvalidPartialSignature, err)
+	}
+	return PartialSignatureFromProto(&msg)
+}
+
+// MarshalSigningRequest returns the protobuf encoding of the request as a keysplitting.v1.SigningRequest message
+func MarshalSigningRequest(req *keysplitting.SigningRequest) ([]byte, error) {
+	msg, err := SigningRequestToProto(req)
+	if err != nil {
+		return nil, err
+	}
+	return proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+}
+
+// UnmarshalSigningRequest decodes a keysplitting.v1.SigningRequest message
+func UnmarshalSigningRequest(data []byte) (*keysplitting.SigningRequest, error) {
+	var msg keysplittingv1.SigningRequest
+	if err := proto.Unmarshal(data, &msg); err != nil {
+		return nil, fmt.Errorf("failed to decode protobuf signing request: %s", err)
+	}
+	return SigningRequestFromProto(&msg)
+}
+
+// PartialSignatureToProto returns the partial signature as a keysplitting.v1.PartialSignature message
+func PartialSignatureToProto(partialSig *keysplitting.PartialSignature) (*keysplittingv1.PartialSignature, error) {
+	splitBy, err := splitByToProto(partialSig.SplitBy)
+	if err != nil {
+		return nil, err
+	}
+	signers := make([]int32, len(partialSig.Signers))
+	for i, signer := range partialSig.Signers {
+		if signer < 0 || signer > math.MaxInt32 {
+			return nil, fmt.Errorf("%w: signer index %d is out of range", keysplitting.ErrInvalidPartialSignature, signer)
+		}
+		signers[i] = int32(signer)
+	}
+
+	return &keysplittingv1.PartialSignature{
+		Signers: signers,
+		Hash:    uint32(partialSig.Hash),
+		SplitBy: splitBy,
+		Sig:     partialSig.Sig,
+		Nonce:   partialSig.Nonce,
+		Proof:   partialSig.Proof,
+	}, nil
+}
+
+// PartialSignatureFromProto returns the partial signature in a keysplitting.v1.PartialSignature message
+func PartialSignatureFromProto(msg *keysplittingv1.PartialSignature) (*keysplitting.PartialSignature, error) {
+	splitBy, err := splitByFromProto(msg.GetSplitBy())
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to decode protobuf partial signature: %s", keysplitting.ErrInvalidPartialSignature, err)
+	}
+	if err := checkSplitBy(splitBy); err != nil {
+		return nil, err
+	}
+	if len(msg.GetSig()) == 0 {
+		return nil, fmt.Errorf("%w: partial signature is empty", keysplitting.ErrInvalidPartialSignature)
+	}
+
+	decoded := &keysplitting.PartialSignature{
+		Signers: make([]int, len(msg.GetSigners())),
+		Hash:    crypto.Hash(msg.GetHash()),
+		SplitBy: splitBy,
+		Sig:     msg.GetSig(),
+		Nonce:   msg.GetNonce(),
+		Proof:   msg.GetProof(),
+	}
+	for i, signer := range msg.GetSigners() {
+		if signer < 0 {
+			return nil, fmt.Errorf("%w: signer index %d is negative", keysplitting.ErrInvalidPartialSignature, signer)
+		}
+		decoded.Signers[i] = int(signer)
+	}
+	return decoded, nil
+}
+
+// SigningRequestToProto returns the request as a keysplitting.v1.SigningRequest message
+func SigningRequestToProto(req *keysplitting.SigningRequest) (*keysplittingv1.SigningRequest, error) {
+	msg := &keysplittingv1.SigningRequest{
+		KeyId:   req.KeyID,
+		Hash:    uint32(req.Hash),
+		Digest:  req.Digest,
+		PssSalt: req.PSSSalt,
+		Nonce:   req.Nonce,
+	}
+	if req.PartialSignature != nil {
+		partialSig, err := PartialSignatureToProto(req.PartialSignature)
+		if err != nil {
+			return nil, err
+		}
+		msg.PartialSignature = partialSig
+	}
+	return msg, nil
+}
+
+// SigningRequestFromProto returns the request in a keysplitting.v1.SigningRequest message
+func SigningRequestFromProto(msg *keysplittingv1.SigningRequest) (*keysplitting.SigningRequest, error) {
+	req := &keysplitting.SigningRequest{
+		KeyID:   msg.GetKeyId(),
+		Hash:    crypto.Hash(msg.GetHash()),
+		Digest:  msg.GetDigest(),
+		PSSSalt: msg.GetPssSalt(),
+		Nonce:   msg.GetNonce(),
+	}
+	if partialSig := msg.GetPartialSignature(); partialSig != nil {
+		decoded, err := PartialSignatureFromProto(partialSig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode protobuf signing request: %w", err)
+		}
+		req.PartialSignature = decoded
+	}
+	return req, nil
+}
+
+// PublicKeyToProto returns pub as a keysplitting.v1.PublicKey message
+func PublicKeyToProto(pub *rsa.PublicKey) *keysplittingv1.PublicKey {
+	return &keysplittingv1.PublicKey{N: pub.N.Bytes(), E: int64(pub.E)}
+}
+
+// PublicKeyFromProto returns the public key in a keysplitting.v1.PublicKey message
+func PublicKeyFromProto(msg *keysplittingv1.PublicKey) (*rsa.PublicKey, error) {
+	if msg.GetE() < 0 || msg.GetE() > math.MaxInt32 {
+		return nil, fmt.Errorf("public exponent is out of range")
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(msg.GetN()), E: int(msg.GetE())}, nil
+}
+
+// the RemoteShard returned by NewLoopbackShard
+type loopbackShard struct {
+	shard keysplitting.RemoteShard
+}
+
+// NewLoopbackShard returns a keysplitting.RemoteShard that passes each request and response to and from shard through their
+// protobuf encodings, as a networked transport would. It is useful for testing code that will be deployed with remote shards
+func NewLoopbackShard(shard keysplitting.RemoteShard) keysplitting.RemoteShard {
+	return &loopbackShard{shard: shard}
+}
+
+func (s *loopbackShard) PartialSign(ctx context.Context, req *keysplitting.SigningRequest) (*keysplitting.PartialSignature, error) {
+	encodedReq, err := MarshalSigningRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	received, err := UnmarshalSigningRequest(encodedReq)
+	if err != nil {
+		return nil, err
+	}
+
+	partialSig, err := s.shard.PartialSign(ctx, received)
+	if err != nil {
+		return nil, err
+	}
+
+	encodedResp, err := MarshalPartialSignature(partialSig)
+	if err != nil {
+		return nil, err
+	}
+	return UnmarshalPartialSignature(encodedResp)
+}
+
+// returns n as a BigInt message, or nil if n is nil
+func bigIntToProto(n *big.Int) *keysplittingv1.BigInt {
+	if n == nil {
+		return nil
+	}
+	return &keysplittingv1.BigInt{Magnitude: new(big.Int).Abs(n).Bytes(), Negative: n.Sign() < 0}
+}
+
+// returns the value of a BigInt message, or nil if it is absent
+func bigIntFromProto(msg *keysplittingv1.BigInt) *big.Int {
+	if msg == nil {
+		return nil
+	}
+	n := new(big.Int).SetBytes(msg.GetMagnitude())
+	if msg.GetNegative() {
+		n.Neg(n)
+	}
+	return n
+}
+
+// returns an error unless splitBy is one of the split algorithms
+func checkSplitBy(splitBy keysplitting.SplitBy) error {
+	if _, ok := protoSplitBy[splitBy]; !ok {
+		return fmt.Errorf("%w: unrecognized split algorithm: %v", keysplitting.ErrUnsupportedSplitBy, splitBy)
+	}
+	return nil
+}
+
+// returns the value of the SplitBy enum corresponding to splitBy
+func splitByToProto(splitBy keysplitting.SplitBy) (keysplittingv1.SplitBy, error) {
+	if err := checkSplitBy(splitBy); err != nil {
+		return 0, err
+	}
+	return protoSplitBy[splitBy], nil
+}
+
+// returns the SplitBy corresponding to a value of the SplitBy enum, which is empty for SPLIT_BY_UNSPECIFIED
+func splitByFromProto(value keysplittingv1.SplitBy) (keysplitting.SplitBy, error) {
+	if value == keysplittingv1.SplitBy_SPLIT_BY_UNSPECIFIED {
+		return "", nil
+	}
+	for splitBy, v := range protoSplitBy {
+		if v == value {
+			return splitBy, nil
+		}
+	}
+	return "", fmt.Errorf("unrecognized split algorithm %d", value)
+}
@@ -0,0 +1,193 @@
+package protobuf
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/bastionzero/keysplitting"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestProtobuf(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Protobuf Suite")
+}
+
+var _ = Describe("Protobuf encoding", func() {
+	Context("Shards", func() {
+		key, _ := rsa.GenerateKey(rand.Reader, 2048)
+		shards, _ := keysplitting.SplitDWithOptions(key, 2, keysplitting.Addition, &keysplitting.SplitOptions{Mask: true})
+
+		It("Round-trips, including negative shards", func() {
+			subShards, err := keysplitting.SplitShard(shards[0], 2, 3)
+			Expect(err).To(BeNil())
+
+			for _, shard := range append(shards, subShards...) {
+				encoded, err := MarshalShard(shard)
+				Expect(err).To(BeNil())
+
+				decoded, err := UnmarshalShard(encoded)
+				Expect(err).To(BeNil())
+				Expect(decoded.Equal(shard)).To(BeTrue())
+			}
+		})
+
+		It("Skips unknown fields", func() {
+			encoded, err := MarshalShard(shards[1])
+			Expect(err).To(BeNil())
+
+			// field 15 as a varint, field 16 as a string, field 17 as fixed32
+			encoded = append(encoded, 0x78, 0x01, 0x82, 0x01, 0x02, 'h', 'i', 0x8d, 0x01, 1, 2, 3, 4)
+			decoded, err := UnmarshalShard(encoded)
+			Expect(err).To(BeNil())
+			Expect(decoded.Equal(shards[1])).To(BeTrue())
+		})
+
+		It("Rejects truncated messages", func() {
+			encoded, err := MarshalShard(shards[1])
+			Expect(err).To(BeNil())
+
+			_, err = UnmarshalShard(encoded[:len(encoded)-1])
+			Expect(errors.Is(err, keysplitting.ErrInvalidShard)).To(BeTrue())
+		})
+
+		It("Refuses zeroized shards", func() {
+			shard := *shards[1]
+			shard.Zeroize()
+			_, err := MarshalShard(&shard)
+			Expect(errors.Is(err, keysplitting.ErrInvalidShard)).To(BeTrue())
+		})
+	})
+
+	Context("Partial signatures", func() {
+		It("Matches the expected wire format", func() {
+			partialSig := &keysplitting.PartialSignature{Signers: []int{1, 300}, Hash: crypto.SHA256, SplitBy: keysplitting.Addition, Sig: []byte{0, 0xff}}
+			encoded, err := MarshalPartialSignature(partialSig)
+			Expect(err).To(BeNil())
+			// signers packed as [1, 300], hash = 5, split_by = 2, sig = 00ff
+			Expect(hex.EncodeToString(encoded)).To(Equal("0a0301ac02" + "1005" + "1802" + "220200ff"))
+
+			decoded, err := UnmarshalPartialSignature(encoded)
+			Expect(err).To(BeNil())
+			Expect(decoded).To(Equal(partialSig))
+		})
+
+		It("Accepts unpacked signers", func() {
+			encoded, _ := hex.DecodeString("0801" + "0802" + "1802" + "220101")
+			decoded, err := UnmarshalPartialSignature(encoded)
+			Expect(err).To(BeNil())
+			Expect(decoded.Signers).To(Equal([]int{1, 2}))
+			Expect(decoded.Hash).To(Equal(crypto.Hash(0)))
+		})
+
+		It("Round-trips a real partial signature", func() {
+			key, _ := rsa.GenerateKey(rand.Reader, 2048)
+			shards, _ := keysplitting.SplitD(key, 2, keysplitting.Multiplication)
+			hashed := sha256.Sum256([]byte("TEST MESSAGE"))
+
+			partialSig, err := keysplitting.SignFirst(rand.Reader, shards[0], crypto.SHA256, hashed[:])
+			Expect(err).To(BeNil())
+			encoded, err := MarshalPartialSignature(partialSig)
+			Expect(err).To(BeNil())
+
+			decoded, err := UnmarshalPartialSignature(encoded)
+			Expect(err).To(BeNil())
+			sig, err := keysplitting.SignNext(rand.Reader, shards[1], crypto.SHA256, hashed[:], decoded)
+			Expect(err).To(BeNil())
+			Expect(rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, hashed[:], sig.Sig)).To(Succeed())
+		})
+
+		It("Rejects a missing split algorithm", func() {
+			encoded, _ := hex.DecodeString("0801" + "220101")
+			_, err := UnmarshalPartialSignature(encoded)
+			Expect(errors.Is(err, keysplitting.ErrUnsupportedSplitBy)).To(BeTrue())
+		})
+
+		It("Keeps the session nonce and proof", func() {
+			partialSig := &keysplitting.PartialSignature{Signers: []int{1}, Hash: crypto.SHA256, SplitBy: keysplitting.Addition, Sig: []byte{1}, Nonce: []byte("NONCE"), Proof: []byte("PROOF")}
+			encoded, err := MarshalPartialSignature(partialSig)
+			Expect(err).To(BeNil())
+
+			decoded, err := UnmarshalPartialSignature(encoded)
+			Expect(err).To(BeNil())
+			Expect(decoded).To(Equal(partialSig))
+		})
+	})
+
+	Context("Big integers", func() {
+		It("Round-trips zero, positive, and negative values", func() {
+			for _, n := range []*big.Int{big.NewInt(0), big.NewInt(300), big.NewInt(-300)} {
+				Expect(bigIntFromProto(bigIntToProto(n)).Cmp(n)).To(Equal(0))
+			}
+			Expect(bigIntToProto(nil)).To(BeNil())
+			Expect(bigIntFromProto(nil)).To(BeNil())
+		})
+	})
+
+	Context("Signing requests", func() {
+		It("Round-trips", func() {
+			partialSig := &keysplitting.PartialSignature{Signers: []int{1}, Hash: crypto.SHA256, SplitBy: keysplitting.Multiplication, Sig: []byte{1, 2}}
+			for _, req := range []*keysplitting.SigningRequest{
+				{KeyID: "abc", Hash: crypto.SHA256, Digest: []byte{3}},
+				{KeyID: "abc", Hash: crypto.SHA256, Digest: []byte{3}, PSSSalt: []byte{4}, PartialSignature: partialSig, Nonce: []byte{5}},
+			} {
+				encoded, err := MarshalSigningRequest(req)
+				Expect(err).To(BeNil())
+
+				decoded, err := UnmarshalSigningRequest(encoded)
+				Expect(err).To(BeNil())
+				Expect(decoded).To(Equal(req))
+			}
+		})
+	})
+})
+
+var _ = Describe("Loopback shards", func() {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	hashed := sha256.Sum256([]byte("TEST MESSAGE"))
+	ctx := context.Background()
+
+	// a local shard for each of the shards, alternating with loopback ones
+	remotes := func(shards []*keysplitting.PrivateKeyShard) []keysplitting.RemoteShard {
+		remotes := make([]keysplitting.RemoteShard, len(shards))
+		for i, shard := range shards {
+			local, err := keysplitting.NewLocalShard(shard)
+			Expect(err).To(BeNil())
+			if remotes[i] = local; i%2 == 1 {
+				remotes[i] = NewLoopbackShard(local)
+			}
+		}
+		return remotes
+	}
+
+	It("Signs sequentially with a mix of local and loopback shards", func() {
+		for _, splitBy := range []keysplitting.SplitBy{keysplitting.Addition, keysplitting.Multiplication} {
+			shards, err := keysplitting.SplitD(key, 3, splitBy)
+			Expect(err).To(BeNil())
+
+			sig, err := keysplitting.SignSequential(ctx, &key.PublicKey, crypto.SHA256, hashed[:], remotes(shards)...)
+			Expect(err).To(BeNil())
+			Expect(rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, hashed[:], sig)).To(Succeed())
+		}
+	})
+
+	It("Signs brokered with a mix of local and loopback shards", func() {
+		shards, err := keysplitting.SplitD(key, 3, keysplitting.Addition)
+		Expect(err).To(BeNil())
+		salt, err := keysplitting.NewPSSSalt(rand.Reader, &key.PublicKey, crypto.SHA256, nil)
+		Expect(err).To(BeNil())
+
+		opts := &keysplitting.PSSOptions{Hash: crypto.SHA256, Salt: salt}
+		sig, err := keysplitting.SignBrokered(ctx, &key.PublicKey, opts, hashed[:], remotes(shards)...)
+		Expect(err).To(BeNil())
+		Expect(rsa.VerifyPSS(&key.PublicKey, crypto.SHA256, hashed[:], sig, &rsa.PSSOptions{SaltLength: len(salt)})).To(Succeed())
+	})
+})
@@ -0,0 +1,103 @@
+package keysplitting
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"io"
+	"time"
+)
+
+// RSASSA-PSS signatures are randomized by a salt. Every party must encode the message identically in order for their
+// partial signatures to combine into a valid signature, so the salt cannot be chosen independently by each signer.
+// Instead, it is generated once with NewPSSSalt (typically by the first signer or the broker) and sent alongside the
+// message to every other party. The salt is not secret.
+
+// NewPSSSalt generates a random salt for use in a split PSS signature. The salt length is determined by opts in the same
+// way as [rsa.SignPSS]. If opts is nil, the salt is as long as possible, which matches the default of [rsa.SignPSS].
+// Note that the verifier must use a compatible salt length, e.g. [rsa.PSSSaltLengthAuto]
+func NewPSSSalt(random io.Reader, pub *rsa.PublicKey, hashFn crypto.Hash, opts *rsa.PSSOptions) ([]byte, error) {
+	if opts != nil && opts.Hash != 0 {
+		hashFn = opts.Hash
+	}
+
+	saltLength := rsa.PSSSaltLengthAuto
+	if opts != nil {
+		saltLength = opts.SaltLength
+	}
+
+	switch saltLength {
+	case rsa.PSSSaltLengthAuto:
+		saltLength = (pub.N.BitLen()-1+7)/8 - 2 - hashFn.Size()
+	case rsa.PSSSaltLengthEqualsHash:
+		saltLength = hashFn.Size()
+	}
+
+	if saltLength < 0 {
+		return nil, rsa.ErrMessageTooLong
+	}
+
+	salt := make([]byte, saltLength)
+	if _, err := io.ReadFull(random, salt); err != nil {
+		return nil, &RandomnessError{Err: err}
+	}
+	return salt, nil
+}
+
+// PSSOptions selects RSASSA-PSS when passed as the [crypto.SignerOpts] to [SignFirst], [SignNext], or
+// [PrivateKeyShard.Sign]. Unlike [rsa.PSSOptions], it carries the salt itself, which every party must share
+type PSSOptions struct {
+	Hash crypto.Hash // the hash function used to produce the signed digest
+	Salt []byte      // the shared salt (see [NewPSSSalt])
+}
+
+// HashFunc returns opts.Hash so that PSSOptions implements [crypto.SignerOpts]
+func (opts *PSSOptions) HashFunc() crypto.Hash {
+	return opts.Hash
+}
+
+// SignFirstPSS uses the given key shard to perform the initial RSASSA-PSS signature on a hashed message.
+// Note that hashed must be the result of hashing the input message using the given hash function, and that
+// every party must use the same salt (see [NewPSSSalt])
+func SignFirstPSS(random io.Reader, shard *PrivateKeyShard, hashFn crypto.Hash, hashed []byte, salt []byte) (*PartialSignature, error) {
+	start := time.Now()
+	sig, err := signFirstPSS(random, shard, hashFn, hashed, salt)
+	observePartialSignature(start, err)
+	auditPartialSign(shard, hashFn, hashed, err)
+	if err != nil {
+		return nil, err
+	}
+	return newPartialSignature(shard, hashFn, sig), nil
+}
+
+// returns the shard's own RSASSA-PSS signature on hashed
+func signFirstPSS(random io.Reader, shard *PrivateKeyShard, hashFn crypto.Hash, hashed []byte, salt []byte) ([]byte, error) {
+	if err := checkSplitBy(shard.SplitBy); err != nil {
+		return nil, err
+	}
+	if err := shard.checkUsable(); err != nil {
+		return nil, err
+	}
+
+	priv := &rsa.PrivateKey{
+		PublicKey: *shard.PublicKey,
+		D:         shard.exponent(hashed, salt),
+	}
+	return signPSSWithSalt(random, priv, hashFn, hashed, salt)
+}
+
+// SignNextPSS uses the given key shard to add an RSASSA-PSS signature to a partially-signed message.
+// It combines signatures in the same way as [SignNext].
+//
+// Note that hashed must be the result of hashing the input message using the given hash function, and that
+// every party must use the same salt (see [NewPSSSalt]). For multiplicative shards, neither is strictly required.
+//
+// Once all parties have signed, the result can be verified with [rsa.VerifyPSS]
+func SignNextPSS(random io.Reader, shard *PrivateKeyShard, hashFn crypto.Hash, hashed []byte, salt []byte, partialSig *PartialSignature) (*PartialSignature, error) {
+	start := time.Now()
+	next, err := signNext(shard, hashFn, nil, partialSig, func() ([]byte, error) {
+		return signFirstPSS(random, shard, hashFn, hashed, salt)
+	})
+	observePartialSignature(start, err)
+	auditPartialSign(shard, hashFn, hashed, err)
+	return next, err
+}
@@ -0,0 +1,122 @@
+package keysplitting
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// run a full workflow of splitting a key and using the shards to produce a PSS signature
+func runPSSTest(priv *rsa.PrivateKey, k int, hashed []byte, splitBy SplitBy, opts *rsa.PSSOptions) {
+	It("Produces a valid split PSS signature", func() {
+		shards, err := SplitD(priv, k, splitBy)
+		Expect(err).To(BeNil(), fmt.Sprintf("failed to split RSA key into %d shards: %s", k, err))
+
+		salt, err := NewPSSSalt(rand.Reader, &priv.PublicKey, crypto.SHA256, opts)
+		Expect(err).To(BeNil(), fmt.Sprintf("failed to generate salt: %s", err))
+
+		sigNext, err := SignFirstPSS(rand.Reader, shards[0], crypto.SHA256, hashed, salt)
+		Expect(err).To(BeNil(), fmt.Sprintf("failed to generate first signature: %s", err))
+
+		for i := 1; i < len(shards); i++ {
+			Expect(rsa.VerifyPSS(&priv.PublicKey, crypto.SHA256, hashed, sigNext.Sig, opts)).NotTo(Succeed(), "partial signature must not verify")
+
+			sigNext, err = SignNextPSS(rand.Reader, shards[i], crypto.SHA256, hashed, salt, sigNext)
+			Expect(err).To(BeNil(), fmt.Sprintf("failed to generate signature #%d: %s", i, err))
+		}
+
+		Expect(rsa.VerifyPSS(&priv.PublicKey, crypto.SHA256, hashed, sigNext.Sig, opts)).To(Succeed())
+	})
+}
+
+var _ = Describe("PSS", func() {
+	hashed := sha256.Sum256([]byte("TEST MESSAGE"))
+	priv, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	Context("Sequential signatures", func() {
+		When("Splitting a key multiplicatively", func() {
+			runPSSTest(priv, 3, hashed[:], Multiplication, nil)
+		})
+
+		When("Splitting a key additively", func() {
+			runPSSTest(priv, 3, hashed[:], Addition, nil)
+		})
+
+		When("Using a salt as long as the hash", func() {
+			runPSSTest(priv, 3, hashed[:], Addition, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		})
+	})
+
+	Context("Brokered signatures", func() {
+		It("Produces a valid combined PSS signature", func() {
+			shards, err := SplitD(priv, 3, Addition)
+			Expect(err).To(BeNil())
+
+			salt, err := NewPSSSalt(rand.Reader, &priv.PublicKey, crypto.SHA256, nil)
+			Expect(err).To(BeNil())
+
+			partials := make([]*PartialSignature, len(shards))
+			for i, shard := range shards {
+				partials[i], err = SignFirstPSS(rand.Reader, shard, crypto.SHA256, hashed[:], salt)
+				Expect(err).To(BeNil())
+			}
+
+			sig, err := CombinePartialSignatures(&priv.PublicKey, partials...)
+			Expect(err).To(BeNil())
+			Expect(rsa.VerifyPSS(&priv.PublicKey, crypto.SHA256, hashed[:], sig, nil)).To(Succeed())
+		})
+	})
+
+	Context("Signer options", func() {
+		It("Produces a valid PSS signature when SignFirst and SignNext are given PSSOptions", func() {
+			shards, _ := SplitD(priv, 3, Multiplication)
+			salt, _ := NewPSSSalt(rand.Reader, &priv.PublicKey, crypto.SHA256, nil)
+			opts := &PSSOptions{Hash: crypto.SHA256, Salt: salt}
+
+			sig, err := SignFirst(rand.Reader, shards[0], opts, hashed[:])
+			Expect(err).To(BeNil())
+			for _, shard := range shards[1:] {
+				sig, err = SignNext(rand.Reader, shard, opts, hashed[:], sig)
+				Expect(err).To(BeNil())
+			}
+			Expect(rsa.VerifyPSS(&priv.PublicKey, crypto.SHA256, hashed[:], sig.Sig, nil)).To(Succeed())
+		})
+
+		It("Refuses rsa.PSSOptions, which has no shared salt", func() {
+			shards, _ := SplitD(priv, 2, Addition)
+
+			_, err := SignFirst(rand.Reader, shards[0], &rsa.PSSOptions{Hash: crypto.SHA256}, hashed[:])
+			Expect(err).NotTo(BeNil())
+		})
+
+		It("Refuses nil options", func() {
+			shards, _ := SplitD(priv, 2, Addition)
+
+			_, err := SignFirst(rand.Reader, shards[0], nil, hashed[:])
+			Expect(err).NotTo(BeNil())
+			_, err = SignNext(rand.Reader, shards[1], nil, hashed[:], &PartialSignature{Hash: crypto.SHA256, SplitBy: Addition, Sig: []byte{1}})
+			Expect(err).NotTo(BeNil())
+		})
+	})
+
+	Context("Mismatched salts", func() {
+		It("Does not produce a valid signature", func() {
+			shards, _ := SplitD(priv, 2, Addition)
+
+			salt1, _ := NewPSSSalt(rand.Reader, &priv.PublicKey, crypto.SHA256, nil)
+			salt2, _ := NewPSSSalt(rand.Reader, &priv.PublicKey, crypto.SHA256, nil)
+
+			sig1, err := SignFirstPSS(rand.Reader, shards[0], crypto.SHA256, hashed[:], salt1)
+			Expect(err).To(BeNil())
+			sig2, err := SignNextPSS(rand.Reader, shards[1], crypto.SHA256, hashed[:], salt2, sig1)
+			Expect(err).To(BeNil())
+
+			Expect(rsa.VerifyPSS(&priv.PublicKey, crypto.SHA256, hashed[:], sig2.Sig, nil)).NotTo(Succeed())
+		})
+	})
+})
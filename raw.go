@@ -0,0 +1,34 @@
+package keysplitting
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"io"
+)
+
+// Some protocols, such as TLS 1.1 and older and some legacy token formats, sign data that is not a standard digest, e.g.
+// the concatenation of an MD5 and a SHA-1 hash. For these, PKCS #1 v1.5 allows the data to be signed directly without a
+// DigestInfo prefix, which crypto/rsa selects with a zero crypto.Hash. Raw signing isn't advisable except for interoperability.
+
+// SignFirstRaw is like [SignFirst], but signs data directly instead of a digest, equivalent to passing crypto.Hash(0).
+// The resulting signature verifies with [rsa.VerifyPKCS1v15] given a zero hash. data must not be empty, and must be at least
+// 11 bytes shorter than the modulus to leave room for the padding
+func SignFirstRaw(random io.Reader, shard *PrivateKeyShard, data []byte) (*PartialSignature, error) {
+	return SignFirst(random, shard, crypto.Hash(0), data)
+}
+
+// SignNextRaw is like [SignNext], but signs data directly instead of a digest (see [SignFirstRaw])
+func SignNextRaw(random io.Reader, shard *PrivateKeyShard, data []byte, partialSig *PartialSignature) (*PartialSignature, error) {
+	return SignNext(random, shard, crypto.Hash(0), data, partialSig)
+}
+
+// checks that data can be signed directly with a PKCS #1 v1.5 signature under pub
+func checkRawLength(pub *rsa.PublicKey, data []byte) error {
+	if len(data) == 0 {
+		return errorf(ErrUnsupportedHash, "cannot sign empty data")
+	}
+	if maxLen := pub.Size() - 11; len(data) > maxLen {
+		return errorf(ErrUnsupportedHash, "data is %d bytes long, but at most %d bytes can be signed directly with this key", len(data), maxLen)
+	}
+	return nil
+}
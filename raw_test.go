@@ -0,0 +1,62 @@
+package keysplitting
+
+import (
+	"crypto"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Raw signatures", func() {
+	priv, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	// the TLS 1.1 style concatenation of an MD5 and a SHA-1 hash
+	md5Hash := md5.Sum([]byte("TEST MESSAGE"))
+	sha1Hash := sha1.Sum([]byte("TEST MESSAGE"))
+	data := append(md5Hash[:], sha1Hash[:]...)
+
+	for _, splitBy := range []SplitBy{Multiplication, Addition} {
+		splitBy := splitBy
+
+		It("Produces a valid "+string(splitBy)+" split signature on raw data", func() {
+			shards, _ := SplitD(priv, 3, splitBy)
+
+			sig, err := SignFirstRaw(rand.Reader, shards[0], data)
+			Expect(err).To(BeNil())
+			for _, shard := range shards[1:] {
+				sig, err = SignNextRaw(rand.Reader, shard, data, sig)
+				Expect(err).To(BeNil())
+			}
+			Expect(rsa.VerifyPKCS1v15(&priv.PublicKey, crypto.Hash(0), data, sig.Sig)).To(Succeed())
+		})
+	}
+
+	It("Refuses empty data", func() {
+		shards, _ := SplitD(priv, 2, Addition)
+
+		_, err := SignFirstRaw(rand.Reader, shards[0], nil)
+		Expect(err).NotTo(BeNil())
+	})
+
+	It("Refuses data that is too long for the modulus", func() {
+		shards, _ := SplitD(priv, 2, Addition)
+
+		_, err := SignFirstRaw(rand.Reader, shards[0], make([]byte, priv.Size()-11))
+		Expect(err).To(BeNil())
+		_, err = SignFirstRaw(rand.Reader, shards[0], make([]byte, priv.Size()-10))
+		Expect(err).NotTo(BeNil())
+	})
+
+	It("Refuses to mix raw and hashed partial signatures", func() {
+		shards, _ := SplitD(priv, 2, Addition)
+
+		sig, err := SignFirstRaw(rand.Reader, shards[0], data)
+		Expect(err).To(BeNil())
+		_, err = SignNext(rand.Reader, shards[1], crypto.SHA1, sha1Hash[:], sig)
+		Expect(err).NotTo(BeNil())
+	})
+})
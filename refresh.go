@@ -0,0 +1,130 @@
+package keysplitting
+
+import (
+	"math/big"
+)
+
+// A RefreshDelta is a random value sent from one shard holder to another while refreshing additive shards.
+// Deltas are secret and must be sent over a secure channel
+type RefreshDelta struct {
+	From  int      // index of the sending shard
+	To    int      // index of the receiving shard
+	Delta *big.Int // amount moved from the sender's shard to the receiver's shard
+}
+
+// NewRefreshDeltas is the first step of proactively refreshing a set of k additive shards, which all holders perform together.
+// It picks a random delta for each of the other k-1 shards, indexed 1..k, and returns them in order of recipient.
+// Each holder then sends each delta to its recipient and calls [RefreshShard] with all of the deltas it sent and received.
+//
+// Refreshing re-randomizes the shards without changing the key or revealing anything about D: every delta is subtracted
+// from the sender's shard and added to the recipient's, so the sum of the shards is unchanged. Since nobody knows phi(N),
+// the deltas cancel out over the integers rather than (mod phi(N)), so they are random integers 2^128 times larger than
+// N or the sender's shard, whichever is longer, to hide the shards statistically. This lengthens the shards by about 128
+// bits with each refresh. As with the shards produced by [SplitD], a refreshed shard may be negative, which signing
+// handles by inverting (mod N).
+//
+// Once every holder has refreshed, the old shards are useless in combination with the new ones, so an attacker who slowly
+// collects old shards has to start over. Note that shard commitments must be reissued after a refresh
+func NewRefreshDeltas(shard *PrivateKeyShard, k int) ([]*RefreshDelta, error) {
+	if err := checkRefreshable(shard, k); err != nil {
+		return nil, err
+	}
+
+	deltas := make([]*RefreshDelta, 0, k-1)
+	for to := 1; to <= k; to++ {
+		if to == shard.Index {
+			continue
+		}
+
+		delta, err := randomHiding(shard.D, shard.PublicKey.N)
+		if err != nil {
+			return nil, err
+		}
+		deltas = append(deltas, &RefreshDelta{From: shard.Index, To: to, Delta: delta})
+	}
+	return deltas, nil
+}
+
+// RefreshShard is the second step of proactively refreshing a set of k additive shards (see [NewRefreshDeltas]).
+// It returns a new shard that is shard minus all of the outgoing deltas, plus all of the incoming deltas.
+// The old shard should be destroyed once every holder has refreshed
+func RefreshShard(shard *PrivateKeyShard, k int, outgoing []*RefreshDelta, incoming []*RefreshDelta) (*PrivateKeyShard, error) {
+	if err := checkRefreshable(shard, k); err != nil {
+		return nil, err
+	}
+	if len(outgoing) != k-1 || len(incoming) != k-1 {
+		return nil, errorf(ErrTooFewShards, "expected deltas to and from each of the other %d shards", k-1)
+	}
+
+	d := new(big.Int).Set(shard.D)
+	sent := make(map[int]bool)
+	for _, delta := range outgoing {
+		if delta.From != shard.Index {
+			return nil, errorf(ErrInvalidShard, "outgoing delta was not sent by shard %d", shard.Index)
+		}
+		if delta.To == shard.Index || delta.To < 1 || delta.To > k || sent[delta.To] {
+			return nil, errorf(ErrInvalidShard, "unexpected or duplicate delta to shard %d", delta.To)
+		}
+		sent[delta.To] = true
+		d.Sub(d, delta.Delta)
+	}
+	seen := make(map[int]bool)
+	for _, delta := range incoming {
+		if delta.To != shard.Index || delta.From == shard.Index || delta.From < 1 || delta.From > k || seen[delta.From] {
+			return nil, errorf(ErrInvalidShard, "unexpected or duplicate delta from shard %d", delta.From)
+		}
+		seen[delta.From] = true
+		d.Add(d, delta.Delta)
+	}
+
+	return &PrivateKeyShard{
+		PublicKey: shard.PublicKey,
+		D:         d,
+		SplitBy:   shard.SplitBy,
+		Index:     shard.Index,
+		Mask:      shard.Mask,
+	}, nil
+}
+
+// RefreshShards runs the refresh protocol on a complete set of additive shards held in a single place, e.g. by a dealer
+// or in tests. In a real deployment, each holder runs [NewRefreshDeltas] and [RefreshShard] on its own machine instead
+func RefreshShards(shards []*PrivateKeyShard) ([]*PrivateKeyShard, error) {
+	k := len(shards)
+	outgoing := make(map[int][]*RefreshDelta)
+	incoming := make(map[int][]*RefreshDelta)
+
+	for _, shard := range shards {
+		deltas, err := NewRefreshDeltas(shard, k)
+		if err != nil {
+			return nil, err
+		}
+		outgoing[shard.Index] = deltas
+		for _, delta := range deltas {
+			incoming[delta.To] = append(incoming[delta.To], delta)
+		}
+	}
+
+	refreshed := make([]*PrivateKeyShard, k)
+	for i, shard := range shards {
+		newShard, err := RefreshShard(shard, k, outgoing[shard.Index], incoming[shard.Index])
+		if err != nil {
+			return nil, err
+		}
+		refreshed[i] = newShard
+	}
+	return refreshed, nil
+}
+
+// checks that shard is one of k additive shards with a known index
+func checkRefreshable(shard *PrivateKeyShard, k int) error {
+	if shard.SplitBy != Addition {
+		return errorf(ErrUnsupportedSplitBy, "only additive shards can be refreshed")
+	}
+	if k < 2 {
+		return errorf(ErrTooFewShards, "cannot refresh fewer than 2 shards")
+	}
+	if shard.Index < 1 || shard.Index > k {
+		return errorf(ErrInvalidShard, "shard index %d is out of range", shard.Index)
+	}
+	return shard.checkUsable()
+}
@@ -0,0 +1,130 @@
+package keysplitting
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha512"
+	"errors"
+	"math/big"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// signs hashed with every shard and combines the results
+func signBrokered(pub *rsa.PublicKey, shards []*PrivateKeyShard, hashed []byte) []byte {
+	partials := make([]*PartialSignature, len(shards))
+	for i, shard := range shards {
+		var err error
+		partials[i], err = SignFirst(rand.Reader, shard, crypto.SHA512, hashed)
+		Expect(err).To(BeNil())
+	}
+
+	sig, err := CombinePartialSignatures(pub, partials...)
+	Expect(err).To(BeNil())
+	return sig
+}
+
+var _ = Describe("Shard refresh", func() {
+	hashed := sha512.Sum512([]byte("TEST MESSAGE"))
+	priv, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	When("Refreshing additive shards", Ordered, func() {
+		var shards, refreshed []*PrivateKeyShard
+
+		BeforeAll(func() {
+			shards, _ = SplitD(priv, 4, Addition)
+		})
+
+		It("Produces new shards", func() {
+			var err error
+			refreshed, err = RefreshShards(shards)
+			Expect(err).To(BeNil())
+			Expect(refreshed).To(HaveLen(len(shards)))
+
+			for i := range shards {
+				Expect(refreshed[i].Index).To(Equal(shards[i].Index))
+				Expect(refreshed[i].D.Cmp(shards[i].D)).NotTo(Equal(0))
+			}
+		})
+
+		It("Moves deltas far larger than the shards between them", func() {
+			deltas, err := NewRefreshDeltas(shards[0], len(shards))
+			Expect(err).To(BeNil())
+			for _, delta := range deltas {
+				Expect(delta.Delta.BitLen()).To(BeNumerically(">", priv.N.BitLen()+64))
+			}
+		})
+
+		It("Preserves the sum of the shards", func() {
+			Expect(shardSum(refreshed).Cmp(shardSum(shards))).To(Equal(0))
+		})
+
+		It("Produces a valid signature with the new shards", func() {
+			sig := signBrokered(&priv.PublicKey, refreshed, hashed[:])
+			Expect(rsa.VerifyPKCS1v15(&priv.PublicKey, crypto.SHA512, hashed[:], sig)).To(Succeed())
+		})
+
+		It("Does not produce a valid signature when mixing old and new shards", func() {
+			mixed := []*PrivateKeyShard{shards[0], refreshed[1], refreshed[2], refreshed[3]}
+			sig := signBrokered(&priv.PublicKey, mixed, hashed[:])
+			Expect(rsa.VerifyPKCS1v15(&priv.PublicKey, crypto.SHA512, hashed[:], sig)).NotTo(Succeed())
+		})
+
+		It("Can be refreshed repeatedly", func() {
+			again, err := RefreshShards(refreshed)
+			Expect(err).To(BeNil())
+
+			sig := signBrokered(&priv.PublicKey, again, hashed[:])
+			Expect(rsa.VerifyPKCS1v15(&priv.PublicKey, crypto.SHA512, hashed[:], sig)).To(Succeed())
+		})
+	})
+
+	When("Refreshing multiplicative shards", func() {
+		It("Fails", func() {
+			shards, _ := SplitD(priv, 2, Multiplication)
+			_, err := RefreshShards(shards)
+			Expect(err).NotTo(BeNil())
+		})
+	})
+
+	When("Receiving a duplicate delta", func() {
+		It("Fails", func() {
+			shards, _ := SplitD(priv, 3, Addition)
+			deltas1, _ := NewRefreshDeltas(shards[0], 3)
+			deltas2, _ := NewRefreshDeltas(shards[1], 3)
+
+			outgoing, _ := NewRefreshDeltas(shards[2], 3)
+
+			// deltas1[1] and deltas2[1] are both intended for shard 3
+			_, err := RefreshShard(shards[2], 3, outgoing, []*RefreshDelta{deltas1[1], deltas1[1]})
+			Expect(err).NotTo(BeNil())
+
+			_, err = RefreshShard(shards[2], 3, outgoing, []*RefreshDelta{deltas1[1], deltas2[1]})
+			Expect(err).To(BeNil())
+		})
+	})
+
+	When("Sending unexpected deltas", func() {
+		It("Fails", func() {
+			shards, _ := SplitD(priv, 3, Addition)
+			deltas1, _ := NewRefreshDeltas(shards[0], 3)
+			deltas2, _ := NewRefreshDeltas(shards[1], 3)
+			incoming := []*RefreshDelta{deltas2[0], {From: 3, To: 1, Delta: big.NewInt(1)}}
+
+			for _, outgoing := range [][]*RefreshDelta{
+				{deltas1[0], deltas1[0]},
+				{deltas1[0], {From: 1, To: 1, Delta: big.NewInt(1)}},
+				{deltas1[0], {From: 1, To: 4, Delta: big.NewInt(1)}},
+				{deltas1[0], {From: 1, To: 0, Delta: big.NewInt(1)}},
+			} {
+				_, err := RefreshShard(shards[0], 3, outgoing, incoming)
+				Expect(errors.Is(err, ErrInvalidShard)).To(BeTrue())
+			}
+
+			_, err := RefreshShard(shards[0], 3, deltas1, incoming)
+			Expect(err).To(BeNil())
+		})
+	})
+})
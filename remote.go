@@ -0,0 +1,273 @@
+package keysplitting
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"sync"
+	"time"
+)
+
+// A SigningRequest asks a shard holder to add their signature to a digest. It corresponds to the keysplitting.v1.SigningRequest
+// protobuf message, so that transports can send it with the protobuf subpackage
+type SigningRequest struct {
+	KeyID            string            // identifies the key, as returned by [PrivateKeyShard.KeyID]
+	Hash             crypto.Hash       // the hash function used to produce Digest (0 for raw signatures)
+	Digest           []byte            // the hashed message
+	PSSSalt          []byte            // the shared salt, for RSASSA-PSS signatures only (see [NewPSSSalt])
+	PartialSignature *PartialSignature // the signature to extend, or nil if the holder signs first
+	Nonce            []byte            // binds the signature to a signing session, if set (see [NonceOptions])
+}
+
+// NewSigningRequest returns a request for the holder of a shard of pub to sign hashed, which was produced with opts.HashFunc().
+// opts selects the signature scheme as for [SignFirst]
+func NewSigningRequest(pub *rsa.PublicKey, opts crypto.SignerOpts, hashed []byte) (*SigningRequest, error) {
+	req := &SigningRequest{KeyID: PublicKeyID(pub), Digest: hashed}
+	if o, ok := opts.(*NonceOptions); ok {
+		var err error
+		if opts, err = o.unwrap(); err != nil {
+			return nil, err
+		}
+		req.Nonce = o.Nonce
+	}
+	switch o := opts.(type) {
+	case nil:
+		return nil, errorf(ErrUnsupportedHash, "no signer options provided")
+	case *PSSOptions:
+		if len(o.Salt) == 0 {
+			return nil, errorf(ErrUnsupportedHash, "remote PSS signatures require a non-empty shared salt")
+		}
+		req.Hash, req.PSSSalt = o.Hash, o.Salt
+	case *rsa.PSSOptions:
+		return nil, errorf(ErrUnsupportedHash, "split PSS signatures require a shared salt, which *rsa.PSSOptions cannot carry; use *keysplitting.PSSOptions instead")
+	default:
+		req.Hash = opts.HashFunc()
+	}
+	return req, nil
+}
+
+// returns the signer options corresponding to the request
+func (req *SigningRequest) signerOpts() crypto.SignerOpts {
+	var opts crypto.SignerOpts = req.Hash
+	if len(req.PSSSalt) > 0 {
+		opts = &PSSOptions{Hash: req.Hash, Salt: req.PSSSalt}
+	}
+	if len(req.Nonce) > 0 {
+		opts = &NonceOptions{Opts: opts, Nonce: req.Nonce}
+	}
+	return opts
+}
+
+// returns a copy of the request that extends partialSig
+func (req *SigningRequest) next(partialSig *PartialSignature) *SigningRequest {
+	next := *req
+	next.PartialSignature = partialSig
+	return &next
+}
+
+// A RemoteShard is a shard holder that signs on request, whether it is in the same process or on another machine. The
+// sequential and brokered flows of [SignSequential] and [SignBrokered] work the same with any mix of them, and transports
+// such as gRPC or HTTP only need to carry a [SigningRequest] one way and a [PartialSignature] the other
+type RemoteShard interface {
+	// PartialSign adds the holder's signature to req.PartialSignature, or signs first if it is nil
+	PartialSign(ctx context.Context, req *SigningRequest) (*PartialSignature, error)
+}
+
+// the RemoteShard returned by NewLocalShard
+type localShard struct {
+	signer ShardSigner
+	keyID  string
+}
+
+// NewLocalShard returns a RemoteShard that signs in this process with signer, which may be a *PrivateKeyShard or any other
+// [ShardSigner]. It is also what a server for a networked transport would call to answer requests
+func NewLocalShard(signer ShardSigner) (RemoteShard, error) {
+	pub, ok := signer.Public().(*rsa.PublicKey)
+	if !ok || pub == nil {
+		return nil, errorf(ErrInvalidShard, "shard signer has no RSA public key")
+	}
+	return &localShard{signer: signer, keyID: PublicKeyID(pub)}, nil
+}
+
+func (s *localShard) PartialSign(ctx context.Context, req *SigningRequest) (*PartialSignature, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if req.KeyID != s.keyID {
+		return nil, errorf(ErrKeyMismatch, "signing request is for key %s, but the shard belongs to key %s", req.KeyID, s.keyID)
+	}
+
+	// in-memory shards can trace their signature as part of ctx
+	if shard, ok := s.signer.(*PrivateKeyShard); ok {
+		if req.PartialSignature == nil {
+			return signFirstContext(ctx, rand.Reader, shard, req.signerOpts(), req.Digest)
+		}
+		return signNextContext(ctx, rand.Reader, shard, req.signerOpts(), req.Digest, req.PartialSignature)
+	}
+	if req.PartialSignature == nil {
+		return s.signer.SignFirst(rand.Reader, req.signerOpts(), req.Digest)
+	}
+	return s.signer.SignNext(rand.Reader, req.signerOpts(), req.Digest, req.PartialSignature)
+}
+
+// SignSequential has each shard sign in turn, as with [SignFirst] followed by [SignNext], and returns the final signature.
+// It works for keys split by either algorithm. Unless opts is a *[NonceOptions], the signature is bound to a fresh session nonce
+func SignSequential(ctx context.Context, pub *rsa.PublicKey, opts crypto.SignerOpts, hashed []byte, shards ...RemoteShard) (sig []byte, err error) {
+	ctx, span := StartSpan(ctx, "keysplitting.SignSequential", remoteAttributes(pub, opts, len(shards))...)
+	defer func() { span.End(err) }()
+	defer startSession()()
+
+	if len(shards) < 2 {
+		return nil, errorf(ErrTooFewShards, "cannot sign with fewer than 2 shards")
+	}
+	opts, err = withNonce(opts)
+	if err != nil {
+		return nil, err
+	}
+	req, err := NewSigningRequest(pub, opts, hashed)
+	if err != nil {
+		return nil, err
+	}
+
+	var partialSig *PartialSignature
+	for _, shard := range shards {
+		if partialSig, err = partialSign(ctx, shard, req.next(partialSig)); err != nil {
+			return nil, err
+		}
+	}
+	// the last shard completes the signature, so there is nothing to combine except the nonce to remove
+	start := time.Now()
+	if err := partialSig.checkNonce(req.Nonce); err != nil {
+		observeCombine(start, err)
+		return nil, err
+	}
+	sig, err = unbindNonce(pub, req.Nonce, partialSig.Sig)
+	observeCombine(start, err)
+	return sig, err
+}
+
+// SignBrokered asks every shard to sign at once, and combines their partial signatures with [CombinePartialSignatures].
+// It only works for keys split with [SplitBy].Addition. If any shard fails, the others' requests are canceled.
+// As with [SignSequential], the signature is bound to a fresh session nonce unless opts is a *[NonceOptions]
+func SignBrokered(ctx context.Context, pub *rsa.PublicKey, opts crypto.SignerOpts, hashed []byte, shards ...RemoteShard) (sig []byte, err error) {
+	ctx, span := StartSpan(ctx, "keysplitting.SignBrokered", remoteAttributes(pub, opts, len(shards))...)
+	defer func() { span.End(err) }()
+	defer startSession()()
+
+	if len(shards) < 2 {
+		return nil, errorf(ErrTooFewShards, "cannot sign with fewer than 2 shards")
+	}
+	opts, err = withNonce(opts)
+	if err != nil {
+		return nil, err
+	}
+	req, err := NewSigningRequest(pub, opts, hashed)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	partials := make([]*PartialSignature, len(shards))
+	errs := make([]error, len(shards))
+	var wg sync.WaitGroup
+	for i, shard := range shards {
+		wg.Add(1)
+		go func(i int, shard RemoteShard) {
+			defer wg.Done()
+			if partials[i], errs[i] = partialSign(ctx, shard, req.next(nil)); errs[i] != nil {
+				cancel()
+			}
+		}(i, shard)
+	}
+	wg.Wait()
+
+	// report the first failure rather than a cancellation it caused
+	for _, err := range errs {
+		if err != nil && err != context.Canceled {
+			return nil, err
+		}
+	}
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, partialSig := range partials {
+		if err := partialSig.checkNonce(req.Nonce); err != nil {
+			return nil, err
+		}
+	}
+	return CombinePartialSignatures(pub, partials...)
+}
+
+// SignAllParallel has every shard sign in its own goroutine, combines their partial signatures as [SignBrokered] does, and
+// checks the signature against the shards' public key before returning it. The shards may be *PrivateKeyShard values or
+// any other [ShardSigner], and must all be additive shards of the same key. A signature that doesn't verify is reported as
+// [ErrIncompleteSignature]. Shards held elsewhere can be signed with in the same way with [SignBrokered]
+func SignAllParallel(ctx context.Context, opts crypto.SignerOpts, hashed []byte, shards ...ShardSigner) ([]byte, error) {
+	if len(shards) < 2 {
+		return nil, errorf(ErrTooFewShards, "cannot sign with fewer than 2 shards")
+	}
+	remotes := make([]RemoteShard, len(shards))
+	for i, shard := range shards {
+		remote, err := NewLocalShard(shard)
+		if err != nil {
+			return nil, err
+		}
+		remotes[i] = remote
+	}
+
+	pub := shards[0].Public().(*rsa.PublicKey)
+	sig, err := SignBrokered(ctx, pub, opts, hashed, remotes...)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifySignature(pub, opts, hashed, sig); err != nil {
+		return nil, err
+	}
+	return sig, nil
+}
+
+// verifies a complete signature according to the signature scheme of opts
+func verifySignature(pub *rsa.PublicKey, opts crypto.SignerOpts, hashed, sig []byte) error {
+	if o, ok := opts.(*NonceOptions); ok {
+		var err error
+		if opts, err = o.unwrap(); err != nil {
+			return err
+		}
+	}
+	if pssOpts, ok := opts.(*PSSOptions); ok {
+		if err := rsa.VerifyPSS(pub, pssOpts.Hash, hashed, sig, &rsa.PSSOptions{SaltLength: len(pssOpts.Salt), Hash: pssOpts.Hash}); err != nil {
+			return ErrIncompleteSignature
+		}
+		return nil
+	}
+	if err := rsa.VerifyPKCS1v15(pub, opts.HashFunc(), hashed, sig); err != nil {
+		return ErrIncompleteSignature
+	}
+	return nil
+}
+
+// asks shard to sign req, tracing the request as part of ctx
+func partialSign(ctx context.Context, shard RemoteShard, req *SigningRequest) (partialSig *PartialSignature, err error) {
+	ctx, span := StartSpan(ctx, "keysplitting.RemoteShard.PartialSign")
+	defer func() {
+		if partialSig != nil && len(partialSig.Signers) > 0 {
+			span.SetAttributes(TraceAttribute{Key: TraceShardIndex, Value: partialSig.Signers[len(partialSig.Signers)-1]})
+		}
+		span.End(err)
+	}()
+	return shard.PartialSign(ctx, req)
+}
+
+// returns the attributes describing a signature by the given number of remote shards
+func remoteAttributes(pub *rsa.PublicKey, opts crypto.SignerOpts, shards int) []TraceAttribute {
+	var hashFn crypto.Hash
+	if opts != nil {
+		hashFn = opts.HashFunc()
+	}
+	return append(keyAttributes(pub, "", hashFn), TraceAttribute{Key: TraceShardCount, Value: shards})
+}
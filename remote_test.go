@@ -0,0 +1,141 @@
+package keysplitting
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// a RemoteShard that always fails, or waits for its context to be canceled
+type failingRemoteShard struct {
+	block bool
+}
+
+func (s failingRemoteShard) PartialSign(ctx context.Context, req *SigningRequest) (*PartialSignature, error) {
+	if s.block {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return nil, fmt.Errorf("holder is unavailable")
+}
+
+var _ = Describe("Remote shards", func() {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	hashed := sha256.Sum256([]byte("TEST MESSAGE"))
+	ctx := context.Background()
+
+	// a local shard for each of the shards
+	remotes := func(shards []*PrivateKeyShard) []RemoteShard {
+		remotes := make([]RemoteShard, len(shards))
+		for i, shard := range shards {
+			var err error
+			remotes[i], err = NewLocalShard(shard)
+			Expect(err).To(BeNil())
+		}
+		return remotes
+	}
+
+	It("Signs sequentially with local shards", func() {
+		for _, splitBy := range []SplitBy{Addition, Multiplication} {
+			shards, err := SplitD(key, 3, splitBy)
+			Expect(err).To(BeNil())
+
+			sig, err := SignSequential(ctx, &key.PublicKey, crypto.SHA256, hashed[:], remotes(shards)...)
+			Expect(err).To(BeNil())
+			Expect(rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, hashed[:], sig)).To(Succeed())
+		}
+	})
+
+	It("Signs brokered with local shards", func() {
+		shards, err := SplitD(key, 3, Addition)
+		Expect(err).To(BeNil())
+		salt, err := NewPSSSalt(rand.Reader, &key.PublicKey, crypto.SHA256, nil)
+		Expect(err).To(BeNil())
+
+		sig, err := SignBrokered(ctx, &key.PublicKey, &PSSOptions{Hash: crypto.SHA256, Salt: salt}, hashed[:], remotes(shards)...)
+		Expect(err).To(BeNil())
+		Expect(rsa.VerifyPSS(&key.PublicKey, crypto.SHA256, hashed[:], sig, &rsa.PSSOptions{SaltLength: len(salt)})).To(Succeed())
+	})
+
+	It("Refuses requests for other keys", func() {
+		other, _ := rsa.GenerateKey(rand.Reader, 2048)
+		shards, err := SplitD(key, 2, Addition)
+		Expect(err).To(BeNil())
+
+		req, err := NewSigningRequest(&other.PublicKey, crypto.SHA256, hashed[:])
+		Expect(err).To(BeNil())
+		_, err = remotes(shards)[1].PartialSign(ctx, req)
+		Expect(errors.Is(err, ErrKeyMismatch)).To(BeTrue())
+	})
+
+	It("Cancels the other shards when one fails", func() {
+		shards, err := SplitD(key, 2, Addition)
+		Expect(err).To(BeNil())
+
+		_, err = SignBrokered(ctx, &key.PublicKey, crypto.SHA256, hashed[:], failingRemoteShard{block: true}, remotes(shards)[0], failingRemoteShard{})
+		Expect(err).To(MatchError("holder is unavailable"))
+	})
+
+	It("Respects cancellation", func() {
+		shards, err := SplitD(key, 2, Addition)
+		Expect(err).To(BeNil())
+		canceled, cancel := context.WithCancel(ctx)
+		cancel()
+
+		_, err = SignSequential(canceled, &key.PublicKey, crypto.SHA256, hashed[:], remotes(shards)...)
+		Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+	})
+
+	Context("SignAllParallel", func() {
+		signers := func(shards []*PrivateKeyShard) []ShardSigner {
+			signers := make([]ShardSigner, len(shards))
+			for i, shard := range shards {
+				signers[i] = shard
+			}
+			return signers
+		}
+
+		It("Signs with every shard at once", func() {
+			shards, err := SplitD(key, 3, Addition)
+			Expect(err).To(BeNil())
+
+			sig, err := SignAllParallel(ctx, crypto.SHA256, hashed[:], signers(shards)...)
+			Expect(err).To(BeNil())
+			Expect(rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, hashed[:], sig)).To(Succeed())
+
+			salt, err := NewPSSSalt(rand.Reader, &key.PublicKey, crypto.SHA256, nil)
+			Expect(err).To(BeNil())
+			sig, err = SignAllParallel(ctx, &PSSOptions{Hash: crypto.SHA256, Salt: salt}, hashed[:], signers(shards)...)
+			Expect(err).To(BeNil())
+			Expect(rsa.VerifyPSS(&key.PublicKey, crypto.SHA256, hashed[:], sig, &rsa.PSSOptions{SaltLength: len(salt)})).To(Succeed())
+		})
+
+		It("Reports a missing shard", func() {
+			shards, err := SplitD(key, 3, Addition)
+			Expect(err).To(BeNil())
+
+			_, err = SignAllParallel(ctx, crypto.SHA256, hashed[:], signers(shards[:2])...)
+			Expect(errors.Is(err, ErrIncompleteSignature)).To(BeTrue())
+			_, err = SignAllParallel(ctx, crypto.SHA256, hashed[:], shards[0])
+			Expect(errors.Is(err, ErrTooFewShards)).To(BeTrue())
+		})
+
+		It("Refuses shards of different keys", func() {
+			other, _ := rsa.GenerateKey(rand.Reader, 2048)
+			shards, err := SplitD(key, 2, Addition)
+			Expect(err).To(BeNil())
+			otherShards, err := SplitD(other, 2, Addition)
+			Expect(err).To(BeNil())
+
+			_, err = SignAllParallel(ctx, crypto.SHA256, hashed[:], shards[0], otherShards[1])
+			Expect(errors.Is(err, ErrKeyMismatch)).To(BeTrue())
+		})
+	})
+})
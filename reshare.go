@@ -0,0 +1,113 @@
+package keysplitting
+
+import (
+	"crypto/rsa"
+	"math/big"
+)
+
+// A ReshareMessage is a piece of an old additive shard sent to the holder of a new shard while resharing a key.
+// Messages are secret and must be sent over a secure channel
+type ReshareMessage struct {
+	From int      // index of the old shard that produced this piece
+	To   int      // index of the new shard that receives this piece
+	D    *big.Int // the piece itself
+	Mask *big.Int // the piece of the old shard's mask, if it has one (see [SplitOptions].Mask)
+}
+
+// NewReshareMessages is the first step of converting a k-way additive split into a newK-way additive split without
+// reconstructing D or involving the original dealer. Each of the k old holders splits its shard into newK pieces that add
+// up to it, as with [SplitShard], and returns them in order of recipient. Each holder then sends the piece for new shard j,
+// indexed 1..newK, to its new holder, who combines all k of the pieces it receives with [CombineReshareMessages].
+//
+// Every piece of every old shard ends up in exactly one new shard, so the new shards add up to the same value as the old
+// ones. The pieces are random integers 2^128 times larger than N, which hide the old shard statistically. Masked shards'
+// masks are split and recombined in the same way, so the new shards' masks still cancel out. Once resharing is complete, the old shards should be destroyed and shard commitments must be reissued
+func NewReshareMessages(shard *PrivateKeyShard, k, newK int) ([]*ReshareMessage, error) {
+	if shard.SplitBy != Addition {
+		return nil, errorf(ErrUnsupportedSplitBy, "only additive shards can be reshared")
+	}
+	if k < 2 || newK < 2 {
+		return nil, errorf(ErrTooFewShards, "cannot reshare to or from fewer than 2 shards")
+	}
+	if shard.Index < 1 || shard.Index > k {
+		return nil, errorf(ErrInvalidShard, "shard index %d is out of range", shard.Index)
+	}
+
+	pieces, err := SplitShard(shard, newK, k+1)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]*ReshareMessage, newK)
+	for i, piece := range pieces {
+		messages[i] = &ReshareMessage{From: shard.Index, To: i + 1, D: piece.D, Mask: piece.Mask}
+	}
+	return messages, nil
+}
+
+// CombineReshareMessages is the second step of resharing (see [NewReshareMessages]). It adds up the pieces received from
+// each of the k old shards to produce the new shard with the given index. Either all of the messages or none of them must
+// carry a mask
+func CombineReshareMessages(pub *rsa.PublicKey, index, k int, messages []*ReshareMessage) (*PrivateKeyShard, error) {
+	if len(messages) != k {
+		return nil, errorf(ErrTooFewShards, "expected a message from each of the %d old shards", k)
+	}
+
+	d := new(big.Int)
+	var mask *big.Int
+	if messages[0].Mask != nil {
+		mask = new(big.Int)
+	}
+	seen := make(map[int]bool)
+	for _, message := range messages {
+		if message.To != index || message.From < 1 || message.From > k || seen[message.From] {
+			return nil, errorf(ErrInvalidShard, "unexpected or duplicate message from shard %d", message.From)
+		}
+		if (message.Mask == nil) != (mask == nil) {
+			return nil, errorf(ErrInvalidShard, "message from shard %d disagrees with the others about masking", message.From)
+		}
+		seen[message.From] = true
+		d.Add(d, message.D)
+		if mask != nil {
+			mask.Add(mask, message.Mask)
+		}
+	}
+
+	return &PrivateKeyShard{
+		PublicKey: pub,
+		D:         d,
+		SplitBy:   Addition,
+		Index:     index,
+		Mask:      mask,
+	}, nil
+}
+
+// Reshare runs the resharing protocol on a complete set of additive shards held in a single place, returning newK new shards.
+// In a real deployment, each holder runs [NewReshareMessages] and [CombineReshareMessages] on its own machine instead
+func Reshare(shards []*PrivateKeyShard, newK int) ([]*PrivateKeyShard, error) {
+	k := len(shards)
+	if k == 0 {
+		return nil, errorf(ErrTooFewShards, "no shards provided")
+	}
+	incoming := make(map[int][]*ReshareMessage)
+
+	for _, shard := range shards {
+		messages, err := NewReshareMessages(shard, k, newK)
+		if err != nil {
+			return nil, err
+		}
+		for _, message := range messages {
+			incoming[message.To] = append(incoming[message.To], message)
+		}
+	}
+
+	reshared := make([]*PrivateKeyShard, newK)
+	for i := 1; i <= newK; i++ {
+		newShard, err := CombineReshareMessages(shards[0].PublicKey, i, k, incoming[i])
+		if err != nil {
+			return nil, err
+		}
+		reshared[i-1] = newShard
+	}
+	return reshared, nil
+}
@@ -0,0 +1,104 @@
+package keysplitting
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"math/big"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Resharing", func() {
+	hashed := sha512.Sum512([]byte("TEST MESSAGE"))
+	priv, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	for _, sizes := range [][2]int{{2, 5}, {5, 2}, {3, 3}} {
+		k, newK := sizes[0], sizes[1]
+
+		When(fmt.Sprintf("Resharing %d shards into %d", k, newK), func() {
+			It("Produces new shards that sign in place of the old ones", func() {
+				shards, _ := SplitD(priv, k, Addition)
+				reshared, err := Reshare(shards, newK)
+				Expect(err).To(BeNil())
+				Expect(reshared).To(HaveLen(newK))
+				Expect(shardSum(reshared).Cmp(shardSum(shards))).To(Equal(0))
+
+				for i, shard := range reshared {
+					Expect(shard.Index).To(Equal(i + 1))
+				}
+
+				sig := signBrokered(&priv.PublicKey, reshared, hashed[:])
+				Expect(rsa.VerifyPKCS1v15(&priv.PublicKey, crypto.SHA512, hashed[:], sig)).To(Succeed())
+			})
+		})
+	}
+
+	When("Resharing masked shards", func() {
+		It("Carries the masks over to the new shards", func() {
+			shards, _ := SplitDWithOptions(priv, 2, Addition, &SplitOptions{Mask: true})
+			reshared, err := Reshare(shards, 3)
+			Expect(err).To(BeNil())
+
+			masks := new(big.Int)
+			for _, shard := range reshared {
+				Expect(shard.Mask).NotTo(BeNil())
+				masks.Add(masks, shard.Mask)
+			}
+			Expect(new(big.Int).Mod(masks, eulerTotient(priv.Primes)).Sign()).To(Equal(0))
+
+			sig := signBrokered(&priv.PublicKey, reshared, hashed[:])
+			Expect(rsa.VerifyPKCS1v15(&priv.PublicKey, crypto.SHA512, hashed[:], sig)).To(Succeed())
+		})
+	})
+
+	When("Resharing multiplicative shards", func() {
+		It("Fails", func() {
+			shards, _ := SplitD(priv, 2, Multiplication)
+			_, err := Reshare(shards, 3)
+			Expect(err).NotTo(BeNil())
+		})
+	})
+
+	When("Combining messages", func() {
+		var shards []*PrivateKeyShard
+		var fromFirst, fromSecond []*ReshareMessage
+
+		BeforeEach(func() {
+			shards, _ = SplitD(priv, 2, Addition)
+			fromFirst, _ = NewReshareMessages(shards[0], 2, 3)
+			fromSecond, _ = NewReshareMessages(shards[1], 2, 3)
+		})
+
+		It("Fails if a message is missing", func() {
+			_, err := CombineReshareMessages(&priv.PublicKey, 1, 2, []*ReshareMessage{fromFirst[0]})
+			Expect(err).NotTo(BeNil())
+		})
+
+		It("Fails if a message is duplicated", func() {
+			_, err := CombineReshareMessages(&priv.PublicKey, 1, 2, []*ReshareMessage{fromFirst[0], fromFirst[0]})
+			Expect(err).NotTo(BeNil())
+		})
+
+		It("Hides the old shards in pieces far larger than the modulus", func() {
+			for _, message := range fromFirst[:2] {
+				Expect(message.D.BitLen()).To(BeNumerically(">", priv.N.BitLen()))
+			}
+		})
+
+		It("Fails if only some of the messages carry a mask", func() {
+			fromFirst[0].Mask = big.NewInt(1)
+			_, err := CombineReshareMessages(&priv.PublicKey, 1, 2, []*ReshareMessage{fromFirst[0], fromSecond[0]})
+			Expect(errors.Is(err, ErrInvalidShard)).To(BeTrue())
+		})
+
+		It("Fails if a message was meant for another shard", func() {
+			_, err := CombineReshareMessages(&priv.PublicKey, 1, 2, []*ReshareMessage{fromFirst[0], fromSecond[1]})
+			Expect(err).NotTo(BeNil())
+		})
+	})
+})
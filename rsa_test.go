@@ -0,0 +1,74 @@
+package keysplitting
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/asn1"
+	"fmt"
+	"math/big"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// used to check the hard-coded DigestInfo prefixes against the ASN.1 encoder
+type digestInfo struct {
+	Algorithm struct {
+		Algorithm  asn1.ObjectIdentifier
+		Parameters asn1.RawValue
+	}
+	Digest []byte
+}
+
+var _ = Describe("PKCS #1 v1.5 encoding", func() {
+	priv, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	for _, c := range []struct {
+		hashFn crypto.Hash
+		oid    asn1.ObjectIdentifier
+	}{
+		{crypto.SHA3_224, asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 7}},
+		{crypto.SHA3_256, asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 8}},
+		{crypto.SHA3_384, asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 9}},
+		{crypto.SHA3_512, asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 10}},
+		{crypto.BLAKE2b_256, asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 1722, 12, 2, 1, 8}},
+		{crypto.BLAKE2b_384, asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 1722, 12, 2, 1, 12}},
+		{crypto.BLAKE2b_512, asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 1722, 12, 2, 1, 16}},
+	} {
+		hashFn, oid := c.hashFn, c.oid
+
+		When(fmt.Sprintf("Using %v", hashFn), func() {
+			// the hash function itself needn't be linked in, since only the digest length matters here
+			hashed := make([]byte, hashFn.Size())
+			_, _ = rand.Read(hashed)
+
+			It("Encodes a DigestInfo with the standard OID", func() {
+				em, err := emsaPKCS1v15Encode(hashFn, hashed, priv.Size())
+				Expect(err).To(BeNil())
+
+				tLen := len(hashPrefixes[hashFn]) + len(hashed)
+				var info digestInfo
+				rest, err := asn1.Unmarshal(em[len(em)-tLen:], &info)
+				Expect(err).To(BeNil())
+				Expect(rest).To(BeEmpty())
+				Expect(info.Algorithm.Algorithm.Equal(oid)).To(BeTrue())
+				Expect(info.Algorithm.Parameters.Tag).To(Equal(asn1.TagNull))
+				Expect(info.Digest).To(Equal(hashed))
+			})
+
+			It("Produces a split signature that decrypts to the encoded message", func() {
+				shards, _ := SplitD(priv, 2, Addition)
+
+				sig, err := SignFirst(rand.Reader, shards[0], hashFn, hashed)
+				Expect(err).To(BeNil())
+				sig, err = SignNext(rand.Reader, shards[1], hashFn, hashed, sig)
+				Expect(err).To(BeNil())
+
+				em, _ := emsaPKCS1v15Encode(hashFn, hashed, priv.Size())
+				m := new(big.Int).Exp(new(big.Int).SetBytes(sig.Sig), big.NewInt(int64(priv.E)), priv.N)
+				Expect(m.Cmp(new(big.Int).SetBytes(em))).To(Equal(0))
+			})
+		})
+	}
+})
@@ -0,0 +1,140 @@
+package schnorr
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"sync"
+
+	"github.com/bastionzero/keysplitting"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+)
+
+// A Broker collects the partial signatures of the signers of a single message as they arrive, and combines them once every
+// signer whose commitment it was given has signed, as keysplitting.Broker does for additive RSA shards. Since each partial
+// signature is checked against its signer's verification share as it arrives, a bad one is rejected straight away and never
+// spoils the signature. It is safe for concurrent use
+type Broker struct {
+	group   *GroupKey
+	keyID   string
+	message []byte
+	session *signingSession
+
+	mu     sync.Mutex
+	logger keysplitting.Logger
+	zs     []*secp256k1.ModNScalar
+	count  int
+	sig    []byte
+	done   chan struct{}
+}
+
+// NewBroker returns a Broker that expects a partial signature on message from the signer of each of the commitments, which must
+// be the same commitments the signers are given
+func NewBroker(group *GroupKey, message []byte, commitments []*NonceCommitment) (*Broker, error) {
+	session, err := newSigningSession(group, message, commitments)
+	if err != nil {
+		return nil, err
+	}
+	return &Broker{
+		group:   group,
+		keyID:   hex.EncodeToString(group.PublicKey),
+		message: append([]byte{}, message...),
+		session: session,
+		logger:  keysplitting.NopLogger{},
+		zs:      make([]*secp256k1.ModNScalar, len(session.commitments)),
+		done:    make(chan struct{}),
+	}, nil
+}
+
+// SetLogger sets the [keysplitting.Logger] that the broker reports each partial signature it accepts or rejects to. A nil
+// logger, the default, discards them
+func (b *Broker) SetLogger(logger keysplitting.Logger) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if logger == nil {
+		logger = keysplitting.NopLogger{}
+	}
+	b.logger = logger
+}
+
+// AddPartial adds a partial signature. Once the last expected partial signature has been added, AddPartial combines them
+// and returns the complete signature; until then it returns nil. It fails with a *[keysplitting.MisbehavingSignersError] if
+// the partial signature doesn't match its signer's verification share, and [keysplitting.ErrInvalidPartialSignature] if its
+// signer wasn't expected, has already signed, or it arrives after the signature is complete
+func (b *Broker) AddPartial(partial *PartialSignature) (sig []byte, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	defer func() {
+		switch {
+		case err != nil:
+			b.logger.Warn("rejected partial signature", "key_id", b.keyID, "signer", partial.Index, "error", err)
+		case sig != nil:
+			b.logger.Info("combined partial signatures", "key_id", b.keyID, "shares", len(b.zs))
+		default:
+			b.logger.Debug("added partial signature", "key_id", b.keyID, "signer", partial.Index)
+		}
+	}()
+
+	if b.sig != nil {
+		return nil, fmt.Errorf("%w: all %d partial signatures have already been combined", keysplitting.ErrInvalidPartialSignature, len(b.zs))
+	}
+	i := b.session.position(partial.Index)
+	if i < 0 {
+		return nil, fmt.Errorf("%w: share %d didn't commit to this signature", keysplitting.ErrInvalidPartialSignature, partial.Index)
+	}
+	if b.zs[i] != nil {
+		return nil, fmt.Errorf("%w: share %d has already signed", keysplitting.ErrInvalidPartialSignature, partial.Index)
+	}
+	if err := checkPartial(partial); err != nil {
+		return nil, err
+	}
+	if err := b.session.verifyPartial(b.group, i, partial.Z); err != nil {
+		return nil, err
+	}
+
+	b.zs[i] = new(secp256k1.ModNScalar).Set(partial.Z)
+	b.count++
+	if b.count < len(b.zs) {
+		return nil, nil
+	}
+
+	sig = b.session.signature(b.zs)
+	if !Verify(b.group.PublicKey, b.message, sig) {
+		return nil, fmt.Errorf("%w: combined signature does not verify", keysplitting.ErrInvalidPartialSignature)
+	}
+	b.sig = sig
+	close(b.done)
+	return sig, nil
+}
+
+// Signature returns the complete signature, or nil if some partial signatures are still missing
+func (b *Broker) Signature() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.sig
+}
+
+// Signers returns the indices of the shares that have signed so far
+func (b *Broker) Signers() []int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	signers := []int{}
+	for i, z := range b.zs {
+		if z != nil {
+			signers = append(signers, b.session.commitments[i].Index)
+		}
+	}
+	return signers
+}
+
+// Wait blocks until every partial signature has been added and returns the complete signature, or returns ctx.Err() if ctx
+// is done first
+func (b *Broker) Wait(ctx context.Context) ([]byte, error) {
+	select {
+	case <-b.done:
+		return b.Signature(), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
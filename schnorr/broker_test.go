@@ -0,0 +1,80 @@
+package schnorr
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"time"
+
+	"github.com/bastionzero/keysplitting"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Broker", func() {
+	priv := make([]byte, SecretKeySize)
+	_, _ = rand.Read(priv)
+	shares, _ := Split(rand.Reader, priv, 3, 4)
+	message := sha256.Sum256([]byte("TEST MESSAGE"))
+
+	It("Combines partial signatures as they arrive", func() {
+		commitments, partials := signWith(shares[1:], message[:])
+		broker, err := NewBroker(shares[0].Group, message[:], commitments)
+		Expect(err).To(BeNil())
+
+		results := make(chan error, len(partials))
+		for _, partial := range partials {
+			go func(partial *PartialSignature) {
+				_, err := broker.AddPartial(partial)
+				results <- err
+			}(partial)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		sig, err := broker.Wait(ctx)
+		Expect(err).To(BeNil())
+		Expect(Verify(shares[0].Group.PublicKey, message[:], sig)).To(BeTrue())
+		for range partials {
+			Expect(<-results).To(BeNil())
+		}
+		Expect(broker.Signers()).To(ConsistOf(2, 3, 4))
+
+		_, err = broker.AddPartial(partials[0])
+		Expect(errors.Is(err, keysplitting.ErrInvalidPartialSignature)).To(BeTrue())
+	})
+
+	It("Rejects bad partial signatures as they arrive, and still completes", func() {
+		commitments, partials := signWith(shares[:3], message[:])
+		broker, err := NewBroker(shares[0].Group, message[:], commitments)
+		Expect(err).To(BeNil())
+
+		bad := &PartialSignature{Index: partials[0].Index, Z: new(secp256k1.ModNScalar).Add2(partials[0].Z, scalarFromInt(1))}
+		_, err = broker.AddPartial(bad)
+		var misbehaving *keysplitting.MisbehavingSignersError
+		Expect(errors.As(err, &misbehaving)).To(BeTrue())
+		Expect(misbehaving.Signers).To(Equal([]int{1}))
+
+		_, err = broker.AddPartial(&PartialSignature{Index: 4, Z: scalarFromInt(1)})
+		Expect(errors.Is(err, keysplitting.ErrInvalidPartialSignature)).To(BeTrue())
+
+		var sig []byte
+		for _, partial := range partials {
+			sig, err = broker.AddPartial(partial)
+			Expect(err).To(BeNil())
+		}
+		Expect(sig).To(Equal(broker.Signature()))
+		Expect(Verify(shares[0].Group.PublicKey, message[:], sig)).To(BeTrue())
+	})
+
+	It("Stops waiting when the context is done", func() {
+		commitments, _ := signWith(shares[:3], message[:])
+		broker, err := NewBroker(shares[0].Group, message[:], commitments)
+		Expect(err).To(BeNil())
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err = broker.Wait(ctx)
+		Expect(err).To(Equal(context.Canceled))
+	})
+})
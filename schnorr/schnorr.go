@@ -0,0 +1,395 @@
+/*
+Package schnorr splits secp256k1 keys into threshold shares, any t of which produce BIP-340 Schnorr signatures [1] together,
+such as those of Taproot key path spends, without the key ever being rebuilt. It follows the same two-round FROST protocol [2]
+as the frost subpackage, adjusted for BIP-340's x-only public keys and nonces, so the workflow is the one used for RSA keys:
+a dealer splits the key with [Split], each shard holder signs with its share, and a [Broker] or [Combine] turns the partial
+signatures into a signature that [Verify], or any other BIP-340 implementation, accepts.
+
+ 1. Every signer calls [Commit] with its share, keeps the [SigningNonces] secret, and sends the [NonceCommitment] to the others
+ 2. Every signer calls [Sign] with its nonces, the message and the signers' commitments, and sends the [PartialSignature] on
+ 3. The [Broker] for the message and commitments, or anyone calling [Combine], completes the signature
+
+BIP-340 needs the public key and the signature's nonce to have an even y coordinate. The dealer negates the key when it
+doesn't, and the signers negate their nonces when the combined nonce doesn't, so that the parties never need to agree on
+anything beyond the commitments. Nonces must only ever be used once, and [Sign] clears them.
+
+The curve arithmetic is pure Go, so the package doesn't need cgo, but multiplying the generator by a share or a nonce isn't
+constant time.
+
+	[1] https://github.com/bitcoin/bips/blob/master/bip-0340.mediawiki
+	[2] https://www.rfc-editor.org/rfc/rfc9591
+*/
+package schnorr
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/bastionzero/keysplitting"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+)
+
+// the sizes of BIP-340 public keys, secret keys and signatures
+const (
+	PublicKeySize = 32
+	SecretKeySize = 32
+	SignatureSize = 64
+)
+
+// A GroupKey is the public part of a set of shares
+type GroupKey struct {
+	PublicKey          []byte   // the BIP-340 x-only public key that signatures verify against
+	Threshold          int      // t, the number of shares required to sign
+	VerificationShares [][]byte // s_i * G for each share as compressed points, in order of their index
+}
+
+// A KeyShare is one of n shares of a secp256k1 private key, from [Split]
+type KeyShare struct {
+	Group  *GroupKey             // public part
+	Index  int                   // this share's position in 1..n
+	Secret *secp256k1.ModNScalar // share of the private key, negated if need be for the public key to have an even y coordinate
+}
+
+// SigningNonces are a signer's secret nonces for a single signature
+type SigningNonces struct {
+	index           int
+	hiding, binding *secp256k1.ModNScalar
+	commitment      *NonceCommitment
+}
+
+// A NonceCommitment is a signer's public commitment to its [SigningNonces]
+type NonceCommitment struct {
+	Index   int    // index of the committing share
+	Hiding  []byte // d * G, as a compressed point
+	Binding []byte // e * G, as a compressed point
+}
+
+// A PartialSignature is the contribution of a single [KeyShare] to a signature
+type PartialSignature struct {
+	Index int                   // index of the share that produced the partial signature
+	Z     *secp256k1.ModNScalar // ±(d + e * rho) + lambda * s * c mod n
+}
+
+// Split splits the 32-byte secret key priv into n shares such that any t of them can sign with [Sign]
+func Split(random io.Reader, priv []byte, t, n int) ([]*KeyShare, error) {
+	d := new(secp256k1.ModNScalar)
+	if len(priv) != SecretKeySize || d.SetByteSlice(priv) || d.IsZero() {
+		return nil, fmt.Errorf("%w: invalid secp256k1 secret key", keysplitting.ErrInvalidShard)
+	}
+	if t < 2 {
+		return nil, fmt.Errorf("%w: threshold must be at least 2", keysplitting.ErrTooFewShards)
+	}
+	if n < t {
+		return nil, fmt.Errorf("%w: cannot split key into fewer shares than the threshold", keysplitting.ErrTooFewShards)
+	}
+
+	P := baseMul(d)
+	if !hasEvenY(P) {
+		d.Negate()
+	}
+
+	// f(X) = d + a1 * X + ... + a(t-1) * X^(t-1)
+	coefficients := make([]*secp256k1.ModNScalar, t)
+	coefficients[0] = d
+	for i := 1; i < t; i++ {
+		a, err := randomScalar(random)
+		if err != nil {
+			return nil, err
+		}
+		coefficients[i] = a
+	}
+
+	group := &GroupKey{PublicKey: xOnly(P), Threshold: t, VerificationShares: make([][]byte, n)}
+	shares := make([]*KeyShare, n)
+	for i := 1; i <= n; i++ {
+		s := evaluatePolynomial(coefficients, scalarFromInt(i))
+		group.VerificationShares[i-1] = compressed(baseMul(s))
+		shares[i-1] = &KeyShare{Group: group, Index: i, Secret: s}
+	}
+	return shares, nil
+}
+
+// Commit generates fresh nonces for signing with share, and the commitment to send to the other signers
+func Commit(random io.Reader, share *KeyShare) (*SigningNonces, *NonceCommitment, error) {
+	hiding, err := generateNonce(random, share.Secret)
+	if err != nil {
+		return nil, nil, err
+	}
+	binding, err := generateNonce(random, share.Secret)
+	if err != nil {
+		return nil, nil, err
+	}
+	commitment := &NonceCommitment{Index: share.Index, Hiding: compressed(baseMul(hiding)), Binding: compressed(baseMul(binding))}
+	return &SigningNonces{index: share.Index, hiding: hiding, binding: binding, commitment: commitment}, commitment, nil
+}
+
+// Sign uses share and its nonces from [Commit] to produce a partial signature on message. The commitments must be those of
+// every signer, including this one, and every signer must sign the same message with the same commitments
+func Sign(share *KeyShare, nonces *SigningNonces, message []byte, commitments []*NonceCommitment) (*PartialSignature, error) {
+	if nonces == nil || nonces.hiding == nil {
+		return nil, fmt.Errorf("%w: signing nonces have already been used", keysplitting.ErrInvalidPartialSignature)
+	}
+	if nonces.index != share.Index {
+		return nil, fmt.Errorf("%w: signing nonces belong to share %d", keysplitting.ErrInvalidShard, nonces.index)
+	}
+	session, err := newSigningSession(share.Group, message, commitments)
+	if err != nil {
+		return nil, err
+	}
+	i := session.position(share.Index)
+	if i < 0 || string(session.commitments[i].Hiding) != string(nonces.commitment.Hiding) ||
+		string(session.commitments[i].Binding) != string(nonces.commitment.Binding) {
+		return nil, fmt.Errorf("%w: commitments don't include this signer's nonces", keysplitting.ErrInvalidPartialSignature)
+	}
+
+	// z_i = ±(d_i + e_i * rho_i) + lambda_i * s_i * c
+	z := new(secp256k1.ModNScalar).Mul2(nonces.binding, session.bindingFactors[i]).Add(nonces.hiding)
+	if session.negateNonces {
+		z.Negate()
+	}
+	z.Add(new(secp256k1.ModNScalar).Mul2(session.lambdas[i], share.Secret).Mul(session.challenge))
+
+	// never reuse the nonces, since two signatures with the same nonces reveal the share
+	nonces.hiding, nonces.binding = nil, nil
+	return &PartialSignature{Index: share.Index, Z: z}, nil
+}
+
+// Combine combines the partial signatures of every signer whose commitment is given into a BIP-340 signature on message,
+// and checks it against the group's public key. If it doesn't verify, the error is a *[keysplitting.MisbehavingSignersError]
+// naming the shares whose partial signatures are bad
+func Combine(group *GroupKey, message []byte, commitments []*NonceCommitment, partials ...*PartialSignature) ([]byte, error) {
+	session, err := newSigningSession(group, message, commitments)
+	if err != nil {
+		return nil, err
+	}
+	if len(partials) != len(session.commitments) {
+		return nil, fmt.Errorf("%w: expected %d partial signatures, got %d", keysplitting.ErrTooFewShards, len(session.commitments), len(partials))
+	}
+
+	zs := make([]*secp256k1.ModNScalar, len(partials))
+	for _, partial := range partials {
+		i := session.position(partial.Index)
+		if i < 0 || zs[i] != nil {
+			return nil, fmt.Errorf("%w: unexpected partial signature from share %d", keysplitting.ErrInvalidPartialSignature, partial.Index)
+		}
+		if err := checkPartial(partial); err != nil {
+			return nil, err
+		}
+		zs[i] = partial.Z
+	}
+	sig := session.signature(zs)
+	if Verify(group.PublicKey, message, sig) {
+		return sig, nil
+	}
+
+	var bad []int
+	for i, commitment := range session.commitments {
+		if session.verifyPartial(group, i, zs[i]) != nil {
+			bad = append(bad, commitment.Index)
+		}
+	}
+	if len(bad) > 0 {
+		return nil, &keysplitting.MisbehavingSignersError{Signers: bad}
+	}
+	return nil, fmt.Errorf("%w: combined signature does not verify", keysplitting.ErrInvalidPartialSignature)
+}
+
+// Verify reports whether sig is a valid BIP-340 signature on message by the x-only public key pub
+func Verify(pub, message, sig []byte) bool {
+	if len(pub) != PublicKeySize || len(sig) != SignatureSize {
+		return false
+	}
+	P, err := liftX(pub)
+	if err != nil {
+		return false
+	}
+	var r secp256k1.FieldVal
+	var s secp256k1.ModNScalar
+	if r.SetByteSlice(sig[:32]) || s.SetByteSlice(sig[32:]) {
+		return false
+	}
+
+	// R = s * G - e * P must have an even y coordinate and x coordinate r
+	e := challenge(sig[:32], pub, message)
+	R := add(baseMul(&s), neg(mul(e, P)))
+	return !isInfinity(R) && hasEvenY(R) && R.X.Equals(&r)
+}
+
+// the values every signer derives from the message and the commitments
+type signingSession struct {
+	commitments      []*NonceCommitment // in order of their index
+	bindingFactors   []*secp256k1.ModNScalar
+	lambdas          []*secp256k1.ModNScalar
+	commitmentShares []*secp256k1.JacobianPoint // D_i + rho_i * E_i
+	negateNonces     bool                       // whether R, the sum of the commitment shares, has an odd y coordinate
+	nonce            []byte                     // the x coordinate of R
+	challenge        *secp256k1.ModNScalar      // the BIP-340 challenge
+}
+
+func newSigningSession(group *GroupKey, message []byte, commitments []*NonceCommitment) (*signingSession, error) {
+	if len(commitments) < group.Threshold {
+		return nil, fmt.Errorf("%w: cannot sign with fewer than %d shares", keysplitting.ErrTooFewShards, group.Threshold)
+	}
+	sorted := append([]*NonceCommitment{}, commitments...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Index < sorted[j].Index })
+
+	// rho_i = H(P || H(message) || H(encoded commitments) || i)
+	msgHash := sha256.Sum256(message)
+	var encoded []byte
+	for i, commitment := range sorted {
+		if commitment.Index < 1 || commitment.Index > len(group.VerificationShares) {
+			return nil, fmt.Errorf("%w: commitment index %d is out of range", keysplitting.ErrInvalidPartialSignature, commitment.Index)
+		}
+		if i > 0 && sorted[i-1].Index == commitment.Index {
+			return nil, fmt.Errorf("%w: duplicate commitment from share %d", keysplitting.ErrInvalidPartialSignature, commitment.Index)
+		}
+		encoded = append(encoded, scalarBytes(scalarFromInt(commitment.Index))...)
+		encoded = append(encoded, commitment.Hiding...)
+		encoded = append(encoded, commitment.Binding...)
+	}
+	commitmentsHash := sha256.Sum256(encoded)
+
+	session := &signingSession{commitments: sorted}
+	indices := make([]int, len(sorted))
+	for i, commitment := range sorted {
+		indices[i] = commitment.Index
+	}
+	R := new(secp256k1.JacobianPoint)
+	for i, commitment := range sorted {
+		D, err := decompress(commitment.Hiding)
+		if err != nil {
+			return nil, err
+		}
+		E, err := decompress(commitment.Binding)
+		if err != nil {
+			return nil, err
+		}
+		rho := hashToScalar(rhoTag, group.PublicKey, msgHash[:], commitmentsHash[:], scalarBytes(scalarFromInt(commitment.Index)))
+		share := add(D, mul(rho, E))
+
+		session.bindingFactors = append(session.bindingFactors, rho)
+		session.lambdas = append(session.lambdas, lagrangeCoefficient(indices, i))
+		session.commitmentShares = append(session.commitmentShares, share)
+		R = add(R, share)
+	}
+	if isInfinity(R) {
+		return nil, fmt.Errorf("%w: commitments sum to the point at infinity", keysplitting.ErrInvalidPartialSignature)
+	}
+
+	session.negateNonces = !hasEvenY(R)
+	session.nonce = xOnly(R)
+	session.challenge = challenge(session.nonce, group.PublicKey, message)
+	return session, nil
+}
+
+// returns the position of the share with the given index among the signers, or -1
+func (session *signingSession) position(index int) int {
+	for i, commitment := range session.commitments {
+		if commitment.Index == index {
+			return i
+		}
+	}
+	return -1
+}
+
+// returns the signature made of the given partial signatures, in the order of the commitments
+func (session *signingSession) signature(zs []*secp256k1.ModNScalar) []byte {
+	z := new(secp256k1.ModNScalar)
+	for _, zi := range zs {
+		z.Add(zi)
+	}
+	return append(append([]byte{}, session.nonce...), scalarBytes(z)...)
+}
+
+// checks the i'th signer's partial signature against its verification share:
+// z_i * G == ±(D_i + rho_i * E_i) + lambda_i * c * Y_i
+func (session *signingSession) verifyPartial(group *GroupKey, i int, z *secp256k1.ModNScalar) error {
+	Y, err := decompress(group.VerificationShares[session.commitments[i].Index-1])
+	if err != nil {
+		return err
+	}
+	expected := session.commitmentShares[i]
+	if session.negateNonces {
+		expected = neg(expected)
+	}
+	expected = add(expected, mul(new(secp256k1.ModNScalar).Mul2(session.lambdas[i], session.challenge), Y))
+	if !equal(baseMul(z), expected) {
+		return &keysplitting.MisbehavingSignersError{Signers: []int{session.commitments[i].Index}}
+	}
+	return nil
+}
+
+func checkPartial(partial *PartialSignature) error {
+	if partial.Z == nil {
+		return fmt.Errorf("%w: partial signature from share %d is missing", keysplitting.ErrInvalidPartialSignature, partial.Index)
+	}
+	return nil
+}
+
+// the tags of the BIP-340 tagged hashes used by the protocol, the first of which is BIP-340's own
+const (
+	challengeTag = "BIP0340/challenge"
+	nonceTag     = "keysplitting/schnorr/nonce"
+	rhoTag       = "keysplitting/schnorr/rho"
+)
+
+// returns the BIP-340 challenge for the nonce's and the public key's x coordinates
+func challenge(nonce, pub, message []byte) *secp256k1.ModNScalar {
+	return hashToScalar(challengeTag, nonce, pub, message)
+}
+
+// returns H(random || secret), so that a weak source of randomness doesn't on its own expose the share (RFC 9591, section 4.1)
+func generateNonce(random io.Reader, secret *secp256k1.ModNScalar) (*secp256k1.ModNScalar, error) {
+	buf := make([]byte, 32)
+	if _, err := io.ReadFull(random, buf); err != nil {
+		return nil, &keysplitting.RandomnessError{Err: err}
+	}
+	return hashToScalar(nonceTag, buf, scalarBytes(secret)), nil
+}
+
+// returns the tagged hash SHA-256(SHA-256(tag) || SHA-256(tag) || data...) mod n
+func hashToScalar(tag string, data ...[]byte) *secp256k1.ModNScalar {
+	tagHash := sha256.Sum256([]byte(tag))
+	h := sha256.New()
+	h.Write(tagHash[:])
+	h.Write(tagHash[:])
+	for _, d := range data {
+		h.Write(d)
+	}
+	x := new(secp256k1.ModNScalar)
+	x.SetByteSlice(h.Sum(nil))
+	return x
+}
+
+// returns the 32-byte big-endian encoding of a scalar
+func scalarBytes(x *secp256k1.ModNScalar) []byte {
+	b := x.Bytes()
+	return b[:]
+}
+
+// returns the Lagrange coefficient at 0 for the i'th of the given indices, mod n
+func lagrangeCoefficient(indices []int, i int) *secp256k1.ModNScalar {
+	num, den := scalarFromInt(1), scalarFromInt(1)
+	minusXi := scalarFromInt(indices[i]).Negate()
+	for j, index := range indices {
+		if j == i {
+			continue
+		}
+		xj := scalarFromInt(index)
+		num.Mul(xj)
+		den.Mul(xj.Add(minusXi))
+	}
+	// the indices are public, so the inverse needn't be constant time
+	return num.Mul(den.InverseNonConst())
+}
+
+// evaluates the polynomial with the given coefficients at x, mod n
+func evaluatePolynomial(coefficients []*secp256k1.ModNScalar, x *secp256k1.ModNScalar) *secp256k1.ModNScalar {
+	y := new(secp256k1.ModNScalar)
+	for i := len(coefficients) - 1; i >= 0; i-- {
+		y.Mul(x).Add(coefficients[i])
+	}
+	return y
+}
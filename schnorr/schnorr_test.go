@@ -0,0 +1,181 @@
+package schnorr
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/bastionzero/keysplitting"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestSchnorr(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Schnorr Suite")
+}
+
+func mustDecodeHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	Expect(err).To(BeNil())
+	return b
+}
+
+// signs as BIP-340's reference implementation does, with a whole key
+func referenceSign(priv, message, aux []byte) []byte {
+	tagged := func(tag string, data ...[]byte) []byte {
+		tagHash := sha256.Sum256([]byte(tag))
+		h := sha256.New()
+		h.Write(tagHash[:])
+		h.Write(tagHash[:])
+		for _, d := range data {
+			h.Write(d)
+		}
+		return h.Sum(nil)
+	}
+
+	d := new(secp256k1.ModNScalar)
+	d.SetByteSlice(priv)
+	P := baseMul(d)
+	if !hasEvenY(P) {
+		d.Negate()
+	}
+	t := tagged("BIP0340/aux", aux)
+	for i, b := range scalarBytes(d) {
+		t[i] ^= b
+	}
+	k := new(secp256k1.ModNScalar)
+	k.SetByteSlice(tagged("BIP0340/nonce", t, xOnly(P), message))
+	R := baseMul(k)
+	if !hasEvenY(R) {
+		k.Negate()
+	}
+	s := challenge(xOnly(R), xOnly(P), message).Mul(d).Add(k)
+	return append(xOnly(R), scalarBytes(s)...)
+}
+
+// runs both signing rounds with the given shares, returning the commitments and partial signatures
+func signWith(shares []*KeyShare, message []byte) ([]*NonceCommitment, []*PartialSignature) {
+	nonces := make([]*SigningNonces, len(shares))
+	commitments := make([]*NonceCommitment, len(shares))
+	for i, share := range shares {
+		var err error
+		nonces[i], commitments[i], err = Commit(rand.Reader, share)
+		Expect(err).To(BeNil())
+	}
+	partials := make([]*PartialSignature, len(shares))
+	for i, share := range shares {
+		var err error
+		partials[i], err = Sign(share, nonces[i], message, commitments)
+		Expect(err).To(BeNil())
+	}
+	return commitments, partials
+}
+
+var _ = Describe("Threshold Schnorr", func() {
+	priv := make([]byte, SecretKeySize)
+	_, _ = rand.Read(priv)
+	shares, splitErr := Split(rand.Reader, priv, 2, 3)
+	message := sha256.Sum256([]byte("TEST MESSAGE"))
+
+	It("Verifies BIP-340 test vectors", func() {
+		vectors := []struct{ priv, pub, aux, message, sig string }{
+			{
+				"0000000000000000000000000000000000000000000000000000000000000003",
+				"f9308a019258c31049344f85f89d5229b531c845836f99b08601f113bce036f9",
+				"0000000000000000000000000000000000000000000000000000000000000000",
+				"0000000000000000000000000000000000000000000000000000000000000000",
+				"e907831f80848d1069a5371b402410364bdf1c5f8307b0084c55f1ce2dca821525f66a4a85ea8b71e482a74f382d2ce5ebeee8fdb2172f477df4900d310536c0",
+			},
+			{
+				"b7e151628aed2a6abf7158809cf4f3c762e7160f38b4da56a784d9045190cfef",
+				"dff1d77f2a671c5f36183726db2341be58feae1da2deced843240f7b502ba659",
+				"0000000000000000000000000000000000000000000000000000000000000001",
+				"243f6a8885a308d313198a2e03707344a4093822299f31d0082efa98ec4e6c89",
+				"6896bd60eeae296db48a229ff71dfe071bde413e6d43f917dc8dcf8c78de33418906d11ac976abccb20b091292bff4ea897efcb639ea871cfa95f6de339e4b0a",
+			},
+		}
+		for _, v := range vectors {
+			pub, msg, sig := mustDecodeHex(v.pub), mustDecodeHex(v.message), mustDecodeHex(v.sig)
+			Expect(referenceSign(mustDecodeHex(v.priv), msg, mustDecodeHex(v.aux))).To(Equal(sig))
+			Expect(Verify(pub, msg, sig)).To(BeTrue())
+
+			sig[63] ^= 1
+			Expect(Verify(pub, msg, sig)).To(BeFalse())
+		}
+	})
+
+	It("Signs with any t shares", func() {
+		Expect(splitErr).To(BeNil())
+		d := new(secp256k1.ModNScalar)
+		d.SetByteSlice(priv)
+		Expect(shares[0].Group.PublicKey).To(Equal(xOnly(baseMul(d))))
+
+		// enough signatures that both parities of the combined nonce come up
+		for i := 0; i < 8; i++ {
+			signers := []*KeyShare{shares[i%3], shares[(i+1)%3]}
+			commitments, partials := signWith(signers, message[:])
+			sig, err := Combine(shares[0].Group, message[:], commitments, partials...)
+			Expect(err).To(BeNil())
+			Expect(Verify(shares[0].Group.PublicKey, message[:], sig)).To(BeTrue())
+		}
+	})
+
+	It("Signs with keys whose public key has an odd y coordinate", func() {
+		for {
+			priv := make([]byte, SecretKeySize)
+			_, _ = rand.Read(priv)
+			d := new(secp256k1.ModNScalar)
+			if d.SetByteSlice(priv) || hasEvenY(baseMul(d)) {
+				continue
+			}
+			shares, err := Split(rand.Reader, priv, 2, 2)
+			Expect(err).To(BeNil())
+			commitments, partials := signWith(shares, message[:])
+			sig, err := Combine(shares[0].Group, message[:], commitments, partials...)
+			Expect(err).To(BeNil())
+			Expect(Verify(shares[0].Group.PublicKey, message[:], sig)).To(BeTrue())
+			return
+		}
+	})
+
+	It("Names the signers of bad partial signatures", func() {
+		commitments, partials := signWith(shares, message[:])
+		partials[2].Z = new(secp256k1.ModNScalar).Add2(partials[2].Z, scalarFromInt(1))
+		_, err := Combine(shares[0].Group, message[:], commitments, partials...)
+		var misbehaving *keysplitting.MisbehavingSignersError
+		Expect(errors.As(err, &misbehaving)).To(BeTrue())
+		Expect(misbehaving.Signers).To(Equal([]int{3}))
+	})
+
+	It("Never reuses nonces", func() {
+		nonces, commitment, err := Commit(rand.Reader, shares[0])
+		Expect(err).To(BeNil())
+		_, other, err := Commit(rand.Reader, shares[1])
+		Expect(err).To(BeNil())
+		commitments := []*NonceCommitment{commitment, other}
+		_, err = Sign(shares[0], nonces, message[:], commitments)
+		Expect(err).To(BeNil())
+		_, err = Sign(shares[0], nonces, message[:], commitments)
+		Expect(errors.Is(err, keysplitting.ErrInvalidPartialSignature)).To(BeTrue())
+	})
+
+	It("Refuses to sign with fewer than t shares", func() {
+		nonces, commitment, err := Commit(rand.Reader, shares[0])
+		Expect(err).To(BeNil())
+		_, err = Sign(shares[0], nonces, message[:], []*NonceCommitment{commitment})
+		Expect(errors.Is(err, keysplitting.ErrTooFewShards)).To(BeTrue())
+	})
+
+	It("Rejects invalid keys and thresholds", func() {
+		_, err := Split(rand.Reader, make([]byte, SecretKeySize), 2, 3)
+		Expect(errors.Is(err, keysplitting.ErrInvalidShard)).To(BeTrue())
+		_, err = Split(rand.Reader, priv, 1, 3)
+		Expect(errors.Is(err, keysplitting.ErrTooFewShards)).To(BeTrue())
+		_, err = Split(rand.Reader, priv, 4, 3)
+		Expect(errors.Is(err, keysplitting.ErrTooFewShards)).To(BeTrue())
+	})
+})
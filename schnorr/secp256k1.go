@@ -0,0 +1,115 @@
+package schnorr
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/bastionzero/keysplitting"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+)
+
+// Points and scalars are those of github.com/decred/dcrd/dcrec/secp256k1/v4, which is pure Go. Its scalar arithmetic is
+// constant time, but its point multiplication isn't, so the time taken to multiply the generator by a share or a nonce may
+// leak some of it to an observer who can measure it closely. Points are always kept in affine coordinates, with the all-zero
+// point standing for the point at infinity
+
+// returns k * G
+func baseMul(k *secp256k1.ModNScalar) *secp256k1.JacobianPoint {
+	var p secp256k1.JacobianPoint
+	secp256k1.ScalarBaseMultNonConst(k, &p)
+	p.ToAffine()
+	return &p
+}
+
+// returns k * p for a public k
+func mul(k *secp256k1.ModNScalar, p *secp256k1.JacobianPoint) *secp256k1.JacobianPoint {
+	var r secp256k1.JacobianPoint
+	secp256k1.ScalarMultNonConst(k, p, &r)
+	r.ToAffine()
+	return &r
+}
+
+// returns p + q
+func add(p, q *secp256k1.JacobianPoint) *secp256k1.JacobianPoint {
+	var r secp256k1.JacobianPoint
+	secp256k1.AddNonConst(p, q, &r)
+	r.ToAffine()
+	return &r
+}
+
+// returns -p
+func neg(p *secp256k1.JacobianPoint) *secp256k1.JacobianPoint {
+	r := *p
+	r.Y.Negate(1).Normalize()
+	return &r
+}
+
+func isInfinity(p *secp256k1.JacobianPoint) bool {
+	return p.X.IsZero() && p.Y.IsZero()
+}
+
+func equal(p, q *secp256k1.JacobianPoint) bool {
+	return p.X.Equals(&q.X) && p.Y.Equals(&q.Y)
+}
+
+func hasEvenY(p *secp256k1.JacobianPoint) bool {
+	return !p.Y.IsOdd()
+}
+
+// returns the 32-byte x coordinate, as used by BIP-340 for public keys and nonces
+func xOnly(p *secp256k1.JacobianPoint) []byte {
+	return p.X.Bytes()[:]
+}
+
+// returns the 33-byte compressed encoding of the point (SEC 1, section 2.3.3)
+func compressed(p *secp256k1.JacobianPoint) []byte {
+	return secp256k1.NewPublicKey(&p.X, &p.Y).SerializeCompressed()
+}
+
+// returns the point with the given x coordinate and an even y coordinate (BIP-340's lift_x)
+func liftX(x []byte) (*secp256k1.JacobianPoint, error) {
+	if len(x) != 32 {
+		return nil, fmt.Errorf("%w: x coordinate is out of range", keysplitting.ErrInvalidPartialSignature)
+	}
+	return decompress(append([]byte{secp256k1.PubKeyFormatCompressedEven}, x...))
+}
+
+// decodes a compressed point
+func decompress(b []byte) (*secp256k1.JacobianPoint, error) {
+	if len(b) != secp256k1.PubKeyBytesLenCompressed {
+		return nil, fmt.Errorf("%w: invalid compressed secp256k1 point", keysplitting.ErrInvalidPartialSignature)
+	}
+	pub, err := secp256k1.ParsePubKey(b)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid compressed secp256k1 point: %s", keysplitting.ErrInvalidPartialSignature, err)
+	}
+	var p secp256k1.JacobianPoint
+	pub.AsJacobian(&p)
+	return &p, nil
+}
+
+// returns a uniformly random nonzero scalar
+func randomScalar(random io.Reader) (*secp256k1.ModNScalar, error) {
+	var buf [32]byte
+	defer zeroize(buf[:])
+	for {
+		if _, err := io.ReadFull(random, buf[:]); err != nil {
+			return nil, &keysplitting.RandomnessError{Err: err}
+		}
+		var s secp256k1.ModNScalar
+		if overflow := s.SetBytes(&buf); overflow == 0 && !s.IsZero() {
+			return &s, nil
+		}
+	}
+}
+
+// returns the scalar x, for a share's index
+func scalarFromInt(x int) *secp256k1.ModNScalar {
+	return new(secp256k1.ModNScalar).SetInt(uint32(x))
+}
+
+func zeroize(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
@@ -0,0 +1,47 @@
+package schnorr
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Secp256k1", func() {
+	It("Multiplies the generator", func() {
+		// 3 * G, the public key of BIP-340's first test vector
+		Expect(hex.EncodeToString(xOnly(baseMul(scalarFromInt(3))))).To(Equal("f9308a019258c31049344f85f89d5229b531c845836f99b08601f113bce036f9"))
+		G := baseMul(scalarFromInt(1))
+		Expect(equal(baseMul(scalarFromInt(2)), add(G, G))).To(BeTrue())
+		Expect(equal(baseMul(scalarFromInt(1).Negate()), neg(G))).To(BeTrue())
+		Expect(isInfinity(add(G, neg(G)))).To(BeTrue())
+		Expect(isInfinity(baseMul(new(secp256k1.ModNScalar)))).To(BeTrue())
+	})
+
+	It("Decompresses what it compresses", func() {
+		for i := 0; i < 8; i++ {
+			k, err := randomScalar(rand.Reader)
+			Expect(err).To(BeNil())
+			p := baseMul(k)
+			decoded, err := decompress(compressed(p))
+			Expect(err).To(BeNil())
+			Expect(equal(decoded, p)).To(BeTrue())
+		}
+	})
+
+	It("Rejects invalid encodings", func() {
+		_, err := decompress(make([]byte, 33))
+		Expect(err).ToNot(BeNil())
+		// p itself is out of range
+		p, _ := hex.DecodeString("fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f")
+		_, err = liftX(p)
+		Expect(err).ToNot(BeNil())
+		// x = 5 isn't on the curve, since 5^3 + 7 = 132 is not a square mod p
+		five := make([]byte, 32)
+		five[31] = 5
+		_, err = liftX(five)
+		Expect(err).ToNot(BeNil())
+	})
+})
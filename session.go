@@ -0,0 +1,333 @@
+package keysplitting
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"io"
+	"math/big"
+	"sync"
+)
+
+// A Session tracks one multi-party signature from start to finish, and refuses any step that doesn't fit: a partial signature
+// from a shard split by the other algorithm, over a different hash function, from a shard that has already signed, or that
+// doesn't build on what the session has so far. Without one, mixing up partial signatures usually produces an invalid signature
+// with no indication of what went wrong. Every partial signature must also be bound to the session's nonce (see [NonceOptions]),
+// so that one captured from another session for the same digest can't be replayed into it. A Session is safe for concurrent use
+type Session struct {
+	pub     *rsa.PublicKey
+	splitBy SplitBy
+	k       int
+	opts    crypto.SignerOpts
+	hashed  []byte
+	nonce   []byte
+
+	mu          sync.Mutex
+	policy      *QuorumPolicy
+	commitments *ShardCommitments
+	current     *PartialSignature   // the partial signature so far, or nil if no one has signed
+	partials    []*PartialSignature // every partial signature added, for diagnosing a failed signature
+	sig         []byte
+	failed      bool
+	err         error // why the signature failed, if it is known
+}
+
+// NewSession starts a session in which k shards of pub, split by splitBy, sign hashed, which must be the result of hashing
+// the message with opts.HashFunc(). opts selects the signature scheme as for [SignFirst]
+func NewSession(pub *rsa.PublicKey, splitBy SplitBy, k int, opts crypto.SignerOpts, hashed []byte) (*Session, error) {
+	nonce, err := NewNonce(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return newSession(pub, splitBy, k, opts, hashed, nonce)
+}
+
+// starts a session as NewSession does, but with the given nonce
+func newSession(pub *rsa.PublicKey, splitBy SplitBy, k int, opts crypto.SignerOpts, hashed, nonce []byte) (*Session, error) {
+	if err := checkSplitBy(splitBy); err != nil {
+		return nil, err
+	}
+	if k < 2 {
+		return nil, errorf(ErrTooFewShards, "cannot sign with fewer than 2 shards")
+	}
+	if err := checkSessionOpts(opts, hashed); err != nil {
+		return nil, err
+	}
+	return &Session{pub: pub, splitBy: splitBy, k: k, opts: opts, hashed: append([]byte{}, hashed...), nonce: nonce}, nil
+}
+
+// checks that a session can sign hashed with opts, which must not already be bound to a nonce
+func checkSessionOpts(opts crypto.SignerOpts, hashed []byte) error {
+	if opts == nil {
+		return errorf(ErrUnsupportedHash, "no signer options provided")
+	}
+	if _, ok := opts.(*rsa.PSSOptions); ok {
+		return errorf(ErrUnsupportedHash, "split PSS signatures require a shared salt, which *rsa.PSSOptions cannot carry; use *keysplitting.PSSOptions instead")
+	}
+	if hashFn := opts.HashFunc(); hashFn != 0 && len(hashed) != hashFn.Size() {
+		return errorf(ErrUnsupportedHash, "digest is %d bytes long, but %v digests are %d bytes long", len(hashed), hashFn, hashFn.Size())
+	}
+	if _, ok := opts.(*NonceOptions); ok {
+		return errorf(ErrUnsupportedHash, "a session generates its own nonce")
+	}
+	return nil
+}
+
+// Nonce returns the session nonce, which is sent to each party along with the digest to sign
+func (s *Session) Nonce() []byte {
+	return append([]byte{}, s.nonce...)
+}
+
+// SignerOpts returns the options that each party must sign with, i.e. the session's options bound to its nonce
+func (s *Session) SignerOpts() *NonceOptions {
+	return &NonceOptions{Opts: s.opts, Nonce: s.Nonce()}
+}
+
+// SetPolicy makes the session refuse to complete the signature unless the shards that signed satisfy policy. The partial
+// signature that would have completed it is rejected with [ErrPolicyNotSatisfied]
+func (s *Session) SetPolicy(policy *QuorumPolicy) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.policy = policy
+}
+
+// Sign adds the signature of a local shard to the session, as with [SignFirst] for the first shard and [SignNext] after that
+func (s *Session) Sign(random io.Reader, signer ShardSigner) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkOpen(); err != nil {
+		return err
+	}
+	if pub, ok := signer.Public().(*rsa.PublicKey); !ok || !pub.Equal(s.pub) {
+		return errorf(ErrKeyMismatch, "shard belongs to a different key than the session")
+	}
+
+	var partialSig *PartialSignature
+	var err error
+	if s.current == nil {
+		partialSig, err = signer.SignFirst(random, s.SignerOpts(), s.hashed)
+	} else {
+		partialSig, err = signer.SignNext(random, s.SignerOpts(), s.hashed, s.current)
+	}
+	if err != nil {
+		return err
+	}
+	return s.add(partialSig)
+}
+
+// AddPartial adds a partial signature produced elsewhere with [Session.SignerOpts]. It must either extend the session's current partial signature
+// (see [Session.Current]), as [SignNext] does, or, for additive shards only, be an independent partial signature from shards
+// that haven't signed yet, as in the brokered flow
+func (s *Session) AddPartial(partialSig *PartialSignature) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkOpen(); err != nil {
+		return err
+	}
+	return s.add(partialSig)
+}
+
+// Current returns the partial signature so far, which is what the next party should pass to [SignNext], or nil if no one has
+// signed yet
+func (s *Session) Current() *PartialSignature {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.current == nil {
+		return nil
+	}
+	return s.current.copy()
+}
+
+// Signers returns the indices of the shards that have signed so far, in order
+func (s *Session) Signers() []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.current == nil {
+		return []int{}
+	}
+	return append([]int{}, s.current.Signers...)
+}
+
+// Done reports whether every shard has signed
+func (s *Session) Done() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sig != nil || s.failed
+}
+
+// Signature returns the complete signature once every shard has signed and it has been verified. It fails with
+// [ErrIncompleteSignature] if some shards haven't signed, or if the complete signature doesn't verify, unless the session
+// can tell which shards are to blame (see [Session.SetCommitments])
+func (s *Session) Signature() ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.sig == nil {
+		if s.err != nil {
+			return nil, s.err
+		}
+		if s.failed {
+			return nil, errorf(ErrIncompleteSignature, "every shard has signed, but the signature does not verify")
+		}
+		return nil, errorf(ErrIncompleteSignature, "%d of %d shards have signed", s.signerCount(), s.k)
+	}
+	return append([]byte{}, s.sig...), nil
+}
+
+// refuses any further steps once every shard has signed
+func (s *Session) checkOpen() error {
+	if s.sig != nil || s.failed {
+		return errorf(ErrInvalidPartialSignature, "every shard has already signed")
+	}
+	return nil
+}
+
+func (s *Session) signerCount() int {
+	if s.current == nil {
+		return 0
+	}
+	return len(s.current.Signers)
+}
+
+// SetCommitments gives the session the public commitments to the shards. If the complete signature of an additive session
+// doesn't verify, the session then checks the proof attached to each partial signature with [DiagnosePartialSignatures], and
+// [Session.Signature] returns a *[MisbehavingSignersError] naming the shards whose partial signatures aren't proven. The
+// holders must therefore attach proofs with [ShardCommitments.Prove] before their partial signatures are added
+func (s *Session) SetCommitments(commitments *ShardCommitments) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.commitments = commitments
+}
+
+// adds partialSig to the session, and verifies the complete signature once every shard has signed
+func (s *Session) add(partialSig *PartialSignature) error {
+	if partialSig.SplitBy != s.splitBy {
+		return errorf(ErrInvalidPartialSignature, "cannot add a partial signature split by %v to a session split by %v", partialSig.SplitBy, s.splitBy)
+	}
+	if partialSig.Hash != s.opts.HashFunc() {
+		return errorf(ErrInvalidPartialSignature, "partial signature was produced with a different hash function than the session")
+	}
+	if err := partialSig.checkNonce(s.nonce); err != nil {
+		return err
+	}
+	partialInt := new(big.Int).SetBytes(partialSig.Sig)
+	if partialInt.Sign() == 0 || partialInt.Cmp(s.pub.N) >= 0 {
+		return errorf(ErrInvalidPartialSignature, "partial signature is out of range for the session's public key")
+	}
+
+	next, err := s.merge(partialSig, partialInt)
+	if err != nil {
+		return err
+	}
+	if len(next.Signers) > s.k {
+		return errorf(ErrInvalidPartialSignature, "partial signature has %d signers, but the session only has %d shards", len(next.Signers), s.k)
+	}
+	if len(next.Signers) == s.k && s.policy != nil {
+		if err := s.policy.Check(next); err != nil {
+			return err
+		}
+	}
+
+	s.current = next
+	s.partials = append(s.partials, partialSig.copy())
+	if len(next.Signers) == s.k {
+		sig, err := unbindNonce(s.pub, s.nonce, next.Sig)
+		if err == nil && s.verify(sig) == nil {
+			s.sig = sig
+		} else {
+			s.failed = true
+			s.diagnose()
+		}
+	}
+	return nil
+}
+
+// records which shards are to blame for a failed signature, if the session can tell
+func (s *Session) diagnose() {
+	if s.commitments == nil || s.splitBy != Addition {
+		return
+	}
+	var misbehaving *MisbehavingSignersError
+	if err := DiagnosePartialSignatures(s.commitments, s.opts, s.hashed, s.partials...); errors.As(err, &misbehaving) {
+		s.err = misbehaving
+	}
+}
+
+// returns the session's partial signature with partialSig added to it
+func (s *Session) merge(partialSig *PartialSignature, partialInt *big.Int) (*PartialSignature, error) {
+	signers := partialSig.Signers
+	if s.current == nil {
+		if hasDuplicateSigner(signers) {
+			return nil, errorf(ErrInvalidPartialSignature, "partial signature has the same signer twice")
+		}
+		return partialSig.copy(), nil
+	}
+
+	// a partial signature that extends the current one replaces it
+	if len(signers) > len(s.current.Signers) && equalSigners(signers[:len(s.current.Signers)], s.current.Signers) {
+		if hasDuplicateSigner(signers) {
+			return nil, errorf(ErrInvalidPartialSignature, "partial signature has the same signer twice")
+		}
+		return partialSig.copy(), nil
+	}
+
+	// otherwise, only an additive partial signature from new signers can be combined with it
+	if s.splitBy != Addition {
+		return nil, errorf(ErrInvalidPartialSignature, "multiplicative partial signature does not extend the session's current partial signature")
+	}
+	for _, signer := range signers {
+		if s.current.signedBy(signer) {
+			return nil, errorf(ErrInvalidPartialSignature, "shard %d has already signed", signer)
+		}
+	}
+	if hasDuplicateSigner(signers) {
+		return nil, errorf(ErrInvalidPartialSignature, "partial signature has the same signer twice")
+	}
+
+	combined := new(big.Int).SetBytes(s.current.Sig)
+	combined.Mul(combined, partialInt).Mod(combined, s.pub.N)
+	return &PartialSignature{
+		Signers: append(append([]int{}, s.current.Signers...), signers...),
+		Hash:    s.current.Hash,
+		SplitBy: s.splitBy,
+		Sig:     combined.FillBytes(make([]byte, s.pub.Size())),
+		Nonce:   s.Nonce(),
+	}, nil
+}
+
+// verifies a complete signature according to the session's signature scheme
+func (s *Session) verify(sig []byte) error {
+	if pssOpts, ok := s.opts.(*PSSOptions); ok {
+		return rsa.VerifyPSS(s.pub, pssOpts.Hash, s.hashed, sig, &rsa.PSSOptions{SaltLength: len(pssOpts.Salt), Hash: pssOpts.Hash})
+	}
+	return VerifyFinal(s.pub, s.opts.HashFunc(), s.hashed, sig, s.splitBy)
+}
+
+func equalSigners(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// reports whether a known shard index appears more than once. Unknown indices never match
+func hasDuplicateSigner(signers []int) bool {
+	seen := map[int]bool{}
+	for _, signer := range signers {
+		if signer != 0 && seen[signer] {
+			return true
+		}
+		seen[signer] = true
+	}
+	return false
+}
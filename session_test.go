@@ -0,0 +1,194 @@
+package keysplitting
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Session", func() {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	additive, _ := SplitD(key, 3, Addition)
+	multiplicative, _ := SplitD(key, 3, Multiplication)
+	hashed := sha512.Sum512([]byte("TEST MESSAGE"))
+
+	It("Produces a valid signature from sequential signers", func() {
+		for _, shards := range [][]*PrivateKeyShard{additive, multiplicative} {
+			session, err := NewSession(&key.PublicKey, shards[0].SplitBy, len(shards), crypto.SHA512, hashed[:])
+			Expect(err).To(BeNil())
+
+			for i, shard := range shards {
+				_, err := session.Signature()
+				Expect(errors.Is(err, ErrIncompleteSignature)).To(BeTrue())
+				Expect(session.Sign(rand.Reader, shard)).To(Succeed())
+				Expect(session.Signers()).To(HaveLen(i + 1))
+			}
+
+			Expect(session.Done()).To(BeTrue())
+			sig, err := session.Signature()
+			Expect(err).To(BeNil())
+			Expect(rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA512, hashed[:], sig)).To(Succeed())
+		}
+	})
+
+	It("Combines brokered and sequential additive partial signatures", func() {
+		session, err := NewSession(&key.PublicKey, Addition, 3, crypto.SHA512, hashed[:])
+		Expect(err).To(BeNil())
+
+		first, err := SignFirst(rand.Reader, additive[0], session.SignerOpts(), hashed[:])
+		Expect(err).To(BeNil())
+		second, err := SignFirst(rand.Reader, additive[1], session.SignerOpts(), hashed[:])
+		Expect(err).To(BeNil())
+		Expect(session.AddPartial(second)).To(Succeed())
+		Expect(session.AddPartial(first)).To(Succeed())
+
+		third, err := SignNext(rand.Reader, additive[2], session.SignerOpts(), hashed[:], session.Current())
+		Expect(err).To(BeNil())
+		Expect(session.AddPartial(third)).To(Succeed())
+
+		sig, err := session.Signature()
+		Expect(err).To(BeNil())
+		Expect(rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA512, hashed[:], sig)).To(Succeed())
+	})
+
+	It("Produces a valid PSS signature", func() {
+		salt, err := NewPSSSalt(rand.Reader, &key.PublicKey, crypto.SHA512, nil)
+		Expect(err).To(BeNil())
+		opts := &PSSOptions{Hash: crypto.SHA512, Salt: salt}
+
+		session, err := NewSession(&key.PublicKey, Multiplication, 3, opts, hashed[:])
+		Expect(err).To(BeNil())
+		for _, shard := range multiplicative {
+			Expect(session.Sign(rand.Reader, shard)).To(Succeed())
+		}
+
+		sig, err := session.Signature()
+		Expect(err).To(BeNil())
+		Expect(rsa.VerifyPSS(&key.PublicKey, crypto.SHA512, hashed[:], sig, nil)).To(Succeed())
+	})
+
+	It("Refuses partial signatures from another session", func() {
+		for _, shards := range [][]*PrivateKeyShard{additive, multiplicative} {
+			session, err := NewSession(&key.PublicKey, shards[0].SplitBy, len(shards), crypto.SHA512, hashed[:])
+			Expect(err).To(BeNil())
+			other, err := NewSession(&key.PublicKey, shards[0].SplitBy, len(shards), crypto.SHA512, hashed[:])
+			Expect(err).To(BeNil())
+
+			Expect(other.Sign(rand.Reader, shards[0])).To(Succeed())
+			err = session.AddPartial(other.Current())
+			Expect(errors.Is(err, ErrInvalidPartialSignature)).To(BeTrue())
+
+			unbound, err := SignFirst(rand.Reader, shards[0], crypto.SHA512, hashed[:])
+			Expect(err).To(BeNil())
+			err = session.AddPartial(unbound)
+			Expect(errors.Is(err, ErrInvalidPartialSignature)).To(BeTrue())
+
+			// a replayed partial signature with its nonce relabeled leaves the signature invalid
+			relabeled := other.Current()
+			relabeled.Nonce = session.Nonce()
+			Expect(session.AddPartial(relabeled)).To(Succeed())
+			for _, shard := range shards[1:] {
+				Expect(session.Sign(rand.Reader, shard)).To(Succeed())
+			}
+			_, err = session.Signature()
+			Expect(errors.Is(err, ErrIncompleteSignature)).To(BeTrue())
+		}
+	})
+
+	It("Refuses shards and partial signatures of the other split mode", func() {
+		session, err := NewSession(&key.PublicKey, Multiplication, 3, crypto.SHA512, hashed[:])
+		Expect(err).To(BeNil())
+		Expect(session.Sign(rand.Reader, multiplicative[0])).To(Succeed())
+
+		err = session.Sign(rand.Reader, additive[1])
+		Expect(errors.Is(err, ErrInvalidPartialSignature)).To(BeTrue())
+
+		partialSig, err := SignFirst(rand.Reader, additive[1], session.SignerOpts(), hashed[:])
+		Expect(err).To(BeNil())
+		err = session.AddPartial(partialSig)
+		Expect(errors.Is(err, ErrInvalidPartialSignature)).To(BeTrue())
+
+		Expect(session.Signers()).To(HaveLen(1))
+	})
+
+	It("Refuses partial signatures over a different hash function", func() {
+		session, err := NewSession(&key.PublicKey, Addition, 3, crypto.SHA512, hashed[:])
+		Expect(err).To(BeNil())
+
+		hashed256 := sha256.Sum256([]byte("TEST MESSAGE"))
+		partialSig, err := SignFirst(rand.Reader, additive[0], &NonceOptions{Opts: crypto.SHA256, Nonce: session.Nonce()}, hashed256[:])
+		Expect(err).To(BeNil())
+		err = session.AddPartial(partialSig)
+		Expect(errors.Is(err, ErrInvalidPartialSignature)).To(BeTrue())
+	})
+
+	It("Refuses a shard that has already signed", func() {
+		session, err := NewSession(&key.PublicKey, Addition, 3, crypto.SHA512, hashed[:])
+		Expect(err).To(BeNil())
+		Expect(session.Sign(rand.Reader, additive[0])).To(Succeed())
+
+		err = session.Sign(rand.Reader, additive[0])
+		Expect(errors.Is(err, ErrInvalidPartialSignature)).To(BeTrue())
+
+		partialSig, err := SignFirst(rand.Reader, additive[0], session.SignerOpts(), hashed[:])
+		Expect(err).To(BeNil())
+		err = session.AddPartial(partialSig)
+		Expect(errors.Is(err, ErrInvalidPartialSignature)).To(BeTrue())
+	})
+
+	It("Refuses multiplicative partial signatures that don't extend the chain", func() {
+		session, err := NewSession(&key.PublicKey, Multiplication, 3, crypto.SHA512, hashed[:])
+		Expect(err).To(BeNil())
+		Expect(session.Sign(rand.Reader, multiplicative[0])).To(Succeed())
+
+		partialSig, err := SignFirst(rand.Reader, multiplicative[1], session.SignerOpts(), hashed[:])
+		Expect(err).To(BeNil())
+		err = session.AddPartial(partialSig)
+		Expect(errors.Is(err, ErrInvalidPartialSignature)).To(BeTrue())
+	})
+
+	It("Refuses shards of a different key", func() {
+		otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+		otherShards, _ := SplitD(otherKey, 2, Addition)
+
+		session, err := NewSession(&key.PublicKey, Addition, 3, crypto.SHA512, hashed[:])
+		Expect(err).To(BeNil())
+		err = session.Sign(rand.Reader, otherShards[0])
+		Expect(errors.Is(err, ErrKeyMismatch)).To(BeTrue())
+	})
+
+	It("Refuses further steps once every shard has signed", func() {
+		session, err := NewSession(&key.PublicKey, Addition, 2, crypto.SHA512, hashed[:])
+		Expect(err).To(BeNil())
+
+		// a 3-way split signed by only 2 of its shards cannot verify
+		Expect(session.Sign(rand.Reader, additive[0])).To(Succeed())
+		Expect(session.Sign(rand.Reader, additive[1])).To(Succeed())
+		Expect(session.Done()).To(BeTrue())
+		_, err = session.Signature()
+		Expect(errors.Is(err, ErrIncompleteSignature)).To(BeTrue())
+
+		err = session.Sign(rand.Reader, additive[2])
+		Expect(errors.Is(err, ErrInvalidPartialSignature)).To(BeTrue())
+	})
+
+	It("Rejects invalid parameters", func() {
+		_, err := NewSession(&key.PublicKey, Addition, 1, crypto.SHA512, hashed[:])
+		Expect(errors.Is(err, ErrTooFewShards)).To(BeTrue())
+
+		_, err = NewSession(&key.PublicKey, SplitBy("Exponentiation"), 2, crypto.SHA512, hashed[:])
+		Expect(errors.Is(err, ErrUnsupportedSplitBy)).To(BeTrue())
+
+		_, err = NewSession(&key.PublicKey, Addition, 2, crypto.SHA256, hashed[:])
+		Expect(errors.Is(err, ErrUnsupportedHash)).To(BeTrue())
+
+		_, err = NewSession(&key.PublicKey, Addition, 2, &rsa.PSSOptions{Hash: crypto.SHA512}, hashed[:])
+		Expect(errors.Is(err, ErrUnsupportedHash)).To(BeTrue())
+	})
+})
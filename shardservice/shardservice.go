@@ -0,0 +1,290 @@
+/*
+Package shardservice connects shard holders over gRPC, with a [Server] for the keysplitting.v1.ShardService defined in
+proto/keysplitting/v1/keysplitting.proto and a [Client] that calls it as a keysplitting.RemoteShard, so that holders running
+as separate services don't each need their own RPC layer. It is a separate package so that keysplitting doesn't depend on
+gRPC
+*/
+package shardservice
+
+import (
+	"context"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/bastionzero/keysplitting"
+	keysplittingv1 "github.com/bastionzero/keysplitting/proto/keysplitting/v1"
+	"github.com/bastionzero/keysplitting/protobuf"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// a trailer carrying the text of the sentinel error that a failure wraps, so that the client can wrap it too
+const grpcSentinelTrailer = "keysplitting-error"
+
+// the errors that can be carried across the wire in the grpcSentinelTrailer
+var grpcSentinels = []error{
+	keysplitting.ErrTooFewShards, keysplitting.ErrUnsupportedSplitBy, keysplitting.ErrUnsupportedHash,
+	keysplitting.ErrInvalidPartialSignature, keysplitting.ErrKeyMismatch, keysplitting.ErrInvalidShard,
+	keysplitting.ErrTooManyAttempts, keysplitting.ErrIncompleteSignature, keysplitting.ErrIncorrectPassphrase,
+	keysplitting.ErrClosed, keysplitting.ErrInvalidKey, keysplitting.ErrInvalidMessage,
+}
+
+// An Error is a failed gRPC call. It wraps the sentinel error, such as keysplitting.ErrKeyMismatch, that the server's error
+// wrapped, or the context error if the call was canceled or ran out of time. Its GRPCStatus method makes it a gRPC status
+// error too
+type Error struct {
+	Code     codes.Code
+	Message  string
+	sentinel error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("gRPC call failed with status %v: %s", e.Code, e.Message)
+}
+
+func (e *Error) Unwrap() error {
+	return e.sentinel
+}
+
+// GRPCStatus returns the status of the call, for google.golang.org/grpc/status.FromError
+func (e *Error) GRPCStatus() *status.Status {
+	return status.New(e.Code, e.Message)
+}
+
+// A Server implements the keysplitting.v1.ShardService gRPC service, answering SignPartial requests with a
+// keysplitting.RemoteShard, usually one from keysplitting.NewLocalShard. It is registered with a gRPC server like any other
+// service:
+//
+//	server := grpc.NewServer(grpc.Creds(credentials.NewTLS(tlsConfig)))
+//	keysplittingv1.RegisterShardServiceServer(server, shardservice.NewServer(shard, pub))
+//	err := server.Serve(listener)
+//
+// Since the service signs anything it is asked to, the TLS configuration should authenticate clients
+type Server struct {
+	keysplittingv1.UnimplementedShardServiceServer
+
+	shard      keysplitting.RemoteShard
+	pub        *rsa.PublicKey
+	keyID      string
+	notServing int32
+	logger     keysplitting.Logger
+}
+
+var _ keysplittingv1.ShardServiceServer = (*Server)(nil)
+
+// NewServer returns a server that signs with shard, which belongs to pub
+func NewServer(shard keysplitting.RemoteShard, pub *rsa.PublicKey) *Server {
+	return &Server{shard: shard, pub: pub, keyID: keysplitting.PublicKeyID(pub), logger: keysplitting.NopLogger{}}
+}
+
+// SetLogger sets the keysplitting.Logger that the server reports each request it signs or refuses to. A nil logger, the
+// default, discards them. It must be called before the server starts serving
+func (s *Server) SetLogger(logger keysplitting.Logger) {
+	s.logger = logger
+	if logger == nil {
+		s.logger = keysplitting.NopLogger{}
+	}
+}
+
+// SetServing sets whether the Health method reports the server as serving, e.g. so that it can be drained before shutdown.
+// It does not affect the other methods
+func (s *Server) SetServing(serving bool) {
+	var notServing int32
+	if !serving {
+		notServing = 1
+	}
+	atomic.StoreInt32(&s.notServing, notServing)
+}
+
+// SignPartial implements keysplittingv1.ShardServiceServer, adding the shard's signature to the request's
+func (s *Server) SignPartial(ctx context.Context, req *keysplittingv1.SigningRequest) (*keysplittingv1.PartialSignature, error) {
+	partialSig, err := s.signPartial(incomingGRPCSpan(ctx), req)
+	if err != nil {
+		return nil, grpcStatusFromError(ctx, err)
+	}
+	return partialSig, nil
+}
+
+func (s *Server) signPartial(ctx context.Context, msg *keysplittingv1.SigningRequest) (resp *keysplittingv1.PartialSignature, err error) {
+	ctx, span := keysplitting.StartSpan(ctx, "shardservice.Server.SignPartial",
+		keysplitting.TraceAttribute{Key: keysplitting.TraceKeySize, Value: s.pub.N.BitLen()})
+	defer func() { span.End(err) }()
+
+	req, err := protobuf.SigningRequestFromProto(msg)
+	if err != nil {
+		s.logger.Warn("received invalid signing request", "error", err)
+		return nil, fmt.Errorf("%w: %s", keysplitting.ErrInvalidPartialSignature, err)
+	}
+	partialSig, err := s.shard.PartialSign(ctx, req)
+	if err != nil {
+		s.logger.Warn("refused to sign", "key_id", req.KeyID, "error", err)
+		return nil, err
+	}
+	s.logger.Debug("signed", "key_id", s.keyID, "signers", partialSig.Signers)
+	return protobuf.PartialSignatureToProto(partialSig)
+}
+
+// GetPublicKey implements keysplittingv1.ShardServiceServer, returning the public key that the shard belongs to
+func (s *Server) GetPublicKey(context.Context, *keysplittingv1.GetPublicKeyRequest) (*keysplittingv1.PublicKey, error) {
+	return protobuf.PublicKeyToProto(s.pub), nil
+}
+
+// Health implements keysplittingv1.ShardServiceServer, reporting whether the server is serving (see [Server.SetServing])
+func (s *Server) Health(context.Context, *keysplittingv1.HealthRequest) (*keysplittingv1.HealthResponse, error) {
+	if atomic.LoadInt32(&s.notServing) != 0 {
+		return &keysplittingv1.HealthResponse{Status: keysplittingv1.HealthResponse_SERVING_STATUS_NOT_SERVING}, nil
+	}
+	return &keysplittingv1.HealthResponse{Status: keysplittingv1.HealthResponse_SERVING_STATUS_SERVING}, nil
+}
+
+// A Client is a keysplitting.RemoteShard that calls a keysplitting.v1.ShardService, such as a [Server] or any other
+// implementation of the service definition
+type Client struct {
+	client keysplittingv1.ShardServiceClient
+}
+
+var _ keysplitting.RemoteShard = (*Client)(nil)
+
+// NewClient returns a client that calls the service over conn, usually a *grpc.ClientConn:
+//
+//	conn, err := grpc.Dial("holder.example.com:8443", grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+//	...
+//	shard := shardservice.NewClient(conn)
+//
+// The caller closes conn once it no longer needs the client
+func NewClient(conn grpc.ClientConnInterface) *Client {
+	return &Client{client: keysplittingv1.NewShardServiceClient(conn)}
+}
+
+// PartialSign calls the SignPartial method
+func (c *Client) PartialSign(ctx context.Context, req *keysplitting.SigningRequest) (*keysplitting.PartialSignature, error) {
+	msg, err := protobuf.SigningRequestToProto(req)
+	if err != nil {
+		return nil, err
+	}
+	var trailer metadata.MD
+	resp, err := c.client.SignPartial(outgoingGRPCSpan(ctx), msg, grpc.Trailer(&trailer))
+	if err != nil {
+		return nil, grpcErrorFromStatus(err, trailer)
+	}
+	return protobuf.PartialSignatureFromProto(resp)
+}
+
+// PublicKey calls the GetPublicKey method, returning the public key of the key that the server's shard belongs to
+func (c *Client) PublicKey(ctx context.Context) (*rsa.PublicKey, error) {
+	var trailer metadata.MD
+	resp, err := c.client.GetPublicKey(outgoingGRPCSpan(ctx), &keysplittingv1.GetPublicKeyRequest{}, grpc.Trailer(&trailer))
+	if err != nil {
+		return nil, grpcErrorFromStatus(err, trailer)
+	}
+	pub, err := protobuf.PublicKeyFromProto(resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode public key: %s", err)
+	}
+	if pub.N.Sign() == 0 || pub.E == 0 {
+		return nil, fmt.Errorf("server returned an empty public key")
+	}
+	return pub, nil
+}
+
+// Health calls the Health method, returning nil if the server is serving
+func (c *Client) Health(ctx context.Context) error {
+	var trailer metadata.MD
+	resp, err := c.client.Health(outgoingGRPCSpan(ctx), &keysplittingv1.HealthRequest{}, grpc.Trailer(&trailer))
+	if err != nil {
+		return grpcErrorFromStatus(err, trailer)
+	}
+	if resp.GetStatus() != keysplittingv1.HealthResponse_SERVING_STATUS_SERVING {
+		return &Error{Code: codes.Unavailable, Message: "shard holder is not serving"}
+	}
+	return nil
+}
+
+// returns the status error for a failed call, with a code chosen by the class of error, and sets the grpcSentinelTrailer
+// to the sentinel error it wraps
+func grpcStatusFromError(ctx context.Context, err error) error {
+	code := codes.Unknown
+	switch {
+	case errors.Is(err, context.Canceled):
+		code = codes.Canceled
+	case errors.Is(err, context.DeadlineExceeded):
+		code = codes.DeadlineExceeded
+	case errors.Is(err, keysplitting.ErrKeyMismatch), errors.Is(err, keysplitting.ErrClosed):
+		code = codes.FailedPrecondition
+	}
+
+	for _, sentinel := range grpcSentinels {
+		if errors.Is(err, sentinel) {
+			if code == codes.Unknown {
+				code = codes.InvalidArgument
+			}
+			_ = grpc.SetTrailer(ctx, metadata.Pairs(grpcSentinelTrailer, sentinel.Error()))
+			break
+		}
+	}
+	return status.Error(code, err.Error())
+}
+
+// returns the Error for the status error of a failed call, wrapping the sentinel error named in its trailer
+func grpcErrorFromStatus(err error, trailer metadata.MD) error {
+	s := status.Convert(err)
+	grpcErr := &Error{Code: s.Code(), Message: s.Message()}
+	switch s.Code() {
+	case codes.Canceled:
+		grpcErr.sentinel = context.Canceled
+	case codes.DeadlineExceeded:
+		grpcErr.sentinel = context.DeadlineExceeded
+	}
+	if values := trailer.Get(grpcSentinelTrailer); len(values) == 1 {
+		for _, sentinel := range grpcSentinels {
+			if sentinel.Error() == values[0] {
+				grpcErr.sentinel = sentinel
+			}
+		}
+	}
+	return grpcErr
+}
+
+// a keysplitting.TraceCarrier over the metadata of a gRPC call
+type grpcMetadataCarrier metadata.MD
+
+func (c grpcMetadataCarrier) Get(key string) string {
+	if values := metadata.MD(c).Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func (c grpcMetadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c grpcMetadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// returns ctx with the caller's span carried by the metadata of the incoming call, if there is one
+func incomingGRPCSpan(ctx context.Context) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	return keysplitting.ExtractSpan(ctx, grpcMetadataCarrier(md))
+}
+
+// returns ctx with its span added to the metadata of outgoing calls
+func outgoingGRPCSpan(ctx context.Context) context.Context {
+	md := metadata.MD{}
+	keysplitting.InjectSpan(ctx, grpcMetadataCarrier(md))
+	for key, values := range md {
+		for _, value := range values {
+			ctx = metadata.AppendToOutgoingContext(ctx, key, value)
+		}
+	}
+	return ctx
+}
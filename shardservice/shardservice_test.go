@@ -0,0 +1,266 @@
+package shardservice
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bastionzero/keysplitting"
+	keysplittingv1 "github.com/bastionzero/keysplitting/proto/keysplitting/v1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestShardService(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Shard Service Suite")
+}
+
+// a keysplitting.RemoteShard that waits for its context to be canceled
+type blockingShard struct{}
+
+func (blockingShard) PartialSign(ctx context.Context, _ *keysplitting.SigningRequest) (*keysplitting.PartialSignature, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+// a Logger that keeps the arguments of every message, by level
+type recordingLogger struct {
+	mu   sync.Mutex
+	args map[string][]map[string]interface{}
+}
+
+func (l *recordingLogger) log(level string, args []interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	message := map[string]interface{}{}
+	for i := 0; i+1 < len(args); i += 2 {
+		message[args[i].(string)] = args[i+1]
+	}
+	if l.args == nil {
+		l.args = map[string][]map[string]interface{}{}
+	}
+	l.args[level] = append(l.args[level], message)
+}
+
+func (l *recordingLogger) Debug(_ string, args ...interface{}) { l.log("debug", args) }
+func (l *recordingLogger) Info(_ string, args ...interface{})  { l.log("info", args) }
+func (l *recordingLogger) Warn(_ string, args ...interface{})  { l.log("warn", args) }
+func (l *recordingLogger) Error(_ string, args ...interface{}) { l.log("error", args) }
+
+type spanKey struct{}
+
+// a Tracer and TracePropagator that records the name and parent of every span, identifying spans by their position
+type recordingTracer struct {
+	mu      sync.Mutex
+	names   []string
+	parents []int
+}
+
+func (t *recordingTracer) Start(ctx context.Context, name string, _ ...keysplitting.TraceAttribute) (context.Context, keysplitting.TraceSpan) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	parent, _ := ctx.Value(spanKey{}).(int)
+	t.names, t.parents = append(t.names, name), append(t.parents, parent)
+	return context.WithValue(ctx, spanKey{}, len(t.names)), nopSpan{}
+}
+
+func (t *recordingTracer) Inject(ctx context.Context, carrier keysplitting.TraceCarrier) {
+	if id, ok := ctx.Value(spanKey{}).(int); ok {
+		carrier.Set("test-span", strconv.Itoa(id))
+	}
+}
+
+func (t *recordingTracer) Extract(ctx context.Context, carrier keysplitting.TraceCarrier) context.Context {
+	if id, err := strconv.Atoi(carrier.Get("test-span")); err == nil {
+		return context.WithValue(ctx, spanKey{}, id)
+	}
+	return ctx
+}
+
+// returns the name of the parent of each span with the given name
+func (t *recordingTracer) parentsOf(name string) []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var parents []string
+	for i, n := range t.names {
+		if n == name && t.parents[i] > 0 {
+			parents = append(parents, t.names[t.parents[i]-1])
+		}
+	}
+	return parents
+}
+
+type nopSpan struct{}
+
+func (nopSpan) SetAttributes(...keysplitting.TraceAttribute) {}
+
+func (nopSpan) End(error) {}
+
+// serves server on an in-memory listener for the rest of the spec, and returns a connection to it
+func serveGRPC(server *Server) *grpc.ClientConn {
+	listener := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	keysplittingv1.RegisterShardServiceServer(s, server)
+	go func() { _ = s.Serve(listener) }()
+	DeferCleanup(s.Stop)
+
+	conn, err := grpc.Dial("bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	Expect(err).To(BeNil())
+	DeferCleanup(conn.Close)
+	return conn
+}
+
+var _ = Describe("gRPC shard service", func() {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	hashed := sha256.Sum256([]byte("TEST MESSAGE"))
+	ctx := context.Background()
+
+	// serves the shard and returns the server and a client for it
+	serve := func(shard *keysplitting.PrivateKeyShard) (*Server, *Client) {
+		local, err := keysplitting.NewLocalShard(shard)
+		Expect(err).To(BeNil())
+		server := NewServer(local, shard.PublicKey)
+		return server, NewClient(serveGRPC(server))
+	}
+
+	It("Signs with shards behind the service", func() {
+		shards, err := keysplitting.SplitD(key, 3, keysplitting.Addition)
+		Expect(err).To(BeNil())
+
+		remotes := make([]keysplitting.RemoteShard, len(shards))
+		for i, shard := range shards {
+			_, remotes[i] = serve(shard)
+		}
+		sig, err := keysplitting.SignBrokered(ctx, &key.PublicKey, crypto.SHA256, hashed[:], remotes...)
+		Expect(err).To(BeNil())
+		Expect(rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, hashed[:], sig)).To(Succeed())
+
+		sig, err = keysplitting.SignSequential(ctx, &key.PublicKey, crypto.SHA256, hashed[:], remotes...)
+		Expect(err).To(BeNil())
+		Expect(rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, hashed[:], sig)).To(Succeed())
+	})
+
+	It("Returns the public key and health", func() {
+		shards, err := keysplitting.SplitD(key, 2, keysplitting.Multiplication)
+		Expect(err).To(BeNil())
+		server, client := serve(shards[0])
+
+		pub, err := client.PublicKey(ctx)
+		Expect(err).To(BeNil())
+		Expect(pub.Equal(&key.PublicKey)).To(BeTrue())
+
+		Expect(client.Health(ctx)).To(Succeed())
+		server.SetServing(false)
+		err = client.Health(ctx)
+		Expect(status.Code(err)).To(Equal(codes.Unavailable))
+	})
+
+	It("Carries sentinel errors across the wire", func() {
+		other, _ := rsa.GenerateKey(rand.Reader, 2048)
+		shards, err := keysplitting.SplitD(key, 2, keysplitting.Addition)
+		Expect(err).To(BeNil())
+		_, client := serve(shards[0])
+
+		req, err := keysplitting.NewSigningRequest(&other.PublicKey, crypto.SHA256, hashed[:])
+		Expect(err).To(BeNil())
+		_, err = client.PartialSign(ctx, req)
+		Expect(errors.Is(err, keysplitting.ErrKeyMismatch)).To(BeTrue())
+		var grpcErr *Error
+		Expect(errors.As(err, &grpcErr)).To(BeTrue())
+		Expect(grpcErr.Code).To(Equal(codes.FailedPrecondition))
+		Expect(grpcErr.Message).To(ContainSubstring("signing request is for key"))
+	})
+
+	It("Propagates deadlines", func() {
+		shards, err := keysplitting.SplitD(key, 2, keysplitting.Addition)
+		Expect(err).To(BeNil())
+		client := NewClient(serveGRPC(NewServer(blockingShard{}, &key.PublicKey)))
+
+		// the server gives up at the deadline, even though the client waits longer
+		deadline, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+		defer cancel()
+		req, err := keysplitting.NewSigningRequest(shards[0].PublicKey, crypto.SHA256, hashed[:])
+		Expect(err).To(BeNil())
+		_, err = client.PartialSign(deadline, req)
+		Expect(errors.Is(err, context.DeadlineExceeded)).To(BeTrue())
+	})
+
+	It("Rejects malformed signing requests", func() {
+		shards, err := keysplitting.SplitD(key, 2, keysplitting.Addition)
+		Expect(err).To(BeNil())
+		server, _ := serve(shards[0])
+		conn := serveGRPC(server)
+
+		// a partial signature without a signature
+		req := &keysplittingv1.SigningRequest{
+			KeyId:            keysplitting.PublicKeyID(&key.PublicKey),
+			PartialSignature: &keysplittingv1.PartialSignature{SplitBy: keysplittingv1.SplitBy_SPLIT_BY_ADDITION},
+		}
+		_, err = keysplittingv1.NewShardServiceClient(conn).SignPartial(ctx, req)
+		Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+	})
+
+	It("Reports the requests it signs and refuses", func() {
+		shards, err := keysplitting.SplitD(key, 2, keysplitting.Addition)
+		Expect(err).To(BeNil())
+		local, err := keysplitting.NewLocalShard(shards[0])
+		Expect(err).To(BeNil())
+		logger := &recordingLogger{}
+		server := NewServer(local, &key.PublicKey)
+		server.SetLogger(logger)
+		client := NewClient(serveGRPC(server))
+
+		req, err := keysplitting.NewSigningRequest(&key.PublicKey, crypto.SHA256, hashed[:])
+		Expect(err).To(BeNil())
+		_, err = client.PartialSign(ctx, req)
+		Expect(err).To(BeNil())
+		other, _ := rsa.GenerateKey(rand.Reader, 1024)
+		req, err = keysplitting.NewSigningRequest(&other.PublicKey, crypto.SHA256, hashed[:])
+		Expect(err).To(BeNil())
+		_, err = client.PartialSign(ctx, req)
+		Expect(errors.Is(err, keysplitting.ErrKeyMismatch)).To(BeTrue())
+
+		Expect(logger.args["debug"]).To(HaveLen(1))
+		Expect(logger.args["debug"][0]).To(HaveKeyWithValue("signers", []int{1}))
+		Expect(logger.args["warn"]).To(HaveLen(1))
+		Expect(logger.args["warn"][0]).To(HaveKeyWithValue("key_id", keysplitting.PublicKeyID(&other.PublicKey)))
+	})
+
+	It("Continues traces across the service", func() {
+		tracer := &recordingTracer{}
+		keysplitting.SetTracer(tracer)
+		DeferCleanup(func() { keysplitting.SetTracer(nil) })
+
+		shards, err := keysplitting.SplitD(key, 2, keysplitting.Addition)
+		Expect(err).To(BeNil())
+		remotes := make([]keysplitting.RemoteShard, len(shards))
+		for i, shard := range shards {
+			_, remotes[i] = serve(shard)
+		}
+		_, err = keysplitting.SignBrokered(ctx, &key.PublicKey, crypto.SHA256, hashed[:], remotes...)
+		Expect(err).To(BeNil())
+
+		Expect(tracer.parentsOf("shardservice.Server.SignPartial")).To(Equal([]string{
+			"keysplitting.RemoteShard.PartialSign", "keysplitting.RemoteShard.PartialSign",
+		}))
+		Expect(tracer.parentsOf("keysplitting.SignFirst")).To(Equal([]string{
+			"shardservice.Server.SignPartial", "shardservice.Server.SignPartial",
+		}))
+	})
+})
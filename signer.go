@@ -0,0 +1,125 @@
+package keysplitting
+
+import (
+	"crypto"
+	"io"
+	"math/big"
+	"time"
+)
+
+// A ShardSigner produces partial signatures with a shard. A *PrivateKeyShard is the in-memory implementation, and others such as
+// [PKCS11Shard] perform the private exponentiation somewhere the shard never leaves, so that code which signs through a
+// ShardSigner works unchanged with either
+type ShardSigner interface {
+	// Public returns the public key of the whole key that the shard belongs to
+	Public() crypto.PublicKey
+	// SignFirst is like the package-level [SignFirst] with this shard
+	SignFirst(random io.Reader, opts crypto.SignerOpts, hashed []byte) (*PartialSignature, error)
+	// SignNext is like the package-level [SignNext] with this shard
+	SignNext(random io.Reader, opts crypto.SignerOpts, hashed []byte, partialSig *PartialSignature) (*PartialSignature, error)
+}
+
+// SignFirst calls the package-level [SignFirst] with the shard, so that a PrivateKeyShard satisfies [ShardSigner]
+func (pks *PrivateKeyShard) SignFirst(random io.Reader, opts crypto.SignerOpts, hashed []byte) (*PartialSignature, error) {
+	return SignFirst(random, pks, opts, hashed)
+}
+
+// SignNext calls the package-level [SignNext] with the shard, so that a PrivateKeyShard satisfies [ShardSigner]
+func (pks *PrivateKeyShard) SignNext(random io.Reader, opts crypto.SignerOpts, hashed []byte, partialSig *PartialSignature) (*PartialSignature, error) {
+	return SignNext(random, pks, opts, hashed, partialSig)
+}
+
+// signs with a shard whose private exponentiation is performed by exp, e.g. inside a hardware token. The shard holds only the
+// public key, index and split algorithm, and must not be masked, since the mask changes the exponent from one signature to the next
+type exponentiationSigner struct {
+	shard *PrivateKeyShard
+	exp   func(m *big.Int) (*big.Int, error)
+}
+
+func (s *exponentiationSigner) Public() crypto.PublicKey {
+	return s.shard.PublicKey
+}
+
+func (s *exponentiationSigner) SignFirst(random io.Reader, opts crypto.SignerOpts, hashed []byte) (*PartialSignature, error) {
+	start := time.Now()
+	sig, err := s.signFirst(opts, hashed)
+	observePartialSignature(start, err)
+	auditPartialSign(s.shard, opts, hashed, err)
+	if err != nil {
+		return nil, err
+	}
+	partialSig := newPartialSignature(s.shard, opts.HashFunc(), sig)
+	partialSig.Nonce = nonceOf(opts)
+	return partialSig, nil
+}
+
+func (s *exponentiationSigner) SignNext(random io.Reader, opts crypto.SignerOpts, hashed []byte, partialSig *PartialSignature) (next *PartialSignature, err error) {
+	start := time.Now()
+	defer func() {
+		observePartialSignature(start, err)
+		auditPartialSign(s.shard, opts, hashed, err)
+	}()
+
+	if opts == nil {
+		return nil, errorf(ErrUnsupportedHash, "no signer options provided")
+	}
+	if err := partialSig.checkNext(s.shard, opts.HashFunc(), nonceOf(opts)); err != nil {
+		return nil, err
+	}
+
+	partialInt := new(big.Int).SetBytes(partialSig.Sig)
+	if partialInt.Sign() == 0 || partialInt.Cmp(s.shard.PublicKey.N) >= 0 {
+		return nil, errorf(ErrInvalidPartialSignature, "partial signature is out of range for the shard's public key")
+	}
+
+	var nextSig *big.Int
+	switch s.shard.SplitBy {
+	case Multiplication:
+		if nextSig, err = s.exp(partialInt); err != nil {
+			return nil, err
+		}
+	case Addition:
+		baseSig, err := s.signFirst(opts, hashed)
+		if err != nil {
+			return nil, err
+		}
+		nextSig = new(big.Int).SetBytes(baseSig)
+		nextSig.Mul(nextSig, partialInt).Mod(nextSig, s.shard.PublicKey.N)
+	default:
+		return nil, errorf(ErrUnsupportedSplitBy, "unrecognized split algorithm: %v", s.shard.SplitBy)
+	}
+	return partialSig.extend(s.shard, nextSig.FillBytes(make([]byte, s.shard.PublicKey.Size()))), nil
+}
+
+// returns the shard's own signature on hashed using the scheme selected by opts, as with signFirstWithOpts
+func (s *exponentiationSigner) signFirst(opts crypto.SignerOpts, hashed []byte) ([]byte, error) {
+	if err := checkSplitBy(s.shard.SplitBy); err != nil {
+		return nil, err
+	}
+
+	pub := s.shard.PublicKey
+	var nonce []byte
+	if o, ok := opts.(*NonceOptions); ok {
+		var err error
+		if opts, err = o.unwrap(); err != nil {
+			return nil, err
+		}
+		nonce = o.Nonce
+	}
+	em, err := encodeMessage(pub, opts, hashed)
+	if err != nil {
+		return nil, err
+	}
+
+	m := new(big.Int).SetBytes(em)
+	if nonce != nil {
+		if m, err = bindNonce(pub, nonce, m); err != nil {
+			return nil, err
+		}
+	}
+	c, err := s.exp(m)
+	if err != nil {
+		return nil, err
+	}
+	return c.FillBytes(make([]byte, pub.Size())), nil
+}
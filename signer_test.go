@@ -0,0 +1,94 @@
+package keysplitting
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Shard signers", func() {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	hashed := sha256.Sum256([]byte("TEST MESSAGE"))
+
+	// signs in sequence through the ShardSigner interface
+	signChain := func(signers []ShardSigner, opts crypto.SignerOpts) []byte {
+		partialSig, err := signers[0].SignFirst(rand.Reader, opts, hashed[:])
+		Expect(err).To(BeNil())
+		for _, signer := range signers[1:] {
+			partialSig, err = signer.SignNext(rand.Reader, opts, hashed[:], partialSig)
+			Expect(err).To(BeNil())
+		}
+		return partialSig.Sig
+	}
+
+	// an exponentiationSigner for each shard, as used by signers that keep the shard elsewhere
+	external := func(shards []*PrivateKeyShard) []ShardSigner {
+		signers := make([]ShardSigner, len(shards))
+		for i, shard := range shards {
+			shard := shard
+			signers[i] = &exponentiationSigner{
+				shard: &PrivateKeyShard{PublicKey: shard.PublicKey, SplitBy: shard.SplitBy, Index: shard.Index},
+				exp: func(m *big.Int) (*big.Int, error) {
+					return new(big.Int).Exp(m, shard.D, shard.PublicKey.N), nil
+				},
+			}
+		}
+		return signers
+	}
+
+	for _, splitBy := range []SplitBy{Addition, Multiplication} {
+		splitBy := splitBy
+
+		It("Signs through the interface with in-memory shards split by "+string(splitBy), func() {
+			shards, err := SplitD(key, 3, splitBy)
+			Expect(err).To(BeNil())
+
+			signers := make([]ShardSigner, len(shards))
+			for i, shard := range shards {
+				signers[i] = shard
+			}
+			Expect(rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, hashed[:], signChain(signers, crypto.SHA256))).To(Succeed())
+		})
+
+		It("Matches in-memory signing when exponentiating elsewhere with shards split by "+string(splitBy), func() {
+			shards, err := SplitD(key, 3, splitBy)
+			Expect(err).To(BeNil())
+			signers := external(shards)
+
+			salt, err := NewPSSSalt(rand.Reader, &key.PublicKey, crypto.SHA256, nil)
+			Expect(err).To(BeNil())
+			for _, opts := range []crypto.SignerOpts{crypto.SHA256, &PSSOptions{Hash: crypto.SHA256, Salt: salt}} {
+				first, err := shards[0].SignFirst(rand.Reader, opts, hashed[:])
+				Expect(err).To(BeNil())
+				externalFirst, err := signers[0].SignFirst(rand.Reader, opts, hashed[:])
+				Expect(err).To(BeNil())
+				Expect(externalFirst).To(Equal(first))
+			}
+
+			// mix both kinds of signer in one chain
+			sig := signChain([]ShardSigner{signers[0], shards[1], signers[2]}, crypto.SHA256)
+			Expect(rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, hashed[:], sig)).To(Succeed())
+		})
+	}
+
+	It("Refuses to sign twice or with mismatched options", func() {
+		shards, err := SplitD(key, 2, Addition)
+		Expect(err).To(BeNil())
+		signers := external(shards)
+
+		partialSig, err := signers[0].SignFirst(rand.Reader, crypto.SHA256, hashed[:])
+		Expect(err).To(BeNil())
+		_, err = signers[0].SignNext(rand.Reader, crypto.SHA256, hashed[:], partialSig)
+		Expect(errors.Is(err, ErrInvalidPartialSignature)).To(BeTrue())
+		_, err = signers[1].SignNext(rand.Reader, crypto.SHA512, hashed[:], partialSig)
+		Expect(errors.Is(err, ErrInvalidPartialSignature)).To(BeTrue())
+		_, err = signers[1].SignFirst(rand.Reader, &rsa.PSSOptions{Hash: crypto.SHA256}, hashed[:])
+		Expect(errors.Is(err, ErrUnsupportedHash)).To(BeTrue())
+	})
+})
@@ -0,0 +1,67 @@
+package keysplitting
+
+import (
+	"crypto"
+	"hash"
+	"io"
+)
+
+// A SigningSession hashes a message as it is written and then signs the digest with a single shard.
+// It implements [io.Writer], so large messages can be streamed into it, e.g. with [io.Copy], rather than held in memory.
+// A SigningSession is not safe for concurrent use
+type SigningSession struct {
+	random     io.Reader
+	shard      *PrivateKeyShard
+	opts       crypto.SignerOpts
+	h          hash.Hash
+	partialSig *PartialSignature
+	done       bool
+}
+
+// NewSigningSession starts a session in which shard signs a message hashed with opts.HashFunc(). If partialSig is nil,
+// the shard signs first as with [SignFirst]. Otherwise, it adds its signature to partialSig as with [SignNext]
+func NewSigningSession(random io.Reader, shard *PrivateKeyShard, opts crypto.SignerOpts, partialSig *PartialSignature) (*SigningSession, error) {
+	if opts == nil {
+		return nil, errorf(ErrUnsupportedHash, "no signer options provided")
+	}
+	hashFn := opts.HashFunc()
+	if err := checkHashAvailable(hashFn); err != nil {
+		return nil, err
+	}
+	if partialSig != nil {
+		if err := partialSig.checkNext(shard, hashFn, nonceOf(opts)); err != nil {
+			return nil, err
+		}
+	}
+
+	return &SigningSession{
+		random:     random,
+		shard:      shard,
+		opts:       opts,
+		h:          hashFn.New(),
+		partialSig: partialSig,
+	}, nil
+}
+
+// Write adds more of the message to the session. It only fails if the session has already signed
+func (s *SigningSession) Write(p []byte) (int, error) {
+	if s.done {
+		return 0, errorf(ErrClosed, "cannot write to a signing session after it has signed")
+	}
+	return s.h.Write(p)
+}
+
+// Partial signs the digest of everything written to the session and returns the resulting partial signature.
+// A session can only sign once, after which it can no longer be written to
+func (s *SigningSession) Partial() (*PartialSignature, error) {
+	if s.done {
+		return nil, errorf(ErrClosed, "signing session has already signed")
+	}
+	s.done = true
+
+	hashed := s.h.Sum(nil)
+	if s.partialSig == nil {
+		return SignFirst(s.random, s.shard, s.opts, hashed)
+	}
+	return SignNext(s.random, s.shard, s.opts, hashed, s.partialSig)
+}
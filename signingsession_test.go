@@ -0,0 +1,68 @@
+package keysplitting
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha512"
+	"io"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Signing sessions", func() {
+	priv, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	// a message large enough to be written in several chunks
+	message := bytes.Repeat([]byte("TEST MESSAGE "), 100000)
+	hashed := sha512.Sum512(message)
+
+	for _, splitBy := range []SplitBy{Multiplication, Addition} {
+		splitBy := splitBy
+
+		It("Produces a valid "+string(splitBy)+" split signature from a streamed message", func() {
+			shards, _ := SplitD(priv, 3, splitBy)
+
+			var partialSig *PartialSignature
+			for _, shard := range shards {
+				session, err := NewSigningSession(rand.Reader, shard, crypto.SHA512, partialSig)
+				Expect(err).To(BeNil())
+
+				_, err = io.Copy(session, bytes.NewReader(message))
+				Expect(err).To(BeNil())
+
+				partialSig, err = session.Partial()
+				Expect(err).To(BeNil())
+			}
+			Expect(rsa.VerifyPKCS1v15(&priv.PublicKey, crypto.SHA512, hashed[:], partialSig.Sig)).To(Succeed())
+		})
+	}
+
+	It("Only signs once", func() {
+		shards, _ := SplitD(priv, 2, Addition)
+		session, err := NewSigningSession(rand.Reader, shards[0], crypto.SHA512, nil)
+		Expect(err).To(BeNil())
+
+		_, err = session.Write(message)
+		Expect(err).To(BeNil())
+		_, err = session.Partial()
+		Expect(err).To(BeNil())
+
+		_, err = session.Partial()
+		Expect(err).NotTo(BeNil())
+		_, err = session.Write(message)
+		Expect(err).NotTo(BeNil())
+	})
+
+	It("Refuses a partial signature that doesn't match the session", func() {
+		shards, _ := SplitD(priv, 2, Addition)
+		partialSig, _ := SignFirst(rand.Reader, shards[0], crypto.SHA512, hashed[:])
+
+		_, err := NewSigningSession(rand.Reader, shards[1], crypto.SHA256, partialSig)
+		Expect(err).NotTo(BeNil())
+		_, err = NewSigningSession(rand.Reader, shards[0], crypto.SHA512, partialSig)
+		Expect(err).NotTo(BeNil())
+	})
+})
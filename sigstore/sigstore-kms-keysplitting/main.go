@@ -0,0 +1,20 @@
+/*
+sigstore-kms-keysplitting is a sigstore KMS plugin that signs with a split key. Installed on the PATH, it lets cosign and
+other sigstore clients sign with a key reference of the form keysplitting://<path to a sigstore.Config>, e.g.
+
+	cosign sign --key keysplitting:///etc/cosign/release-key.json registry.example.com/app@sha256:...
+*/
+package main
+
+import (
+	"context"
+	"os"
+
+	"github.com/bastionzero/keysplitting/sigstore"
+)
+
+func main() {
+	if err := sigstore.RunPlugin(context.Background(), os.Args[1:], os.Stdin, os.Stdout, sigstore.OpenConfig); err != nil {
+		os.Exit(1)
+	}
+}
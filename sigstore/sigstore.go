@@ -0,0 +1,335 @@
+/*
+Package sigstore exposes a split key to sigstore and cosign, so that container images and other artifacts can only be signed
+when several release managers cooperate.
+
+A [SignerVerifier] implements sigstore's kms.SignerVerifier, and so signature.SignerVerifier, for any crypto.Signer of a split
+key. [RegisterProvider] adds it to sigstore's KMS providers, so that a program built with sigstore resolves key references
+such as keysplitting:///etc/cosign/release-key.json, naming a [Config], with kms.Get:
+
+	sigstore.RegisterProvider(sigstore.OpenConfig)
+	sv, err := kms.Get(ctx, "keysplitting:///etc/cosign/release-key.json", crypto.SHA256)
+
+cosign itself signs with a split key through the sigstore-kms-keysplitting plugin binary, which [RunPlugin] implements:
+
+	cosign sign --key keysplitting:///etc/cosign/release-key.json registry.example.com/app@sha256:...
+
+Each release manager serves their shard with a shardservice.Server, which decides whether to sign
+*/
+package sigstore
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bastionzero/keysplitting"
+	"github.com/bastionzero/keysplitting/shardservice"
+	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/sigstore/sigstore/pkg/signature/kms"
+	"github.com/sigstore/sigstore/pkg/signature/options"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// the prefix of key references to split keys
+const keyReferencePrefix = "keysplitting://"
+
+// the hash functions that a SignerVerifier signs and verifies with
+var supportedHashFuncs = []crypto.Hash{crypto.SHA256, crypto.SHA384, crypto.SHA512}
+
+// A SignerVerifier signs and verifies artifacts with a split key through a [crypto.Signer] for it, such as a
+// keysplitting.RemoteSigner
+type SignerVerifier struct {
+	signer   crypto.Signer
+	pub      *rsa.PublicKey
+	opts     crypto.SignerOpts
+	verifier signature.Verifier
+}
+
+var _ kms.SignerVerifier = (*SignerVerifier)(nil)
+
+// New returns a SignerVerifier that signs with signer, which must have an RSA public key. opts is the hash function to sign
+// with using RSASSA-PKCS1-v1_5, or an *[rsa.PSSOptions] to sign with RSASSA-PSS
+func New(signer crypto.Signer, opts crypto.SignerOpts) (*SignerVerifier, error) {
+	pub, ok := signer.Public().(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: sigstore signer must have an RSA public key", keysplitting.ErrKeyMismatch)
+	}
+	if opts == nil || !opts.HashFunc().Available() {
+		return nil, fmt.Errorf("%w: unavailable hash function", keysplitting.ErrUnsupportedHash)
+	}
+
+	var verifier signature.Verifier
+	var err error
+	if _, ok := opts.(*rsa.PSSOptions); ok {
+		verifier, err = signature.LoadRSAPSSVerifier(pub, opts.HashFunc(), &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthAuto})
+	} else {
+		verifier, err = signature.LoadRSAPKCS1v15Verifier(pub, opts.HashFunc())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", keysplitting.ErrUnsupportedHash, err)
+	}
+	return &SignerVerifier{signer: signer, pub: pub, opts: opts, verifier: verifier}, nil
+}
+
+// PublicKey returns the public key of the split key. The options are ignored
+func (sv *SignerVerifier) PublicKey(...signature.PublicKeyOption) (crypto.PublicKey, error) {
+	return sv.pub, nil
+}
+
+// SignMessage returns the signature on the message read from message. It recognizes the options.WithDigest,
+// options.WithCryptoSignerOpts and options.WithRand options
+func (sv *SignerVerifier) SignMessage(message io.Reader, opts ...signature.SignOption) ([]byte, error) {
+	random := rand.Reader
+	for _, opt := range opts {
+		opt.ApplyRand(&random)
+	}
+	digest, hashFn, err := signature.ComputeDigestForSigning(message, sv.opts.HashFunc(), supportedHashFuncs, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	var signerOpts crypto.SignerOpts = hashFn
+	if pssOpts, ok := sv.opts.(*rsa.PSSOptions); ok {
+		signerOpts = &rsa.PSSOptions{SaltLength: pssOpts.SaltLength, Hash: hashFn}
+	}
+	return sv.signer.Sign(random, digest, signerOpts)
+}
+
+// VerifySignature checks the signature read from sig on the message read from message, recognizing the same options as
+// the verifiers in sigstore's signature package. It fails with keysplitting.ErrIncompleteSignature if the signature doesn't
+// verify
+func (sv *SignerVerifier) VerifySignature(sig, message io.Reader, opts ...signature.VerifyOption) error {
+	if err := sv.verifier.VerifySignature(sig, message, opts...); err != nil {
+		return fmt.Errorf("%w: %s", keysplitting.ErrIncompleteSignature, err)
+	}
+	return nil
+}
+
+// CreateKey implements kms.SignerVerifier, but always fails, since split keys are made with keysplitting.SplitD or a key
+// ceremony
+func (sv *SignerVerifier) CreateKey(context.Context, string) (crypto.PublicKey, error) {
+	return nil, fmt.Errorf("the keysplitting provider can't create keys, which must be split before they are used")
+}
+
+// CryptoSigner returns the signer of the split key, and the options it signs with
+func (sv *SignerVerifier) CryptoSigner(context.Context, func(error)) (crypto.Signer, crypto.SignerOpts, error) {
+	return sv.signer, sv.opts, nil
+}
+
+// SupportedAlgorithms returns the algorithm that the split key signs with, as named by sigstore
+func (sv *SignerVerifier) SupportedAlgorithms() []string {
+	return []string{sv.DefaultAlgorithm()}
+}
+
+// DefaultAlgorithm returns the algorithm that the split key signs with, as named by sigstore, such as "rsa-sign-pkcs1-sha256"
+func (sv *SignerVerifier) DefaultAlgorithm() string {
+	scheme := "pkcs1"
+	if _, ok := sv.opts.(*rsa.PSSOptions); ok {
+		scheme = "pss"
+	}
+	return fmt.Sprintf("rsa-sign-%s-%s", scheme, strings.ToLower(strings.ReplaceAll(sv.opts.HashFunc().String(), "-", "")))
+}
+
+// Config describes a split key for the plugin: its public key, which pins the key that the shards must sign for, and the
+// gRPC targets of the shard holders, in the order they sign, which are dialed with TLS
+type Config struct {
+	PublicKey string   `json:"public_key"` // PEM "PUBLIC KEY" block
+	Shards    []string `json:"shards"`     // e.g. "release-manager-1.example.com:8443"
+}
+
+// OpenConfig returns a keysplitting.RemoteSigner for the split key described by the [Config] in the file at path
+func OpenConfig(ctx context.Context, path string) (crypto.Signer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("malformed split key config: %w", err)
+	}
+
+	block, _ := pem.Decode([]byte(config.PublicKey))
+	if block == nil || block.Type != "PUBLIC KEY" {
+		return nil, fmt.Errorf("split key config has no PEM public key")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("malformed public key: %w", err)
+	}
+	pub, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: split key config has a non-RSA public key", keysplitting.ErrKeyMismatch)
+	}
+
+	shards := make([]keysplitting.RemoteShard, len(config.Shards))
+	for i, target := range config.Shards {
+		// connections are made lazily, and last as long as the plugin's process
+		conn, err := grpc.DialContext(ctx, target, grpc.WithTransportCredentials(credentials.NewTLS(nil)))
+		if err != nil {
+			return nil, err
+		}
+		shards[i] = shardservice.NewClient(conn)
+	}
+	return keysplitting.NewRemoteSigner(pub, shards...)
+}
+
+// An Opener returns a crypto.Signer for the split key named by a key resource ID, the part of a key reference after
+// "keysplitting://". [OpenConfig] is one
+type Opener func(ctx context.Context, keyResourceID string) (crypto.Signer, error)
+
+// RegisterProvider adds the split keys opened by open to sigstore's KMS providers, as kms.AddProvider does for sigstore's own
+// providers, so that kms.Get returns a [SignerVerifier] for key references that start with "keysplitting://"
+func RegisterProvider(open Opener) {
+	kms.AddProvider(keyReferencePrefix, func(ctx context.Context, keyResourceID string, hashFn crypto.Hash, _ ...signature.RPCOption) (kms.SignerVerifier, error) {
+		return openSignerVerifier(ctx, open, keyResourceID, hashFn)
+	})
+}
+
+// opens the split key named by a key reference, signing with RSASSA-PKCS1-v1_5 and hashFn, or SHA-256 if it is zero
+func openSignerVerifier(ctx context.Context, open Opener, keyResourceID string, hashFn crypto.Hash) (*SignerVerifier, error) {
+	if hashFn == 0 {
+		hashFn = crypto.SHA256
+	}
+	signer, err := open(ctx, strings.TrimPrefix(keyResourceID, keyReferencePrefix))
+	if err != nil {
+		return nil, err
+	}
+	return New(signer, hashFn)
+}
+
+// the version of sigstore's KMS plugin protocol that RunPlugin speaks
+const pluginProtocolVersion = "v1"
+
+// used exclusively as a placeholder for encoding-decoding
+type pluginArgs struct {
+	MethodName  string `json:"methodName"`
+	InitOptions struct {
+		CtxDeadline     *time.Time  `json:"ctxDeadline,omitempty"`
+		ProtocolVersion string      `json:"protocolVersion"`
+		KeyResourceID   string      `json:"keyResourceID"`
+		HashFunc        crypto.Hash `json:"hashFunc"`
+	} `json:"initOptions"`
+	VerifySignature *pluginSignature `json:"verifySignature,omitempty"`
+}
+
+// used exclusively as a placeholder for encoding-decoding
+type pluginResp struct {
+	ErrorMessage        string                     `json:"errorMessage,omitempty"`
+	DefaultAlgorithm    *pluginDefaultAlgorithm    `json:"defaultAlgorithm,omitempty"`
+	SupportedAlgorithms *pluginSupportedAlgorithms `json:"supportedAlgorithms,omitempty"`
+	PublicKey           *pluginPublicKey           `json:"publicKey,omitempty"`
+	SignMessage         *pluginSignature           `json:"signMessage,omitempty"`
+	VerifySignature     *struct{}                  `json:"verifySignature,omitempty"`
+}
+
+// used exclusively as a placeholder for encoding-decoding
+type pluginDefaultAlgorithm struct {
+	DefaultAlgorithm string `json:"defaultAlgorithm"`
+}
+
+// used exclusively as a placeholder for encoding-decoding
+type pluginSupportedAlgorithms struct {
+	SupportedAlgorithms []string `json:"supportedAlgorithms"`
+}
+
+// used exclusively as a placeholder for encoding-decoding
+type pluginPublicKey struct {
+	PublicKeyPEM []byte `json:"publicKeyPEM"`
+}
+
+// used exclusively as a placeholder for encoding-decoding
+type pluginSignature struct {
+	Signature []byte `json:"signature"`
+}
+
+// RunPlugin handles a single invocation of a sigstore KMS plugin, as sigstore's cliplugin package makes it. That package,
+// which also has the handler that plugins usually dispatch with, needs a newer Go than this module, so RunPlugin decodes the
+// protocol's JSON itself and dispatches each method to a [SignerVerifier]. args are the
+// command line arguments after the program name, i.e. the protocol version and the JSON method arguments, stdin holds the
+// message for the signMessage and verifySignature methods, and the JSON response is written to stdout. open is called with
+// the key resource ID to get a signer for the split key. Creating keys is not supported, since split keys are made with
+// keysplitting.SplitD or a key ceremony. A failed method is reported in the response as well as returned
+func RunPlugin(ctx context.Context, args []string, stdin io.Reader, stdout io.Writer, open Opener) error {
+	var resp pluginResp
+	err := runPluginMethod(ctx, args, stdin, open, &resp)
+	if err != nil {
+		resp = pluginResp{ErrorMessage: err.Error()}
+	}
+	if encodeErr := json.NewEncoder(stdout).Encode(resp); encodeErr != nil && err == nil {
+		err = encodeErr
+	}
+	return err
+}
+
+func runPluginMethod(ctx context.Context, args []string, stdin io.Reader, open Opener, resp *pluginResp) error {
+	if len(args) != 2 {
+		return fmt.Errorf("expected the protocol version and method arguments, got %d arguments", len(args))
+	}
+	if args[0] != pluginProtocolVersion {
+		return fmt.Errorf("unsupported plugin protocol version %q", args[0])
+	}
+	var pa pluginArgs
+	if err := json.Unmarshal([]byte(args[1]), &pa); err != nil {
+		return fmt.Errorf("malformed plugin arguments: %w", err)
+	}
+	if pa.InitOptions.CtxDeadline != nil {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, *pa.InitOptions.CtxDeadline)
+		defer cancel()
+	}
+
+	switch pa.MethodName {
+	case "defaultAlgorithm", "supportedAlgorithms", "publicKey", "signMessage", "verifySignature":
+	case "createKey":
+		return fmt.Errorf("the keysplitting plugin can't create keys, which must be split before they are used")
+	default:
+		return fmt.Errorf("unsupported plugin method %q", pa.MethodName)
+	}
+	sv, err := openSignerVerifier(ctx, open, pa.InitOptions.KeyResourceID, pa.InitOptions.HashFunc)
+	if err != nil {
+		return err
+	}
+
+	switch pa.MethodName {
+	case "defaultAlgorithm":
+		resp.DefaultAlgorithm = &pluginDefaultAlgorithm{DefaultAlgorithm: sv.DefaultAlgorithm()}
+	case "supportedAlgorithms":
+		resp.SupportedAlgorithms = &pluginSupportedAlgorithms{SupportedAlgorithms: sv.SupportedAlgorithms()}
+	case "publicKey":
+		pub, err := sv.PublicKey(options.WithContext(ctx))
+		if err != nil {
+			return err
+		}
+		der, err := x509.MarshalPKIXPublicKey(pub)
+		if err != nil {
+			return err
+		}
+		resp.PublicKey = &pluginPublicKey{PublicKeyPEM: pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})}
+	case "signMessage":
+		sig, err := sv.SignMessage(stdin, options.WithContext(ctx))
+		if err != nil {
+			return err
+		}
+		resp.SignMessage = &pluginSignature{Signature: sig}
+	case "verifySignature":
+		if pa.VerifySignature == nil {
+			return fmt.Errorf("verifySignature requires a signature")
+		}
+		if err := sv.VerifySignature(bytes.NewReader(pa.VerifySignature.Signature), stdin, options.WithContext(ctx)); err != nil {
+			return err
+		}
+		resp.VerifySignature = &struct{}{}
+	}
+	return nil
+}
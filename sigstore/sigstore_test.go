@@ -0,0 +1,188 @@
+package sigstore
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bastionzero/keysplitting"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/sigstore/sigstore/pkg/signature/kms"
+	"github.com/sigstore/sigstore/pkg/signature/options"
+)
+
+func TestSigstore(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Sigstore Suite")
+}
+
+var _ = Describe("Sigstore", func() {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	shards, _ := keysplitting.SplitD(key, 2, keysplitting.Addition)
+	remotes := make([]keysplitting.RemoteShard, len(shards))
+	for i, shard := range shards {
+		remotes[i], _ = keysplitting.NewLocalShard(shard)
+	}
+	remoteSigner, _ := keysplitting.NewRemoteSigner(&key.PublicKey, remotes...)
+	artifact := []byte("sha256:0123456789abcdef")
+	ctx := context.Background()
+
+	It("Signs and verifies with PKCS #1 v1.5 and PSS", func() {
+		for _, opts := range []crypto.SignerOpts{crypto.SHA256, &rsa.PSSOptions{Hash: crypto.SHA384}} {
+			sv, err := New(remoteSigner, opts)
+			Expect(err).To(BeNil())
+			pub, err := sv.PublicKey()
+			Expect(err).To(BeNil())
+			Expect(pub).To(Equal(&key.PublicKey))
+
+			sig, err := sv.SignMessage(bytes.NewReader(artifact))
+			Expect(err).To(BeNil())
+			Expect(sv.VerifySignature(bytes.NewReader(sig), bytes.NewReader(artifact))).To(Succeed())
+
+			err = sv.VerifySignature(bytes.NewReader(sig), strings.NewReader("sha256:tampered"))
+			Expect(errors.Is(err, keysplitting.ErrIncompleteSignature)).To(BeTrue())
+		}
+
+		sv, err := New(remoteSigner, crypto.SHA256)
+		Expect(err).To(BeNil())
+		sig, err := sv.SignMessage(bytes.NewReader(artifact))
+		Expect(err).To(BeNil())
+		hashed := sha256.Sum256(artifact)
+		Expect(rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, hashed[:], sig)).To(Succeed())
+
+		// a digest computed elsewhere, as cosign passes for an image
+		sig, err = sv.SignMessage(nil, options.WithDigest(hashed[:]))
+		Expect(err).To(BeNil())
+		Expect(rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, hashed[:], sig)).To(Succeed())
+		Expect(sv.VerifySignature(bytes.NewReader(sig), nil, options.WithDigest(hashed[:]))).To(Succeed())
+	})
+
+	It("Is a sigstore KMS provider", func() {
+		RegisterProvider(func(ctx context.Context, keyResourceID string) (crypto.Signer, error) {
+			Expect(keyResourceID).To(Equal("/etc/cosign/release-key.json"))
+			return remoteSigner, nil
+		})
+		sv, err := kms.Get(ctx, "keysplitting:///etc/cosign/release-key.json", crypto.SHA384)
+		Expect(err).To(BeNil())
+		Expect(sv.DefaultAlgorithm()).To(Equal("rsa-sign-pkcs1-sha384"))
+		Expect(sv.SupportedAlgorithms()).To(Equal([]string{"rsa-sign-pkcs1-sha384"}))
+		_, err = sv.CreateKey(ctx, sv.DefaultAlgorithm())
+		Expect(err).NotTo(BeNil())
+
+		signer, opts, err := sv.CryptoSigner(ctx, nil)
+		Expect(err).To(BeNil())
+		Expect(signer).To(Equal(remoteSigner))
+		Expect(opts).To(Equal(crypto.SHA384))
+
+		sig, err := sv.SignMessage(bytes.NewReader(artifact))
+		Expect(err).To(BeNil())
+		verifier, err := signature.LoadRSAPKCS1v15Verifier(&key.PublicKey, crypto.SHA384)
+		Expect(err).To(BeNil())
+		Expect(verifier.VerifySignature(bytes.NewReader(sig), bytes.NewReader(artifact))).To(Succeed())
+	})
+
+	// runs the plugin with the given method arguments, and returns its response
+	run := func(args map[string]interface{}, stdin []byte) (pluginResp, error) {
+		encoded, err := json.Marshal(args)
+		Expect(err).To(BeNil())
+		var stdout bytes.Buffer
+		var opened string
+		open := func(ctx context.Context, keyResourceID string) (crypto.Signer, error) {
+			opened = keyResourceID
+			return remoteSigner, nil
+		}
+		runErr := RunPlugin(ctx, []string{"v1", string(encoded)}, bytes.NewReader(stdin), &stdout, open)
+
+		var resp pluginResp
+		Expect(json.Unmarshal(stdout.Bytes(), &resp)).To(Succeed())
+		if opened != "" {
+			Expect(opened).To(Equal("/etc/cosign/release-key.json"))
+		}
+		return resp, runErr
+	}
+	initOptions := map[string]interface{}{
+		"protocolVersion": "v1",
+		"keyResourceID":   "keysplitting:///etc/cosign/release-key.json",
+		"hashFunc":        crypto.SHA256,
+	}
+
+	It("Speaks the KMS plugin protocol", func() {
+		resp, err := run(map[string]interface{}{"methodName": "publicKey", "initOptions": initOptions}, nil)
+		Expect(err).To(BeNil())
+		block, _ := pem.Decode(resp.PublicKey.PublicKeyPEM)
+		Expect(block).ToNot(BeNil())
+		pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+		Expect(err).To(BeNil())
+		Expect(pub).To(Equal(&key.PublicKey))
+
+		resp, err = run(map[string]interface{}{"methodName": "signMessage", "initOptions": initOptions}, artifact)
+		Expect(err).To(BeNil())
+		Expect(resp.ErrorMessage).To(BeEmpty())
+		sig := resp.SignMessage.Signature
+		hashed := sha256.Sum256(artifact)
+		Expect(rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, hashed[:], sig)).To(Succeed())
+
+		resp, err = run(map[string]interface{}{
+			"methodName":      "verifySignature",
+			"initOptions":     initOptions,
+			"verifySignature": map[string]interface{}{"signature": sig},
+		}, artifact)
+		Expect(err).To(BeNil())
+		Expect(resp.VerifySignature).ToNot(BeNil())
+
+		resp, err = run(map[string]interface{}{"methodName": "defaultAlgorithm", "initOptions": initOptions}, nil)
+		Expect(err).To(BeNil())
+		Expect(resp.DefaultAlgorithm.DefaultAlgorithm).To(Equal("rsa-sign-pkcs1-sha256"))
+	})
+
+	It("Reports failures in the response", func() {
+		resp, err := run(map[string]interface{}{
+			"methodName":      "verifySignature",
+			"initOptions":     initOptions,
+			"verifySignature": map[string]interface{}{"signature": []byte("forged")},
+		}, artifact)
+		Expect(errors.Is(err, keysplitting.ErrIncompleteSignature)).To(BeTrue())
+		Expect(resp.ErrorMessage).To(Equal(err.Error()))
+
+		resp, err = run(map[string]interface{}{"methodName": "createKey", "initOptions": initOptions}, nil)
+		Expect(err).ToNot(BeNil())
+		Expect(resp.ErrorMessage).ToNot(BeEmpty())
+
+		var stdout bytes.Buffer
+		Expect(RunPlugin(ctx, []string{"v2", "{}"}, nil, &stdout, nil)).ToNot(Succeed())
+		Expect(stdout.String()).To(ContainSubstring("unsupported plugin protocol version"))
+	})
+
+	It("Opens a split key from its config", func() {
+		der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+		Expect(err).To(BeNil())
+		config, err := json.Marshal(Config{
+			PublicKey: string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})),
+			Shards:    []string{"release-manager-1.example.com:8443", "release-manager-2.example.com:8443"},
+		})
+		Expect(err).To(BeNil())
+		path := filepath.Join(GinkgoT().TempDir(), "release-key.json")
+		Expect(os.WriteFile(path, config, 0600)).To(Succeed())
+
+		signer, err := OpenConfig(ctx, path)
+		Expect(err).To(BeNil())
+		Expect(signer.Public()).To(Equal(&key.PublicKey))
+
+		Expect(os.WriteFile(path, []byte(`{"public_key": "", "shards": []}`), 0600)).To(Succeed())
+		_, err = OpenConfig(ctx, path)
+		Expect(err).ToNot(BeNil())
+	})
+})
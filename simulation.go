@@ -0,0 +1,54 @@
+//go:build keysplitting_simulation
+
+package keysplitting
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"io"
+	"math/big"
+)
+
+// Simulation reports whether the package was built with the keysplitting_simulation build tag. Simulation builds accept
+// keys of as few as 512 bits, and provide [GenerateSimulationKey], so that integration tests can split and sign in
+// milliseconds. Keys that small offer no security, so the tag must never be used for production builds, which don't even
+// compile code that calls GenerateSimulationKey
+const Simulation = true
+
+// the smallest modulus, in bits, that Validate accepts
+const minModulusBits = 512
+
+// GenerateSimulationKey generates an insecure RSA key of the given size, which must be at least 512 bits, for use in tests.
+// It is only available in simulation builds (see [Simulation]). Note that modules whose go.mod declares Go 1.24 or later
+// must also run their tests with GODEBUG=rsa1024min=0 for crypto/rsa to verify signatures by keys smaller than 1024 bits
+func GenerateSimulationKey(random io.Reader, bits int) (*rsa.PrivateKey, error) {
+	if bits < minModulusBits || bits > maxModulusBits {
+		return nil, errorf(ErrInvalidShard, "modulus of %d bits is out of range [%d, %d]", bits, minModulusBits, maxModulusBits)
+	}
+	e := big.NewInt(65537)
+	for {
+		p, err := rand.Prime(random, bits-bits/2)
+		if err != nil {
+			return nil, &RandomnessError{Err: err}
+		}
+		q, err := rand.Prime(random, bits/2)
+		if err != nil {
+			return nil, &RandomnessError{Err: err}
+		}
+		if p.Cmp(q) == 0 {
+			continue
+		}
+		priv := &rsa.PrivateKey{
+			PublicKey: rsa.PublicKey{N: new(big.Int).Mul(p, q), E: int(e.Int64())},
+			Primes:    []*big.Int{p, q},
+		}
+		if priv.N.BitLen() != bits {
+			continue
+		}
+		if priv.D = new(big.Int).ModInverse(e, eulerTotient(priv.Primes)); priv.D == nil {
+			continue
+		}
+		priv.Precompute()
+		return priv, nil
+	}
+}
@@ -0,0 +1,49 @@
+//go:build keysplitting_simulation
+
+package keysplitting
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Simulation", func() {
+	hashed := sha256.Sum256([]byte("TEST MESSAGE"))
+
+	It("Splits and signs with small keys", func() {
+		Expect(Simulation).To(BeTrue())
+		priv, err := GenerateSimulationKey(rand.Reader, 512)
+		Expect(err).To(BeNil())
+		Expect(priv.N.BitLen()).To(Equal(512))
+		Expect(priv.Validate()).To(Succeed())
+
+		for _, splitBy := range []SplitBy{Multiplication, Addition} {
+			shards, err := SplitD(priv, 3, splitBy)
+			Expect(err).To(BeNil())
+
+			partialSig, err := SignFirst(rand.Reader, shards[0], crypto.SHA256, hashed[:])
+			Expect(err).To(BeNil())
+			for _, shard := range shards[1:] {
+				partialSig, err = SignNext(rand.Reader, shard, crypto.SHA256, hashed[:], partialSig)
+				Expect(err).To(BeNil())
+			}
+			Expect(VerifyFinal(&priv.PublicKey, crypto.SHA256, hashed[:], partialSig.Sig, splitBy)).To(Succeed())
+
+			encoded, err := shards[0].EncodePEM()
+			Expect(err).To(BeNil())
+			decoded, err := DecodePEM(encoded)
+			Expect(err).To(BeNil())
+			Expect(decoded.Equal(shards[0])).To(BeTrue())
+		}
+	})
+
+	It("Refuses keys smaller than 512 bits", func() {
+		_, err := GenerateSimulationKey(rand.Reader, 256)
+		Expect(errors.Is(err, ErrInvalidShard)).To(BeTrue())
+	})
+})
@@ -0,0 +1,75 @@
+package keysplitting
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io"
+)
+
+// SplitPEM parses an RSA private key from a PKCS #1 ("RSA PRIVATE KEY") or PKCS #8 ("PRIVATE KEY") PEM block, splits it
+// into k shards as [SplitDWithOptions] would, and returns each shard encoded by [PrivateKeyShard.EncodePEM]. The parsed key
+// and the shards are zeroized before it returns, so that the only copies of the key's secrets are the caller's keyPEM and
+// the returned encodings
+func SplitPEM(keyPEM []byte, k int, splitBy SplitBy, opts *SplitOptions) ([]string, error) {
+	block, rest := pem.Decode(keyPEM)
+	if block == nil || len(rest) > 0 {
+		return nil, errorf(ErrInvalidKey, "failed to decode PEM block containing private key")
+	}
+
+	priv, err := parseRSAPrivateKey(block)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroizePrivateKey(priv)
+
+	shards, err := SplitDWithOptions(priv, k, splitBy, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	encoded := make([]string, len(shards))
+	for i, shard := range shards {
+		defer shard.Zeroize()
+		if encoded[i], err = shard.EncodePEM(); err != nil {
+			return nil, err
+		}
+	}
+	return encoded, nil
+}
+
+// SplitPEMFromReader is like [SplitPEM] but reads the PEM-encoded key from r
+func SplitPEMFromReader(r io.Reader, k int, splitBy SplitBy, opts *SplitOptions) ([]string, error) {
+	keyPEM, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private key: %w", err)
+	}
+	defer zeroizeBytes(keyPEM)
+
+	return SplitPEM(keyPEM, k, splitBy, opts)
+}
+
+// parses an RSA private key from a PKCS #1 or PKCS #8 PEM block
+func parseRSAPrivateKey(block *pem.Block) (*rsa.PrivateKey, error) {
+	switch block.Type {
+	case pkcs1PEMType:
+		priv, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, errorf(ErrInvalidKey, "failed to parse PKCS #1 private key: %s", err)
+		}
+		return priv, nil
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, errorf(ErrInvalidKey, "failed to parse PKCS #8 private key: %s", err)
+		}
+		priv, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, errorf(ErrInvalidKey, "PKCS #8 private key is a %T rather than an RSA key", key)
+		}
+		return priv, nil
+	default:
+		return nil, errorf(ErrInvalidKey, "unsupported PEM block type %q", block.Type)
+	}
+}
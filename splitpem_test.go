@@ -0,0 +1,82 @@
+package keysplitting
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SplitPEM", func() {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	hashed := sha512.Sum512([]byte("TEST MESSAGE"))
+
+	pkcs1 := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	pkcs8DER, _ := x509.MarshalPKCS8PrivateKey(key)
+	pkcs8 := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8DER})
+
+	for _, tc := range []struct {
+		format string
+		pem    []byte
+	}{
+		{"PKCS #1", pkcs1},
+		{"PKCS #8", pkcs8},
+	} {
+		tc := tc
+		It(fmt.Sprintf("Splits a %s key into working shards", tc.format), func() {
+			encoded, err := SplitPEM(tc.pem, 3, Addition, nil)
+			Expect(err).To(BeNil())
+			Expect(encoded).To(HaveLen(3))
+
+			shards := make([]*PrivateKeyShard, len(encoded))
+			for i := range encoded {
+				shards[i], err = DecodePEM(encoded[i])
+				Expect(err).To(BeNil())
+			}
+
+			sig := signBrokered(&key.PublicKey, shards, hashed[:])
+			Expect(rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA512, hashed[:], sig)).To(Succeed())
+		})
+	}
+
+	It("Reads the key from an io.Reader", func() {
+		encoded, err := SplitPEMFromReader(bytes.NewReader(pkcs1), 2, Multiplication, nil)
+		Expect(err).To(BeNil())
+		Expect(encoded).To(HaveLen(2))
+
+		shard, err := DecodePEM(encoded[0])
+		Expect(err).To(BeNil())
+		Expect(shard.PublicKey.Equal(&key.PublicKey)).To(BeTrue())
+		Expect(shard.SplitBy).To(Equal(Multiplication))
+	})
+
+	It("Rejects other kinds of keys and malformed input", func() {
+		ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		ecDER, _ := x509.MarshalPKCS8PrivateKey(ecKey)
+
+		for _, input := range [][]byte{
+			pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: ecDER}),
+			pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: pkcs8DER}),
+			pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: pkcs8DER}),
+			append(append([]byte{}, pkcs1...), pkcs8...),
+			[]byte("not PEM"),
+		} {
+			_, err := SplitPEM(input, 2, Addition, nil)
+			Expect(err).NotTo(BeNil(), string(input))
+		}
+	})
+
+	It("Passes errors from the split through", func() {
+		_, err := SplitPEM(pkcs1, 1, Addition, nil)
+		Expect(err).To(MatchError(ErrTooFewShards))
+	})
+})
@@ -0,0 +1,246 @@
+package keysplitting
+
+import (
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// SSH_AGENT_SUCCESS (draft-miller-ssh-agent), which golang.org/x/crypto/ssh/agent doesn't export
+const sshAgentSuccess = 6
+
+// the names of the agent extensions used to hold shards. Extension names must be of the form name@domain
+const (
+	sshAgentAddShardExtension     = "keysplitting-add-shard@bastionzero.com"
+	sshAgentRemoveShardExtension  = "keysplitting-remove-shard@bastionzero.com"
+	sshAgentExponentiateExtension = "keysplitting-exponentiate@bastionzero.com"
+)
+
+// the contents of the extension requests and of the exponentiation's reply, in SSH wire format
+type sshAgentAddShardRequest struct {
+	DER []byte
+}
+
+type sshAgentRemoveShardRequest struct {
+	Fingerprint string
+}
+
+type sshAgentExponentiateRequest struct {
+	Fingerprint string
+	M           []byte
+}
+
+type sshAgentExponentiateReply struct {
+	C []byte `sshtype:"6"`
+}
+
+// ErrSSHAgentExtensionUnsupported is golang.org/x/crypto/ssh/agent's ErrExtensionUnsupported, which an [SSHAgentShard] returns
+// if the agent doesn't support this package's extensions
+var ErrSSHAgentExtensionUnsupported = agent.ErrExtensionUnsupported
+
+// An SSHAgentShard is a [ShardSigner] whose shard is held by an ssh-agent, so that on a developer's machine the shard can live
+// in the agent's memory rather than the application's. It talks to the agent through golang.org/x/crypto/ssh/agent, and
+// the agent must support this package's agent extensions, as one serving an [SSHAgentShardKeyring] does. OpenSSH's own agent
+// doesn't support them.
+//
+// The agent is usually a client for the agent's socket:
+//
+//	conn, err := net.Dial("unix", os.Getenv("SSH_AUTH_SOCK"))
+//	...
+//	signer, err := keysplitting.AddShardToSSHAgent(agent.NewClient(conn), shard)
+type SSHAgentShard struct {
+	exponentiationSigner
+
+	agent agent.ExtendedAgent
+}
+
+// AddShardToSSHAgent hands the shard to the agent, and returns an SSHAgentShard that signs with it. The caller should
+// [PrivateKeyShard.Zeroize] the shard once it has been added. Masked shards can't be added, since the agent only
+// exponentiates with a fixed exponent
+func AddShardToSSHAgent(client agent.ExtendedAgent, shard *PrivateKeyShard) (*SSHAgentShard, error) {
+	if err := shard.Validate(); err != nil {
+		return nil, err
+	}
+	if shard.Mask != nil {
+		return nil, errorf(ErrInvalidShard, "masked shards can't be held by an ssh-agent")
+	}
+
+	der, err := shard.marshalDER()
+	if err != nil {
+		return nil, err
+	}
+	defer zeroizeBytes(der)
+
+	s := NewSSHAgentShard(client, shard.PublicKey, shard.SplitBy, shard.Index)
+	req := ssh.Marshal(&sshAgentAddShardRequest{DER: der})
+	defer zeroizeBytes(req)
+	if _, err := s.call(sshAgentAddShardExtension, req); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// NewSSHAgentShard returns an SSHAgentShard that signs with a shard previously added to the agent by [AddShardToSSHAgent].
+// The agent identifies the shard by its [PrivateKeyShard.Fingerprint], which depends only on the given public information
+func NewSSHAgentShard(client agent.ExtendedAgent, pub *rsa.PublicKey, splitBy SplitBy, index int) *SSHAgentShard {
+	s := &SSHAgentShard{agent: client}
+	s.shard = &PrivateKeyShard{PublicKey: pub, SplitBy: splitBy, Index: index}
+	s.exp = s.exponentiate
+	return s
+}
+
+// Remove deletes the shard from the agent
+func (s *SSHAgentShard) Remove() error {
+	_, err := s.call(sshAgentRemoveShardExtension, ssh.Marshal(&sshAgentRemoveShardRequest{Fingerprint: s.shard.Fingerprint()}))
+	return err
+}
+
+// returns m^D (mod N), computed by the agent
+func (s *SSHAgentShard) exponentiate(m *big.Int) (*big.Int, error) {
+	pub := s.shard.PublicKey
+	req := &sshAgentExponentiateRequest{Fingerprint: s.shard.Fingerprint(), M: m.FillBytes(make([]byte, pub.Size()))}
+	reply, err := s.call(sshAgentExponentiateExtension, ssh.Marshal(req))
+	if err != nil {
+		return nil, err
+	}
+
+	var msg sshAgentExponentiateReply
+	if err := ssh.Unmarshal(reply, &msg); err != nil || len(msg.C) != pub.Size() {
+		return nil, fmt.Errorf("ssh-agent returned a malformed exponentiation")
+	}
+	result := new(big.Int).SetBytes(msg.C)
+	if result.Cmp(pub.N) >= 0 {
+		return nil, fmt.Errorf("ssh-agent returned a malformed exponentiation")
+	}
+	return result, nil
+}
+
+// sends an extension request and returns the whole reply, which must be SSH_AGENT_SUCCESS or an extension of it
+func (s *SSHAgentShard) call(extension string, contents []byte) ([]byte, error) {
+	reply, err := s.agent.Extension(extension, contents)
+	if errors.Is(err, agent.ErrExtensionUnsupported) {
+		return nil, err
+	}
+	if err != nil || len(reply) == 0 || reply[0] != sshAgentSuccess {
+		return nil, fmt.Errorf("ssh-agent failed to perform %s", extension)
+	}
+	return reply, nil
+}
+
+// An SSHAgentShardKeyring is an ssh-agent that holds shards for [SSHAgentShard] as well as ordinary keys. It implements
+// golang.org/x/crypto/ssh/agent.ExtendedAgent, so it is served with agent.ServeAgent: this package's extensions are handled
+// by the keyring itself, and everything else by the agent it wraps. It is safe for concurrent use
+type SSHAgentShardKeyring struct {
+	agent.Agent
+
+	mu     sync.Mutex
+	shards map[string]*PrivateKeyShard
+}
+
+var _ agent.ExtendedAgent = (*SSHAgentShardKeyring)(nil)
+
+// NewSSHAgentShardKeyring returns a keyring with no shards that passes other requests on to keys, or to an empty
+// agent.NewKeyring if keys is nil
+func NewSSHAgentShardKeyring(keys agent.Agent) *SSHAgentShardKeyring {
+	if keys == nil {
+		keys = agent.NewKeyring()
+	}
+	return &SSHAgentShardKeyring{Agent: keys, shards: map[string]*PrivateKeyShard{}}
+}
+
+// SignWithFlags implements agent.ExtendedAgent by passing the request on to the wrapped agent
+func (k *SSHAgentShardKeyring) SignWithFlags(key ssh.PublicKey, data []byte, flags agent.SignatureFlags) (*ssh.Signature, error) {
+	if extended, ok := k.Agent.(agent.ExtendedAgent); ok {
+		return extended.SignWithFlags(key, data, flags)
+	}
+	if flags != 0 {
+		return nil, fmt.Errorf("agent: signature flags are not supported")
+	}
+	return k.Agent.Sign(key, data)
+}
+
+// Extension implements agent.ExtendedAgent, handling this package's extensions and passing any other on to the wrapped
+// agent, if it supports extensions
+func (k *SSHAgentShardKeyring) Extension(extensionType string, contents []byte) ([]byte, error) {
+	switch extensionType {
+	case sshAgentAddShardExtension:
+		var req sshAgentAddShardRequest
+		if err := ssh.Unmarshal(contents, &req); err != nil {
+			return nil, errorf(ErrInvalidShard, "malformed %s request", extensionType)
+		}
+		shard, err := unmarshalDER(req.DER)
+		if err != nil {
+			return nil, err
+		}
+		if shard.Mask != nil {
+			return nil, errorf(ErrInvalidShard, "masked shards can't be held by an ssh-agent")
+		}
+
+		k.mu.Lock()
+		defer k.mu.Unlock()
+		fingerprint := shard.Fingerprint()
+		if old, ok := k.shards[fingerprint]; ok {
+			old.Zeroize()
+		}
+		k.shards[fingerprint] = shard
+		return []byte{sshAgentSuccess}, nil
+	case sshAgentRemoveShardExtension:
+		var req sshAgentRemoveShardRequest
+		if err := ssh.Unmarshal(contents, &req); err != nil {
+			return nil, errorf(ErrInvalidShard, "malformed %s request", extensionType)
+		}
+
+		k.mu.Lock()
+		defer k.mu.Unlock()
+		shard, ok := k.shards[req.Fingerprint]
+		if !ok {
+			return nil, errorf(ErrKeyMismatch, "no such shard")
+		}
+		shard.Zeroize()
+		delete(k.shards, req.Fingerprint)
+		return []byte{sshAgentSuccess}, nil
+	case sshAgentExponentiateExtension:
+		var req sshAgentExponentiateRequest
+		if err := ssh.Unmarshal(contents, &req); err != nil {
+			return nil, errorf(ErrInvalidPartialSignature, "malformed %s request", extensionType)
+		}
+
+		k.mu.Lock()
+		defer k.mu.Unlock()
+		shard, ok := k.shards[req.Fingerprint]
+		if !ok {
+			return nil, errorf(ErrKeyMismatch, "no such shard")
+		}
+		m := new(big.Int).SetBytes(req.M)
+		if len(req.M) != shard.PublicKey.Size() || m.Cmp(shard.PublicKey.N) >= 0 {
+			return nil, errorf(ErrInvalidPartialSignature, "input is out of range for the shard's public key")
+		}
+		// a negative additive shard inverts its input, which fails if the input shares a factor with N
+		c := new(big.Int).Exp(m, shard.D, shard.PublicKey.N)
+		if c == nil {
+			return nil, errorf(ErrInvalidPartialSignature, "input is not invertible")
+		}
+		return ssh.Marshal(&sshAgentExponentiateReply{C: c.FillBytes(make([]byte, shard.PublicKey.Size()))}), nil
+	default:
+		if extended, ok := k.Agent.(agent.ExtendedAgent); ok {
+			return extended.Extension(extensionType, contents)
+		}
+		return nil, agent.ErrExtensionUnsupported
+	}
+}
+
+// Close zeroizes every shard in the keyring and empties it. It doesn't affect the wrapped agent
+func (k *SSHAgentShardKeyring) Close() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	for fingerprint, shard := range k.shards {
+		shard.Zeroize()
+		delete(k.shards, fingerprint)
+	}
+}
@@ -0,0 +1,114 @@
+package keysplitting
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"net"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+var _ = Describe("ssh-agent shards", func() {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	hashed := sha256.Sum256([]byte("TEST MESSAGE"))
+
+	// returns a client of an agent serving keys
+	dialAgent := func(keys agent.Agent) agent.ExtendedAgent {
+		client, server := net.Pipe()
+		go func() {
+			defer server.Close()
+			_ = agent.ServeAgent(keys, server)
+		}()
+		DeferCleanup(client.Close)
+		return agent.NewClient(client)
+	}
+
+	It("Signs with shards held by the agent", func() {
+		keyring := NewSSHAgentShardKeyring(nil)
+		client := dialAgent(keyring)
+		shards, err := SplitD(key, 3, Addition)
+		Expect(err).To(BeNil())
+
+		partials := make([]*PartialSignature, len(shards))
+		for i, shard := range shards {
+			signer, err := AddShardToSSHAgent(client, shard)
+			Expect(err).To(BeNil())
+			partials[i], err = signer.SignFirst(rand.Reader, crypto.SHA256, hashed[:])
+			Expect(err).To(BeNil())
+		}
+		Expect(keyring.shards).To(HaveLen(3))
+
+		sig, err := CombinePartialSignatures(&key.PublicKey, partials...)
+		Expect(err).To(BeNil())
+		Expect(rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, hashed[:], sig)).To(Succeed())
+	})
+
+	It("Signs sequentially with a multiplicative shard already in the agent", func() {
+		keyring := NewSSHAgentShardKeyring(nil)
+		shards, err := SplitD(key, 2, Multiplication)
+		Expect(err).To(BeNil())
+		_, err = AddShardToSSHAgent(dialAgent(keyring), shards[1])
+		Expect(err).To(BeNil())
+
+		signer := NewSSHAgentShard(dialAgent(keyring), &key.PublicKey, Multiplication, shards[1].Index)
+		partialSig, err := SignFirst(rand.Reader, shards[0], crypto.SHA256, hashed[:])
+		Expect(err).To(BeNil())
+		partialSig, err = signer.SignNext(rand.Reader, crypto.SHA256, hashed[:], partialSig)
+		Expect(err).To(BeNil())
+		Expect(rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, hashed[:], partialSig.Sig)).To(Succeed())
+	})
+
+	It("Removes shards from the agent", func() {
+		keyring := NewSSHAgentShardKeyring(nil)
+		shards, err := SplitD(key, 2, Addition)
+		Expect(err).To(BeNil())
+		signer, err := AddShardToSSHAgent(dialAgent(keyring), shards[0])
+		Expect(err).To(BeNil())
+
+		Expect(signer.Remove()).To(Succeed())
+		Expect(keyring.shards).To(BeEmpty())
+		_, err = signer.SignFirst(rand.Reader, crypto.SHA256, hashed[:])
+		Expect(err).NotTo(BeNil())
+	})
+
+	It("Refuses masked shards", func() {
+		shards, err := SplitDWithOptions(key, 2, Addition, &SplitOptions{Mask: true})
+		Expect(err).To(BeNil())
+
+		_, err = AddShardToSSHAgent(dialAgent(NewSSHAgentShardKeyring(nil)), shards[0])
+		Expect(errors.Is(err, ErrInvalidShard)).To(BeTrue())
+	})
+
+	It("Reports agents without the extensions", func() {
+		shards, err := SplitD(key, 2, Addition)
+		Expect(err).To(BeNil())
+		_, err = AddShardToSSHAgent(dialAgent(agent.NewKeyring()), shards[0])
+		Expect(errors.Is(err, ErrSSHAgentExtensionUnsupported)).To(BeTrue())
+	})
+
+	It("Passes everything else on to the wrapped agent", func() {
+		keyring := NewSSHAgentShardKeyring(nil)
+		client := dialAgent(keyring)
+		other, err := rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).To(BeNil())
+		Expect(client.Add(agent.AddedKey{PrivateKey: other, Comment: "other"})).To(Succeed())
+
+		keys, err := client.List()
+		Expect(err).To(BeNil())
+		Expect(keys).To(HaveLen(1))
+		pub, err := ssh.NewPublicKey(&other.PublicKey)
+		Expect(err).To(BeNil())
+		sig, err := client.SignWithFlags(pub, []byte("TEST MESSAGE"), agent.SignatureFlagRsaSha256)
+		Expect(err).To(BeNil())
+		Expect(pub.Verify([]byte("TEST MESSAGE"), sig)).To(Succeed())
+
+		_, err = keyring.Extension("session-bind@openssh.com", nil)
+		Expect(errors.Is(err, ErrSSHAgentExtensionUnsupported)).To(BeTrue())
+	})
+})
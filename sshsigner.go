@@ -0,0 +1,90 @@
+package keysplitting
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"io"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// the hash function of each SSH signature algorithm for RSA keys (RFC 8332), in order of preference
+var sshAlgorithms = []struct {
+	name   string
+	hashFn crypto.Hash
+}{
+	{ssh.KeyAlgoRSASHA512, crypto.SHA512},
+	{ssh.KeyAlgoRSASHA256, crypto.SHA256},
+	{ssh.KeyAlgoRSA, crypto.SHA1},
+}
+
+// An SSHSigner produces SSH signatures with a split key through a [crypto.Signer] for it, such as a [RemoteSigner], so that
+// an SSH certificate authority can require several operators to cooperate before any certificate is minted. It implements
+// ssh.Signer, ssh.AlgorithmSigner and ssh.MultiAlgorithmSigner from golang.org/x/crypto/ssh, so certificates are signed
+// with ssh.Certificate.SignCert, and it can authenticate an SSH client with ssh.PublicKeys
+type SSHSigner struct {
+	pub    ssh.PublicKey
+	signer crypto.Signer
+}
+
+var (
+	_ ssh.Signer               = (*SSHSigner)(nil)
+	_ ssh.AlgorithmSigner      = (*SSHSigner)(nil)
+	_ ssh.MultiAlgorithmSigner = (*SSHSigner)(nil)
+)
+
+// NewSSHSigner returns an SSHSigner that signs with signer, which must have an RSA public key
+func NewSSHSigner(signer crypto.Signer) (*SSHSigner, error) {
+	pub, ok := signer.Public().(*rsa.PublicKey)
+	if !ok {
+		return nil, errorf(ErrKeyMismatch, "SSH signer must have an RSA public key")
+	}
+	sshPub, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		return nil, errorf(ErrKeyMismatch, "failed to convert public key to SSH: %s", err)
+	}
+	return &SSHSigner{pub: sshPub, signer: signer}, nil
+}
+
+// PublicKey implements ssh.Signer, returning the split key as an "ssh-rsa" key
+func (s *SSHSigner) PublicKey() ssh.PublicKey {
+	return s.pub
+}
+
+// Sign implements ssh.Signer, signing data with "rsa-sha2-512"
+func (s *SSHSigner) Sign(random io.Reader, data []byte) (*ssh.Signature, error) {
+	return s.SignWithAlgorithm(random, data, ssh.KeyAlgoRSASHA512)
+}
+
+// SignWithAlgorithm implements ssh.AlgorithmSigner, signing data with one of the algorithms returned by
+// [SSHSigner.Algorithms], or "rsa-sha2-512" if algorithm is empty. Other algorithms fail with [ErrUnsupportedHash]
+func (s *SSHSigner) SignWithAlgorithm(random io.Reader, data []byte, algorithm string) (*ssh.Signature, error) {
+	if algorithm == "" {
+		algorithm = ssh.KeyAlgoRSASHA512
+	}
+	for _, alg := range sshAlgorithms {
+		if alg.name != algorithm {
+			continue
+		}
+		hashed, err := hashMessage(alg.hashFn, data)
+		if err != nil {
+			return nil, err
+		}
+		sig, err := s.signer.Sign(random, hashed, alg.hashFn)
+		if err != nil {
+			return nil, err
+		}
+		return &ssh.Signature{Format: algorithm, Blob: sig}, nil
+	}
+	return nil, errorf(ErrUnsupportedHash, "unsupported SSH signature algorithm %q", algorithm)
+}
+
+// Algorithms implements ssh.MultiAlgorithmSigner, returning "rsa-sha2-512", "rsa-sha2-256" and "ssh-rsa" in order of
+// preference. ssh.Certificate.SignCert signs with the first of them
+func (s *SSHSigner) Algorithms() []string {
+	algorithms := make([]string, len(sshAlgorithms))
+	for i, alg := range sshAlgorithms {
+		algorithms[i] = alg.name
+	}
+	return algorithms
+}
@@ -0,0 +1,283 @@
+package keysplitting
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+)
+
+// A TestVector records every step of one split signature: the whole key, its shards, the message, each partial signature,
+// and the final signature. Implementations of the scheme in other languages can check themselves against vectors produced
+// by [NewTestVector], which marshal to a stable JSON format, and [TestVector.Verify] checks vectors from elsewhere against
+// this package. Partial signatures are unbound PKCS #1 v1.5 signatures, which are deterministic. For additive splits, they
+// are each shard's own signature, as in the brokered flow; for multiplicative splits, they are the chain after each shard.
+//
+// Test vectors contain the whole private key, so they must only ever be made with throwaway keys
+type TestVector struct {
+	Description string
+	Key         *rsa.PrivateKey
+	SplitBy     SplitBy
+	Hash        crypto.Hash
+	Message     []byte
+	Shards      []*PrivateKeyShard
+	Partials    []*PartialSignature
+	Signature   []byte
+}
+
+// used exclusively as a placeholder for encoding-decoding
+type testVectorJSON struct {
+	Description string                  `json:"description,omitempty"`
+	Key         testVectorKeyJSON       `json:"key"`
+	SplitBy     SplitBy                 `json:"split_by"`
+	Hash        string                  `json:"hash"`
+	Message     string                  `json:"message"`
+	Digest      string                  `json:"digest"`
+	Shards      []testVectorShardJSON   `json:"shards"`
+	Partials    []testVectorPartialJSON `json:"partials"`
+	Signature   string                  `json:"signature"`
+}
+
+// used exclusively as a placeholder for encoding-decoding
+type testVectorKeyJSON struct {
+	N      string   `json:"n"`
+	E      int      `json:"e"`
+	D      string   `json:"d"`
+	Primes []string `json:"primes"`
+}
+
+// used exclusively as a placeholder for encoding-decoding
+type testVectorShardJSON struct {
+	Index int    `json:"index"`
+	D     string `json:"d"`
+}
+
+// used exclusively as a placeholder for encoding-decoding
+type testVectorPartialJSON struct {
+	Signers []int  `json:"signers"`
+	Sig     string `json:"sig"`
+}
+
+// NewTestVector splits priv into k shards with randomness from random, signs message with each of them, and records every step
+func NewTestVector(random io.Reader, priv *rsa.PrivateKey, k int, splitBy SplitBy, hashFn crypto.Hash, message []byte) (*TestVector, error) {
+	shards, err := SplitDWithOptions(priv, k, splitBy, &SplitOptions{Rand: random})
+	if err != nil {
+		return nil, err
+	}
+	tv := &TestVector{
+		Description: fmt.Sprintf("%d-bit key split %d ways by %s, signed with %v", priv.N.BitLen(), k, splitBy, hashFn),
+		Key:         priv,
+		SplitBy:     splitBy,
+		Hash:        hashFn,
+		Message:     append([]byte{}, message...),
+		Shards:      shards,
+	}
+	if tv.Partials, tv.Signature, err = tv.sign(); err != nil {
+		return nil, err
+	}
+	return tv, nil
+}
+
+// Verify checks that the vector's shards compose its key, and that signing its message with them produces exactly its
+// partial signatures and final signature, which must verify against the key. The error wraps [ErrKeyMismatch] if the
+// shards don't belong to the key, [ErrInvalidPartialSignature] if a partial signature differs, or [ErrIncompleteSignature]
+// if the final signature differs or doesn't verify
+func (tv *TestVector) Verify() error {
+	if tv.Key == nil || len(tv.Key.Primes) < 2 {
+		return errorf(ErrKeyMismatch, "test vector has no private key")
+	}
+	if err := tv.Key.Validate(); err != nil {
+		return errorf(ErrKeyMismatch, "test vector has an invalid private key: %s", err)
+	}
+	if len(tv.Shards) < 2 {
+		return errorf(ErrTooFewShards, "test vector has fewer than 2 shards")
+	}
+
+	// shards may have been split (mod phi(N)) or (mod lambda(N)), and are congruent (mod lambda(N)) either way
+	combined := big.NewInt(0)
+	if tv.SplitBy == Multiplication {
+		combined.SetInt64(1)
+	}
+	for _, shard := range tv.Shards {
+		if shard.SplitBy != tv.SplitBy || !shard.PublicKey.Equal(&tv.Key.PublicKey) {
+			return errorf(ErrKeyMismatch, "shard %d doesn't belong to the test vector's key", shard.Index)
+		}
+		if tv.SplitBy == Multiplication {
+			combined.Mul(combined, shard.D)
+		} else {
+			combined.Add(combined, shard.D)
+		}
+	}
+	if !congruentModN(combined, tv.Key.D, carmichaelLambda(tv.Key.Primes)) {
+		return errorf(ErrKeyMismatch, "test vector's shards don't compose its private key")
+	}
+
+	partials, sig, err := tv.sign()
+	if err != nil {
+		return err
+	}
+	if len(partials) != len(tv.Partials) {
+		return errorf(ErrInvalidPartialSignature, "test vector has %d partial signatures, but %d shards", len(tv.Partials), len(partials))
+	}
+	for i, partial := range partials {
+		expected := tv.Partials[i]
+		if !bytes.Equal(partial.Sig, expected.Sig) || !equalSigners(partial.Signers, expected.Signers) {
+			return errorf(ErrInvalidPartialSignature, "partial signature #%d doesn't match", i+1)
+		}
+	}
+	if !bytes.Equal(sig, tv.Signature) {
+		return errorf(ErrIncompleteSignature, "final signature doesn't match")
+	}
+	return VerifyFinal(&tv.Key.PublicKey, tv.Hash, tv.digest(), tv.Signature, tv.SplitBy)
+}
+
+// MarshalJSON implements [json.Marshaler]. Big integers and byte strings are encoded in lowercase hexadecimal, with a
+// leading minus sign for negative additive shards, and the hash function by its name, e.g. "SHA-256"
+func (tv *TestVector) MarshalJSON() ([]byte, error) {
+	if tv.Key == nil {
+		return nil, errorf(ErrKeyMismatch, "test vector has no private key")
+	}
+	v := testVectorJSON{
+		Description: tv.Description,
+		Key:         testVectorKeyJSON{N: tv.Key.N.Text(16), E: tv.Key.E, D: tv.Key.D.Text(16)},
+		SplitBy:     tv.SplitBy,
+		Hash:        tv.Hash.String(),
+		Message:     hex.EncodeToString(tv.Message),
+		Digest:      hex.EncodeToString(tv.digest()),
+		Signature:   hex.EncodeToString(tv.Signature),
+	}
+	for _, p := range tv.Key.Primes {
+		v.Key.Primes = append(v.Key.Primes, p.Text(16))
+	}
+	for _, shard := range tv.Shards {
+		v.Shards = append(v.Shards, testVectorShardJSON{Index: shard.Index, D: shard.D.Text(16)})
+	}
+	for _, partial := range tv.Partials {
+		v.Partials = append(v.Partials, testVectorPartialJSON{Signers: partial.Signers, Sig: hex.EncodeToString(partial.Sig)})
+	}
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON implements [json.Unmarshaler], decoding the format written by [TestVector.MarshalJSON]. It only parses the
+// vector; use [TestVector.Verify] to check it
+func (tv *TestVector) UnmarshalJSON(data []byte) error {
+	var v testVectorJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := checkSplitBy(v.SplitBy); err != nil {
+		return err
+	}
+	hashFn, err := parseHashName(v.Hash)
+	if err != nil {
+		return err
+	}
+
+	var decodeErr error
+	parseInt := func(s string) *big.Int {
+		n, ok := new(big.Int).SetString(s, 16)
+		if !ok && decodeErr == nil {
+			decodeErr = errorf(ErrInvalidKey, "invalid hexadecimal integer in test vector: %q", s)
+		}
+		return n
+	}
+	parseBytes := func(s string) []byte {
+		b, err := hex.DecodeString(s)
+		if err != nil && decodeErr == nil {
+			decodeErr = errorf(ErrInvalidKey, "invalid hexadecimal string in test vector: %s", err)
+		}
+		return b
+	}
+
+	key := &rsa.PrivateKey{PublicKey: rsa.PublicKey{N: parseInt(v.Key.N), E: v.Key.E}, D: parseInt(v.Key.D)}
+	for _, p := range v.Key.Primes {
+		key.Primes = append(key.Primes, parseInt(p))
+	}
+	decoded := TestVector{Description: v.Description, Key: key, SplitBy: v.SplitBy, Hash: hashFn, Message: parseBytes(v.Message)}
+	for _, shard := range v.Shards {
+		decoded.Shards = append(decoded.Shards, &PrivateKeyShard{PublicKey: &key.PublicKey, D: parseInt(shard.D), SplitBy: v.SplitBy, Index: shard.Index})
+	}
+	for _, partial := range v.Partials {
+		decoded.Partials = append(decoded.Partials, &PartialSignature{Signers: partial.Signers, Hash: hashFn, SplitBy: v.SplitBy, Sig: parseBytes(partial.Sig)})
+	}
+	decoded.Signature = parseBytes(v.Signature)
+	if decodeErr != nil {
+		return decodeErr
+	}
+	if digest := parseBytes(v.Digest); decodeErr == nil && !bytes.Equal(digest, decoded.digest()) {
+		return errorf(ErrUnsupportedHash, "test vector's digest is not the %v hash of its message", hashFn)
+	}
+
+	if len(key.Primes) == 2 {
+		key.Precompute()
+	}
+	*tv = decoded
+	return nil
+}
+
+// returns the vector's partial signatures and final signature, computed from its shards
+func (tv *TestVector) sign() ([]*PartialSignature, []byte, error) {
+	hashed := tv.digest()
+	partials := make([]*PartialSignature, len(tv.Shards))
+	var sig []byte
+	switch tv.SplitBy {
+	case Multiplication:
+		var partialSig *PartialSignature
+		var err error
+		for i, shard := range tv.Shards {
+			if partialSig == nil {
+				partialSig, err = SignFirst(rand.Reader, shard, tv.Hash, hashed)
+			} else {
+				partialSig, err = SignNext(rand.Reader, shard, tv.Hash, hashed, partialSig)
+			}
+			if err != nil {
+				return nil, nil, err
+			}
+			partials[i] = partialSig
+		}
+		sig = append([]byte{}, partialSig.Sig...)
+	case Addition:
+		for i, shard := range tv.Shards {
+			partialSig, err := SignFirst(rand.Reader, shard, tv.Hash, hashed)
+			if err != nil {
+				return nil, nil, err
+			}
+			partials[i] = partialSig
+		}
+		var err error
+		if sig, err = CombinePartialSignatures(&tv.Key.PublicKey, partials...); err != nil {
+			return nil, nil, err
+		}
+	default:
+		return nil, nil, errorf(ErrUnsupportedSplitBy, "unrecognized split algorithm: %v", tv.SplitBy)
+	}
+	return partials, sig, nil
+}
+
+// returns the hash of the vector's message
+func (tv *TestVector) digest() []byte {
+	if !tv.Hash.Available() {
+		return nil
+	}
+	h := tv.Hash.New()
+	h.Write(tv.Message)
+	return h.Sum(nil)
+}
+
+// returns the hash function with the given name, as returned by crypto.Hash.String
+func parseHashName(name string) (crypto.Hash, error) {
+	for hashFn := crypto.MD4; hashFn <= crypto.BLAKE2b_512; hashFn++ {
+		if hashFn.String() == name {
+			if err := checkHashAvailable(hashFn); err != nil {
+				return 0, err
+			}
+			return hashFn, nil
+		}
+	}
+	return 0, errorf(ErrUnsupportedHash, "unrecognized hash function: %q", name)
+}
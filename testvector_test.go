@@ -0,0 +1,113 @@
+package keysplitting
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Test vectors", func() {
+	var priv *rsa.PrivateKey
+	message := []byte("test vector message")
+
+	BeforeEach(func() {
+		priv, _ = rsa.GenerateKey(rand.Reader, 2048)
+	})
+
+	for _, splitBy := range []SplitBy{Multiplication, Addition} {
+		splitBy := splitBy
+
+		Context("Split by "+string(splitBy), func() {
+			var tv *TestVector
+
+			BeforeEach(func() {
+				var err error
+				tv, err = NewTestVector(rand.Reader, priv, 3, splitBy, crypto.SHA256, message)
+				Expect(err).To(BeNil())
+			})
+
+			It("Produces a vector that verifies", func() {
+				Expect(tv.Partials).To(HaveLen(3))
+				Expect(tv.Verify()).To(Succeed())
+			})
+
+			It("Survives a round trip through JSON", func() {
+				encoded, err := json.Marshal(tv)
+				Expect(err).To(BeNil())
+
+				var decoded TestVector
+				Expect(json.Unmarshal(encoded, &decoded)).To(Succeed())
+				Expect(decoded.Verify()).To(Succeed())
+				Expect(decoded.Signature).To(Equal(tv.Signature))
+				Expect(decoded.Hash).To(Equal(crypto.SHA256))
+
+				reencoded, err := json.Marshal(&decoded)
+				Expect(err).To(BeNil())
+				Expect(reencoded).To(Equal(encoded))
+			})
+
+			It("Detects a tampered partial signature", func() {
+				tv.Partials[1].Sig[0] ^= 1
+				err := tv.Verify()
+				Expect(errors.Is(err, ErrInvalidPartialSignature)).To(BeTrue())
+			})
+
+			It("Detects a tampered final signature", func() {
+				tv.Signature[0] ^= 1
+				err := tv.Verify()
+				Expect(errors.Is(err, ErrIncompleteSignature)).To(BeTrue())
+			})
+
+			It("Detects shards that don't compose the key", func() {
+				tv.Shards[0].D.Add(tv.Shards[0].D, bigOne)
+				err := tv.Verify()
+				Expect(errors.Is(err, ErrKeyMismatch)).To(BeTrue())
+			})
+		})
+	}
+
+	It("Produces the same vector from the same randomness", func() {
+		first, err := NewTestVector(newDeterministicReader("vector"), priv, 2, Addition, crypto.SHA256, message)
+		Expect(err).To(BeNil())
+		second, err := NewTestVector(newDeterministicReader("vector"), priv, 2, Addition, crypto.SHA256, message)
+		Expect(err).To(BeNil())
+
+		firstJSON, _ := json.Marshal(first)
+		secondJSON, _ := json.Marshal(second)
+		Expect(firstJSON).To(Equal(secondJSON))
+	})
+
+	It("Refuses a vector whose digest doesn't match its message", func() {
+		tv, err := NewTestVector(rand.Reader, priv, 2, Addition, crypto.SHA256, message)
+		Expect(err).To(BeNil())
+		encoded, _ := json.Marshal(tv)
+
+		var v map[string]interface{}
+		Expect(json.Unmarshal(encoded, &v)).To(Succeed())
+		v["digest"] = "00"
+		encoded, _ = json.Marshal(v)
+
+		var decoded TestVector
+		Expect(json.Unmarshal(encoded, &decoded)).NotTo(Succeed())
+	})
+
+	It("Refuses an unknown hash function", func() {
+		tv, err := NewTestVector(rand.Reader, priv, 2, Addition, crypto.SHA256, message)
+		Expect(err).To(BeNil())
+		encoded, _ := json.Marshal(tv)
+
+		var v map[string]interface{}
+		Expect(json.Unmarshal(encoded, &v)).To(Succeed())
+		v["hash"] = "SHA-257"
+		encoded, _ = json.Marshal(v)
+
+		var decoded TestVector
+		err = json.Unmarshal(encoded, &decoded)
+		Expect(errors.Is(err, ErrUnsupportedHash)).To(BeTrue())
+	})
+})
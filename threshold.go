@@ -0,0 +1,239 @@
+package keysplitting
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"math/big"
+	"time"
+)
+
+// A ThresholdKeyShare is one of n shares of an RSA private key split with [SplitThreshold].
+// Any t of the n shares can produce a valid signature, while fewer than t shares reveal nothing about the key
+type ThresholdKeyShare struct {
+	PublicKey *rsa.PublicKey // public part
+	Index     int            // this share's position in 1..n, which the combiner needs in order to interpolate
+	S         *big.Int       // share of the private exponent, f(Index) (mod phi(N))
+	Threshold int            // t, the number of shares required to sign
+	Parties   int            // n, the total number of shares
+}
+
+// A ThresholdPartialSignature is the contribution of a single [ThresholdKeyShare] to a threshold signature
+type ThresholdPartialSignature struct {
+	Index int    // index of the share that produced the partial signature
+	Sig   []byte // x^(2 * Δ * s_i) (mod N), where x is the encoded message and Δ = n!
+}
+
+// SplitThreshold splits priv into n shares such that any t of them can sign, following Shoup's "Practical Threshold Signatures" [3]
+//
+// The private exponent D is hidden as the constant term of a random polynomial f of degree t-1 over ℤ/phiℤ, and share i is f(i).
+// Rather than reconstructing D, the combiner interpolates f(0) "in the exponent" using the partial signatures, scaling everything
+// by Δ = n! so that the Lagrange coefficients are integers and no inverses modulo the secret phi are needed.
+//
+// The public exponent must be a prime larger than n, which holds for the usual E = 65537.
+// Note that Shoup's security proof assumes N is a product of safe primes, which [rsa.GenerateKey] does not guarantee
+func SplitThreshold(priv *rsa.PrivateKey, t, n int) ([]*ThresholdKeyShare, error) {
+	if t < 2 {
+		return nil, errorf(ErrTooFewShards, "threshold must be at least 2")
+	}
+	if n < t {
+		return nil, errorf(ErrTooFewShards, "cannot split key into fewer shares than the threshold")
+	}
+
+	e := big.NewInt(int64(priv.E))
+	if !e.ProbablyPrime(20) || priv.E <= n {
+		return nil, errorf(ErrInvalidKey, "threshold signatures require a prime public exponent greater than the number of shares")
+	}
+
+	phi := eulerTotient(priv.Primes)
+
+	// f(X) = D + a1 * X + a2 * X^2 + ... + a(t-1) * X^(t-1)
+	coefficients := make([]*big.Int, t)
+	coefficients[0] = new(big.Int).Mod(priv.D, phi)
+	for i := 1; i < t; i++ {
+		a, err := rand.Int(rand.Reader, phi)
+		if err != nil {
+			return nil, &RandomnessError{Err: err}
+		}
+		coefficients[i] = a
+	}
+
+	shares := make([]*ThresholdKeyShare, n)
+	for i := 1; i <= n; i++ {
+		shares[i-1] = &ThresholdKeyShare{
+			PublicKey: &priv.PublicKey,
+			Index:     i,
+			S:         evaluatePolynomial(coefficients, big.NewInt(int64(i)), phi),
+			Threshold: t,
+			Parties:   n,
+		}
+	}
+
+	return shares, nil
+}
+
+// SignThreshold uses the given share to produce a partial PKCS #1 v1.5 signature on a hashed message.
+// Note that hashed must be the result of hashing the input message using the given hash function
+//
+// Unlike [SignFirst] and [SignNext], the partial signatures are always produced independently and combined with [CombineThreshold]
+func SignThreshold(share *ThresholdKeyShare, hashFn crypto.Hash, hashed []byte) (partialSig *ThresholdPartialSignature, err error) {
+	start := time.Now()
+	defer func() {
+		observePartialSignature(start, err)
+		audit(AuditPartialSign, share.PublicKey, []int{share.Index}, "", hashFn, hashed, err)
+	}()
+
+	if share.Index < 1 || share.Index > share.Parties {
+		return nil, errorf(ErrInvalidShard, "share index %d is out of range", share.Index)
+	}
+
+	em, err := emsaPKCS1v15Encode(hashFn, hashed, share.PublicKey.Size())
+	if err != nil {
+		return nil, err
+	}
+
+	// x_i <- x^(2 * Δ * s_i) (mod N)
+	exponent := new(big.Int).MulRange(1, int64(share.Parties))
+	exponent.Lsh(exponent, 1)
+	exponent.Mul(exponent, share.S)
+
+	x := new(big.Int).SetBytes(em)
+	xi := new(big.Int).Exp(x, exponent, share.PublicKey.N)
+
+	return &ThresholdPartialSignature{
+		Index: share.Index,
+		Sig:   xi.FillBytes(make([]byte, share.PublicKey.Size())),
+	}, nil
+}
+
+// CombineThreshold combines at least t partial signatures from a t-of-n split into a complete PKCS #1 v1.5 signature,
+// which can be verified against pub in the usual way. If more than t partial signatures are provided, the first t are used.
+// Note that hashed must be the result of hashing the input message using the given hash function
+func CombineThreshold(pub *rsa.PublicKey, t, n int, hashFn crypto.Hash, hashed []byte, partials ...*ThresholdPartialSignature) (sig []byte, err error) {
+	start := time.Now()
+	_, span := StartSpan(context.Background(), "keysplitting.CombineThreshold",
+		append(keyAttributes(pub, "", hashFn), TraceAttribute{Key: TraceShardCount, Value: len(partials)})...)
+	defer func() {
+		span.End(err)
+		observeCombine(start, err)
+		parties := make([]int, len(partials))
+		for i, partial := range partials {
+			parties[i] = partial.Index
+		}
+		audit(AuditCombine, pub, parties, "", hashFn, hashed, err)
+	}()
+	return combineThreshold(pub, t, n, hashFn, hashed, partials)
+}
+
+// combines partials as CombineThreshold does, without recording the attempt
+func combineThreshold(pub *rsa.PublicKey, t, n int, hashFn crypto.Hash, hashed []byte, partials []*ThresholdPartialSignature) ([]byte, error) {
+	if len(partials) < t {
+		return nil, errorf(ErrTooFewShards, "cannot combine fewer than %d partial signatures", t)
+	}
+	partials = partials[:t]
+
+	indices := make([]int, t)
+	for i, partial := range partials {
+		if partial.Index < 1 || partial.Index > n {
+			return nil, errorf(ErrInvalidPartialSignature, "partial signature index %d is out of range", partial.Index)
+		}
+		for _, index := range indices[:i] {
+			if index == partial.Index {
+				return nil, errorf(ErrInvalidPartialSignature, "duplicate partial signature from share %d", index)
+			}
+		}
+		indices[i] = partial.Index
+	}
+
+	em, err := emsaPKCS1v15Encode(hashFn, hashed, pub.Size())
+	if err != nil {
+		return nil, err
+	}
+	x := new(big.Int).SetBytes(em)
+
+	// w <- x_1^(2 * λ_1) * ... * x_t^(2 * λ_t) = x^(4 * Δ^2 * D) (mod N), where the λ_i are Δ times the Lagrange coefficients at 0
+	delta := new(big.Int).MulRange(1, int64(n))
+	w := big.NewInt(1)
+	for i, partial := range partials {
+		xi := new(big.Int).SetBytes(partial.Sig)
+		if xi.Sign() == 0 || xi.Cmp(pub.N) >= 0 {
+			return nil, errorf(ErrInvalidPartialSignature, "partial signature from share %d is out of range for the given public key", partial.Index)
+		}
+
+		lambda := new(big.Int).Lsh(lagrangeCoefficient(delta, indices, i), 1)
+		term, err := expSigned(xi, lambda, pub.N)
+		if err != nil {
+			return nil, err
+		}
+		w.Mul(w, term)
+		w.Mod(w, pub.N)
+	}
+
+	// since w^E = x^(4 * Δ^2), and 4 * Δ^2 is coprime to E, find a and b such that a * 4 * Δ^2 + b * E = 1.
+	// Then y <- w^a * x^b is the E'th root of x, i.e. the signature
+	ePrime := new(big.Int).Mul(delta, delta)
+	ePrime.Lsh(ePrime, 2)
+	e := big.NewInt(int64(pub.E))
+	a, b := new(big.Int), new(big.Int)
+	if new(big.Int).GCD(a, b, ePrime, e).Cmp(bigOne) != 0 {
+		return nil, errorf(ErrInvalidKey, "public exponent is not coprime to 4 * (%d!)^2", n)
+	}
+
+	wa, err := expSigned(w, a, pub.N)
+	if err != nil {
+		return nil, err
+	}
+	xb, err := expSigned(x, b, pub.N)
+	if err != nil {
+		return nil, err
+	}
+	y := new(big.Int).Mul(wa, xb)
+	y.Mod(y, pub.N)
+
+	// a bad partial signature produces a bad result, so check the result before handing it back
+	if new(big.Int).Exp(y, e, pub.N).Cmp(x) != 0 {
+		return nil, errorf(ErrInvalidPartialSignature, "combined signature does not verify; at least one partial signature is invalid")
+	}
+
+	return y.FillBytes(make([]byte, pub.Size())), nil
+}
+
+// returns f(x) (mod m), where f is the polynomial with the given coefficients in increasing order of degree
+func evaluatePolynomial(coefficients []*big.Int, x *big.Int, m *big.Int) *big.Int {
+	// Horner's method
+	result := new(big.Int)
+	for i := len(coefficients) - 1; i >= 0; i-- {
+		result.Mul(result, x)
+		result.Add(result, coefficients[i])
+		result.Mod(result, m)
+	}
+	return result
+}
+
+// returns Δ times the Lagrange coefficient of indices[i] for interpolating at 0, which is always an integer
+func lagrangeCoefficient(delta *big.Int, indices []int, i int) *big.Int {
+	num := new(big.Int).Set(delta)
+	den := big.NewInt(1)
+	for j, index := range indices {
+		if j == i {
+			continue
+		}
+		num.Mul(num, big.NewInt(int64(-index)))
+		den.Mul(den, big.NewInt(int64(indices[i]-index)))
+	}
+	return num.Quo(num, den)
+}
+
+// returns base^exp (mod N), using the inverse of base if exp is negative
+func expSigned(base *big.Int, exp *big.Int, N *big.Int) (*big.Int, error) {
+	if exp.Sign() >= 0 {
+		return new(big.Int).Exp(base, exp, N), nil
+	}
+
+	inverse := new(big.Int).ModInverse(base, N)
+	if inverse == nil {
+		return nil, errorf(ErrInvalidPartialSignature, "value has no inverse modulo N")
+	}
+	return new(big.Int).Exp(inverse, new(big.Int).Neg(exp), N), nil
+}
@@ -0,0 +1,91 @@
+package keysplitting
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha512"
+	"fmt"
+	mrand "math/rand"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Threshold signatures", func() {
+	hashed := sha512.Sum512([]byte("TEST MESSAGE"))
+	priv, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	Context("Basic interfacing", func() {
+		It("Refuses a threshold below 2", func() {
+			_, err := SplitThreshold(priv, 1, 3)
+			Expect(err).NotTo(BeNil())
+		})
+
+		It("Refuses more required shares than parties", func() {
+			_, err := SplitThreshold(priv, 4, 3)
+			Expect(err).NotTo(BeNil())
+		})
+	})
+
+	for _, params := range [][2]int{{2, 2}, {2, 3}, {3, 5}, {5, 8}} {
+		t, n := params[0], params[1]
+
+		When(fmt.Sprintf("Splitting a key %d-of-%d", t, n), Ordered, func() {
+			var shares []*ThresholdKeyShare
+			var partials []*ThresholdPartialSignature
+
+			It("Successfully splits the key", func() {
+				var err error
+				shares, err = SplitThreshold(priv, t, n)
+				Expect(err).To(BeNil())
+				Expect(shares).To(HaveLen(n))
+			})
+
+			It("Produces a partial signature with every share", func() {
+				partials = make([]*ThresholdPartialSignature, n)
+				for i, share := range shares {
+					partial, err := SignThreshold(share, crypto.SHA512, hashed[:])
+					Expect(err).To(BeNil())
+					Expect(rsa.VerifyPKCS1v15(&priv.PublicKey, crypto.SHA512, hashed[:], partial.Sig)).NotTo(Succeed(), "partial signature must not verify")
+					partials[i] = partial
+				}
+			})
+
+			It("Produces a valid signature from any t partial signatures", func() {
+				// try the first t, the last t, and a shuffled selection
+				selections := [][]*ThresholdPartialSignature{partials[:t], partials[n-t:]}
+				shuffled := append([]*ThresholdPartialSignature{}, partials...)
+				mrand.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+				selections = append(selections, shuffled[:t])
+
+				for _, selection := range selections {
+					sig, err := CombineThreshold(&priv.PublicKey, t, n, crypto.SHA512, hashed[:], selection...)
+					Expect(err).To(BeNil())
+					Expect(rsa.VerifyPKCS1v15(&priv.PublicKey, crypto.SHA512, hashed[:], sig)).To(Succeed())
+				}
+			})
+
+			It("Fails with fewer than t partial signatures", func() {
+				_, err := CombineThreshold(&priv.PublicKey, t, n, crypto.SHA512, hashed[:], partials[:t-1]...)
+				Expect(err).NotTo(BeNil())
+			})
+
+			It("Fails with duplicate partial signatures", func() {
+				duplicates := make([]*ThresholdPartialSignature, t)
+				for i := range duplicates {
+					duplicates[i] = partials[0]
+				}
+				_, err := CombineThreshold(&priv.PublicKey, t, n, crypto.SHA512, hashed[:], duplicates...)
+				Expect(err).NotTo(BeNil())
+			})
+
+			It("Fails with a corrupted partial signature", func() {
+				corrupted := append([]*ThresholdPartialSignature{}, partials[:t]...)
+				corrupted[0] = &ThresholdPartialSignature{Index: corrupted[0].Index, Sig: partials[t-1].Sig}
+				_, err := CombineThreshold(&priv.PublicKey, t, n, crypto.SHA512, hashed[:], corrupted...)
+				Expect(err).NotTo(BeNil())
+			})
+		})
+	}
+})
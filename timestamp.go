@@ -0,0 +1,294 @@
+package keysplitting
+
+import (
+	"crypto"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+	"io"
+	"math/big"
+	"time"
+)
+
+// A Timestamp describes an RFC 3161 timestamp token to be signed with [SignTimestamp]. [ParseTimestampRequest] fills in the
+// fields that come from the client's request, and the TSA the rest
+type Timestamp struct {
+	HashAlgorithm      crypto.Hash           // the hash function of the message imprint
+	HashedMessage      []byte                // the message imprint, i.e. the digest of the data being timestamped
+	Policy             asn1.ObjectIdentifier // the TSA policy under which the token is issued
+	SerialNumber       *big.Int              // unique among the tokens the TSA issues
+	Time               time.Time             // when the token is issued, to the second
+	Accuracy           time.Duration         // the accuracy of Time, or omitted if 0
+	Nonce              *big.Int              // the nonce of the request, if it had one
+	IncludeCertificate bool                  // whether to include the TSA's certificate in the token, as the request asks
+	Hash               crypto.Hash           // the hash function to sign with, one of SHA-256, SHA-384 or SHA-512. SHA-256 if unset
+}
+
+var (
+	oidSHA256               = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidSHA384               = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 2}
+	oidSHA512               = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3}
+	oidRSAEncryption        = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	oidSignedData           = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidContentType          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+	oidMessageDigest        = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+	oidTSTInfo              = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 1, 4}
+	oidSigningCertificateV2 = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 2, 47}
+	timestampHashAlgorithms = map[crypto.Hash]asn1.ObjectIdentifier{crypto.SHA1: oidSHA1, crypto.SHA256: oidSHA256, crypto.SHA384: oidSHA384, crypto.SHA512: oidSHA512}
+)
+
+const (
+	timestampVersion       = 1 // of both TimeStampReq and TSTInfo
+	timestampGranted       = 0 // the PKIStatus of a response with a token
+	timestampCMSVersion    = 3 // the eContentType isn't id-data
+	timestampSignerVersion = 1 // the signer is identified by issuer and serial number
+)
+
+// used exclusively as a placeholder for encoding-decoding
+type timeStampReq struct {
+	Version        int
+	MessageImprint messageImprint
+	ReqPolicy      asn1.ObjectIdentifier `asn1:"optional"`
+	Nonce          *big.Int              `asn1:"optional"`
+	CertReq        bool                  `asn1:"optional"`
+	Extensions     []pkix.Extension      `asn1:"optional,tag:0"`
+}
+
+// used exclusively as a placeholder for encoding-decoding
+type messageImprint struct {
+	HashAlgorithm pkix.AlgorithmIdentifier
+	HashedMessage []byte
+}
+
+// used exclusively as a placeholder for encoding-decoding
+type tstInfo struct {
+	Version        int
+	Policy         asn1.ObjectIdentifier
+	MessageImprint messageImprint
+	SerialNumber   *big.Int
+	GenTime        time.Time   `asn1:"generalized"`
+	Accuracy       tstAccuracy `asn1:"optional"`
+	Nonce          *big.Int    `asn1:"optional"`
+}
+
+// used exclusively as a placeholder for encoding-decoding
+type tstAccuracy struct {
+	Seconds int `asn1:"optional"`
+	Millis  int `asn1:"optional,tag:0"`
+	Micros  int `asn1:"optional,tag:1"`
+}
+
+// used exclusively as a placeholder for encoding-decoding
+type cmsContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue // [0] EXPLICIT
+}
+
+// used exclusively as a placeholder for encoding-decoding
+type cmsSignedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	EncapContentInfo cmsEncapContentInfo
+	Certificates     []asn1.RawValue `asn1:"optional,set,tag:0"`
+	SignerInfos      []cmsSignerInfo `asn1:"set"`
+}
+
+// used exclusively as a placeholder for encoding-decoding
+type cmsEncapContentInfo struct {
+	EContentType asn1.ObjectIdentifier
+	EContent     []byte `asn1:"explicit,tag:0"`
+}
+
+// used exclusively as a placeholder for encoding-decoding
+type cmsSignerInfo struct {
+	Version            int
+	IssuerAndSerial    cmsIssuerAndSerial
+	DigestAlgorithm    pkix.AlgorithmIdentifier
+	SignedAttrs        asn1.RawValue
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          []byte
+}
+
+// used exclusively as a placeholder for encoding-decoding
+type cmsIssuerAndSerial struct {
+	Issuer       asn1.RawValue
+	SerialNumber *big.Int
+}
+
+// used exclusively as a placeholder for encoding-decoding
+type cmsAttribute struct {
+	Type   asn1.ObjectIdentifier
+	Values []asn1.RawValue `asn1:"set"`
+}
+
+// used exclusively as a placeholder for encoding-decoding
+type signingCertificateV2 struct {
+	Certs []essCertIDv2 // the hash algorithm is SHA-256, which is the default and so omitted
+}
+
+// used exclusively as a placeholder for encoding-decoding
+type essCertIDv2 struct {
+	CertHash []byte
+}
+
+// used exclusively as a placeholder for encoding-decoding
+type timeStampResp struct {
+	Status         pkiStatusInfo
+	TimeStampToken asn1.RawValue
+}
+
+// used exclusively as a placeholder for encoding-decoding
+type pkiStatusInfo struct {
+	Status int
+}
+
+// ParseTimestampRequest parses the DER encoding of an RFC 3161 TimeStampReq into a Timestamp, leaving the TSA to fill in
+// its SerialNumber and Time, and its Policy if the request didn't name one
+func ParseTimestampRequest(der []byte) (*Timestamp, error) {
+	var req timeStampReq
+	rest, err := asn1.Unmarshal(der, &req)
+	if err != nil {
+		return nil, fmt.Errorf("malformed timestamp request: %s", err)
+	}
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("malformed timestamp request: trailing data")
+	}
+	if req.Version != timestampVersion {
+		return nil, fmt.Errorf("unsupported timestamp request version: %d", req.Version)
+	}
+	if len(req.Extensions) > 0 {
+		return nil, fmt.Errorf("timestamp request extensions aren't supported")
+	}
+	for hashFn, oid := range timestampHashAlgorithms {
+		if oid.Equal(req.MessageImprint.HashAlgorithm.Algorithm) {
+			return &Timestamp{
+				HashAlgorithm:      hashFn,
+				HashedMessage:      req.MessageImprint.HashedMessage,
+				Policy:             req.ReqPolicy,
+				Nonce:              req.Nonce,
+				IncludeCertificate: req.CertReq,
+			}, nil
+		}
+	}
+	return nil, errorf(ErrUnsupportedHash, "unsupported message imprint hash algorithm %v", req.MessageImprint.HashAlgorithm.Algorithm)
+}
+
+// SignTimestamp returns the DER encoding of an RFC 3161 timestamp token, a CMS SignedData over the TSTInfo that ts
+// describes, signed by signer on behalf of tsa, so that a time-stamping authority can run with a key that is never whole.
+// tsa is the TSA's certificate, which must be for time stamping only, and signer is typically a [RemoteSigner] for its split
+// key. The signature is checked against the certificate before the token is returned. [TimestampResponse] wraps the token in
+// the response to the request
+func SignTimestamp(random io.Reader, tsa *x509.Certificate, signer crypto.Signer, ts *Timestamp) ([]byte, error) {
+	if err := checkIssuerKey(tsa, signer); err != nil {
+		return nil, err
+	}
+	hashFn := ts.Hash
+	if hashFn == 0 {
+		hashFn = crypto.SHA256
+	}
+	algorithm, ok := ocspSignatureAlgorithm[hashFn]
+	if !ok {
+		return nil, errorf(ErrUnsupportedHash, "timestamp tokens can't be signed with %v", hashFn)
+	}
+	imprintAlgorithm, ok := timestampHashAlgorithms[ts.HashAlgorithm]
+	if !ok {
+		return nil, errorf(ErrUnsupportedHash, "unsupported message imprint hash function %v", ts.HashAlgorithm)
+	}
+	if len(ts.HashedMessage) != ts.HashAlgorithm.Size() {
+		return nil, fmt.Errorf("message imprint is %d bytes, not the %d of %v", len(ts.HashedMessage), ts.HashAlgorithm.Size(), ts.HashAlgorithm)
+	}
+	if len(ts.Policy) == 0 || ts.SerialNumber == nil || ts.Time.IsZero() {
+		return nil, fmt.Errorf("timestamp must have a policy, serial number and time")
+	}
+
+	info, err := asn1.Marshal(tstInfo{
+		Version:        timestampVersion,
+		Policy:         ts.Policy,
+		MessageImprint: messageImprint{HashAlgorithm: pkix.AlgorithmIdentifier{Algorithm: imprintAlgorithm}, HashedMessage: ts.HashedMessage},
+		SerialNumber:   ts.SerialNumber,
+		GenTime:        ts.Time.UTC().Truncate(time.Second),
+		Accuracy: tstAccuracy{
+			Seconds: int(ts.Accuracy / time.Second),
+			Millis:  int(ts.Accuracy % time.Second / time.Millisecond),
+			Micros:  int(ts.Accuracy % time.Millisecond / time.Microsecond),
+		},
+		Nonce: ts.Nonce,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	infoDigest, err := hashMessage(hashFn, info)
+	if err != nil {
+		return nil, err
+	}
+	certHash := sha256.Sum256(tsa.Raw)
+	signedAttrs, err := marshalCMSAttributes([]asn1.ObjectIdentifier{oidContentType, oidMessageDigest, oidSigningCertificateV2},
+		[]interface{}{oidTSTInfo, infoDigest, signingCertificateV2{Certs: []essCertIDv2{{CertHash: certHash[:]}}}})
+	if err != nil {
+		return nil, err
+	}
+	attrsDigest, err := hashMessage(hashFn, signedAttrs)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := signer.Sign(random, attrsDigest, hashFn)
+	if err != nil {
+		return nil, err
+	}
+	if err := tsa.CheckSignature(algorithm.SignatureAlgorithm, signedAttrs, sig); err != nil {
+		return nil, errorf(ErrIncompleteSignature, "timestamp token signature doesn't verify: %s", err)
+	}
+
+	// the signed attributes are signed as a SET, but encoded with an implicit [0] tag in the SignerInfo
+	implicitAttrs := append([]byte{0xa0}, signedAttrs[1:]...)
+	digestAlgorithm := pkix.AlgorithmIdentifier{Algorithm: timestampHashAlgorithms[hashFn]}
+	signedData := cmsSignedData{
+		Version:          timestampCMSVersion,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{digestAlgorithm},
+		EncapContentInfo: cmsEncapContentInfo{EContentType: oidTSTInfo, EContent: info},
+		SignerInfos: []cmsSignerInfo{{
+			Version:            timestampSignerVersion,
+			IssuerAndSerial:    cmsIssuerAndSerial{Issuer: asn1.RawValue{FullBytes: tsa.RawIssuer}, SerialNumber: tsa.SerialNumber},
+			DigestAlgorithm:    digestAlgorithm,
+			SignedAttrs:        asn1.RawValue{FullBytes: implicitAttrs},
+			SignatureAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidRSAEncryption, Parameters: asn1.NullRawValue},
+			Signature:          sig,
+		}},
+	}
+	if ts.IncludeCertificate {
+		signedData.Certificates = []asn1.RawValue{{FullBytes: tsa.Raw}}
+	}
+	content, err := asn1.Marshal(signedData)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(cmsContentInfo{
+		ContentType: oidSignedData,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: content},
+	})
+}
+
+// TimestampResponse returns the DER encoding of an RFC 3161 TimeStampResp granting the request with token, from
+// [SignTimestamp]
+func TimestampResponse(token []byte) ([]byte, error) {
+	return asn1.Marshal(timeStampResp{
+		Status:         pkiStatusInfo{Status: timestampGranted},
+		TimeStampToken: asn1.RawValue{FullBytes: token},
+	})
+}
+
+// returns the DER encoding of a SET OF Attribute with the given types, each with a single value
+func marshalCMSAttributes(types []asn1.ObjectIdentifier, values []interface{}) ([]byte, error) {
+	attrs := make([]cmsAttribute, len(types))
+	for i, oid := range types {
+		encoded, err := asn1.Marshal(values[i])
+		if err != nil {
+			return nil, err
+		}
+		attrs[i] = cmsAttribute{Type: oid, Values: []asn1.RawValue{{FullBytes: encoded}}}
+	}
+	return asn1.MarshalWithParams(attrs, "set")
+}
@@ -0,0 +1,151 @@
+package keysplitting
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"math/big"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Timestamp authority", func() {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	shards, _ := SplitD(key, 2, Addition)
+	remotes := make([]RemoteShard, len(shards))
+	for i, shard := range shards {
+		remotes[i], _ = NewLocalShard(shard)
+	}
+	remoteSigner, _ := NewRemoteSigner(&key.PublicKey, remotes...)
+	policy := asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 99999, 1}
+
+	der, _ := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber:          big.NewInt(7),
+		Subject:               pkix.Name{CommonName: "Split Key TSA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping},
+		BasicConstraintsValid: true,
+	}, &x509.Certificate{Subject: pkix.Name{CommonName: "Split Key TSA"}, SerialNumber: big.NewInt(7)}, &key.PublicKey, key)
+	tsa, _ := x509.ParseCertificate(der)
+
+	hashed := sha256.Sum256([]byte("TIMESTAMPED DOCUMENT"))
+	request, _ := asn1.Marshal(timeStampReq{
+		Version:        1,
+		MessageImprint: messageImprint{HashAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidSHA256}, HashedMessage: hashed[:]},
+		Nonce:          big.NewInt(12345),
+		CertReq:        true,
+	})
+
+	It("Parses timestamp requests", func() {
+		ts, err := ParseTimestampRequest(request)
+		Expect(err).To(BeNil())
+		Expect(ts.HashAlgorithm).To(Equal(crypto.SHA256))
+		Expect(ts.HashedMessage).To(Equal(hashed[:]))
+		Expect(ts.Nonce).To(Equal(big.NewInt(12345)))
+		Expect(ts.IncludeCertificate).To(BeTrue())
+		Expect(ts.Policy).To(BeEmpty())
+
+		md5Request, _ := asn1.Marshal(timeStampReq{
+			Version:        1,
+			MessageImprint: messageImprint{HashAlgorithm: pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 5}}, HashedMessage: hashed[:16]},
+		})
+		_, err = ParseTimestampRequest(md5Request)
+		Expect(errors.Is(err, ErrUnsupportedHash)).To(BeTrue())
+		_, err = ParseTimestampRequest(append(request, 0))
+		Expect(err).ToNot(BeNil())
+	})
+
+	It("Signs timestamp tokens over the TSTInfo", func() {
+		ts, err := ParseTimestampRequest(request)
+		Expect(err).To(BeNil())
+		ts.Policy = policy
+		ts.SerialNumber = big.NewInt(1)
+		ts.Time = time.Unix(1700000000, 0)
+		ts.Accuracy = 1500 * time.Millisecond
+
+		token, err := SignTimestamp(rand.Reader, tsa, remoteSigner, ts)
+		Expect(err).To(BeNil())
+
+		var contentInfo cmsContentInfo
+		_, err = asn1.Unmarshal(token, &contentInfo)
+		Expect(err).To(BeNil())
+		Expect(contentInfo.ContentType).To(Equal(oidSignedData))
+		var signedData cmsSignedData
+		_, err = asn1.Unmarshal(contentInfo.Content.Bytes, &signedData)
+		Expect(err).To(BeNil())
+		Expect(signedData.Version).To(Equal(3))
+		Expect(signedData.EncapContentInfo.EContentType).To(Equal(oidTSTInfo))
+		Expect(signedData.Certificates).To(HaveLen(1))
+		Expect(signedData.Certificates[0].FullBytes).To(Equal(tsa.Raw))
+
+		var info tstInfo
+		_, err = asn1.Unmarshal(signedData.EncapContentInfo.EContent, &info)
+		Expect(err).To(BeNil())
+		Expect(info.Policy).To(Equal(policy))
+		Expect(info.MessageImprint.HashedMessage).To(Equal(hashed[:]))
+		Expect(info.GenTime.Equal(ts.Time)).To(BeTrue())
+		Expect(info.Accuracy).To(Equal(tstAccuracy{Seconds: 1, Millis: 500}))
+		Expect(info.Nonce).To(Equal(big.NewInt(12345)))
+
+		// the signature covers the signed attributes, re-tagged as a SET
+		Expect(signedData.SignerInfos).To(HaveLen(1))
+		signerInfo := signedData.SignerInfos[0]
+		Expect(signerInfo.IssuerAndSerial.SerialNumber).To(Equal(tsa.SerialNumber))
+		signedAttrs := append([]byte{0x31}, signerInfo.SignedAttrs.FullBytes[1:]...)
+		Expect(tsa.CheckSignature(x509.SHA256WithRSA, signedAttrs, signerInfo.Signature)).To(Succeed())
+
+		var attrs []cmsAttribute
+		_, err = asn1.UnmarshalWithParams(signedAttrs, &attrs, "set")
+		Expect(err).To(BeNil())
+		infoDigest := sha256.Sum256(signedData.EncapContentInfo.EContent)
+		var found bool
+		for _, attr := range attrs {
+			if attr.Type.Equal(oidMessageDigest) {
+				var digest []byte
+				_, err = asn1.Unmarshal(attr.Values[0].FullBytes, &digest)
+				Expect(err).To(BeNil())
+				Expect(digest).To(Equal(infoDigest[:]))
+				found = true
+			}
+		}
+		Expect(found).To(BeTrue())
+
+		resp, err := TimestampResponse(token)
+		Expect(err).To(BeNil())
+		var decoded timeStampResp
+		_, err = asn1.Unmarshal(resp, &decoded)
+		Expect(err).To(BeNil())
+		Expect(decoded.Status.Status).To(Equal(0))
+		Expect(decoded.TimeStampToken.FullBytes).To(Equal(token))
+	})
+
+	It("Rejects incomplete timestamps and mismatched keys", func() {
+		ts := &Timestamp{HashAlgorithm: crypto.SHA256, HashedMessage: hashed[:], SerialNumber: big.NewInt(1), Time: time.Now()}
+		_, err := SignTimestamp(rand.Reader, tsa, remoteSigner, ts)
+		Expect(err).ToNot(BeNil())
+
+		ts.Policy = policy
+		ts.HashedMessage = hashed[:20]
+		_, err = SignTimestamp(rand.Reader, tsa, remoteSigner, ts)
+		Expect(err).ToNot(BeNil())
+
+		ts.HashedMessage = hashed[:]
+		ts.Hash = crypto.SHA1
+		_, err = SignTimestamp(rand.Reader, tsa, remoteSigner, ts)
+		Expect(errors.Is(err, ErrUnsupportedHash)).To(BeTrue())
+
+		other, _ := rsa.GenerateKey(rand.Reader, 2048)
+		ts.Hash = 0
+		_, err = SignTimestamp(rand.Reader, tsa, other, ts)
+		Expect(errors.Is(err, ErrKeyMismatch)).To(BeTrue())
+	})
+})
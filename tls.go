@@ -0,0 +1,93 @@
+package keysplitting
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+)
+
+// A RemoteSigner implements [crypto.Signer] by having every shard of a split key sign in turn with [SignSequential], so that
+// libraries which sign through a crypto.Signer, such as crypto/tls, can use a key whose shards are held by remote parties.
+// It works for keys split by either algorithm
+type RemoteSigner struct {
+	pub    *rsa.PublicKey
+	shards []RemoteShard
+}
+
+// NewRemoteSigner returns a RemoteSigner for pub that signs with the given shards, in order
+func NewRemoteSigner(pub *rsa.PublicKey, shards ...RemoteShard) (*RemoteSigner, error) {
+	if len(shards) < 2 {
+		return nil, errorf(ErrTooFewShards, "cannot sign with fewer than 2 shards")
+	}
+	return &RemoteSigner{pub: pub, shards: append([]RemoteShard{}, shards...)}, nil
+}
+
+// Public returns the public key of the split key
+func (s *RemoteSigner) Public() crypto.PublicKey {
+	return s.pub
+}
+
+// Sign implements [crypto.Signer]. opts is a [crypto.Hash] for a PKCS #1 v1.5 signature, or a *[rsa.PSSOptions] for an
+// RSASSA-PSS signature, as crypto/tls passes for TLS 1.2 and 1.3, in which case the shared salt is generated from random
+// with [NewPSSSalt]. A *[PSSOptions] with a salt of the caller's choosing is accepted as well
+func (s *RemoteSigner) Sign(random io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	opts, err := sharedSaltOpts(random, s.pub, opts)
+	if err != nil {
+		return nil, err
+	}
+	return SignSequential(context.Background(), s.pub, opts, digest, s.shards...)
+}
+
+// returns opts, except that a *rsa.PSSOptions is replaced by a *PSSOptions with a salt generated from random
+func sharedSaltOpts(random io.Reader, pub *rsa.PublicKey, opts crypto.SignerOpts) (crypto.SignerOpts, error) {
+	o, ok := opts.(*rsa.PSSOptions)
+	if !ok {
+		return opts, nil
+	}
+	salt, err := NewPSSSalt(random, pub, o.Hash, o)
+	if err != nil {
+		return nil, err
+	}
+	return &PSSOptions{Hash: o.Hash, Salt: salt}, nil
+}
+
+// the signature algorithms a split key can sign with in a TLS handshake, most preferred first
+var tlsSignatureSchemes = []tls.SignatureScheme{
+	tls.PSSWithSHA256,
+	tls.PSSWithSHA384,
+	tls.PSSWithSHA512,
+	tls.PKCS1WithSHA256,
+	tls.PKCS1WithSHA384,
+	tls.PKCS1WithSHA512,
+}
+
+// NewTLSCertificate returns a [tls.Certificate] for the certificate chain, given as DER with the leaf first, whose private key is
+// a [RemoteSigner] that signs with the given shards of the leaf's key, so that a TLS server can terminate connections without
+// any single host holding the whole key. The certificate offers both the PKCS #1 v1.5 and the RSASSA-PSS signature algorithms
+// that TLS 1.2 and 1.3 negotiate
+func NewTLSCertificate(chain [][]byte, shards ...RemoteShard) (*tls.Certificate, error) {
+	if len(chain) == 0 {
+		return nil, errorf(ErrKeyMismatch, "no certificate provided")
+	}
+	leaf, err := x509.ParseCertificate(chain[0])
+	if err != nil {
+		return nil, err
+	}
+	pub, ok := leaf.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return nil, errorf(ErrKeyMismatch, "certificate doesn't have an RSA public key")
+	}
+	signer, err := NewRemoteSigner(pub, shards...)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Certificate{
+		Certificate:                  append([][]byte{}, chain...),
+		PrivateKey:                   signer,
+		SupportedSignatureAlgorithms: append([]tls.SignatureScheme{}, tlsSignatureSchemes...),
+		Leaf:                         leaf,
+	}, nil
+}
@@ -0,0 +1,105 @@
+package keysplitting
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"net"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Split-key TLS", func() {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	hashed := sha256.Sum256([]byte("TEST MESSAGE"))
+
+	// returns a self-signed certificate for pub, signed with priv
+	certificate := func(pub crypto.PublicKey, priv crypto.Signer) []byte {
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(1),
+			Subject:      pkix.Name{CommonName: "split.example"},
+			DNSNames:     []string{"split.example"},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, template, pub, priv)
+		Expect(err).To(BeNil())
+		return der
+	}
+
+	remotes := func(splitBy SplitBy) []RemoteShard {
+		shards, err := SplitD(key, 3, splitBy)
+		Expect(err).To(BeNil())
+		remotes := make([]RemoteShard, len(shards))
+		for i, shard := range shards {
+			remotes[i], err = NewLocalShard(shard)
+			Expect(err).To(BeNil())
+		}
+		return remotes
+	}
+
+	It("Completes TLS 1.2 and 1.3 handshakes with a split server key", func() {
+		der := certificate(&key.PublicKey, key)
+		roots := x509.NewCertPool()
+		leaf, err := x509.ParseCertificate(der)
+		Expect(err).To(BeNil())
+		roots.AddCert(leaf)
+
+		for _, splitBy := range []SplitBy{Addition, Multiplication} {
+			cert, err := NewTLSCertificate([][]byte{der}, remotes(splitBy)...)
+			Expect(err).To(BeNil())
+
+			for _, version := range []uint16{tls.VersionTLS12, tls.VersionTLS13} {
+				serverConn, clientConn := net.Pipe()
+				server := tls.Server(serverConn, &tls.Config{Certificates: []tls.Certificate{*cert}, MaxVersion: version})
+				client := tls.Client(clientConn, &tls.Config{RootCAs: roots, ServerName: "split.example", MinVersion: version, MaxVersion: version})
+
+				serverErr := make(chan error, 1)
+				go func() { serverErr <- server.Handshake() }()
+				Expect(client.Handshake()).To(Succeed())
+				Expect(<-serverErr).To(Succeed())
+				Expect(client.ConnectionState().Version).To(Equal(version))
+				client.Close()
+				server.Close()
+			}
+		}
+	})
+
+	It("Signs PKCS #1 v1.5 and PSS digests as a crypto.Signer", func() {
+		signer, err := NewRemoteSigner(&key.PublicKey, remotes(Addition)...)
+		Expect(err).To(BeNil())
+		var _ crypto.Signer = signer
+		Expect(signer.Public()).To(Equal(&key.PublicKey))
+
+		sig, err := signer.Sign(rand.Reader, hashed[:], crypto.SHA256)
+		Expect(err).To(BeNil())
+		Expect(rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, hashed[:], sig)).To(Succeed())
+
+		pssOpts := &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: crypto.SHA256}
+		sig, err = signer.Sign(rand.Reader, hashed[:], pssOpts)
+		Expect(err).To(BeNil())
+		Expect(rsa.VerifyPSS(&key.PublicKey, crypto.SHA256, hashed[:], sig, pssOpts)).To(Succeed())
+	})
+
+	It("Refuses certificates without an RSA key and too few shards", func() {
+		ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).To(BeNil())
+		_, err = NewTLSCertificate([][]byte{certificate(&ecKey.PublicKey, ecKey)}, remotes(Addition)...)
+		Expect(errors.Is(err, ErrKeyMismatch)).To(BeTrue())
+
+		_, err = NewTLSCertificate([][]byte{certificate(&key.PublicKey, key)}, remotes(Addition)[0])
+		Expect(errors.Is(err, ErrTooFewShards)).To(BeTrue())
+	})
+})
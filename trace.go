@@ -0,0 +1,129 @@
+package keysplitting
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"sync"
+)
+
+// Keys of the attributes recorded on spans
+const (
+	TraceKeySize    = "keysplitting.key_size"    // the size of the modulus in bits
+	TraceShardCount = "keysplitting.shard_count" // the number of shards being split into, combined, or asked to sign
+	TraceSplitBy    = "keysplitting.split_by"    // the split algorithm, i.e. "Addition" or "Multiplication"
+	TraceShardIndex = "keysplitting.shard_index" // the index of the shard that is signing
+	TraceHash       = "keysplitting.hash"        // the hash function used to produce the digest
+)
+
+// A TraceAttribute annotates a span. Value is always a string, an int, or a bool
+type TraceAttribute struct {
+	Key   string
+	Value interface{}
+}
+
+// A Tracer starts spans for splitting, signing, and combining, including the calls made to remote shards by [SignSequential]
+// and [SignBrokered], so that a slow signature can be followed across every party. The oteltrace subpackage implements it
+// with OpenTelemetry, so that this package doesn't depend on it
+type Tracer interface {
+	Start(ctx context.Context, name string, attrs ...TraceAttribute) (context.Context, TraceSpan)
+}
+
+// A TraceSpan is a span started by a [Tracer]
+type TraceSpan interface {
+	SetAttributes(attrs ...TraceAttribute)
+	// End ends the span, recording err as its outcome if it isn't nil
+	End(err error)
+}
+
+// A TraceCarrier holds the key-value pairs that carry a span across process boundaries, such as the metadata of a gRPC call.
+// It has the same methods as OpenTelemetry's propagation.TextMapCarrier
+type TraceCarrier interface {
+	Get(key string) string
+	Set(key, value string)
+	Keys() []string
+}
+
+// A TracePropagator carries spans across process boundaries. If the [Tracer] given to [SetTracer] also implements it,
+// transports such as the gRPC service of the shardservice subpackage carry the caller's span in each request with
+// [InjectSpan], and the server continues it with [ExtractSpan]
+type TracePropagator interface {
+	Inject(ctx context.Context, carrier TraceCarrier)
+	Extract(ctx context.Context, carrier TraceCarrier) context.Context
+}
+
+var tracer struct {
+	sync.RWMutex
+	tracer Tracer
+}
+
+// SetTracer sets the [Tracer] that starts spans for all subsequent operations. A nil tracer, the default, disables tracing
+func SetTracer(t Tracer) {
+	tracer.Lock()
+	defer tracer.Unlock()
+	tracer.tracer = t
+}
+
+// returns the current tracer, or nil if tracing is disabled
+func currentTracer() Tracer {
+	tracer.RLock()
+	defer tracer.RUnlock()
+	return tracer.tracer
+}
+
+// the span returned when tracing is disabled
+type nopSpan struct{}
+
+func (nopSpan) SetAttributes(...TraceAttribute) {}
+
+func (nopSpan) End(error) {}
+
+// StartSpan starts a span under ctx with the [Tracer] given to [SetTracer], for transports and other packages that extend the
+// trace of a signature. If tracing is disabled, it returns ctx and a span that does nothing
+func StartSpan(ctx context.Context, name string, attrs ...TraceAttribute) (context.Context, TraceSpan) {
+	t := currentTracer()
+	if t == nil {
+		return ctx, nopSpan{}
+	}
+	return t.Start(ctx, name, attrs...)
+}
+
+// InjectSpan adds the span in ctx to carrier, if the [Tracer] given to [SetTracer] is also a [TracePropagator]
+func InjectSpan(ctx context.Context, carrier TraceCarrier) {
+	if propagator, ok := currentTracer().(TracePropagator); ok {
+		propagator.Inject(ctx, carrier)
+	}
+}
+
+// ExtractSpan returns ctx with the span carried by carrier, if there is one and the [Tracer] given to [SetTracer] is also a
+// [TracePropagator]
+func ExtractSpan(ctx context.Context, carrier TraceCarrier) context.Context {
+	if propagator, ok := currentTracer().(TracePropagator); ok {
+		return propagator.Extract(ctx, carrier)
+	}
+	return ctx
+}
+
+// returns the attributes describing a key, along with any others that are set
+func keyAttributes(pub *rsa.PublicKey, splitBy SplitBy, hashFn crypto.Hash) []TraceAttribute {
+	var attrs []TraceAttribute
+	if pub != nil && pub.N != nil {
+		attrs = append(attrs, TraceAttribute{Key: TraceKeySize, Value: pub.N.BitLen()})
+	}
+	if splitBy != "" {
+		attrs = append(attrs, TraceAttribute{Key: TraceSplitBy, Value: string(splitBy)})
+	}
+	if hashFn != 0 {
+		attrs = append(attrs, TraceAttribute{Key: TraceHash, Value: hashFn.String()})
+	}
+	return attrs
+}
+
+// returns the attributes describing a shard signing with opts
+func shardAttributes(shard *PrivateKeyShard, opts crypto.SignerOpts) []TraceAttribute {
+	var hashFn crypto.Hash
+	if opts != nil {
+		hashFn = opts.HashFunc()
+	}
+	return append(keyAttributes(shard.PublicKey, shard.SplitBy, hashFn), TraceAttribute{Key: TraceShardIndex, Value: shard.Index})
+}
@@ -0,0 +1,214 @@
+package keysplitting
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"strconv"
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type recordedSpanKey struct{}
+
+// a TraceCarrier over a map
+type mapCarrier map[string]string
+
+func (c mapCarrier) Get(key string) string { return c[key] }
+
+func (c mapCarrier) Set(key, value string) { c[key] = value }
+
+func (c mapCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// a span recorded by a recordingTracer
+type recordedSpan struct {
+	id, parent int
+	name       string
+	attrs      map[string]interface{}
+	err        error
+	ended      bool
+	tracer     *recordingTracer
+}
+
+func (s *recordedSpan) SetAttributes(attrs ...TraceAttribute) {
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	for _, attr := range attrs {
+		s.attrs[attr.Key] = attr.Value
+	}
+}
+
+func (s *recordedSpan) End(err error) {
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	s.err, s.ended = err, true
+}
+
+// a Tracer and TracePropagator that keeps every span, identifying each span's parent by the ID stored in the context
+type recordingTracer struct {
+	mu    sync.Mutex
+	spans []*recordedSpan
+}
+
+func (t *recordingTracer) Start(ctx context.Context, name string, attrs ...TraceAttribute) (context.Context, TraceSpan) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	parent, _ := ctx.Value(recordedSpanKey{}).(int)
+	span := &recordedSpan{id: len(t.spans) + 1, parent: parent, name: name, attrs: map[string]interface{}{}, tracer: t}
+	for _, attr := range attrs {
+		span.attrs[attr.Key] = attr.Value
+	}
+	t.spans = append(t.spans, span)
+	return context.WithValue(ctx, recordedSpanKey{}, span.id), span
+}
+
+func (t *recordingTracer) Inject(ctx context.Context, carrier TraceCarrier) {
+	if id, ok := ctx.Value(recordedSpanKey{}).(int); ok {
+		carrier.Set("test-span", strconv.Itoa(id))
+	}
+}
+
+func (t *recordingTracer) Extract(ctx context.Context, carrier TraceCarrier) context.Context {
+	if id, err := strconv.Atoi(carrier.Get("test-span")); err == nil {
+		return context.WithValue(ctx, recordedSpanKey{}, id)
+	}
+	return ctx
+}
+
+// returns the spans with the given name
+func (t *recordingTracer) named(name string) []*recordedSpan {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var spans []*recordedSpan
+	for _, span := range t.spans {
+		if span.name == name {
+			spans = append(spans, span)
+		}
+	}
+	return spans
+}
+
+var _ = Describe("Tracing", func() {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	hashed := sha256.Sum256([]byte("TEST MESSAGE"))
+	ctx := context.Background()
+	var tracer *recordingTracer
+
+	BeforeEach(func() {
+		tracer = &recordingTracer{}
+		SetTracer(tracer)
+	})
+
+	AfterEach(func() {
+		SetTracer(nil)
+	})
+
+	It("Traces splitting, signing, and combining", func() {
+		shards, err := SplitD(key, 3, Addition)
+		Expect(err).To(BeNil())
+
+		spans := tracer.named("keysplitting.SplitD")
+		Expect(spans).To(HaveLen(1))
+		Expect(spans[0].ended).To(BeTrue())
+		Expect(spans[0].err).To(BeNil())
+		Expect(spans[0].attrs).To(Equal(map[string]interface{}{
+			TraceKeySize:    2048,
+			TraceShardCount: 3,
+			TraceSplitBy:    "Addition",
+		}))
+
+		partials := make([]*PartialSignature, len(shards))
+		for i, shard := range shards {
+			partials[i], err = SignFirst(rand.Reader, shard, crypto.SHA256, hashed[:])
+			Expect(err).To(BeNil())
+		}
+		spans = tracer.named("keysplitting.SignFirst")
+		Expect(spans).To(HaveLen(3))
+		Expect(spans[2].attrs).To(HaveKeyWithValue(TraceShardIndex, 3))
+		Expect(spans[2].attrs).To(HaveKeyWithValue(TraceHash, "SHA-256"))
+
+		_, err = CombinePartialSignatures(&key.PublicKey, partials...)
+		Expect(err).To(BeNil())
+		_, err = CombinePartialSignatures(&key.PublicKey, partials[0])
+		Expect(errors.Is(err, ErrTooFewShards)).To(BeTrue())
+
+		spans = tracer.named("keysplitting.CombinePartialSignatures")
+		Expect(spans).To(HaveLen(2))
+		Expect(spans[0].attrs).To(HaveKeyWithValue(TraceShardCount, 3))
+		Expect(spans[0].err).To(BeNil())
+		Expect(errors.Is(spans[1].err, ErrTooFewShards)).To(BeTrue())
+	})
+
+	It("Traces each shard's part in a remote signature", func() {
+		shards, err := SplitD(key, 2, Multiplication)
+		Expect(err).To(BeNil())
+		remotes := make([]RemoteShard, len(shards))
+		for i, shard := range shards {
+			remotes[i], err = NewLocalShard(shard)
+			Expect(err).To(BeNil())
+		}
+
+		_, err = SignSequential(ctx, &key.PublicKey, crypto.SHA256, hashed[:], remotes...)
+		Expect(err).To(BeNil())
+
+		root := tracer.named("keysplitting.SignSequential")
+		Expect(root).To(HaveLen(1))
+		Expect(root[0].attrs).To(HaveKeyWithValue(TraceShardCount, 2))
+		requests := tracer.named("keysplitting.RemoteShard.PartialSign")
+		Expect(requests).To(HaveLen(2))
+		for i, request := range requests {
+			Expect(request.parent).To(Equal(root[0].id))
+			Expect(request.attrs).To(HaveKeyWithValue(TraceShardIndex, i+1))
+		}
+		first, next := tracer.named("keysplitting.SignFirst"), tracer.named("keysplitting.SignNext")
+		Expect(first).To(HaveLen(1))
+		Expect(next).To(HaveLen(1))
+		Expect(first[0].parent).To(Equal(requests[0].id))
+		Expect(next[0].parent).To(Equal(requests[1].id))
+	})
+
+	It("Traces waiting for a broker", func() {
+		broker, err := NewBroker(&key.PublicKey, 2)
+		Expect(err).To(BeNil())
+		waitCtx, cancel := context.WithCancel(ctx)
+		cancel()
+		_, err = broker.Wait(waitCtx)
+		Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+
+		spans := tracer.named("keysplitting.Broker.Wait")
+		Expect(spans).To(HaveLen(1))
+		Expect(spans[0].attrs).To(HaveKeyWithValue(TraceShardCount, 2))
+		Expect(errors.Is(spans[0].err, context.Canceled)).To(BeTrue())
+	})
+
+	It("Carries spans with the tracer's propagator", func() {
+		spanCtx, span := StartSpan(ctx, "caller")
+		carrier := mapCarrier{}
+		InjectSpan(spanCtx, carrier)
+		span.End(nil)
+		Expect(carrier).To(HaveKey("test-span"))
+
+		StartSpan(ExtractSpan(ctx, carrier), "callee")
+		Expect(tracer.named("callee")[0].parent).To(Equal(tracer.named("caller")[0].id))
+	})
+
+	It("Doesn't trace by default", func() {
+		SetTracer(nil)
+		_, err := SplitD(key, 2, Addition)
+		Expect(err).To(BeNil())
+		Expect(tracer.spans).To(BeEmpty())
+	})
+})
@@ -0,0 +1,42 @@
+package keysplitting
+
+import (
+	"crypto"
+	"crypto/rsa"
+)
+
+// VerifyPartialSignature checks that partialSig was produced by [SignFirst] with the shard that commitments commit to, using the
+// proof that its holder attached with [ShardCommitments.Prove]. opts and hashed are those the shard was asked to sign, as for
+// [SignFirst]. A partial signature bound to a session nonce is checked against the nonce it records.
+// A nil error indicates that the partial signature is valid, up to its sign (see [ShardCommitments])
+func VerifyPartialSignature(commitments *ShardCommitments, opts crypto.SignerOpts, hashed []byte, partialSig *PartialSignature) (err error) {
+	defer func() {
+		var hashFn crypto.Hash
+		if opts != nil {
+			hashFn = opts.HashFunc()
+		}
+		audit(AuditVerify, commitments.PublicKey, partialSig.Signers, partialSig.SplitBy, hashFn, hashed, err)
+	}()
+	return commitments.verify(opts, hashed, partialSig)
+}
+
+// VerifyFinal checks that sig is a complete PKCS #1 v1.5 signature on hashed, as produced by the last call to [SignNext] or by
+// [CombinePartialSignatures] for a key split with splitBy. Note that hashed must be the result of hashing the input message
+// using the given hash function. A nil error indicates that the signature is valid.
+//
+// If sig doesn't verify, VerifyFinal returns [ErrIncompleteSignature], which usually means that not every shard has signed
+func VerifyFinal(pub *rsa.PublicKey, hashFn crypto.Hash, hashed, sig []byte, splitBy SplitBy) (err error) {
+	defer func() { audit(AuditVerify, pub, nil, splitBy, hashFn, hashed, err) }()
+
+	if err := checkSplitBy(splitBy); err != nil {
+		return err
+	}
+	if len(sig) != pub.Size() {
+		return errorf(ErrInvalidPartialSignature, "signature is %d bytes long, but a signature under this key must be %d bytes long", len(sig), pub.Size())
+	}
+
+	if err := rsa.VerifyPKCS1v15(pub, hashFn, hashed, sig); err != nil {
+		return ErrIncompleteSignature
+	}
+	return nil
+}
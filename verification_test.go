@@ -0,0 +1,118 @@
+package keysplitting
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Partial signature verification", func() {
+	hashed := sha512.Sum512([]byte("TEST MESSAGE"))
+	otherHashed := sha512.Sum512([]byte("OTHER MESSAGE"))
+	priv, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	for _, params := range []struct {
+		opts *SplitOptions
+		k    int
+	}{
+		{nil, 2},
+		{nil, 3},
+		{nil, 6},
+		{&SplitOptions{PhiMultiple: true}, 2},
+		{&SplitOptions{PhiMultiple: true}, 5},
+	} {
+		params := params
+
+		When(fmt.Sprintf("Splitting a key %d ways (%+v)", params.k, params.opts), Ordered, func() {
+			var shards []*PrivateKeyShard
+			var commitments *ShardCommitments
+			partials := make([]*PartialSignature, params.k)
+
+			It("Commits to every shard", func() {
+				var err error
+				shards, commitments, err = SplitDWithCommitments(priv, params.k, params.opts)
+				Expect(err).To(BeNil())
+				Expect(commitments.Vi).To(HaveLen(params.k))
+			})
+
+			It("Accepts honest partial signatures", func() {
+				for i, shard := range shards {
+					var err error
+					partials[i], err = SignFirst(rand.Reader, shard, crypto.SHA512, hashed[:])
+					Expect(err).To(BeNil())
+					Expect(commitments.Prove(rand.Reader, shard, crypto.SHA512, hashed[:], partials[i])).To(Succeed())
+					Expect(VerifyPartialSignature(commitments, crypto.SHA512, hashed[:], partials[i])).To(Succeed())
+				}
+			})
+
+			It("Rejects a partial signature relabeled as another shard's", func() {
+				relabeled := partials[1].copy()
+				relabeled.Signers = []int{1}
+				Expect(VerifyPartialSignature(commitments, crypto.SHA512, hashed[:], relabeled)).NotTo(Succeed())
+			})
+
+			It("Rejects a partial signature on a different message", func() {
+				Expect(VerifyPartialSignature(commitments, crypto.SHA512, otherHashed[:], partials[0])).NotTo(Succeed())
+			})
+
+			It("Rejects a corrupted partial signature", func() {
+				corrupted := partials[0].copy()
+				corrupted.Sig[len(corrupted.Sig)-1] ^= 1
+				Expect(VerifyPartialSignature(commitments, crypto.SHA512, hashed[:], corrupted)).NotTo(Succeed())
+			})
+
+			It("Rejects a partial signature without a proof", func() {
+				unproven := partials[0].copy()
+				unproven.Proof = nil
+				err := VerifyPartialSignature(commitments, crypto.SHA512, hashed[:], unproven)
+				Expect(errors.Is(err, ErrInvalidPartialSignature)).To(BeTrue())
+			})
+		})
+	}
+})
+
+var _ = Describe("Final signature verification", func() {
+	hashed := sha512.Sum512([]byte("TEST MESSAGE"))
+	priv, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	for _, splitBy := range []SplitBy{Multiplication, Addition} {
+		splitBy := splitBy
+
+		When(fmt.Sprintf("Splitting a key by %s", splitBy), Ordered, func() {
+			var partial, final *PartialSignature
+
+			BeforeAll(func() {
+				shards, _ := SplitD(priv, 3, splitBy)
+				partial, _ = SignFirst(rand.Reader, shards[0], crypto.SHA512, hashed[:])
+				partial, _ = SignNext(rand.Reader, shards[1], crypto.SHA512, hashed[:], partial)
+				final, _ = SignNext(rand.Reader, shards[2], crypto.SHA512, hashed[:], partial)
+			})
+
+			It("Accepts a complete signature", func() {
+				Expect(VerifyFinal(&priv.PublicKey, crypto.SHA512, hashed[:], final.Sig, splitBy)).To(Succeed())
+			})
+
+			It("Reports a partial signature as incomplete", func() {
+				err := VerifyFinal(&priv.PublicKey, crypto.SHA512, hashed[:], partial.Sig, splitBy)
+				Expect(errors.Is(err, ErrIncompleteSignature)).To(BeTrue())
+			})
+
+			It("Rejects a signature of the wrong length", func() {
+				err := VerifyFinal(&priv.PublicKey, crypto.SHA512, hashed[:], final.Sig[1:], splitBy)
+				Expect(err).NotTo(BeNil())
+				Expect(errors.Is(err, ErrIncompleteSignature)).To(BeFalse())
+				Expect(errors.Is(err, ErrInvalidPartialSignature)).To(BeTrue())
+			})
+		})
+	}
+
+	It("Rejects an unrecognized split algorithm", func() {
+		Expect(VerifyFinal(&priv.PublicKey, crypto.SHA512, hashed[:], make([]byte, priv.Size()), "")).NotTo(Succeed())
+	})
+})
@@ -0,0 +1,105 @@
+package keysplitting
+
+import (
+	"crypto"
+	"crypto/rsa"
+)
+
+// A WeightedShard is the bundle of threshold shares held by one custodian of a key split with [SplitWeighted]. A custodian
+// with weight w holds w shares, and so counts as w approvers towards the threshold
+type WeightedShard struct {
+	Custodian int                  // this custodian's position in 1..len(weights)
+	Weight    int                  // the number of approvers the custodian counts as
+	Shares    []*ThresholdKeyShare // the custodian's shares, one per unit of weight
+}
+
+// A WeightedPartialSignature is the contribution of one custodian to a weighted threshold signature, with a partial signature
+// for each of their shares
+type WeightedPartialSignature struct {
+	Custodian int                          // the custodian that produced the partial signature
+	Partials  []*ThresholdPartialSignature // the partial signature of each of the custodian's shares
+}
+
+// SplitWeighted splits priv among len(weights) custodians such that any set of custodians whose weights add up to at least
+// t can sign, e.g. so that a CFO counts as two approvers. It is [SplitThreshold] into as many shares as the total weight,
+// with each custodian receiving a bundle of as many shares as their weight
+func SplitWeighted(priv *rsa.PrivateKey, t int, weights []int) ([]*WeightedShard, error) {
+	n := 0
+	for i, weight := range weights {
+		if weight < 1 {
+			return nil, errorf(ErrTooFewShards, "custodian %d has weight %d, but every custodian needs a weight of at least 1", i+1, weight)
+		}
+		n += weight
+	}
+
+	shares, err := SplitThreshold(priv, t, n)
+	if err != nil {
+		return nil, err
+	}
+
+	shards := make([]*WeightedShard, len(weights))
+	for i, weight := range weights {
+		shards[i] = &WeightedShard{Custodian: i + 1, Weight: weight, Shares: shares[:weight]}
+		shares = shares[weight:]
+	}
+	return shards, nil
+}
+
+// SignWeighted uses each of the custodian's shares to produce a partial PKCS #1 v1.5 signature on a hashed message, as with
+// [SignThreshold]. Note that hashed must be the result of hashing the input message using the given hash function
+func SignWeighted(shard *WeightedShard, hashFn crypto.Hash, hashed []byte) (*WeightedPartialSignature, error) {
+	if len(shard.Shares) != shard.Weight {
+		return nil, errorf(ErrInvalidShard, "custodian %d has weight %d but %d shares", shard.Custodian, shard.Weight, len(shard.Shares))
+	}
+
+	partialSig := &WeightedPartialSignature{Custodian: shard.Custodian}
+	for _, share := range shard.Shares {
+		partial, err := SignThreshold(share, hashFn, hashed)
+		if err != nil {
+			return nil, err
+		}
+		partialSig.Partials = append(partialSig.Partials, partial)
+	}
+	return partialSig, nil
+}
+
+// CombineWeighted combines the partial signatures of custodians whose total weight is at least t into a complete PKCS #1 v1.5
+// signature, as with [CombineThreshold]. n is the total weight of all custodians. It fails with [ErrTooFewShards] if the
+// custodians' total weight is too low, and [ErrInvalidPartialSignature] if a custodian contributes more than once
+func CombineWeighted(pub *rsa.PublicKey, t, n int, hashFn crypto.Hash, hashed []byte, partials ...*WeightedPartialSignature) ([]byte, error) {
+	custodians := map[int]bool{}
+	var shares []*ThresholdPartialSignature
+	for _, partial := range partials {
+		if custodians[partial.Custodian] {
+			return nil, errorf(ErrInvalidPartialSignature, "custodian %d contributed more than one partial signature", partial.Custodian)
+		}
+		custodians[partial.Custodian] = true
+		shares = append(shares, partial.Partials...)
+	}
+	if len(shares) < t {
+		return nil, errorf(ErrTooFewShards, "custodians with a total weight of %d cannot meet a threshold of %d", len(shares), t)
+	}
+	return CombineThreshold(pub, t, n, hashFn, hashed, shares...)
+}
+
+// CustodianWeights returns the weight of each custodian by index, for use as [QuorumPolicy].Weights
+func CustodianWeights(shards []*WeightedShard) map[int]int {
+	weights := make(map[int]int, len(shards))
+	for _, shard := range shards {
+		weights[shard.Custodian] = shard.Weight
+	}
+	return weights
+}
+
+// CombineWeightedWithPolicy is like [CombineWeighted], but first checks that the custodians that signed satisfy policy,
+// whose shard indices are the custodians' indices
+func CombineWeightedWithPolicy(pub *rsa.PublicKey, t, n int, hashFn crypto.Hash, hashed []byte, policy *QuorumPolicy, partials ...*WeightedPartialSignature) ([]byte, error) {
+	custodians := make([]int, len(partials))
+	for i, partial := range partials {
+		custodians[i] = partial.Custodian
+	}
+	if err := policy.CheckSigners(custodians...); err != nil {
+		return nil, err
+	}
+	return CombineWeighted(pub, t, n, hashFn, hashed, partials...)
+}
@@ -0,0 +1,90 @@
+package keysplitting
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Weighted shares", func() {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	hashed := sha256.Sum256([]byte("TEST MESSAGE"))
+
+	// the CFO counts as two approvers, and any 3 approvers can sign
+	shards, _ := SplitWeighted(key, 3, []int{2, 1, 1, 1})
+	partials := func() []*WeightedPartialSignature {
+		partials := make([]*WeightedPartialSignature, len(shards))
+		for i, shard := range shards {
+			partials[i], _ = SignWeighted(shard, crypto.SHA256, hashed[:])
+		}
+		return partials
+	}()
+
+	It("Bundles a share for each unit of weight", func() {
+		Expect(shards).To(HaveLen(4))
+		Expect(shards[0].Shares).To(HaveLen(2))
+		Expect(partials[0].Partials).To(HaveLen(2))
+		for i, shard := range shards {
+			Expect(shard.Custodian).To(Equal(i + 1))
+			Expect(shard.Shares).To(HaveLen(shard.Weight))
+			Expect(shard.Shares[0].Parties).To(Equal(5))
+		}
+		Expect(CustodianWeights(shards)).To(Equal(map[int]int{1: 2, 2: 1, 3: 1, 4: 1}))
+	})
+
+	It("Signs once the custodians' weights reach the threshold", func() {
+		for _, signers := range [][]*WeightedPartialSignature{
+			{partials[0], partials[1]},
+			{partials[3], partials[0]},
+			{partials[1], partials[2], partials[3]},
+		} {
+			sig, err := CombineWeighted(&key.PublicKey, 3, 5, crypto.SHA256, hashed[:], signers...)
+			Expect(err).To(BeNil())
+			Expect(rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, hashed[:], sig)).To(Succeed())
+		}
+	})
+
+	It("Refuses custodians whose weights fall short", func() {
+		_, err := CombineWeighted(&key.PublicKey, 3, 5, crypto.SHA256, hashed[:], partials[1], partials[2])
+		Expect(errors.Is(err, ErrTooFewShards)).To(BeTrue())
+
+		_, err = CombineWeighted(&key.PublicKey, 3, 5, crypto.SHA256, hashed[:], partials[1], partials[1], partials[2])
+		Expect(errors.Is(err, ErrInvalidPartialSignature)).To(BeTrue())
+	})
+
+	It("Weighs custodians in quorum policies", func() {
+		policy := &QuorumPolicy{
+			Labels:  map[int][]string{1: {"finance"}, 2: {"finance"}, 3: {"engineering"}},
+			Weights: CustodianWeights(shards),
+			Rule:    QuorumRule{AllOf: []QuorumRule{{Label: "finance", Min: 2}, {Weight: 3}}},
+		}
+
+		sig, err := CombineWeightedWithPolicy(&key.PublicKey, 3, 5, crypto.SHA256, hashed[:], policy, partials[0], partials[2])
+		Expect(err).To(BeNil())
+		Expect(rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, hashed[:], sig)).To(Succeed())
+
+		_, err = CombineWeightedWithPolicy(&key.PublicKey, 3, 5, crypto.SHA256, hashed[:], policy, partials[1], partials[2], partials[3])
+		Expect(errors.Is(err, ErrPolicyNotSatisfied)).To(BeTrue())
+
+		policy.Weights[2] = 0
+		Expect(policy.Validate()).NotTo(Succeed())
+	})
+
+	It("Rejects custodians without weight", func() {
+		_, err := SplitWeighted(key, 2, []int{1, 0, 1})
+		Expect(errors.Is(err, ErrTooFewShards)).To(BeTrue())
+
+		_, err = SplitWeighted(key, 4, []int{2, 1})
+		Expect(errors.Is(err, ErrTooFewShards)).To(BeTrue())
+
+		tampered := *shards[0]
+		tampered.Shares = tampered.Shares[:1]
+		_, err = SignWeighted(&tampered, crypto.SHA256, hashed[:])
+		Expect(errors.Is(err, ErrInvalidShard)).To(BeTrue())
+	})
+})
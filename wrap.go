@@ -0,0 +1,143 @@
+package keysplitting
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/asn1"
+	"encoding/pem"
+	"fmt"
+	"io"
+)
+
+const wrappedPEMType = "WRAPPED RSA SPLIT PRIVATE KEY"
+
+// used exclusively as a placeholder for encoding-decoding
+type wrappedShard struct {
+	RecipientKeyID []byte // SHA-256 hash of the recipient's PKCS #1 public key
+	EncryptedKey   []byte // the AES-256 key, encrypted to the recipient with RSA-OAEP
+	Nonce          []byte
+	Ciphertext     []byte
+}
+
+// WrapShardFor encrypts the shard to the holder of recipientPub, so that a dealer can send it over an untrusted channel such
+// as e-mail or a shared drive. The shard is encrypted with a fresh AES-256-GCM key, which is itself encrypted with RSA-OAEP
+// using SHA-256. Only the holder of the corresponding private key can recover the shard, with [UnwrapShard]
+func (pks *PrivateKeyShard) WrapShardFor(recipientPub *rsa.PublicKey) (string, error) {
+	plaintext, err := pks.marshalDER()
+	if err != nil {
+		return "", err
+	}
+	defer zeroizeBytes(plaintext)
+
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return "", &RandomnessError{Err: err}
+	}
+	defer zeroizeBytes(key)
+
+	recipientKeyID := publicKeyID(recipientPub)
+	encryptedKey, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, recipientPub, key, []byte(wrappedPEMType))
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt key to recipient: %s", err)
+	}
+
+	aead, err := newWrappingAEAD(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", &RandomnessError{Err: err}
+	}
+
+	b, err := asn1.Marshal(wrappedShard{
+		RecipientKeyID: recipientKeyID[:],
+		EncryptedKey:   encryptedKey,
+		Nonce:          nonce,
+		Ciphertext:     aead.Seal(nil, nonce, plaintext, wrappingAAD(recipientKeyID[:])),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to DER-encode: %s", err)
+	}
+
+	keyPEM := new(bytes.Buffer)
+	err = pem.Encode(keyPEM, &pem.Block{
+		Type:  wrappedPEMType,
+		Bytes: b,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to PEM-encode: %s", err)
+	}
+
+	return keyPEM.String(), nil
+}
+
+// UnwrapShard decrypts a shard encoded by [PrivateKeyShard.WrapShardFor]. The recipient is usually an *rsa.PrivateKey, but may
+// be any [crypto.Decrypter] with an RSA public key that supports OAEP, such as a key held in an HSM. It fails with [ErrKeyMismatch]
+// if the shard was wrapped for a different recipient, and [ErrInvalidShard] if it cannot be decrypted
+func UnwrapShard(encodedPks string, recipient crypto.Decrypter) (*PrivateKeyShard, error) {
+	recipientPub, ok := recipient.Public().(*rsa.PublicKey)
+	if !ok {
+		return nil, errorf(ErrKeyMismatch, "recipient key is a %T rather than an RSA key", recipient.Public())
+	}
+
+	block, rest := pem.Decode([]byte(encodedPks))
+	if block == nil || block.Type != wrappedPEMType || len(rest) > 0 {
+		return nil, errorf(ErrInvalidShard, "failed to decode PEM block containing wrapped private key shard")
+	}
+
+	var wrapped wrappedShard
+	rest, err := asn1.Unmarshal(block.Bytes, &wrapped)
+	if err != nil {
+		return nil, errorf(ErrInvalidShard, "failed to unmarshal DER-encoded wrapped private key shard: %s", err)
+	}
+	if len(rest) > 0 {
+		return nil, errorf(ErrInvalidShard, "failed to unmarshal DER-encoded wrapped private key shard")
+	}
+
+	recipientKeyID := publicKeyID(recipientPub)
+	if !bytes.Equal(wrapped.RecipientKeyID, recipientKeyID[:]) {
+		return nil, errorf(ErrKeyMismatch, "private key shard was wrapped for a different recipient")
+	}
+
+	key, err := recipient.Decrypt(rand.Reader, wrapped.EncryptedKey, &rsa.OAEPOptions{Hash: crypto.SHA256, Label: []byte(wrappedPEMType)})
+	if err != nil {
+		return nil, errorf(ErrInvalidShard, "failed to decrypt wrapped private key shard")
+	}
+	defer zeroizeBytes(key)
+
+	aead, err := newWrappingAEAD(key)
+	if err != nil {
+		return nil, errorf(ErrInvalidShard, "failed to decrypt wrapped private key shard")
+	}
+	if len(wrapped.Nonce) != aead.NonceSize() {
+		return nil, errorf(ErrInvalidShard, "invalid nonce length")
+	}
+
+	plaintext, err := aead.Open(nil, wrapped.Nonce, wrapped.Ciphertext, wrappingAAD(recipientKeyID[:]))
+	if err != nil {
+		return nil, errorf(ErrInvalidShard, "failed to decrypt wrapped private key shard")
+	}
+	defer zeroizeBytes(plaintext)
+
+	return unmarshalDER(plaintext)
+}
+
+// returns an AES-256-GCM cipher with the given key
+func newWrappingAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// binds the ciphertext to both the format and the recipient
+func wrappingAAD(recipientKeyID []byte) []byte {
+	return append([]byte(wrappedPEMType), recipientKeyID...)
+}
@@ -0,0 +1,53 @@
+package keysplitting
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/pem"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Shard wrapping", func() {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	shards, _ := SplitD(key, 2, Addition)
+	recipient, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	It("Round-trips to the intended recipient", func() {
+		wrapped, err := shards[0].WrapShardFor(&recipient.PublicKey)
+		Expect(err).To(BeNil())
+
+		unwrapped, err := UnwrapShard(wrapped, recipient)
+		Expect(err).To(BeNil())
+		Expect(unwrapped.Equal(shards[0])).To(BeTrue())
+	})
+
+	It("Rejects other recipients", func() {
+		other, _ := rsa.GenerateKey(rand.Reader, 2048)
+		wrapped, err := shards[0].WrapShardFor(&recipient.PublicKey)
+		Expect(err).To(BeNil())
+
+		_, err = UnwrapShard(wrapped, other)
+		Expect(errors.Is(err, ErrKeyMismatch)).To(BeTrue())
+	})
+
+	It("Detects tampering", func() {
+		wrapped, err := shards[0].WrapShardFor(&recipient.PublicKey)
+		Expect(err).To(BeNil())
+
+		block, _ := pem.Decode([]byte(wrapped))
+		block.Bytes[len(block.Bytes)-1] ^= 1
+		_, err = UnwrapShard(string(pem.EncodeToMemory(block)), recipient)
+		Expect(errors.Is(err, ErrInvalidShard)).To(BeTrue())
+	})
+
+	It("Doesn't reveal the shard", func() {
+		wrapped, err := shards[0].WrapShardFor(&recipient.PublicKey)
+		Expect(err).To(BeNil())
+
+		_, err = DecodePEM(wrapped)
+		Expect(errors.Is(err, ErrInvalidShard)).To(BeTrue())
+	})
+})